@@ -260,6 +260,21 @@ func (f FileURL) GetAccessControl(ctx context.Context) (BlobFSAccessControl, err
 	return BlobFSAccessControl{resp.XMsOwner(), resp.XMsGroup(), resp.XMsACL(), resp.XMsPermissions()}, nil
 }
 
+// GetAccessControlWithUPN is GetAccessControl, but with the owner, the group and the named entries of the ACL given by
+// user principal name, rather than by object id, where they are users that have one
+func (f FileURL) GetAccessControlWithUPN(ctx context.Context) (BlobFSAccessControl, error) {
+	upn := true
+	resp, err := f.fileClient.GetProperties(ctx, f.fileSystemName, f.path, PathGetPropertiesActionGetAccessControl, &upn,
+		nil, nil, nil,
+		nil, nil, nil, nil, nil)
+
+	if err != nil {
+		return BlobFSAccessControl{}, err
+	}
+
+	return BlobFSAccessControl{resp.XMsOwner(), resp.XMsGroup(), resp.XMsACL(), resp.XMsPermissions()}, nil
+}
+
 func (f FileURL) SetAccessControl(ctx context.Context, permissions BlobFSAccessControl) (*PathUpdateResponse, error) {
 	// TODO: the go http client has a problem with PATCH and content-length header
 	//       we should investigate and report the issue
@@ -31,7 +31,10 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +43,7 @@ import (
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-azcopy/v10/ste"
@@ -59,6 +63,9 @@ const base10Mega = 1000 * 1000
 
 const pipeLocation = "~pipe~"
 
+// stdioArg, given as the source or the destination of a copy, is stdin or stdout
+const stdioArg = "-"
+
 const PreservePermissionsFlag = "preserve-permissions"
 
 // represents the raw copy command input from the user
@@ -67,6 +74,8 @@ type rawCopyCmdArgs struct {
 	src    string
 	dst    string
 	fromTo string
+	// whether stdin or stdout was given as -, rather than with --from-to PipeBlob or BlobPipe. Only then is progress reported
+	stdio bool
 	// blobUrlForRedirection string
 
 	// new include/exclude only apply to file names
@@ -77,63 +86,164 @@ type rawCopyCmdArgs struct {
 	excludePath           string
 	includeRegex          string
 	excludeRegex          string
+	includeExt            string
+	excludeExt            string
+	includeMetadata       string
+	excludeMetadata       string
+	includeContentType    string
+	excludeContentType    string
 	includeFileAttributes string
 	excludeFileAttributes string
 	includeBefore         string
 	includeAfter          string
+	ignoreCasePattern     bool
+	minSize               string
+	maxSize               string
+	minDepth              uint
+	maxDepth              uint
 	legacyInclude         string // used only for warnings
 	legacyExclude         string // used only for warnings
 	listOfVersionIDs      string
+	listVersions          bool
+	maxVersionsPerBlob    uint
+	changeToken           string
+	sinceChangeToken      string
+
+	// allows filtering an Azure Files source by metadata, at the cost of getting the properties of each file
+	getPropertiesForMetadata bool
+
+	// an S3-compatible service other than AWS, whose URLs are then read as S3 URLs
+	s3Endpoint       string
+	s3ForcePathStyle bool
+	s3SkipSSLVerify  bool
 
 	// filters from flags
 	listOfFilesToCopy string
+	listOfUrls        string
+	keepFromUrl       string
+	pathMap           string
 	recursive         bool
 	followSymlinks    bool
-	autoDecompress    bool
+	preserveSymlinks  bool
+	// restrictSymlinksToRoot stops followed symlinks from reaching files outside of the source
+	restrictSymlinksToRoot bool
+	autoDecompress         bool
+	// what happens to names ending with a dot, when Azure Files is the source or destination
+	trailingDot string
 	// forceWrite flag is used to define the User behavior
 	// to overwrite the existing blobs or not.
 	forceWrite      string
 	forceIfReadOnly bool
 
 	// options from flags
-	blockSizeMB              float64
-	metadata                 string
-	contentType              string
-	contentEncoding          string
-	contentDisposition       string
-	contentLanguage          string
-	cacheControl             string
-	noGuessMimeType          bool
-	preserveLastModifiedTime bool
-	putMd5                   bool
-	md5ValidationOption      string
-	CheckLength              bool
-	deleteSnapshotsOption    string
-	dryrun                   bool
+	blockSizeMB                  float64
+	metadata                     string
+	contentType                  string
+	contentEncoding              string
+	contentDisposition           string
+	contentLanguage              string
+	cacheControl                 string
+	noGuessMimeType              bool
+	noGuessMimeTypeFromExtension bool
+	contentTypeMapFile           string // a file giving the content type of each extension
+	defaultContentType           string // for the extensions that aren't in contentTypeMapFile
+	preserveLastModifiedTime     bool
+	putMd5                       bool
+	md5ValidationOption          string
+	CheckLength                  bool
+	deleteSnapshotsOption        string
+	dryrun                       bool
 
 	blobTags string
 	// defines the type of the blob at the destination in case of upload / account to account copy
-	blobType      string
-	blockBlobTier string
-	pageBlobTier  string
-	output        string // TODO: Is this unused now? replaced with param at root level?
+	blobType string
+	// pattern=BlobType pairs, choosing the type of the blobs uploaded from files whose names match the pattern
+	blobTypeByPattern string
+	blockBlobTier     string
+	pageBlobTier      string
+	output            string // TODO: Is this unused now? replaced with param at root level?
 	// list of blobTypes to exclude while enumerating the transfer
 	excludeBlobType string
+	// list of container names (or patterns) to skip when the source is a whole account
+	excludeContainer string
+	// the most files that the job may schedule. 0 means no limit
+	maxFileCount uint64
+	// skip the files that already exist at the destination with the same size as at the source
+	skipExistingWithSameSize bool
+	// fail a file's transfer when it has made no progress for this long. 0 means never
+	perTransferTimeout time.Duration
+	// copy each source blob from a snapshot of it, taken just before, which is deleted afterwards
+	snapshotSourceFirst bool
+	// check the CRC64 that Azure Blob returns for each uploaded block or page against one computed of the data sent
+	verifyCrc64 bool
+	// set the metadata, tags and HTTP headers of existing destination blobs from their sources, without copying data
+	metadataOnly bool
+	// only write a destination blob if it has this ETag, or if it exists at all for *
+	ifMatch string
+	// only write a destination blob if it doesn't have this ETag, or if it doesn't exist at all for *
+	ifNoneMatch string
+	// give each destination blob an immutability policy that lasts until this time, which may be relative to now
+	immutabilityPolicyUntil string
+	// put each destination blob under a legal hold
+	legalHold bool
+	// the Azure clouds that the source and the destination are in. Empty infers them from the URLs
+	sourceCloud      string
+	destinationCloud string
+	// where to record the listing of each directory: "log", or the path of a file. Empty for nowhere
+	enumerationTelemetry string
+	// stream a download into a single archive file, in this format, instead of a file per source file
+	destinationArchive string
+	// upload the entries of the source archive file, which is in this format, as if they were files of their own
+	sourceArchive string
+	// copy every file directly under the destination, without the folders it's in at the source
+	flatten bool
+	// what happens when flattening gives two files the same destination path
+	flattenCollisions string
+	// keep empty folders in Blob, by writing a marker blob into each, and turn the markers back into folders when downloading
+	preserveEmptyDirectories bool
+	// the name of those markers
+	emptyDirectoryMarker string
+	// the order in which the transfers are scheduled, and for PatternPriority, the names of the files that go first
+	transferOrder   string
+	priorityPattern string
+	// where to write the manifest of the transferred and failed files
+	manifestOutput string
+	// cancel the job once more than this many transfers have failed. 0 means no limit
+	maxErrors uint32
 	// Opt-in flag to persist SMB ACLs to Azure Files.
 	preserveSMBPermissions bool
 	preservePermissions    bool // Separate flag so that we don't get funkiness with two "flags" targeting the same boolean
 	preserveOwner          bool // works in conjunction with preserveSmbPermissions
 	// Default true; false indicates that the destination is the target directory, rather than something we'd put a directory under (e.g. a container)
 	asSubdir bool
+	// whether the name of the source's top directory is left out of destination paths, whether or not the source ends
+	// with /*. Only used when stripTopDirSet, since otherwise that is decided by whether it does
+	stripTopDir bool
+	// set when --strip-top-dir is given, whether true or false
+	stripTopDirSet bool
 	// Opt-in flag to persist additional SMB properties to Azure Files. Named ...info instead of ...properties
 	// because the latter was similar enough to preserveSMBPermissions to induce user error
 	preserveSMBInfo bool
 	// Opt-in flag to persist additional POSIX properties
 	preservePOSIXProperties bool
+	// Opt-in flag to keep the user-namespace extended attributes of local files in blob metadata
+	preserveXattrs bool
 	// Opt-in flag to preserve the blob index tags during service to service transfer.
 	s2sPreserveBlobTags bool
+	// a tag filter expression, used to find the source blobs by their index tags
+	includeBlobTags string
+	// semicolon-separated owners, by object id or user principal name, of the ADLS Gen 2 paths to include and exclude
+	includeOwner string
+	excludeOwner string
+	// how many owners are looked up at once, for includeOwner and excludeOwner
+	ownerLookupConcurrency int
+	// set by the move command, rather than by a flag: delete each source file once it has been copied successfully
+	deleteSourceOnSuccess bool
 	// Flag to enable Window's special privileges
 	backupMode bool
+	// whether downloads may follow the redirects of their sources, such as CDN endpoints
+	followSourceRedirects bool
+	downloadRangesInPlace bool
 	// whether user wants to preserve full properties during service to service copy, the default value is true.
 	// For S3 and Azure File non-single file source, as list operation doesn't return full properties of objects/files,
 	// to preserve full properties AzCopy needs to send one additional request per object/file.
@@ -157,6 +267,12 @@ type rawCopyCmdArgs struct {
 	// whether to include blobs that have metadata 'hdi_isfolder = true'
 	includeDirectoryStubs bool
 
+	// whether to leave out the source root folder itself, while still copying the folders beneath it
+	excludeRootFolder bool
+
+	// whether to expand tokens such as {yyyy} in the destination to parts of the time the job started
+	destinationTimeTokens bool
+
 	// whether to disable automatic decoding of illegal chars on Windows
 	disableAutoDecoding bool
 
@@ -256,17 +372,41 @@ func (raw rawCopyCmdArgs) stripTrailingWildcardOnRemoteSource(location common.Lo
 }
 
 func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
-	cooked := CookedCopyCmdArgs{
-		jobID: azcopyCurrentJobID,
-	}
-
 	// set up the front end scanning logger
-	azcopyScanningLogger = common.NewJobLogger(azcopyCurrentJobID, azcopyLogVerbosity, azcopyLogPathFolder, "-scanning")
+	azcopyScanningLogger = common.NewJobLogger(azcopyCurrentJobID, azcopyComponentLogLevels.For(common.ELogComponent.Enumeration()), azcopyLogPathFolder, "-scanning")
 	azcopyScanningLogger.OpenLog()
 	glcm.RegisterCloseFunc(func() {
 		azcopyScanningLogger.CloseLog()
 	})
 
+	// the endpoint decides which URLs are S3 URLs, so it is set before the location of the source is inferred
+	if err := setS3CompatibleEndpoint(raw.s3Endpoint, raw.s3ForcePathStyle, raw.s3SkipSSLVerify); err != nil {
+		return CookedCopyCmdArgs{jobID: azcopyCurrentJobID}, err
+	}
+
+	cooked, err := raw.cookArgs()
+
+	// if redirection is triggered, avoid printing any output, as AzCopy always has. The - form is newer, and reports
+	// its progress, on stderr when stdout is the destination
+	if cooked.isRedirection() && !raw.stdio {
+		glcm.SetOutputFormat(common.EOutputFormat.None())
+	}
+	if err != nil {
+		return cooked, err
+	}
+
+	globalBlobFSMd5ValidationOption = cooked.md5ValidationOption // workaround, to avoid having to pass this all the way through the chain of methods in enumeration, just for one weird and (presumably) temporary workaround
+	return cooked, nil
+}
+
+// cookArgs is the part of cook that only fills in the cooked args, without setting up logging or changing anything
+// else for the whole process, so that ListTransfers can use it too. Its only side effect is the messages that it
+// prints. Any S3-compatible endpoint must have been set before it is called, since it decides which URLs are S3's
+func (raw rawCopyCmdArgs) cookArgs() (CookedCopyCmdArgs, error) {
+	cooked := CookedCopyCmdArgs{
+		jobID: azcopyCurrentJobID,
+	}
+
 	/* We support DFS by using blob end-point of the account. We replace dfs by blob in src and dst */
 	if src, dst := InferArgumentLocation(raw.src), InferArgumentLocation(raw.dst); src == common.ELocation.BlobFS() || dst == common.ELocation.BlobFS() {
 		srcDfs := src == common.ELocation.BlobFS() && dst != common.ELocation.Local()
@@ -285,7 +425,31 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		cooked.isHNStoHNS = srcDfs && dstDfs
 	}
 
-	fromTo, err := ValidateFromTo(raw.src, raw.dst, raw.fromTo) // TODO: src/dst
+	var fromTo common.FromTo
+	var err error
+
+	// the entries of the filters can be kept in files, which are read before anything looks at the filters
+	if raw.include, err = expandFilterFiles(raw.include, "include-pattern"); err != nil {
+		return cooked, err
+	}
+	if raw.exclude, err = expandFilterFiles(raw.exclude, "exclude-pattern"); err != nil {
+		return cooked, err
+	}
+	if raw.includePath, err = expandFilterFiles(raw.includePath, "include-path"); err != nil {
+		return cooked, err
+	}
+	if raw.excludePath, err = expandFilterFiles(raw.excludePath, "exclude-path"); err != nil {
+		return cooked, err
+	}
+
+	if raw.listOfUrls != "" {
+		if raw.src != "" {
+			return cooked, errors.New("cannot combine a source with list-of-urls, since each URL in the list is a source of its own")
+		}
+		fromTo, err = fromToForListOfUrls(raw.dst, raw.fromTo)
+	} else {
+		fromTo, err = ValidateFromTo(raw.src, raw.dst, raw.fromTo) // TODO: src/dst
+	}
 	if err != nil {
 		return cooked, err
 	}
@@ -297,8 +461,18 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		tempDest = common.Dev_Null // map all capitalization of "NUL"/"nul" to one because (on Windows) they all mean the same thing
 	}
 
+	// expanded once, here, so that every file of the job goes under the same path, even if the job spans midnight
+	if raw.destinationTimeTokens {
+		if tempDest, err = expandDestinationTimeTokens(tempDest, fromTo.To(), time.Now()); err != nil {
+			return cooked, err
+		}
+	}
+
 	// Check if source has a trailing wildcard on a URL
-	if fromTo.From().IsRemote() {
+	if raw.listOfUrls != "" {
+		// with no source root, there is no top directory to add at the destination either
+		cooked.StripTopDir = true
+	} else if fromTo.From().IsRemote() {
 		tempSrc, cooked.StripTopDir, err = raw.stripTrailingWildcardOnRemoteSource(fromTo.From())
 
 		if err != nil {
@@ -326,7 +500,29 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 
 	cooked.FromTo = fromTo
 	cooked.Recursive = raw.recursive
-	cooked.FollowSymlinks = raw.followSymlinks
+	if err = cooked.SymlinkHandling.Determine(raw.followSymlinks, raw.preserveSymlinks); err != nil {
+		return cooked, err
+	}
+	if err = validateSymlinkHandlingMode(cooked.SymlinkHandling, cooked.FromTo); err != nil {
+		return cooked, err
+	}
+	cooked.restrictSymlinksToRoot = raw.restrictSymlinksToRoot
+	if err = cooked.trailingDot.Parse(raw.trailingDot); err != nil {
+		return cooked, err
+	}
+	if cooked.trailingDot == common.ETrailingDotOption.Disable() &&
+		cooked.FromTo.From() != common.ELocation.File() && cooked.FromTo.To() != common.ELocation.File() {
+		return cooked, errors.New("trailing-dot=Disable only applies when the source or the destination is Azure Files")
+	}
+	if cooked.sourceCloud, err = resolveAzureCloud(raw.sourceCloud, "source-cloud", cooked.FromTo.From(), cooked.Source.Value); err != nil {
+		return cooked, err
+	}
+	if cooked.destinationCloud, err = resolveAzureCloud(raw.destinationCloud, "destination-cloud", cooked.FromTo.To(), cooked.Destination.Value); err != nil {
+		return cooked, err
+	}
+	if err = validateCrossCloudCopy(cooked.FromTo, cooked.sourceCloud, cooked.destinationCloud); err != nil {
+		return cooked, err
+	}
 	cooked.ForceIfReadOnly = raw.forceIfReadOnly
 	if err = validateForceIfReadOnly(cooked.ForceIfReadOnly, cooked.FromTo); err != nil {
 		return cooked, err
@@ -337,6 +533,31 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	if err != nil {
 		return cooked, err
 	}
+	if err = validateOverwriteOption(cooked.ForceWrite, cooked.FromTo); err != nil {
+		return cooked, err
+	}
+	if raw.skipExistingWithSameSize && (fromTo.To() == common.ELocation.Pipe() || fromTo.To() == common.ELocation.None()) {
+		return cooked, fmt.Errorf("skip-existing-with-same-size cannot be used when the destination is %s, since it has no existing files", fromTo.To().String())
+	}
+	cooked.skipExistingWithSameSize = raw.skipExistingWithSameSize
+	if raw.perTransferTimeout < 0 {
+		return cooked, errors.New("per-transfer-timeout cannot be negative")
+	}
+	cooked.perTransferTimeout = raw.perTransferTimeout
+	if raw.manifestOutput != "" {
+		if raw.dryrun {
+			return cooked, errors.New("manifest-output cannot be used with dry-run, since nothing is transferred")
+		}
+		if cooked.manifestOutput, err = filepath.Abs(raw.manifestOutput); err != nil {
+			return cooked, fmt.Errorf("invalid manifest-output %s: %w", raw.manifestOutput, err)
+		}
+		if fi, err := os.Stat(filepath.Dir(cooked.manifestOutput)); err != nil || !fi.IsDir() {
+			return cooked, fmt.Errorf("the folder for manifest-output, %s, does not exist", filepath.Dir(cooked.manifestOutput))
+		}
+	}
+	if cooked.enumerationTelemetry, err = enumerationTelemetryFromFlag(raw.enumerationTelemetry); err != nil {
+		return cooked, err
+	}
 	allowAutoDecompress := fromTo == common.EFromTo.BlobLocal() || fromTo == common.EFromTo.FileLocal()
 	if raw.autoDecompress && !allowAutoDecompress {
 		return cooked, errors.New("automatic decompression is only supported for downloads from Blob and Azure Files") // as at Sept 2019, our ADLS Gen 2 Swagger does not include content-encoding for directory (path) listings so we can't support it there
@@ -361,9 +582,18 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		return cooked, err
 	}
 
+	cooked.blobTypeByPattern, err = parseBlobTypeByPattern(raw.blobTypeByPattern)
+	if err != nil {
+		return cooked, err
+	}
+	if len(cooked.blobTypeByPattern) > 0 && cooked.FromTo != common.EFromTo.LocalBlob() {
+		return cooked, fmt.Errorf("blob-type-by-pattern is only supported when uploading to Blob storage")
+	}
+
 	// If the given blobType is AppendBlob, block-size-mb should not be greater than
 	// 4MB.
-	if cookedSize, _ := blockSizeInBytes(raw.blockSizeMB); cooked.blobType == common.EBlobType.AppendBlob() && cookedSize > common.MaxAppendBlobBlockSize {
+	if cookedSize, _ := blockSizeInBytes(raw.blockSizeMB); cookedSize > common.MaxAppendBlobBlockSize &&
+		(cooked.blobType == common.EBlobType.AppendBlob() || anyBlobTypeIs(cooked.blobTypeByPattern, common.EBlobType.AppendBlob())) {
 		return cooked, fmt.Errorf("block size cannot be greater than 4MB for AppendBlob blob type")
 	}
 
@@ -401,6 +631,21 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	// warn on exclude unsupported wildcards here. Include have to be later, to cover list-of-files
 	raw.warnIfHasWildcard(excludeWarningOncer, "exclude-path", raw.excludePath)
 
+	// With a whole account, or the containers matching a wildcard, as the source, there is no single container for the
+	// include-path to be listed in. So the paths are filtered within each of the containers instead.
+	if (raw.includePath != "" || raw.listOfFilesToCopy != "") && fromTo.From().IsRemote() && raw.listOfUrls == "" {
+		if srcLevel, err := DetermineLocationLevel(cooked.Source.Value, fromTo.From(), true); err == nil && srcLevel == ELocationLevel.Service() {
+			if raw.listOfFilesToCopy != "" {
+				return cooked, errors.New("list-of-files cannot be used when copying from a whole account, or from the containers matching a wildcard. Use include-path to choose paths within each container")
+			}
+			for _, v := range raw.parsePatterns(raw.includePath) {
+				if v = strings.Trim(v, common.AZCOPY_PATH_SEPARATOR_STRING); v != "" {
+					cooked.includePathPerContainer = append(cooked.includePathPerContainer, v)
+				}
+			}
+		}
+	}
+
 	// unbuffered so this reads as we need it to rather than all at once in bulk
 	listChan := make(chan string)
 	var f *os.File
@@ -461,11 +706,20 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 					headerLineNum++
 				}
 
+				// Windows line endings are already taken care of by the scanner, which drops the \r of a \r\n.
+				// Lines that are blank, or that start with #, are comments
+				if trimmed := strings.TrimSpace(v); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+					continue
+				}
+
 				addToChannel(v, "list-of-files")
 			}
 		}
 
 		// This occurs much earlier than the other include or exclude filters. It would be preferable to move them closer later on in the refactor.
+		if cooked.includePathPerContainer != nil {
+			return // a filter, rather than the list, handles these
+		}
 		includePathList := raw.parsePatterns(raw.includePath)
 
 		for _, v := range includePathList {
@@ -473,20 +727,45 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		}
 	}()
 
+	if raw.pathMap != "" {
+		if cooked.pathRewriter, err = loadPathMap(raw.pathMap); err != nil {
+			return cooked, err
+		}
+	}
+
 	// A combined implementation reduces the amount of code duplication present.
 	// However, it _does_ increase the amount of code-intertwining present.
 	if raw.listOfFilesToCopy != "" && raw.includePath != "" {
 		return cooked, errors.New("cannot combine list of files and include path")
 	}
 
-	if raw.listOfFilesToCopy != "" || raw.includePath != "" {
+	if raw.listOfFilesToCopy != "" || (raw.includePath != "" && cooked.includePathPerContainer == nil) {
 		cooked.ListOfFilesChannel = listChan
 	}
 
+	if raw.listOfUrls != "" {
+		if raw.listOfFilesToCopy != "" || raw.includePath != "" || raw.listOfVersionIDs != "" {
+			return cooked, errors.New("cannot combine list-of-urls with list-of-files, include-path or list-of-versions")
+		}
+		if cooked.ListOfUrlsChannel, err = readListOfUrls(raw.listOfUrls); err != nil {
+			return cooked, err
+		}
+		cooked.urlListFailures = &urlListFailures{}
+	}
+	// commands that share cook() with copy, such as remove, don't have the flag, and so leave it empty. That means BlobName
+	if raw.keepFromUrl != "" {
+		if err = cooked.keepFromUrl.Parse(raw.keepFromUrl); err != nil {
+			return cooked, err
+		}
+	}
+	if cooked.keepFromUrl != common.EKeepFromUrl.BlobName() && raw.listOfUrls == "" {
+		return cooked, errors.New("keep-from-url can only be used with list-of-urls")
+	}
+
 	if raw.includeBefore != "" {
 		// must set chooseEarliest = false, so that if there's an ambiguous local date, the latest will be returned
 		// (since that's safest for includeBefore.  Better to choose the later time and do more work, than the earlier one and fail to pick up a changed file
-		parsedIncludeBefore, err := IncludeBeforeDateFilter{}.ParseISO8601(raw.includeBefore, false)
+		parsedIncludeBefore, err := IncludeBeforeDateFilter{}.ParseTime(raw.includeBefore, false, time.Now())
 		if err != nil {
 			return cooked, err
 		}
@@ -496,13 +775,39 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	if raw.includeAfter != "" {
 		// must set chooseEarliest = true, so that if there's an ambiguous local date, the earliest will be returned
 		// (since that's safest for includeAfter.  Better to choose the earlier time and do more work, than the later one and fail to pick up a changed file
-		parsedIncludeAfter, err := IncludeAfterDateFilter{}.ParseISO8601(raw.includeAfter, true)
+		parsedIncludeAfter, err := IncludeAfterDateFilter{}.ParseTime(raw.includeAfter, true, time.Now())
 		if err != nil {
 			return cooked, err
 		}
 		cooked.IncludeAfter = &parsedIncludeAfter
 	}
 
+	if raw.minSize != "" {
+		minSizeBytes, err := SizeFilter{}.ParseSize(raw.minSize, "min-size")
+		if err != nil {
+			return cooked, err
+		}
+		cooked.MinSizeBytes = &minSizeBytes
+	}
+
+	if raw.maxSize != "" {
+		maxSizeBytes, err := SizeFilter{}.ParseSize(raw.maxSize, "max-size")
+		if err != nil {
+			return cooked, err
+		}
+		cooked.MaxSizeBytes = &maxSizeBytes
+	}
+
+	if cooked.MinSizeBytes != nil && cooked.MaxSizeBytes != nil && *cooked.MinSizeBytes > *cooked.MaxSizeBytes {
+		return cooked, errors.New("min-size cannot be greater than max-size")
+	}
+
+	if raw.maxDepth != 0 && raw.minDepth > raw.maxDepth {
+		return cooked, errors.New("min-depth cannot be greater than max-depth")
+	}
+	cooked.MinDepth = raw.minDepth
+	cooked.MaxDepth = raw.maxDepth
+
 	versionsChan := make(chan string)
 	var filePtr *os.File
 	// Get file path from user which would contain list of all versionIDs
@@ -542,6 +847,90 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		cooked.ListOfVersionIDs = versionsChan
 	}
 
+	if raw.listVersions {
+		if cooked.FromTo.From() != common.ELocation.Blob() {
+			return cooked, fmt.Errorf("list-versions is unsupported for this source (%s). It can only be used when the source is Blob", cooked.FromTo.From().String())
+		}
+		if raw.listOfVersionIDs != "" || raw.listOfFilesToCopy != "" || raw.includePath != "" || raw.listOfUrls != "" || raw.includeBlobTags != "" {
+			return cooked, errors.New("list-versions cannot be combined with list-of-versions, list-of-files, include-path, list-of-urls or include-blob-tags")
+		}
+		if sourceQuery, err := url.ParseQuery(cooked.Source.ExtraQuery); err == nil && (sourceQuery.Get("versionid") != "" || sourceQuery.Get("versionId") != "") {
+			return cooked, errors.New("list-versions cannot be used with a source URL that names a version. Remove the versionid from the URL to copy every version, or keep it and drop list-versions to copy just that one")
+		}
+		cooked.listVersions = true
+	}
+	if raw.maxVersionsPerBlob > 0 {
+		// each URL of list-of-urls is copied as it is, so no versions are listed for the keeper to choose from
+		if raw.listOfUrls != "" {
+			return cooked, errors.New("max-versions-per-blob cannot be used with list-of-urls, since no versions are listed for the blobs of the list")
+		}
+		if !raw.listVersions {
+			return cooked, errors.New("max-versions-per-blob can only be used with list-versions")
+		}
+		cooked.maxVersionsPerBlob = raw.maxVersionsPerBlob
+	}
+
+	if raw.snapshotSourceFirst {
+		if cooked.FromTo != common.EFromTo.BlobBlob() {
+			return cooked, fmt.Errorf("snapshot-source-first is unsupported for this transfer (%s). It can only be used when copying from Blob to Blob", cooked.FromTo.String())
+		}
+		cooked.snapshotSourceFirst = true
+	}
+
+	if raw.verifyCrc64 {
+		if cooked.FromTo.To() != common.ELocation.Blob() || !cooked.FromTo.From().IsLocal() {
+			return cooked, fmt.Errorf("verify-crc64 is unsupported for this transfer (%s). It can only be used when uploading to Blob", cooked.FromTo.String())
+		}
+		cooked.verifyCrc64 = true
+	}
+
+	if raw.metadataOnly {
+		if cooked.FromTo.To() != common.ELocation.Blob() || !cooked.FromTo.From().IsRemote() {
+			return cooked, fmt.Errorf("metadata-only is unsupported for this transfer (%s). It can only be used when copying to Blob from another remote location", cooked.FromTo.String())
+		}
+		if !raw.s2sPreserveProperties {
+			return cooked, errors.New("metadata-only sets the properties that are preserved from the source, so it cannot be used with s2s-preserve-properties=false")
+		}
+		if cooked.ForceWrite != common.EOverwriteOption.True() {
+			return cooked, fmt.Errorf("metadata-only always updates the existing destination blobs, so it cannot be used with overwrite=%s", cooked.ForceWrite.String())
+		}
+		cooked.metadataOnly = true
+	}
+
+	if raw.ifMatch != "" || raw.ifNoneMatch != "" {
+		if cooked.FromTo.To() != common.ELocation.Blob() {
+			return cooked, fmt.Errorf("if-match and if-none-match are unsupported for this transfer (%s). They can only be used when the destination is Blob", cooked.FromTo.String())
+		}
+		if cooked.metadataOnly {
+			return cooked, errors.New("if-match and if-none-match cannot be used with metadata-only")
+		}
+		if len(raw.ifMatch) > ste.CustomHeaderMaxBytes || len(raw.ifNoneMatch) > ste.CustomHeaderMaxBytes {
+			return cooked, fmt.Errorf("the ETags given to if-match and if-none-match can be at most %d characters long", ste.CustomHeaderMaxBytes)
+		}
+		cooked.ifMatchETag = raw.ifMatch
+		cooked.ifNoneMatchETag = raw.ifNoneMatch
+	}
+
+	if raw.immutabilityPolicyUntil != "" || raw.legalHold {
+		if cooked.FromTo.To() != common.ELocation.Blob() {
+			return cooked, fmt.Errorf("immutability-policy-until and legal-hold are unsupported for this transfer (%s). They can only be used when the destination is Blob", cooked.FromTo.String())
+		}
+		if cooked.metadataOnly {
+			return cooked, errors.New("immutability-policy-until and legal-hold cannot be used with metadata-only, since it doesn't write the blobs")
+		}
+		if raw.immutabilityPolicyUntil != "" {
+			// a local time that a change of daylight saving makes ambiguous is taken as the later one, so that the retention is never shorter than asked for
+			now := time.Now()
+			if cooked.immutabilityPolicyUntil, err = parseFilterTime(raw.immutabilityPolicyUntil, false, now); err != nil {
+				return cooked, fmt.Errorf("invalid immutability-policy-until: %w", err)
+			}
+			if !cooked.immutabilityPolicyUntil.After(now) {
+				return cooked, fmt.Errorf("immutability-policy-until must be in the future, but %s is not", formatAsUTC(cooked.immutabilityPolicyUntil))
+			}
+		}
+		cooked.legalHold = raw.legalHold
+	}
+
 	if cooked.FromTo.To() == common.ELocation.None() && strings.EqualFold(raw.metadata, common.MetadataAndBlobTagsClearFlag) { // in case of Blob, BlobFS and Files
 		glcm.Info("*** WARNING *** Metadata will be cleared because of input --metadata=clear ")
 	}
@@ -555,6 +944,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	cooked.contentDisposition = raw.contentDisposition
 	cooked.cacheControl = raw.cacheControl
 	cooked.noGuessMimeType = raw.noGuessMimeType
+	cooked.noGuessMimeTypeFromExtension = raw.noGuessMimeTypeFromExtension
 	cooked.preserveLastModifiedTime = raw.preserveLastModifiedTime
 	cooked.disableAutoDecoding = raw.disableAutoDecoding
 
@@ -572,11 +962,17 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	cooked.blobTags = blobTags
 
 	// Check if user has provided `s2s-preserve-blob-tags` flag. If yes, we have to ensure that
-	// 1. Both source and destination must be blob storages.
+	// 1. Both source and destination must be blob storages. A destination other than blob has nowhere to put the tags, so the flag is ignored for it.
 	// 2. `blob-tags` is not present as they create conflicting scenario of whether to preserve blob tags from the source or set user defined tags on the destination
 	if raw.s2sPreserveBlobTags {
-		if cooked.FromTo.From() != common.ELocation.Blob() || cooked.FromTo.To() != common.ELocation.Blob() {
-			return cooked, errors.New("either source or destination is not a blob storage. blob index tags is a property of blobs only therefore both source and destination must be blob storage")
+		if cooked.FromTo.To() != common.ELocation.Blob() {
+			msg := fmt.Sprintf("s2s-preserve-blob-tags is ignored, as blob index tags cannot be set on the destination (%s). Blob index tags are a property of blobs only.", cooked.FromTo.To().String())
+			glcm.Info(msg)
+			if jobsAdmin.JobsAdmin != nil {
+				jobsAdmin.JobsAdmin.LogToJobLog(msg, pipeline.LogWarning)
+			}
+		} else if cooked.FromTo.From() != common.ELocation.Blob() {
+			return cooked, errors.New("the source is not a blob storage. blob index tags is a property of blobs only therefore both source and destination must be blob storage")
 		} else if raw.blobTags != "" {
 			return cooked, errors.New("both s2s-preserve-blob-tags and blob-tags flags cannot be used in conjunction")
 		} else {
@@ -584,6 +980,43 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		}
 	}
 
+	// The tag filter is pushed down to the service's Find Blobs by Tags operation, which only exists for Blob
+	if raw.includeBlobTags != "" {
+		if cooked.FromTo.From() != common.ELocation.Blob() {
+			return cooked, fmt.Errorf("include-blob-tags is unsupported for this source (%s). It can only be used when the source is Blob", cooked.FromTo.From().String())
+		}
+		if raw.listOfFilesToCopy != "" || raw.includePath != "" || raw.listOfVersionIDs != "" {
+			return cooked, errors.New("include-blob-tags cannot be combined with include-path, list-of-files or list-of-versions")
+		}
+		cooked.IncludeBlobTags = raw.includeBlobTags
+	}
+
+	// Only ADLS Gen 2 paths have owners. Those that are read through the blob endpoint are looked up through the dfs one
+	if raw.includeOwner != "" || raw.excludeOwner != "" {
+		if from := cooked.FromTo.From(); from != common.ELocation.Blob() && from != common.ELocation.BlobFS() {
+			return cooked, fmt.Errorf("include-owner and exclude-owner are unsupported for this source (%s). They can only be used when the source is ADLS Gen 2", from.String())
+		}
+		if raw.ownerLookupConcurrency < 1 {
+			return cooked, errors.New("owner-lookup-concurrency must be at least 1")
+		}
+		cooked.includeOwner = parseOwners(raw.includeOwner)
+		cooked.excludeOwner = parseOwners(raw.excludeOwner)
+		cooked.ownerLookupConcurrency = raw.ownerLookupConcurrency
+	}
+
+	// A move deletes the sources itself once the job is done, so the source must be one we know how to delete from
+	if raw.deleteSourceOnSuccess {
+		switch cooked.FromTo.From() {
+		case common.ELocation.Local(), common.ELocation.Blob(), common.ELocation.File(), common.ELocation.BlobFS():
+		default:
+			return cooked, fmt.Errorf("move is unsupported for this source (%s). The source must be local, Blob, Azure Files or ADLS Gen 2", cooked.FromTo.From().String())
+		}
+		if raw.listOfVersionIDs != "" || raw.listVersions {
+			return cooked, errors.New("move cannot be combined with list-of-versions or list-versions, since deleting the source would delete the whole blob rather than the versions copied")
+		}
+		cooked.deleteSourceOnSuccess = true
+	}
+
 	// Setting CPK-N
 	cpkOptions := common.CpkOptions{}
 	// Setting CPK-N
@@ -617,6 +1050,11 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		if strings.Contains(destUrl.Host, "dfs.core.windows.net") {
 			return cooked, errors.New("client provided keys (CPK) based encryption is only supported with blob endpoints (blob.core.windows.net)")
 		}
+
+		// the encryption scope applies where blobs are written, so any other destination would quietly ignore it
+		if cpkOptions.CpkScopeInfo != "" && !cooked.FromTo.IsDownload() && cooked.FromTo.To() != common.ELocation.Blob() {
+			return cooked, fmt.Errorf("cpk-by-name (the encryption scope) is only supported when the destination is Blob storage, not %s", cooked.FromTo.To())
+		}
 	}
 
 	cooked.CpkOptions = cpkOptions
@@ -630,25 +1068,49 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	if cooked.contentType != "" {
 		cooked.noGuessMimeType = true // As specified in the help text, noGuessMimeType is inferred here.
 	}
+	if raw.contentTypeMapFile != "" {
+		if !cooked.FromTo.IsUpload() {
+			return cooked, fmt.Errorf("content-type-map is only supported for uploads, not for the scenario (%s)", cooked.FromTo.String())
+		}
+		if cooked.noGuessMimeType || cooked.noGuessMimeTypeFromExtension {
+			return cooked, errors.New("content-type-map cannot be combined with content-type, no-guess-mime-type or no-guess-mime-type-from-extension")
+		}
+		contentTypeMap, err := loadContentTypeMap(raw.contentTypeMapFile)
+		if err != nil {
+			return cooked, err
+		}
+		// the map is kept in the job plan, so that a resumed job still uses it
+		serialized, _ := json.Marshal(contentTypeMap)
+		if len(serialized) > ste.ContentTypeMapMaxBytes {
+			return cooked, fmt.Errorf("content-type-map %s is too large: it takes %d bytes, and at most %d are supported",
+				raw.contentTypeMapFile, len(serialized), ste.ContentTypeMapMaxBytes)
+		}
+		cooked.contentTypeMap = string(serialized)
+	}
+	if raw.defaultContentType != "" {
+		if raw.contentTypeMapFile == "" {
+			return cooked, errors.New("default-content-type can only be used with content-type-map. To give every file the same content type, use content-type instead")
+		}
+		if err = validateContentType(raw.defaultContentType); err != nil {
+			return cooked, fmt.Errorf("invalid default-content-type: %w", err)
+		}
+		if len(raw.defaultContentType) > ste.CustomHeaderMaxBytes {
+			return cooked, fmt.Errorf("default-content-type is too long: at most %d characters are supported", ste.CustomHeaderMaxBytes)
+		}
+		cooked.defaultContentType = raw.defaultContentType
+	}
 
 	cooked.putMd5 = raw.putMd5
 	err = cooked.md5ValidationOption.Parse(raw.md5ValidationOption)
 	if err != nil {
 		return cooked, err
 	}
-	globalBlobFSMd5ValidationOption = cooked.md5ValidationOption // workaround, to avoid having to pass this all the way through the chain of methods in enumeration, just for one weird and (presumably) temporary workaround
-
 	cooked.CheckLength = raw.CheckLength
 	// length of devnull will be 0, thus this will always fail unless downloading an empty file
 	if cooked.Destination.Value == common.Dev_Null {
 		cooked.CheckLength = false
 	}
 
-	// if redirection is triggered, avoid printing any output
-	if cooked.isRedirection() {
-		glcm.SetOutputFormat(common.EOutputFormat.None())
-	}
-
 	cooked.preserveSMBInfo = areBothLocationsSMBAware(cooked.FromTo)
 	// If user has explicitly specified not to copy SMB Information, set cooked.preserveSMBInfo to false
 	if !raw.preserveSMBInfo {
@@ -660,6 +1122,11 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		return cooked, fmt.Errorf("in order to use --preserve-posix-properties, both the source and destination must be POSIX-aware (Linux->Blob, Blob->Linux, Blob->Blob)")
 	}
 
+	cooked.preserveXattrs = raw.preserveXattrs
+	if cooked.preserveXattrs && !areBothLocationsPOSIXAware(cooked.FromTo) {
+		return cooked, fmt.Errorf("in order to use --preserve-xattrs, both the source and destination must be POSIX-aware (Linux->Blob, Blob->Linux, Blob->Blob)")
+	}
+
 	if err = validatePreserveSMBPropertyOption(cooked.preserveSMBInfo, cooked.FromTo, &cooked.ForceWrite, "preserve-smb-info"); err != nil {
 		return cooked, err
 	}
@@ -684,8 +1151,9 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	cooked.asSubdir = raw.asSubdir
 
 	cooked.IncludeDirectoryStubs = raw.includeDirectoryStubs || (cooked.isHNStoHNS && cooked.preservePermissions.IsTruthy())
+	cooked.excludeRootFolder = raw.excludeRootFolder
 
-	if err = crossValidateSymlinksAndPermissions(cooked.FollowSymlinks, cooked.preservePermissions.IsTruthy()); err != nil {
+	if err = crossValidateSymlinksAndPermissions(cooked.SymlinkHandling == common.ESymlinkHandlingType.Follow(), cooked.preservePermissions.IsTruthy()); err != nil {
 		return cooked, err
 	}
 
@@ -694,6 +1162,107 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		return cooked, err
 	}
 
+	cooked.followSourceRedirects = raw.followSourceRedirects
+	if cooked.followSourceRedirects && !cooked.FromTo.IsDownload() {
+		return cooked, errors.New("follow-source-redirects is only supported for downloads")
+	}
+
+	cooked.downloadRangesInPlace = raw.downloadRangesInPlace
+	if cooked.downloadRangesInPlace && !cooked.FromTo.IsDownload() {
+		return cooked, errors.New("download-ranges-in-place is only supported for downloads")
+	}
+
+	if err = cooked.destinationArchive.Parse(raw.destinationArchive); err != nil {
+		return cooked, err
+	}
+	if cooked.destinationArchive != common.EArchiveFormat.None() {
+		if err = validateDestinationArchive(cooked); err != nil {
+			return cooked, err
+		}
+		// the entries of an archive don't carry SMB properties, so don't try to set them
+		cooked.preserveSMBInfo = false
+	}
+
+	if err = cooked.sourceArchive.Parse(raw.sourceArchive); err != nil {
+		return cooked, err
+	}
+	if cooked.sourceArchive != common.EArchiveFormat.None() {
+		if raw.listOfFilesToCopy != "" || raw.includePath != "" {
+			return cooked, errors.New("source-archive cannot be used with list-of-files or include-path. Use include-pattern to choose entries instead")
+		}
+		if err = validateSourceArchive(cooked); err != nil {
+			return cooked, err
+		}
+		// the entries of an archive have no SMB properties to preserve
+		cooked.preserveSMBInfo = false
+		// the entries go straight under the destination, rather than under a folder named after the archive
+		cooked.StripTopDir = true
+	}
+
+	cooked.maxErrors = raw.maxErrors
+	if cooked.maxErrors > 0 && (cooked.FromTo.From() == common.ELocation.Pipe() || cooked.FromTo.To() == common.ELocation.Pipe()) {
+		return cooked, errors.New("max-errors cannot be used when piping, since there is only one file")
+	}
+	cooked.flatten = raw.flatten
+	if err = cooked.flattenCollisions.Parse(raw.flattenCollisions); err != nil {
+		return cooked, err
+	}
+	if raw.flattenCollisions != "" && !cooked.flatten {
+		return cooked, errors.New("flatten-collisions only applies with --flatten")
+	}
+	if cooked.flatten && (cooked.FromTo.From() == common.ELocation.Pipe() || cooked.FromTo.To() == common.ELocation.Pipe()) {
+		return cooked, errors.New("flatten cannot be used when piping, since there is only one file")
+	}
+
+	cooked.preserveEmptyDirectories = raw.preserveEmptyDirectories
+	cooked.emptyDirectoryMarker = raw.emptyDirectoryMarker
+	if cooked.emptyDirectoryMarker != "" && !cooked.preserveEmptyDirectories {
+		return cooked, errors.New("empty-directory-marker only applies with --preserve-empty-directories")
+	}
+	if cooked.emptyDirectoryMarker == "" {
+		cooked.emptyDirectoryMarker = defaultEmptyDirectoryMarker
+	}
+	if cooked.preserveEmptyDirectories {
+		if cooked.FromTo != common.EFromTo.LocalBlob() && cooked.FromTo != common.EFromTo.BlobLocal() {
+			return cooked, errors.New("preserve-empty-directories only applies to uploads to Blob and downloads from it, since the other locations have folders of their own")
+		}
+		if cooked.flatten || cooked.sourceArchive != common.EArchiveFormat.None() || cooked.destinationArchive != common.EArchiveFormat.None() {
+			return cooked, errors.New("preserve-empty-directories cannot be used with flatten, source-archive or destination-archive, since they don't copy folders as they are")
+		}
+		if err = validateEmptyDirectoryMarker(cooked.emptyDirectoryMarker); err != nil {
+			return cooked, err
+		}
+		// the markers are folder stubs, which listings otherwise leave out
+		cooked.IncludeDirectoryStubs = true
+	}
+
+	if err = cooked.transferOrder.Parse(raw.transferOrder); err != nil {
+		return cooked, err
+	}
+	cooked.priorityPattern = raw.priorityPattern
+	if raw.priorityPattern != "" {
+		// braces are expanded here, as for include-pattern, so that the engine only has the usual wildcards to match
+		patterns, err := expandBracePatterns(raw.parsePatterns(raw.priorityPattern), "priority-pattern")
+		if err != nil {
+			return cooked, err
+		}
+		cooked.priorityPattern = strings.Join(patterns, ";")
+	}
+	if err = validateTransferOrder(cooked.transferOrder, cooked.priorityPattern); err != nil {
+		return cooked, err
+	}
+
+	if raw.stripTopDirSet {
+		stripTopDir := raw.stripTopDir
+		if raw.listOfUrls != "" || cooked.sourceArchive != common.EArchiveFormat.None() || cooked.destinationArchive != common.EArchiveFormat.None() || cooked.flatten {
+			return cooked, errors.New("strip-top-dir cannot be used with list-of-urls, source-archive, destination-archive or flatten, since they don't put the files under a top directory at the destination")
+		}
+		if !stripTopDir && !raw.asSubdir {
+			return cooked, errors.New("strip-top-dir=false cannot be combined with as-subdir=false, since one keeps the name of the source's top directory and the other leaves it out")
+		}
+		cooked.stripTopDirOverride = &stripTopDir
+	}
+
 	// Make sure the given input is the one of the enums given by the blob SDK
 	err = cooked.permanentDeleteOption.Parse(raw.permanentDeleteOption)
 	if err != nil {
@@ -730,6 +1299,9 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		if cooked.s2sSourceChangeValidation {
 			return cooked, fmt.Errorf("s2s-detect-source-changed is not supported while uploading")
 		}
+		if len(cooked.metadata) > 0 {
+			glcm.Info("metadata is ignored, as it cannot be set on files uploaded to ADLS Gen 2 through its dfs endpoint. The content headers are still applied.")
+		}
 	case common.EFromTo.LocalBlob():
 		if cooked.preserveLastModifiedTime {
 			return cooked, fmt.Errorf("preserve-last-modified-time is not supported while uploading to Blob Storage")
@@ -772,7 +1344,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	case common.EFromTo.BlobLocal(),
 		common.EFromTo.FileLocal(),
 		common.EFromTo.BlobFSLocal():
-		if cooked.FollowSymlinks {
+		if cooked.SymlinkHandling == common.ESymlinkHandlingType.Follow() {
 			return cooked, fmt.Errorf("follow-symlinks flag is not supported while downloading")
 		}
 		if cooked.blockBlobTier != common.EBlockBlobTier.None() ||
@@ -782,6 +1354,9 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		if cooked.noGuessMimeType {
 			return cooked, fmt.Errorf("no-guess-mime-type is not supported while downloading")
 		}
+		if cooked.noGuessMimeTypeFromExtension {
+			return cooked, fmt.Errorf("no-guess-mime-type-from-extension is not supported while downloading")
+		}
 		if len(cooked.contentType) > 0 || len(cooked.contentEncoding) > 0 || len(cooked.contentLanguage) > 0 || len(cooked.contentDisposition) > 0 || len(cooked.cacheControl) > 0 || len(cooked.metadata) > 0 {
 			return cooked, fmt.Errorf("content-type, content-encoding, content-language, content-disposition, cache-control, or metadata is not supported while downloading")
 		}
@@ -806,7 +1381,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		if cooked.preserveLastModifiedTime {
 			return cooked, fmt.Errorf("preserve-last-modified-time is not supported while copying from service to service")
 		}
-		if cooked.FollowSymlinks {
+		if cooked.SymlinkHandling == common.ESymlinkHandlingType.Follow() {
 			return cooked, fmt.Errorf("follow-symlinks flag is not supported while copying from service to service")
 		}
 		// blob type is not supported if destination is not blob
@@ -822,6 +1397,9 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		if cooked.noGuessMimeType {
 			return cooked, fmt.Errorf("no-guess-mime-type is not supported while copying from service to service")
 		}
+		if cooked.noGuessMimeTypeFromExtension {
+			return cooked, fmt.Errorf("no-guess-mime-type-from-extension is not supported while copying from service to service")
+		}
 		if len(cooked.contentType) > 0 || len(cooked.contentEncoding) > 0 || len(cooked.contentLanguage) > 0 || len(cooked.contentDisposition) > 0 || len(cooked.cacheControl) > 0 || len(cooked.metadata) > 0 {
 			return cooked, fmt.Errorf("content-type, content-encoding, content-language, content-disposition, cache-control, or metadata is not supported while copying from service to service")
 		}
@@ -854,27 +1432,121 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		}
 	}
 
+	// Excluded containers are dropped while the account is listed, so only blob accounts can use them.
+	// InitResourceTraverser checks that the source really is an account, once it knows what the source is.
+	if raw.excludeContainer != "" {
+		if cooked.FromTo.From() != common.ELocation.Blob() {
+			return cooked, fmt.Errorf("exclude-container is unsupported for this source (%s). It can only be used when the source is a Blob account", cooked.FromTo.From().String())
+		}
+		for _, name := range strings.Split(raw.excludeContainer, ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				cooked.excludeContainer = append(cooked.excludeContainer, name)
+			}
+		}
+	}
+
+	cooked.maxFileCount = raw.maxFileCount
+
 	err = cooked.s2sInvalidMetadataHandleOption.Parse(raw.s2sInvalidMetadataHandleOption)
 	if err != nil {
 		return cooked, err
 	}
 
 	// parse the filter patterns
-	cooked.IncludePatterns = raw.parsePatterns(raw.include)
-	cooked.ExcludePatterns = raw.parsePatterns(raw.exclude)
+	if cooked.IncludePatterns, err = expandBracePatterns(raw.parsePatterns(raw.include), "include-pattern"); err != nil {
+		return cooked, err
+	}
+	if cooked.ExcludePatterns, err = expandBracePatterns(raw.parsePatterns(raw.exclude), "exclude-pattern"); err != nil {
+		return cooked, err
+	}
+	cooked.IgnoreCasePattern = raw.ignoreCasePattern
 	cooked.ExcludePathPatterns = raw.parsePatterns(raw.excludePath)
 
 	if (raw.includeFileAttributes != "" || raw.excludeFileAttributes != "") && fromTo.From() != common.ELocation.Local() {
 		return cooked, errors.New("cannot check file attributes on remote objects")
 	}
-	cooked.IncludeFileAttributes = raw.parsePatterns(raw.includeFileAttributes)
-	cooked.ExcludeFileAttributes = raw.parsePatterns(raw.excludeFileAttributes)
+	if cooked.IncludeFileAttributes, err = parseFileAttributes(raw.includeFileAttributes, "include-attributes"); err != nil {
+		return cooked, err
+	}
+	if cooked.ExcludeFileAttributes, err = parseFileAttributes(raw.excludeFileAttributes, "exclude-attributes"); err != nil {
+		return cooked, err
+	}
 
 	cooked.includeRegex = raw.parsePatterns(raw.includeRegex)
 	cooked.excludeRegex = raw.parsePatterns(raw.excludeRegex)
+	if err = validateRegexPatterns(cooked.includeRegex, "include-regex"); err != nil {
+		return cooked, err
+	}
+	if err = validateRegexPatterns(cooked.excludeRegex, "exclude-regex"); err != nil {
+		return cooked, err
+	}
+	if cooked.includeExt, err = parseExtensions(raw.includeExt, "include-ext"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludeExt, err = parseExtensions(raw.excludeExt, "exclude-ext"); err != nil {
+		return cooked, err
+	}
+
+	if cooked.includeMetadata, err = parseMetadataConditions(raw.includeMetadata, "include-metadata"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludeMetadata, err = parseMetadataConditions(raw.excludeMetadata, "exclude-metadata"); err != nil {
+		return cooked, err
+	}
+	if len(cooked.includeMetadata) != 0 || len(cooked.excludeMetadata) != 0 {
+		switch cooked.FromTo.From() {
+		case common.ELocation.Blob():
+			// listings include the metadata of each blob
+		case common.ELocation.File():
+			// listings of a share don't, so it costs an extra request per file, unless we get the properties anyway to download
+			if cooked.FromTo.To().IsRemote() && !raw.getPropertiesForMetadata {
+				return cooked, errors.New("filtering an Azure Files source by metadata takes one extra request per file, " +
+					"since listing a share does not return metadata. Set get-properties-for-metadata to allow that")
+			}
+			cooked.getPropertiesForMetadata = true
+		default:
+			return cooked, fmt.Errorf("include-metadata and exclude-metadata are unsupported for this source (%s). They can only be used when the source is Blob or Azure Files", cooked.FromTo.From().String())
+		}
+	}
+
+	if cooked.includeContentType, err = parseContentTypePatterns(raw.includeContentType, "include-content-type"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludeContentType, err = parseContentTypePatterns(raw.excludeContentType, "exclude-content-type"); err != nil {
+		return cooked, err
+	}
+	if len(cooked.includeContentType) != 0 || len(cooked.excludeContentType) != 0 {
+		switch cooked.FromTo.From() {
+		case common.ELocation.Blob():
+			// listings include the content type of each blob
+		case common.ELocation.File():
+			// as with metadata, listings of a share don't
+			if cooked.FromTo.To().IsRemote() && !raw.getPropertiesForMetadata {
+				return cooked, errors.New("filtering an Azure Files source by content type takes one extra request per file, " +
+					"since listing a share does not return content types. Set get-properties-for-metadata to allow that")
+			}
+			cooked.getPropertiesForMetadata = true
+		default:
+			return cooked, fmt.Errorf("include-content-type and exclude-content-type are unsupported for this source (%s). They can only be used when the source is Blob or Azure Files", cooked.FromTo.From().String())
+		}
+	}
 
 	cooked.dryrunMode = raw.dryrun
 
+	if cooked.changeTokenPath, err = changeTokenPathFromFlag("change-token", raw.changeToken); err != nil {
+		return cooked, err
+	}
+	if cooked.changeTokenPath != "" && cooked.dryrunMode {
+		return cooked, errors.New("change-token cannot be used with dry-run, since a dry run transfers nothing")
+	}
+	if cooked.sinceChangeTokenPath, err = changeTokenPathFromFlag("since-change-token", raw.sinceChangeToken); err != nil {
+		return cooked, err
+	}
+	if (cooked.changeTokenPath != "" || cooked.sinceChangeTokenPath != "") &&
+		(raw.listOfUrls != "" || cooked.FromTo.From() == common.ELocation.Pipe() || cooked.FromTo.From() == common.ELocation.Benchmark()) {
+		return cooked, errors.New("change-token and since-change-token cannot be used with list-of-urls, or when the source is a pipe or a benchmark, since they need a single source whose files have last modified times")
+	}
+
 	if azcopyOutputVerbosity == common.EOutputVerbosity.Quiet() || azcopyOutputVerbosity == common.EOutputVerbosity.Essential() {
 		if cooked.ForceWrite == common.EOverwriteOption.Prompt() {
 			err = fmt.Errorf("cannot set output level '%s' with overwrite option '%s'", azcopyOutputVerbosity.String(), cooked.ForceWrite.String())
@@ -926,6 +1598,91 @@ func validateForceIfReadOnly(toForce bool, fromTo common.FromTo) error {
 	return nil
 }
 
+func isAzureLocation(l common.Location) bool {
+	return l == common.ELocation.Blob() || l == common.ELocation.File() || l == common.ELocation.BlobFS()
+}
+
+// resolveAzureCloud returns the cloud that an Azure location is in: the one given by the flag, or else the one whose
+// endpoint suffix its host ends in. A flag that contradicts the host's suffix is an error, since the suffix can't lie
+func resolveAzureCloud(flagValue string, flagName string, location common.Location, resource string) (common.AzureCloud, error) {
+	if !isAzureLocation(location) {
+		if flagValue != "" {
+			return common.EAzureCloud.Unknown(), fmt.Errorf("%s only applies to Azure Blob, Azure Files and ADLS Gen 2 locations, not %s", flagName, location.String())
+		}
+		return common.EAzureCloud.Unknown(), nil
+	}
+
+	inferred := common.EAzureCloud.Unknown()
+	if u, err := url.Parse(resource); err == nil {
+		inferred = common.AzureCloudOfHost(u.Host)
+	}
+	if flagValue == "" {
+		return inferred, nil
+	}
+
+	var cloud common.AzureCloud
+	if err := cloud.Parse(flagValue); err != nil || cloud == common.EAzureCloud.Unknown() {
+		return cloud, fmt.Errorf("invalid %s '%s'. It must be 'Public', 'USGov' or 'China'", flagName, flagValue)
+	}
+	if inferred != common.EAzureCloud.Unknown() && inferred != cloud {
+		return cloud, fmt.Errorf("%s is %s, but the host ends in %s, which is in the %s cloud", flagName, cloud.String(), inferred.EndpointSuffix(), inferred.String())
+	}
+	return cloud, nil
+}
+
+// validateCrossCloudCopy refuses copies between Azure locations in different clouds. Those are service to service,
+// and the service-side copy that the destination does can only read from sources in its own cloud. Sources outside
+// Azure, such as S3, have no cloud, and can be copied to any
+func validateCrossCloudCopy(fromTo common.FromTo, sourceCloud, destinationCloud common.AzureCloud) error {
+	if sourceCloud == common.EAzureCloud.Unknown() || destinationCloud == common.EAzureCloud.Unknown() || sourceCloud == destinationCloud {
+		return nil
+	}
+	return fmt.Errorf("cannot copy from the %s cloud to the %s cloud (%s): the service-side copy between Azure locations can't reach a source in another cloud. "+
+		"Download the files with one copy, then upload them with another", sourceCloud.String(), destinationCloud.String(), fromTo.String())
+}
+
+// authenticationCloud is the cloud of the location that the job authenticates to with OAuth, when it does: the
+// destination, unless that isn't in Azure, as for a download
+func (cca *CookedCopyCmdArgs) authenticationCloud() common.AzureCloud {
+	if cca.destinationCloud != common.EAzureCloud.Unknown() {
+		return cca.destinationCloud
+	}
+	return cca.sourceCloud
+}
+
+// validateOverwriteOption checks that, if the overwrite decision depends on last modified times, both ends of the
+// transfer can supply them. A pipe has no last modified time, and nothing is written to a destination of None
+func validateOverwriteOption(overwrite common.OverwriteOption, fromTo common.FromTo) error {
+	if overwrite != common.EOverwriteOption.IfSourceNewer() {
+		return nil
+	}
+
+	hasLastModifiedTime := func(l common.Location) bool {
+		return l != common.ELocation.Pipe() && l != common.ELocation.None()
+	}
+	if !hasLastModifiedTime(fromTo.From()) {
+		return fmt.Errorf("overwrite option '%s' cannot be used when the source is %s, since it has no last modified time to compare", overwrite.String(), fromTo.From().String())
+	}
+	if !hasLastModifiedTime(fromTo.To()) {
+		return fmt.Errorf("overwrite option '%s' cannot be used when the destination is %s, since it has no last modified time to compare", overwrite.String(), fromTo.To().String())
+	}
+	return nil
+}
+
+// validateSymlinkHandlingMode checks that preserved symlinks have somewhere to go. They are kept as specially marked
+// blobs, so they can only be preserved when uploading to Blob, or downloading from it
+func validateSymlinkHandlingMode(symlinkHandling common.SymlinkHandlingType, fromTo common.FromTo) error {
+	if symlinkHandling != common.ESymlinkHandlingType.Preserve() {
+		return nil
+	}
+	switch fromTo {
+	case common.EFromTo.LocalBlob(), common.EFromTo.BlobLocal():
+		return nil
+	default:
+		return fmt.Errorf("flag --preserve-symlinks can only be used when uploading to Blob or downloading from it, not for %s", fromTo.String())
+	}
+}
+
 func areBothLocationsSMBAware(fromTo common.FromTo) bool {
 	// preserverSMBInfo will be true by default for SMB-aware locations unless specified false.
 	// 1. Upload (Windows -> Azure File)
@@ -942,8 +1699,7 @@ func areBothLocationsSMBAware(fromTo common.FromTo) bool {
 
 func areBothLocationsPOSIXAware(fromTo common.FromTo) bool {
 	// POSIX properties are stored in blob metadata-- They don't need a special persistence strategy for BlobBlob.
-	return runtime.GOOS == "linux" && (
-		// fromTo == common.EFromTo.BlobLocal() || TODO
+	return runtime.GOOS == "linux" && (fromTo == common.EFromTo.BlobLocal() ||
 		fromTo == common.EFromTo.LocalBlob()) ||
 		fromTo == common.EFromTo.BlobBlob()
 }
@@ -973,6 +1729,58 @@ func validatePreserveOwner(preserve bool, fromTo common.FromTo) error {
 	return nil
 }
 
+// validateDestinationArchive checks that a copy can stream its files into a single archive at the destination
+func validateDestinationArchive(cooked CookedCopyCmdArgs) error {
+	if cooked.destinationArchive == common.EArchiveFormat.Zip() {
+		return errors.New("destination-archive=Zip is not supported. Use Tar or TarGz")
+	}
+	if !cooked.FromTo.IsDownload() || cooked.Destination.Value == common.Dev_Null {
+		return fmt.Errorf("destination-archive is unsupported for this transfer (%s). It can only be used when downloading to a local file", cooked.FromTo.String())
+	}
+	if cooked.autoDecompress {
+		// a tar entry's size must be known before its data is written, and the size of decompressed data isn't
+		return errors.New("destination-archive cannot be used with decompress")
+	}
+	if cooked.preservePermissions.IsTruthy() || cooked.preservePOSIXProperties {
+		return errors.New("destination-archive cannot preserve permissions or POSIX properties, since they aren't set on the files in the archive")
+	}
+
+	archive := cooked.Destination.ValueLocal()
+	if fi, err := os.Stat(archive); err == nil {
+		if fi.IsDir() {
+			return fmt.Errorf("the destination of destination-archive must be the path of the archive file, but %s is a folder", archive)
+		}
+		if cooked.ForceWrite != common.EOverwriteOption.True() {
+			return fmt.Errorf("the destination archive %s already exists, and is only replaced with overwrite=true", archive)
+		}
+	}
+	return nil
+}
+
+// validateSourceArchive checks that a copy can upload the entries of a single archive at the source
+func validateSourceArchive(cooked CookedCopyCmdArgs) error {
+	if cooked.sourceArchive != common.EArchiveFormat.Tar() && cooked.sourceArchive != common.EArchiveFormat.Zip() {
+		// the entries of a compressed tar can only be read one after the other, not in parallel
+		return fmt.Errorf("source-archive=%s is not supported. Use Tar or Zip, and decompress a .tar.gz first", cooked.sourceArchive.String())
+	}
+	if !cooked.FromTo.IsUpload() {
+		return fmt.Errorf("source-archive is unsupported for this transfer (%s). It can only be used when uploading from a local file", cooked.FromTo.String())
+	}
+	if cooked.preservePermissions.IsTruthy() || cooked.preservePOSIXProperties || cooked.preserveXattrs || cooked.SymlinkHandling != common.ESymlinkHandlingType.Skip() {
+		return errors.New("source-archive cannot preserve permissions, POSIX properties, extended attributes or symlinks, since the entries of an archive are read without being extracted")
+	}
+
+	archive := cooked.Source.ValueLocal()
+	fi, err := os.Stat(archive)
+	if err != nil {
+		return fmt.Errorf("cannot read the source archive: %w", err)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("the source of source-archive must be the path of the archive file, but %s is a folder", archive)
+	}
+	return nil
+}
+
 func crossValidateSymlinksAndPermissions(followSymlinks, preservePermissions bool) error {
 	if followSymlinks && preservePermissions {
 		return errors.New("cannot follow symlinks when preserving permissions (since the correct permission inheritance behaviour for symlink targets is undefined)")
@@ -994,6 +1802,29 @@ func validateBackupMode(backupMode bool, fromTo common.FromTo) error {
 	}
 }
 
+// validateTransferOrder checks that a priority pattern is given with, and only with, PatternPriority, and that its
+// patterns are valid and fit in the job's plan
+func validateTransferOrder(order common.TransferOrder, priorityPattern string) error {
+	if order != common.ETransferOrder.PatternPriority() {
+		if priorityPattern != "" {
+			return errors.New("priority-pattern only applies with --transfer-order=PatternPriority")
+		}
+		return nil
+	}
+	if priorityPattern == "" {
+		return errors.New("transfer-order=PatternPriority needs --priority-pattern, to say which files to transfer first")
+	}
+	if len(priorityPattern) > ste.CustomHeaderMaxBytes {
+		return fmt.Errorf("priority-pattern can be at most %d characters", ste.CustomHeaderMaxBytes)
+	}
+	for _, pattern := range strings.Split(priorityPattern, ";") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("priority-pattern %q is not a valid pattern: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
 func validatePutMd5(putMd5 bool, fromTo common.FromTo) error {
 	// In case of S2S transfers, log info message to inform the users that MD5 check doesn't work for S2S Transfers.
 	// This is because we cannot calculate MD5 hash of the data stored at a remote locations.
@@ -1091,49 +1922,153 @@ type CookedCopyCmdArgs struct {
 	// includePathPatterns are handled like a list-of-files. Do not panic. This is not a bug that it is not present here.
 	IncludePatterns       []string
 	ExcludePatterns       []string
+	IgnoreCasePattern     bool
 	ExcludePathPatterns   []string
 	IncludeFileAttributes []string
 	ExcludeFileAttributes []string
 	IncludeBefore         *time.Time
 	IncludeAfter          *time.Time
+	MinSizeBytes          *int64
+	MaxSizeBytes          *int64
+	MinDepth              uint
+	MaxDepth              uint
+	// include-path, when the source has many containers. Each path is matched within each container, by a filter
+	includePathPerContainer []string
+
+	// gives each file and folder its path at the destination, if it is not the same as at the source (from path-map)
+	pathRewriter PathRewriter
 
 	// include/exclude filters with regular expression (also for sync)
 	includeRegex []string
 	excludeRegex []string
 
+	// the name patterns (*.jpg) for the extensions given to include-ext and exclude-ext (also for sync)
+	includeExt []string
+	excludeExt []string
+
+	// include/exclude filters on source metadata and content types, and whether to get the properties of each file so that they can be applied
+	includeMetadata          []metadataCondition
+	excludeMetadata          []metadataCondition
+	includeContentType       []string
+	excludeContentType       []string
+	getPropertiesForMetadata bool
+
 	// list of version ids
 	ListOfVersionIDs chan string
+	// listVersions copies every version of each blob, rather than just its current version
+	listVersions bool
+	// maxVersionsPerBlob limits listVersions to the newest this many versions of each blob. 0 means no limit
+	maxVersionsPerBlob uint
+	// the file in which to save a change token once every file has been transferred
+	changeTokenPath string
+	// the file with the change token of an earlier copy, so that only the files changed since are transferred
+	sinceChangeTokenPath string
+	// the files that have changed since that token, for the token to save. Nil unless either flag is set
+	changes *changeTracker
+	// the blob URLs of list-of-urls, each of which is a source of its own. Nil unless that flag is set
+	ListOfUrlsChannel chan string
+	keepFromUrl       common.KeepFromUrl
+	// the URLs of list-of-urls that could not be listed, which are reported as failed transfers
+	urlListFailures *urlListFailures
 	// filters from flags
 	ListOfFilesChannel chan string // Channels are nullable.
 	Recursive          bool
 	StripTopDir        bool
-	FollowSymlinks     bool
-	ForceWrite         common.OverwriteOption // says whether we should try to overwrite
-	ForceIfReadOnly    bool                   // says whether we should _force_ any overwrites (triggered by forceWrite) to work on Azure Files objects that are set to read-only
-	autoDecompress     bool
+	SymlinkHandling    common.SymlinkHandlingType
+	// restrictSymlinksToRoot says whether followed symlinks must lead somewhere inside of the source
+	restrictSymlinksToRoot bool
+	ForceWrite             common.OverwriteOption // says whether we should try to overwrite
+	ForceIfReadOnly        bool                   // says whether we should _force_ any overwrites (triggered by forceWrite) to work on Azure Files objects that are set to read-only
+	autoDecompress         bool
+	// trailingDot says whether names ending with a dot keep it at the destination, when Azure Files is involved
+	trailingDot common.TrailingDotOption
 
 	// options from flags
 	blockSize int64
 	// list of blobTypes to exclude while enumerating the transfer
 	excludeBlobType []azblob.BlobType
-	blobType        common.BlobType
+	// names, or patterns, of the containers to skip when copying from a whole account
+	excludeContainer []string
+	// the owners of the ADLS Gen 2 paths to copy, and of those not to, by object id or user principal name
+	includeOwner []string
+	excludeOwner []string
+	// how many of those owners are looked up at once
+	ownerLookupConcurrency int
+	// enumeration fails once more files than this have been scheduled. 0 means no limit
+	maxFileCount uint64
+	blobType     common.BlobType
+	// the types of the blobs uploaded from files whose names match these patterns. First match wins. Files that match
+	// none are given blobType
+	blobTypeByPattern []blobTypeForPattern
+	// skip the files that already exist at the destination with the same size, as found by one listing of the destination
+	skipExistingWithSameSize bool
+	// fail a file's transfer, so that a resume retries it, once it has made no progress for this long. 0 means never
+	perTransferTimeout time.Duration
+	// snapshot each source blob just before copying it, and copy from the snapshot, so that writes to the blob made
+	// meanwhile don't end up in the copy. Only for Blob to Blob
+	snapshotSourceFirst bool
+	// fail the transfer of a file when the CRC64 that the service computed of an uploaded block, page or blob doesn't
+	// match the one computed of the data that was sent. Only for uploads to Blob
+	verifyCrc64 bool
+	// give each existing destination blob the metadata, index tags and HTTP headers of its source, and copy no data.
+	// A destination blob that doesn't exist fails its transfer. Only for service to service copies to Blob
+	metadataOnly bool
+	// the conditions that a destination blob must meet to be written: the ETag it must have, and one it mustn't.
+	// * stands for any ETag. A blob that doesn't meet them fails its transfer with PreconditionFailed. Only for Blob destinations
+	ifMatchETag     string
+	ifNoneMatchETag string
+	// the time until which each destination blob is protected by an immutability policy. Zero for none
+	immutabilityPolicyUntil time.Time
+	// whether each destination blob is put under a legal hold
+	legalHold bool
+	// the Azure clouds that the source and the destination are in, which decide the authority that OAuth tokens come
+	// from. Unknown for locations outside Azure, and for Azure hosts with custom domains when no cloud was given
+	sourceCloud      common.AzureCloud
+	destinationCloud common.AzureCloud
+	// where the listing of each source directory is recorded: "log" for the scanning log, or the absolute path of a
+	// file. Empty for nowhere
+	enumerationTelemetry string
+	// for downloads, the format of the single archive file, at the destination path, that the source files are
+	// streamed into. Each file's entry is named with its relative path. None writes each to a file of its own, as usual
+	destinationArchive common.ArchiveFormat
+	// for uploads, the format of the archive file, at the source path, whose entries are uploaded. Each is uploaded under
+	// its name in the archive, and filters apply to those names. None uploads the files in the source folder, as usual
+	sourceArchive common.ArchiveFormat
+	// flatten drops the folders from the destination paths, so that every file is copied directly under the destination
+	flatten           bool
+	flattenCollisions common.FlattenCollisionPolicy
+	// for uploads to Blob, write a zero-byte blob named emptyDirectoryMarker into each empty folder, and for downloads from
+	// Blob, create the folder that each such blob is in, instead of downloading it
+	preserveEmptyDirectories bool
+	emptyDirectoryMarker     string
+	// the order in which the ste schedules the transfers of each job part. For PatternPriority, the files whose names match
+	// one of the semicolon-separated patterns in priorityPattern are scheduled first
+	transferOrder   common.TransferOrder
+	priorityPattern string
+	// the absolute path of the JSON Lines manifest of the transferred files. The failed ones go alongside it. Empty for no manifest
+	manifestOutput string
+	// how many transfers can fail before the job cancels itself, and exits with TooManyErrors. 0 means no limit
+	maxErrors uint32
 	// Blob index tags categorize data in your storage account utilizing key-value tag attributes.
 	// These tags are automatically indexed and exposed as a queryable multi-dimensional index to easily find data.
-	blobTags                 common.BlobTags
-	blockBlobTier            common.BlockBlobTier
-	pageBlobTier             common.PageBlobTier
-	metadata                 string
-	contentType              string
-	contentEncoding          string
-	contentLanguage          string
-	contentDisposition       string
-	cacheControl             string
-	noGuessMimeType          bool
-	preserveLastModifiedTime bool
-	deleteSnapshotsOption    common.DeleteSnapshotsOption
-	putMd5                   bool
-	md5ValidationOption      common.HashValidationOption
-	CheckLength              bool
+	blobTags                     common.BlobTags
+	blockBlobTier                common.BlockBlobTier
+	pageBlobTier                 common.PageBlobTier
+	metadata                     string
+	contentType                  string
+	contentEncoding              string
+	contentLanguage              string
+	contentDisposition           string
+	cacheControl                 string
+	noGuessMimeType              bool
+	noGuessMimeTypeFromExtension bool
+	contentTypeMap               string // the content type of each extension, from --content-type-map, as a JSON object
+	defaultContentType           string // the content type of extensions that aren't in contentTypeMap
+	preserveLastModifiedTime     bool
+	deleteSnapshotsOption        common.DeleteSnapshotsOption
+	putMd5                       bool
+	md5ValidationOption          common.HashValidationOption
+	CheckLength                  bool
 	// commandString hold the user given command which is logged to the Job log file
 	commandString string
 
@@ -1153,6 +2088,15 @@ type CookedCopyCmdArgs struct {
 	// used to calculate job summary
 	jobStartTime time.Time
 
+	// how many files the enumerator has scheduled so far, to enforce maxFileCount
+	scheduledFileCount uint64
+
+	// how many files the enumerator has skipped because of skipExistingWithSameSize
+	skippedExistingFileCount uint64
+
+	// what a dry run of remove would have deleted. Nil if the dry run didn't count it
+	dryrunSummary *common.DryrunSummary
+
 	// this flag is set by the enumerator
 	// it is useful to indicate whether we are simply waiting for the purpose of cancelling
 	isEnumerationComplete bool
@@ -1163,13 +2107,25 @@ type CookedCopyCmdArgs struct {
 	preserveSMBInfo bool
 	// Whether the user wants to preserve the POSIX properties ...
 	preservePOSIXProperties bool
+	// Whether the user wants to preserve the user-namespace extended attributes of local files
+	preserveXattrs bool
 
 	// Whether to enable Windows special privileges
 	backupMode bool
 
+	// Whether downloads may follow the redirects of their sources
+	followSourceRedirects bool
+
+	// Whether the ranges of downloads are written to their places in sparse files as they arrive
+	downloadRangesInPlace bool
+
 	// Whether to rename/share the root
 	asSubdir bool
 
+	// set by --strip-top-dir to say whether the name of the source's top directory is left out of destination paths, which
+	// then no longer depends on StripTopDir, and so on whether the source ends with /*. Nil to go by StripTopDir
+	stripTopDirOverride *bool
+
 	// whether user wants to preserve full properties during service to service copy, the default value is true.
 	// For S3 and Azure File non-single file source, as list operation doesn't return full properties of objects/files,
 	// to preserve full properties AzCopy needs to send one additional request per object/file.
@@ -1186,6 +2142,13 @@ type CookedCopyCmdArgs struct {
 	s2sSourceChangeValidation bool
 	// To specify whether user wants to preserve the blob index tags during service to service transfer.
 	S2sPreserveBlobTags bool
+
+	// Blob index tag filter expression, which is evaluated by the service when enumerating the source
+	IncludeBlobTags string
+
+	// whether this is a move, i.e. the source files that were transferred successfully are deleted once the job is done.
+	// Like the followup properties below, this is NOT available on resume, so a resumed move only copies
+	deleteSourceOnSuccess bool
 	// specify how user wants to handle invalid metadata.
 	s2sInvalidMetadataHandleOption common.InvalidMetadataHandleOption
 
@@ -1199,12 +2162,19 @@ type CookedCopyCmdArgs struct {
 	// whether to include blobs that have metadata 'hdi_isfolder = true'
 	IncludeDirectoryStubs bool
 
+	// excludeRootFolder leaves out the transfer of the source root folder's own properties, as happens anyway when
+	// the top directory is stripped, so that the folder it lands in at the destination is left as it is
+	excludeRootFolder bool
+
 	// whether to disable automatic decoding of illegal chars on Windows
 	disableAutoDecoding bool
 
 	// specify if dry run mode on
 	dryrunMode bool
 
+	// when set, each transfer is handed to it as it is enumerated, instead of being scheduled. See ListTransfers
+	listTransfer func(TransferDescriptor) error
+
 	CpkOptions common.CpkOptions
 
 	// Optional flag that permanently deletes soft deleted blobs
@@ -1228,45 +2198,59 @@ func (cca *CookedCopyCmdArgs) isRedirection() bool {
 	}
 }
 
+// allowsTrailingDot says whether requests to Azure Files should keep the trailing dots of names. Only copies to or from
+// Azure Files with --trailing-dot=Enable do; every other job is left to the service's default, which strips them
+func (cca *CookedCopyCmdArgs) allowsTrailingDot() bool {
+	return cca.trailingDot == common.ETrailingDotOption.Enable() &&
+		(cca.FromTo.From() == common.ELocation.File() || cca.FromTo.To() == common.ELocation.File())
+}
+
 func (cca *CookedCopyCmdArgs) process() error {
 
 	err := common.SetBackupMode(cca.backupMode, cca.FromTo)
 	if err != nil {
 		return err
 	}
+	autoLoginCloud = cca.authenticationCloud()
 
 	if cca.isRedirection() {
-		err := cca.processRedirectionCopy()
+		progress := newPipeProgress(-1)
+		progress.start()
+		err := cca.processRedirectionCopy(progress)
+		progress.stop()
 
 		if err != nil {
 			return err
 		}
 
 		// if no error, the operation is now complete
-		glcm.Exit(nil, common.EExitCode.Success())
+		glcm.Exit(progress.builder(true), common.EExitCode.Success())
 	}
 	return cca.processCopyJobPartOrders()
 }
 
 // TODO discuss with Jeff what features should be supported by redirection, such as metadata, content-type, etc.
-func (cca *CookedCopyCmdArgs) processRedirectionCopy() error {
+func (cca *CookedCopyCmdArgs) processRedirectionCopy(progress *pipeProgress) error {
 	if cca.FromTo == common.EFromTo.PipeBlob() {
-		return cca.processRedirectionUpload(cca.Destination, cca.blockSize)
+		return cca.processRedirectionUpload(cca.Destination, cca.blockSize, os.Stdin, progress)
 	} else if cca.FromTo == common.EFromTo.BlobPipe() {
-		return cca.processRedirectionDownload(cca.Source)
+		return cca.processRedirectionDownload(cca.Source, os.Stdout, progress)
 	}
 
 	return fmt.Errorf("unsupported redirection type: %s", cca.FromTo)
 }
 
-func (cca *CookedCopyCmdArgs) processRedirectionDownload(blobResource common.ResourceString) error {
+// processRedirectionDownload downloads the blob to w, which is normally stdout. progress counts the bytes as they go,
+// out of the size of the blob
+func (cca *CookedCopyCmdArgs) processRedirectionDownload(blobResource common.ResourceString, w io.Writer, progress *pipeProgress) error {
 
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 
 	// step 0: check the Stdout before uploading
-	_, err := os.Stdout.Stat()
-	if err != nil {
-		return fmt.Errorf("fatal: cannot write to Stdout due to error: %s", err.Error())
+	if stdout, ok := w.(*os.File); ok {
+		if _, err := stdout.Stat(); err != nil {
+			return fmt.Errorf("fatal: cannot write to Stdout due to error: %s", err.Error())
+		}
 	}
 
 	// The isPublic flag is useful in S2S transfers but doesn't much matter for download. Fortunately, no S2S happens here.
@@ -1303,9 +2287,10 @@ func (cca *CookedCopyCmdArgs) processRedirectionDownload(blobResource common.Res
 
 	blobBody := blobStream.Body(azblob.RetryReaderOptions{MaxRetryRequests: ste.MaxRetryPerDownloadBody})
 	defer blobBody.Close()
+	progress.setTotal(blobStream.ContentLength())
 
 	// step 4: pipe everything into Stdout
-	_, err = io.Copy(os.Stdout, blobBody)
+	_, err = io.Copy(w, progress.countReads(blobBody))
 	if err != nil {
 		return fmt.Errorf("fatal: cannot download blob to Stdout due to error: %s", err.Error())
 	}
@@ -1313,7 +2298,9 @@ func (cca *CookedCopyCmdArgs) processRedirectionDownload(blobResource common.Res
 	return nil
 }
 
-func (cca *CookedCopyCmdArgs) processRedirectionUpload(blobResource common.ResourceString, blockSize int64) error {
+// processRedirectionUpload uploads what is read from r, which is normally stdin, to a block blob. Its size isn't known
+// until r ends, so it's uploaded a block at a time. progress counts the bytes as they are read
+func (cca *CookedCopyCmdArgs) processRedirectionUpload(blobResource common.ResourceString, blockSize int64, r io.Reader, progress *pipeProgress) error {
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 
 	// if no block size is set, then use default value
@@ -1355,7 +2342,7 @@ func (cca *CookedCopyCmdArgs) processRedirectionUpload(blobResource common.Resou
 	if cca.blockBlobTier != common.EBlockBlobTier.None() {
 		bbAccessTier = azblob.AccessTierType(cca.blockBlobTier.String())
 	}
-	_, err = azblob.UploadStreamToBlockBlob(ctx, os.Stdin, blockBlobUrl, azblob.UploadStreamToBlockBlobOptions{
+	_, err = azblob.UploadStreamToBlockBlob(ctx, progress.countReads(r), blockBlobUrl, azblob.UploadStreamToBlockBlobOptions{
 		BufferSize:  int(blockSize),
 		MaxBuffers:  pipingUploadParallelism,
 		Metadata:    metadataMap.ToAzBlobMetadata(),
@@ -1378,8 +2365,11 @@ func (cca *CookedCopyCmdArgs) processRedirectionUpload(blobResource common.Resou
 // dispatches the job order (in parts) to the storage engine
 func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+	if cca.allowsTrailingDot() {
+		ctx = context.WithValue(ctx, ste.AllowTrailingDot, true) // for the enumeration; the job gets it from its plan
+	}
 	// Make AUTO default for Azure Files since Azure Files throttles too easily unless user specified concurrency value
-	if jobsAdmin.JobsAdmin != nil && (cca.FromTo.From() == common.ELocation.File() || cca.FromTo.To() == common.ELocation.File()) && glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ConcurrencyValue()) == "" {
+	if jobsAdmin.JobsAdmin != nil && cca.listTransfer == nil && (cca.FromTo.From() == common.ELocation.File() || cca.FromTo.To() == common.ELocation.File()) && glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ConcurrencyValue()) == "" {
 		jobsAdmin.JobsAdmin.SetConcurrencySettingsToAuto()
 	}
 
@@ -1390,7 +2380,10 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 	// Note: Currently, only one credential type is necessary for source and destination.
 	// For upload&download, only one side need credential.
 	// For S2S copy, as azcopy-v10 use Put*FromUrl, only one credential is needed for destination.
-	if cca.credentialInfo.CredentialType, err = getCredentialType(ctx, rawFromToInfo{
+	if cca.ListOfUrlsChannel != nil && !cca.FromTo.To().IsRemote() {
+		// a download authenticates to the source, and each URL in the list carries its own SAS, unless it is public
+		cca.credentialInfo.CredentialType = common.ECredentialType.Anonymous()
+	} else if cca.credentialInfo.CredentialType, err = getCredentialType(ctx, rawFromToInfo{
 		fromTo:         cca.FromTo,
 		source:         cca.Source.Value,
 		destination:    cca.Destination.Value,
@@ -1410,6 +2403,13 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 		} else {
 			cca.credentialInfo.OAuthTokenInfo = *tokenInfo
 		}
+		side, cloud := "destination", cca.destinationCloud
+		if !cca.FromTo.To().IsRemote() {
+			side, cloud = "source", cca.sourceCloud
+		}
+		if err = checkTokenCloud(cca.credentialInfo.OAuthTokenInfo, cloud, side); err != nil {
+			return err
+		}
 	}
 
 	// initialize the fields that are constant across all job part orders,
@@ -1424,23 +2424,31 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 		LogLevel:        azcopyLogVerbosity,
 		ExcludeBlobType: cca.excludeBlobType,
 		BlobAttributes: common.BlobTransferAttributes{
-			BlobType:                 cca.blobType,
-			BlockSizeInBytes:         cca.blockSize,
-			ContentType:              cca.contentType,
-			ContentEncoding:          cca.contentEncoding,
-			ContentLanguage:          cca.contentLanguage,
-			ContentDisposition:       cca.contentDisposition,
-			CacheControl:             cca.cacheControl,
-			BlockBlobTier:            cca.blockBlobTier,
-			PageBlobTier:             cca.pageBlobTier,
-			Metadata:                 cca.metadata,
-			NoGuessMimeType:          cca.noGuessMimeType,
-			PreserveLastModifiedTime: cca.preserveLastModifiedTime,
-			PutMd5:                   cca.putMd5,
-			MD5ValidationOption:      cca.md5ValidationOption,
-			DeleteSnapshotsOption:    cca.deleteSnapshotsOption,
+			BlobType:                     cca.blobType,
+			BlockSizeInBytes:             cca.blockSize,
+			ContentType:                  cca.contentType,
+			ContentEncoding:              cca.contentEncoding,
+			ContentLanguage:              cca.contentLanguage,
+			ContentDisposition:           cca.contentDisposition,
+			CacheControl:                 cca.cacheControl,
+			BlockBlobTier:                cca.blockBlobTier,
+			PageBlobTier:                 cca.pageBlobTier,
+			Metadata:                     cca.metadata,
+			NoGuessMimeType:              cca.noGuessMimeType,
+			NoGuessMimeTypeFromExtension: cca.noGuessMimeTypeFromExtension,
+			PreserveLastModifiedTime:     cca.preserveLastModifiedTime,
+			PutMd5:                       cca.putMd5,
+			MD5ValidationOption:          cca.md5ValidationOption,
+			DeleteSnapshotsOption:        cca.deleteSnapshotsOption,
 			// Setting tags when tags explicitly provided by the user through blob-tags flag
-			BlobTagsString: cca.blobTags.ToString(),
+			BlobTagsString:  cca.blobTags.ToString(),
+			IfMatchETag:     cca.ifMatchETag,
+			IfNoneMatchETag: cca.ifNoneMatchETag,
+			// the policy is only set on the blobs, not on any folders or symlinks written with them
+			ImmutabilityPolicyUntil: cca.immutabilityPolicyUntil,
+			LegalHold:               cca.legalHold,
+			ContentTypeMap:          cca.contentTypeMap,
+			DefaultContentType:      cca.defaultContentType,
 		},
 		CommandString:  cca.commandString,
 		CredentialInfo: cca.credentialInfo,
@@ -1618,6 +2626,9 @@ func (cca *CookedCopyCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 	// fetch a job status
 	var summary common.ListJobSummaryResponse
 	Rpc(common.ERpcCmd.ListJobSummary(), &cca.jobID, &summary)
+	if cca.urlListFailures != nil {
+		cca.urlListFailures.addTo(&summary)
+	}
 	glcmSwapOnce.Do(func() {
 		Rpc(common.ERpcCmd.GetJobLCMWrapper(), &cca.jobID, &glcm)
 	})
@@ -1641,6 +2652,9 @@ func (cca *CookedCopyCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 
 		return common.Iffloat64(timeElapsed != 0, bytesInMb/timeElapsed, 0) * 8
 	}
+	throughput := computeThroughput()
+	glcm.ProgressEvent(common.NewProgressEvent(summary, throughput))
+
 	glcm.Progress(func(format common.OutputFormat) string {
 		if format == common.EOutputFormat.Json() {
 			jsonOutput, err := json.Marshal(summary)
@@ -1659,7 +2673,6 @@ func (cca *CookedCopyCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 				scanningString = ""
 			}
 
-			throughput := computeThroughput()
 			throughputString := fmt.Sprintf("2-sec Throughput (Mb/s): %v", jobsAdmin.ToFixed(throughput, 4))
 			if throughput == 0 {
 				// As there would be case when no bits sent from local, e.g. service side copy, when throughput = 0, hide it.
@@ -1670,31 +2683,41 @@ func (cca *CookedCopyCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 			isBenchmark := cca.FromTo.From() == common.ELocation.Benchmark()
 			perfString, diskString := getPerfDisplayText(summary.PerfStrings, summary.PerfConstraint, duration, isBenchmark)
 
-			return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s",
+			return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s%s",
 				summary.PercentComplete,
 				summary.TransfersCompleted,
 				summary.TransfersFailed,
 				summary.TotalTransfers-(summary.TransfersCompleted+summary.TransfersFailed+summary.TransfersSkipped),
-				summary.TransfersSkipped, summary.TotalTransfers, scanningString, perfString, throughputString, diskString)
+				summary.TransfersSkipped, summary.TotalTransfers, scanningString, perfString, throughputString, diskString, getETADisplayText(summary))
 		}
 	})
 
 	if jobDone {
 		exitCode := cca.getSuccessExitCode()
 		if summary.TransfersFailed > 0 {
-			exitCode = common.EExitCode.Error()
+			exitCode = common.ExitCodeForTransfers(summary.TransfersCompleted, summary.TransfersFailed)
+		}
+		if summary.AbortReason != "" {
+			exitCode = common.EExitCode.TooManyErrors()
+		}
+
+		// a move only deletes its sources once every transfer has finished, so that no source is deleted before it has been copied
+		isMove := cca.deleteSourceOnSuccess && !cca.isCleanupJob
+		if isMove {
+			summary.SourcesDeleted, summary.SourceDeletionsFailed = cca.deleteMovedSources()
+			if summary.SourceDeletionsFailed > 0 && exitCode == common.EExitCode.Success() {
+				exitCode = common.EExitCode.PartialFailure() // the files were copied, but some sources remain
+			}
 		}
 
+		// the next token only moves on once every file that it covers has been transferred
+		savedChangeToken := cca.changeTokenPath != "" && !cca.isCleanupJob && cca.saveChangeToken(summary)
+
 		builder := func(format common.OutputFormat) string {
-			if format == common.EOutputFormat.Json() {
-				jsonOutput, err := json.Marshal(summary)
-				common.PanicIfErr(err)
-				return string(jsonOutput)
-			} else {
-				screenStats, logStats := formatExtraStats(cca.FromTo, summary.AverageIOPS, summary.AverageE2EMilliseconds, summary.NetworkErrorPercentage, summary.ServerBusyPercentage)
+			screenStats, logStats := formatExtraStats(cca.FromTo, summary.AverageIOPS, summary.AverageE2EMilliseconds, summary.NetworkErrorPercentage, summary.ServerBusyPercentage)
 
-				output := fmt.Sprintf(
-					`
+			output := fmt.Sprintf(
+				`
 
 Job %s summary
 Elapsed Time (Minutes): %v
@@ -1707,31 +2730,69 @@ Number of Transfers Skipped: %v
 TotalBytesTransferred: %v
 Final Job Status: %v%s%s
 `,
-					summary.JobID.String(),
-					jobsAdmin.ToFixed(duration.Minutes(), 4),
-					summary.FileTransfers,
-					summary.FolderPropertyTransfers,
-					summary.TotalTransfers,
-					summary.TransfersCompleted,
-					summary.TransfersFailed,
-					summary.TransfersSkipped,
-					summary.TotalBytesTransferred,
-					summary.JobStatus,
-					screenStats,
-					formatPerfAdvice(summary.PerformanceAdvice))
-
-				// abbreviated output for cleanup jobs
-				if cca.isCleanupJob {
-					output = fmt.Sprintf("%s: %s)", cleanupStatusString, summary.JobStatus)
-				}
+				summary.JobID.String(),
+				jobsAdmin.ToFixed(duration.Minutes(), 4),
+				summary.FileTransfers,
+				summary.FolderPropertyTransfers,
+				summary.TotalTransfers,
+				summary.TransfersCompleted,
+				summary.TransfersFailed,
+				summary.TransfersSkipped,
+				summary.TotalBytesTransferred,
+				summary.JobStatus,
+				screenStats,
+				formatPerfAdvice(summary.PerformanceAdvice))
+
+			if summary.POSIXPropertiesNotRestored > 0 {
+				output += fmt.Sprintf("Number of Files Whose POSIX Properties Were Not Restored: %v\n", summary.POSIXPropertiesNotRestored)
+			}
 
-				// log to job log
-				jobMan, exists := jobsAdmin.JobsAdmin.JobMgr(summary.JobID)
-				if exists {
-					jobMan.Log(pipeline.LogInfo, logStats+"\n"+output)
-				}
-				return output
+			if summary.TransfersPreconditionFailed > 0 {
+				output += fmt.Sprintf("Number of Transfers Failed On A Precondition: %v\n", summary.TransfersPreconditionFailed)
+			}
+
+			if summary.TransfersFailed > 0 {
+				output += fmt.Sprintf("Failed Transfers By Category: %v\n", summary.FailuresByCategory)
+			}
+
+			if summary.AutoTunedThroughputMbps > 0 {
+				output += fmt.Sprintf("Auto-tuned Throughput (Mbps): %.0f\n", summary.AutoTunedThroughputMbps)
+			}
+			if summary.AutoTunedConcurrency > 0 {
+				output += fmt.Sprintf("Auto-tuned Concurrency: %v\n", summary.AutoTunedConcurrency)
+			}
+
+			if summary.AbortReason != "" {
+				output += fmt.Sprintf("Job Cancelled Because: %s\n", summary.AbortReason)
+			}
+
+			if isMove {
+				output += fmt.Sprintf("Number of Source Files Moved: %v\nNumber of Source Deletions Failed: %v\n",
+					summary.SourcesDeleted,
+					summary.SourceDeletionsFailed)
+			}
+
+			if savedChangeToken {
+				output += fmt.Sprintf("Change Token Saved To: %s\n", cca.changeTokenPath)
 			}
+
+			// abbreviated output for cleanup jobs
+			if cca.isCleanupJob {
+				output = fmt.Sprintf("%s: %s)", cleanupStatusString, summary.JobStatus)
+			}
+
+			// log to job log, whatever the output format, so that the summary is kept even with --output-level=quiet
+			jobMan, exists := jobsAdmin.JobsAdmin.JobMgr(summary.JobID)
+			if exists {
+				jobMan.Log(pipeline.LogInfo, logStats+"\n"+output)
+			}
+
+			if format == common.EOutputFormat.Json() {
+				jsonOutput, err := json.Marshal(summary)
+				common.PanicIfErr(err)
+				return string(jsonOutput)
+			}
+			return output
 		}
 
 		if cca.hasFollowup() {
@@ -1811,6 +2872,15 @@ func getPerfDisplayText(perfDiagnosticStrings []string, constraint common.PerfCo
 	return
 }
 
+// getETADisplayText returns the job's estimated time remaining, ready to end the progress line, or nothing when
+// there is no estimate
+func getETADisplayText(summary common.ListJobSummaryResponse) string {
+	if summary.EstimatedSecondsRemaining <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(", ETA: %v", time.Duration(summary.EstimatedSecondsRemaining)*time.Second)
+}
+
 func shouldDisplayPerfStates() bool {
 	return glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ShowPerfStates()) != ""
 }
@@ -1825,7 +2895,8 @@ func isStdinPipeIn() (bool, error) {
 	// if the stdin is a named pipe, then we assume there will be data on the stdin
 	// the reason for this assumption is that we do not know when will the data come in
 	// it could come in right away, or come in 10 minutes later
-	return info.Mode()&(os.ModeNamedPipe|os.ModeSocket) != 0, nil
+	// A file redirected into the stdin is read the same way, to its end
+	return info.Mode()&(os.ModeNamedPipe|os.ModeSocket) != 0 || info.Mode().IsRegular(), nil
 }
 
 // TODO check file size, max is 4.75TB
@@ -1836,12 +2907,36 @@ func init() {
 	cpCmd := &cobra.Command{
 		Use:        "copy [source] [destination]",
 		Aliases:    []string{"cp", "c"},
-		SuggestFor: []string{"cpy", "cy", "mv"}, // TODO why does message appear twice on the console
+		SuggestFor: []string{"cpy", "cy"}, // TODO why does message appear twice on the console
 		Short:      copyCmdShortDescription,
 		Long:       copyCmdLongDescription,
 		Example:    copyCmdExample,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 1 { // redirection
+			// like many other tools, take - to be stdin as the source, or stdout as the destination
+			if len(args) == 2 && raw.listOfUrls == "" && (args[0] == stdioArg) != (args[1] == stdioArg) {
+				implied, blobURL, side := common.EFromTo.PipeBlob(), args[1], "source"
+				if args[1] == stdioArg {
+					implied, blobURL, side = common.EFromTo.BlobPipe(), args[0], "destination"
+				}
+				var userFromTo common.FromTo
+				if raw.fromTo == "" {
+					raw.fromTo = implied.String()
+				} else if userFromTo.Parse(raw.fromTo) != nil || userFromTo != implied {
+					return fmt.Errorf("fatal: with - as the %s, the from-to argument must be %s", side, implied)
+				}
+				args = []string{blobURL}
+				raw.stdio = true
+			}
+			raw.stripTopDirSet = cmd.Flags().Changed("strip-top-dir")
+
+			if len(args) == 1 && raw.listOfUrls != "" { // the sources are the URLs in the list
+				raw.dst = args[0]
+
+				glcm.EnableInputWatcher()
+				if cancelFromStdin {
+					glcm.EnableCancelFromStdIn()
+				}
+			} else if len(args) == 1 { // redirection
 				// Enforce the usage of from-to flag when pipes are involved
 				if raw.fromTo == "" {
 					return fmt.Errorf("fatal: from-to argument required, PipeBlob (upload) or BlobPipe (download) is acceptable")
@@ -1855,8 +2950,10 @@ func init() {
 				if userFromTo == common.EFromTo.PipeBlob() {
 					// Case 1: PipeBlob. Check for the std input pipe
 					stdinPipeIn, err := isStdinPipeIn()
-					if stdinPipeIn == false || err != nil {
-						return fmt.Errorf("fatal: failed to read from Stdin due to error: %s", err)
+					if err != nil {
+						return err
+					} else if !stdinPipeIn {
+						return errors.New("fatal: Stdin is a terminal. Pipe or redirect the data to upload into AzCopy")
 					}
 					raw.src = pipeLocation
 					raw.dst = args[0]
@@ -1864,6 +2961,8 @@ func init() {
 					// Case 2: BlobPipe. In this case if pipe is missing, content will be echoed on the terminal
 					raw.src = args[0]
 					raw.dst = pipeLocation
+					// Stdout is for the blob, so everything else AzCopy has to say goes to Stderr
+					glcm.SetOutputWriter(os.Stderr)
 				}
 			} else if len(args) == 2 { // normal copy
 				raw.src = args[0]
@@ -1902,66 +3001,316 @@ func init() {
 	}
 	rootCmd.AddCommand(cpCmd)
 
+	// moveCmd is a copy that deletes each source file once it has been transferred successfully.
+	// It shares raw with cpCmd, and so all of its flags, which are added to it below once they are all defined
+	moveCmd := &cobra.Command{
+		Use:        "move [source] [destination]",
+		Aliases:    []string{"mv"},
+		SuggestFor: []string{"mov", "mve"},
+		Short:      moveCmdShortDescription,
+		Long:       moveCmdLongDescription,
+		Example:    moveCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// redirection is not supported, since there is nothing to delete from a pipe
+			if len(args) != 2 {
+				return errors.New("wrong number of arguments, please refer to the help page on usage of this command")
+			}
+			if args[0] == stdioArg || args[1] == stdioArg {
+				return errors.New("move cannot read from Stdin or write to Stdout. Use copy instead")
+			}
+			return cpCmd.Args(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			raw.deleteSourceOnSuccess = true
+			cpCmd.Run(cmd, args)
+		},
+	}
+	rootCmd.AddCommand(moveCmd)
+
+	raw.addFlags(cpCmd.PersistentFlags(), &ste.ADLSFlushThreshold)
+
+	// the move command accepts exactly the same flags as copy
+	moveCmd.PersistentFlags().AddFlagSet(cpCmd.PersistentFlags())
+}
+
+// addFlags defines the flags of the copy command on flags, bound to raw. flushThreshold is bound to the hidden
+// flush-threshold flag, which sets a value for the whole engine rather than one of raw's
+func (raw *rawCopyCmdArgs) addFlags(flags *pflag.FlagSet, flushThreshold *uint32) {
 	// filters change which files get transferred
-	cpCmd.PersistentFlags().BoolVar(&raw.followSymlinks, "follow-symlinks", false, "Follow symbolic links when uploading from local file system.")
-	cpCmd.PersistentFlags().StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Include only those files modified before or on the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. As of AzCopy 10.7, this flag applies only to files, not folders, so folder properties won't be copied when using this flag with --preserve-smb-info or --preserve-smb-permissions.")
-	cpCmd.PersistentFlags().StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Include only those files modified on or after the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. As of AzCopy 10.5, this flag applies only to files, not folders, so folder properties won't be copied when using this flag with --preserve-smb-info or --preserve-smb-permissions.")
-	cpCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only these files when copying. "+
-		"This option supports wildcard characters (*). Separate files by using a ';'.")
-	cpCmd.PersistentFlags().StringVar(&raw.includePath, "include-path", "", "Include only these paths when copying. "+
-		"This option does not support wildcard characters (*). Checks relative path prefix (For example: myFolder;myFolder/subDirName/file.pdf).")
-	cpCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when copying. "+ // Currently, only exclude-path is supported alongside account traversal.
-		"This option does not support wildcard characters (*). Checks relative path prefix(For example: myFolder;myFolder/subDirName/file.pdf). When used in combination with account traversal, paths do not include the container name.")
-	cpCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include only the relative path of the files that align with regular expressions. Separate regular expressions with ';'.")
-	cpCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude all the relative path of the files that align with regular expressions. Separate regular expressions with ';'.")
+	flags.BoolVar(&raw.followSymlinks, "follow-symlinks", false, "Follow symbolic links when uploading from local file system. "+
+		"Links that lead back to a folder already being transferred are detected, and not followed again. "+
+		"By default, symbolic links are skipped.")
+	flags.BoolVar(&raw.restrictSymlinksToRoot, "restrict-symlinks-to-root", true, "When following symbolic links, "+
+		"don't follow those whose targets are outside of the source folder, so that a link can't pull files from elsewhere on the machine into the transfer. "+
+		"Each such link is skipped with a warning. Set to false to follow links wherever they lead.")
+	flags.StringVar(&raw.trailingDot, "trailing-dot", common.ETrailingDotOption.Enable().String(), "Says what happens to names that end with a dot, like 'file.' or 'dir./child', "+
+		"when the source or the destination is Azure Files. 'Enable' (the default) keeps the dots, by sending the x-ms-allow-trailing-dot header with the requests for such names (and only those). "+
+		"'Disable' removes them from the names written to the destination; a name that would then clash with another is skipped with a warning, rather than overwriting it.")
+	flags.BoolVar(&raw.preserveSymlinks, "preserve-symlinks", false, "Preserve symbolic links, rather than skipping or following them. "+
+		"On upload, each link is stored as an empty blob, whose metadata records that it is a link, and what it points to. "+
+		"On download, such blobs are recreated as symbolic links, except that a link whose target is an absolute path, or leads outside of the destination, fails rather than being created. "+
+		"Only supported between the local file system and Blob. Cannot be combined with --follow-symlinks.")
+	flags.StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Include only those files modified before or on the given date/time. The value should be in ISO8601 format, or relative to the start of the job, as a sign, a whole number and a unit of d, h or m (e.g. '-7d' for the last week). If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. As of AzCopy 10.7, this flag applies only to files, not folders, so folder properties won't be copied when using this flag with --preserve-smb-info or --preserve-smb-permissions.")
+	flags.StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Include only those files modified on or after the given date/time. The value should be in ISO8601 format, or relative to the start of the job, as a sign, a whole number and a unit of d, h or m (e.g. '-7d' for the last week). If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. As of AzCopy 10.5, this flag applies only to files, not folders, so folder properties won't be copied when using this flag with --preserve-smb-info or --preserve-smb-permissions.")
+	flags.StringVar(&raw.minSize, "min-size", "", "Include only those files whose size is greater than or equal to the given value. "+
+		"The value is either a number of bytes, or "+sizeStringDescription+". This flag does not apply to folders.")
+	flags.StringVar(&raw.maxSize, "max-size", "", "Include only those files whose size is less than or equal to the given value. "+
+		"The value is either a number of bytes, or "+sizeStringDescription+". This flag does not apply to folders.")
+	flags.UintVar(&raw.minDepth, "min-depth", 0, "Include only those files and folders that are at least this deep beneath the source. "+
+		"Files directly in the source folder are at depth 1, files in its subfolders at depth 2, and so on. "+
+		"The source folder itself is at depth 0, so its properties are not transferred when this flag is used. Folders are filtered by their own depth, like files.")
+	flags.UintVar(&raw.maxDepth, "max-depth", 0, "Include only those files and folders that are at most this deep beneath the source, counted as for --min-depth. "+
+		"0, the default, means no limit. Only has an effect with --recursive, since otherwise only depth 1 is scanned. "+
+		"Local folders, Azure Files directories and blob virtual directories that are this deep are not listed at all "+
+		"(for blobs, unless AZCOPY_DISABLE_HIERARCHICAL_SCAN is set, since a flat listing can't skip anything).")
+	flags.StringVar(&raw.include, "include-pattern", "", "Include only these files when copying. "+
+		"This option supports wildcard characters (*). Separate files by using a ';'."+bracePatternHelp+filterFileHelp)
+	flags.StringVar(&raw.includePath, "include-path", "", "Include only these paths when copying. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix (For example: myFolder;myFolder/subDirName/file.pdf). "+
+		"When used in combination with account traversal, or with a wildcard in the container name, the paths do not include the container name, and are matched within each container."+filterFileHelp)
+	flags.StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when copying. "+ // Currently, only exclude-path is supported alongside account traversal.
+		"This option does not support wildcard characters (*). Checks relative path prefix(For example: myFolder;myFolder/subDirName/file.pdf). When used in combination with account traversal, paths do not include the container name."+filterFileHelp)
+	flags.StringVar(&raw.includeRegex, "include-regex", "", "Include only the relative path of the files that align with regular expressions. Separate regular expressions with ';'. "+
+		"Each expression must match the whole relative path, as if it started with '^' and ended with '$'. To match part of a path, add '.*' for the rest of it (For example: .*\\.pdf, or .*draft.* for 'draft' anywhere in the path). Earlier versions let an expression match any part of the path. "+
+		"When used together with --include-pattern, a file is included if it matches either flag.")
+	flags.StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude all the relative path of the files that align with regular expressions. Separate regular expressions with ';'. "+
+		"Each expression must match the whole relative path, as if it started with '^' and ended with '$'. To match part of a path, add '.*' for the rest of it (For example: .*\\.pdf, or .*draft.* for 'draft' anywhere in the path). Earlier versions let an expression match any part of the path.")
+	flags.StringVar(&raw.includeExt, "include-ext", "", "Include only the files with these extensions, separated by ';', with or without the leading dot (For example: jpg;png;gif). "+
+		"Shorthand for --include-pattern *.jpg;*.png;*.gif, except that extensions are always matched case-insensitively. Files with no extension are not included. "+
+		"When used together with --include-pattern or --include-regex, a file is included if it matches any of these flags.")
+	flags.StringVar(&raw.excludeExt, "exclude-ext", "", "Exclude the files with these extensions, separated by ';', with or without the leading dot (For example: tmp;log). "+
+		"Extensions are always matched case-insensitively. Files with no extension are not excluded.")
+	flags.StringVar(&raw.includeMetadata, "include-metadata", "", "Include only the source files that have any of the given metadata, as key=value pairs separated by ';' (For example: archive=true;tier=arch*). "+
+		"Keys are case-insensitive. Values support wildcard characters (*). Only supported when the source is Blob or Azure Files. This flag does not apply to folders.")
+	flags.StringVar(&raw.excludeMetadata, "exclude-metadata", "", "Exclude the source files that have any of the given metadata, as key=value pairs separated by ';'. "+
+		"Keys are case-insensitive. Values support wildcard characters (*). Only supported when the source is Blob or Azure Files. This flag does not apply to folders.")
+	flags.StringVar(&raw.includeContentType, "include-content-type", "", "Include only the source files whose Content-Type matches any of the given types, separated by ';' (For example: image/*;application/pdf). "+
+		"Types may be exact, or contain wildcard characters (*), which don't match the '/'. They are case-insensitive, and parameters such as '; charset=utf-8' are ignored. "+
+		"Files with no content type match only '*'. Only supported when the source is Blob or Azure Files. This flag does not apply to folders.")
+	flags.StringVar(&raw.excludeContentType, "exclude-content-type", "", "Exclude the source files whose Content-Type matches any of the given types, separated by ';', "+
+		"matched as for --include-content-type. Only supported when the source is Blob or Azure Files. This flag does not apply to folders.")
+	flags.BoolVar(&raw.getPropertiesForMetadata, "get-properties-for-metadata", false, "Allow --include-metadata, --exclude-metadata, --include-content-type and --exclude-content-type when copying from Azure Files to another service. "+
+		"Listing a share returns neither metadata nor content types, so AzCopy gets the properties of every file, which takes one extra request per file. "+
+		"These requests are made during scanning, as many at once as the AZCOPY_CONCURRENT_SCAN environment variable allows, so lower it to reduce the load on the share. "+
+		"Not needed for Blob sources, whose listings include metadata and content types, or for downloads from Azure Files, which get the properties anyway.")
+	flags.StringVar(&raw.s3Endpoint, "s3-endpoint", "", "The URL of an S3-compatible service other than AWS, such as MinIO or Wasabi (For example: https://s3.wasabisys.com or http://localhost:9000). "+
+		"Source URLs on its host are then read as S3 URLs, and are authenticated with AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY as usual. "+
+		"Use http:// for a service that is not reached over HTTPS. No region is needed; services that ignore the region are sent the default of AWS. "+
+		"For a copy to Azure, the service must be reachable from the internet, since Azure reads each object from it directly. Pass the same flag to jobs resume.")
+	flags.BoolVar(&raw.s3ForcePathStyle, "s3-force-path-style", false, "Address buckets of the --s3-endpoint service in the path of URLs (http://host/bucket/key), rather than as a subdomain of its host. "+
+		"Needed by most self-hosted services, whose buckets have no DNS names of their own.")
+	flags.BoolVar(&raw.s3SkipSSLVerify, "s3-skip-ssl-verify", false, "Accept any certificate from the --s3-endpoint service, such as the self-signed one of a self-hosted service. "+
+		"Only AzCopy's own requests to the service skip the verification, so use it only for services that you trust.")
 	// This flag is implemented only for Storage Explorer.
-	cpCmd.PersistentFlags().StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of text file which has the list of only files to be copied.")
-	cpCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude these files when copying. This option supports wildcard characters (*)")
-	cpCmd.PersistentFlags().StringVar(&raw.forceWrite, "overwrite", "true", "Overwrite the conflicting files and blobs at the destination if this flag is set to true. (default 'true') Possible values include 'true', 'false', 'prompt', and 'ifSourceNewer'. For destinations that support folders, conflicting folder-level properties will be overwritten this flag is 'true' or if a positive response is provided to the prompt.")
-	cpCmd.PersistentFlags().BoolVar(&raw.autoDecompress, "decompress", false, "Automatically decompress files when downloading, if their content-encoding indicates that they are compressed. The supported content-encoding values are 'gzip' and 'deflate'. File extensions of '.gz'/'.gzip' or '.zz' aren't necessary, but will be removed if present.")
-	cpCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when uploading from local file system.")
-	cpCmd.PersistentFlags().StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: LocalBlob, BlobLocal, LocalBlobFS. Piping: BlobPipe, PipeBlob")
-	cpCmd.PersistentFlags().StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when copying blobs from the container "+
+	flags.StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of a text file which lists the files and folders to be copied, one path per line. "+
+		"The paths are relative to the source, and should NOT be URL-encoded. Folders are copied with their contents when --recursive is true. "+
+		"Blank lines, and lines that start with #, are ignored. A listed path that cannot be found is reported as a warning, and the rest of the list is still copied.")
+	flags.StringVar(&raw.pathMap, "path-map", "", "Defines the location of a JSON file that gives files and folders a different path at the destination. "+
+		"It maps each path relative to the source, with '/' as the separator, to its path relative to the destination, or to \"\" to skip it (e.g. {\"2023/report.pdf\": \"reports/2023.pdf\"}). "+
+		"Paths that are not in the file keep their source path. If two files are given the same destination path, the second is skipped with a warning.")
+	flags.StringVar(&raw.listOfUrls, "list-of-urls", "", "Defines the location of a text file which lists the blobs to be copied, one full URL per line, "+
+		"in place of the source argument (e.g. azcopy copy --list-of-urls urls.txt [destination]). The URLs can be in any container of any account. "+
+		"Each URL is used with its own SAS, if it has one. Otherwise, the blob must be public. A URL may also be a virtual directory, which is copied with its contents when --recursive is true. "+
+		"Blank lines, and lines that start with #, are ignored. A URL that cannot be listed is reported as a failed transfer, and the rest of the list is still copied. "+
+		"Such failures are not part of the job's plan, so they are not retried by 'azcopy jobs resume'.")
+	flags.StringVar(&raw.keepFromUrl, "keep-from-url", common.EKeepFromUrl.BlobName().String(), "Which part of each URL of --list-of-urls becomes the blob's path at the destination. "+
+		"'BlobName' (the default) keeps the name of the blob within its container, including its virtual directories. "+
+		"'ContainerAndBlobName' puts each blob under a folder named after its container, so that like-named blobs from different containers do not overwrite each other. "+
+		"'FileName' keeps only the last segment of the blob's name, so that all the blobs land in the same folder.")
+	flags.StringVar(&raw.exclude, "exclude-pattern", "", "Exclude these files when copying. This option supports wildcard characters (*)."+bracePatternHelp+filterFileHelp)
+	flags.BoolVar(&raw.ignoreCasePattern, "ignore-case-pattern", false, "Match --include-pattern and --exclude-pattern case-insensitively (e.g. *.TXT then also matches file.txt). By default, patterns are case-sensitive. "+
+		"Note that this may select several files whose names differ only in case, which will overwrite each other if the destination is case-insensitive (e.g. Azure Files, or a local Windows or macOS file system).")
+	flags.StringVar(&raw.forceWrite, "overwrite", "true", "Overwrite the conflicting files and blobs at the destination if this flag is set to true. (default 'true') Possible values include 'true', 'false', 'prompt', and 'ifSourceNewer'. With 'ifSourceNewer', an existing file is overwritten only if the source was last modified after the destination. For destinations that support folders, conflicting folder-level properties will be overwritten this flag is 'true' or if a positive response is provided to the prompt.")
+	flags.BoolVar(&raw.autoDecompress, "decompress", false, "Automatically decompress files when downloading, if their content-encoding indicates that they are compressed. The supported content-encoding values are 'gzip' and 'deflate'; files with any other content-encoding are downloaded as they are, with a warning. Decompression happens as the file is written, without holding it in memory. File extensions of '.gz'/'.gzip' or '.zz' aren't necessary, but will be removed if present.")
+	flags.BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when uploading from local file system.")
+	flags.StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: LocalBlob, BlobLocal, LocalBlobFS. Piping: BlobPipe, PipeBlob")
+	flags.StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when copying blobs from the container "+
 		"or the account. Use of this flag is not applicable for copying data from non azure-service to service. More than one blob should be separated by ';'. ")
+	flags.Uint64Var(&raw.maxFileCount, "max-file-count", 0, "Fails the job, before it goes any further, as soon as more than this many files have been found to transfer. "+
+		"Guards against copying far more than intended, e.g. a whole account by mistake. 0 means no limit (default 0).")
+	flags.BoolVar(&raw.skipExistingWithSameSize, "skip-existing-with-same-size", false, "Skip the files that already exist at the destination with the same size as the source, e.g. to cheaply carry on with a large copy that was interrupted. "+
+		"Each file is looked up at the destination just before it is scheduled, and nothing but the size is compared: not the last modified times, nor the content. "+
+		"So a file whose content has changed, but whose size has not, is skipped too. Use the sync command when that matters.")
+	flags.DurationVar(&raw.perTransferTimeout, "per-transfer-timeout", 0, "Fails the transfer of a file that has made no progress for this long (e.g. 10m), so that one stuck file does not hold up the end of the job. "+
+		"This is not a limit on how long a file may take: the timeout starts again whenever some of the file's data is sent or received, and time spent held back by cap-mbps or auto-tune-throughput does not count. "+
+		"A file that times out is reported as failed, and is retried by 'azcopy jobs resume'. 0 means no timeout (default 0).")
+	flags.StringVar(&raw.manifestOutput, "manifest-output", "", "Writes a manifest of the files that were transferred to this path when the job ends, even if some of them failed. "+
+		"The manifest is in JSON Lines format, with one line per file, giving its Source, Destination, Size and, if it is known, its ContentMD5. "+
+		"The files that failed are listed in a second manifest alongside it, with the reason for each failure. E.g. manifest.jsonl and manifest.failed.jsonl. "+
+		"Folders are not listed. A resumed job does not write a manifest.")
+	flags.Uint32Var(&raw.maxErrors, "max-errors", 0, "Cancels the job once more than this many transfers have failed, rather than carrying on with a job that is failing on most of its files. "+
+		"The transfers in progress are stopped, the summary and any manifest are still written, and AzCopy exits with code 4. "+
+		"A resumed job has no limit. 0 means no limit (default 0).")
+	flags.StringVar(&raw.excludeContainer, "exclude-container", "", "Exclude these containers when copying from a whole Blob account. Separate names with ';'. "+
+		"Wildcards (*) are supported, e.g. 'logs*;backup'. Excluded containers are never listed, so nothing in them is considered.")
 	// options change how the transfers are performed
-	cpCmd.PersistentFlags().Float64Var(&raw.blockSizeMB, "block-size-mb", 0, "Use this block size (specified in MiB) when uploading to Azure Storage, and downloading from Azure Storage. The default value is automatically calculated based on file size. Decimal fractions are allowed (For example: 0.25).")
-	cpCmd.PersistentFlags().StringVar(&raw.blobType, "blob-type", "Detect", "Defines the type of blob at the destination. This is used for uploading blobs and when copying between accounts (default 'Detect'). Valid values include 'Detect', 'BlockBlob', 'PageBlob', and 'AppendBlob'. "+
+	flags.Float64Var(&raw.blockSizeMB, "block-size-mb", 0, "Use this block size (specified in MiB) when uploading to Azure Storage, and downloading from Azure Storage. The default value is automatically calculated based on file size. Decimal fractions are allowed (For example: 0.25). "+
+		"When downloading, each block is a ranged request of its own, so the blocks of even a single large file are downloaded in parallel, as many at once as AZCOPY_CONCURRENCY_VALUE allows.")
+	flags.StringVar(&raw.blobType, "blob-type", "Detect", "Defines the type of blob at the destination. This is used for uploading blobs and when copying between accounts (default 'Detect'). Valid values include 'Detect', 'BlockBlob', 'PageBlob', and 'AppendBlob'. "+
 		"When copying between accounts, a value of 'Detect' causes AzCopy to use the type of source blob to determine the type of the destination blob. When uploading a file, 'Detect' determines if the file is a VHD or a VHDX file based on the file extension. If the file is either a VHD or VHDX file, AzCopy treats the file as a page blob.")
-	cpCmd.PersistentFlags().StringVar(&raw.blockBlobTier, "block-blob-tier", "None", "upload block blob to Azure Storage using this blob tier.")
-	cpCmd.PersistentFlags().StringVar(&raw.pageBlobTier, "page-blob-tier", "None", "Upload page blob to Azure Storage using this blob tier. (default 'None').")
-	cpCmd.PersistentFlags().StringVar(&raw.metadata, "metadata", "", "Upload to Azure Storage with these key-value pairs as metadata.")
-	cpCmd.PersistentFlags().StringVar(&raw.contentType, "content-type", "", "Specifies the content type of the file. Implies no-guess-mime-type. Returned on download.")
-	cpCmd.PersistentFlags().StringVar(&raw.contentEncoding, "content-encoding", "", "Set the content-encoding header. Returned on download.")
-	cpCmd.PersistentFlags().StringVar(&raw.contentDisposition, "content-disposition", "", "Set the content-disposition header. Returned on download.")
-	cpCmd.PersistentFlags().StringVar(&raw.contentLanguage, "content-language", "", "Set the content-language header. Returned on download.")
-	cpCmd.PersistentFlags().StringVar(&raw.cacheControl, "cache-control", "", "Set the cache-control header. Returned on download.")
-	cpCmd.PersistentFlags().BoolVar(&raw.noGuessMimeType, "no-guess-mime-type", false, "Prevents AzCopy from detecting the content-type based on the extension or content of the file.")
-	cpCmd.PersistentFlags().BoolVar(&raw.preserveLastModifiedTime, "preserve-last-modified-time", false, "Only available when destination is file system.")
-	cpCmd.PersistentFlags().BoolVar(&raw.preserveSMBPermissions, "preserve-smb-permissions", false, "False by default. Preserves SMB ACLs between aware resources (Windows and Azure Files). For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
-	cpCmd.PersistentFlags().BoolVar(&raw.asSubdir, "as-subdir", true, "True by default. Places folder sources as subdirectories under the destination.")
-	cpCmd.PersistentFlags().BoolVar(&raw.preserveOwner, common.PreserveOwnerFlagName, common.PreserveOwnerDefault, "Only has an effect in downloads, and only when --preserve-smb-permissions is used. If true (the default), the file Owner and Group are preserved in downloads. If set to false, --preserve-smb-permissions will still preserve ACLs but Owner and Group will be based on the user running AzCopy")
-	cpCmd.PersistentFlags().BoolVar(&raw.preserveSMBInfo, "preserve-smb-info", true, "For SMB-aware locations, flag will be set to true by default. Preserves SMB property info (last write time, creation time, attribute bits) between SMB-aware resources (Windows and Azure Files). Only the attribute bits supported by Azure Files will be transferred; any others will be ignored. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern). The info transferred for folders is the same as that for files, except for Last Write Time which is never preserved for folders.")
-	cpCmd.PersistentFlags().BoolVar(&raw.preservePOSIXProperties, "preserve-posix-properties", false, "'Preserves' property info gleaned from stat or statx into object metadata.")
-	cpCmd.PersistentFlags().BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When overwriting an existing file on Windows or Azure Files, force the overwrite to work even if the existing file has its read-only attribute set")
-	cpCmd.PersistentFlags().BoolVar(&raw.backupMode, common.BackupModeFlagName, false, "Activates Windows' SeBackupPrivilege for uploads, or SeRestorePrivilege for downloads, to allow AzCopy to see read all files, regardless of their file system permissions, and to restore all permissions. Requires that the account running AzCopy already has these permissions (e.g. has Administrator rights or is a member of the 'Backup Operators' group). All this flag does is activate privileges that the account already has")
-	cpCmd.PersistentFlags().BoolVar(&raw.putMd5, "put-md5", false, "Create an MD5 hash of each file, and save the hash as the Content-MD5 property of the destination blob or file. (By default the hash is NOT created.) Only available when uploading.")
-	cpCmd.PersistentFlags().StringVar(&raw.md5ValidationOption, "check-md5", common.DefaultHashValidationOption.String(), "Specifies how strictly MD5 hashes should be validated when downloading. Only available when downloading. Available options: NoCheck, LogOnly, FailIfDifferent, FailIfDifferentOrMissing. (default 'FailIfDifferent')")
-	cpCmd.PersistentFlags().StringVar(&raw.includeFileAttributes, "include-attributes", "", "(Windows only) Include files whose attributes match the attribute list. For example: A;S;R")
-	cpCmd.PersistentFlags().StringVar(&raw.excludeFileAttributes, "exclude-attributes", "", "(Windows only) Exclude files whose attributes match the attribute list. For example: A;S;R")
-	cpCmd.PersistentFlags().BoolVar(&raw.CheckLength, "check-length", true, "Check the length of a file on the destination after the transfer. If there is a mismatch between source and destination, the transfer is marked as failed.")
-	cpCmd.PersistentFlags().BoolVar(&raw.s2sPreserveProperties, "s2s-preserve-properties", true, "Preserve full properties during service to service copy. "+
+	flags.StringVar(&raw.blobTypeByPattern, "blob-type-by-pattern", "", "Chooses the type of blob by the name of the uploaded file, as a list of pattern=BlobType pairs. For example: *.log=AppendBlob;*.tar=BlockBlob. "+
+		"Patterns are matched against file names, as in --include-pattern, and the first match wins. Files that match no pattern are uploaded as --blob-type says. Only available when uploading to Blob storage.")
+	flags.StringVar(&raw.blockBlobTier, "block-blob-tier", "None", "upload block blob to Azure Storage using this blob tier. "+
+		"The tier is set as part of the upload, and takes precedence over any tier preserved from the source by --s2s-preserve-access-tier.")
+	flags.StringVar(&raw.pageBlobTier, "page-blob-tier", "None", "Upload page blob to Azure Storage using this blob tier. (default 'None').")
+	flags.StringVar(&raw.metadata, "metadata", "", "Upload to Azure Storage with these key-value pairs as metadata.")
+	flags.StringVar(&raw.contentType, "content-type", "", "Specifies the content type of the file. Implies no-guess-mime-type. Returned on download.")
+	flags.StringVar(&raw.contentEncoding, "content-encoding", "", "Set the content-encoding header. Returned on download.")
+	flags.StringVar(&raw.contentDisposition, "content-disposition", "", "Set the content-disposition header. Returned on download.")
+	flags.StringVar(&raw.contentLanguage, "content-language", "", "Set the content-language header. Returned on download.")
+	flags.StringVar(&raw.cacheControl, "cache-control", "", "Set the cache-control header. Returned on download.")
+	flags.BoolVar(&raw.noGuessMimeType, "no-guess-mime-type", false, "Prevents AzCopy from detecting the content-type based on the extension or content of the file.")
+	flags.BoolVar(&raw.noGuessMimeTypeFromExtension, "no-guess-mime-type-from-extension", false, "Prevents AzCopy from detecting the content-type based on the extension of the file (including through the mapping in "+
+		common.EEnvironmentVariable.MimeMapping().Name+"), so that it is only detected from the content of the file. content-type, if given, takes precedence over any detection.")
+	flags.StringVar(&raw.contentTypeMapFile, "content-type-map", "", "When uploading, sets the content type of each file from its extension, as given by this file, "+
+		"ahead of any detection. A .csv file has an extension,content-type pair on each line (e.g. .md,text/markdown); any other file is a JSON object such as {\".md\": \"text/markdown\"}. "+
+		"Files whose extensions aren't listed get --default-content-type, or their detected content type if that isn't given.")
+	flags.StringVar(&raw.defaultContentType, "default-content-type", "", "The content type of files whose extensions aren't in --content-type-map. "+
+		"By default, their content type is detected as usual.")
+	flags.BoolVar(&raw.preserveLastModifiedTime, "preserve-last-modified-time", false, "Sets the last modified time of each downloaded file to that of its source. "+
+		"Only available when destination is file system. The file system may round the time to its own granularity (for example 2 seconds on FAT). If the time cannot be set, the transfer still succeeds, and a warning is logged.")
+	flags.BoolVar(&raw.preserveSMBPermissions, "preserve-smb-permissions", false, "False by default. Preserves SMB ACLs between aware resources (Windows and Azure Files). For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
+	flags.BoolVar(&raw.asSubdir, "as-subdir", true, "True by default. Places folder sources as subdirectories under the destination.")
+	flags.BoolVar(&raw.stripTopDir, "strip-top-dir", false, "Whether to leave the name of the source's top directory out of the destination paths. "+
+		"When this flag is not given, it is left out only when the source ends with /*, so that dir/* copies what is in dir, and dir copies dir itself. "+
+		"When it is given, as --strip-top-dir (meaning true) or --strip-top-dir=false, it takes precedence over that convention: true puts what is in the source directory straight under the destination even without /*, "+
+		"and false puts it under a directory named after the source directory even with /*. It only changes the destination paths: "+
+		"a source ending with /* still needs --recursive to copy its subdirectories. Cannot be combined with --as-subdir=false when false.")
+	flags.BoolVar(&raw.preserveOwner, common.PreserveOwnerFlagName, common.PreserveOwnerDefault, "Only has an effect in downloads, and only when --preserve-smb-permissions is used. If true (the default), the file Owner and Group are preserved in downloads. If set to false, --preserve-smb-permissions will still preserve ACLs but Owner and Group will be based on the user running AzCopy")
+	flags.BoolVar(&raw.preserveSMBInfo, "preserve-smb-info", true, "For SMB-aware locations, flag will be set to true by default. Preserves SMB property info (last write time, creation time, attribute bits) between SMB-aware resources (Windows and Azure Files). Only the attribute bits supported by Azure Files will be transferred; any others will be ignored. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern). The info transferred for folders is the same as that for files, except for Last Write Time which is never preserved for folders.")
+	flags.BoolVar(&raw.preservePOSIXProperties, "preserve-posix-properties", false, "'Preserves' property info gleaned from stat or statx into object metadata. When downloading to Linux, the mode, owner and group are restored from that metadata. Files whose properties can't be restored (e.g. for lack of permission to change their owner) are logged as warnings and counted in the job summary, but are not failures.")
+	flags.BoolVar(&raw.preserveXattrs, "preserve-xattrs", false, "Keeps the extended attributes in the 'user.' namespace of local files in the metadata of their blobs, and restores them when downloading to Linux. "+
+		"Other namespaces, such as 'security.' and 'trusted.', are not kept. A file whose extended attributes would not fit in the 8KiB that blob metadata may take is uploaded without them, with a warning in the log.")
+	flags.BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When overwriting an existing file on Windows or Azure Files, force the overwrite to work even if the existing file has its read-only attribute set")
+	flags.BoolVar(&raw.followSourceRedirects, "follow-source-redirects", false, "Lets downloads follow redirects from their source, for example from a CDN endpoint to the storage account behind it. "+
+		"At most 5 redirects are followed for each read, and ranged reads keep their range, even when they are redirected to another host. Without this flag, redirects are handled as they were before.")
+	flags.BoolVar(&raw.downloadRangesInPlace, "download-ranges-in-place", false, "Writes each range (block) of a downloaded file to its place in the file as soon as it arrives, instead of holding it in memory until the ranges before it have been written. "+
+		"The file is created sparse where the OS and file system support it, so that nothing has to be filled in ahead of a range. This lets the ranges of a single large file be downloaded further apart, as many at once as AZCOPY_CONCURRENCY_VALUE allows, without waiting on the slowest of them. "+
+		"Files whose MD5 hash is to be checked (see --check-md5) are still written in order, since the hash has to be computed in order, and so are files that are decompressed or written into an archive.")
+	flags.BoolVar(&raw.backupMode, common.BackupModeFlagName, false, "Activates Windows' SeBackupPrivilege for uploads, or SeRestorePrivilege for downloads, to allow AzCopy to see read all files, regardless of their file system permissions, and to restore all permissions. Requires that the account running AzCopy already has these permissions (e.g. has Administrator rights or is a member of the 'Backup Operators' group). All this flag does is activate privileges that the account already has")
+	flags.BoolVar(&raw.putMd5, "put-md5", false, "Create an MD5 hash of each file, and save the hash as the Content-MD5 property of the destination blob or file. (By default the hash is NOT created.) Only available when uploading.")
+	flags.StringVar(&raw.md5ValidationOption, "check-md5", common.DefaultHashValidationOption.String(), "Specifies how strictly MD5 hashes should be validated when downloading. Only available when downloading. Available options: NoCheck, LogOnly, FailIfDifferent, FailIfDifferentOrMissing. (default 'FailIfDifferent')")
+	flags.StringVar(&raw.includeFileAttributes, "include-attributes", "", "(Windows only) Include files that have any of the attributes in the attribute list. For example: A;S;R, or ASR")
+	flags.StringVar(&raw.excludeFileAttributes, "exclude-attributes", "", "(Windows only) Exclude files that have any of the attributes in the attribute list. For example: A;S;R, or ASR. "+
+		"When used with include-attributes, a file must match the include list and not match this one, so exclusion takes precedence.")
+	flags.BoolVar(&raw.CheckLength, "check-length", true, "Check the length of a file on the destination after the transfer. If there is a mismatch between source and destination, the transfer is marked as failed.")
+	flags.BoolVar(&raw.s2sPreserveProperties, "s2s-preserve-properties", true, "Preserve full properties during service to service copy. "+
 		"For AWS S3 and Azure File non-single file source, the list operation doesn't return full properties of objects and files. To preserve full properties, AzCopy needs to send one additional request per object or file.")
-	cpCmd.PersistentFlags().BoolVar(&raw.s2sPreserveAccessTier, "s2s-preserve-access-tier", true, "Preserve access tier during service to service copy. "+
+	flags.BoolVar(&raw.s2sPreserveAccessTier, "s2s-preserve-access-tier", true, "Preserve access tier during service to service copy. "+
 		"Please refer to [Azure Blob storage: hot, cool, and archive access tiers](https://docs.microsoft.com/azure/storage/blobs/storage-blob-storage-tiers) to ensure destination storage account supports setting access tier. "+
-		"In the cases that setting access tier is not supported, please use s2sPreserveAccessTier=false to bypass copying access tier. (default true). ")
-	cpCmd.PersistentFlags().BoolVar(&raw.s2sSourceChangeValidation, "s2s-detect-source-changed", false, "Detect if the source file/blob changes while it is being read. (This parameter only applies to service to service copies, because the corresponding check is permanently enabled for uploads and downloads.)")
-	cpCmd.PersistentFlags().StringVar(&raw.s2sInvalidMetadataHandleOption, "s2s-handle-invalid-metadata", common.DefaultInvalidMetadataHandleOption.String(), "Specifies how invalid metadata keys are handled. Available options: ExcludeIfInvalid, FailIfInvalid, RenameIfInvalid. (default 'ExcludeIfInvalid').")
-	cpCmd.PersistentFlags().StringVar(&raw.listOfVersionIDs, "list-of-versions", "", "Specifies a file where each version id is listed on a separate line. Ensure that the source must point to a single blob and all the version ids specified in the file using this flag must belong to the source blob only. AzCopy will download the specified versions in the destination folder provided.")
-	cpCmd.PersistentFlags().StringVar(&raw.blobTags, "blob-tags", "", "Set tags on blobs to categorize data in your storage account")
-	cpCmd.PersistentFlags().BoolVar(&raw.s2sPreserveBlobTags, "s2s-preserve-blob-tags", false, "Preserve index tags during service to service transfer from one blob storage to another")
-	cpCmd.PersistentFlags().BoolVar(&raw.includeDirectoryStubs, "include-directory-stub", false, "False by default to ignore directory stubs. Directory stubs are blobs with metadata 'hdi_isfolder:true'. Setting value to true will preserve directory stubs during transfers.")
-	cpCmd.PersistentFlags().BoolVar(&raw.disableAutoDecoding, "disable-auto-decoding", false, "False by default to enable automatic decoding of illegal chars on Windows. Can be set to true to disable automatic decoding.")
-	cpCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the file paths that would be copied by this command. This flag does not copy the actual files.")
+		"In the cases that setting access tier is not supported, please use s2sPreserveAccessTier=false to bypass copying access tier. (default true). "+
+		"Blobs in the Archive tier can't be copied, whether or not their tier is preserved, until they have been rehydrated to the Hot or Cool tier (e.g. with azcopy set-properties).")
+	flags.BoolVar(&raw.s2sSourceChangeValidation, "s2s-detect-source-changed", false, "Detect if the source file/blob changes while it is being read. (This parameter only applies to service to service copies, because the corresponding check is permanently enabled for uploads and downloads.)")
+	flags.StringVar(&raw.s2sInvalidMetadataHandleOption, "s2s-handle-invalid-metadata", common.DefaultInvalidMetadataHandleOption.String(), "Specifies how invalid metadata keys are handled. Available options: ExcludeIfInvalid, FailIfInvalid, RenameIfInvalid. (default 'ExcludeIfInvalid').")
+	flags.StringVar(&raw.listOfVersionIDs, "list-of-versions", "", "Specifies a file where each version id is listed on a separate line. Ensure that the source must point to a single blob and all the version ids specified in the file using this flag must belong to the source blob only. AzCopy will download the specified versions in the destination folder provided.")
+	flags.BoolVar(&raw.listVersions, "list-versions", false, "Copy every version of each matching blob, rather than only its current version. "+
+		"Each version is written where the blob would have gone, with its version id (with ':' replaced by '-') and a '-' in front of its name, so that versions don't overwrite each other. "+
+		"Soft-deleted versions are not copied; undelete them first if they are needed. Only supported when the source is Blob. "+
+		"To copy just one version, leave out this flag and add ?versionid=<id> to the source URL instead.")
+	flags.StringVar(&raw.changeToken, "change-token", "", "Once every file has been transferred, save a change token in this file, recording how recently the newest file transferred was modified. "+
+		"Give the token to a later copy from the same source, with --since-change-token, to transfer only the files that have changed since. "+
+		"If any transfer fails, or the job is cancelled, the file is left as it was. The same file can be given to both flags, to copy only what has changed since the last time, every time.")
+	flags.StringVar(&raw.sinceChangeToken, "since-change-token", "", "Transfer only the files that have changed since the copy that saved the change token in this file (see --change-token). "+
+		"Unlike --include-after, this compares only the last modified times given by the source, so the clock of the machine running AzCopy doesn't matter. "+
+		"If the file doesn't exist yet, or its token is for a different source, every file is transferred. Applies only to files, not folders. "+
+		"If no file has changed, the copy succeeds without transferring anything, and --change-token is saved all the same.")
+	flags.UintVar(&raw.maxVersionsPerBlob, "max-versions-per-blob", 0, "With --list-versions, copy only the newest this many versions of each blob. "+
+		"Versions are ordered by the time in their version id, when they were created, not by their last modified time. The current version counts as one of them, and is always copied. "+
+		"All versions are listed before any are copied, so that the newest are known; for very many blobs this needs more memory. 0 (the default) copies every version.")
+	flags.BoolVar(&raw.snapshotSourceFirst, "snapshot-source-first", false, "Snapshot each source blob just before copying it, copy from the snapshot, and then delete the snapshot, "+
+		"so that writes made to a blob while it is being copied don't leave a mix of old and new data at the destination. Only supported when copying from Blob to Blob. "+
+		"This costs more: each snapshot is an extra write operation, and its deletion another; while a snapshot exists, the source blob's blocks that get overwritten are "+
+		"billed twice and count towards the account's capacity. The source's credential must allow writing (to take the snapshot) and deleting (to remove it). "+
+		"Snapshots are deleted whether or not their transfer works, but one can be left behind if AzCopy is killed, in which case it must be deleted by hand. "+
+		"Blobs that are already a snapshot or a version are copied as they are.")
+	flags.BoolVar(&raw.verifyCrc64, "verify-crc64", false, "Check each block, page or blob uploaded to Azure Blob against the CRC64 that the service computed of what it received, "+
+		"and fail the file's transfer if they differ. This catches corruption on the way to the service even when no MD5 is checked. "+
+		"The service doesn't return a CRC64 for every request, and where there is none the data is accepted as it would have been without this flag. Only supported when uploading to Blob.")
+	flags.BoolVar(&raw.metadataOnly, "metadata-only", false, "Don't copy any data. Instead, give each blob that already exists at the destination the metadata, "+
+		"HTTP headers (content type, encoding and so on) and, with --s2s-preserve-blob-tags, the index tags of its source. "+
+		"The content and Content-MD5 of the destination blobs are left as they are. A source whose destination blob doesn't exist fails, since there is nothing to set its metadata on. "+
+		"Only supported when copying to Blob from another remote location (Blob, Azure Files, S3 or Google Cloud Storage).")
+	flags.StringVar(&raw.ifMatch, "if-match", "", "Only write a destination blob if its ETag is this one. "+
+		"Use * to only replace blobs that already exist. Since every blob has an ETag of its own, a specific ETag is only useful when copying a single file. "+
+		"A blob that doesn't match isn't written, and its transfer fails with the status PreconditionFailed, which the job summary counts separately. Only supported when the destination is Blob.")
+	flags.StringVar(&raw.ifNoneMatch, "if-none-match", "", "Only write a destination blob if its ETag isn't this one. "+
+		"Use * to never replace a blob that already exists, even one that another writer creates while the job runs. "+
+		"A blob that doesn't match isn't written, and its transfer fails with the status PreconditionFailed, which the job summary counts separately. Only supported when the destination is Blob.")
+	flags.StringVar(&raw.immutabilityPolicyUntil, "immutability-policy-until", "", "Give each destination blob an unlocked immutability (time-based retention) policy, "+
+		"so that it can't be changed or deleted until this time. The value is in ISO8601 format, or relative to the start of the job, e.g. '+365d'. "+
+		"The destination container must have version-level immutability support. "+
+		"A blob that already has a policy lasting longer keeps it, so running a copy again never shortens a retention. Only supported when the destination is Blob.")
+	flags.BoolVar(&raw.legalHold, "legal-hold", false, "Put each destination blob under a legal hold, so that it can't be changed or deleted until the hold is cleared. "+
+		"The destination container must have version-level immutability support. Only supported when the destination is Blob.")
+	flags.StringVar(&raw.enumerationTelemetry, "enumeration-telemetry", "", "Records how long each directory took to list, and how many entries it had, to find the directories that stall the enumeration of a huge tree. "+
+		"'log' writes a line for each directory to the scanning log, at the Info level. Any other value is the path of a file that gets a JSON object for each directory, one per line, "+
+		"with the side (source or destination), directory, listing worker, start time, duration in milliseconds, and numbers of entries and of subdirectories. "+
+		"Only enumerations that list directory by directory produce records: local folders, Azure Files, and Blob when it isn't listed flat. Off by default.")
+	flags.StringVar(&raw.sourceCloud, "source-cloud", "", "The Azure cloud that the source is in: 'Public', 'USGov' or 'China'. "+
+		"By default it is inferred from the endpoint suffix of the source URL, so this is only needed for custom domains and other hosts that don't end in one. "+
+		"The cloud decides the Azure Active Directory authority that auto-login gets its token from, when the source is the side that AzCopy authenticates to with OAuth. "+
+		"A service-side copy can't reach from one cloud to another, so to copy between Azure clouds, download with one copy and upload with another.")
+	flags.StringVar(&raw.destinationCloud, "destination-cloud", "", "The Azure cloud that the destination is in: 'Public', 'USGov' or 'China'. "+
+		"By default it is inferred from the endpoint suffix of the destination URL, so this is only needed for custom domains and other hosts that don't end in one. "+
+		"The cloud decides the Azure Active Directory authority that auto-login gets its token from, e.g. that of US Government for an upload from S3 to an account there.")
+	flags.StringVar(&raw.destinationArchive, "destination-archive", common.EArchiveFormat.None().String(), "When downloading, stream all the source files into a single archive file, "+
+		"rather than writing each to a file of its own. The destination is the path of the archive, and each file's entry is named with its path relative to the source. "+
+		"Possible values are 'None' (the default), 'Tar' and 'TarGz' (a gzip-compressed tar). A tar is written one entry at a time, so files take turns: "+
+		"the chunks of each file are still downloaded in parallel, but only one file is in flight at once, "+
+		"so expect lower throughput than a normal download, especially for many small files. Large files are streamed into the archive without being held in memory. "+
+		"A file that fails part way through keeps its entry, padded with zeros, since a tar can't take an entry back; check the job's failed transfers. "+
+		"A job that writes an archive can't be resumed.")
+	flags.StringVar(&raw.sourceArchive, "source-archive", common.EArchiveFormat.None().String(), "When uploading, treat the source as an archive file, "+
+		"and upload each of its entries as if it were a file of its own. Possible values are 'None' (the default), 'Tar' and 'Zip'. "+
+		"Each entry is uploaded under its name in the archive, and include-pattern, exclude-pattern and the other filters apply to those names. "+
+		"Folder entries are handled like folders. The entries are read straight out of the archive, without being extracted to disk. "+
+		"A compressed tar (.tar.gz) isn't supported, since its entries can't be read in parallel; decompress it first. "+
+		"Links and other special entries are skipped. Without --recursive, only the entries at the top of the archive are uploaded.")
+	flags.BoolVar(&raw.flatten, "flatten", false, "Copy every file directly into the destination folder, dropping the folders that it's in at the source, so that 'a/b/report.txt' is copied to 'report.txt'. "+
+		"Folders themselves aren't created at the destination. The filters are applied to the source paths, before flattening. "+
+		"The source folder is still created at the destination, as usual, unless the source ends with /* or --as-subdir=false is given. "+
+		"Since two files may then have the same name, no file is transferred until all of them have been found, and their names are held in memory until then.")
+	flags.StringVar(&raw.flattenCollisions, "flatten-collisions", "", "With --flatten, what to do when files from different folders have the same name. "+
+		"'Fail' (the default) stops before anything is transferred. 'Rename' copies all of them, giving each one after the first (in order of their source paths) "+
+		"a numeric suffix before its extension, as in 'report-2.txt'. 'Overwrite' copies only the most recently modified one, as if each had been copied over the one before.")
+	flags.BoolVar(&raw.preserveEmptyDirectories, "preserve-empty-directories", false, "Keep empty folders, which Blob storage has no place for. "+
+		"When uploading to Blob, a zero-byte marker blob, named by --empty-directory-marker, is written into each folder that is empty at the source, so that the folder can be recreated. "+
+		"When downloading from Blob, the folder that each marker is in is created, and the markers aren't downloaded as files. "+
+		"The markers are written as directory stubs (with the metadata 'hdi_isfolder:true'), so that other listings and copies leave them out. "+
+		"Downloads also create the folders of any other directory stubs, as --include-directory-stub does. Requires --recursive, and isn't applied with file-focused filters, such as --include-pattern.")
+	flags.StringVar(&raw.emptyDirectoryMarker, "empty-directory-marker", "", "With --preserve-empty-directories, the name of the marker blobs "+
+		"(default '"+defaultEmptyDirectoryMarker+"'). Give the same name when downloading as when uploading.")
+	flags.StringVar(&raw.transferOrder, "transfer-order", "", "The order in which files are transferred. "+
+		"'Listed' (the default) transfers them in the order that they are found at the source. 'SmallestFirst' and 'LargestFirst' transfer them by size. "+
+		"'PatternPriority' transfers the files whose names match --priority-pattern first, and then the others. "+
+		"The order is best-effort: it applies to each batch of up to "+strconv.Itoa(NumOfFilesPerDispatchJobPart)+" files, in the order the batches are found, "+
+		"and since files are transferred concurrently, and small files by workers of their own, they can finish in another order. Folders are transferred before the files of their batch.")
+	flags.StringVar(&raw.priorityPattern, "priority-pattern", "", "With --transfer-order=PatternPriority, the names of the files to transfer first, "+
+		"as patterns like those of --include-pattern, separated by semicolons, e.g. '*.json;index.*'."+bracePatternHelp)
+	flags.StringVar(&raw.blobTags, "blob-tags", "", "Set tags on blobs to categorize data in your storage account")
+	flags.BoolVar(&raw.s2sPreserveBlobTags, "s2s-preserve-blob-tags", false, "Preserve index tags during service to service transfer from one blob storage to another. Ignored, with a warning, when the destination is not blob storage")
+	flags.StringVar(&raw.includeBlobTags, "include-blob-tags", "", "Include only the source blobs whose index tags match the given expression, in the syntax of Find Blobs by Tags. "+
+		"For example: \"project\" = 'alpha' AND \"year\" >= '2021'. The blobs are found by the service, so the source must be Blob, "+
+		"and its credential must allow finding blobs by tags (e.g. an account SAS with the 'f' permission, or OAuth).")
+	flags.StringVar(&raw.includeOwner, "include-owner", "", "Include only the files and folders of an ADLS Gen 2 source that are owned by one of these principals, "+
+		"given by object id or by user principal name and separated by semicolons, e.g. '1f2e3d4c-0000-0000-0000-000000000000;someone@contoso.com'. "+
+		"Listings don't include owners, so this costs an extra request (a HEAD) for each file and folder listed, and two if both object ids and names are given. "+
+		"Files are scheduled in no particular order once their owners are known.")
+	flags.StringVar(&raw.excludeOwner, "exclude-owner", "", "Exclude the files and folders of an ADLS Gen 2 source that are owned by one of these principals, "+
+		"given like those of --include-owner, and at the same cost.")
+	flags.IntVar(&raw.ownerLookupConcurrency, "owner-lookup-concurrency", 16, "How many owners to look up at once, for --include-owner and --exclude-owner.")
+	flags.BoolVar(&raw.includeDirectoryStubs, "include-directory-stub", false, "False by default to ignore directory stubs. Directory stubs are blobs with metadata 'hdi_isfolder:true'. Setting value to true will preserve directory stubs during transfers.")
+	flags.BoolVar(&raw.destinationTimeTokens, "destination-time-tokens", false, "Expand the tokens {yyyy}, {yy}, {MM}, {dd}, {HH}, {mm} and {ss} in the destination "+
+		"to the year, month, day, hour, minute and second, in UTC, at which the job started, e.g. to copy to backups/{yyyy}/{MM}/{dd}. "+
+		"They are expanded once, so all the files of a job go under the same path. Any other text in braces is an error when this flag is used.")
+	flags.BoolVar(&raw.excludeRootFolder, "exclude-root-folder", false, "Don't transfer the source folder itself, only what is in it. "+
+		"The files and folders beneath it are copied as usual, but the properties and permissions of the folder that they land in at the destination are left alone, "+
+		"which helps when copying into a folder that already exists. Only matters when folders are transferred, i.e. with --recursive between locations that both have folders. "+
+		"It is already the case, without this flag, when the source ends in /*, and when --include-path is used (since the root folder matches no path).")
+	flags.BoolVar(&raw.disableAutoDecoding, "disable-auto-decoding", false, "False by default to enable automatic decoding of illegal chars on Windows. Can be set to true to disable automatic decoding.")
+	flags.BoolVar(&raw.dryrun, "dry-run", false, "Prints the file paths that would be copied by this command. This flag does not copy the actual files. "+
+		"Each planned transfer is printed on its own line, or as one JSON object per line with --output-type=json. "+
+		"All filters are applied exactly as in a real run, but the overwrite option is not, since it is only checked when a file is transferred.")
 	// s2sGetPropertiesInBackend is an optional flag for controlling whether S3 object's or Azure file's full properties are get during enumerating in frontend or
 	// right before transferring in ste(backend).
 	// The traditional behavior of all existing enumerator is to get full properties during enumerating(more specifically listing),
@@ -1970,31 +3319,31 @@ func init() {
 	// To achieve better performance and at same time have good control for overall go routine numbers, getting property in ste is introduced,
 	// so properties can be get in parallel, at same time no additional go routines are created for this specific job.
 	// The usage of this hidden flag is to provide fallback to traditional behavior, when service supports returning full properties during list.
-	cpCmd.PersistentFlags().BoolVar(&raw.s2sGetPropertiesInBackend, "s2s-get-properties-in-backend", true, "get S3 objects' or Azure files' properties in backend, if properties need to be accessed. Properties need to be accessed if s2s-preserve-properties is true, and in certain other cases where we need the properties for modification time checks or MD5 checks")
+	flags.BoolVar(&raw.s2sGetPropertiesInBackend, "s2s-get-properties-in-backend", true, "get S3 objects' or Azure files' properties in backend, if properties need to be accessed. Properties need to be accessed if s2s-preserve-properties is true, and in certain other cases where we need the properties for modification time checks or MD5 checks")
 
 	// Public Documentation: https://docs.microsoft.com/en-us/azure/storage/blobs/encryption-customer-provided-keys
 	// Clients making requests against Azure Blob storage have the option to provide an encryption key on a per-request basis.
 	// Including the encryption key on the request provides granular control over encryption settings for Blob storage operations.
 	// Customer-provided keys can be stored in Azure Key Vault or in another key store linked to storage account.
-	cpCmd.PersistentFlags().StringVar(&raw.cpkScopeInfo, "cpk-by-name", "", "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key name will be fetched from Azure Key Vault and will be used to encrypt the data")
-	cpCmd.PersistentFlags().BoolVar(&raw.cpkInfo, "cpk-by-value", false, "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key and its hash will be fetched from environment variables")
+	flags.StringVar(&raw.cpkScopeInfo, "cpk-by-name", "", "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key name will be fetched from Azure Key Vault and will be used to encrypt the data. When writing, the destination must be Blob storage, and its container must allow this encryption scope.")
+	flags.BoolVar(&raw.cpkInfo, "cpk-by-value", false, "Client provided key by value lets clients making requests against Azure Blob storage provide an encryption key on a per-request basis. "+
+		"The key and its SHA256 hash are read from the CPK_ENCRYPTION_KEY and CPK_ENCRYPTION_KEY_SHA256 environment variables, so the key never appears on the command line, and AzCopy leaves it out of its logs. "+
+		"Blobs written with a key must be downloaded with the same key.")
 
 	// permanently hidden
-	// Hide the list-of-files flag since it is implemented only for Storage Explorer.
-	cpCmd.PersistentFlags().MarkHidden("list-of-files")
-	cpCmd.PersistentFlags().MarkHidden("s2s-get-properties-in-backend")
+	flags.MarkHidden("s2s-get-properties-in-backend")
 
 	// temp, to assist users with change in param names, by providing a clearer message when these obsolete ones are accidentally used
-	cpCmd.PersistentFlags().StringVar(&raw.legacyInclude, "include", "", "Legacy include param. DO NOT USE")
-	cpCmd.PersistentFlags().StringVar(&raw.legacyExclude, "exclude", "", "Legacy exclude param. DO NOT USE")
-	cpCmd.PersistentFlags().MarkHidden("include")
-	cpCmd.PersistentFlags().MarkHidden("exclude")
+	flags.StringVar(&raw.legacyInclude, "include", "", "Legacy include param. DO NOT USE")
+	flags.StringVar(&raw.legacyExclude, "exclude", "", "Legacy exclude param. DO NOT USE")
+	flags.MarkHidden("include")
+	flags.MarkHidden("exclude")
 
 	// Hide the flush-threshold flag since it is implemented only for CI.
-	cpCmd.PersistentFlags().Uint32Var(&ste.ADLSFlushThreshold, "flush-threshold", 7500, "Adjust the number of blocks to flush at once on accounts that have a hierarchical namespace.")
-	cpCmd.PersistentFlags().MarkHidden("flush-threshold")
+	flags.Uint32Var(flushThreshold, "flush-threshold", 7500, "Adjust the number of blocks to flush at once on accounts that have a hierarchical namespace.")
+	flags.MarkHidden("flush-threshold")
 
 	// Deprecate the old persist-smb-permissions flag
-	cpCmd.PersistentFlags().MarkHidden("preserve-smb-permissions")
-	cpCmd.PersistentFlags().BoolVar(&raw.preservePermissions, PreservePermissionsFlag, false, "False by default. Preserves ACLs between aware resources (Windows and Azure Files, or ADLS Gen 2 to ADLS Gen 2). For Hierarchical Namespace accounts, you will need a container SAS or OAuth token with Modify Ownership and Modify Permissions permissions. For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
+	flags.MarkHidden("preserve-smb-permissions")
+	flags.BoolVar(&raw.preservePermissions, PreservePermissionsFlag, false, "False by default. Preserves ACLs between aware resources (Windows and Azure Files, or ADLS Gen 2 to ADLS Gen 2). Between ADLS Gen 2 accounts, the whole ACL is copied, including the default ACL of each directory. Named users and groups are copied by object ID, so they only grant access if they also exist in the destination's tenant. For Hierarchical Namespace accounts, you will need a container SAS or OAuth token with Modify Ownership and Modify Permissions permissions. For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
 }
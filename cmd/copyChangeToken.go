@@ -0,0 +1,221 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// changeToken is what a copy saves, in its change-token file, once it has transferred every file: the last modified
+// time of the newest file it saw at the source, and the files that had that time. A later copy from the same source,
+// given the token, only transfers the files that have changed since. Because the times compared are all the source's
+// own, unlike those of include-after, the clock of the machine running AzCopy doesn't matter.
+// The files at the newest time are kept because last modified times are only to the second at some sources, so
+// another file could still be written in that same second, after the listing
+type changeToken struct {
+	Source          string // without any SAS
+	MaxLastModified time.Time
+	FilesAtMax      []string
+}
+
+// readChangeToken returns the token in the since-change-token file, if it is for this source. Without a token that can
+// be used, every file is transferred, as if the flag had not been given
+func (cca *CookedCopyCmdArgs) readChangeToken() *changeToken {
+	raw, err := ioutil.ReadFile(cca.sinceChangeTokenPath)
+	if os.IsNotExist(err) {
+		glcm.Info(fmt.Sprintf("There is no change token in %s yet, so every file will be transferred.", cca.sinceChangeTokenPath))
+		return nil
+	}
+
+	ignore := func(reason string) *changeToken {
+		glcm.Info(fmt.Sprintf("The change token in %s is ignored, because %s. Every file will be transferred.", cca.sinceChangeTokenPath, reason))
+		return nil
+	}
+
+	var token changeToken
+	if err != nil {
+		return ignore(err.Error())
+	}
+	if err = json.Unmarshal(raw, &token); err != nil {
+		return ignore("it could not be read: " + err.Error())
+	}
+	if token.Source != cca.Source.Value {
+		return ignore("it is for a different source")
+	}
+	return &token
+}
+
+// changeTracker passes on only the files that have changed since a change token, if there is one, and keeps track
+// of the newest of them, for the token that the copy saves. It is safe for concurrent use
+type changeTracker struct {
+	since *changeToken // nil passes on every file
+
+	mu              sync.Mutex
+	maxLastModified time.Time
+	filesAtMax      map[string]bool
+}
+
+func newChangeTracker(since *changeToken) *changeTracker {
+	return &changeTracker{since: since, filesAtMax: make(map[string]bool)}
+}
+
+// wrap returns a processor that only sees the files that have changed since the token. Anything that isn't a file,
+// such as a folder, or that has no last modified time, goes straight through, and has no part in the next token
+func (t *changeTracker) wrap(processor objectProcessor) objectProcessor {
+	var alreadySeen map[string]bool
+	if t.since != nil {
+		alreadySeen = make(map[string]bool, len(t.since.FilesAtMax))
+		for _, name := range t.since.FilesAtMax {
+			alreadySeen[name] = true
+		}
+	}
+
+	return func(object StoredObject) error {
+		if object.entityType != common.EEntityType.File() || object.lastModifiedTime.IsZero() {
+			return processor(object)
+		}
+		name := object.ContainerName + "/" + object.relativePath
+		lmt := object.lastModifiedTime
+
+		if t.since != nil {
+			unchanged := lmt.Before(t.since.MaxLastModified) || (lmt.Equal(t.since.MaxLastModified) && alreadySeen[name])
+			if unchanged {
+				if azcopyScanningLogger != nil {
+					azcopyScanningLogger.Log(pipeline.LogDebug, fmt.Sprintf("Not transferring %s, because it has not changed since the change token", name))
+				}
+				return nil
+			}
+		}
+
+		t.mu.Lock()
+		if lmt.After(t.maxLastModified) {
+			t.maxLastModified = lmt
+			t.filesAtMax = map[string]bool{name: true}
+		} else if lmt.Equal(t.maxLastModified) {
+			t.filesAtMax[name] = true
+		}
+		t.mu.Unlock()
+
+		return processor(object)
+	}
+}
+
+// next returns the token for the files seen, which also covers those that the previous token did
+func (t *changeTracker) next(source string) changeToken {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	token := changeToken{Source: source, MaxLastModified: t.maxLastModified}
+	names := t.filesAtMax
+	if t.since != nil && !t.since.MaxLastModified.Before(t.maxLastModified) {
+		token.MaxLastModified = t.since.MaxLastModified
+		names = make(map[string]bool)
+		for _, name := range t.since.FilesAtMax {
+			names[name] = true
+		}
+		if t.since.MaxLastModified.Equal(t.maxLastModified) {
+			for name := range t.filesAtMax {
+				names[name] = true
+			}
+		}
+	}
+
+	token.FilesAtMax = make([]string, 0, len(names))
+	for name := range names {
+		token.FilesAtMax = append(token.FilesAtMax, name)
+	}
+	sort.Strings(token.FilesAtMax)
+	return token
+}
+
+// saveChangeToken saves the token for the files that the job transferred, but only if it transferred all of them.
+// Otherwise the file is left as it was, so that a copy from it transfers again the files that didn't make it
+func (cca *CookedCopyCmdArgs) saveChangeToken(summary common.ListJobSummaryResponse) (saved bool) {
+	completed := summary.JobStatus == common.EJobStatus.Completed() || summary.JobStatus == common.EJobStatus.CompletedWithSkipped()
+	// a cancelled transfer doesn't change the status of its job, so it is found by the count of transfers that didn't complete
+	allDone := summary.TransfersFailed == 0 && summary.TransfersCompleted+summary.TransfersSkipped == summary.TotalTransfers
+	if !completed || !allDone {
+		glcm.Info(fmt.Sprintf("The change token in %s has not been saved, because not every file was transferred.", cca.changeTokenPath))
+		return false
+	}
+
+	if err := cca.writeChangeToken(); err != nil {
+		glcm.Info(fmt.Sprintf("Failed to save the change token in %s: %s", cca.changeTokenPath, err))
+		return false
+	}
+	return true
+}
+
+func (cca *CookedCopyCmdArgs) writeChangeToken() error {
+	raw, err := json.Marshal(cca.changes.next(cca.Source.Value))
+	common.PanicIfErr(err)
+
+	// written to a temporary file first, so that an interruption can't leave a partial token behind
+	tempPath := cca.changeTokenPath + ".tmp"
+	if err = ioutil.WriteFile(tempPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, cca.changeTokenPath)
+}
+
+// quitIfUnchangedSinceToken ends a copy that scheduled nothing, if it had a change token to go by. Then nothing that
+// it would transfer has changed since, which is a success, like a sync that finds everything already in sync. The
+// token is saved again, so that change-token still holds a token when it is a different file from since-change-token
+func (cca *CookedCopyCmdArgs) quitIfUnchangedSinceToken() {
+	if cca.changes == nil || cca.changes.since == nil {
+		return
+	}
+
+	message := fmt.Sprintf("No files have changed since the change token in %s, so there was nothing to transfer.", cca.sinceChangeTokenPath)
+	if cca.changeTokenPath != "" {
+		if err := cca.writeChangeToken(); err != nil {
+			glcm.Error(fmt.Sprintf("%s But the change token could not be saved in %s: %s", message, cca.changeTokenPath, err))
+		}
+		message += fmt.Sprintf("\nChange Token Saved To: %s", cca.changeTokenPath)
+	}
+	glcm.Exit(func(format common.OutputFormat) string {
+		return message
+	}, common.EExitCode.Success())
+}
+
+// changeTokenPathFromFlag validates a change-token flag, and makes its path absolute, so that it doesn't depend on the working directory
+func changeTokenPathFromFlag(flagName string, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s path %s: %w", flagName, path, err)
+	}
+	if fi, err := os.Stat(filepath.Dir(absPath)); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("the folder for the %s file, %s, does not exist", flagName, filepath.Dir(absPath))
+	}
+	return absPath, nil
+}
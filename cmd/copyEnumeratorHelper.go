@@ -15,6 +15,15 @@ var EnumerationParallelStatFiles = false
 
 // addTransfer accepts a new transfer, if the threshold is reached, dispatch a job part order.
 func addTransfer(e *common.CopyJobPartOrderRequest, transfer common.CopyTransfer, cca *CookedCopyCmdArgs) error {
+	// stop as soon as the limit is passed, rather than after listing everything
+	if transfer.EntityType != common.EEntityType.Folder() {
+		cca.scheduledFileCount++
+		if cca.maxFileCount > 0 && cca.scheduledFileCount > cca.maxFileCount {
+			return fmt.Errorf("the job was stopped after finding %d files to transfer, which is more than the limit set by max-file-count (%d). "+
+				"Check that the source is the one intended, or raise the limit", cca.scheduledFileCount, cca.maxFileCount)
+		}
+	}
+
 	// Remove the source and destination roots from the path to save space in the plan files
 	transfer.Source = strings.TrimPrefix(transfer.Source, e.SourceRoot.Value)
 	transfer.Destination = strings.TrimPrefix(transfer.Destination, e.DestinationRoot.Value)
@@ -23,20 +32,9 @@ func addTransfer(e *common.CopyJobPartOrderRequest, transfer common.CopyTransfer
 	// we do this so that in the case of large transfer, the transfer engine can get started
 	// while the frontend is still gathering more transfers
 	if len(e.Transfers.List) == NumOfFilesPerDispatchJobPart {
-		shuffleTransfers(e.Transfers.List)
-		resp := common.CopyJobPartOrderResponse{}
-
-		Rpc(common.ERpcCmd.CopyJobPartOrder(), (*common.CopyJobPartOrderRequest)(e), &resp)
-
-		if !resp.JobStarted {
-			return fmt.Errorf("copy job part order with JobId %s and part number %d failed because %s", e.JobID, e.PartNum, resp.ErrorMsg)
-		}
-		// if the current part order sent to engine is 0, then start fetching the Job Progress summary.
-		if e.PartNum == 0 {
-			cca.waitUntilJobCompletion(false)
+		if err := dispatchPart(e, cca); err != nil {
+			return err
 		}
-		e.Transfers = common.Transfers{}
-		e.PartNum++
 	}
 
 	// only append the transfer after we've checked and dispatched a part
@@ -45,7 +43,7 @@ func addTransfer(e *common.CopyJobPartOrderRequest, transfer common.CopyTransfer
 		//Should this block be a function?
 		e.Transfers.List = append(e.Transfers.List, transfer)
 		e.Transfers.TotalSizeInBytes += uint64(transfer.SourceSize)
-		if transfer.EntityType == common.EEntityType.File() {
+		if transfer.EntityType != common.EEntityType.Folder() { // preserved symlinks count as files
 			e.Transfers.FileTransferCount++
 		} else {
 			e.Transfers.FolderTransferCount++
@@ -55,6 +53,41 @@ func addTransfer(e *common.CopyJobPartOrderRequest, transfer common.CopyTransfer
 	return nil
 }
 
+// dispatchPart sends the transfers that have been added so far as a part of the job, and starts the next part
+func dispatchPart(e *common.CopyJobPartOrderRequest, cca *CookedCopyCmdArgs) error {
+	shuffleTransfers(e.Transfers.List)
+	resp := common.CopyJobPartOrderResponse{}
+
+	Rpc(common.ERpcCmd.CopyJobPartOrder(), (*common.CopyJobPartOrderRequest)(e), &resp)
+
+	if !resp.JobStarted {
+		return fmt.Errorf("copy job part order with JobId %s and part number %d failed because %s", e.JobID, e.PartNum, resp.ErrorMsg)
+	}
+	// if the current part order sent to engine is 0, then start fetching the Job Progress summary.
+	if e.PartNum == 0 {
+		cca.waitUntilJobCompletion(false)
+	}
+	e.Transfers = common.Transfers{}
+	e.PartNum++
+	return nil
+}
+
+// switchSourceRoot makes root the source root of the transfers that are added next. All the transfers of a part share
+// its source root, and its SAS, so any that were added under a different root are dispatched in a part of their own first.
+// Used for list-of-urls, where the sources are in many containers
+func switchSourceRoot(e *common.CopyJobPartOrderRequest, root common.ResourceString, cca *CookedCopyCmdArgs) error {
+	if e.SourceRoot == root {
+		return nil
+	}
+	if len(e.Transfers.List) > 0 {
+		if err := dispatchPart(e, cca); err != nil {
+			return err
+		}
+	}
+	e.SourceRoot = root
+	return nil
+}
+
 // this function shuffles the transfers before they are dispatched
 // this is done to avoid hitting the same partition continuously in an append only pattern
 // TODO this should probably be removed after the high throughput block blob feature is implemented on the service side
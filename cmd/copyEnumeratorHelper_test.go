@@ -21,6 +21,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	chk "gopkg.in/check.v1"
 )
@@ -61,3 +63,52 @@ func (s *copyEnumeratorHelperTestSuite) TestAddTransferPathRootsTrimmed(c *chk.C
 	c.Assert(request.Transfers.List[0].Source, chk.Equals, "c.txt")
 	c.Assert(request.Transfers.List[0].Destination, chk.Equals, "c.txt")
 }
+
+func (s *copyEnumeratorHelperTestSuite) TestAddTransferStopsPastMaxFileCount(c *chk.C) {
+	request := common.CopyJobPartOrderRequest{}
+	cca := &CookedCopyCmdArgs{maxFileCount: 2}
+
+	// folders don't count towards the limit
+	c.Assert(addTransfer(&request, common.CopyTransfer{Source: "dir", EntityType: common.EEntityType.Folder()}, cca), chk.IsNil)
+	c.Assert(addTransfer(&request, common.CopyTransfer{Source: "dir/a.txt"}, cca), chk.IsNil)
+	c.Assert(addTransfer(&request, common.CopyTransfer{Source: "dir/b.txt"}, cca), chk.IsNil)
+
+	err := addTransfer(&request, common.CopyTransfer{Source: "dir/c.txt"}, cca)
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "after finding 3 files"), chk.Equals, true, chk.Commentf(err.Error()))
+	c.Assert(len(request.Transfers.List), chk.Equals, 3) // the file past the limit was not scheduled
+}
+
+func (s *copyEnumeratorHelperTestSuite) TestVersionsGetDestinationNamesOfTheirOwn(c *chk.C) {
+	cca := &CookedCopyCmdArgs{FromTo: common.EFromTo.BlobLocal(), Destination: newLocalRes("y/z/")}
+	versions := []string{"2022-01-01T10:00:00.0000000Z", "2022-01-02T10:00:00.0000000Z"}
+
+	for _, v := range versions {
+		inFolder := StoredObject{name: "c.txt", relativePath: "b/c.txt", entityType: common.EEntityType.File(), blobVersionID: v}
+		c.Assert(cca.MakeEscapedRelativePath(true, true, false, inFolder), chk.Equals, "/b/c.txt") // the source is the blob itself, with the version given separately
+		c.Assert(cca.MakeEscapedRelativePath(false, true, false, inFolder), chk.Equals, "/b/"+strings.ReplaceAll(v, ":", "-")+"-c.txt")
+
+		single := StoredObject{name: "c.txt", entityType: common.EEntityType.File(), blobVersionID: v}
+		c.Assert(cca.MakeEscapedRelativePath(false, true, false, single), chk.Equals, "/"+strings.ReplaceAll(v, ":", "-")+"-c.txt")
+	}
+
+	current := StoredObject{name: "c.txt", relativePath: "b/c.txt", entityType: common.EEntityType.File()}
+	c.Assert(cca.MakeEscapedRelativePath(false, true, false, current), chk.Equals, "/b/c.txt")
+}
+
+func (s *copyEnumeratorHelperTestSuite) TestStrippingTrailingDotsReportsClashingNames(c *chk.C) {
+	c.Assert(stripTrailingDots("/file."), chk.Equals, "/file")
+	c.Assert(stripTrailingDots("/dir./child"), chk.Equals, "/dir/child")
+	c.Assert(stripTrailingDots("/dir../a.b.."), chk.Equals, "/dir/a.b")
+	c.Assert(stripTrailingDots("/../file"), chk.Equals, "/../file") // names that are only dots stay as they are
+
+	claims := trailingDotClaims{}
+	_, taken := claims.claim(stripTrailingDots("/dir./file"), "dir./file")
+	c.Assert(taken, chk.Equals, false)
+	_, taken = claims.claim(stripTrailingDots("/dir/other."), "dir/other.")
+	c.Assert(taken, chk.Equals, false)
+
+	earlier, taken := claims.claim(stripTrailingDots("/dir/file"), "dir/file")
+	c.Assert(taken, chk.Equals, true)
+	c.Assert(earlier, chk.Equals, "dir./file")
+}
@@ -35,7 +35,10 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	var isPublic bool
 	var err error
 
-	if srcCredInfo, isPublic, err = GetCredentialInfoForLocation(ctx, cca.FromTo.From(), cca.Source.Value, cca.Source.SAS, true, cca.CpkOptions); err != nil {
+	if cca.ListOfUrlsChannel != nil {
+		// each URL in the list carries its own SAS, unless it is public, so there is no one credential for the source
+		srcCredInfo.CredentialType = common.ECredentialType.Anonymous()
+	} else if srcCredInfo, isPublic, err = GetCredentialInfoForLocation(ctx, cca.FromTo.From(), cca.Source.Value, cca.Source.SAS, true, cca.CpkOptions); err != nil {
 		return nil, err
 		// If S2S and source takes OAuthToken as its cred type (OR) source takes anonymous as its cred type, but it's not public and there's no SAS
 	} else if cca.FromTo.IsS2S() &&
@@ -67,12 +70,14 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	jobPartOrder.PreserveSMBPermissions = cca.preservePermissions
 	jobPartOrder.PreserveSMBInfo = cca.preserveSMBInfo
 	jobPartOrder.PreservePOSIXProperties = cca.preservePOSIXProperties
+	jobPartOrder.PreserveXattrs = cca.preserveXattrs
 
 	// Infer on download so that we get LMT and MD5 on files download
 	// On S2S transfers the following rules apply:
 	// If preserve properties is enabled, but get properties in backend is disabled, turn it on
 	// If source change validation is enabled on files to remote, turn it on (consider a separate flag entirely?)
 	getRemoteProperties := cca.ForceWrite == common.EOverwriteOption.IfSourceNewer() ||
+		cca.getPropertiesForMetadata || // Listings of Azure Files don't return metadata or content types, so the metadata and content type filters need the properties of each file.
 		(cca.FromTo.From() == common.ELocation.File() && !cca.FromTo.To().IsRemote()) || // If download, we still need LMT and MD5 from files.
 		(cca.FromTo.From() == common.ELocation.File() && cca.FromTo.To().IsRemote() && (cca.s2sSourceChangeValidation || cca.IncludeAfter != nil || cca.IncludeBefore != nil)) || // If S2S from File to *, and sourceChangeValidation is enabled, we get properties so that we have LMTs. Likewise, if we are using includeAfter or includeBefore, which require LMTs.
 		(cca.FromTo.From().IsRemote() && cca.FromTo.To().IsRemote() && cca.s2sPreserveProperties && !cca.s2sGetPropertiesInBackend) // If S2S and preserve properties AND get properties in backend is on, turn this off, as properties will be obtained in the backend.
@@ -81,14 +86,51 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	jobPartOrder.DestLengthValidation = cca.CheckLength
 	jobPartOrder.S2SInvalidMetadataHandleOption = cca.s2sInvalidMetadataHandleOption
 	jobPartOrder.S2SPreserveBlobTags = cca.S2sPreserveBlobTags
+	jobPartOrder.PerTransferTimeout = cca.perTransferTimeout
+	jobPartOrder.SnapshotSourceFirst = cca.snapshotSourceFirst
+	jobPartOrder.FollowSourceRedirects = cca.followSourceRedirects
+	jobPartOrder.DownloadRangesInPlace = cca.downloadRangesInPlace
+	jobPartOrder.AllowTrailingDot = cca.allowsTrailingDot()
+	jobPartOrder.VerifyCrc64 = cca.verifyCrc64
+	jobPartOrder.MetadataOnly = cca.metadataOnly
+	jobPartOrder.DestinationArchive = cca.destinationArchive
+	jobPartOrder.SourceArchive = cca.sourceArchive
+	jobPartOrder.ManifestOutput = cca.manifestOutput
+	jobPartOrder.MaxErrors = cca.maxErrors
+	jobPartOrder.TransferOrder = cca.transferOrder
+	jobPartOrder.PriorityPattern = cca.priorityPattern
+
+	if cca.ListOfUrlsChannel != nil {
+		traverser = newUrlListTraverser(cca.ListOfUrlsChannel, cca.keepFromUrl, cca.urlListFailures, &ctx, cca.Recursive,
+			getRemoteProperties, cca.IncludeDirectoryStubs, func(common.EntityType) {}, cca.S2sPreserveBlobTags,
+			azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions)
+	} else if cca.sourceArchive != common.EArchiveFormat.None() {
+		traverser = newArchiveTraverser(cca.Source.ValueLocal(), cca.sourceArchive, cca.Recursive, func(common.EntityType) {})
+	} else {
+		symlinkRoot := ""
+		if cca.restrictSymlinksToRoot && cca.FromTo.From() == common.ELocation.Local() {
+			symlinkRoot = cleanLocalPath(getPathBeforeFirstWildcard(cca.Source.ValueLocal()))
+		}
+
+		telemetry, err := startEnumerationTelemetry(cca.enumerationTelemetry)
+		if err != nil {
+			return nil, err
+		}
+		srcCtx := telemetry.attach(ctx, "source")
 
-	traverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &ctx, &srcCredInfo,
-		&cca.FollowSymlinks, cca.ListOfFilesChannel, cca.Recursive, getRemoteProperties,
-		cca.IncludeDirectoryStubs, cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs,
-		cca.S2sPreserveBlobTags, azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions, nil /* errorChannel */)
+		traverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &srcCtx, &srcCredInfo,
+			cca.SymlinkHandling, symlinkRoot, cca.ListOfFilesChannel, cca.Recursive, getRemoteProperties,
+			cca.IncludeDirectoryStubs, cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs, cca.listVersions,
+			cca.S2sPreserveBlobTags, azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions, nil /* errorChannel */, cca.IncludeBlobTags, cca.excludeContainer)
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+
+		// don't list what the DepthFilter below would only throw away, where the traverser can avoid it
+		if limited, ok := traverser.(depthLimitedTraverser); ok && cca.MaxDepth != 0 {
+			limited.setMaxDepth(cca.MaxDepth)
+		}
 	}
 
 	// Ensure we're only copying a directory under valid conditions
@@ -105,6 +147,9 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	if cca.ListOfVersionIDs != nil && (!(cca.FromTo == common.EFromTo.BlobLocal() || cca.FromTo == common.EFromTo.BlobTrash()) || isSourceDir || !isDestDir) {
 		log.Fatalf("Either source is not a blob or destination is not a local folder")
 	}
+	if cca.listVersions && !isSourceDir && !isDestDir {
+		return nil, errors.New("the destination must be a folder when copying the versions of a single blob, since each version is written to a file of its own")
+	}
 	srcLevel, err := DetermineLocationLevel(cca.Source.Value, cca.FromTo.From(), true)
 
 	if err != nil {
@@ -117,6 +162,12 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 		return nil, err
 	}
 
+	if !cca.immutabilityPolicyUntil.IsZero() || cca.legalHold {
+		if err = cca.checkDestinationSupportsImmutability(ctx, dstLevel); err != nil {
+			return nil, err
+		}
+	}
+
 	// Disallow list-of-files and include-path on service-level traversal due to a major bug
 	// TODO: Fix the bug.
 	//       Two primary issues exist with the list-of-files implementation:
@@ -160,6 +211,10 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			return nil, err
 		}
 
+		if cca.ListOfUrlsChannel != nil && dstContainerName == "" {
+			return nil, errors.New("with list-of-urls, the destination must include a container or share, since the URLs can be in many containers")
+		}
+
 		// only create the destination container in S2S scenarios
 		if cca.FromTo.From().IsRemote() && dstContainerName != "" { // if the destination has a explicit container name
 			// Attempt to create the container. If we fail, fail silently.
@@ -237,18 +292,51 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 		}
 	}
 
+	if cca.FromTo.To() == common.ELocation.Blob() {
+		if err = validateEncryptionScope(ctx, cca.Destination, dstContainerName, cca.CpkOptions); err != nil {
+			return nil, err
+		}
+	}
+
 	filters := cca.InitModularFilters()
 
 	// decide our folder transfer strategy
 	var message string
-	jobPartOrder.Fpo, message = newFolderPropertyOption(cca.FromTo, cca.Recursive, cca.StripTopDir, filters, cca.preserveSMBInfo, cca.preservePermissions.IsTruthy(), cca.preservePOSIXProperties, cca.isHNStoHNS, strings.EqualFold(cca.Destination.Value, common.Dev_Null), cca.IncludeDirectoryStubs)
-	if !cca.dryrunMode {
+	jobPartOrder.Fpo, message = newFolderPropertyOption(cca.FromTo, cca.Recursive, cca.StripTopDir || cca.excludeRootFolder, filters, cca.preserveSMBInfo, cca.preservePermissions.IsTruthy(), cca.preservePOSIXProperties, cca.isHNStoHNS, strings.EqualFold(cca.Destination.Value, common.Dev_Null), cca.IncludeDirectoryStubs || cca.preserveEmptyDirectories)
+	if cca.preserveEmptyDirectories && jobPartOrder.Fpo != common.EFolderPropertiesOption.NoFolders() {
+		message = fmt.Sprintf("Empty folders will be kept, with a marker blob named %s in each, because --preserve-empty-directories was specified", cca.emptyDirectoryMarker)
+		if cca.FromTo.IsDownload() {
+			message = fmt.Sprintf("The folders that have a marker blob named %s will be created, and the markers won't be downloaded, because --preserve-empty-directories was specified", cca.emptyDirectoryMarker)
+		}
+	}
+	if !cca.dryrunMode && cca.listTransfer == nil {
 		glcm.Info(message)
 	}
 	if jobsAdmin.JobsAdmin != nil {
 		jobsAdmin.JobsAdmin.LogToJobLog(message, pipeline.LogInfo)
 	}
 
+	var rewriter *destinationPathRewriter
+	if cca.pathRewriter != nil {
+		rewriter = newDestinationPathRewriter(cca.pathRewriter, cca.FromTo)
+	}
+
+	var existingSizes *destinationSizeLookup
+	if cca.skipExistingWithSameSize {
+		if dstLevel == ELocationLevel.Service() {
+			return nil, errors.New("skip-existing-with-same-size cannot be used when the destination is a whole account. Give the container, share or folder to copy to instead")
+		}
+		if existingSizes, err = cca.newDestinationSizeLookup(ctx); err != nil {
+			return nil, fmt.Errorf("cannot look up the files that already exist at the destination: %w", err)
+		}
+	}
+
+	var trailingDotClaims trailingDotClaims
+	if cca.trailingDot == common.ETrailingDotOption.Disable() {
+		trailingDotClaims = make(map[string]string)
+	}
+
+	var warnCannotDecompressOnce sync.Once
 	processor := func(object StoredObject) error {
 		// Start by resolving the name and creating the container
 		if object.ContainerName != "" {
@@ -280,8 +368,44 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			}
 		}
 
+		// a blob that stands for a symlink is recreated as one, rather than downloaded as an empty file
+		if cca.SymlinkHandling == common.ESymlinkHandlingType.Preserve() && cca.FromTo.IsDownload() &&
+			object.entityType == common.EEntityType.File() && strings.EqualFold(object.Metadata[common.POSIXSymlinkMeta], "true") {
+			object.entityType = common.EEntityType.Symlink()
+		}
+
+		if cca.autoDecompress && cca.FromTo.IsDownload() && object.entityType == common.EEntityType.File() {
+			if _, err := common.GetCompressionType(object.contentEncoding); err != nil {
+				warnCannotDecompressOnce.Do(func() {
+					WarnStdoutAndScanningLog(fmt.Sprintf("Files whose content-encoding can't be decompressed (such as '%s', which has '%s') are downloaded without being decompressed. "+
+						"Only 'gzip' and 'deflate' can be", object.relativePath, object.contentEncoding))
+				})
+			}
+		}
+
 		srcRelPath := cca.MakeEscapedRelativePath(true, isDestDir, cca.asSubdir, object)
-		dstRelPath := cca.MakeEscapedRelativePath(false, isDestDir, cca.asSubdir, object)
+		dstObject := object
+		sourceRoot := cca.Source
+		if object.sourceRoot != nil {
+			sourceRoot = *object.sourceRoot
+			dstObject.relativePath = object.keptPath
+		}
+		if object.flattenedName != "" {
+			dstObject.relativePath = object.flattenedName
+		}
+		if object.emptyDirectoryDestination != nil {
+			dstObject.relativePath = *object.emptyDirectoryDestination
+		}
+		if rewriter != nil {
+			var keep bool
+			if dstObject, keep = rewriter.apply(dstObject); !keep {
+				return nil
+			}
+		}
+		dstRelPath := cca.MakeEscapedRelativePath(false, isDestDir, cca.asSubdir, dstObject)
+		if trailingDotClaims != nil {
+			dstRelPath = stripTrailingDots(dstRelPath)
+		}
 
 		transfer, shouldSendToSte := object.ToNewCopyTransfer(
 			cca.autoDecompress && cca.FromTo.IsDownload(),
@@ -289,9 +413,39 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			cca.s2sPreserveAccessTier,
 			jobPartOrder.Fpo,
 		)
+		if trailingDotClaims != nil && shouldSendToSte {
+			if earlier, taken := trailingDotClaims.claim(dstRelPath, object.relativePath); taken {
+				WarnStdoutAndScanningLog(fmt.Sprintf("Skipping '%s', because without trailing dots its name is the same as that of '%s', which is already being copied. "+
+					"Use --trailing-dot=Enable to keep both", object.relativePath, earlier))
+				return nil
+			}
+		}
 		if !cca.S2sPreserveBlobTags {
 			transfer.BlobTags = cca.blobTags
 		}
+		if blobType, ok := blobTypeForName(cca.blobTypeByPattern, object.name); ok && object.entityType == common.EEntityType.File() {
+			transfer.BlobType = blobType.ToAzBlobType() // uploads have no source blob type, so this says which type to create instead
+		}
+
+		if existingSizes != nil && shouldSendToSte && transfer.EntityType == common.EEntityType.File() {
+			if size, exists := existingSizes.sizeOf(dstRelPath); exists && size == transfer.SourceSize {
+				cca.skippedExistingFileCount++
+				return nil
+			}
+		}
+
+		if cca.listTransfer != nil {
+			if !shouldSendToSte {
+				return nil
+			}
+			return cca.listTransfer(TransferDescriptor{
+				RelativePath:     strings.TrimPrefix(srcRelPath, common.AZCOPY_PATH_SEPARATOR_STRING),
+				Destination:      common.GenerateFullPath(jobPartOrder.DestinationRoot.Value, dstRelPath),
+				EntityType:       transfer.EntityType,
+				Size:             transfer.SourceSize,
+				LastModifiedTime: transfer.LastModifiedTime,
+			})
+		}
 
 		if cca.dryrunMode && shouldSendToSte {
 			glcm.Dryrun(func(format common.OutputFormat) string {
@@ -313,7 +467,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 					} else if cca.FromTo.To() == common.ELocation.Local() {
 						// formatting to local source
 						dryrunValue := fmt.Sprintf("DRYRUN: copy %v%v to %v",
-							strings.Trim(cca.Source.Value, "/"), srcRelPath,
+							strings.Trim(sourceRoot.Value, "/"), srcRelPath,
 							common.ToShortPath(cca.Destination.Value))
 						if runtime.GOOS == "windows" {
 							dryrunValue += strings.ReplaceAll(dstRelPath, "/", "\\")
@@ -323,7 +477,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 						return dryrunValue
 					} else {
 						return fmt.Sprintf("DRYRUN: copy %v%v to %v%v",
-							cca.Source.Value,
+							sourceRoot.Value,
 							srcRelPath,
 							cca.Destination.Value,
 							dstRelPath)
@@ -334,15 +488,61 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 		}
 
 		if shouldSendToSte {
+			if object.sourceRoot != nil {
+				if err := switchSourceRoot(&jobPartOrder, sourceRoot, cca); err != nil {
+					return err
+				}
+			}
 			return addTransfer(&jobPartOrder, transfer, cca)
 		}
 		return nil
 	}
 	finalizer := func() error {
-		return dispatchFinalPart(&jobPartOrder, cca)
+		if cca.skippedExistingFileCount > 0 {
+			glcm.Info(fmt.Sprintf("Skipped %d files that already exist at the destination with the same size.", cca.skippedExistingFileCount))
+		}
+		if cca.listTransfer != nil {
+			return nil // nothing was scheduled
+		}
+		err := dispatchFinalPart(&jobPartOrder, cca)
+		if err == NothingScheduledError {
+			cca.quitIfUnchangedSinceToken()
+		}
+		return err
 	}
 
-	return NewCopyEnumerator(traverser, filters, processor, finalizer), nil
+	// innermost, so that the token only covers the files that are transferred, once the owner filter and the other
+	// wrappers below have dropped any
+	if cca.changeTokenPath != "" || cca.sinceChangeTokenPath != "" {
+		var since *changeToken
+		if cca.sinceChangeTokenPath != "" {
+			since = cca.readChangeToken()
+		}
+		cca.changes = newChangeTracker(since)
+		processor = cca.changes.wrap(processor)
+	}
+	if cca.flatten {
+		processor, finalizer = newFlattener(cca.flattenCollisions, cca.FromTo).wrap(processor, finalizer)
+	}
+	if cca.preserveEmptyDirectories {
+		processor, finalizer = newEmptyDirectoryMarkers(cca.emptyDirectoryMarker, cca.FromTo).wrap(processor, finalizer)
+	}
+	if cca.maxVersionsPerBlob > 0 {
+		processor, finalizer = newNewestVersionsKeeper(cca.maxVersionsPerBlob).wrap(processor, finalizer)
+	}
+	var abandon func()
+	if len(cca.includeOwner) > 0 || len(cca.excludeOwner) > 0 {
+		lookup, err := newOwnerLookup(ctx, cca.Source, srcCredInfo, azcopyLogVerbosity.ToPipelineLogLevel())
+		if err != nil {
+			return nil, err
+		}
+		filter := &ownerFilter{include: cca.includeOwner, exclude: cca.excludeOwner, lookup: lookup, concurrency: cca.ownerLookupConcurrency}
+		processor, finalizer, abandon = filter.wrap(processor, finalizer)
+	}
+
+	enumerator := NewCopyEnumerator(traverser, filters, processor, finalizer)
+	enumerator.Abandon = abandon
+	return enumerator, nil
 }
 
 // This is condensed down into an individual function as we don't end up re-using the destination traverser at all.
@@ -359,9 +559,9 @@ func (cca *CookedCopyCmdArgs) isDestDirectory(dst common.ResourceString, ctx *co
 		return false
 	}
 
-	rt, err := InitResourceTraverser(dst, cca.FromTo.To(), ctx, &dstCredInfo, nil,
+	rt, err := InitResourceTraverser(dst, cca.FromTo.To(), ctx, &dstCredInfo, common.ESymlinkHandlingType.Skip(), "",
 		nil, false, false, false, common.EPermanentDeleteOption.None(),
-		func(common.EntityType) {}, cca.ListOfVersionIDs, false, pipeline.LogNone, cca.CpkOptions, nil /* errorChannel */)
+		func(common.EntityType) {}, cca.ListOfVersionIDs, false, false, pipeline.LogNone, cca.CpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 
 	if err != nil {
 		return false
@@ -382,18 +582,34 @@ func (cca *CookedCopyCmdArgs) InitModularFilters() []ObjectFilter {
 		filters = append(filters, &IncludeAfterDateFilter{Threshold: *cca.IncludeAfter})
 	}
 
+	if cca.MinSizeBytes != nil || cca.MaxSizeBytes != nil {
+		filters = append(filters, &SizeFilter{MinSizeBytes: cca.MinSizeBytes, MaxSizeBytes: cca.MaxSizeBytes})
+	}
+
+	if cca.MinDepth != 0 || cca.MaxDepth != 0 {
+		filters = append(filters, &DepthFilter{MinDepth: cca.MinDepth, MaxDepth: cca.MaxDepth})
+	}
+
+	// include-pattern, include-ext and include-regex are alternatives to each other, so they are ORed together
+	includeFilters := make([]ObjectFilter, 0)
 	if len(cca.IncludePatterns) != 0 {
-		filters = append(filters, &IncludeFilter{patterns: cca.IncludePatterns}) // TODO should this call buildIncludeFilters?
+		includeFilters = append(includeFilters, &IncludeFilter{patterns: cca.IncludePatterns, ignoreCase: cca.IgnoreCasePattern}) // TODO should this call buildIncludeFilters?
 	}
+	filters = append(filters, combineIncludeFilters(includeFilters, buildExtensionFilters(cca.includeExt, true), buildRegexFilters(cca.includeRegex, true))...)
 
 	if len(cca.ExcludePatterns) != 0 {
 		for _, v := range cca.ExcludePatterns {
-			filters = append(filters, &excludeFilter{pattern: v})
+			filters = append(filters, &excludeFilter{pattern: v, ignoreCase: cca.IgnoreCasePattern})
 		}
 	}
+	filters = append(filters, buildExtensionFilters(cca.excludeExt, false)...)
 
 	// include-path is not a filter, therefore it does not get handled here.
 	// Check up in cook() around the list-of-files implementation as include-path gets included in the same way.
+	// The exception is a source with many containers, in which the paths are filtered within each container.
+	if len(cca.includePathPerContainer) != 0 {
+		filters = append(filters, &includePathFilter{paths: cca.includePathPerContainer})
+	}
 
 	if len(cca.ExcludePathPatterns) != 0 {
 		for _, v := range cca.ExcludePathPatterns {
@@ -401,13 +617,13 @@ func (cca *CookedCopyCmdArgs) InitModularFilters() []ObjectFilter {
 		}
 	}
 
-	if len(cca.includeRegex) != 0 {
-		filters = append(filters, &regexFilter{patterns: cca.includeRegex, isIncluded: true})
-	}
+	filters = append(filters, buildRegexFilters(cca.excludeRegex, false)...)
 
-	if len(cca.excludeRegex) != 0 {
-		filters = append(filters, &regexFilter{patterns: cca.excludeRegex, isIncluded: false})
-	}
+	filters = append(filters, buildMetadataFilters(cca.includeMetadata, true)...)
+	filters = append(filters, buildMetadataFilters(cca.excludeMetadata, false)...)
+
+	filters = append(filters, buildContentTypeFilters(cca.includeContentType, true)...)
+	filters = append(filters, buildContentTypeFilters(cca.excludeContentType, false)...)
 
 	if len(cca.excludeBlobType) != 0 {
 		excludeSet := map[azblob.BlobType]bool{}
@@ -447,7 +663,7 @@ func (cca *CookedCopyCmdArgs) InitModularFilters() []ObjectFilter {
 }
 
 func (cca *CookedCopyCmdArgs) createDstContainer(containerName string, dstWithSAS common.ResourceString, parentCtx context.Context, existingContainers map[string]bool, logLevel common.LogLevel) (err error) {
-	if _, ok := existingContainers[containerName]; ok {
+	if _, ok := existingContainers[containerName]; ok || cca.listTransfer != nil { // listing transfers must not change the destination
 		return
 	}
 	existingContainers[containerName] = true
@@ -541,6 +757,43 @@ func (cca *CookedCopyCmdArgs) createDstContainer(containerName string, dstWithSA
 	return
 }
 
+// validateEncryptionScope checks, before anything is transferred, that blobs may be written to the destination container
+// under the encryption scope given by cpk-by-name. The blob endpoint cannot list the scopes of the account, but it does say
+// whether the container only allows its own default scope. A scope that does not exist fails the first transfer instead.
+// If the container's properties cannot be read, there's nothing to check against, so that's not an error
+func validateEncryptionScope(ctx context.Context, destination common.ResourceString, containerName string, cpkOptions common.CpkOptions) error {
+	if cpkOptions.CpkScopeInfo == "" || containerName == "" {
+		return nil
+	}
+
+	dstCredInfo, _, err := GetCredentialInfoForLocation(ctx, common.ELocation.Blob(), destination.Value, destination.SAS, false, cpkOptions)
+	if err != nil {
+		return nil
+	}
+	dstPipeline, err := InitPipeline(ctx, common.ELocation.Blob(), dstCredInfo, pipeline.LogNone)
+	if err != nil {
+		return nil
+	}
+	accountRoot, err := GetAccountRoot(destination, common.ELocation.Blob())
+	if err != nil {
+		return nil
+	}
+	dstURL, err := url.Parse(accountRoot)
+	if err != nil {
+		return nil
+	}
+
+	props, err := azblob.NewServiceURL(*dstURL, dstPipeline).NewContainerURL(containerName).GetProperties(ctx, azblob.LeaseAccessConditions{})
+	if err != nil {
+		return nil
+	}
+	if strings.EqualFold(props.DenyEncryptionScopeOverride(), "true") && props.DefaultEncryptionScope() != cpkOptions.CpkScopeInfo {
+		return fmt.Errorf("cannot write blobs with encryption scope '%s', because container '%s' only allows its default encryption scope '%s'",
+			cpkOptions.CpkScopeInfo, containerName, props.DefaultEncryptionScope())
+	}
+	return nil
+}
+
 // Because some invalid characters weren't being properly encoded by url.PathEscape, we're going to instead manually encode them.
 var encodedInvalidCharacters = map[rune]string{
 	'<':  "%3C",
@@ -606,6 +859,49 @@ func pathEncodeRules(path string, fromTo common.FromTo, disableAutoDecoding bool
 	return path
 }
 
+// versionedObjectName puts the version id in front of the name, so that the versions of one blob can sit side by side
+func versionedObjectName(versionID string, name string) string {
+	if versionID == "" {
+		return name
+	}
+	return strings.ReplaceAll(versionID, ":", "-") + "-" + name
+}
+
+// stripTrailingDots removes the dots from the end of each name in a relative path, as Azure Files does to names
+// when it isn't asked to keep them. Names that are nothing but dots are left alone
+func stripTrailingDots(relativePath string) string {
+	names := strings.Split(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+	for i, name := range names {
+		if stripped := strings.TrimRight(name, "."); stripped != "" {
+			names[i] = stripped
+		}
+	}
+	return strings.Join(names, common.AZCOPY_PATH_SEPARATOR_STRING)
+}
+
+// trailingDotClaims maps each destination path to the source that is copied to it, once trailing dots are stripped,
+// so that "file." and "file" aren't both written to "file", the later one silently replacing the earlier
+type trailingDotClaims map[string]string
+
+// claim takes dstRelPath for source. If it's already taken, it returns the source that took it
+func (c trailingDotClaims) claim(dstRelPath string, source string) (earlier string, taken bool) {
+	if earlier, taken = c[dstRelPath]; taken {
+		return earlier, true
+	}
+	c[dstRelPath] = source
+	return "", false
+}
+
+// keepsTopDir says whether destination paths start with the name of the source's top directory. --strip-top-dir says,
+// if it was given. Otherwise they do unless the source ends with /*, or StripTopDir was set for any other reason, or the
+// source is placed as the destination itself (--as-subdir=false)
+func (cca *CookedCopyCmdArgs) keepsTopDir() bool {
+	if cca.stripTopDirOverride != nil {
+		return !*cca.stripTopDirOverride
+	}
+	return !cca.StripTopDir && cca.asSubdir
+}
+
 func (cca *CookedCopyCmdArgs) MakeEscapedRelativePath(source bool, dstIsDir bool, asSubdir bool, object StoredObject) (relativePath string) {
 	// write straight to /dev/null, do not determine a indirect path
 	if !source && cca.Destination.Value == common.Dev_Null {
@@ -623,11 +919,7 @@ func (cca *CookedCopyCmdArgs) MakeEscapedRelativePath(source bool, dstIsDir bool
 				// Our source points to a specific file (and so has no relative path)
 				// but our dest does not point to a specific file, it just points to a directory,
 				// and so relativePath needs the _name_ of the source.
-				processedVID := ""
-				if len(object.blobVersionID) > 0 {
-					processedVID = strings.ReplaceAll(object.blobVersionID, ":", "-") + "-"
-				}
-				relativePath += "/" + processedVID + object.name
+				relativePath += "/" + versionedObjectName(object.blobVersionID, object.name)
 			} else {
 				relativePath = ""
 			}
@@ -646,15 +938,24 @@ func (cca *CookedCopyCmdArgs) MakeEscapedRelativePath(source bool, dstIsDir bool
 		relativePath = "" // otherwise we get "/" from the line below, and that breaks some clients, e.g. blobFS
 	} else {
 		relativePath = "/" + strings.Replace(object.relativePath, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING, -1)
+		if !source && object.blobVersionID != "" {
+			// each version of a blob needs a name of its own at the destination
+			i := strings.LastIndex(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+			relativePath = relativePath[:i+1] + versionedObjectName(object.blobVersionID, relativePath[i+1:])
+		}
 	}
 
 	if common.IffString(source, object.ContainerName, object.DstContainerName) != "" {
 		relativePath = `/` + common.IffString(source, object.ContainerName, object.DstContainerName) + relativePath
-	} else if !source && !cca.StripTopDir && cca.asSubdir { // Avoid doing this where the root is shared or renamed.
+	} else if !source && cca.keepsTopDir() { // Avoid doing this where the root is shared or renamed.
 		// We ONLY need to do this adjustment to the destination.
 		// The source SAS has already been removed. No need to convert it to a URL or whatever.
 		// Save to a directory
 		rootDir := filepath.Base(cca.Source.Value)
+		if cca.FromTo.From().IsLocal() && strings.Contains(cca.Source.Value, "*") {
+			// only --strip-top-dir=false gets here with a wildcard, whose top directory is the one the wildcard is in
+			rootDir = filepath.Base(getPathBeforeFirstWildcard(cca.Source.Value))
+		}
 
 		/* In windows, when a user tries to copy whole volume (eg. D:\),  the upload destination
 		will contains "//"" in the files/directories names because of rootDir = "\" prefix.
@@ -21,11 +21,20 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
@@ -203,7 +212,169 @@ func startsWith(s string, t string) bool {
 	return len(s) >= len(t) && strings.EqualFold(s[0:len(t)], t)
 }
 
+// blobTypeForPattern is one entry of --blob-type-by-pattern: files whose names match pattern are uploaded as blobType
+type blobTypeForPattern struct {
+	pattern  string
+	blobType common.BlobType
+}
+
+// parseBlobTypeByPattern parses a list of pattern=BlobType pairs, separated by ';'. E.g. *.log=AppendBlob;*.tar=BlockBlob
+func parseBlobTypeByPattern(raw string) ([]blobTypeForPattern, error) {
+	result := make([]blobTypeForPattern, 0)
+	for _, entry := range strings.Split(raw, ";") {
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid blob-type-by-pattern entry '%s'. Each entry must be pattern=BlobType, for example *.log=AppendBlob", entry)
+		}
+		pattern := entry[:i]
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s' in blob-type-by-pattern: %w", pattern, err)
+		}
+		var blobType common.BlobType
+		if err := blobType.Parse(entry[i+1:]); err != nil {
+			return nil, fmt.Errorf("invalid blob type '%s' in blob-type-by-pattern. Valid values are BlockBlob, PageBlob and AppendBlob", entry[i+1:])
+		}
+		if blobType == common.EBlobType.Detect() {
+			return nil, fmt.Errorf("blob-type-by-pattern cannot choose Detect for '%s'. Files that match no pattern are uploaded as --blob-type says", pattern)
+		}
+
+		result = append(result, blobTypeForPattern{pattern: pattern, blobType: blobType})
+	}
+	return result, nil
+}
+
+// loadContentTypeMap reads the file of --content-type-map, which gives the content type of each file extension.
+// A .csv file has an extension,content-type pair on each line, and may start with an "extension,content-type" header.
+// Any other file is JSON, such as {".md": "text/markdown"}. Extensions are matched case-insensitively, and may be
+// given with or without their leading dot. Every entry is checked here, so that a mistake fails the job before it starts
+func loadContentTypeMap(fileName string) (map[string]string, error) {
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read content-type-map: %w", err)
+	}
+
+	result := make(map[string]string)
+	add := func(where, extension, contentType string) error {
+		ext := strings.ToLower(strings.TrimSpace(extension))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if len(ext) < 2 || strings.ContainsAny(ext[1:], `./\ `) {
+			return fmt.Errorf("invalid extension '%s' in content-type-map %s, %s. Give one extension, such as .md", extension, fileName, where)
+		}
+		if err := validateContentType(contentType); err != nil {
+			return fmt.Errorf("invalid content type for '%s' in content-type-map %s, %s: %w", extension, fileName, where, err)
+		}
+		if existing, ok := result[ext]; ok && existing != contentType {
+			return fmt.Errorf("content-type-map %s gives '%s' two content types, '%s' and '%s' (%s)", fileName, ext, existing, contentType, where)
+		}
+		result[ext] = contentType
+		return nil
+	}
+
+	if strings.EqualFold(filepath.Ext(fileName), ".csv") {
+		r := csv.NewReader(bytes.NewReader(raw))
+		r.FieldsPerRecord = -1 // a wrong number of fields is reported below, with a clearer message
+		r.TrimLeadingSpace = true
+		for first := true; ; first = false {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("cannot parse content-type-map %s: %w", fileName, err)
+			}
+			line, _ := r.FieldPos(0)
+			if first && strings.EqualFold(strings.TrimSpace(record[0]), "extension") {
+				continue // the header
+			}
+			if len(record) != 2 {
+				return nil, fmt.Errorf("content-type-map %s, line %d has %d fields. Each line must be extension,content-type, for example .md,text/markdown", fileName, line, len(record))
+			}
+			if err = add(fmt.Sprintf("line %d", line), record[0], strings.TrimSpace(record[1])); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		entries := make(map[string]string)
+		if err = json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("cannot parse content-type-map %s. It must be a JSON object of extensions and content types, such as {\".md\": \"text/markdown\"}, or a .csv file: %w", fileName, err)
+		}
+		for extension, contentType := range entries {
+			if err = add(fmt.Sprintf("entry '%s'", extension), extension, contentType); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("content-type-map %s has no entries", fileName)
+	}
+	return result, nil
+}
+
+// validateContentType checks that s is a single media type, such as text/markdown
+func validateContentType(s string) error {
+	mediaType, _, err := mime.ParseMediaType(s)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid content type: %w", s, err)
+	}
+	if !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("'%s' is not a valid content type, which must be type/subtype, such as text/plain", s)
+	}
+	return nil
+}
+
+// blobTypeForName returns the blob type of the first pattern that matches the file name, and false if none does
+func blobTypeForName(patterns []blobTypeForPattern, name string) (common.BlobType, bool) {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p.pattern, name); matched {
+			return p.blobType, true
+		}
+	}
+	return common.EBlobType.Detect(), false
+}
+
+func anyBlobTypeIs(patterns []blobTypeForPattern, blobType common.BlobType) bool {
+	for _, p := range patterns {
+		if p.blobType == blobType {
+			return true
+		}
+	}
+	return false
+}
+
 /////////////////////////////////////////////////////////////////////////////////////////////////
 type s3URLPartsExtension struct {
 	common.S3URLParts
 }
+
+// setS3CompatibleEndpoint makes URLs on the host of the given S3-compatible endpoint read as S3 URLs.
+// An empty endpoint leaves only AWS recognised.
+func setS3CompatibleEndpoint(endpoint string, forcePathStyle, skipSSLVerify bool) error {
+	if endpoint == "" {
+		if forcePathStyle || skipSSLVerify {
+			return errors.New("s3-force-path-style and s3-skip-ssl-verify can only be used with s3-endpoint")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("s3-endpoint must be the URL of the service, such as https://s3.example.com or http://localhost:9000, not %q", endpoint)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return errors.New("s3-endpoint must be the URL of the service only. Name the bucket in the source URL instead")
+	}
+
+	common.SetS3CompatibleEndpoint(&common.S3CompatibleEndpoint{
+		Host:           u.Host,
+		Secure:         u.Scheme == "https",
+		ForcePathStyle: forcePathStyle,
+		SkipSSLVerify:  skipSSLVerify,
+	})
+	return nil
+}
@@ -21,8 +21,10 @@
 package cmd
 
 import (
-	chk "gopkg.in/check.v1"
 	"net/url"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
 )
 
 type copyUtilTestSuite struct{}
@@ -79,3 +81,61 @@ func (s *copyUtilTestSuite) TestIPIsContainerOrBlob(c *chk.C) {
 	c.Assert(isContainerURL, chk.Equals, true) // URL endpoints do not contain the account in the path, making the container the first entry.
 	// The behaviour isn't too different from here.
 }
+
+func (s *copyUtilTestSuite) TestOverwriteIfSourceNewerNeedsLastModifiedTimes(c *chk.C) {
+	ifSourceNewer := common.EOverwriteOption.IfSourceNewer()
+
+	for _, fromTo := range []common.FromTo{common.EFromTo.LocalBlob(), common.EFromTo.BlobLocal(), common.EFromTo.FileFile(), common.EFromTo.S3Blob()} {
+		c.Assert(validateOverwriteOption(ifSourceNewer, fromTo), chk.IsNil)
+	}
+
+	err := validateOverwriteOption(ifSourceNewer, common.EFromTo.PipeBlob())
+	c.Assert(err, chk.NotNil)
+	c.Assert(err.Error(), StringContains, "source is Pipe")
+
+	err = validateOverwriteOption(ifSourceNewer, common.EFromTo.BlobPipe())
+	c.Assert(err, chk.NotNil)
+	c.Assert(err.Error(), StringContains, "destination is Pipe")
+
+	// other overwrite options don't compare times, so they can be used anywhere
+	c.Assert(validateOverwriteOption(common.EOverwriteOption.False(), common.EFromTo.BlobPipe()), chk.IsNil)
+}
+
+func (s *copyUtilTestSuite) TestSymlinkHandlingFlags(c *chk.C) {
+	var handling common.SymlinkHandlingType
+
+	c.Assert(handling.Determine(false, false), chk.IsNil)
+	c.Assert(handling, chk.Equals, common.ESymlinkHandlingType.Skip()) // the default
+	c.Assert(handling.Determine(true, false), chk.IsNil)
+	c.Assert(handling, chk.Equals, common.ESymlinkHandlingType.Follow())
+	c.Assert(handling.Determine(false, true), chk.IsNil)
+	c.Assert(handling, chk.Equals, common.ESymlinkHandlingType.Preserve())
+	c.Assert(handling.Determine(true, true), chk.NotNil)
+
+	// preserved symlinks are kept as blobs, so they can only go to or come from Blob
+	preserve := common.ESymlinkHandlingType.Preserve()
+	c.Assert(validateSymlinkHandlingMode(preserve, common.EFromTo.LocalBlob()), chk.IsNil)
+	c.Assert(validateSymlinkHandlingMode(preserve, common.EFromTo.BlobLocal()), chk.IsNil)
+	c.Assert(validateSymlinkHandlingMode(preserve, common.EFromTo.LocalFile()), chk.NotNil)
+	c.Assert(validateSymlinkHandlingMode(common.ESymlinkHandlingType.Follow(), common.EFromTo.LocalFile()), chk.IsNil)
+}
+
+func (s *copyUtilTestSuite) TestExcludedContainerNames(c *chk.C) {
+	t := &blobAccountTraverser{excludeContainerNames: []string{"logs*", "backup"}}
+
+	for name, expected := range map[string]bool{
+		"logs":      true,
+		"logs-2021": true,
+		"backup":    true,
+		"backups":   false, // a name without a wildcard must match exactly
+		"data":      false,
+	} {
+		excluded, err := t.isContainerExcluded(name)
+		c.Assert(err, chk.IsNil)
+		c.Assert(excluded, chk.Equals, expected, chk.Commentf("container %s", name))
+	}
+
+	// a malformed pattern is reported, rather than silently matching nothing
+	_, err := (&blobAccountTraverser{excludeContainerNames: []string{"[logs"}}).isContainerExcluded("logs")
+	c.Assert(err, chk.NotNil)
+}
@@ -79,39 +79,12 @@ func GetUserOAuthTokenManagerInstance() *common.UserOAuthTokenManager {
 func GetOAuthTokenManagerInstance() (*common.UserOAuthTokenManager, error) {
 	var err error
 	autoOAuth.Do(func() {
-		var lca loginCmdArgs
 		if glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AutoLoginType()) == "" {
 			err = errors.New("no login type specified")
 			return
 		}
 
-		if tenantID := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.TenantID()); tenantID != "" {
-			lca.tenantID = tenantID
-		}
-
-		if endpoint := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AADEndpoint()); endpoint != "" {
-			lca.aadEndpoint = endpoint
-		}
-
-		// Fill up lca
-		switch glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AutoLoginType()) {
-		case "SPN":
-			lca.applicationID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ApplicationID())
-			lca.certPath = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.CertificatePath())
-			lca.certPass = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.CertificatePassword())
-			lca.clientSecret = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ClientSecret())
-			lca.servicePrincipal = true
-
-		case "MSI":
-			lca.identityClientID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityClientID())
-			lca.identityObjectID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityObjectID())
-			lca.identityResourceID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityResourceString())
-			lca.identity = true
-
-		case "DEVICE":
-			lca.identity = false
-		}
-
+		lca := autoLoginCmdArgs(autoLoginCloud)
 		lca.persistToken = false
 		err = lca.process()
 	})
@@ -123,6 +96,103 @@ func GetOAuthTokenManagerInstance() (*common.UserOAuthTokenManager, error) {
 	return GetUserOAuthTokenManagerInstance(), nil
 }
 
+// autoLoginCloud is the cloud whose authority auto-login gets its token from, unless AZCOPY_ACTIVE_DIRECTORY_ENDPOINT
+// names another. A command sets it, before it authenticates, to the cloud of the location that it authenticates to
+var autoLoginCloud = common.EAzureCloud.Public()
+
+// autoLoginCmdArgs fills the login arguments for auto-login from the environment
+func autoLoginCmdArgs(cloud common.AzureCloud) loginCmdArgs {
+	var lca loginCmdArgs
+	// an unknown cloud leaves the authority to login's default
+	lca.aadEndpoint = cloud.ActiveDirectoryEndpoint()
+
+	if tenantID := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.TenantID()); tenantID != "" {
+		lca.tenantID = tenantID
+	}
+
+	if endpoint := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AADEndpoint()); endpoint != "" {
+		lca.aadEndpoint = endpoint
+	}
+
+	// Fill up lca
+	switch glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AutoLoginType()) {
+	case "SPN":
+		lca.applicationID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ApplicationID())
+		lca.certPath = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.CertificatePath())
+		lca.certPass = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.CertificatePassword())
+		lca.clientSecret = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ClientSecret())
+		lca.servicePrincipal = true
+
+	case "MSI":
+		lca.identityClientID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityClientID())
+		lca.identityObjectID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityObjectID())
+		lca.identityResourceID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityResourceString())
+		lca.identity = true
+
+	case "DEVICE":
+		lca.identity = false
+
+	case "DEFAULT":
+		lca = defaultLoginCmdArgs(lca)
+	}
+
+	return lca
+}
+
+// checkTokenCloud refuses an OAuth token issued by the authority of a cloud other than the one that the location is
+// in, since the location's service would reject it for every transfer. Authorities that aren't one of the known
+// clouds', such as Azure Stack's, are left to the service to judge
+func checkTokenCloud(tokenInfo common.OAuthTokenInfo, cloud common.AzureCloud, side string) error {
+	tokenCloud := common.AzureCloudOfAuthority(tokenInfo.ActiveDirectoryEndpoint)
+	if cloud == common.EAzureCloud.Unknown() || tokenCloud == common.EAzureCloud.Unknown() || tokenCloud == cloud {
+		return nil
+	}
+	return fmt.Errorf("the OAuth token is from the authority of the %s cloud (%s), but the %s is in the %s cloud. "+
+		"Log in with --aad-endpoint %s, or for auto-login set %s to it",
+		tokenCloud, tokenInfo.ActiveDirectoryEndpoint, side, cloud, cloud.ActiveDirectoryEndpoint(), common.EEnvironmentVariable.AADEndpoint().Name)
+}
+
+// defaultLoginCmdArgs chooses how to log in from the standard AZURE_* environment variables, in the order that the
+// Azure SDKs' DefaultAzureCredential tries them: a service principal with a secret or certificate, then workload
+// identity (as set up by AKS in its pods), then managed identity. The AZURE_* tenant and authority host, where set,
+// win over AzCopy's own
+func defaultLoginCmdArgs(lca loginCmdArgs) loginCmdArgs {
+	if tenantID := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureTenantID()); tenantID != "" {
+		lca.tenantID = tenantID
+	}
+	if authorityHost := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureAuthorityHost()); authorityHost != "" {
+		lca.aadEndpoint = strings.TrimSuffix(authorityHost, "/")
+	}
+	clientID := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureClientID())
+
+	clientSecret := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureClientSecret())
+	certPath := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureClientCertificatePath())
+	federatedTokenFile := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureFederatedTokenFile())
+	switch {
+	case clientSecret != "" || certPath != "":
+		lca.applicationID = clientID
+		lca.clientSecret = clientSecret
+		lca.certPath = certPath
+		lca.certPass = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AzureClientCertificatePassword())
+		lca.servicePrincipal = true
+	case federatedTokenFile != "":
+		lca.applicationID = clientID
+		lca.federatedTokenFile = federatedTokenFile
+		lca.workloadIdentity = true
+	default:
+		lca.identityClientID = clientID
+		if clientID == "" {
+			lca.identityClientID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityClientID())
+		}
+		lca.identityObjectID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityObjectID())
+		lca.identityResourceID = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ManagedIdentityResourceString())
+		lca.identity = true
+		lca.tenantID = "" // managed identity has no use for a tenant, and login refuses one
+		lca.aadEndpoint = ""
+	}
+	return lca
+}
+
 // ==============================================================================================
 // Get credential type methods
 // ==============================================================================================
@@ -469,7 +539,8 @@ func checkAuthSafeForTarget(ct common.CredentialType, resource, extraSuffixesAAD
 			parts, err := common.NewS3URLParts(*u) // strip any leading bucket name from URL, to get an endpoint we can pass to s3utils
 			if err == nil {
 				u, err := url.Parse("https://" + parts.Endpoint)
-				ok = err == nil && s3utils.IsAmazonEndpoint(*u)
+				_, isCompatible := common.GetS3CompatibleEndpoint(parts.Endpoint) // given explicitly with --s3-endpoint
+				ok = isCompatible || (err == nil && s3utils.IsAmazonEndpoint(*u))
 			}
 		}
 
@@ -477,7 +548,7 @@ func checkAuthSafeForTarget(ct common.CredentialType, resource, extraSuffixesAAD
 			return fmt.Errorf(
 				"s3 authentication to %s is not currently suported in AzCopy", host)
 		}
-	case common.ECredentialType.GoogleAppCredentials():
+	case common.ECredentialType.GoogleAppCredentials(), common.ECredentialType.GoogleHMACKey():
 		if resourceType != common.ELocation.GCP() {
 			return fmt.Errorf("Google Application Credentials to %s is not valid", resourceType.String())
 		}
@@ -564,6 +635,12 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 			if credType, err = getAzureFileCredentialType(); err != nil {
 				return common.ECredentialType.Unknown(), false, err
 			}
+			// there's no SAS, so auto login is presumably meant to cover this too. Say that it can't, rather than letting
+			// each request fail for want of authentication
+			if autoLoginType := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AutoLoginType()); autoLoginType != "" {
+				return common.ECredentialType.Unknown(), false, fmt.Errorf("the %s login type, chosen by %s, can't be used with Azure Files, because AzCopy doesn't support OAuth authentication to Azure Files. Add a SAS to the URL of %s instead",
+					autoLoginType, common.EEnvironmentVariable.AutoLoginType().Name, common.URLStringExtension(resource).RedactSecretQueryParamForLogging())
+			}
 		case common.ELocation.BlobFS():
 			credType, err = getBlobFSCredentialType(ctx, resource, resourceSAS != "")
 			if azErr, ok := err.(common.AzError); ok && azErr.Equals(common.EAzError.LoginCredMissing()) {
@@ -586,10 +663,15 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 			credType = common.ECredentialType.S3AccessKey()
 		case common.ELocation.GCP():
 			googleAppCredentials := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleAppCredentials())
-			if googleAppCredentials == "" {
-				return common.ECredentialType.Unknown(), false, errors.New("GOOGLE_APPLICATION_CREDENTIALS environment variable must be set before using GCP transfer feature")
+			hmacAccessID := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleHMACAccessID())
+			hmacSecret := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleHMACSecret())
+			if googleAppCredentials != "" {
+				credType = common.ECredentialType.GoogleAppCredentials()
+			} else if hmacAccessID != "" && hmacSecret != "" {
+				credType = common.ECredentialType.GoogleHMACKey()
+			} else {
+				return common.ECredentialType.Unknown(), false, errors.New("GOOGLE_APPLICATION_CREDENTIALS, or GOOGLE_HMAC_ACCESS_ID and GOOGLE_HMAC_SECRET, environment variables must be set before using GCP transfer feature")
 			}
-			credType = common.ECredentialType.GoogleAppCredentials()
 		}
 	}
 
@@ -0,0 +1,176 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/common/parallel"
+	"github.com/Azure/azure-storage-file-go/azfile"
+)
+
+// enumerationTelemetryToLog is the value of enumeration-telemetry that writes the records to the scanning log,
+// rather than to a file of their own
+const enumerationTelemetryToLog = "log"
+
+// directoryListingRecord is the telemetry of one directory listing, as written to the sidecar file, one per line
+type directoryListingRecord struct {
+	Side        string // source or destination
+	Directory   string
+	Worker      int
+	Start       time.Time
+	DurationMs  float64
+	Entries     int
+	Directories int
+	Error       string `json:",omitempty"`
+}
+
+// enumerationTelemetry records how long each directory took to list, and how much was in it, so that the directories
+// that stall the enumeration of a huge tree can be found. Only traversers that list directory by directory, in
+// parallel, produce records: local, Azure Files, and Blob when it isn't listed flat
+type enumerationTelemetry struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	log    func(string)
+}
+
+// enumerationTelemetryFromFlag checks the value of enumeration-telemetry: empty for none, "log", or the path of the
+// sidecar file, which is made absolute
+func enumerationTelemetryFromFlag(value string) (string, error) {
+	if value == "" || value == enumerationTelemetryToLog {
+		return value, nil
+	}
+	absPath, err := filepath.Abs(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid enumeration-telemetry path %s: %w", value, err)
+	}
+	if fi, err := os.Stat(filepath.Dir(absPath)); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("the folder for the enumeration telemetry, %s, does not exist", filepath.Dir(absPath))
+	}
+	return absPath, nil
+}
+
+// startEnumerationTelemetry starts recording to the destination, if there is one, until AzCopy exits. The nil it
+// returns for none attaches nothing
+func startEnumerationTelemetry(destination string) (*enumerationTelemetry, error) {
+	if destination == "" {
+		return nil, nil
+	}
+	t, err := newEnumerationTelemetry(destination)
+	if err != nil {
+		return nil, err
+	}
+	glcm.RegisterCloseFunc(t.close)
+	return t, nil
+}
+
+// newEnumerationTelemetry writes the records to the file at the path, or to the scanning log for "log"
+func newEnumerationTelemetry(destination string) (*enumerationTelemetry, error) {
+	if destination == enumerationTelemetryToLog {
+		return &enumerationTelemetry{log: func(msg string) {
+			if azcopyScanningLogger != nil {
+				azcopyScanningLogger.Log(pipeline.LogInfo, msg)
+			}
+		}}, nil
+	}
+
+	f, err := os.Create(destination)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the enumeration telemetry file: %w", err)
+	}
+	return &enumerationTelemetry{file: f, writer: bufio.NewWriter(f)}, nil
+}
+
+// attach returns a context that has the traversers using it report their listings, as those of the side
+func (t *enumerationTelemetry) attach(ctx context.Context, side string) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return parallel.WithListingObserver(ctx, func(listing parallel.DirectoryListing) {
+		t.observe(side, listing)
+	})
+}
+
+func (t *enumerationTelemetry) observe(side string, listing parallel.DirectoryListing) {
+	record := directoryListingRecord{
+		Side:        side,
+		Directory:   telemetryDirectoryName(listing.Directory),
+		Worker:      listing.Worker,
+		Start:       listing.Start,
+		DurationMs:  float64(listing.Duration) / float64(time.Millisecond),
+		Entries:     listing.Entries,
+		Directories: listing.Directories,
+	}
+	if listing.Err != nil {
+		record.Error = listing.Err.Error()
+	}
+
+	if t.log != nil {
+		msg := fmt.Sprintf("Enumeration telemetry: listed %s directory '%s' in %.3fms on worker %d, finding %d entries, %d of them directories",
+			record.Side, record.Directory, record.DurationMs, record.Worker, record.Entries, record.Directories)
+		if record.Error != "" {
+			msg += ". Error: " + record.Error
+		}
+		t.log(msg)
+		return
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = t.writer.Write(append(line, '\n'))
+}
+
+func (t *enumerationTelemetry) close() {
+	if t.file == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.writer.Flush(); err != nil {
+		glcm.Info("Failed to write the enumeration telemetry: " + err.Error())
+	}
+	_ = t.file.Close()
+}
+
+// telemetryDirectoryName is the path of a directory that a traverser crawled, without any SAS
+func telemetryDirectoryName(dir parallel.Directory) string {
+	switch d := dir.(type) {
+	case string:
+		return d // a local path, or a Blob prefix
+	case azfile.DirectoryURL:
+		return azfile.NewFileURLParts(d.URL()).DirectoryOrFilePath
+	default:
+		return common.URLStringExtension(fmt.Sprint(d)).RedactSecretQueryParamForLogging()
+	}
+}
@@ -28,7 +28,7 @@ Copies source data to a destination location. The supported directions are:
   - Azure Files (SAS) -> Azure Files (SAS)
   - Azure Files (SAS) -> Azure Blob (SAS or OAuth authentication)
   - AWS S3 (Access Key) -> Azure Block Blob (SAS or OAuth authentication)
-  - Google Cloud Storage (Service Account Key) -> Azure Block Blob (SAS or OAuth authentication)
+  - Google Cloud Storage (Service Account Key or HMAC Key) -> Azure Block Blob (SAS or OAuth authentication)
 
 Please refer to the examples for more information.
 
@@ -66,6 +66,10 @@ Upload a single file by using OAuth and piping (block blobs only):
 
   - cat "/path/to/file.txt" | azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/blob]" --from-to PipeBlob
 
+Upload the output of another command, whose size isn't known in advance, by giving - as the source (block blobs only). Unlike --from-to PipeBlob, which prints nothing, this shows the bytes uploaded so far:
+
+  - tar -c "/path/to/dir" | azcopy cp - "https://[account].blob.core.windows.net/[container]/[path/to/blob]?[SAS]"
+
 Upload an entire directory by using a SAS token:
   
   - azcopy cp "/path/to/dir" "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true
@@ -104,6 +108,10 @@ Download a single file by using OAuth and then piping the output to a file (bloc
   
   - azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/blob]" --from-to BlobPipe > "/path/to/file.txt"
 
+Download a single file into another command, by giving - as the destination. Only the file is written to stdout; progress and other messages go to stderr (--from-to BlobPipe prints nothing at all):
+
+  - azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/blob]?[SAS]" - | tar -x
+
 Download an entire directory by using a SAS token:
   
   - azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" "/path/to/dir" --recursive=true
@@ -130,6 +138,14 @@ Download all the versions of a blob from Azure Storage to local directory. Ensur
 
   - azcopy cp "https://[srcaccount].blob.core.windows.net/[containername]/[blobname]" "/path/to/dir" --list-of-versions="/another/path/to/dir/[versionidsFile]"
 
+Download every version of every blob in a virtual directory. Each version is saved with its version id in front of its name.
+
+  - azcopy cp "https://[srcaccount].blob.core.windows.net/[containername]/[path/to/directory]?[SAS]" "/path/to/dir" --recursive --list-versions
+
+Download one particular version of a blob.
+
+  - azcopy cp "https://[srcaccount].blob.core.windows.net/[containername]/[blobname]?versionid=[versionid]&[SAS]" "/path/to/file.txt"
+
 Copy a single blob to another blob by using a SAS token.
 
   - azcopy cp "https://[srcaccount].blob.core.windows.net/[container]/[path/to/blob]?[SAS]" "https://[destaccount].blob.core.windows.net/[container]/[path/to/blob]?[SAS]"
@@ -192,6 +208,10 @@ Copy an entire bucket to Blob Storage from Google Cloud Storage (GCS) by using a
  
   - azcopy cp "https://storage.cloud.google.com/[bucket]" "https://[destaccount].blob.core.windows.net/?[SAS]" --recursive=true
 
+Copy an entire bucket to Blob Storage from Google Cloud Storage (GCS) by using an HMAC key and a SAS token. First, set the environment variables GOOGLE_HMAC_ACCESS_ID and GOOGLE_HMAC_SECRET for GCS source, and leave GOOGLE_APPLICATION_CREDENTIALS unset.
+ 
+  - azcopy cp "https://storage.cloud.google.com/[bucket]" "https://[destaccount].blob.core.windows.net/?[SAS]" --recursive=true
+
 Copy all buckets to Blob Storage from Google Cloud Storage (GCS) by using a service account key and a SAS token. First, set the environment variables GOOGLE_APPLICATION_CREDENTIALS and GOOGLE_CLOUD_PROJECT=<project-id> for GCS source
  
   - azcopy cp "https://storage.cloud.google.com/" "https://[destaccount].blob.core.windows.net/?[SAS]" --recursive=true
@@ -201,6 +221,31 @@ Copy a subset of buckets by using a wildcard symbol (*) in the bucket name from
   - azcopy cp "https://storage.cloud.google.com/[bucket*name]/" "https://[destaccount].blob.core.windows.net/?[SAS]" --recursive=true
 `
 
+// ===================================== MOVE COMMAND ===================================== //
+const moveCmdShortDescription = "Moves source data to a destination location, by copying it and then deleting the source"
+
+const moveCmdLongDescription = `
+Moves source data to a destination location. A move is a copy, followed by the deletion of each source file that was
+transferred successfully. The deletion only happens once the whole copy job is done, so a source file is never deleted
+before its destination has been written. Files that fail to transfer, or are skipped, are left at the source.
+
+The move command accepts all the flags of the copy command. The supported sources are local directories, Blob, Azure Files
+and ADLS Gen 2. Only files are deleted; folders are left in place at the source.
+
+The job summary reports how many source files were deleted, and how many could not be deleted (those have still been
+copied to the destination). Source deletion is performed by the move command itself, so if the job is cancelled and later
+resumed with 'azcopy jobs resume', the resumed job will copy the remaining files but will not delete any sources.
+`
+
+const moveCmdExample = `Move a local directory to a blob container:
+
+  - azcopy move "/path/to/dir" "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true
+
+Move a virtual directory from one blob container to another:
+
+  - azcopy move "https://[srcaccount].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" "https://[destaccount].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true
+`
+
 // ===================================== ENV COMMAND ===================================== //
 const envCmdShortDescription = "Shows the environment variables that you can use to configure the behavior of AzCopy."
 
@@ -230,7 +275,10 @@ If you set the with-status flag, then only the list of transfers associated with
 const resumeJobsCmdShortDescription = "Resume the existing job with the given job ID."
 
 const resumeJobsCmdLongDescription = `
-Resume the existing job with the given job ID.`
+Resume the existing job with the given job ID.
+
+A job that was run with --checkpoint-path can be resumed with --resume-from set to the same folder. Only the transfers
+that had not completed are run again, and the ones that the original job's filters excluded stay excluded.`
 
 const removeJobsCmdShortDescription = "Remove all files associated with the given job ID."
 
@@ -373,13 +421,20 @@ Remove a single file from a Blob Storage account that has a hierarchical namespa
 Remove a single directory from a Blob Storage account that has a hierarchical namespace (include/exclude not supported):
 
    - azcopy rm "https://[account].dfs.core.windows.net/[container]/[path/to/directory]?[SAS]"
+
+Remove a large virtual directory, keeping the plan files of the job in a folder of your choice. If the removal is interrupted, resume it from that folder.
+The blobs that were already deleted are not deleted again (this applies to Blob and File storage, not to the hierarchical namespace examples above):
+
+   - azcopy rm "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true --checkpoint-path=/path/to/checkpoint
+   - azcopy jobs resume --resume-from=/path/to/checkpoint --source-sas="[SAS]"
 `
 
 // ===================================== SYNC COMMAND ===================================== //
 const syncCmdShortDescription = "Replicate source to the destination location"
 
 const syncCmdLongDescription = `
-The last modified times are used for comparison. The file is skipped if the last modified time in the destination is more recent. The supported pairs are:
+By default, the last modified times are used for comparison. The file is skipped if the last modified time in the destination is more recent.
+With --compare-by=MD5, the MD5 hashes are compared instead, and the file is skipped only if both ends have the same hash. The supported pairs are:
   
   - Local <-> Azure Blob / Azure File (either SAS or OAuth authentication can be used)
   - Azure Blob <-> Azure Blob (Source must include a SAS or is publicly accessible; either SAS or OAuth authentication can be used for destination)
@@ -404,8 +459,12 @@ The built-in lookup table is small but on Unix it is augmented by the local syst
 
 On Windows, MIME types are extracted from the registry.
 
-Please also note that sync works off of the last modified times exclusively. So in the case of Azure File <-> Azure File,
+Please also note that, unless --compare-by=MD5 is used, sync works off of the last modified times exclusively. So in the case of Azure File <-> Azure File,
 the header field Last-Modified is used instead of x-ms-file-change-time, which means that metadata changes at the source can also trigger a full copy.
+
+When comparing by MD5, a remote object is compared using the hash stored with it, so upload with --put-md5 to give later syncs a hash to compare.
+A file with no stored hash is always transferred. Local files have no stored hash, so each local file that exists at both ends is read in full to compute one,
+which makes the comparison much slower than comparing last modified times.
 `
 
 const syncCmdExample = `
@@ -26,9 +26,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-azcopy/v10/ste"
 	"github.com/spf13/cobra"
@@ -106,6 +109,8 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 
 		return common.Iffloat64(timeElapsed != 0, bytesInMb/timeElapsed, 0) * 8
 	}
+	throughput := computeThroughput()
+	glcm.ProgressEvent(common.NewProgressEvent(summary, throughput))
 
 	glcm.Progress(func(format common.OutputFormat) string {
 		if format == common.EOutputFormat.Json() {
@@ -120,7 +125,6 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 				scanningString = ""
 			}
 
-			throughput := computeThroughput()
 			throughputString := fmt.Sprintf("2-sec Throughput (Mb/s): %v", jobsAdmin.ToFixed(throughput, 4))
 			if throughput == 0 {
 				// As there would be case when no bits sent from local, e.g. service side copy, when throughput = 0, hide it.
@@ -130,40 +134,43 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 			// indicate whether constrained by disk or not
 			perfString, diskString := getPerfDisplayText(summary.PerfStrings, summary.PerfConstraint, duration, false)
 
-			return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s",
+			return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s%s",
 				summary.PercentComplete,
 				summary.TransfersCompleted,
 				summary.TransfersFailed,
 				summary.TotalTransfers-(summary.TransfersCompleted+summary.TransfersFailed+summary.TransfersSkipped),
-				summary.TransfersSkipped, summary.TotalTransfers, scanningString, perfString, throughputString, diskString)
+				summary.TransfersSkipped, summary.TotalTransfers, scanningString, perfString, throughputString, diskString, getETADisplayText(summary))
 		}
 	})
 
 	if jobDone {
-		exitCode := common.EExitCode.Success()
-		if summary.TransfersFailed > 0 {
-			exitCode = common.EExitCode.Error()
-		}
+		exitCode := common.ExitCodeForTransfers(summary.TransfersCompleted, summary.TransfersFailed)
 
 		lcm.Exit(func(format common.OutputFormat) string {
+			output := fmt.Sprintf(
+				"\n\nJob %s summary\nElapsed Time (Minutes): %v\nNumber of File Transfers: %v\nNumber of Folder Property Transfers: %v\nTotal Number Of Transfers: %v\nNumber of Transfers Completed: %v\nNumber of Transfers Failed: %v\nNumber of Transfers Skipped: %v\nTotalBytesTransferred: %v\nFinal Job Status: %v\n",
+				summary.JobID.String(),
+				jobsAdmin.ToFixed(duration.Minutes(), 4),
+				summary.FileTransfers,
+				summary.FolderPropertyTransfers,
+				summary.TotalTransfers,
+				summary.TransfersCompleted,
+				summary.TransfersFailed,
+				summary.TransfersSkipped,
+				summary.TotalBytesTransferred,
+				summary.JobStatus)
+
+			// log to job log, whatever the output format, so that the summary is kept even with --output-level=quiet
+			if jobMan, exists := jobsAdmin.JobsAdmin.JobMgr(summary.JobID); exists {
+				jobMan.Log(pipeline.LogInfo, output)
+			}
+
 			if format == common.EOutputFormat.Json() {
 				jsonOutput, err := json.Marshal(summary)
 				common.PanicIfErr(err)
 				return string(jsonOutput)
-			} else {
-				return fmt.Sprintf(
-					"\n\nJob %s summary\nElapsed Time (Minutes): %v\nNumber of File Transfers: %v\nNumber of Folder Property Transfers: %v\nTotal Number Of Transfers: %v\nNumber of Transfers Completed: %v\nNumber of Transfers Failed: %v\nNumber of Transfers Skipped: %v\nTotalBytesTransferred: %v\nFinal Job Status: %v\n",
-					summary.JobID.String(),
-					jobsAdmin.ToFixed(duration.Minutes(), 4),
-					summary.FileTransfers,
-					summary.FolderPropertyTransfers,
-					summary.TotalTransfers,
-					summary.TransfersCompleted,
-					summary.TransfersFailed,
-					summary.TransfersSkipped,
-					summary.TotalBytesTransferred,
-					summary.JobStatus)
 			}
+			return output
 		}, exitCode)
 	}
 
@@ -183,11 +190,22 @@ func init() {
 			// the resume command requires necessarily to have an argument
 			// resume jobId -- resumes all the parts of an existing job for given jobId
 
-			// If no argument is passed then it is not valid
-			if len(args) != 1 {
+			// If no argument is passed then it is not valid, unless the job can be found from its checkpoint folder
+			if len(args) > 1 || (len(args) == 0 && resumeCmdArgs.resumeFrom == "") {
 				return errors.New("this command requires jobId to be passed as argument")
 			}
-			resumeCmdArgs.jobID = args[0]
+			if len(args) == 1 {
+				resumeCmdArgs.jobID = args[0]
+			}
+
+			// the plan files are read from the checkpoint folder, instead of from the usual location.
+			// This must be known before the STE starts, which happens before the command itself runs
+			if resumeCmdArgs.resumeFrom != "" {
+				if _, err := os.Stat(resumeCmdArgs.resumeFrom); err != nil {
+					return fmt.Errorf("cannot resume from %s: %w", resumeCmdArgs.resumeFrom, err)
+				}
+				cmdLineCheckpointPath = resumeCmdArgs.resumeFrom
+			}
 
 			glcm.EnableInputWatcher()
 			if cancelFromStdin {
@@ -207,25 +225,105 @@ func init() {
 	jobsCmd.AddCommand(resumeCmd)
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.includeTransfer, "include", "", "Filter: only include these failed transfer(s) when resuming the job. "+
 		"Files should be separated by ';'.")
+	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.resumeFrom, "resume-from", "", "Resume the job whose plan files were kept in this folder, by running it with --checkpoint-path. "+
+		"The job ID may then be omitted, if it is the only job in the folder.")
+	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.destination, "destination", "", "Resume the job into this destination, instead of its own, e.g. another account or container when the original has problems. "+
+		"It must be the same kind of location as the job's destination, at the same level, e.g. a container for a container, since the paths of the job's files are relative to it. Its SAS may be given with it, or with --destination-sas. "+
+		"Only the transfers that didn't complete run again, and they write to the new destination, so the job's files are then split across the two: "+
+		"those that completed before stay in the original destination, and are not copied to the new one. "+
+		"The job keeps the new destination, so it is where later resumes write, and what 'jobs show' reports for all of the job's transfers, including those that completed before.")
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.excludeTransfer, "exclude", "", "Filter: exclude these failed transfer(s) when resuming the job. "+
 		"Files should be separated by ';'.")
 	// oauth options
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.SourceSAS, "source-sas", "", "Source SAS token of the source for a given Job ID.")
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.DestinationSAS, "destination-sas", "", "destination SAS token of the destination for a given Job ID.")
+	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.s3Endpoint, "s3-endpoint", "", "The URL of the S3-compatible service that the job copies from, as given to the copy with --s3-endpoint.")
+	resumeCmd.PersistentFlags().BoolVar(&resumeCmdArgs.s3ForcePathStyle, "s3-force-path-style", false, "Address buckets of the --s3-endpoint service in the path of URLs, as given to the copy.")
+	resumeCmd.PersistentFlags().BoolVar(&resumeCmdArgs.s3SkipSSLVerify, "s3-skip-ssl-verify", false, "Accept any certificate from the --s3-endpoint service, as given to the copy.")
 }
 
 type resumeCmdArgs struct {
 	jobID           string
 	includeTransfer string
 	excludeTransfer string
+	resumeFrom      string
+	// the destination to resume the job into, instead of its own
+	destination string
 
 	SourceSAS      string
 	DestinationSAS string
+
+	// the S3-compatible service that the job copies from, if it is not AWS
+	s3Endpoint       string
+	s3ForcePathStyle bool
+	s3SkipSSLVerify  bool
+}
+
+// cookDestinationOverride checks that the destination that a job is to be resumed into is compatible with the job's
+// own: the same kind of location, and, for a remote one, at the same level, since the paths that the job's transfers
+// write to are relative to it
+func cookDestinationOverride(override string, fromTo common.FromTo, original string) (common.ResourceString, error) {
+	to := fromTo.To()
+	switch to {
+	case common.ELocation.Local():
+		if InferArgumentLocation(override) != common.ELocation.Local() {
+			return common.ResourceString{}, fmt.Errorf("the job writes to local files, so it can only be resumed into another local folder, which %s isn't",
+				common.URLStringExtension(override).RedactSecretQueryParamForLogging())
+		}
+		absPath, err := filepath.Abs(override)
+		if err != nil {
+			return common.ResourceString{}, fmt.Errorf("invalid destination %s: %w", override, err)
+		}
+		return SplitResourceString(absPath, to)
+	case common.ELocation.Blob(), common.ELocation.File(), common.ELocation.BlobFS():
+	default:
+		return common.ResourceString{}, fmt.Errorf("a %s job can't be resumed into another destination, since it doesn't write to one", fromTo.String())
+	}
+
+	// a URL whose host doesn't say which service it is, such as a custom domain or an IP address, is taken to be the
+	// right one. Those are inferred to be Local or Unknown
+	location := InferArgumentLocation(override)
+	if !startsWith(override, "http") || (location != to && location != common.ELocation.Local() && location != common.ELocation.Unknown()) {
+		return common.ResourceString{}, fmt.Errorf("the job writes to %s, so it can only be resumed into another %s destination, which %s isn't",
+			to.String(), to.String(), common.URLStringExtension(override).RedactSecretQueryParamForLogging())
+	}
+	resource, err := SplitResourceString(override, to)
+	if err != nil {
+		return resource, err
+	}
+
+	originalContainer, err := GetContainerName(original, to)
+	if err != nil {
+		return resource, err
+	}
+	newContainer, err := GetContainerName(resource.Value, to)
+	if err != nil {
+		return resource, fmt.Errorf("invalid destination %s: %w", resource.Value, err)
+	}
+	if (originalContainer == "") != (newContainer == "") {
+		level := "a container"
+		if originalContainer == "" {
+			level = "an account"
+		}
+		return resource, fmt.Errorf("the job's destination, %s, is %s, so the destination it is resumed into must be one too", original, level)
+	}
+	return resource, nil
 }
 
 // processes the resume command,
 // dispatches the resume Job order to the storage engine.
 func (rca resumeCmdArgs) process() error {
+	if err := setS3CompatibleEndpoint(rca.s3Endpoint, rca.s3ForcePathStyle, rca.s3SkipSSLVerify); err != nil {
+		return err
+	}
+
+	if rca.jobID == "" {
+		var err error
+		if rca.jobID, err = rca.onlyJobInCheckpoint(); err != nil {
+			return err
+		}
+	}
+
 	// parsing the given JobId to validate its format correctness
 	jobID, err := common.ParseJobID(rca.jobID)
 	if err != nil {
@@ -281,6 +379,19 @@ func (rca resumeCmdArgs) process() error {
 		return errors.New("resuming benchmark jobs is not supported")
 	}
 
+	destination := getJobFromToResponse.Destination
+	var destinationOverride common.ResourceString
+	if rca.destination != "" {
+		if destinationOverride, err = cookDestinationOverride(rca.destination, getJobFromToResponse.FromTo, getJobFromToResponse.Destination); err != nil {
+			return err
+		}
+		if rca.DestinationSAS == "" {
+			rca.DestinationSAS = destinationOverride.SAS
+		}
+		destinationOverride.SAS = "" // it travels as the DestinationSAS
+		destination = destinationOverride.Value
+	}
+
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 	// Initialize credential info.
 	credentialInfo := common.CredentialInfo{}
@@ -288,7 +399,7 @@ func (rca resumeCmdArgs) process() error {
 	if credentialInfo.CredentialType, err = getCredentialType(ctx, rawFromToInfo{
 		fromTo:         getJobFromToResponse.FromTo,
 		source:         getJobFromToResponse.Source,
-		destination:    getJobFromToResponse.Destination,
+		destination:    destination,
 		sourceSAS:      rca.SourceSAS,
 		destinationSAS: rca.DestinationSAS,
 	}, common.CpkOptions{}); err != nil {
@@ -307,12 +418,13 @@ func (rca resumeCmdArgs) process() error {
 	var resumeJobResponse common.CancelPauseResumeResponse
 	Rpc(common.ERpcCmd.ResumeJob(),
 		&common.ResumeJobRequest{
-			JobID:           jobID,
-			SourceSAS:       rca.SourceSAS,
-			DestinationSAS:  rca.DestinationSAS,
-			CredentialInfo:  credentialInfo,
-			IncludeTransfer: includeTransfer,
-			ExcludeTransfer: excludeTransfer,
+			JobID:               jobID,
+			SourceSAS:           rca.SourceSAS,
+			DestinationSAS:      rca.DestinationSAS,
+			CredentialInfo:      credentialInfo,
+			IncludeTransfer:     includeTransfer,
+			ExcludeTransfer:     excludeTransfer,
+			DestinationOverride: destinationOverride,
 		},
 		&resumeJobResponse)
 
@@ -325,3 +437,21 @@ func (rca resumeCmdArgs) process() error {
 
 	return nil
 }
+
+// onlyJobInCheckpoint finds the ID of the job to resume, when only the checkpoint folder that holds its plan files was given
+func (rca resumeCmdArgs) onlyJobInCheckpoint() (string, error) {
+	var listJobsResponse common.ListJobsResponse
+	Rpc(common.ERpcCmd.ListJobs(), common.EJobStatus.All(), &listJobsResponse)
+	if listJobsResponse.ErrorMessage != "" {
+		return "", errors.New(listJobsResponse.ErrorMessage)
+	}
+
+	switch len(listJobsResponse.JobIDDetails) {
+	case 0:
+		return "", fmt.Errorf("no job was found in %s", rca.resumeFrom)
+	case 1:
+		return listJobsResponse.JobIDDetails[0].JobId.String(), nil
+	default:
+		return "", fmt.Errorf("%s holds more than one job, so the ID of the job to resume must be given", rca.resumeFrom)
+	}
+}
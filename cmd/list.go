@@ -222,9 +222,9 @@ func (cooked cookedListCmdArgs) HandleListContainerCommand() (err error) {
 		}
 	}
 
-	traverser, err := InitResourceTraverser(source, cooked.location, &ctx, &credentialInfo, nil, nil,
+	traverser, err := InitResourceTraverser(source, cooked.location, &ctx, &credentialInfo, common.ESymlinkHandlingType.Skip(), "", nil,
 		true, false, false, common.EPermanentDeleteOption.None(), func(common.EntityType) {},
-		nil, false, pipeline.LogNone, common.CpkOptions{}, nil /* errorChannel */)
+		nil, false, false, pipeline.LogNone, common.CpkOptions{}, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 
 	if err != nil {
 		return fmt.Errorf("failed to initialize traverser: %s", err.Error())
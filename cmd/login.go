@@ -89,6 +89,7 @@ type loginCmdArgs struct {
 
 	identity         bool // Whether to use MSI.
 	servicePrincipal bool
+	workloadIdentity bool // Whether to exchange a federated token, as with AKS workload identity. Only for auto login
 
 	// Info of VM's user assigned identity, client or object ids of the service identity are required if
 	// your VM has multiple user-assigned managed identities.
@@ -103,6 +104,9 @@ type loginCmdArgs struct {
 	certPass      string
 	clientSecret  string
 	persistToken  bool
+
+	// the file holding the federated token that workload identity exchanges for access tokens for applicationID
+	federatedTokenFile string
 }
 
 type argValidity struct {
@@ -113,6 +117,14 @@ type argValidity struct {
 func (lca loginCmdArgs) validate() error {
 	// Only support one kind of oauth login at same time.
 	switch {
+	case lca.workloadIdentity:
+		if lca.identity || lca.servicePrincipal {
+			return errors.New("you can only log in with one type of auth at once")
+		}
+
+		if lca.tenantID == "" || lca.applicationID == "" || lca.federatedTokenFile == "" {
+			return errors.New("workload identity auth requires a tenant ID, an application (client) ID and a federated token file")
+		}
 	case lca.identity:
 		if lca.servicePrincipal {
 			return errors.New("you can only log in with one type of auth at once")
@@ -178,6 +190,12 @@ func (lca loginCmdArgs) process() error {
 
 			glcm.Info("SPN Auth via secret succeeded.")
 		}
+	case lca.workloadIdentity:
+		if _, err := uotm.WorkloadIdentityLogin(lca.tenantID, lca.aadEndpoint, lca.federatedTokenFile, lca.applicationID, lca.persistToken); err != nil {
+			return err
+		}
+
+		glcm.Info("Workload identity auth succeeded.")
 	case lca.identity:
 		if _, err := uotm.MSILogin(context.TODO(), common.IdentityInfo{
 			ClientID: lca.identityClientID,
@@ -0,0 +1,162 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-storage-file-go/azfile"
+
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+	"github.com/Azure/azure-storage-azcopy/v10/ste"
+)
+
+// the number of source files that are deleted concurrently once a move job is done
+const moveSourceDeletionParallelism = 32
+
+// deleteMovedSources is the second half of a move: once the copy job is done, it deletes the source of every file
+// that was transferred successfully. Folders are left in place, as are the sources of failed or skipped transfers.
+// It returns how many sources were deleted, and how many could not be.
+func (cca *CookedCopyCmdArgs) deleteMovedSources() (deleted, failed uint32) {
+	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+
+	logFailure := func(msg string) {
+		if jobMan, exists := jobsAdmin.JobsAdmin.JobMgr(cca.jobID); exists {
+			jobMan.Log(pipeline.LogError, msg)
+		}
+		glcm.Info(msg)
+	}
+
+	var resp common.ListJobTransfersResponse
+	Rpc(common.ERpcCmd.ListJobTransfers(), common.ListJobTransfersRequest{JobID: cca.jobID, OfStatus: common.ETransferStatus.Success()}, &resp)
+	if resp.ErrorMsg != "" {
+		logFailure("cannot list the transferred files, so no sources were deleted: " + resp.ErrorMsg)
+		return 0, 0
+	}
+
+	deleteSource, err := cca.newMovedSourceDeleter(ctx)
+	if err != nil {
+		logFailure("cannot delete the sources that were moved: " + err.Error())
+		return 0, uint32(len(resp.Details))
+	}
+
+	sources := make(chan string)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < moveSourceDeletionParallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range sources {
+				if err := deleteSource(src); err != nil {
+					atomic.AddUint32(&failed, 1)
+					logFailure(fmt.Sprintf("failed to delete the source %s after it was moved: %s", src, err))
+				} else {
+					atomic.AddUint32(&deleted, 1)
+				}
+			}
+		}()
+	}
+
+	for _, transfer := range resp.Details {
+		if transfer.IsFolderProperties {
+			continue
+		}
+		sources <- transfer.Src
+	}
+	close(sources)
+	wg.Wait()
+
+	return deleted, failed
+}
+
+// newMovedSourceDeleter returns a func that deletes one source file, given its full path as recorded in the job plan.
+// The job plan does not hold the source SAS, so it is appended to remote sources here.
+func (cca *CookedCopyCmdArgs) newMovedSourceDeleter(ctx context.Context) (func(src string) error, error) {
+	from := cca.FromTo.From()
+	if from == common.ELocation.Local() {
+		return os.Remove, nil
+	}
+
+	credInfo, _, err := GetCredentialInfoForLocation(ctx, from, cca.Source.Value, cca.Source.SAS, true, cca.CpkOptions)
+	if err != nil {
+		return nil, err
+	}
+	p, err := InitPipeline(ctx, from, credInfo, azcopyLogVerbosity.ToPipelineLogLevel())
+	if err != nil {
+		return nil, err
+	}
+
+	toURL := func(src string) (url.URL, error) {
+		u, err := url.Parse(src)
+		if err != nil {
+			return url.URL{}, err
+		}
+		if cca.Source.SAS != "" {
+			if u.RawQuery == "" {
+				u.RawQuery = cca.Source.SAS
+			} else {
+				u.RawQuery += "&" + cca.Source.SAS
+			}
+		}
+		return *u, nil
+	}
+
+	switch from {
+	case common.ELocation.Blob():
+		return func(src string) error {
+			u, err := toURL(src)
+			if err != nil {
+				return err
+			}
+			_, err = azblob.NewBlobURL(u, p).Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+			return err
+		}, nil
+	case common.ELocation.File():
+		return func(src string) error {
+			u, err := toURL(src)
+			if err != nil {
+				return err
+			}
+			_, err = azfile.NewFileURL(u, p).Delete(ctx)
+			return err
+		}, nil
+	case common.ELocation.BlobFS():
+		return func(src string) error {
+			u, err := toURL(src)
+			if err != nil {
+				return err
+			}
+			_, err = azbfs.NewFileURL(u, p).Delete(ctx)
+			return err
+		}, nil
+	default:
+		return nil, fmt.Errorf("deleting sources is not supported for %s", from.String())
+	}
+}
@@ -49,18 +49,18 @@ func newRemoveEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, er
 
 	// Include-path is handled by ListOfFilesChannel.
 	sourceTraverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &ctx, &cca.credentialInfo,
-		nil, cca.ListOfFilesChannel, cca.Recursive, false, cca.IncludeDirectoryStubs,
-		cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs, false,
-		azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions, nil /* errorChannel */)
+		common.ESymlinkHandlingType.Skip(), "", cca.ListOfFilesChannel, cca.Recursive, false, cca.IncludeDirectoryStubs,
+		cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs, false, false,
+		azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 
 	// report failure to create traverser
 	if err != nil {
 		return nil, err
 	}
 
-	includeFilters := buildIncludeFilters(cca.IncludePatterns)
-	excludeFilters := buildExcludeFilters(cca.ExcludePatterns, false)
-	excludePathFilters := buildExcludeFilters(cca.ExcludePathPatterns, true)
+	includeFilters := buildIncludeFilters(cca.IncludePatterns, cca.IgnoreCasePattern)
+	excludeFilters := buildExcludeFilters(cca.ExcludePatterns, false, cca.IgnoreCasePattern)
+	excludePathFilters := buildExcludeFilters(cca.ExcludePathPatterns, true, false)
 	includeSoftDelete := buildIncludeSoftDeleted(cca.permanentDeleteOption)
 
 	// set up the filters in the right order
@@ -94,6 +94,7 @@ func newRemoveEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, er
 		jobInitiated, err := transferScheduler.dispatchFinalPart()
 		if err != nil {
 			if cca.dryrunMode {
+				cca.dryrunSummary = &transferScheduler.dryrunSummary
 				return nil
 			} else if err == NothingScheduledError {
 				// No log file needed. Logging begins as a part of awaiting job completion.
@@ -23,11 +23,14 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -46,12 +49,19 @@ var azcopyMaxFileAndSocketHandles int
 var outputFormatRaw string
 var outputVerbosityRaw string
 var logVerbosityRaw string
+var logVerbosityByComponentRaw string
 var cancelFromStdin bool
 var azcopyOutputFormat common.OutputFormat
 var azcopyOutputVerbosity common.OutputVerbosity
 var azcopyLogVerbosity common.LogLevel
+var azcopyComponentLogLevels common.ComponentLogLevels
 var loggerInfo jobLoggerInfo
 var cmdLineCapMegaBitsPerSecond float64
+var cmdLineAutoTuneThroughput bool
+var cmdLineBandwidthSchedule string
+var cmdLineCheckpointPath string
+var cmdLineLocalAddress string
+var cmdLineProgressEvents bool
 var azcopyAwaitContinue bool
 var azcopyAwaitAllowOpenFiles bool
 var azcopyScanningLogger common.ILoggerResetable
@@ -84,6 +94,16 @@ var rootCmd = &cobra.Command{
 				ste.UploadTryTimeout = timeout
 			}
 		}
+		if err := applyRetryOptionsFromEnvironment(); err != nil {
+			return err
+		}
+		if cmdLineLocalAddress != "" {
+			localAddress, err := common.ParseLocalAddress(cmdLineLocalAddress)
+			if err != nil {
+				return fmt.Errorf("invalid --local-address: %w", err)
+			}
+			common.GlobalDialLocalAddress = localAddress
+		}
 		glcm.E2EEnableAwaitAllowOpenFiles(azcopyAwaitAllowOpenFiles)
 		if azcopyAwaitContinue {
 			glcm.E2EAwaitContinue()
@@ -107,7 +127,20 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		common.AzcopyCurrentJobLogger = common.NewJobLogger(loggerInfo.jobID, azcopyLogVerbosity, loggerInfo.logFileFolder, "")
+
+		// --log-level is the level of every component that isn't given one of its own
+		azcopyComponentLogLevels = common.NewComponentLogLevels(azcopyLogVerbosity)
+		if err = azcopyComponentLogLevels.Parse(logVerbosityByComponentRaw); err != nil {
+			return fmt.Errorf("invalid --log-level-by-component: %w", err)
+		}
+
+		if cmdLineProgressEvents {
+			if azcopyOutputFormat != common.EOutputFormat.Json() {
+				return errors.New("progress-events can only be used with --output-type=json")
+			}
+			glcm.EnableProgressEvents()
+		}
+		common.AzcopyCurrentJobLogger = common.NewComponentJobLogger(loggerInfo.jobID, azcopyComponentLogLevels, loggerInfo.logFileFolder, "")
 		common.AzcopyCurrentJobLogger.OpenLog()
 
 		glcm.SetForceLogging()
@@ -128,13 +161,27 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
-		// currently, we only automatically do auto-tuning when benchmarking
-		preferToAutoTuneGRs := cmd == benchCmd // TODO: do we have a better way to do this than making benchCmd global?
+		// currently, we only automatically do auto-tuning when benchmarking, or when asked to auto-tune the throughput
+		preferToAutoTuneGRs := cmd == benchCmd || cmdLineAutoTuneThroughput // TODO: do we have a better way to do this than making benchCmd global?
 		providePerformanceAdvice := cmd == benchCmd
 
 		// startup of the STE happens here, so that the startup can access the values of command line parameters that are defined for "root" command
 		concurrencySettings := ste.NewConcurrencySettings(azcopyMaxFileAndSocketHandles, preferToAutoTuneGRs)
-		err = jobsAdmin.MainSTE(concurrencySettings, float64(cmdLineCapMegaBitsPerSecond), common.AzcopyJobPlanFolder, azcopyLogPathFolder, providePerformanceAdvice)
+		if cmdLineCheckpointPath != "" {
+			if err = useJobPlanFolder(cmdLineCheckpointPath); err != nil {
+				return err
+			}
+		}
+		var bandwidthSchedule *ste.BandwidthSchedule
+		if cmdLineBandwidthSchedule != "" {
+			if cmdLineAutoTuneThroughput {
+				return errors.New("bandwidth-schedule cannot be combined with auto-tune-throughput")
+			}
+			if bandwidthSchedule, err = ste.ParseBandwidthSchedule(cmdLineBandwidthSchedule); err != nil {
+				return err
+			}
+		}
+		err = jobsAdmin.MainSTE(concurrencySettings, float64(cmdLineCapMegaBitsPerSecond), cmdLineAutoTuneThroughput, bandwidthSchedule, common.AzcopyJobPlanFolder, azcopyLogPathFolder, providePerformanceAdvice)
 		if err != nil {
 			return err
 		}
@@ -179,6 +226,85 @@ var glcmSwapOnce = &sync.Once{}
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 
+// useJobPlanFolder keeps the plan files of this run in the given folder, instead of in the usual one. Since the plan
+// files record the status of every transfer as it completes, the folder is all that is needed to resume the job later.
+// The cached login credential stays where it is, so it is looked up (in the usual folder) before the switch
+func useJobPlanFolder(folder string) error {
+	absFolder, err := filepath.Abs(folder)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint path %s: %w", folder, err)
+	}
+	folder = absFolder
+	if err = os.MkdirAll(folder, os.ModeDir|os.ModePerm); err != nil {
+		return fmt.Errorf("could not create the checkpoint folder %s: %w", folder, err)
+	}
+
+	GetUserOAuthTokenManagerInstance()
+	common.AzcopyJobPlanFolder = folder
+	return nil
+}
+
+// applyRetryOptionsFromEnvironment overrides, with any values set in the AZCOPY_RETRY_* environment variables,
+// the retry policy that the STE applies to each request made by a transfer
+func applyRetryOptionsFromEnvironment() error {
+	o := ste.RequestRetryOptions
+
+	if v := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.RetryMaxTries()); v != "" {
+		maxTries, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || maxTries < 1 {
+			return fmt.Errorf("%s must be a whole number of at least 1", common.EEnvironmentVariable.RetryMaxTries().Name)
+		}
+		o.MaxTries = int32(maxTries)
+	}
+
+	parseDelay := func(ev common.EnvironmentVariable, target *time.Duration) error {
+		if v := glcm.GetEnvironmentVariable(ev); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("%s must be a positive duration, such as 500ms or 2s", ev.Name)
+			}
+			*target = d
+		}
+		return nil
+	}
+	if err := parseDelay(common.EEnvironmentVariable.RetryDelay(), &o.RetryDelay); err != nil {
+		return err
+	}
+	if err := parseDelay(common.EEnvironmentVariable.RetryMaxDelay(), &o.MaxRetryDelay); err != nil {
+		return err
+	}
+	if o.RetryDelay > o.MaxRetryDelay {
+		return fmt.Errorf("%s cannot be longer than %s", common.EEnvironmentVariable.RetryDelay().Name, common.EEnvironmentVariable.RetryMaxDelay().Name)
+	}
+
+	if v := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.RetryMultiplier()); v != "" {
+		multiplier, err := strconv.ParseFloat(v, 64)
+		if err != nil || multiplier < 1 {
+			return fmt.Errorf("%s must be a number of at least 1", common.EEnvironmentVariable.RetryMultiplier().Name)
+		}
+		o.Multiplier = multiplier
+	}
+
+	if v := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.RetryJitter()); v != "" {
+		jitter, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", common.EEnvironmentVariable.RetryJitter().Name)
+		}
+		o.NoJitter = !jitter
+	}
+
+	if v := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.RetryHonorRetryAfter()); v != "" {
+		honor, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", common.EEnvironmentVariable.RetryHonorRetryAfter().Name)
+		}
+		o.IgnoreRetryAfter = !honor
+	}
+
+	ste.RequestRetryOptions = o
+	return nil
+}
+
 func Execute(logPathFolder, jobPlanFolder string, maxFileAndSocketHandles int, jobID common.JobID) {
 	azcopyLogPathFolder = logPathFolder
 	common.AzcopyJobPlanFolder = jobPlanFolder
@@ -206,9 +332,28 @@ func init() {
 	rootCmd.SetUsageTemplate(strings.Replace((&cobra.Command{}).UsageTemplate(), "Global Flags", "Flags Applying to All Commands", -1))
 
 	rootCmd.PersistentFlags().Float64Var(&cmdLineCapMegaBitsPerSecond, "cap-mbps", 0, "Caps the transfer rate, in megabits per second. Moment-by-moment throughput might vary slightly from the cap. If this option is set to zero, or it is omitted, the throughput isn't capped.")
+	rootCmd.PersistentFlags().BoolVar(&cmdLineAutoTuneThroughput, "auto-tune-throughput", false, "Start the transfer rate low, and raise it for as long as the service keeps up, backing off whenever it responds that it is busy (503). "+
+		"The number of concurrent requests is tuned the same way, unless AZCOPY_CONCURRENCY_VALUE fixes it. "+
+		"If cap-mbps is also set, the rate is never raised above it. The rate and concurrency it settles on are shown in the job summary, and can be used as a fixed cap-mbps and AZCOPY_CONCURRENCY_VALUE for later jobs.")
+	rootCmd.PersistentFlags().StringVar(&cmdLineBandwidthSchedule, "bandwidth-schedule", "", "Caps the transfer rate by the time of day, as comma-separated windows of the form HH:MM-HH:MM=megabits per second, or =unlimited. "+
+		"E.g. 09:00-17:00=50,17:00-09:00=unlimited. Times are in the local time zone of the machine running AzCopy, and a window may wrap past midnight. "+
+		"The time is checked every 30 seconds, and a new cap applies to the transfers in progress. Outside all the windows, cap-mbps applies, if it is set. Cannot be combined with auto-tune-throughput.")
+	rootCmd.PersistentFlags().StringVar(&cmdLineCheckpointPath, "checkpoint-path", "", "Folder in which to keep the plan files of the job, instead of the usual plan file location. "+
+		"The plan files record which transfers have completed, so a job that is interrupted can be resumed from this folder with 'azcopy jobs resume --resume-from'.")
+	rootCmd.PersistentFlags().StringVar(&cmdLineLocalAddress, "local-address", "", "Makes every connection to Azure Storage, Azure Active Directory and S3 from this local IP address, so that on a machine with several network interfaces they leave by the one that has it. "+
+		"AzCopy stops before doing any work if the address isn't assigned to this machine. Only destinations of the same address family are connected to: with an IPv4 address, "+
+		"a host name that resolves only to IPv6 addresses fails to connect, and vice versa. The connections to a proxy are made from it too. Google Cloud Storage sources are not affected.")
 	rootCmd.PersistentFlags().StringVar(&outputFormatRaw, "output-type", "text", "Format of the command's output. The choices include: text, json. The default value is 'text'.")
-	rootCmd.PersistentFlags().StringVar(&outputVerbosityRaw, "output-level", "default", "Define the output verbosity. Available levels: essential, quiet.")
+	rootCmd.PersistentFlags().BoolVar(&cmdLineProgressEvents, "progress-events", false, "Also output a structured ProgressEvent message with each progress report, for building dashboards. "+
+		"Events are numbered and timestamped, and carry the counts of transfers and bytes done, the current throughput, and the transfers that failed since the previous event. Requires --output-type=json.")
+	rootCmd.PersistentFlags().StringVar(&outputVerbosityRaw, "output-level", "default", "Define the output verbosity. Available levels: essential, quiet. "+
+		"With quiet nothing is printed, not even progress, which suits scheduled jobs. The job summary is still written to the log file, and the outcome is given by the exit code: "+
+		"0 if every transfer completed or was skipped, 3 if some transfers failed but others completed, and 1 if all the attempted transfers failed or the job couldn't run.")
 	rootCmd.PersistentFlags().StringVar(&logVerbosityRaw, "log-level", "INFO", "Define the log verbosity for the log file, available levels: INFO(all requests/responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default 'INFO').")
+	rootCmd.PersistentFlags().StringVar(&logVerbosityByComponentRaw, "log-level-by-component", "", "Sets the log verbosity of particular components of the job, overriding --log-level for them. "+
+		"Takes a semicolon-separated list of component=level, e.g. 'enumeration=DEBUG;network=WARNING'. The components are enumeration (listing the source and destination), "+
+		"scheduler (scheduling and starting transfers), network (requests and responses) and validation (checking transferred data, e.g. its MD5 hash). "+
+		"The levels are those of --log-level, plus DEBUG.")
 
 	rootCmd.PersistentFlags().StringVar(&cmdLineExtraSuffixesAAD, trustedSuffixesNameAAD, "", "Specifies additional domain suffixes where Azure Active Directory login tokens may be sent.  The default is '"+
 		trustedSuffixesAAD+"'. Any listed here are added to the default. For security, you should only put Microsoft Azure domains here. Separate multiple entries with semi-colons.")
@@ -48,18 +48,18 @@ func setPropertiesEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator
 
 	// Include-path is handled by ListOfFilesChannel.
 	sourceTraverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &ctx, &cca.credentialInfo,
-		nil, cca.ListOfFilesChannel, cca.Recursive, false, cca.IncludeDirectoryStubs,
-		cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs, false,
-		azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions, nil /* errorChannel */)
+		common.ESymlinkHandlingType.Skip(), "", cca.ListOfFilesChannel, cca.Recursive, false, cca.IncludeDirectoryStubs,
+		cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs, false, false,
+		azcopyLogVerbosity.ToPipelineLogLevel(), cca.CpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 
 	// report failure to create traverser
 	if err != nil {
 		return nil, err
 	}
 
-	includeFilters := buildIncludeFilters(cca.IncludePatterns)
-	excludeFilters := buildExcludeFilters(cca.ExcludePatterns, false)
-	excludePathFilters := buildExcludeFilters(cca.ExcludePathPatterns, true)
+	includeFilters := buildIncludeFilters(cca.IncludePatterns, cca.IgnoreCasePattern)
+	excludeFilters := buildExcludeFilters(cca.ExcludePatterns, false, cca.IgnoreCasePattern)
+	excludePathFilters := buildExcludeFilters(cca.ExcludePathPatterns, true, false)
 	includeSoftDelete := buildIncludeSoftDeleted(cca.permanentDeleteOption)
 
 	// set up the filters in the right order
@@ -23,6 +23,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
 	"strings"
@@ -54,6 +55,11 @@ type rawSyncCmdArgs struct {
 	legacyExclude         string // for warning messages only
 	includeRegex          string
 	excludeRegex          string
+	includeExt            string
+	excludeExt            string
+	ignoreCasePattern     bool
+	includeBefore         string
+	includeAfter          string
 
 	preservePermissions     bool
 	preserveSMBPermissions  bool // deprecated and synonymous with preservePermissions
@@ -71,6 +77,14 @@ type rawSyncCmdArgs struct {
 
 	// this flag is to disable comparator and overwrite files at destination irrespective
 	mirrorMode bool
+	compareBy  string
+	// never overwrite a destination file that is more recent than the source
+	excludeIfDestinationNewer bool
+	// make the destination an exact copy, including the properties of files whose content is already in sync
+	mirror            bool
+	mirrorMetadata    bool
+	mirrorContentType bool
+	mirrorTier        bool
 
 	s2sPreserveAccessTier bool
 	// Opt-in flag to preserve the blob index tags during service to service transfer.
@@ -88,6 +102,14 @@ type rawSyncCmdArgs struct {
 	cpkScopeInfo string
 	// dry run mode bool
 	dryrun bool
+	// where the sync saves what it needs to resume its job, once it has compared the source with the destination
+	syncStatePath string
+	// where the sync keeps the MD5s of local files, with compare-by MD5, so that the next sync needn't read them again
+	checksumCachePath string
+	// only report how the source and destination compare, without transferring or deleting anything
+	compareOnly bool
+	// where to record the listing of each directory: "log", or the path of a file. Empty for nowhere
+	enumerationTelemetry string
 }
 
 func (raw *rawSyncCmdArgs) parsePatterns(pattern string) (cookedPatterns []string) {
@@ -123,7 +145,7 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	cooked := cookedSyncCmdArgs{}
 
 	// set up the front end scanning logger
-	azcopyScanningLogger = common.NewJobLogger(azcopyCurrentJobID, azcopyLogVerbosity, azcopyLogPathFolder, "-scanning")
+	azcopyScanningLogger = common.NewJobLogger(azcopyCurrentJobID, azcopyComponentLogLevels.For(common.ELogComponent.Enumeration()), azcopyLogPathFolder, "-scanning")
 	azcopyScanningLogger.OpenLog()
 	glcm.RegisterCloseFunc(func() {
 		azcopyScanningLogger.CloseLog()
@@ -150,6 +172,18 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	cooked.isHNSToHNS = srcHNS && dstHNS
 
 	var err error
+
+	// the entries of the filters can be kept in files, which are read before anything looks at the filters
+	if raw.include, err = expandFilterFiles(raw.include, "include-pattern"); err != nil {
+		return cooked, err
+	}
+	if raw.exclude, err = expandFilterFiles(raw.exclude, "exclude-pattern"); err != nil {
+		return cooked, err
+	}
+	if raw.excludePath, err = expandFilterFiles(raw.excludePath, "exclude-path"); err != nil {
+		return cooked, err
+	}
+
 	cooked.fromTo, err = ValidateFromTo(raw.src, raw.dst, raw.fromTo)
 	if err != nil {
 		return cooked, err
@@ -231,13 +265,25 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	}
 
 	// parse the filter patterns
-	cooked.includePatterns = raw.parsePatterns(raw.include)
-	cooked.excludePatterns = raw.parsePatterns(raw.exclude)
+	if cooked.includePatterns, err = expandBracePatterns(raw.parsePatterns(raw.include), "include-pattern"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludePatterns, err = expandBracePatterns(raw.parsePatterns(raw.exclude), "exclude-pattern"); err != nil {
+		return cooked, err
+	}
+	cooked.ignoreCasePattern = raw.ignoreCasePattern
 	cooked.excludePaths = raw.parsePatterns(raw.excludePath)
 
 	// parse the attribute filter patterns
-	cooked.includeFileAttributes = raw.parsePatterns(raw.includeFileAttributes)
-	cooked.excludeFileAttributes = raw.parsePatterns(raw.excludeFileAttributes)
+	if (raw.includeFileAttributes != "" || raw.excludeFileAttributes != "") && cooked.fromTo.From() != common.ELocation.Local() {
+		return cooked, errors.New("cannot check file attributes on remote objects")
+	}
+	if cooked.includeFileAttributes, err = parseFileAttributes(raw.includeFileAttributes, "include-attributes"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludeFileAttributes, err = parseFileAttributes(raw.excludeFileAttributes, "exclude-attributes"); err != nil {
+		return cooked, err
+	}
 
 	cooked.preserveSMBInfo = areBothLocationsSMBAware(cooked.fromTo)
 	// If user has explicitly specified not to copy SMB Information, set cooked.preserveSMBInfo to false
@@ -313,6 +359,11 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	// Get the key (EncryptionKey and EncryptionKeySHA256) value from environment variables when required.
 	cpkOptions.CpkInfo = raw.cpkInfo
 
+	// the encryption scope applies where blobs are written, so any other destination would quietly ignore it
+	if cpkOptions.CpkScopeInfo != "" && !cooked.fromTo.IsDownload() && cooked.fromTo.To() != common.ELocation.Blob() {
+		return cooked, fmt.Errorf("cpk-by-name (the encryption scope) is only supported when the destination is Blob storage, not %s", cooked.fromTo.To())
+	}
+
 	// We only support transfer from source encrypted by user key when user wishes to download.
 	// Due to service limitation, S2S transfer is not supported for source encrypted by user key.
 	if cooked.fromTo.IsDownload() && (cpkOptions.CpkScopeInfo != "" || cpkOptions.CpkInfo) {
@@ -324,17 +375,110 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	cooked.cpkOptions = cpkOptions
 
 	cooked.mirrorMode = raw.mirrorMode
+	cooked.excludeIfDestinationNewer = raw.excludeIfDestinationNewer
+
+	err = cooked.compareBy.Parse(raw.compareBy)
+	if err != nil {
+		return cooked, err
+	}
+	if cooked.compareBy == common.ESyncComparator.MD5() && cooked.mirrorMode {
+		return cooked, errors.New("compare-by cannot be used with mirror-mode, which transfers every file without comparing it")
+	}
+
+	if raw.mirror {
+		// local files have no stored properties to compare, only those that AzCopy would work out as it uploads them
+		if !cooked.fromTo.IsS2S() {
+			return cooked, fmt.Errorf("mirror is only supported between remote locations, not %s", cooked.fromTo)
+		}
+		if raw.mirrorTier && !cooked.preserveAccessTier {
+			return cooked, errors.New("mirror-tier cannot be used with s2s-preserve-access-tier=false, since the tier would never be corrected")
+		}
+		// an exact copy has no extra files, but the user may still ask to be prompted before they are deleted
+		if cooked.deleteDestination == common.EDeleteDestination.False() {
+			cooked.deleteDestination = common.EDeleteDestination.True()
+		}
+		cooked.propertyComparer = &syncPropertyComparer{metadata: raw.mirrorMetadata, contentType: raw.mirrorContentType, tier: raw.mirrorTier}
+	}
 
 	cooked.includeRegex = raw.parsePatterns(raw.includeRegex)
 	cooked.excludeRegex = raw.parsePatterns(raw.excludeRegex)
+	if err = validateRegexPatterns(cooked.includeRegex, "include-regex"); err != nil {
+		return cooked, err
+	}
+	if err = validateRegexPatterns(cooked.excludeRegex, "exclude-regex"); err != nil {
+		return cooked, err
+	}
+	if cooked.includeExt, err = parseExtensions(raw.includeExt, "include-ext"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludeExt, err = parseExtensions(raw.excludeExt, "exclude-ext"); err != nil {
+		return cooked, err
+	}
+
+	if raw.includeBefore != "" {
+		// choose the latest of any ambiguous local times, as copy does, so that changed files aren't missed
+		parsedIncludeBefore, err := IncludeBeforeDateFilter{}.ParseTime(raw.includeBefore, false, time.Now())
+		if err != nil {
+			return cooked, err
+		}
+		cooked.includeBefore = &parsedIncludeBefore
+	}
+	if raw.includeAfter != "" {
+		// and the earliest, for the same reason
+		parsedIncludeAfter, err := IncludeAfterDateFilter{}.ParseTime(raw.includeAfter, true, time.Now())
+		if err != nil {
+			return cooked, err
+		}
+		cooked.includeAfter = &parsedIncludeAfter
+	}
 
 	cooked.dryrunMode = raw.dryrun
+	if cooked.dryrunMode {
+		cooked.dryrunSummary = &common.SyncDryrunSummary{}
+	}
+
+	if cooked.syncStatePath, err = syncStatePathFromFlag(raw.syncStatePath); err != nil {
+		return cooked, err
+	}
+	if cooked.syncStatePath != "" && cooked.dryrunMode {
+		return cooked, errors.New("sync-state cannot be used with dry-run, since a dry run has no job to resume")
+	}
+
+	if cooked.checksumCachePath, err = checksumCachePathFromFlag(raw.checksumCachePath); err != nil {
+		return cooked, err
+	}
+	if cooked.checksumCachePath != "" && (cooked.compareBy != common.ESyncComparator.MD5() ||
+		(cooked.fromTo.From() != common.ELocation.Local() && cooked.fromTo.To() != common.ELocation.Local())) {
+		return cooked, errors.New("checksum-cache only applies with --compare-by=MD5 when the source or destination is local, since only local files have their hashes computed")
+	}
+
+	if cooked.enumerationTelemetry, err = enumerationTelemetryFromFlag(raw.enumerationTelemetry); err != nil {
+		return cooked, err
+	}
+
+	cooked.compareOnly = raw.compareOnly
+	if cooked.compareOnly {
+		switch {
+		case cooked.dryrunMode:
+			return cooked, errors.New("compare-only cannot be used with dry-run, since it transfers nothing anyway")
+		case cooked.syncStatePath != "":
+			return cooked, errors.New("compare-only cannot be used with sync-state, since it has no job to resume")
+		// the report sorts every file by how it compares, so the flags that only decide what is transferred don't apply
+		case cooked.excludeIfDestinationNewer:
+			return cooked, errors.New("compare-only cannot be used with exclude-if-destination-newer, which only decides what is transferred")
+		case cooked.includeBefore != nil || cooked.includeAfter != nil:
+			return cooked, fmt.Errorf("compare-only cannot be used with %s or %s, which only decide what is transferred", common.IncludeBeforeFlagName, common.IncludeAfterFlagName)
+		}
+		cooked.compareReport = newSyncCompareReport()
+	}
 
 	if azcopyOutputVerbosity == common.EOutputVerbosity.Quiet() || azcopyOutputVerbosity == common.EOutputVerbosity.Essential() {
 		if cooked.deleteDestination == common.EDeleteDestination.Prompt() {
 			err = fmt.Errorf("cannot set output level '%s' with delete-destination option '%s'", azcopyOutputVerbosity.String(), cooked.deleteDestination.String())
 		} else if cooked.dryrunMode {
 			err = fmt.Errorf("cannot set output level '%s' with dry-run mode", azcopyOutputVerbosity.String())
+		} else if cooked.compareOnly {
+			err = fmt.Errorf("cannot set output level '%s' with compare-only", azcopyOutputVerbosity.String())
 		}
 	}
 	if err != nil {
@@ -361,6 +505,10 @@ type cookedSyncCmdArgs struct {
 
 	// deletion count keeps track of how many extra files from the destination were removed
 	atomicDeletionCount uint32
+	// declined deletion count keeps track of how many extra files the user chose to keep, when prompted
+	atomicDeclinedDeletionCount uint32
+	// keeps track of how many files were not transferred because they were more recent at the destination
+	atomicDestinationNewerCount uint32
 
 	source                  common.ResourceString
 	destination             common.ResourceString
@@ -379,6 +527,13 @@ type cookedSyncCmdArgs struct {
 	excludeFileAttributes []string
 	includeRegex          []string
 	excludeRegex          []string
+	includeExt            []string
+	excludeExt            []string
+	ignoreCasePattern     bool
+	// includeBefore and includeAfter limit which source files are transferred, by their last modified times.
+	// Files outside of the window still count as present at the source, so they are never deleted from the destination.
+	includeBefore *time.Time
+	includeAfter  *time.Time
 
 	// options
 	preservePermissions     common.PreservePermissionsOption
@@ -423,8 +578,30 @@ type cookedSyncCmdArgs struct {
 	cpkOptions common.CpkOptions
 
 	mirrorMode bool
+	compareBy  common.SyncComparator
+	// leaves alone the files that are more recent at the destination than at the source, even with mirrorMode or compareBy MD5
+	excludeIfDestinationNewer bool
+	// set by --mirror, to also transfer the files whose properties differ
+	propertyComparer *syncPropertyComparer
 
 	dryrunMode bool
+	// what a dry run would have done, so far. Nil if this isn't a dry run
+	dryrunSummary *common.SyncDryrunSummary
+
+	// the file in which the sync saves its state, so that it can be resumed without scanning again. Empty if there is none
+	syncStatePath string
+
+	// the file in which the MD5s of local files are cached from one sync to the next. Empty if there is none
+	checksumCachePath string
+
+	// reports how the source and destination compare, rather than syncing them. Nothing is transferred or deleted
+	compareOnly bool
+	// the files sorted by how they compare, so far. Nil unless compareOnly is set
+	compareReport *common.SyncCompareReport
+
+	// where the listing of each source and destination directory is recorded: "log" for the scanning log, or the
+	// absolute path of a file. Empty for nowhere
+	enumerationTelemetry string
 }
 
 func (cca *cookedSyncCmdArgs) incrementDeletionCount() {
@@ -435,6 +612,22 @@ func (cca *cookedSyncCmdArgs) getDeletionCount() uint32 {
 	return atomic.LoadUint32(&cca.atomicDeletionCount)
 }
 
+func (cca *cookedSyncCmdArgs) incrementDeclinedDeletionCount() {
+	atomic.AddUint32(&cca.atomicDeclinedDeletionCount, 1)
+}
+
+func (cca *cookedSyncCmdArgs) getDeclinedDeletionCount() uint32 {
+	return atomic.LoadUint32(&cca.atomicDeclinedDeletionCount)
+}
+
+func (cca *cookedSyncCmdArgs) incrementDestinationNewerCount() {
+	atomic.AddUint32(&cca.atomicDestinationNewerCount, 1)
+}
+
+func (cca *cookedSyncCmdArgs) getDestinationNewerCount() uint32 {
+	return atomic.LoadUint32(&cca.atomicDestinationNewerCount)
+}
+
 // setFirstPartOrdered sets the value of atomicFirstPartOrdered to 1
 func (cca *cookedSyncCmdArgs) setFirstPartOrdered() {
 	atomic.StoreUint32(&cca.atomicFirstPartOrdered, 1)
@@ -537,6 +730,10 @@ func (cca *cookedSyncCmdArgs) getJsonOfSyncJobSummary(summary common.ListJobSumm
 	wrapped := common.ListSyncJobSummaryResponse{ListJobSummaryResponse: summary}
 	wrapped.DeleteTotalTransfers = cca.getDeletionCount()
 	wrapped.DeleteTransfersCompleted = cca.getDeletionCount()
+	wrapped.DeleteTransfersDeclined = cca.getDeclinedDeletionCount()
+	wrapped.SkippedDestinationNewer = cca.getDestinationNewerCount()
+	wrapped.FilesScannedAtSource = atomic.LoadUint64(&cca.atomicSourceFilesScanned)
+	wrapped.FilesScannedAtDestination = atomic.LoadUint64(&cca.atomicDestinationFilesScanned)
 	jsonOutput, err := json.Marshal(wrapped)
 	common.PanicIfErr(err)
 	return string(jsonOutput)
@@ -563,6 +760,8 @@ func (cca *cookedSyncCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 		// reset the interval timer and byte count
 		cca.intervalStartTime = time.Now()
 		cca.intervalBytesTransferred = summary.BytesOverWire
+
+		lcm.ProgressEvent(common.NewProgressEvent(summary, throughput))
 	}
 
 	// first part not dispatched, and we are still scanning
@@ -580,24 +779,20 @@ func (cca *cookedSyncCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 		// indicate whether constrained by disk or not
 		perfString, diskString := getPerfDisplayText(summary.PerfStrings, summary.PerfConstraint, duration, false)
 
-		return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Total%s, 2-sec Throughput (Mb/s): %v%s",
+		return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Total%s, 2-sec Throughput (Mb/s): %v%s%s",
 			summary.PercentComplete,
 			summary.TransfersCompleted,
 			summary.TransfersFailed,
 			summary.TotalTransfers-summary.TransfersCompleted-summary.TransfersFailed,
-			summary.TotalTransfers, perfString, jobsAdmin.ToFixed(throughput, 4), diskString)
+			summary.TotalTransfers, perfString, jobsAdmin.ToFixed(throughput, 4), diskString, getETADisplayText(summary))
 	})
 
 	if jobDone {
-		exitCode := common.EExitCode.Success()
-		if summary.TransfersFailed > 0 {
-			exitCode = common.EExitCode.Error()
-		}
+		exitCode := common.ExitCodeForTransfers(summary.TransfersCompleted, summary.TransfersFailed)
+
+		cca.finishSyncState(summary)
 
 		lcm.Exit(func(format common.OutputFormat) string {
-			if format == common.EOutputFormat.Json() {
-				return cca.getJsonOfSyncJobSummary(summary)
-			}
 			screenStats, logStats := formatExtraStats(cca.fromTo, summary.AverageIOPS, summary.AverageE2EMilliseconds, summary.NetworkErrorPercentage, summary.ServerBusyPercentage)
 
 			output := fmt.Sprintf(
@@ -612,6 +807,7 @@ Total Number Of Copy Transfers: %v
 Number of Copy Transfers Completed: %v
 Number of Copy Transfers Failed: %v
 Number of Deletions at Destination: %v
+Number of Deletions Declined at Destination: %v
 Total Number of Bytes Transferred: %v
 Total Number of Bytes Enumerated: %v
 Final Job Status: %v%s%s
@@ -625,18 +821,42 @@ Final Job Status: %v%s%s
 				summary.TotalTransfers,
 				summary.TransfersCompleted,
 				summary.TransfersFailed,
-				cca.atomicDeletionCount,
+				cca.getDeletionCount(),
+				cca.getDeclinedDeletionCount(),
 				summary.TotalBytesTransferred,
 				summary.TotalBytesEnumerated,
 				summary.JobStatus,
 				screenStats,
 				formatPerfAdvice(summary.PerformanceAdvice))
 
+			if summary.POSIXPropertiesNotRestored > 0 {
+				output += fmt.Sprintf("Number of Files Whose POSIX Properties Were Not Restored: %v\n", summary.POSIXPropertiesNotRestored)
+			}
+
+			if summary.TransfersFailed > 0 {
+				output += fmt.Sprintf("Failed Copy Transfers By Category: %v\n", summary.FailuresByCategory)
+			}
+
+			if cca.excludeIfDestinationNewer {
+				output += fmt.Sprintf("Number of Files Skipped Because They Were Newer at Destination: %v\n", cca.getDestinationNewerCount())
+			}
+
+			if summary.AutoTunedThroughputMbps > 0 {
+				output += fmt.Sprintf("Auto-tuned Throughput (Mbps): %.0f\n", summary.AutoTunedThroughputMbps)
+			}
+			if summary.AutoTunedConcurrency > 0 {
+				output += fmt.Sprintf("Auto-tuned Concurrency: %v\n", summary.AutoTunedConcurrency)
+			}
+
+			// log to job log, whatever the output format, so that the summary is kept even with --output-level=quiet
 			jobMan, exists := jobsAdmin.JobsAdmin.JobMgr(summary.JobID)
 			if exists {
 				jobMan.Log(pipeline.LogInfo, logStats+"\n"+output)
 			}
 
+			if format == common.EOutputFormat.Json() {
+				return cca.getJsonOfSyncJobSummary(summary)
+			}
 			return output
 		}, exitCode)
 	}
@@ -685,13 +905,20 @@ func (cca *cookedSyncCmdArgs) process() (err error) {
 		}
 	}
 
+	// an earlier sync, which was interrupted once it had ordered all its transfers, only needs its job resumed
+	if cca.syncStatePath != "" {
+		if jobID, found := cca.readSyncState(); found {
+			return cca.resumeFromSyncState(jobID)
+		}
+	}
+
 	enumerator, err := cca.initEnumerator(ctx)
 	if err != nil {
 		return err
 	}
 
-	// trigger the progress reporting
-	if !cca.dryrunMode {
+	// trigger the progress reporting. A dry run and a comparison have no job to report on
+	if !cca.dryrunMode && !cca.compareOnly {
 		cca.waitUntilJobCompletion(false)
 	}
 
@@ -703,6 +930,28 @@ func (cca *cookedSyncCmdArgs) process() (err error) {
 	return nil
 }
 
+// syncDryrunSummaryBuilder reports what a dry run of sync would have done, sorted into the files it would add to the
+// destination, update there, and delete from there. In JSON output it can be parsed as a common.SyncDryrunSummary
+func syncDryrunSummaryBuilder(summary *common.SyncDryrunSummary) common.OutputBuilder {
+	if summary == nil {
+		return nil
+	}
+	return func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			jsonOutput, err := json.Marshal(summary)
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+		output := fmt.Sprintf("DRYRUN: files to add: %d, totalling %d bytes\n", summary.FilesToAdd, summary.BytesToAdd) +
+			fmt.Sprintf("DRYRUN: files to update: %d, totalling %d bytes\n", summary.FilesToUpdate, summary.BytesToUpdate) +
+			fmt.Sprintf("DRYRUN: files to delete: %d, totalling %d bytes", summary.FilesToDelete, summary.BytesToDelete)
+		if summary.FolderCount > 0 {
+			output += fmt.Sprintf("\nDRYRUN: folders whose properties would be set: %d", summary.FolderCount)
+		}
+		return output
+	}
+}
+
 func init() {
 	raw := rawSyncCmdArgs{}
 	// syncCmd represents the sync command
@@ -737,7 +986,10 @@ func init() {
 				glcm.Error("Cannot perform sync due to error: " + err.Error())
 			}
 			if cooked.dryrunMode {
-				glcm.Exit(nil, common.EExitCode.Success())
+				glcm.Exit(syncDryrunSummaryBuilder(cooked.dryrunSummary), common.EExitCode.Success())
+			}
+			if cooked.compareOnly {
+				glcm.Exit(syncCompareReportBuilder(cooked.compareReport), common.EExitCode.Success())
 			}
 
 			glcm.SurrenderControl()
@@ -752,7 +1004,7 @@ func init() {
 	// smb info/permissions can be persisted in the scenario of File -> File
 	syncCmd.PersistentFlags().BoolVar(&raw.preserveSMBPermissions, "preserve-smb-permissions", false, "False by default. Preserves SMB ACLs between aware resources (Azure Files). This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
 	syncCmd.PersistentFlags().BoolVar(&raw.preserveSMBInfo, "preserve-smb-info", true, "For SMB-aware locations, flag will be set to true by default. Preserves SMB property info (last write time, creation time, attribute bits) between SMB-aware resources (Azure Files). This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern). The info transferred for folders is the same as that for files, except for Last Write Time which is not preserved for folders. ")
-	syncCmd.PersistentFlags().BoolVar(&raw.preservePOSIXProperties, "preserve-posix-properties", false, "'Preserves' property info gleaned from stat or statx into object metadata.")
+	syncCmd.PersistentFlags().BoolVar(&raw.preservePOSIXProperties, "preserve-posix-properties", false, "'Preserves' property info gleaned from stat or statx into object metadata. When downloading to Linux, the mode, owner and group are restored from that metadata.")
 
 	// TODO: enable when we support local <-> File
 	// syncCmd.PersistentFlags().BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When overwriting an existing file on Windows or Azure Files, force the overwrite to work even if the existing file has its read-only attribute set")
@@ -760,16 +1012,31 @@ func init() {
 	// syncCmd.PersistentFlags().BoolVar(&raw.backupMode, common.BackupModeFlagName, false, "Activates Windows' SeBackupPrivilege for uploads, or SeRestorePrivilege for downloads, to allow AzCopy to see read all files, regardless of their file system permissions, and to restore all permissions. Requires that the account running AzCopy already has these permissions (e.g. has Administrator rights or is a member of the 'Backup Operators' group). All this flag does is activate privileges that the account already has")
 
 	syncCmd.PersistentFlags().Float64Var(&raw.blockSizeMB, "block-size-mb", 0, "Use this block size (specified in MiB) when uploading to Azure Storage or downloading from Azure Storage. Default is automatically calculated based on file size. Decimal fractions are allowed (For example: 0.25).")
-	syncCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
-	syncCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	syncCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName;{2020,2021}_*."+bracePatternHelp+filterFileHelp)
+	syncCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName;{2020,2021}_*."+bracePatternHelp+filterFileHelp)
+	syncCmd.PersistentFlags().BoolVar(&raw.ignoreCasePattern, "ignore-case-pattern", false, "Match --include-pattern and --exclude-pattern case-insensitively (e.g. *.TXT then also matches file.txt). By default, patterns are case-sensitive. "+
+		"Note that this may select several files whose names differ only in case, which will overwrite each other if the destination is case-insensitive (e.g. Azure Files, or a local Windows or macOS file system).")
 	syncCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when comparing the source against the destination. "+
-		"This option does not support wildcard characters (*). Checks relative path prefix(For example: myFolder;myFolder/subDirName/file.pdf).")
-	syncCmd.PersistentFlags().StringVar(&raw.includeFileAttributes, "include-attributes", "", "(Windows only) Include only files whose attributes match the attribute list. For example: A;S;R")
-	syncCmd.PersistentFlags().StringVar(&raw.excludeFileAttributes, "exclude-attributes", "", "(Windows only) Exclude files whose attributes match the attribute list. For example: A;S;R")
-	syncCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include the relative path of the files that match with the regular expressions. Separate regular expressions with ';'.")
-	syncCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude the relative path of the files that match with the regular expressions. Separate regular expressions with ';'.")
+		"This option does not support wildcard characters (*). Checks relative path prefix(For example: myFolder;myFolder/subDirName/file.pdf)."+filterFileHelp)
+	syncCmd.PersistentFlags().StringVar(&raw.includeFileAttributes, "include-attributes", "", "(Windows only) Include only files that have any of the attributes in the attribute list. For example: A;S;R, or ASR")
+	syncCmd.PersistentFlags().StringVar(&raw.excludeFileAttributes, "exclude-attributes", "", "(Windows only) Exclude files that have any of the attributes in the attribute list. For example: A;S;R, or ASR. "+
+		"When used with include-attributes, a file must match the include list and not match this one, so exclusion takes precedence.")
+	syncCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include the relative path of the files that match with the regular expressions. Separate regular expressions with ';'. "+
+		"Each expression must match the whole relative path, as if it started with '^' and ended with '$'. To match part of a path, add '.*' for the rest of it (For example: .*\\.pdf, or .*draft.* for 'draft' anywhere in the path). Earlier versions let an expression match any part of the path. "+
+		"When used together with --include-pattern, a file is included if it matches either flag.")
+	syncCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude the relative path of the files that match with the regular expressions. Separate regular expressions with ';'. "+
+		"Each expression must match the whole relative path, as if it started with '^' and ended with '$'. To match part of a path, add '.*' for the rest of it (For example: .*\\.pdf, or .*draft.* for 'draft' anywhere in the path). Earlier versions let an expression match any part of the path.")
+	syncCmd.PersistentFlags().StringVar(&raw.includeExt, "include-ext", "", "Include only the files with these extensions, separated by ';', with or without the leading dot (For example: jpg;png;gif). "+
+		"Shorthand for --include-pattern *.jpg;*.png;*.gif, except that extensions are always matched case-insensitively. Files with no extension are not included. "+
+		"When used together with --include-pattern or --include-regex, a file is included if it matches any of these flags.")
+	syncCmd.PersistentFlags().StringVar(&raw.excludeExt, "exclude-ext", "", "Exclude the files with these extensions, separated by ';', with or without the leading dot (For example: tmp;log). "+
+		"Extensions are always matched case-insensitively. Files with no extension are not excluded.")
+	syncCmd.PersistentFlags().StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Transfer only those source files modified before or on the given date/time. The value should be in ISO8601 format, or relative to the start of the job, as a sign, a whole number and a unit of d, h or m (e.g. '-7d' for the last week). If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. "+
+		"Files in the window are compared with the destination as usual. Files outside it are left alone: they are not transferred, and their copies at the destination are never deleted, even with --delete-destination.")
+	syncCmd.PersistentFlags().StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Transfer only those source files modified on or after the given date/time. The value should be in ISO8601 format, or relative to the start of the job, as a sign, a whole number and a unit of d, h or m (e.g. '-7d' for the last week). If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. "+
+		"Files in the window are compared with the destination as usual. Files outside it are left alone: they are not transferred, and their copies at the destination are never deleted, even with --delete-destination.")
 	syncCmd.PersistentFlags().StringVar(&raw.deleteDestination, "delete-destination", "false", "Defines whether to delete extra files from the destination that are not present at the source. Could be set to true, false, or prompt. "+
-		"If set to prompt, the user will be asked a question before scheduling files and blobs for deletion. (default 'false').")
+		"If set to prompt, the user will be asked a question before scheduling files and blobs for deletion, and files and blobs that the user chooses to keep are counted separately in the job summary. (default 'false').")
 	syncCmd.PersistentFlags().BoolVar(&raw.putMd5, "put-md5", false, "Create an MD5 hash of each file, and save the hash as the Content-MD5 property of the destination blob or file. (By default the hash is NOT created.) Only available when uploading.")
 	syncCmd.PersistentFlags().StringVar(&raw.md5ValidationOption, "check-md5", common.DefaultHashValidationOption.String(), "Specifies how strictly MD5 hashes should be validated when downloading. This option is only available when downloading. Available values include: NoCheck, LogOnly, FailIfDifferent, FailIfDifferentOrMissing. (default 'FailIfDifferent').")
 	syncCmd.PersistentFlags().BoolVar(&raw.s2sPreserveAccessTier, "s2s-preserve-access-tier", true, "Preserve access tier during service to service copy. "+
@@ -780,10 +1047,40 @@ func init() {
 	// Clients making requests against Azure Blob storage have the option to provide an encryption key on a per-request basis.
 	// Including the encryption key on the request provides granular control over encryption settings for Blob storage operations.
 	// Customer-provided keys can be stored in Azure Key Vault or in another key store linked to storage account.
-	syncCmd.PersistentFlags().StringVar(&raw.cpkScopeInfo, "cpk-by-name", "", "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key name will be fetched from Azure Key Vault and will be used to encrypt the data")
-	syncCmd.PersistentFlags().BoolVar(&raw.cpkInfo, "cpk-by-value", false, "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key and its hash will be fetched from environment variables")
+	syncCmd.PersistentFlags().StringVar(&raw.cpkScopeInfo, "cpk-by-name", "", "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key name will be fetched from Azure Key Vault and will be used to encrypt the data. When writing, the destination must be Blob storage, and its container must allow this encryption scope.")
+	syncCmd.PersistentFlags().BoolVar(&raw.cpkInfo, "cpk-by-value", false, "Client provided key by value lets clients making requests against Azure Blob storage provide an encryption key on a per-request basis. "+
+		"The key and its SHA256 hash are read from the CPK_ENCRYPTION_KEY and CPK_ENCRYPTION_KEY_SHA256 environment variables, so the key never appears on the command line, and AzCopy leaves it out of its logs. "+
+		"Blobs written with a key must be downloaded with the same key.")
 	syncCmd.PersistentFlags().BoolVar(&raw.mirrorMode, "mirror-mode", false, "Disable last-modified-time based comparison and overwrites the conflicting files and blobs at the destination if this flag is set to true. Default is false")
-	syncCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the path of files that would be copied or removed by the sync command. This flag does not copy or remove the actual files.")
+	syncCmd.PersistentFlags().BoolVar(&raw.excludeIfDestinationNewer, "exclude-if-destination-newer", false, "Never overwrite a file at the destination whose last modified time is more recent than that of the source file, "+
+		"e.g. to keep edits made at the destination. This applies even with --mirror-mode or --compare-by=MD5, and the files left alone are counted in the job summary. "+
+		"It only protects files that exist at the source too: with --delete-destination, a destination file that isn't at the source is still deleted, however recent it is.")
+	syncCmd.PersistentFlags().BoolVar(&raw.mirror, "mirror", false, "Make the destination an exact copy of the source. This implies --delete-destination=true (unless it is set to prompt), "+
+		"and a file whose content is already in sync is still transferred again if its properties differ. Only supported between remote locations. "+
+		"Since each such file is transferred in full, the properties compared can be chosen with --mirror-metadata, --mirror-content-type and --mirror-tier.")
+	syncCmd.PersistentFlags().BoolVar(&raw.mirrorMetadata, "mirror-metadata", true, "With --mirror, transfer a file again if its metadata differs. (default true)")
+	syncCmd.PersistentFlags().BoolVar(&raw.mirrorContentType, "mirror-content-type", true, "With --mirror, transfer a file again if its content type differs. (default true)")
+	syncCmd.PersistentFlags().BoolVar(&raw.mirrorTier, "mirror-tier", false, "With --mirror, transfer a blob again if its access tier differs. Only blobs have a tier, so this has no effect when either end is Azure Files.")
+	syncCmd.PersistentFlags().StringVar(&raw.compareBy, "compare-by", common.ESyncComparator.LastModifiedTime().String(), "Decides whether a file that exists at both the source and the destination is transferred. "+
+		"LastModifiedTime transfers it if the source is more recent. MD5 transfers it if the MD5 hashes differ, or if either end has no hash (for example, blobs uploaded without --put-md5, and Azure Files listings). "+
+		"Local files have no stored hash, so with MD5 each local file that exists at both ends is read in full to compute one. (default 'LastModifiedTime')")
+	syncCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the path of files that would be copied or removed by the sync command. This flag does not copy or remove the actual files. "+
+		"Each planned transfer is printed on its own line, or as one JSON object per line with --output-type=json. "+
+		"They are followed by how many files would be added to the destination, updated there because they differ (as decided by --compare-by), and deleted from there, with their total sizes in bytes.")
+	syncCmd.PersistentFlags().BoolVar(&raw.compareOnly, "compare-only", false, "Compares the source with the destination and reports the files that are only in the source, only in the destination, "+
+		"in both but differ (as decided by --compare-by, and by --mirror if it is set), and in both and identical, without transferring or deleting anything. "+
+		"With --output-type=json, the report is a single JSON object with a list of relative paths for each of those. Folders are not reported.")
+	syncCmd.PersistentFlags().StringVar(&raw.enumerationTelemetry, "enumeration-telemetry", "", "Records how long each directory took to list, and how many entries it had, to find the directories that stall the enumeration of a huge tree. "+
+		"'log' writes a line for each directory to the scanning log, at the Info level. Any other value is the path of a file that gets a JSON object for each directory, one per line, "+
+		"with the side (source or destination), directory, listing worker, start time, duration in milliseconds, and numbers of entries and of subdirectories. "+
+		"Only enumerations that list directory by directory produce records: local folders, Azure Files, and Blob when it isn't listed flat. Off by default.")
+	syncCmd.PersistentFlags().StringVar(&raw.syncStatePath, "sync-state", "", "Saves the state of the sync in this file, once the source and destination have been compared and all the transfers ordered. "+
+		"If the sync is then interrupted, running it again with the same source, destination and sync-state resumes its job, without scanning the source and destination again. "+
+		"Only the source and destination are checked, so other changes to the command (e.g. to its filters) have no effect on a resumed sync. "+
+		"The file is removed once every transfer has completed. A sync-state for a different source or destination is ignored, and then replaced.")
+	syncCmd.PersistentFlags().StringVar(&raw.checksumCachePath, "checksum-cache", "", "With --compare-by=MD5, keeps the MD5s computed for local files in this file, "+
+		"each with the size and last modified time that the file had when it was read. The next sync with the same file then only reads the local files whose size or last modified time has changed. "+
+		"The file holds the hashes of the files compared by the last sync that used it. A file that can't be read is ignored, and replaced.")
 
 	// temp, to assist users with change in param names, by providing a clearer message when these obsolete ones are accidentally used
 	syncCmd.PersistentFlags().StringVar(&raw.legacyInclude, "include", "", "Legacy include param. DO NOT USE")
@@ -798,5 +1095,5 @@ func init() {
 
 	// Deprecate the old persist-smb-permissions flag
 	syncCmd.PersistentFlags().MarkHidden("preserve-smb-permissions")
-	syncCmd.PersistentFlags().BoolVar(&raw.preservePermissions, PreservePermissionsFlag, false, "False by default. Preserves ACLs between aware resources (Windows and Azure Files, or ADLS Gen 2 to ADLS Gen 2). For Hierarchical Namespace accounts, you will need a container SAS or OAuth token with Modify Ownership and Modify Permissions permissions. For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
+	syncCmd.PersistentFlags().BoolVar(&raw.preservePermissions, PreservePermissionsFlag, false, "False by default. Preserves ACLs between aware resources (Windows and Azure Files, or ADLS Gen 2 to ADLS Gen 2). Between ADLS Gen 2 accounts, the whole ACL is copied, including the default ACL of each directory. Named users and groups are copied by object ID, so they only grant access if they also exist in the destination's tenant. For Hierarchical Namespace accounts, you will need a container SAS or OAuth token with Modify Ownership and Modify Permissions permissions. For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
 }
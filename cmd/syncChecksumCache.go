@@ -0,0 +1,123 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// checksumCacheEntry is the MD5 of a local file, as it was when the file had this size and last modified time
+type checksumCacheEntry struct {
+	Size         int64
+	LastModified time.Time
+	MD5          []byte
+}
+
+// checksumCache saves, in the checksum-cache file, the MD5s that sync --compare-by=MD5 computes for local files, so that
+// the next sync only reads the files whose size or last modified time has changed since. The file holds the hashes of
+// the files that the last sync with it compared, so the hashes of files that have gone are not kept for ever.
+// It is safe for concurrent use
+type checksumCache struct {
+	path string
+
+	mu sync.Mutex
+	// the hashes read from the file, by the full path of each file
+	previous map[string]checksumCacheEntry
+	// the hashes used or computed by this sync, which replace those in the file when it is saved
+	current map[string]checksumCacheEntry
+}
+
+// loadChecksumCache reads the cache from its file. A file that doesn't exist yet, or can't be read, is an empty cache,
+// since its hashes can always be computed again
+func loadChecksumCache(path string) *checksumCache {
+	c := &checksumCache{path: path, previous: map[string]checksumCacheEntry{}, current: map[string]checksumCacheEntry{}}
+
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c
+	}
+	if err == nil {
+		err = json.Unmarshal(raw, &c.previous)
+	}
+	if err != nil {
+		glcm.Info(fmt.Sprintf("The checksum cache in %s is ignored, because it could not be read: %s. The MD5 of each local file will be computed again.", path, err))
+		c.previous = map[string]checksumCacheEntry{}
+	}
+	return c
+}
+
+// get returns the cached MD5 of a file, if the file still has the size and last modified time that it had when it was hashed
+func (c *checksumCache) get(path string, size int64, lastModified time.Time) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.previous[path]
+	if !ok || len(entry.MD5) == 0 || entry.Size != size || !entry.LastModified.Equal(lastModified) {
+		return nil, false
+	}
+	c.current[path] = entry
+	return entry.MD5, true
+}
+
+// put caches the MD5 of a file, as it was with this size and last modified time
+func (c *checksumCache) put(path string, size int64, lastModified time.Time, md5 []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current[path] = checksumCacheEntry{Size: size, LastModified: lastModified, MD5: md5}
+}
+
+// save replaces the file with the hashes used or computed by this sync
+func (c *checksumCache) save() error {
+	c.mu.Lock()
+	raw, err := json.Marshal(c.current)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// written to a temporary file first, so that an interruption can't leave a partial cache behind
+	tempPath := c.path + ".tmp"
+	if err = ioutil.WriteFile(tempPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, c.path)
+}
+
+// checksumCachePathFromFlag validates the checksum-cache flag, and makes its path absolute, so that it doesn't depend on the working directory
+func checksumCachePathFromFlag(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid checksum-cache path %s: %w", path, err)
+	}
+	if fi, err := os.Stat(filepath.Dir(absPath)); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("the folder for the checksum cache, %s, does not exist", filepath.Dir(absPath))
+	}
+	return absPath, nil
+}
@@ -20,7 +20,168 @@
 
 package cmd
 
-import "strings"
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// syncHashComparer is used instead of the last modified times, when sync compares by MD5.
+// Remote objects come with the hash stored alongside them, if they have one, but local files don't,
+// so when an end is local its files are read in full to compute their hashes
+type syncHashComparer struct {
+	// the root of each end, if it is local; empty otherwise
+	sourceLocalRoot      string
+	destinationLocalRoot string
+
+	// reuses the hashes of the local files that haven't changed since an earlier sync computed them. Nil if there is none
+	cache *checksumCache
+	// how many local files were read to compute their hashes, and how many hashes were taken from the cache instead
+	atomicFilesHashed  uint64
+	atomicCachedHashes uint64
+}
+
+// contentDiffers says whether the source and destination content differ.
+// A hash that is missing or can't be computed counts as a difference, so that the file is transferred rather than skipped
+func (h *syncHashComparer) contentDiffers(source, destination StoredObject) bool {
+	sourceMD5 := h.md5Of(source, h.sourceLocalRoot)
+	if len(sourceMD5) == 0 {
+		return true
+	}
+	destinationMD5 := h.md5Of(destination, h.destinationLocalRoot)
+	return len(destinationMD5) == 0 || !bytes.Equal(sourceMD5, destinationMD5)
+}
+
+func (h *syncHashComparer) md5Of(object StoredObject, localRoot string) []byte {
+	if localRoot == "" {
+		return object.md5
+	}
+
+	path := common.GenerateFullPath(localRoot, object.relativePath)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	// the file is looked up, and cached, by what it is as it's opened, so that a change made while it's read is
+	// noticed by the next sync
+	var info os.FileInfo
+	if h.cache != nil {
+		if info, err = file.Stat(); err == nil {
+			if cached, ok := h.cache.get(path, info.Size(), info.ModTime()); ok {
+				atomic.AddUint64(&h.atomicCachedHashes, 1)
+				return cached
+			}
+		}
+	}
+
+	atomic.AddUint64(&h.atomicFilesHashed, 1)
+	hasher := md5.New()
+	if _, err = io.Copy(hasher, file); err != nil {
+		return nil
+	}
+	sum := hasher.Sum(nil)
+	if info != nil {
+		h.cache.put(path, info.Size(), info.ModTime(), sum)
+	}
+	return sum
+}
+
+// finish saves the checksum cache, if there is one, once every file has been compared
+func (h *syncHashComparer) finish() {
+	if h == nil || h.cache == nil {
+		return
+	}
+	if azcopyScanningLogger != nil {
+		azcopyScanningLogger.Log(pipeline.LogInfo, fmt.Sprintf("Computed the MD5 of %d local files, and took that of %d more from the checksum cache",
+			atomic.LoadUint64(&h.atomicFilesHashed), atomic.LoadUint64(&h.atomicCachedHashes)))
+	}
+	if err := h.cache.save(); err != nil {
+		glcm.Info(fmt.Sprintf("Failed to save the checksum cache in %s, so the next sync will compute the MD5 of each local file again: %s", h.cache.path, err))
+	}
+}
+
+// syncPropertyComparer is used by --mirror, to find the files whose content is in sync but whose properties are not.
+// A file whose properties differ is transferred again in full, so each property is only compared if it's switched on
+type syncPropertyComparer struct {
+	metadata    bool
+	contentType bool
+	tier        bool
+}
+
+// propertiesDiffer says whether any of the compared properties differ between the source and destination files
+func (p *syncPropertyComparer) propertiesDiffer(source, destination StoredObject) bool {
+	if p.contentType && source.contentType != destination.contentType {
+		return true
+	}
+	if p.metadata && !metadataEqualIgnoringKeyCase(source.Metadata, destination.Metadata) {
+		return true
+	}
+	// only blobs have a tier, so there is nothing to compare when either end is a file share
+	if p.tier && source.blobAccessTier != azblob.AccessTierNone && destination.blobAccessTier != azblob.AccessTierNone {
+		return source.blobAccessTier != destination.blobAccessTier
+	}
+	return false
+}
+
+// metadataEqualIgnoringKeyCase compares metadata the way the services treat it: keys are case-insensitive, but values aren't.
+// Some listings keep the case of the keys while others lower-case them, so the keys can't be compared as they are
+func metadataEqualIgnoringKeyCase(a, b common.Metadata) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	lowered := make(map[string]string, len(b))
+	for k, v := range b {
+		lowered[strings.ToLower(k)] = v
+	}
+	for k, v := range a {
+		if other, ok := lowered[strings.ToLower(k)]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
+
+// syncNeedsTransfer says whether the source object should be transferred over the destination object of the same name.
+// If onDestinationNewer is not nil, a file that is more recent at the destination is never transferred, whatever the
+// other settings, and is passed to onDestinationNewer instead. If propertyComparer is not nil, a file that would
+// otherwise be skipped is still transferred if its properties differ
+func syncNeedsTransfer(source, destination StoredObject, disableComparison bool, hashComparer *syncHashComparer, propertyComparer *syncPropertyComparer, onDestinationNewer func(source StoredObject)) bool {
+	if onDestinationNewer != nil && source.entityType == common.EEntityType.File() && destination.isMoreRecentThan(source) {
+		onDestinationNewer(source)
+		return false
+	}
+	if disableComparison {
+		return true
+	}
+	// folders have no content to hash, so their properties are still compared by time
+	if hashComparer != nil && source.entityType == common.EEntityType.File() {
+		if hashComparer.contentDiffers(source, destination) {
+			return true
+		}
+	} else if source.isMoreRecentThan(destination) {
+		return true
+	}
+	return propertyComparer != nil && source.entityType == common.EEntityType.File() && propertyComparer.propertiesDiffer(source, destination)
+}
+
+// scheduleSyncUpdate picks the processor for a transfer that replaces a file at the destination
+func scheduleSyncUpdate(updateScheduler, copyTransferScheduler objectProcessor) objectProcessor {
+	if updateScheduler != nil {
+		return updateScheduler
+	}
+	return copyTransferScheduler
+}
 
 // with the help of an objectIndexer containing the source objects
 // find out the destination objects that should be transferred
@@ -32,14 +193,29 @@ type syncDestinationComparator struct {
 	// the processor responsible for scheduling copy transfers
 	copyTransferScheduler objectProcessor
 
+	// schedules the transfers that replace a file at the destination, if not nil. Otherwise copyTransferScheduler does
+	updateScheduler objectProcessor
+
+	// is given the source objects that are in sync with the destination, and so aren't transferred, if not nil
+	inSyncProcessor objectProcessor
+
 	// storing the source objects
 	sourceIndex *objectIndexer
 
 	disableComparison bool
+
+	// compares by MD5 rather than by last modified time, if not nil
+	hashComparer *syncHashComparer
+
+	// also transfers the files whose properties differ, if not nil
+	propertyComparer *syncPropertyComparer
+
+	// if not nil, files that are more recent at the destination are passed to this, rather than transferred
+	onDestinationNewer func(source StoredObject)
 }
 
-func newSyncDestinationComparator(i *objectIndexer, copyScheduler, cleaner objectProcessor, disableComparison bool) *syncDestinationComparator {
-	return &syncDestinationComparator{sourceIndex: i, copyTransferScheduler: copyScheduler, destinationCleaner: cleaner, disableComparison: disableComparison}
+func newSyncDestinationComparator(i *objectIndexer, copyScheduler, cleaner objectProcessor, disableComparison bool, hashComparer *syncHashComparer, propertyComparer *syncPropertyComparer, onDestinationNewer func(source StoredObject)) *syncDestinationComparator {
+	return &syncDestinationComparator{sourceIndex: i, copyTransferScheduler: copyScheduler, destinationCleaner: cleaner, disableComparison: disableComparison, hashComparer: hashComparer, propertyComparer: propertyComparer, onDestinationNewer: onDestinationNewer}
 }
 
 // it will only schedule transfers for destination objects that are present in the indexer but stale compared to the entry in the map
@@ -57,11 +233,13 @@ func (f *syncDestinationComparator) processIfNecessary(destinationObject StoredO
 	// if the destinationObject is present at source and stale, we transfer the up-to-date version from source
 	if present {
 		defer delete(f.sourceIndex.indexMap, destinationObject.relativePath)
-		if f.disableComparison || sourceObjectInMap.isMoreRecentThan(destinationObject) {
-			err := f.copyTransferScheduler(sourceObjectInMap)
+		if syncNeedsTransfer(sourceObjectInMap, destinationObject, f.disableComparison, f.hashComparer, f.propertyComparer, f.onDestinationNewer) {
+			err := scheduleSyncUpdate(f.updateScheduler, f.copyTransferScheduler)(sourceObjectInMap)
 			if err != nil {
 				return err
 			}
+		} else if f.inSyncProcessor != nil {
+			return f.inSyncProcessor(sourceObjectInMap)
 		}
 	} else {
 		// purposefully ignore the error from destinationCleaner
@@ -79,14 +257,29 @@ type syncSourceComparator struct {
 	// the processor responsible for scheduling copy transfers
 	copyTransferScheduler objectProcessor
 
+	// schedules the transfers that replace a file at the destination, if not nil. Otherwise copyTransferScheduler does
+	updateScheduler objectProcessor
+
+	// is given the source objects that are in sync with the destination, and so aren't transferred, if not nil
+	inSyncProcessor objectProcessor
+
 	// storing the destination objects
 	destinationIndex *objectIndexer
 
 	disableComparison bool
+
+	// compares by MD5 rather than by last modified time, if not nil
+	hashComparer *syncHashComparer
+
+	// also transfers the files whose properties differ, if not nil
+	propertyComparer *syncPropertyComparer
+
+	// if not nil, files that are more recent at the destination are passed to this, rather than transferred
+	onDestinationNewer func(source StoredObject)
 }
 
-func newSyncSourceComparator(i *objectIndexer, copyScheduler objectProcessor, disableComparison bool) *syncSourceComparator {
-	return &syncSourceComparator{destinationIndex: i, copyTransferScheduler: copyScheduler, disableComparison: disableComparison}
+func newSyncSourceComparator(i *objectIndexer, copyScheduler objectProcessor, disableComparison bool, hashComparer *syncHashComparer, propertyComparer *syncPropertyComparer, onDestinationNewer func(source StoredObject)) *syncSourceComparator {
+	return &syncSourceComparator{destinationIndex: i, copyTransferScheduler: copyScheduler, disableComparison: disableComparison, hashComparer: hashComparer, propertyComparer: propertyComparer, onDestinationNewer: onDestinationNewer}
 }
 
 // it will only transfer source items that are:
@@ -107,8 +300,11 @@ func (f *syncSourceComparator) processIfNecessary(sourceObject StoredObject) err
 		defer delete(f.destinationIndex.indexMap, relPath)
 
 		// if destination is stale, schedule source for transfer
-		if f.disableComparison || sourceObject.isMoreRecentThan(destinationObjectInMap) {
-			return f.copyTransferScheduler(sourceObject)
+		if syncNeedsTransfer(sourceObject, destinationObjectInMap, f.disableComparison, f.hashComparer, f.propertyComparer, f.onDestinationNewer) {
+			return scheduleSyncUpdate(f.updateScheduler, f.copyTransferScheduler)(sourceObject)
+		}
+		if f.inSyncProcessor != nil {
+			return f.inSyncProcessor(sourceObject)
 		}
 		// skip if source is more recent
 		return nil
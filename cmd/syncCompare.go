@@ -0,0 +1,91 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// newSyncCompareReport makes an empty report. Its lists are never nil, so that each of them is in the JSON output,
+// even when it has nothing in it
+func newSyncCompareReport() *common.SyncCompareReport {
+	return &common.SyncCompareReport{
+		OnlyInSource:      make([]string, 0),
+		OnlyInDestination: make([]string, 0),
+		Differing:         make([]string, 0),
+		Identical:         make([]string, 0),
+	}
+}
+
+// recordInSyncCompareReport takes the place of a processor that would schedule a transfer or a deletion, when sync runs
+// with --compare-only. It only adds the relative path of each file it's given to list. The comparators and the index
+// pass objects on one at a time, so list needs no lock
+func recordInSyncCompareReport(list *[]string) objectProcessor {
+	return func(object StoredObject) error {
+		// folders have no content to compare, and are only ever transferred for their properties
+		if object.entityType == common.EEntityType.File() {
+			*list = append(*list, object.relativePath)
+		}
+		return nil
+	}
+}
+
+// syncCompareReportBuilder reports how the source and destination compare, once sync --compare-only has been through
+// both of them. In JSON output it can be parsed as a common.SyncCompareReport
+func syncCompareReportBuilder(report *common.SyncCompareReport) common.OutputBuilder {
+	if report == nil {
+		return nil
+	}
+	// the files are found in whatever order the traversers list them, which needn't be the same from one run to the next
+	for _, list := range [][]string{report.OnlyInSource, report.OnlyInDestination, report.Differing, report.Identical} {
+		sort.Strings(list)
+	}
+
+	return func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			jsonOutput, err := json.Marshal(report)
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+
+		var output strings.Builder
+		for _, category := range []struct {
+			name  string
+			files []string
+		}{
+			{"only in source", report.OnlyInSource},
+			{"only in destination", report.OnlyInDestination},
+			{"differing", report.Differing},
+			{"identical", report.Identical},
+		} {
+			for _, file := range category.files {
+				output.WriteString(fmt.Sprintf("COMPARE: %s: %s\n", category.name, file))
+			}
+		}
+		output.WriteString(fmt.Sprintf("COMPARE: files only in source: %d, only in destination: %d, differing: %d, identical: %d",
+			len(report.OnlyInSource), len(report.OnlyInDestination), len(report.Differing), len(report.Identical)))
+		return output.String()
+	}
+}
@@ -56,16 +56,23 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 		}
 	}
 
+	telemetry, err := startEnumerationTelemetry(cca.enumerationTelemetry)
+	if err != nil {
+		return nil, err
+	}
+	srcCtx := telemetry.attach(ctx, "source")
+	dstCtx := telemetry.attach(ctx, "destination")
+
 	// TODO: enable symlink support in a future release after evaluating the implications
 	// TODO: Consider passing an errorChannel so that enumeration errors during sync can be conveyed to the caller.
 	// GetProperties is enabled by default as sync supports both upload and download.
 	// This property only supports Files and S3 at the moment, but provided that Files sync is coming soon, enable to avoid stepping on Files sync work
-	sourceTraverser, err := InitResourceTraverser(cca.source, cca.fromTo.From(), &ctx, &srcCredInfo, nil,
+	sourceTraverser, err := InitResourceTraverser(cca.source, cca.fromTo.From(), &srcCtx, &srcCredInfo, common.ESymlinkHandlingType.Skip(), "",
 		nil, cca.recursive, true, cca.isHNSToHNS, common.EPermanentDeleteOption.None(), func(entityType common.EntityType) {
 			if entityType == common.EEntityType.File() {
 				atomic.AddUint64(&cca.atomicSourceFilesScanned, 1)
 			}
-		}, nil, cca.s2sPreserveBlobTags, azcopyLogVerbosity.ToPipelineLogLevel(), cca.cpkOptions, nil /* errorChannel */)
+		}, nil, false, cca.s2sPreserveBlobTags, azcopyLogVerbosity.ToPipelineLogLevel(), cca.cpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 
 	if err != nil {
 		return nil, err
@@ -79,14 +86,24 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 		return nil, err
 	}
 
+	if cca.fromTo.To() == common.ELocation.Blob() {
+		dstContainerName, err := GetContainerName(cca.destination.Value, cca.fromTo.To())
+		if err != nil {
+			return nil, err
+		}
+		if err = validateEncryptionScope(ctx, cca.destination, dstContainerName, cca.cpkOptions); err != nil {
+			return nil, err
+		}
+	}
+
 	// TODO: enable symlink support in a future release after evaluating the implications
 	// GetProperties is enabled by default as sync supports both upload and download.
 	// This property only supports Files and S3 at the moment, but provided that Files sync is coming soon, enable to avoid stepping on Files sync work
-	destinationTraverser, err := InitResourceTraverser(cca.destination, cca.fromTo.To(), &ctx, &dstCredInfo, nil, nil, cca.recursive, true, cca.isHNSToHNS, common.EPermanentDeleteOption.None(), func(entityType common.EntityType) {
+	destinationTraverser, err := InitResourceTraverser(cca.destination, cca.fromTo.To(), &dstCtx, &dstCredInfo, common.ESymlinkHandlingType.Skip(), "", nil, cca.recursive, true, cca.isHNSToHNS, common.EPermanentDeleteOption.None(), func(entityType common.EntityType) {
 		if entityType == common.EEntityType.File() {
 			atomic.AddUint64(&cca.atomicDestinationFilesScanned, 1)
 		}
-	}, nil, cca.s2sPreserveBlobTags, azcopyLogVerbosity.ToPipelineLogLevel(), cca.cpkOptions, nil /* errorChannel */)
+	}, nil, false, cca.s2sPreserveBlobTags, azcopyLogVerbosity.ToPipelineLogLevel(), cca.cpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 	if err != nil {
 		return nil, err
 	}
@@ -101,21 +118,22 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	// Note: includeFilters and includeAttrFilters are ANDed
 	// They must both pass to get the file included
 	// Same rule applies to excludeFilters and excludeAttrFilters
-	filters := buildIncludeFilters(cca.includePatterns)
+	// The include patterns, include extensions and include regexes, on the other hand, are ORed
+	filters := combineIncludeFilters(buildIncludeFilters(cca.includePatterns, cca.ignoreCasePattern), buildExtensionFilters(cca.includeExt, true), buildRegexFilters(cca.includeRegex, true))
 	if cca.fromTo.From() == common.ELocation.Local() {
 		includeAttrFilters := buildAttrFilters(cca.includeFileAttributes, cca.source.ValueLocal(), true)
 		filters = append(filters, includeAttrFilters...)
 	}
 
-	filters = append(filters, buildExcludeFilters(cca.excludePatterns, false)...)
-	filters = append(filters, buildExcludeFilters(cca.excludePaths, true)...)
+	filters = append(filters, buildExcludeFilters(cca.excludePatterns, false, cca.ignoreCasePattern)...)
+	filters = append(filters, buildExtensionFilters(cca.excludeExt, false)...)
+	filters = append(filters, buildExcludeFilters(cca.excludePaths, true, false)...)
 	if cca.fromTo.From() == common.ELocation.Local() {
 		excludeAttrFilters := buildAttrFilters(cca.excludeFileAttributes, cca.source.ValueLocal(), false)
 		filters = append(filters, excludeAttrFilters...)
 	}
 
-	// includeRegex
-	filters = append(filters, buildRegexFilters(cca.includeRegex, true)...)
+	// excludeRegex
 	filters = append(filters, buildRegexFilters(cca.excludeRegex, false)...)
 
 	// after making all filters, log any search prefix computed from them
@@ -127,7 +145,7 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 
 	// decide our folder transfer strategy
 	fpo, folderMessage := newFolderPropertyOption(cca.fromTo, cca.recursive, true, filters, cca.preserveSMBInfo, cca.preservePermissions.IsTruthy(), false, cca.isHNSToHNS, strings.EqualFold(cca.destination.Value, common.Dev_Null), false) // sync always acts like stripTopDir=true
-	if !cca.dryrunMode {
+	if !cca.dryrunMode && !cca.compareOnly {
 		glcm.Info(folderMessage)
 	}
 	if jobsAdmin.JobsAdmin != nil {
@@ -136,9 +154,58 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 
 	transferScheduler := newSyncTransferProcessor(cca, NumOfFilesPerDispatchJobPart, fpo)
 
+	// A dry run sorts the files it would transfer into those that are new at the destination and those that replace a
+	// file there. Only the comparator knows which is which, so it schedules the latter through a processor of their own.
+	scheduleCopyTransfer := transferScheduler.scheduleCopyTransfer
+	scheduleUpdate := transferScheduler.scheduleCopyTransfer
+	if cca.dryrunMode {
+		scheduleCopyTransfer = countSyncDryrunFiles(&cca.dryrunSummary.FilesToAdd, &cca.dryrunSummary.BytesToAdd, scheduleCopyTransfer)
+		scheduleUpdate = countSyncDryrunFiles(&cca.dryrunSummary.FilesToUpdate, &cca.dryrunSummary.BytesToUpdate, scheduleUpdate)
+	}
+
+	// A comparison reports where each file would go, rather than sending it anywhere. It uses the same comparators as
+	// a sync, so it judges the files in the same way, but nothing is transferred or deleted
+	var recordInSync, recordExtra objectProcessor
+	if cca.compareOnly {
+		scheduleCopyTransfer = recordInSyncCompareReport(&cca.compareReport.OnlyInSource)
+		scheduleUpdate = recordInSyncCompareReport(&cca.compareReport.Differing)
+		recordInSync = recordInSyncCompareReport(&cca.compareReport.Identical)
+		recordExtra = recordInSyncCompareReport(&cca.compareReport.OnlyInDestination)
+	}
+
+	// The date window decides which files are transferred, not which files exist, so it's applied as transfers are
+	// scheduled, rather than by the traversers. Otherwise a file outside the window would look as if it were missing
+	// from the source, and would be deleted from the destination.
+	if dateFilters := cca.buildDateFilters(); len(dateFilters) > 0 {
+		scheduleCopyTransfer = scheduleIfPassedFilters(dateFilters, scheduleCopyTransfer)
+		scheduleUpdate = scheduleIfPassedFilters(dateFilters, scheduleUpdate)
+	}
+
 	// set up the comparator so that the source/destination can be compared
 	indexer := newObjectIndexer()
 	var comparator objectProcessor
+	var hashComparer *syncHashComparer
+	if cca.compareBy == common.ESyncComparator.MD5() {
+		hashComparer = &syncHashComparer{}
+		if cca.fromTo.From() == common.ELocation.Local() {
+			hashComparer.sourceLocalRoot = cca.source.ValueLocal()
+		}
+		if cca.fromTo.To() == common.ELocation.Local() {
+			hashComparer.destinationLocalRoot = cca.destination.ValueLocal()
+		}
+		if cca.checksumCachePath != "" {
+			hashComparer.cache = loadChecksumCache(cca.checksumCachePath)
+		}
+	}
+	var onDestinationNewer func(source StoredObject)
+	if cca.excludeIfDestinationNewer {
+		onDestinationNewer = func(source StoredObject) {
+			cca.incrementDestinationNewerCount()
+			if azcopyScanningLogger != nil {
+				azcopyScanningLogger.Log(pipeline.LogInfo, fmt.Sprintf("Not syncing %s, because it is more recent at the destination", source.relativePath))
+			}
+		}
+	}
 	var finalize func() error
 
 	switch cca.fromTo {
@@ -146,23 +213,43 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 		// Upload implies transferring from a local disk to a remote resource.
 		// In this scenario, the local disk (source) is scanned/indexed first because it is assumed that local file systems will be faster to enumerate than remote resources
 		// Then the destination is scanned and filtered based on what the destination contains
-		destinationCleaner, err := newSyncDeleteProcessor(cca)
-		if err != nil {
-			return nil, fmt.Errorf("unable to instantiate destination cleaner due to: %s", err.Error())
+		destCleanerFunc := recordExtra
+		if !cca.compareOnly {
+			destinationCleaner, err := newSyncDeleteProcessor(cca)
+			if err != nil {
+				return nil, fmt.Errorf("unable to instantiate destination cleaner due to: %s", err.Error())
+			}
+			destCleanerFunc = newFpoAwareProcessor(fpo, destinationCleaner.removeImmediately)
 		}
-		destCleanerFunc := newFpoAwareProcessor(fpo, destinationCleaner.removeImmediately)
 
 		// when uploading, we can delete remote objects immediately, because as we traverse the remote location
 		// we ALREADY have available a complete map of everything that exists locally
 		// so as soon as we see a remote destination object we can know whether it exists in the local source
-		comparator = newSyncDestinationComparator(indexer, transferScheduler.scheduleCopyTransfer, destCleanerFunc, cca.mirrorMode).processIfNecessary
+		destinationComparator := newSyncDestinationComparator(indexer, scheduleCopyTransfer, destCleanerFunc, cca.mirrorMode, hashComparer, cca.propertyComparer, onDestinationNewer)
+		destinationComparator.updateScheduler = scheduleUpdate
+		destinationComparator.inSyncProcessor = recordInSync
+		comparator = destinationComparator.processIfNecessary
 		finalize = func() error {
+			// every file that exists at both ends has been compared by now
+			hashComparer.finish()
+
 			// schedule every local file that doesn't exist at the destination
-			err = indexer.traverse(transferScheduler.scheduleCopyTransfer, filters)
+			err = indexer.traverse(scheduleCopyTransfer, filters)
 			if err != nil {
 				return err
 			}
 
+			if cca.compareOnly {
+				// the report is printed on exit
+				return nil
+			}
+
+			if cca.dryrunMode {
+				// nothing was sent to the job, so there is nothing to dispatch. The dry run's summary is printed on exit
+				cca.dryrunSummary.DryrunSummary = transferScheduler.dryrunSummary
+				return nil
+			}
+
 			jobInitiated, err := transferScheduler.dispatchFinalPart()
 			// sync cleanly exits if nothing is scheduled.
 			if err != nil && err != NothingScheduledError {
@@ -170,6 +257,9 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 			}
 
 			quitIfInSync(jobInitiated, cca.getDeletionCount() > 0, cca)
+			if jobInitiated {
+				cca.saveSyncState()
+			}
 			cca.setScanningComplete()
 			return nil
 		}
@@ -179,15 +269,23 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 		indexer.isDestinationCaseInsensitive = IsDestinationCaseInsensitive(cca.fromTo)
 		// in all other cases (download and S2S), the destination is scanned/indexed first
 		// then the source is scanned and filtered based on what the destination contains
-		comparator = newSyncSourceComparator(indexer, transferScheduler.scheduleCopyTransfer, cca.mirrorMode).processIfNecessary
+		sourceComparator := newSyncSourceComparator(indexer, scheduleCopyTransfer, cca.mirrorMode, hashComparer, cca.propertyComparer, onDestinationNewer)
+		sourceComparator.updateScheduler = scheduleUpdate
+		sourceComparator.inSyncProcessor = recordInSync
+		comparator = sourceComparator.processIfNecessary
 
 		finalize = func() error {
+			// every file that exists at both ends has been compared by now
+			hashComparer.finish()
+
 			// remove the extra files at the destination that were not present at the source
 			// we can only know what needs to be deleted when we have FINISHED traversing the remote source
 			// since only then can we know which local files definitely don't exist remotely
 			var deleteScheduler objectProcessor
-			switch cca.fromTo.To() {
-			case common.ELocation.Blob(), common.ELocation.File():
+			switch {
+			case cca.compareOnly:
+				deleteScheduler = recordExtra
+			case cca.fromTo.To() == common.ELocation.Blob(), cca.fromTo.To() == common.ELocation.File():
 				deleter, err := newSyncDeleteProcessor(cca)
 				if err != nil {
 					return err
@@ -202,6 +300,15 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 				return err
 			}
 
+			if cca.compareOnly {
+				return nil
+			}
+
+			if cca.dryrunMode {
+				cca.dryrunSummary.DryrunSummary = transferScheduler.dryrunSummary
+				return nil
+			}
+
 			// let the deletions happen first
 			// otherwise if the final part is executed too quickly, we might quit before deletions could finish
 			jobInitiated, err := transferScheduler.dispatchFinalPart()
@@ -211,6 +318,9 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 			}
 
 			quitIfInSync(jobInitiated, cca.getDeletionCount() > 0, cca)
+			if jobInitiated {
+				cca.saveSyncState()
+			}
 			cca.setScanningComplete()
 			return nil
 		}
@@ -219,6 +329,40 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	}
 }
 
+// scheduleIfPassedFilters passes on to schedule only the objects that pass the filters
+func scheduleIfPassedFilters(filters []ObjectFilter, schedule objectProcessor) objectProcessor {
+	return func(object StoredObject) error {
+		if !passedFilters(filters, object) {
+			return nil
+		}
+		return schedule(object)
+	}
+}
+
+// countSyncDryrunFiles counts the files that a dry run passes on to schedule, and their size in bytes.
+// Folders are left out, since what is transferred for them is only their properties
+func countSyncDryrunFiles(files, bytes *uint64, schedule objectProcessor) objectProcessor {
+	return func(object StoredObject) error {
+		if object.entityType == common.EEntityType.File() {
+			*files++
+			*bytes += uint64(object.size)
+		}
+		return schedule(object)
+	}
+}
+
+// buildDateFilters makes the filters for --include-before and --include-after
+func (cca *cookedSyncCmdArgs) buildDateFilters() []ObjectFilter {
+	filters := make([]ObjectFilter, 0)
+	if cca.includeBefore != nil {
+		filters = append(filters, &IncludeBeforeDateFilter{Threshold: *cca.includeBefore})
+	}
+	if cca.includeAfter != nil {
+		filters = append(filters, &IncludeAfterDateFilter{Threshold: *cca.includeAfter})
+	}
+	return filters
+}
+
 func IsDestinationCaseInsensitive(fromTo common.FromTo) bool {
 	if fromTo.IsDownload() && runtime.GOOS == "windows" {
 		return true
@@ -231,6 +375,9 @@ func quitIfInSync(transferJobInitiated, anyDestinationFileDeleted bool, cca *coo
 	if !transferJobInitiated && !anyDestinationFileDeleted {
 		cca.reportScanningProgress(glcm, 0)
 		glcm.Exit(func(format common.OutputFormat) string {
+			if newer := cca.getDestinationNewerCount(); newer > 0 {
+				return fmt.Sprintf("The source and destination are already in sync, apart from %v files that are newer at the destination, which were left alone.", newer)
+			}
 			return "The source and destination are already in sync."
 		}, common.EExitCode.Success())
 	} else if !transferJobInitiated && anyDestinationFileDeleted {
@@ -100,8 +100,17 @@ type interactiveDeleteProcessor struct {
 	// count the deletions that happened
 	incrementDeletionCount func()
 
+	// count the deletions that the user declined, when prompted
+	incrementDeclinedDeletionCount func()
+
+	// whether the user has been prompted yet. If so, a decision not to delete was the user's choice
+	userPrompted bool
+
 	// dryrunMode
 	dryrunMode bool
+
+	// where a dry run counts the files it would delete. Nil if it doesn't count them
+	dryrunSummary *common.SyncDryrunSummary
 }
 
 func newDeleteTransfer(object StoredObject) (newDeleteTransfer common.CopyTransfer) {
@@ -125,13 +134,23 @@ func newDeleteTransfer(object StoredObject) (newDeleteTransfer common.CopyTransf
 func (d *interactiveDeleteProcessor) removeImmediately(object StoredObject) (err error) {
 	if d.shouldPromptUser {
 		d.shouldDelete, d.shouldPromptUser = d.promptForConfirmation(object) // note down the user's decision
+		d.userPrompted = true
 	}
 
 	if !d.shouldDelete {
+		if d.userPrompted && d.incrementDeclinedDeletionCount != nil {
+			d.incrementDeclinedDeletionCount()
+		}
 		return nil
 	}
 
 	if d.dryrunMode {
+		// like the deleters, sync only deletes files
+		if d.dryrunSummary != nil && object.entityType == common.EEntityType.File() {
+			d.dryrunSummary.FilesToDelete++
+			d.dryrunSummary.BytesToDelete += uint64(object.size)
+		}
+
 		glcm.Dryrun(func(format common.OutputFormat) string {
 			if format == common.EOutputFormat.Json() {
 				jsonOutput, err := json.Marshal(newDeleteTransfer(object))
@@ -201,22 +220,24 @@ func (d *interactiveDeleteProcessor) promptForConfirmation(object StoredObject)
 }
 
 func newInteractiveDeleteProcessor(deleter objectProcessor, deleteDestination common.DeleteDestination,
-	objectTypeToDisplay string, objectLocationToDisplay common.ResourceString, incrementDeletionCounter func(), dryrun bool) *interactiveDeleteProcessor {
+	objectTypeToDisplay string, objectLocationToDisplay common.ResourceString, incrementDeletionCounter func(), incrementDeclinedDeletionCounter func(), dryrun bool, dryrunSummary *common.SyncDryrunSummary) *interactiveDeleteProcessor {
 
 	return &interactiveDeleteProcessor{
-		deleter:                 deleter,
-		objectTypeToDisplay:     objectTypeToDisplay,
-		objectLocationToDisplay: objectLocationToDisplay.Value,
-		incrementDeletionCount:  incrementDeletionCounter,
-		shouldPromptUser:        deleteDestination == common.EDeleteDestination.Prompt(),
-		shouldDelete:            deleteDestination == common.EDeleteDestination.True(), // if shouldPromptUser is true, this will start as false, but we will determine its value later
-		dryrunMode:              dryrun,
+		deleter:                        deleter,
+		objectTypeToDisplay:            objectTypeToDisplay,
+		objectLocationToDisplay:        objectLocationToDisplay.Value,
+		incrementDeletionCount:         incrementDeletionCounter,
+		incrementDeclinedDeletionCount: incrementDeclinedDeletionCounter,
+		shouldPromptUser:               deleteDestination == common.EDeleteDestination.Prompt(),
+		shouldDelete:                   deleteDestination == common.EDeleteDestination.True(), // if shouldPromptUser is true, this will start as false, but we will determine its value later
+		dryrunMode:                     dryrun,
+		dryrunSummary:                  dryrunSummary,
 	}
 }
 
 func newSyncLocalDeleteProcessor(cca *cookedSyncCmdArgs) *interactiveDeleteProcessor {
 	localDeleter := localFileDeleter{rootPath: cca.destination.ValueLocal()}
-	return newInteractiveDeleteProcessor(localDeleter.deleteFile, cca.deleteDestination, "local file", cca.destination, cca.incrementDeletionCount, cca.dryrunMode)
+	return newInteractiveDeleteProcessor(localDeleter.deleteFile, cca.deleteDestination, "local file", cca.destination, cca.incrementDeletionCount, cca.incrementDeclinedDeletionCount, cca.dryrunMode, cca.dryrunSummary)
 }
 
 type localFileDeleter struct {
@@ -264,7 +285,7 @@ func newSyncDeleteProcessor(cca *cookedSyncCmdArgs) (*interactiveDeleteProcessor
 	}
 
 	return newInteractiveDeleteProcessor(newRemoteResourceDeleter(rawURL, p, ctx, cca.fromTo.To()).delete,
-		cca.deleteDestination, cca.fromTo.To().String(), cca.destination, cca.incrementDeletionCount, cca.dryrunMode), nil
+		cca.deleteDestination, cca.fromTo.To().String(), cca.destination, cca.incrementDeletionCount, cca.incrementDeclinedDeletionCount, cca.dryrunMode, cca.dryrunSummary), nil
 }
 
 type remoteResourceDeleter struct {
@@ -0,0 +1,161 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// syncState is what a sync saves, in its sync-state file, once it has finished comparing the source with the destination
+// and has ordered all the transfers. Those transfers, and their progress, are in the job's plan files. So a sync that is
+// interrupted after that point can be resumed, by resuming its job, without scanning the source and destination again.
+// The state is only used for the same source and destination, and is removed once the job has transferred everything.
+type syncState struct {
+	Source      string // without any SAS
+	Destination string
+	FromTo      string
+	JobID       common.JobID
+}
+
+// readSyncState returns the job that a previous sync between the same source and destination left to be resumed,
+// if any. A state for a different source or destination, or for a job that no longer exists, is ignored
+func (cca *cookedSyncCmdArgs) readSyncState() (jobID common.JobID, found bool) {
+	raw, err := ioutil.ReadFile(cca.syncStatePath)
+	if os.IsNotExist(err) {
+		return common.JobID{}, false
+	}
+
+	ignore := func(reason string) (common.JobID, bool) {
+		glcm.Info(fmt.Sprintf("The sync state in %s is ignored, because %s. The source and destination will be scanned.", cca.syncStatePath, reason))
+		return common.JobID{}, false
+	}
+
+	var state syncState
+	if err != nil {
+		return ignore(err.Error())
+	}
+	if err = json.Unmarshal(raw, &state); err != nil {
+		return ignore("it could not be read: " + err.Error())
+	}
+	if state.Source != cca.source.Value || state.Destination != cca.destination.Value || state.FromTo != cca.fromTo.String() {
+		return ignore("it is for a different source or destination")
+	}
+
+	var getJobFromToResponse common.GetJobFromToResponse
+	Rpc(common.ERpcCmd.GetJobFromTo(), &common.GetJobFromToRequest{JobID: state.JobID}, &getJobFromToResponse)
+	if getJobFromToResponse.ErrorMsg != "" {
+		return ignore(fmt.Sprintf("its job %s is no longer known", state.JobID))
+	}
+
+	return state.JobID, true
+}
+
+// saveSyncState records that the job has been ordered in full, so that it can be resumed by a later sync
+func (cca *cookedSyncCmdArgs) saveSyncState() {
+	if cca.syncStatePath == "" {
+		return
+	}
+
+	raw, err := json.Marshal(syncState{
+		Source:      cca.source.Value,
+		Destination: cca.destination.Value,
+		FromTo:      cca.fromTo.String(),
+		JobID:       cca.jobID,
+	})
+	common.PanicIfErr(err)
+
+	// written to a temporary file first, so that an interruption can't leave a partial state behind
+	tempPath := cca.syncStatePath + ".tmp"
+	if err = ioutil.WriteFile(tempPath, raw, 0644); err == nil {
+		err = os.Rename(tempPath, cca.syncStatePath)
+	}
+	if err != nil {
+		glcm.Info(fmt.Sprintf("Failed to save the sync state in %s, so this sync can't be resumed from it: %s", cca.syncStatePath, err))
+	}
+}
+
+// finishSyncState removes the sync state once its job has transferred everything, so the next sync scans afresh.
+// If some transfers failed, or were cancelled, the state is kept, so that the next sync resumes the job instead
+func (cca *cookedSyncCmdArgs) finishSyncState(summary common.ListJobSummaryResponse) {
+	if cca.syncStatePath == "" {
+		return
+	}
+
+	completed := summary.JobStatus == common.EJobStatus.Completed() || summary.JobStatus == common.EJobStatus.CompletedWithSkipped()
+	// a cancelled transfer doesn't change the status of its job, so it is found by the count of transfers that didn't complete
+	allDone := summary.TransfersFailed == 0 && summary.TransfersCompleted+summary.TransfersSkipped == summary.TotalTransfers
+	if !completed || !allDone {
+		glcm.Info(fmt.Sprintf("The sync state in %s has been kept, so the next sync with it will resume job %s, retrying the transfers that did not complete, "+
+			"rather than scanning the source and destination again. Delete it to scan them again instead.", cca.syncStatePath, cca.jobID))
+		return
+	}
+
+	if err := os.Remove(cca.syncStatePath); err != nil && !os.IsNotExist(err) {
+		glcm.Info(fmt.Sprintf("Failed to remove the sync state in %s: %s", cca.syncStatePath, err))
+	}
+}
+
+// resumeFromSyncState resumes the job of an earlier, interrupted, sync. It reports progress as if the scanning had just finished
+func (cca *cookedSyncCmdArgs) resumeFromSyncState(jobID common.JobID) error {
+	glcm.Info(fmt.Sprintf("Resuming job %s from the sync state in %s, without scanning the source and destination again.", jobID, cca.syncStatePath))
+
+	var resumeJobResponse common.CancelPauseResumeResponse
+	Rpc(common.ERpcCmd.ResumeJob(),
+		&common.ResumeJobRequest{
+			JobID:          jobID,
+			SourceSAS:      cca.source.SAS,
+			DestinationSAS: cca.destination.SAS,
+			CredentialInfo: cca.credentialInfo,
+		},
+		&resumeJobResponse)
+	if !resumeJobResponse.CancelledPauseResumed {
+		return errors.New(resumeJobResponse.ErrorMsg + ". Delete the sync state in " + cca.syncStatePath + " to scan the source and destination again instead")
+	}
+
+	cca.jobID = jobID
+	cca.isEnumerationComplete = true
+	cca.setFirstPartOrdered()
+	cca.setScanningComplete()
+	cca.waitUntilJobCompletion(false)
+	return nil
+}
+
+// syncStatePathFromFlag validates the sync-state flag, and makes its path absolute, so that it doesn't depend on the working directory
+func syncStatePathFromFlag(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid sync-state path %s: %w", path, err)
+	}
+	if fi, err := os.Stat(filepath.Dir(absPath)); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("the folder for the sync state, %s, does not exist", filepath.Dir(absPath))
+	}
+	return absPath, nil
+}
@@ -131,6 +131,11 @@ func InferArgumentLocation(arg string) common.Location {
 		u, err := url.Parse(arg)
 		// NOTE: sometimes, a local path can also be parsed as a url. To avoid thinking it's a URL, check Scheme, Host, and Path
 		if err == nil && u.Scheme != "" && u.Host != "" {
+			// the S3-compatible endpoint comes first, since it can have any host, even one that looks like Azure's or an IP address
+			if common.IsS3CompatibleURL(*u) {
+				return common.ELocation.S3()
+			}
+
 			// Is the argument a URL to blob storage?
 			switch host := strings.ToLower(u.Host); true {
 			// Azure Stack does not have the core.windows.net
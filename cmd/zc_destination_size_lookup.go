@@ -0,0 +1,102 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-storage-file-go/azfile"
+)
+
+// destinationSizeLookup finds the size of a file that is already at the destination, for skip-existing-with-same-size.
+// Only the files that are about to be transferred are looked up, one request (or stat) each, so the destination is
+// never listed, and nothing about it is held in memory
+type destinationSizeLookup struct {
+	ctx        context.Context
+	location   common.Location
+	root       common.ResourceString
+	p          pipeline.Pipeline
+	cpkOptions common.CpkOptions
+}
+
+func (cca *CookedCopyCmdArgs) newDestinationSizeLookup(ctx context.Context) (*destinationSizeLookup, error) {
+	l := &destinationSizeLookup{ctx: ctx, location: cca.FromTo.To(), root: cca.Destination, cpkOptions: cca.CpkOptions}
+	if l.location.IsRemote() {
+		dstCredInfo, _, err := GetCredentialInfoForLocation(ctx, l.location, cca.Destination.Value, cca.Destination.SAS, false, cca.CpkOptions)
+		if err != nil {
+			return nil, err
+		}
+		if l.p, err = InitPipeline(ctx, l.location, dstCredInfo, azcopyLogVerbosity.ToPipelineLogLevel()); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// fileURL gives the URL of the file at dstRelPath, the destination path made by MakeEscapedRelativePath, which is
+// already URL-encoded for a remote destination
+func (l *destinationSizeLookup) fileURL(dstRelPath string) (*url.URL, error) {
+	return l.root.CloneWithValue(common.GenerateFullPath(l.root.Value, dstRelPath)).FullURL()
+}
+
+// sizeOf returns the size of the file at dstRelPath. A file that can't be looked up, for whatever reason, is treated
+// as not being there, so that it is transferred
+func (l *destinationSizeLookup) sizeOf(dstRelPath string) (size int64, exists bool) {
+	if l.location == common.ELocation.Local() {
+		info, err := os.Stat(common.GenerateFullPath(l.root.ValueLocal(), dstRelPath))
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		return info.Size(), true
+	}
+
+	u, err := l.fileURL(dstRelPath)
+	if err != nil {
+		return 0, false
+	}
+	switch l.location {
+	case common.ELocation.Blob():
+		props, err := azblob.NewBlobURL(*u, l.p).GetProperties(l.ctx, azblob.BlobAccessConditions{}, common.GetClientProvidedKey(l.cpkOptions))
+		if err != nil || gCopyUtil.doesBlobRepresentAFolder(props.NewMetadata()) {
+			return 0, false
+		}
+		return props.ContentLength(), true
+	case common.ELocation.File():
+		props, err := azfile.NewFileURL(*u, l.p).GetProperties(l.ctx)
+		if err != nil {
+			return 0, false
+		}
+		return props.ContentLength(), true
+	case common.ELocation.BlobFS():
+		props, err := azbfs.NewFileURL(*u, l.p).GetProperties(l.ctx)
+		if err != nil || props.XMsResourceType() != "file" {
+			return 0, false
+		}
+		return props.ContentLength(), true
+	}
+	return 0, false
+}
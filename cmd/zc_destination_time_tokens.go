@@ -0,0 +1,83 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// destinationTimeTokens are the tokens that destination-time-tokens expands, each to the Go time layout of the part of
+// the job's start time that it stands for
+var destinationTimeTokens = map[string]string{
+	"yyyy": "2006",
+	"yy":   "06",
+	"MM":   "01",
+	"dd":   "02",
+	"HH":   "15",
+	"mm":   "04",
+	"ss":   "05",
+}
+
+const destinationTimeTokenList = "{yyyy}, {yy}, {MM}, {dd}, {HH}, {mm} and {ss}"
+
+// expandDestinationTimeTokens replaces each {token} in the path of the destination with that part of the given time,
+// in UTC. It's done once, to the destination root, so every file of the job lands under the same expanded path,
+// and the relative paths of the files (including any that a PathRewriter gives them) are never expanded.
+// The query of a URL, which may hold a SAS, is left alone. Anything in braces that isn't a token is an error
+func expandDestinationTimeTokens(destination string, location common.Location, at time.Time) (string, error) {
+	path, query := destination, ""
+	if location.IsRemote() {
+		if i := strings.Index(destination, "?"); i >= 0 {
+			path, query = destination[:i], destination[i:]
+		}
+		// the braces may have been escaped, when the URL was made by a tool rather than typed
+		path = strings.NewReplacer("%7B", "{", "%7b", "{", "%7D", "}", "%7d", "}").Replace(path)
+	}
+	at = at.UTC()
+
+	var expanded strings.Builder
+	for {
+		start := strings.IndexAny(path, "{}")
+		if start < 0 {
+			break
+		}
+		if path[start] == '}' {
+			return "", fmt.Errorf("the destination has a '}' without a '{' before it. Only the tokens %s may be used in braces", destinationTimeTokenList)
+		}
+		length := strings.IndexAny(path[start+1:], "{}")
+		if length < 0 || path[start+1+length] != '}' {
+			return "", fmt.Errorf("the destination has a '{' without a '}' after it. Only the tokens %s may be used in braces", destinationTimeTokenList)
+		}
+		token := path[start+1 : start+1+length]
+		layout, ok := destinationTimeTokens[token]
+		if !ok {
+			return "", fmt.Errorf("the destination has the unknown token {%s}. The tokens are %s", token, destinationTimeTokenList)
+		}
+		expanded.WriteString(path[:start])
+		expanded.WriteString(at.Format(layout))
+		path = path[start+1+length+1:]
+	}
+	expanded.WriteString(path)
+	return expanded.String() + query, nil
+}
@@ -0,0 +1,127 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// the name of the marker blobs of --preserve-empty-directories, unless --empty-directory-marker gives another
+const defaultEmptyDirectoryMarker = ".azcopy-empty-dir"
+
+// emptyDirectoryMarkers is for --preserve-empty-directories. Blob has no folders of its own, so an empty folder that is
+// uploaded to it is kept by writing a zero-byte blob, with the marker's name, into it. A download turns each of those
+// markers back into the folder that it's in, rather than into a file.
+//
+// An upload only knows that a folder is empty once the enumeration is done, so it holds back the folders that it finds
+// until then, and passes on those that nothing was found in, each with its marker as its destination. The markers are
+// written like any other folder stub, with the metadata hdi_isfolder=true, so that listings that leave out folder
+// stubs (which is most of them) leave out the markers too. A download takes markers by their names, so that it also
+// knows zero-byte markers that were written without that metadata
+type emptyDirectoryMarkers struct {
+	marker   string
+	download bool
+	mu       sync.Mutex
+	folders  []StoredObject  // held back by an upload, in the order they were listed
+	nonEmpty map[string]bool // the relative paths of the folders that an upload found something in
+}
+
+func newEmptyDirectoryMarkers(marker string, fromTo common.FromTo) *emptyDirectoryMarkers {
+	return &emptyDirectoryMarkers{marker: marker, download: fromTo.IsDownload(), nonEmpty: make(map[string]bool)}
+}
+
+// validateEmptyDirectoryMarker checks that a marker is the name of a blob that can go in any folder
+func validateEmptyDirectoryMarker(marker string) error {
+	if marker == "" || marker == "." || marker == ".." || strings.ContainsAny(marker, `/\`) {
+		return errors.New("empty-directory-marker must be a name, without any slashes, such as " + defaultEmptyDirectoryMarker)
+	}
+	return nil
+}
+
+// wrap returns processor and finalizer such that processor sees empty folders, when uploading, and markers, when
+// downloading, as folders whose destination is their marker, or the folder that the marker is in
+func (m *emptyDirectoryMarkers) wrap(processor objectProcessor, finalizer func() error) (objectProcessor, func() error) {
+	if m.download {
+		restore := func(object StoredObject) error {
+			if m.isMarker(object) {
+				folder := path.Dir(object.relativePath)
+				if folder == "." {
+					folder = "" // the marker of the source folder itself
+				}
+				object.entityType = common.EEntityType.Folder()
+				object.emptyDirectoryDestination = &folder
+			}
+			return processor(object)
+		}
+		return restore, finalizer
+	}
+
+	hold := func(object StoredObject) error {
+		m.mu.Lock()
+		if object.relativePath != "" {
+			m.nonEmpty[emptyDirectoryMarkerParent(object.relativePath)] = true
+		}
+		if object.entityType == common.EEntityType.Folder() {
+			m.folders = append(m.folders, object)
+			m.mu.Unlock()
+			return nil
+		}
+		m.mu.Unlock()
+		return processor(object)
+	}
+
+	release := func() error {
+		for _, folder := range m.folders {
+			if m.nonEmpty[folder.relativePath] {
+				continue
+			}
+			marker := path.Join(folder.relativePath, m.marker)
+			folder.emptyDirectoryDestination = &marker
+			if err := processor(folder); err != nil {
+				return err
+			}
+		}
+		return finalizer()
+	}
+	return hold, release
+}
+
+// isMarker says whether a blob that a download found is a marker. Like other folder stubs, it's listed as a folder
+// when it has hdi_isfolder=true
+func (m *emptyDirectoryMarkers) isMarker(object StoredObject) bool {
+	if object.name != m.marker {
+		return false
+	}
+	return object.entityType == common.EEntityType.Folder() || (object.entityType == common.EEntityType.File() && object.size == 0)
+}
+
+// emptyDirectoryMarkerParent is the relative path of the folder that holds the object at relativePath, which the local
+// traverser gives with forward slashes. That of the source folder itself is empty
+func emptyDirectoryMarkerParent(relativePath string) string {
+	if i := strings.LastIndex(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING); i >= 0 {
+		return relativePath[:i]
+	}
+	return ""
+}
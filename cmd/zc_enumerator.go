@@ -90,6 +90,19 @@ type StoredObject struct {
 	leaseState    azblob.LeaseStateType
 	leaseStatus   azblob.LeaseStatusType
 	leaseDuration azblob.LeaseDurationType
+
+	// only set by the urlListTraverser, since each URL that it lists is a source of its own.
+	// sourceRoot is the container of the URL, with the URL's SAS, and relativePath is the name of the blob within it.
+	// keptPath is the part of that which is kept at the destination
+	sourceRoot *common.ResourceString
+	keptPath   string
+
+	// only set for a copy with --flatten: the name that the object has at the destination, directly under its root
+	flattenedName string
+
+	// only set for a copy with --preserve-empty-directories: the relative path at the destination of an empty folder's
+	// marker, when uploading, or of the folder that a marker is in, when downloading
+	emptyDirectoryDestination *string
 }
 
 func (s *StoredObject) isMoreRecentThan(storedObject2 StoredObject) bool {
@@ -120,7 +133,7 @@ func (s *StoredObject) isSourceRootFolder() bool {
 // do not pass through that routine.  So we need to make the filtering available in a separate function
 // so that the sync deletion code path(s) can access it.
 func (s *StoredObject) isCompatibleWithFpo(fpo common.FolderPropertyOption) bool {
-	if s.entityType == common.EEntityType.File() {
+	if s.entityType == common.EEntityType.File() || s.entityType == common.EEntityType.Symlink() {
 		return true
 	} else if s.entityType == common.EEntityType.Folder() {
 		switch fpo {
@@ -261,6 +274,10 @@ func newStoredObject(morpher objectMorpher, name string, relativePath string, en
 			obj.name = "" // make these consistent, even from enumerators that pass in an actual name for these (it doesn't really make sense to pass an actual name)
 		}
 	}
+	// Nor do symlinks. The size that Lstat reports is that of the link's target path, which is sent as metadata, not content
+	if entityType == common.EEntityType.Symlink() {
+		obj.size = 0
+	}
 
 	// in some cases we may be supplied with a func that will perform some modification on the basic object
 	if morpher != nil {
@@ -281,6 +298,22 @@ type ResourceTraverser interface {
 	// Thus, we only check the directory syntax on blob destinations. On sources, we check both syntax and remote, if syntax isn't a directory.
 }
 
+// depthLimitedTraverser is implemented by the traversers that can stop descending at a given depth beneath their root,
+// so that --max-depth saves the listing of everything deeper, rather than just filtering it out afterwards.
+// Folders at maxDepth are still enumerated, but their contents are not. A maxDepth of 0 means no limit.
+type depthLimitedTraverser interface {
+	setMaxDepth(maxDepth uint)
+}
+
+// depthOfRelativePath is how many levels beneath the root a relative path is. The root itself ("") is at depth 0.
+func depthOfRelativePath(relativePath string) int {
+	relativePath = strings.Trim(filepath.ToSlash(relativePath), "/")
+	if relativePath == "" {
+		return 0
+	}
+	return strings.Count(relativePath, "/") + 1
+}
+
 type AccountTraverser interface {
 	ResourceTraverser
 	listContainers() ([]string, error)
@@ -315,14 +348,16 @@ type enumerationCounterFunc func(entityType common.EntityType)
 
 // source, location, recursive, and incrementEnumerationCounter are always required.
 // ctx, pipeline are only required for remote resources.
-// followSymlinks is only required for local resources (defaults to false)
+// symlinkHandling only applies to local resources (its zero value skips symlinks)
+// symlinkRoot, if not empty, is the local folder outside of which symlinks are not followed
+// listVersions makes a blob source list every version of each blob, rather than just its current version.
 // errorOnDirWOutRecursive is used by copy.
 // If errorChannel is non-nil, all errors encountered during enumeration will be conveyed through this channel.
 // To avoid slowdowns, use a buffered channel of enough capacity.
 func InitResourceTraverser(resource common.ResourceString, location common.Location, ctx *context.Context,
-	credential *common.CredentialInfo, followSymlinks *bool, listOfFilesChannel chan string, recursive, getProperties,
+	credential *common.CredentialInfo, symlinkHandling common.SymlinkHandlingType, symlinkRoot string, listOfFilesChannel chan string, recursive, getProperties,
 	includeDirectoryStubs bool, permanentDeleteOption common.PermanentDeleteOption, incrementEnumerationCounter enumerationCounterFunc, listOfVersionIds chan string,
-	s2sPreserveBlobTags bool, logLevel pipeline.LogLevel, cpkOptions common.CpkOptions, errorChannel chan ErrorFileInfo) (ResourceTraverser, error) {
+	listVersions, s2sPreserveBlobTags bool, logLevel pipeline.LogLevel, cpkOptions common.CpkOptions, errorChannel chan ErrorFileInfo, includeBlobTags string, excludeContainerNames []string) (ResourceTraverser, error) {
 	var output ResourceTraverser
 	var p *pipeline.Pipeline
 
@@ -341,6 +376,14 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 		includeSnapshot = true
 		includeVersion = true
 	}
+	if listVersions {
+		if location != common.ELocation.Blob() {
+			return nil, fmt.Errorf("list-versions is unsupported for this source (%s). It can only be used when the source is Blob", location.String())
+		}
+		// includeDeleted stays off, so soft-deleted versions are left out. Like soft-deleted blobs, they must be
+		// undeleted before they can be copied.
+		includeVersion = true
+	}
 
 	// Clean up the resource if it's a local path
 	if location == common.ELocation.Local() {
@@ -358,9 +401,14 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 		p = &tmppipe
 	}
 
-	toFollow := false
-	if followSymlinks != nil {
-		toFollow = *followSymlinks
+	// The tag filter is pushed down to the service, and only Blob has a service-side way to find blobs by their tags
+	if includeBlobTags != "" {
+		if location != common.ELocation.Blob() {
+			return nil, fmt.Errorf("include-blob-tags is unsupported for this source (%s). It can only be used when the source is Blob", location.String())
+		}
+		if listOfFilesChannel != nil || listOfVersionIds != nil {
+			return nil, errors.New("include-blob-tags cannot be combined with include-path, list-of-files or list-of-versions")
+		}
 	}
 
 	// Feed list of files channel into new list traverser
@@ -375,7 +423,7 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 			}
 		}
 
-		output = newListTraverser(resource, location, credential, ctx, recursive, toFollow, getProperties,
+		output = newListTraverser(resource, location, credential, ctx, recursive, symlinkHandling, symlinkRoot, getProperties,
 			listOfFilesChannel, includeDirectoryStubs, incrementEnumerationCounter, s2sPreserveBlobTags, logLevel, cpkOptions)
 		return output, nil
 	}
@@ -403,13 +451,13 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 			}()
 
 			baseResource := resource.CloneWithValue(cleanLocalPath(basePath))
-			output = newListTraverser(baseResource, location, nil, nil, recursive, toFollow, getProperties,
+			output = newListTraverser(baseResource, location, nil, nil, recursive, symlinkHandling, symlinkRoot, getProperties,
 				globChan, includeDirectoryStubs, incrementEnumerationCounter, s2sPreserveBlobTags, logLevel, cpkOptions)
 		} else {
 			if ctx != nil {
-				output = newLocalTraverser(*ctx, resource.ValueLocal(), recursive, toFollow, incrementEnumerationCounter, errorChannel)
+				output = newLocalTraverser(*ctx, resource.ValueLocal(), recursive, symlinkHandling, symlinkRoot, incrementEnumerationCounter, errorChannel)
 			} else {
-				output = newLocalTraverser(context.TODO(), resource.ValueLocal(), recursive, toFollow, incrementEnumerationCounter, errorChannel)
+				output = newLocalTraverser(context.TODO(), resource.ValueLocal(), recursive, symlinkHandling, symlinkRoot, incrementEnumerationCounter, errorChannel)
 			}
 		}
 	case common.ELocation.Benchmark():
@@ -438,12 +486,22 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 			if !recursive {
 				return nil, errors.New(accountTraversalInherentlyRecursiveError)
 			}
+			if includeBlobTags != "" {
+				return nil, errors.New("include-blob-tags cannot be used when copying from multiple containers")
+			}
+			if listVersions {
+				return nil, errors.New("list-versions cannot be used when copying from multiple containers")
+			}
 
-			output = newBlobAccountTraverser(resourceURL, *p, *ctx, includeDirectoryStubs, incrementEnumerationCounter, s2sPreserveBlobTags, cpkOptions)
+			output = newBlobAccountTraverser(resourceURL, *p, *ctx, includeDirectoryStubs, incrementEnumerationCounter, s2sPreserveBlobTags, cpkOptions, excludeContainerNames)
+		} else if len(excludeContainerNames) > 0 {
+			return nil, errors.New("exclude-container can only be used when copying from a whole account, or from the containers matching a wildcard")
 		} else if listOfVersionIds != nil {
 			output = newBlobVersionsTraverser(resourceURL, *p, *ctx, recursive, includeDirectoryStubs, incrementEnumerationCounter, listOfVersionIds, cpkOptions)
 		} else {
-			output = newBlobTraverser(resourceURL, *p, *ctx, recursive, includeDirectoryStubs, incrementEnumerationCounter, s2sPreserveBlobTags, cpkOptions, includeDeleted, includeSnapshot, includeVersion)
+			blobTraverser := newBlobTraverser(resourceURL, *p, *ctx, recursive, includeDirectoryStubs, incrementEnumerationCounter, s2sPreserveBlobTags, cpkOptions, includeDeleted, includeSnapshot, includeVersion)
+			blobTraverser.includeBlobTags = includeBlobTags
+			output = blobTraverser
 		}
 	case common.ELocation.File():
 		resourceURL, err := resource.FullURL()
@@ -553,12 +611,16 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 				return nil, errors.New(accountTraversalInherentlyRecursiveError)
 			}
 
+			if credential.CredentialType == common.ECredentialType.GoogleHMACKey() {
+				return nil, errors.New("listing the buckets of a GCP project requires GOOGLE_APPLICATION_CREDENTIALS to be set, rather than an HMAC key")
+			}
+
 			output, err = newGCPServiceTraverser(resourceURL, *ctx, getProperties, incrementEnumerationCounter)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			output, err = newGCPTraverser(resourceURL, *ctx, recursive, getProperties, incrementEnumerationCounter)
+			output, err = newGCPTraverser(credential.CredentialType, resourceURL, *ctx, recursive, getProperties, incrementEnumerationCounter)
 			if err != nil {
 				return nil, err
 			}
@@ -692,6 +754,9 @@ type CopyEnumerator struct {
 
 	// a finalizer that is always called if the enumeration finishes properly
 	Finalize func() error
+
+	// called instead of Finalize if the enumeration fails, so that whatever ObjectDispatcher started can stop. May be nil
+	Abandon func()
 }
 
 func NewCopyEnumerator(traverser ResourceTraverser, filters []ObjectFilter, objectDispatcher objectProcessor, finalizer func() error) *CopyEnumerator {
@@ -714,6 +779,9 @@ func WarnStdoutAndScanningLog(toLog string) {
 func (e *CopyEnumerator) enumerate() (err error) {
 	err = e.Traverser.Traverse(noPreProccessor, e.ObjectDispatcher, e.Filters)
 	if err != nil {
+		if e.Abandon != nil {
+			e.Abandon()
+		}
 		return
 	}
 
@@ -732,7 +800,7 @@ func passedFilters(filters []ObjectFilter, storedObject StoredObject) bool {
 				glcm.Error(msg)
 			}
 
-			if filter.AppliesOnlyToFiles() && storedObject.entityType != common.EEntityType.File() {
+			if filter.AppliesOnlyToFiles() && storedObject.entityType == common.EEntityType.Folder() {
 				// don't pass folders to filters that only know how to deal with files
 				// As at Feb 2020, we have separate logic to weed out folder properties (and not even send them)
 				// if any filter applies only to files... but that logic runs after this point, so we need this
@@ -21,9 +21,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -63,6 +66,7 @@ func (f *excludeBlobTypeFilter) DoesPass(object StoredObject) bool {
 type excludeFilter struct {
 	pattern     string
 	targetsPath bool
+	ignoreCase  bool // only applies to name patterns, not to paths
 }
 
 func (f *excludeFilter) DoesSupportThisOS() (msg string, supported bool) {
@@ -85,7 +89,7 @@ func (f *excludeFilter) DoesPass(storedObject StoredObject) bool {
 		matched = strings.HasPrefix(storedObject.relativePath, pattern)
 	} else {
 		var err error
-		matched, err = path.Match(f.pattern, storedObject.name)
+		matched, err = matchNamePattern(f.pattern, storedObject.name, f.ignoreCase)
 
 		// if the pattern failed to match with an error, then we assume the pattern is invalid
 		// and let it pass
@@ -101,17 +105,43 @@ func (f *excludeFilter) DoesPass(storedObject StoredObject) bool {
 	return true
 }
 
-func buildExcludeFilters(Patterns []string, targetPath bool) []ObjectFilter {
+func buildExcludeFilters(Patterns []string, targetPath bool, ignoreCase bool) []ObjectFilter {
 	filters := make([]ObjectFilter, 0)
 	for _, pattern := range Patterns {
 		if pattern != "" {
-			filters = append(filters, &excludeFilter{pattern: pattern, targetsPath: targetPath})
+			filters = append(filters, &excludeFilter{pattern: pattern, targetsPath: targetPath, ignoreCase: ignoreCase})
 		}
 	}
 
 	return filters
 }
 
+// includePathFilter passes the objects at, or beneath, any of its paths. include-path is normally handled like a
+// list-of-files, which lists each path directly. But the paths are relative to a single container, so when the source is
+// a whole account, or the containers matching a wildcard, they are applied by this filter within each container instead.
+type includePathFilter struct {
+	paths []string
+}
+
+func (f *includePathFilter) DoesSupportThisOS() (msg string, supported bool) {
+	msg = ""
+	supported = true
+	return
+}
+
+func (f *includePathFilter) AppliesOnlyToFiles() bool {
+	return false // a folder beneath an included path is included too
+}
+
+func (f *includePathFilter) DoesPass(storedObject StoredObject) bool {
+	for _, path := range f.paths {
+		if storedObject.relativePath == path || strings.HasPrefix(storedObject.relativePath, path+common.AZCOPY_PATH_SEPARATOR_STRING) {
+			return true
+		}
+	}
+	return false
+}
+
 // design explanation:
 // include filters are different from the exclude ones, which work together in the "AND" manner
 // meaning and if an StoredObject is rejected by any of the exclude filters, then it is rejected by all of them
@@ -120,7 +150,8 @@ func buildExcludeFilters(Patterns []string, targetPath bool) []ObjectFilter {
 // meaning that if an StoredObject is accepted by any of the include filters, then it is accepted by all of them
 // consequently, all the include Patterns must be stored together
 type IncludeFilter struct {
-	patterns []string
+	patterns   []string
+	ignoreCase bool
 }
 
 func (f *IncludeFilter) DoesSupportThisOS() (msg string, supported bool) {
@@ -144,7 +175,7 @@ func (f *IncludeFilter) DoesPass(storedObject StoredObject) bool {
 		matched := false
 
 		var err error
-		matched, err = path.Match(pattern, checkItem) // note: getEnumerationPreFilter below encodes assumptions about the valid wildcards used here
+		matched, err = matchNamePattern(pattern, checkItem, f.ignoreCase) // note: getEnumerationPreFilter below encodes assumptions about the valid wildcards used here
 
 		// if the pattern failed to match with an error, then we assume the pattern is invalid
 		// and ignore it
@@ -167,6 +198,10 @@ func (f *IncludeFilter) DoesPass(storedObject StoredObject) bool {
 // "foo*bar", then this routine will return "foo", since only things starting with "foo" can pass the filters.
 // Service side enumeration code can be given that prefix, to optimize the enumeration.
 func (f *IncludeFilter) getEnumerationPreFilter() string {
+	if f.ignoreCase {
+		// service-side prefixes are case-sensitive, so they can't be used to pre-select case-insensitive matches
+		return ""
+	}
 	if len(f.patterns) == 1 {
 		pat := f.patterns[0]
 		if strings.ContainsAny(pat, "?[\\") {
@@ -180,7 +215,7 @@ func (f *IncludeFilter) getEnumerationPreFilter() string {
 	}
 }
 
-func buildIncludeFilters(Patterns []string) []ObjectFilter {
+func buildIncludeFilters(Patterns []string, ignoreCase bool) []ObjectFilter {
 	if len(Patterns) == 0 {
 		return []ObjectFilter{}
 	}
@@ -192,7 +227,16 @@ func buildIncludeFilters(Patterns []string) []ObjectFilter {
 		}
 	}
 
-	return []ObjectFilter{&IncludeFilter{patterns: validPatterns}}
+	return []ObjectFilter{&IncludeFilter{patterns: validPatterns, ignoreCase: ignoreCase}}
+}
+
+// matchNamePattern matches a name against an include/exclude pattern, optionally ignoring case.
+// When ignoring case, both sides are lower-cased, which also makes character ranges such as [A-Z] case-insensitive.
+func matchNamePattern(pattern string, name string, ignoreCase bool) (bool, error) {
+	if ignoreCase {
+		return path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	}
+	return path.Match(pattern, name)
 }
 
 type FilterSet []ObjectFilter
@@ -233,8 +277,11 @@ func (fs FilterSet) GetEnumerationPreFilter(recursive bool) string {
 ////////
 
 // includeRegex & excludeRegex
+// The regular expressions are matched against the whole relative path of the object, not just against its name.
+// They are implicitly anchored at both ends, so an expression must match all of the relative path, not just part of it.
+// Use .* to match part of a path, e.g. .*\.pdf for every pdf file.
 type regexFilter struct {
-	patterns   []string
+	patterns   []*regexp.Regexp
 	isIncluded bool
 }
 
@@ -252,43 +299,401 @@ func (f *regexFilter) DoesPass(storedObject StoredObject) bool {
 	if len(f.patterns) == 0 {
 		return true
 	}
-	for _, pattern := range f.patterns {
-		matched := false
-		var err error
 
-		matched, err = regexp.MatchString(pattern, storedObject.relativePath)
-		// if pattern fails to match with an error, we assume the pattern is invalid
-		if err != nil {
-			if f.isIncluded { //if include filter then we ignore it
-				continue
-			} else { //if exclude filter then we let it pass
-				return true
-			}
-		}
+	for _, pattern := range f.patterns {
 		//check if pattern matched relative path
 		//if matched then return isIncluded which is a boolean expression to represent included and excluded
-		if matched {
+		if pattern.MatchString(storedObject.relativePath) {
 			return f.isIncluded
 		}
 	}
 	return !f.isIncluded
 }
 
+// compileAnchoredRegex compiles the expression so that it only matches a whole relative path.
+// The expression is grouped first, so that alternatives such as a|b are anchored as a whole.
+func compileAnchoredRegex(pattern string) (*regexp.Regexp, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		// report the error against the expression the user gave, not the anchored one
+		return nil, err
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// validateRegexPatterns makes sure that all the supplied regular expressions compile, so that a bad expression
+// is reported to the user before enumeration begins, rather than being silently ignored.
+func validateRegexPatterns(patterns []string, flagName string) error {
+	for _, pattern := range patterns {
+		if _, err := compileAnchoredRegex(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression '%s' supplied to %s: %w", pattern, flagName, err)
+		}
+	}
+	return nil
+}
+
+// maxBraceDepth is how deeply braces may be nested in an include or exclude pattern
+const maxBraceDepth = 8
+
+// bracePatternHelp is added to the help of include-pattern and exclude-pattern. Braces used to have no special meaning
+// in them, so it also says how to match a brace in a name
+const bracePatternHelp = " Braces give alternatives, so file{1,2}.txt matches file1.txt and file2.txt. " +
+	"To match a brace or comma in a name, put a backslash before it (e.g. report\\{draft\\}.txt), or put a brace in square brackets ([{])."
+
+// braceEscapeHint is added to the errors about unmatched braces, since they most likely come from names with braces in
+const braceEscapeHint = ". To match a brace in a name, put a backslash before it"
+
+// expandBracePatterns expands the brace alternatives in each of the patterns supplied to flagName, so that
+// file{1,2}.txt becomes file1.txt and file2.txt. This happens before any matching, and the expanded patterns are
+// matched as usual, so it does not change what '*', '?' and '[...]' mean
+func expandBracePatterns(patterns []string, flagName string) ([]string, error) {
+	expanded := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		alternatives, err := expandBraces(pattern, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s' supplied to %s: %w", pattern, flagName, err)
+		}
+		expanded = append(expanded, alternatives...)
+	}
+	return expanded, nil
+}
+
+// expandBraces expands the first brace group in pattern, and then the rest of it. Like in bash, a brace group with no
+// comma, such as {abc}, is left as it is. Braces are literal inside [...], or when escaped with a backslash.
+// offset is where pattern starts in the whole pattern, so that errors can point to the right place
+func expandBraces(pattern string, offset int, depth int) ([]string, error) {
+	if depth > maxBraceDepth {
+		return nil, fmt.Errorf("braces are nested more than %d deep", maxBraceDepth)
+	}
+
+	opening := -1
+	inClass := false
+	for i := 0; i < len(pattern) && opening < 0; i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++ // skip what is escaped
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '{':
+			opening = i
+		case c == '}':
+			return nil, fmt.Errorf("unmatched '}' at position %d"+braceEscapeHint, offset+i+1)
+		}
+	}
+	if opening < 0 {
+		return []string{pattern}, nil
+	}
+
+	// find the matching closing brace, and the commas that separate the alternatives in between
+	closing := -1
+	commas := make([]int, 0)
+	nesting := 0
+	inClass = false
+	for i := opening + 1; i < len(pattern) && closing < 0; i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '{':
+			nesting++
+		case c == '}' && nesting > 0:
+			nesting--
+		case c == '}':
+			closing = i
+		case c == ',' && nesting == 0:
+			commas = append(commas, i)
+		}
+	}
+	if closing < 0 {
+		return nil, fmt.Errorf("unmatched '{' at position %d"+braceEscapeHint, offset+opening+1)
+	}
+
+	prefix, inner := pattern[:opening], pattern[opening+1:closing]
+	var alternatives []string
+	if len(commas) == 0 {
+		// not a brace group, just braces. Whatever is inside them may still have groups to expand
+		innerAlternatives, err := expandBraces(inner, offset+opening+1, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range innerAlternatives {
+			alternatives = append(alternatives, "{"+a+"}")
+		}
+	} else {
+		start := opening + 1
+		for _, end := range append(commas, closing) {
+			partAlternatives, err := expandBraces(pattern[start:end], offset+start, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, partAlternatives...)
+			start = end + 1
+		}
+	}
+
+	suffixes, err := expandBraces(pattern[closing+1:], offset+closing+1, depth)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(alternatives)*len(suffixes))
+	for _, a := range alternatives {
+		for _, suffix := range suffixes {
+			result = append(result, prefix+a+suffix)
+		}
+	}
+	return result, nil
+}
+
+// fileAttributeLetters are the Windows file attributes that include-attributes and exclude-attributes understand:
+// Read-only, Archive, System, Hidden, Compressed, Normal, Encrypted, Temporary, Offline and non-Indexed
+const fileAttributeLetters = "RASHCNETOI"
+
+// parseFileAttributes reads the attribute list supplied to include-attributes or exclude-attributes. Attributes may
+// be separated by ';' (A;S;R), or run together as a set of letters (ASR). An unknown letter is an error, rather than
+// something that silently matches nothing. Attributes can only be read from the local file system on Windows, so on
+// any other OS a list that is not empty is an error too.
+func parseFileAttributes(raw string, flagName string) ([]string, error) {
+	attributes := make([]string, 0)
+	for _, set := range strings.Split(raw, ";") {
+		for _, letter := range strings.ToUpper(strings.TrimSpace(set)) {
+			if !strings.ContainsRune(fileAttributeLetters, letter) {
+				return nil, fmt.Errorf("unknown file attribute '%c' supplied to %s. The attributes are: %s",
+					letter, flagName, strings.Join(strings.Split(fileAttributeLetters, ""), ", "))
+			}
+			attributes = append(attributes, string(letter))
+		}
+	}
+
+	if len(attributes) > 0 {
+		if msg, supported := (&attrFilter{}).DoesSupportThisOS(); !supported {
+			return nil, errors.New(msg)
+		}
+	}
+	return attributes, nil
+}
+
 func buildRegexFilters(patterns []string, isIncluded bool) []ObjectFilter {
 	if len(patterns) == 0 {
 		return []ObjectFilter{}
 	}
 
-	filters := make([]string, 0)
+	filters := make([]*regexp.Regexp, 0)
 	for _, pattern := range patterns {
 		if pattern != "" {
-			filters = append(filters, pattern)
+			// patterns have already been checked by validateRegexPatterns, so any that still fail to compile are ignored
+			if compiled, err := compileAnchoredRegex(pattern); err == nil {
+				filters = append(filters, compiled)
+			}
 		}
 	}
 
 	return []ObjectFilter{&regexFilter{patterns: filters, isIncluded: isIncluded}}
 }
 
+// anyOfFilter passes an object if any of its filters passes it.
+// It is used to combine include filters of different kinds (e.g. include-pattern and include-regex). Just like the
+// patterns inside a single IncludeFilter, alternative include filters work in the "OR" manner.
+type anyOfFilter struct {
+	filters []ObjectFilter
+}
+
+func (f *anyOfFilter) DoesSupportThisOS() (msg string, supported bool) {
+	for _, filter := range f.filters {
+		if msg, supported = filter.DoesSupportThisOS(); !supported {
+			return
+		}
+	}
+	return "", true
+}
+
+func (f *anyOfFilter) AppliesOnlyToFiles() bool {
+	// if any of the alternatives only knows how to deal with files, then we can't treat folders consistently, so don't try
+	for _, filter := range f.filters {
+		if filter.AppliesOnlyToFiles() {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *anyOfFilter) DoesPass(storedObject StoredObject) bool {
+	for _, filter := range f.filters {
+		if filter.DoesPass(storedObject) {
+			return true
+		}
+	}
+	return false
+}
+
+// combineIncludeFilters ORs together the include filters built from include-pattern, include-ext and include-regex.
+// If only one kind is in use, its filters are returned as-is.
+func combineIncludeFilters(filterGroups ...[]ObjectFilter) []ObjectFilter {
+	combined := make([]ObjectFilter, 0)
+	kindsInUse := 0
+	for _, group := range filterGroups {
+		if len(group) != 0 {
+			combined = append(combined, group...)
+			kindsInUse++
+		}
+	}
+
+	if kindsInUse <= 1 {
+		return combined
+	}
+	return []ObjectFilter{&anyOfFilter{filters: combined}}
+}
+
+// parseExtensions reads the extension list supplied to include-ext or exclude-ext (e.g. jpg;.png;gif), and returns
+// the name pattern for each extension (*.jpg). The leading dot is optional. Since these are shorthand for the simplest
+// patterns, an extension that contains wildcards or path separators is an error.
+func parseExtensions(raw string, flagName string) ([]string, error) {
+	patterns := make([]string, 0)
+	for _, ext := range strings.Split(raw, ";") {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+
+		bare := strings.TrimPrefix(ext, ".")
+		if bare == "" || strings.ContainsAny(bare, "*?[]{}/\\") {
+			return nil, fmt.Errorf("invalid extension '%s' supplied to %s. Give the bare extensions, separated by ';' (For example: jpg;png;gif)", ext, flagName)
+		}
+		patterns = append(patterns, "*."+bare)
+	}
+	return patterns, nil
+}
+
+// buildExtensionFilters builds the filters for include-ext or exclude-ext. Extensions often vary in case (.jpg and
+// .JPG), so they are always matched case-insensitively, whether or not ignore-case-pattern is set
+func buildExtensionFilters(patterns []string, isIncluded bool) []ObjectFilter {
+	if isIncluded {
+		return buildIncludeFilters(patterns, true)
+	}
+	return buildExcludeFilters(patterns, false, true)
+}
+
+// metadataCondition is one key=value pair from include-metadata or exclude-metadata. The value may contain the same
+// wildcards as include-pattern
+type metadataCondition struct {
+	key          string
+	valuePattern string
+}
+
+// metadataFilter includes (or excludes) the files that have any of the given metadata. Keys are matched
+// case-insensitively, as the service treats them, and values case-sensitively.
+// Folders are passed through, since in Blob Storage they are either virtual, or stubs whose metadata only says that
+// they are folders.
+// Blob listings return metadata, so it costs nothing extra to filter on it. Listings of Azure Files shares don't,
+// so there the traverser must get the properties of each file, which is one extra request per file.
+type metadataFilter struct {
+	conditions []metadataCondition
+	isIncluded bool
+}
+
+func (f *metadataFilter) DoesSupportThisOS() (msg string, supported bool) {
+	return "", true
+}
+
+func (f *metadataFilter) AppliesOnlyToFiles() bool {
+	return true
+}
+
+func (f *metadataFilter) DoesPass(storedObject StoredObject) bool {
+	for key, value := range storedObject.Metadata {
+		for _, condition := range f.conditions {
+			if !strings.EqualFold(key, condition.key) {
+				continue
+			}
+			if matched, _ := path.Match(condition.valuePattern, value); matched {
+				return f.isIncluded
+			}
+		}
+	}
+	return !f.isIncluded
+}
+
+// parseMetadataConditions reads the key=value pairs supplied to include-metadata or exclude-metadata, separated by ';'
+func parseMetadataConditions(raw string, flagName string) ([]metadataCondition, error) {
+	conditions := make([]metadataCondition, 0)
+	for _, pair := range strings.Split(raw, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid metadata condition '%s' supplied to %s. Each condition must be key=value", pair, flagName)
+		}
+		if _, err := path.Match(kv[1], ""); err != nil {
+			return nil, fmt.Errorf("invalid value pattern in metadata condition '%s' supplied to %s: %w", pair, flagName, err)
+		}
+		conditions = append(conditions, metadataCondition{key: key, valuePattern: kv[1]})
+	}
+	return conditions, nil
+}
+
+func buildMetadataFilters(conditions []metadataCondition, isIncluded bool) []ObjectFilter {
+	if len(conditions) == 0 {
+		return []ObjectFilter{}
+	}
+	return []ObjectFilter{&metadataFilter{conditions: conditions, isIncluded: isIncluded}}
+}
+
+// contentTypeFilter includes (or excludes) the files whose Content-Type matches any of the given patterns, which may
+// be exact (image/png) or contain the same wildcards as include-pattern (image/*). Media types are case-insensitive,
+// and any parameters, such as a charset, are not matched.
+// Like metadataFilter, it passes folders through, costs nothing extra with Blob listings, which return the content
+// type, and needs the properties of each file of an Azure Files share
+type contentTypeFilter struct {
+	patterns   []string
+	isIncluded bool
+}
+
+func (f *contentTypeFilter) DoesSupportThisOS() (msg string, supported bool) {
+	return "", true
+}
+
+func (f *contentTypeFilter) AppliesOnlyToFiles() bool {
+	return true
+}
+
+func (f *contentTypeFilter) DoesPass(storedObject StoredObject) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(storedObject.contentType, ";", 2)[0]))
+	for _, pattern := range f.patterns {
+		if matched, _ := path.Match(pattern, mediaType); matched {
+			return f.isIncluded
+		}
+	}
+	return !f.isIncluded
+}
+
+// parseContentTypePatterns reads the content types supplied to include-content-type or exclude-content-type, separated by ';'
+func parseContentTypePatterns(raw string, flagName string) ([]string, error) {
+	patterns := make([]string, 0)
+	for _, pattern := range strings.Split(raw, ";") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid content type pattern '%s' supplied to %s: %w", pattern, flagName, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func buildContentTypeFilters(patterns []string, isIncluded bool) []ObjectFilter {
+	if len(patterns) == 0 {
+		return []ObjectFilter{}
+	}
+	return []ObjectFilter{&contentTypeFilter{patterns: patterns, isIncluded: isIncluded}}
+}
+
 // includeAfterDateFilter includes files with Last Modified Times >= the specified threshold
 // Used for copy, but doesn't make conceptual sense for sync
 type IncludeAfterDateFilter struct {
@@ -319,6 +724,11 @@ func (_ IncludeAfterDateFilter) ParseISO8601(s string, chooseEarliest bool) (tim
 	return parseISO8601(s, chooseEarliest)
 }
 
+// ParseTime accepts either an ISO 8601 date/time, or a time relative to now (e.g. -7d)
+func (_ IncludeAfterDateFilter) ParseTime(s string, chooseEarliest bool, now time.Time) (time.Time, error) {
+	return parseFilterTime(s, chooseEarliest, now)
+}
+
 func (_ IncludeAfterDateFilter) FormatAsUTC(t time.Time) string {
 	return formatAsUTC(t)
 }
@@ -353,10 +763,104 @@ func (_ IncludeBeforeDateFilter) ParseISO8601(s string, chooseEarliest bool) (ti
 	return parseISO8601(s, chooseEarliest)
 }
 
+// ParseTime accepts either an ISO 8601 date/time, or a time relative to now (e.g. -7d)
+func (_ IncludeBeforeDateFilter) ParseTime(s string, chooseEarliest bool, now time.Time) (time.Time, error) {
+	return parseFilterTime(s, chooseEarliest, now)
+}
+
 func (_ IncludeBeforeDateFilter) FormatAsUTC(t time.Time) string {
 	return formatAsUTC(t)
 }
 
+// SizeFilter includes files whose content length falls within the range [MinSizeBytes, MaxSizeBytes]
+// Either bound may be nil, in which case the range is open on that side. Folders are always passed.
+// Used for copy, but not for sync, because in sync the filters are applied to the destination too, and a destination
+// file that is out of range (e.g. because it is an older, smaller, version of the source) would look like it was missing
+type SizeFilter struct {
+	MinSizeBytes *int64
+	MaxSizeBytes *int64
+}
+
+func (f *SizeFilter) DoesSupportThisOS() (msg string, supported bool) {
+	msg = ""
+	supported = true
+	return
+}
+
+func (f *SizeFilter) AppliesOnlyToFiles() bool {
+	return false // folders have no meaningful size, so we just pass them all through, which leaves folder transfers unaffected
+}
+
+func (f *SizeFilter) DoesPass(storedObject StoredObject) bool {
+	if storedObject.entityType == common.EEntityType.Folder() {
+		return true
+	}
+
+	if f.MinSizeBytes != nil && storedObject.size < *f.MinSizeBytes {
+		return false
+	}
+	if f.MaxSizeBytes != nil && storedObject.size > *f.MaxSizeBytes {
+		return false
+	}
+	return true
+}
+
+// DepthFilter includes the files and folders whose depth beneath the source root falls within [MinDepth, MaxDepth].
+// A file directly in the root (or the source itself, when it is a single file) is at depth 1, a file in a folder of the root is
+// at depth 2, and so on. Folders are filtered by their own depth, like files, so folder properties are only transferred
+// for the folders in range. The root folder is at depth 0, so it is excluded by any MinDepth. A bound of 0 leaves that side open.
+type DepthFilter struct {
+	MinDepth uint
+	MaxDepth uint
+}
+
+func (f *DepthFilter) DoesSupportThisOS() (msg string, supported bool) {
+	msg = ""
+	supported = true
+	return
+}
+
+func (f *DepthFilter) AppliesOnlyToFiles() bool {
+	return false // folders have a depth too
+}
+
+func (f *DepthFilter) DoesPass(storedObject StoredObject) bool {
+	depth := f.depthOf(storedObject)
+	if depth < f.MinDepth {
+		return false
+	}
+	if f.MaxDepth != 0 && depth > f.MaxDepth {
+		return false
+	}
+	return true
+}
+
+func (f *DepthFilter) depthOf(storedObject StoredObject) uint {
+	relativePath := strings.Trim(storedObject.relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+	if relativePath == "" {
+		if storedObject.entityType == common.EEntityType.Folder() {
+			return 0 // the root folder
+		}
+		return 1 // a single file source
+	}
+	return uint(strings.Count(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)) + 1
+}
+
+// ParseSize accepts either a plain number of bytes (e.g. 1500), or a number followed by K, M or G (e.g. 12k or 200G)
+func (_ SizeFilter) ParseSize(s string, flagName string) (int64, error) {
+	bytes, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		bytes, err = ParseSizeString(s, flagName)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if bytes < 0 {
+		return 0, fmt.Errorf("%s must not be negative", flagName)
+	}
+	return bytes, nil
+}
+
 type permDeleteFilter struct {
 	deleteSnapshots bool
 	deleteVersions  bool
@@ -446,7 +950,79 @@ func parseISO8601(s string, chooseEarliest bool) (time.Time, error) {
 	return time.Time{}, err
 }
 
+// parseFilterTime parses the value of include-after or include-before. A value that starts with a sign is relative to now,
+// in whole days, hours or minutes (e.g. -7d, -24h or -30m). Anything else is an ISO 8601 date/time, so absolute times, which
+// always start with the digits of their year, are parsed exactly as they were before relative times were supported.
+func parseFilterTime(s string, chooseEarliest bool, now time.Time) (time.Time, error) {
+	if !strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "+") {
+		return parseISO8601(s, chooseEarliest)
+	}
+
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour, // a day is always 24 hours here, even across a change of daylight saving
+		'h': time.Hour,
+		'm': time.Minute,
+	}
+	unit, ok := units[s[len(s)-1]]
+	count, err := strconv.ParseInt(s[:len(s)-1], 10, 32)
+	if !ok || err != nil {
+		return time.Time{}, fmt.Errorf("could not parse relative time '%s'. Expecting a sign, a whole number and a unit of d (days), h (hours) or m (minutes), e.g. -7d", s)
+	}
+
+	return now.Add(time.Duration(count) * unit), nil
+}
+
 // formatAsUTC is inverse of parseISO8601 (and always uses the most detailed format)
 func formatAsUTC(t time.Time) string {
 	return t.UTC().Format(time.RFC3339)
 }
+
+// filterFileHelp is added to the help of each filter flag whose entries can be read from a file
+const filterFileHelp = " An entry of @file reads the entries in that file, one per line. Blank lines, and lines starting with #, are ignored." +
+	" For an entry that itself starts with @, such as @eaDir, double the @: @@eaDir."
+
+// expandFilterFiles replaces each @file entry of a filter flag, such as include-pattern, with the entries in that
+// file, so that long lists can be kept in a file and reused. The file has one entry per line, and blank lines, and
+// lines starting with #, are ignored. Any other entries given in the flag are kept, in their places. An entry starting
+// with @@ is kept too, with one @ taken off, for names that start with @
+func expandFilterFiles(value string, flagName string) (string, error) {
+	if !strings.Contains(value, "@") {
+		return value, nil
+	}
+
+	entries := make([]string, 0)
+	for _, entry := range strings.Split(value, ";") {
+		if !strings.HasPrefix(entry, "@") {
+			entries = append(entries, entry)
+			continue
+		}
+		if strings.HasPrefix(entry, "@@") {
+			entries = append(entries, entry[1:])
+			continue
+		}
+
+		filePath := entry[1:]
+		raw, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("could not read the %s file %s: %w. If %s is a name that starts with @ rather than a file, write it as @%s",
+				flagName, filePath, err, entry, entry)
+		}
+		found := 0
+		for _, line := range strings.Split(strings.TrimPrefix(string(raw), "\uFEFF"), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if strings.Contains(line, ";") {
+				return "", fmt.Errorf("the %s file %s has an entry with a ';' in it: %s. Put each entry on a line of its own", flagName, filePath, line)
+			}
+			entries = append(entries, line)
+			found++
+		}
+		// an include filter with nothing in it would include everything, which is unlikely to be what was meant
+		if found == 0 {
+			return "", fmt.Errorf("the %s file %s has no entries", flagName, filePath)
+		}
+	}
+	return strings.Join(entries, ";"), nil
+}
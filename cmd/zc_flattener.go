@@ -0,0 +1,187 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// flattener is for --flatten. It holds back the files that a copy finds until the enumeration is done, since only then
+// is it known which of them have the same name, and then passes each one on with the name that it has at the
+// destination, directly under the destination root. Folders are left out, apart from the root folder, since there are
+// none at the destination.
+//
+// Files with the same name are taken in the order of their paths at the source, so that the result is the same on
+// every run, whatever order they were listed in. To rename them, the first keeps the name, and each of the others is
+// given the lowest numeric suffix from 2 up (as in "report-2.txt") that no other file has or was given
+type flattener struct {
+	policy          common.FlattenCollisionPolicy
+	caseInsensitive bool
+	mu              sync.Mutex
+	files           []StoredObject // in the order they were listed
+}
+
+func newFlattener(policy common.FlattenCollisionPolicy, fromTo common.FromTo) *flattener {
+	return &flattener{policy: policy, caseInsensitive: IsDestinationCaseInsensitive(fromTo)}
+}
+
+// wrap returns processor and finalizer such that processor sees each file with its flattened name. A single file, and
+// the root folder, have no relative path, so they go straight through
+func (f *flattener) wrap(processor objectProcessor, finalizer func() error) (objectProcessor, func() error) {
+	hold := func(object StoredObject) error {
+		if object.relativePath == "" {
+			return processor(object)
+		}
+		if object.entityType == common.EEntityType.Folder() {
+			return nil
+		}
+		f.mu.Lock()
+		f.files = append(f.files, object)
+		f.mu.Unlock()
+		return nil
+	}
+
+	release := func() error {
+		if err := f.assignNames(); err != nil {
+			return err
+		}
+		for _, object := range f.files {
+			if object.flattenedName == "" {
+				continue // overwritten by another file with the same name
+			}
+			if err := processor(object); err != nil {
+				return err
+			}
+		}
+		return finalizer()
+	}
+	return hold, release
+}
+
+// assignNames sets the flattenedName of each file, or leaves it empty for a file that isn't to be copied
+func (f *flattener) assignNames() error {
+	byKey := make(map[string][]int)
+	for i, object := range f.files {
+		key := f.key(object, path.Base(flattenerSourcePath(object)))
+		byKey[key] = append(byKey[key], i)
+	}
+	keys := make([]string, 0, len(byKey))
+	taken := make(map[string]bool, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+		taken[key] = true
+	}
+	sort.Strings(keys)
+
+	overwritten := 0
+	for _, key := range keys {
+		group := byKey[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			return flattenerSourcePath(f.files[group[i]]) < flattenerSourcePath(f.files[group[j]])
+		})
+		name := path.Base(flattenerSourcePath(f.files[group[0]]))
+		if len(group) == 1 {
+			f.files[group[0]].flattenedName = name
+			continue
+		}
+
+		switch f.policy {
+		case common.EFlattenCollisionPolicy.Rename():
+			f.files[group[0]].flattenedName = name
+			for _, i := range group[1:] {
+				f.files[i].flattenedName = f.rename(f.files[i], taken)
+			}
+		case common.EFlattenCollisionPolicy.Overwrite():
+			newest := group[0]
+			for _, i := range group[1:] {
+				// on a tie, the later path wins, as it would have been copied last
+				if !f.files[newest].isMoreRecentThan(f.files[i]) {
+					newest = i
+				}
+			}
+			f.files[newest].flattenedName = name
+			for _, i := range group {
+				if i != newest {
+					overwritten++
+					if azcopyScanningLogger != nil {
+						azcopyScanningLogger.Log(pipeline.LogInfo, fmt.Sprintf("Not copying %s, because %s has the same name and was modified more recently",
+							flattenerSourcePath(f.files[i]), flattenerSourcePath(f.files[newest])))
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("cannot flatten the copy, because %s and %s would both be copied to %s. "+
+				"Use --flatten-collisions=rename or --flatten-collisions=overwrite to copy files that have the same name",
+				flattenerSourcePath(f.files[group[0]]), flattenerSourcePath(f.files[group[1]]), name)
+		}
+	}
+
+	if overwritten > 0 {
+		WarnStdoutAndScanningLog(fmt.Sprintf("Not copying %d files, because a more recently modified file with the same name is copied instead.", overwritten))
+	}
+	return nil
+}
+
+// rename returns the first name, with a suffix, that is not yet taken, and takes it
+func (f *flattener) rename(object StoredObject, taken map[string]bool) string {
+	name := path.Base(flattenerSourcePath(object))
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" { // such as ".profile", whose name is all extension
+		stem, ext = name, ""
+	}
+	for n := 2; ; n++ {
+		candidate := stem + "-" + strconv.Itoa(n) + ext
+		if key := f.key(object, candidate); !taken[key] {
+			taken[key] = true
+			return candidate
+		}
+	}
+}
+
+func (f *flattener) key(object StoredObject, name string) string {
+	key := object.ContainerName + common.AZCOPY_PATH_SEPARATOR_STRING + name
+	if object.blobVersionID != "" {
+		key += "?versionid=" + object.blobVersionID // each version of a blob is given a name of its own at the destination
+	}
+	if f.caseInsensitive {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+// flattenerSourcePath is the path that the file would have at the destination, were it not flattened
+func flattenerSourcePath(object StoredObject) string {
+	p := object.relativePath
+	if object.sourceRoot != nil {
+		p = object.keptPath
+	}
+	return strings.ReplaceAll(p, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING)
+}
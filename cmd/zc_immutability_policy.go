@@ -0,0 +1,73 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// checkDestinationSupportsImmutability makes sure, before anything is transferred, that the destination container
+// can hold the immutability policies and legal holds of --immutability-policy-until and --legal-hold. Those need
+// version-level immutability support, and without it every write would fail with an error that doesn't say why
+func (cca *CookedCopyCmdArgs) checkDestinationSupportsImmutability(ctx context.Context, dstLevel LocationLevel) error {
+	if dstLevel == ELocationLevel.Service() {
+		return errors.New("immutability-policy-until and legal-hold cannot be used when the destination is a whole account, since the containers AzCopy creates don't have version-level immutability support. " +
+			"Give a container that has it instead")
+	}
+
+	enabled, known := versionLevelImmutabilityEnabled(ctx, cca.Destination, cca.credentialInfo)
+	if !known {
+		glcm.Info("Could not check whether the destination container has version-level immutability support. " +
+			"If it doesn't, each blob will fail to be written, since it can't be given an immutability policy or legal hold.")
+	} else if !enabled {
+		return fmt.Errorf("immutability-policy-until and legal-hold need a destination container with version-level immutability support, and %s doesn't have it. "+
+			"A container-level immutability policy isn't enough, since it covers every blob in the container the same way. Enable version-level immutability support for the container, or copy to one that has it",
+			strings.Split(cca.Destination.Value, "?")[0])
+	}
+	return nil
+}
+
+// versionLevelImmutabilityEnabled gets whether the container of a Blob resource has version-level immutability
+// support enabled. known is false if the container's properties can't be read
+func versionLevelImmutabilityEnabled(ctx context.Context, resource common.ResourceString, credInfo common.CredentialInfo) (enabled, known bool) {
+	resourceURL, err := resource.FullURL()
+	if err != nil {
+		return false, false
+	}
+	p, err := InitPipeline(ctx, common.ELocation.Blob(), credInfo, azcopyLogVerbosity.ToPipelineLogLevel())
+	if err != nil {
+		return false, false
+	}
+
+	parts := azblob.NewBlobURLParts(*resourceURL)
+	parts.BlobName, parts.Snapshot, parts.VersionID = "", "", ""
+	props, err := azblob.NewContainerURL(parts.URL(), p).GetProperties(ctx, azblob.LeaseAccessConditions{})
+	if err != nil {
+		return false, false
+	}
+	return strings.EqualFold(props.IsImmutableStorageWithVersioningEnabled(), "true"), true
+}
@@ -0,0 +1,96 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TransferDescriptor describes one transfer that a copy would schedule
+type TransferDescriptor struct {
+	// RelativePath is the path of the file or folder relative to the source, using '/' as the separator.
+	// It is escaped in the same way as the rest of the source, if that is a URL, and is empty when the source is the file itself.
+	RelativePath string
+	// Destination is the full path, or URL without SAS, that the file or folder would be written to
+	Destination      string
+	EntityType       common.EntityType
+	Size             int64
+	LastModifiedTime time.Time
+}
+
+// ListTransfers enumerates the transfers that the copy command would schedule for args, without scheduling them.
+// args are those of the copy command: the source, the destination, and any of its flags (but not the global ones, such as
+// --output-type). Flags that are not given have the same defaults as in the copy command.
+// handle is called for each transfer as the source is enumerated, so the listing is never held in memory as a whole.
+// If handle returns an error, enumeration stops and ListTransfers returns that error.
+// Nothing is written to the destination, and nothing is changed for the whole process, so a program that embeds
+// AzCopy can list transfers while a copy is running. For that reason, the flags that do change the whole process,
+// s3-endpoint and backup, can't be used.
+func ListTransfers(args []string, handle func(TransferDescriptor) error) error {
+	// flags and args of its own, so that a copy, or another listing, running at the same time keeps its own
+	raw := rawCopyCmdArgs{}
+	var flushThreshold uint32 // only used by the engine
+	flags := pflag.NewFlagSet("copy", pflag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard) // the error is returned rather than printed with the usage
+	raw.addFlags(flags, &flushThreshold)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("wrong number of arguments, a source and a destination are required")
+	}
+	raw.src = flags.Arg(0)
+	raw.dst = flags.Arg(1)
+	raw.stripTopDirSet = flags.Changed("strip-top-dir")
+
+	if raw.s3Endpoint != "" || raw.s3ForcePathStyle || raw.s3SkipSSLVerify {
+		return errors.New("s3-endpoint cannot be used to list transfers, since it sets the S3 endpoint for the whole process")
+	}
+	if raw.backupMode {
+		return errors.New("backup cannot be used to list transfers, since it changes the privileges of the whole process")
+	}
+
+	cooked, err := raw.cookArgs()
+	if err != nil {
+		return fmt.Errorf("failed to parse user input due to error: %w", err)
+	}
+	if cooked.isRedirection() || !cooked.FromTo.To().IsLocal() && !cooked.FromTo.To().IsRemote() {
+		return fmt.Errorf("cannot list the transfers of %s, since it does not copy files", cooked.FromTo)
+	}
+
+	// return the error of handle as it is, rather than as a failure to start the job
+	var handleErr error
+	cooked.listTransfer = func(transfer TransferDescriptor) error {
+		handleErr = handle(transfer)
+		return handleErr
+	}
+	err = cooked.processCopyJobPartOrders()
+	if handleErr != nil {
+		return handleErr
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// newestVersionsKeeper is for max-versions-per-blob. It holds back the versions that list-versions finds until the
+// enumeration is done, since only then is it known which versions of each blob are the newest, and then passes on
+// just the newest few of each blob.
+//
+// Versions are ordered by the time in their version id, which is when they were created, and not by their last
+// modified time, which e.g. setting the tier of an older version can change. Two versions of one blob never have
+// the same id, but should two have the same time, the greater id counts as newer, so that the choice is the same on
+// every run. The current version is a version like the others, and the newest one, so it is always kept. A blob
+// whose current version has no version id (because it was written before versioning was turned on) has that as its
+// newest version.
+type newestVersionsKeeper struct {
+	max      uint
+	mu       sync.Mutex
+	versions map[string][]StoredObject // the versions of each blob, by its container and path, in the order they were listed
+}
+
+func newNewestVersionsKeeper(max uint) *newestVersionsKeeper {
+	return &newestVersionsKeeper{max: max, versions: make(map[string][]StoredObject)}
+}
+
+// wrap returns processor and finalizer such that processor only sees the newest versions of each blob. Anything that
+// isn't a version of a blob, such as a folder, goes straight through
+func (k *newestVersionsKeeper) wrap(processor objectProcessor, finalizer func() error) (objectProcessor, func() error) {
+	hold := func(object StoredObject) error {
+		if object.entityType != common.EEntityType.File() {
+			return processor(object)
+		}
+		key := object.ContainerName + "/" + object.relativePath
+		k.mu.Lock()
+		k.versions[key] = append(k.versions[key], object)
+		k.mu.Unlock()
+		return nil
+	}
+
+	release := func() error {
+		keys := make([]string, 0, len(k.versions))
+		for key := range k.versions {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // in name order, like a listing
+
+		for _, key := range keys {
+			for _, object := range k.newest(k.versions[key]) {
+				if err := processor(object); err != nil {
+					return err
+				}
+			}
+		}
+		return finalizer()
+	}
+	return hold, release
+}
+
+// newest returns the newest max of versions, still in the order they were listed
+func (k *newestVersionsKeeper) newest(versions []StoredObject) []StoredObject {
+	if uint(len(versions)) <= k.max {
+		return versions
+	}
+
+	byAge := make([]int, len(versions))
+	for i := range byAge {
+		byAge[i] = i
+	}
+	sort.SliceStable(byAge, func(i, j int) bool {
+		return versionIsNewer(versions[byAge[i]].blobVersionID, versions[byAge[j]].blobVersionID)
+	})
+
+	keep := make(map[int]bool, k.max)
+	for _, i := range byAge[:k.max] {
+		keep[i] = true
+	}
+	kept := make([]StoredObject, 0, k.max)
+	for i, object := range versions {
+		if keep[i] {
+			kept = append(kept, object)
+		} else if azcopyScanningLogger != nil {
+			azcopyScanningLogger.Log(pipeline.LogInfo, fmt.Sprintf("Not copying version %s of %s, because it is older than the newest %d", object.blobVersionID, object.relativePath, k.max))
+		}
+	}
+	return kept
+}
+
+// versionIsNewer says whether the version with id a was created after the one with id b. The id "" is the current
+// version of a blob that has no version id, which is newer than any other
+func versionIsNewer(a, b string) bool {
+	if a == "" || b == "" {
+		return a == "" && b != ""
+	}
+	timeA, errA := time.Parse(time.RFC3339Nano, a)
+	timeB, errB := time.Parse(time.RFC3339Nano, b)
+	if errA == nil && errB == nil && !timeA.Equal(timeB) {
+		return timeA.After(timeB)
+	}
+	return a > b
+}
@@ -0,0 +1,223 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// ownerLookup returns the owner of the path that object stands for: by object id or, if upn, by user principal name
+type ownerLookup func(object StoredObject, upn bool) (string, error)
+
+// ownerFilter is for include-owner and exclude-owner, which pick the files and folders of an ADLS Gen 2 source by who
+// owns them. Listings don't say who owns what, so the owner of each path costs a request of its own (a HEAD, to get
+// its access control). Those are made concurrency at a time, in parallel with the listing, and what passes goes on to
+// the processor once its owner is known. So the processor sees the paths in no particular order.
+type ownerFilter struct {
+	include     []string // object ids or user principal names, compared ignoring case
+	exclude     []string
+	lookup      ownerLookup
+	concurrency int
+}
+
+// wrap returns processor and finalizer such that processor only sees what passes the filter. It also returns abandon,
+// which is for when the enumeration fails, so that finalizer is never called: it stops the lookups, without passing
+// anything more to processor
+func (f *ownerFilter) wrap(processor objectProcessor, finalizer func() error) (objectProcessor, func() error, func()) {
+	objects := make(chan StoredObject, f.concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex // guards firstErr, and makes sure the processor is only called by one goroutine at a time
+	var firstErr error
+
+	failure := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr
+	}
+
+	for i := 0; i < f.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range objects {
+				if failure() != nil {
+					continue // nothing more is processed, so there's no need to look anything up
+				}
+				keep, err := f.keep(object)
+
+				mu.Lock()
+				if err == nil && keep && firstErr == nil {
+					err = processor(object)
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	filter := func(object StoredObject) error {
+		if err := failure(); err != nil {
+			return err
+		}
+		objects <- object
+		return nil
+	}
+
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			close(objects)
+			wg.Wait()
+		})
+	}
+
+	finish := func() error {
+		stop()
+		if err := failure(); err != nil {
+			return err
+		}
+		return finalizer()
+	}
+
+	abandon := func() {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = errAbandoned // so that what is still queued is only drained, not processed
+		}
+		mu.Unlock()
+		stop()
+	}
+	return filter, finish, abandon
+}
+
+var errAbandoned = errors.New("the enumeration was abandoned")
+
+// keep says whether the owner of object is one of those included, and not one of those excluded. The folder being
+// copied is always kept, whoever owns it; the filter is for what is in it
+func (f *ownerFilter) keep(object StoredObject) (bool, error) {
+	if object.entityType == common.EEntityType.Folder() && object.relativePath == "" {
+		return true, nil
+	}
+
+	var owners []string
+	for _, byUPN := range []bool{false, true} {
+		if !f.comparesBy(byUPN) {
+			continue
+		}
+		owner, err := f.lookup(object, byUPN)
+		if err != nil {
+			return false, fmt.Errorf("cannot find the owner of %s, for include-owner or exclude-owner: %w", object.relativePath, err)
+		}
+		owners = append(owners, owner)
+	}
+
+	if len(f.include) > 0 && !ownedByAnyOf(owners, f.include) {
+		return false, nil
+	}
+	return !ownedByAnyOf(owners, f.exclude), nil
+}
+
+// comparesBy says whether any of the owners given are user principal names (if upn), or object ids (if not). Only
+// those that are needed are looked up
+func (f *ownerFilter) comparesBy(upn bool) bool {
+	for _, owner := range append(append([]string{}, f.include...), f.exclude...) {
+		if isObjectID(owner) != upn {
+			return true
+		}
+	}
+	return false
+}
+
+func ownedByAnyOf(owners []string, candidates []string) bool {
+	for _, owner := range owners {
+		for _, candidate := range candidates {
+			if strings.EqualFold(owner, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isObjectID(owner string) bool {
+	_, err := common.ParseUUID(owner)
+	return err == nil
+}
+
+// parseOwners splits a semicolon-separated list of owners
+func parseOwners(raw string) []string {
+	owners := make([]string, 0)
+	for _, owner := range strings.Split(raw, ";") {
+		if owner = strings.TrimSpace(owner); owner != "" {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// newOwnerLookup returns an ownerLookup that asks the dfs endpoint of the source for the owner of each path. The
+// source may have been given by its blob endpoint, which is the same account
+func newOwnerLookup(ctx context.Context, source common.ResourceString, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (ownerLookup, error) {
+	sourceURL, err := source.FullURL()
+	if err != nil {
+		return nil, err
+	}
+	p, err := createBlobFSPipeline(ctx, credInfo, logLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	root := azbfs.NewBfsURLParts(*sourceURL)
+	root.Host = strings.Replace(root.Host, ".blob", ".dfs", 1)
+	// the paths of what a wildcard matches are relative to the folder that it is in
+	if i := strings.Index(root.DirectoryOrFilePath, "*"); i >= 0 {
+		root.DirectoryOrFilePath = root.DirectoryOrFilePath[:strings.LastIndex(root.DirectoryOrFilePath[:i], "/")+1]
+	}
+
+	return func(object StoredObject, upn bool) (string, error) {
+		parts := root
+		if object.ContainerName != "" {
+			parts.FileSystemName = object.ContainerName
+		}
+		parts.DirectoryOrFilePath = strings.Trim(path.Join(parts.DirectoryOrFilePath, object.relativePath), "/")
+
+		fileURL := azbfs.NewFileURL(parts.URL(), p)
+		var accessControl azbfs.BlobFSAccessControl
+		var err error
+		if upn {
+			accessControl, err = fileURL.GetAccessControlWithUPN(ctx)
+		} else {
+			accessControl, err = fileURL.GetAccessControl(ctx)
+		}
+		return accessControl.Owner, err
+	}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// PathRewriter maps the path of a file or folder, relative to the source root, to the path that it should have
+// relative to the destination root. Both use '/' as the separator. Returning "" skips the file or folder.
+// It is applied after filtering, so it only sees what will be transferred.
+type PathRewriter func(relPath string) string
+
+// loadPathMap returns a PathRewriter that looks up each relative path in the JSON object saved in the given file.
+// Paths that are not in the file are left as they are. Used by the path-map flag
+func loadPathMap(fileName string) (PathRewriter, error) {
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read path map: %w", err)
+	}
+	pathMap := make(map[string]string)
+	if err = json.Unmarshal(raw, &pathMap); err != nil {
+		return nil, fmt.Errorf("cannot parse path map: %w", err)
+	}
+
+	return func(relPath string) string {
+		if rewritten, ok := pathMap[relPath]; ok {
+			return rewritten
+		}
+		return relPath
+	}, nil
+}
+
+// destinationPathRewriter applies a PathRewriter to the objects of one job, and detects when two of them are given the
+// same destination path
+type destinationPathRewriter struct {
+	rewrite         PathRewriter
+	caseInsensitive bool
+
+	// each destination path that has been given out, and the source path that it was given to
+	claimedBy map[string]string
+}
+
+func newDestinationPathRewriter(rewrite PathRewriter, fromTo common.FromTo) *destinationPathRewriter {
+	return &destinationPathRewriter{
+		rewrite:         rewrite,
+		caseInsensitive: IsDestinationCaseInsensitive(fromTo),
+		claimedBy:       make(map[string]string),
+	}
+}
+
+// apply returns object with its relative path rewritten, and false if the object must be skipped instead. That's
+// either because the rewriter said so, or because an earlier object was already given the same destination path.
+// Single files, and the source root folder, have no relative path, so they are left alone
+func (r *destinationPathRewriter) apply(object StoredObject) (StoredObject, bool) {
+	if object.relativePath == "" {
+		return object, true
+	}
+
+	srcPath := strings.ReplaceAll(object.relativePath, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING)
+	dstPath := strings.Trim(r.rewrite(srcPath), common.AZCOPY_PATH_SEPARATOR_STRING)
+	if dstPath == "" {
+		return object, false
+	}
+
+	key := object.DstContainerName + common.AZCOPY_PATH_SEPARATOR_STRING + dstPath
+	if r.caseInsensitive {
+		key = strings.ToLower(key)
+	}
+	if earlier, ok := r.claimedBy[key]; ok {
+		WarnStdoutAndScanningLog(fmt.Sprintf("Skipping %s, because its destination path %s is already taken by %s", srcPath, dstPath, earlier))
+		return object, false
+	}
+	r.claimedBy[key] = srcPath
+
+	object.relativePath = dstPath
+	return object, true
+}
@@ -0,0 +1,138 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+)
+
+// pipeProgressInterval is how often an upload from stdin, or a download to stdout, reports its progress. The same
+// as for jobs
+const pipeProgressInterval = 2 * time.Second
+
+// pipeProgress reports the progress of an upload from stdin, or a download to stdout. Those don't run as jobs, so it
+// counts the bytes itself, as they pass through. What comes from stdin has no size until it ends, so then there is no
+// percentage, only the bytes so far and the throughput
+type pipeProgress struct {
+	transferred int64 // updated atomically
+	total       int64 // -1 when it isn't known. Also atomic, since it may be learnt while progress is being reported
+	started     time.Time
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newPipeProgress(total int64) *pipeProgress {
+	return &pipeProgress{total: total, started: time.Now(), done: make(chan struct{})}
+}
+
+// setTotal sets the size of what is being piped, once it's known
+func (p *pipeProgress) setTotal(total int64) {
+	atomic.StoreInt64(&p.total, total)
+}
+
+// countReads returns a reader that counts the bytes read from r
+func (p *pipeProgress) countReads(r io.Reader) io.Reader {
+	return &pipeCountingReader{r: r, p: p}
+}
+
+// start reports the progress every pipeProgressInterval, until stop is called
+func (p *pipeProgress) start() {
+	go func() {
+		ticker := time.NewTicker(pipeProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				glcm.Progress(p.builder(false))
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *pipeProgress) stop() {
+	p.stopOnce.Do(func() { close(p.done) })
+}
+
+func (p *pipeProgress) summary() common.PipeProgress {
+	elapsed := time.Since(p.started).Seconds()
+	total := atomic.LoadInt64(&p.total)
+	s := common.PipeProgress{
+		BytesTransferred: uint64(atomic.LoadInt64(&p.transferred)),
+		TotalBytesKnown:  total >= 0,
+		ElapsedSeconds:   elapsed,
+	}
+	if elapsed > 0 {
+		s.Throughput = float64(s.BytesTransferred*8) / (1000 * 1000) / elapsed
+	}
+	if s.TotalBytesKnown {
+		s.TotalBytesExpected = uint64(total)
+		s.PercentComplete = 100
+		if total > 0 {
+			s.PercentComplete = float32(s.BytesTransferred) / float32(total) * 100
+		}
+	}
+	return s
+}
+
+// builder describes the progress so far, or, when final, what was piped in all
+func (p *pipeProgress) builder(final bool) common.OutputBuilder {
+	s := p.summary()
+	return func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			jsonOutput, err := json.Marshal(s)
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+
+		const mebibyte = 1024 * 1024
+		throughputString := fmt.Sprintf("Throughput (Mb/s): %v", jobsAdmin.ToFixed(s.Throughput, 4))
+		if final {
+			return fmt.Sprintf("Piped %.2f MiB in %.1f seconds, %s", float64(s.BytesTransferred)/mebibyte, s.ElapsedSeconds, throughputString)
+		}
+		if !s.TotalBytesKnown {
+			return fmt.Sprintf("%.2f MiB piped, %s", float64(s.BytesTransferred)/mebibyte, throughputString)
+		}
+		return fmt.Sprintf("%.1f %%, %.2f MiB of %.2f MiB piped, %s",
+			s.PercentComplete, float64(s.BytesTransferred)/mebibyte, float64(s.TotalBytesExpected)/mebibyte, throughputString)
+	}
+}
+
+type pipeCountingReader struct {
+	r io.Reader
+	p *pipeProgress
+}
+
+func (c *pipeCountingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	atomic.AddInt64(&c.p.transferred, int64(n))
+	return n, err
+}
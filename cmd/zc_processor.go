@@ -48,6 +48,9 @@ type copyTransferProcessor struct {
 	preserveAccessTier     bool
 	folderPropertiesOption common.FolderPropertyOption
 	dryrunMode             bool
+
+	// what the dry run would have transferred, so far
+	dryrunSummary common.DryrunSummary
 }
 
 func newCopyTransferProcessor(copyJobTemplate *common.CopyJobPartOrderRequest, numOfTransfersPerPart int,
@@ -102,6 +105,13 @@ func (s *copyTransferProcessor) scheduleCopyTransfer(storedObject StoredObject)
 	}
 
 	if s.dryrunMode {
+		if copyTransfer.EntityType == common.EEntityType.Folder() {
+			s.dryrunSummary.FolderCount++
+		} else {
+			s.dryrunSummary.FileCount++
+			s.dryrunSummary.TotalBytes += uint64(copyTransfer.SourceSize)
+		}
+
 		glcm.Dryrun(func(format common.OutputFormat) string {
 			if format == common.EOutputFormat.Json() {
 				jsonOutput, err := json.Marshal(copyTransfer)
@@ -175,7 +185,7 @@ func (s *copyTransferProcessor) scheduleCopyTransfer(storedObject StoredObject)
 	// so that there is at least one transfer for the final part
 	s.copyJobTemplate.Transfers.List = append(s.copyJobTemplate.Transfers.List, copyTransfer)
 	s.copyJobTemplate.Transfers.TotalSizeInBytes += uint64(copyTransfer.SourceSize)
-	if copyTransfer.EntityType == common.EEntityType.File() {
+	if copyTransfer.EntityType != common.EEntityType.Folder() { // preserved symlinks count as files
 		s.copyJobTemplate.Transfers.FileTransferCount++
 	} else {
 		s.copyJobTemplate.Transfers.FolderTransferCount++
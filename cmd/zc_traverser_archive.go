@@ -0,0 +1,98 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// archiveTraverser lists the entries of a local tar or zip file, for source-archive, as if they were the files and
+// folders of a local folder
+type archiveTraverser struct {
+	fullPath                    string
+	format                      common.ArchiveFormat
+	recursive                   bool
+	incrementEnumerationCounter enumerationCounterFunc
+}
+
+func newArchiveTraverser(fullPath string, format common.ArchiveFormat, recursive bool, incrementEnumerationCounter enumerationCounterFunc) *archiveTraverser {
+	return &archiveTraverser{
+		fullPath:                    cleanLocalPath(fullPath),
+		format:                      format,
+		recursive:                   recursive,
+		incrementEnumerationCounter: incrementEnumerationCounter,
+	}
+}
+
+// IsDirectory is true, since an archive holds files and folders, like a folder does
+func (t *archiveTraverser) IsDirectory(bool) bool {
+	return true
+}
+
+func (t *archiveTraverser) Traverse(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+	archive, err := common.OpenArchiveSource(t.fullPath, t.format)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if archive.SkippedEntries > 0 {
+		WarnStdoutAndScanningLog(fmt.Sprintf("%d entries of %s are neither files nor folders, such as links, so they will not be uploaded", archive.SkippedEntries, t.fullPath))
+	}
+
+	for _, e := range archive.Entries() {
+		if !t.recursive && strings.Contains(e.Name, common.AZCOPY_PATH_SEPARATOR_STRING) {
+			continue // without recursive, only the top of the archive is listed, as only the top of a folder would be
+		}
+
+		entityType := common.EEntityType.File()
+		if e.IsFolder {
+			entityType = common.EEntityType.Folder()
+		}
+		if t.incrementEnumerationCounter != nil {
+			t.incrementEnumerationCounter(entityType)
+		}
+
+		err := processIfPassedFilters(filters,
+			newStoredObject(
+				preprocessor,
+				path.Base(e.Name),
+				e.Name,
+				entityType,
+				e.ModTime,
+				e.Size,
+				noContentProps, // as for any local file, the properties come from the job's settings
+				noBlobProps,
+				noMetdata,
+				"", // Local has no such thing as containers
+			),
+			processor)
+		_, err = getProcessingError(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
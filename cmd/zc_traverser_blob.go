@@ -30,7 +30,6 @@ import (
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-blob-go/azblob"
-	"github.com/pkg/errors"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
@@ -61,6 +60,18 @@ type blobTraverser struct {
 	includeSnapshot bool
 
 	includeVersion bool
+
+	// a tag filter expression (in the syntax of Find Blobs by Tags). If set, the service is asked for the matching blobs,
+	// instead of the container being listed
+	includeBlobTags string
+
+	// if set, virtual directories this many levels beneath the root are not listed. Only the hierarchical (parallel)
+	// listing can make use of this; a flat listing returns everything regardless
+	maxDepth uint
+}
+
+func (t *blobTraverser) setMaxDepth(maxDepth uint) {
+	t.maxDepth = maxDepth
 }
 
 func (t *blobTraverser) IsDirectory(isSource bool) bool {
@@ -156,17 +167,27 @@ func (t *blobTraverser) getBlobTags() (common.BlobTags, error) {
 }
 
 func (t *blobTraverser) Traverse(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) (err error) {
+	if t.includeBlobTags != "" {
+		return t.listByTags(preprocessor, processor, filters)
+	}
+
 	blobUrlParts := azblob.NewBlobURLParts(*t.rawURL)
 
 	// check if the url points to a single blob
 	blobProperties, isBlob, isDirStub, propErr := t.getPropertiesIfSingleBlob()
 
+	// the versions of a single blob are found by listing it, since getting its properties only describes the current version
+	if isBlob && t.includeVersion && !t.includeDeleted && !strings.HasSuffix(blobUrlParts.BlobName, common.AZCOPY_PATH_SEPARATOR_STRING) {
+		containerURL := azblob.NewContainerURL(copyHandlerUtil{}.getContainerUrl(blobUrlParts), t.p)
+		return t.listVersionsOfBlob(containerURL, blobUrlParts.ContainerName, blobUrlParts.BlobName, preprocessor, processor, filters)
+	}
+
 	if stgErr, ok := propErr.(azblob.StorageError); ok {
 		// Don't error out unless it's a CPK error just yet
 		// If it's a CPK error, we know it's a single blob and that we can't get the properties on it anyway.
 		if stgErr.ServiceCode() == common.CPK_ERROR_SERVICE_CODE {
-			return errors.New("this blob uses customer provided encryption keys (CPK). At the moment, AzCopy does not support CPK-encrypted blobs. " +
-				"If you wish to make use of this blob, we recommend using one of the Azure Storage SDKs")
+			return fmt.Errorf("cannot read this blob, because it is encrypted with a customer provided key that wasn't given (%s). %s",
+				stgErr.ServiceCode(), common.CPK_ERROR_HINT)
 		}
 
 		if resp := stgErr.Response(); resp == nil {
@@ -270,7 +291,9 @@ func (t *blobTraverser) parallelList(containerURL azblob.ContainerURL, container
 			// queue up the sub virtual directories if recursive is true
 			if t.recursive {
 				for _, virtualDir := range lResp.Segment.BlobPrefixes {
-					enqueueDir(virtualDir.Name)
+					if t.maxDepth == 0 || depthOfRelativePath(strings.TrimPrefix(virtualDir.Name, searchPrefix)) < int(t.maxDepth) {
+						enqueueDir(virtualDir.Name)
+					}
 
 					if t.includeDirectoryStubs {
 						// try to get properties on the directory itself, since it's not listed in BlobItems
@@ -404,7 +427,7 @@ func (t *blobTraverser) createStoredObjectForBlob(preprocessor objectMorpher, bl
 	object.blobDeleted = blobInfo.Deleted
 	if t.includeDeleted && t.includeSnapshot {
 		object.blobSnapshotID = blobInfo.Snapshot
-	} else if t.includeDeleted && t.includeVersion && blobInfo.VersionID != nil {
+	} else if t.includeVersion && blobInfo.VersionID != nil {
 		object.blobVersionID = *blobInfo.VersionID
 	}
 	return object
@@ -471,6 +494,155 @@ func (t *blobTraverser) serialList(containerURL azblob.ContainerURL, containerNa
 	return nil
 }
 
+// listVersionsOfBlob lists every version of the one blob named, each as a single source file, so that each lands
+// beside the others under a name that starts with its version id
+func (t *blobTraverser) listVersionsOfBlob(containerURL azblob.ContainerURL, containerName string, blobName string,
+	preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		listBlob, err := containerURL.ListBlobsFlatSegment(t.ctx, marker,
+			azblob.ListBlobsSegmentOptions{Prefix: blobName, Details: azblob.BlobListingDetails{Metadata: true, Tags: t.s2sPreserveSourceTags, Versions: true}})
+		if err != nil {
+			return fmt.Errorf("cannot list versions of blob %s. Failed with error %s", blobName, err.Error())
+		}
+
+		for _, blobInfo := range listBlob.Segment.BlobItems {
+			// the prefix also matches other blobs whose names start with this one's
+			if blobInfo.Name != blobName {
+				continue
+			}
+
+			storedObject := t.createStoredObjectForBlob(preprocessor, blobInfo, "", containerName)
+
+			if t.s2sPreserveSourceTags && blobInfo.BlobTags != nil {
+				blobTagsMap := common.BlobTags{}
+				for _, blobTag := range blobInfo.BlobTags.BlobTagSet {
+					blobTagsMap[url.QueryEscape(blobTag.Key)] = url.QueryEscape(blobTag.Value)
+				}
+				storedObject.blobTags = blobTagsMap
+			}
+
+			if t.incrementEnumerationCounter != nil {
+				t.incrementEnumerationCounter(common.EEntityType.File())
+			}
+
+			processErr := processIfPassedFilters(filters, storedObject, processor)
+			_, processErr = getProcessingError(processErr)
+			if processErr != nil {
+				return processErr
+			}
+		}
+
+		marker = listBlob.NextMarker
+	}
+
+	return nil
+}
+
+// listByTags pushes the tag filter down to the service, by using Find Blobs by Tags (scoped to our container) instead of
+// listing the container. The service only returns the names of the matching blobs, so their properties are fetched one by one.
+func (t *blobTraverser) listByTags(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+	blobUrlParts := azblob.NewBlobURLParts(*t.rawURL)
+	containerName := blobUrlParts.ContainerName
+	containerURL := azblob.NewContainerURL(copyHandlerUtil{}.getContainerUrl(blobUrlParts), t.p)
+
+	serviceParts := blobUrlParts
+	serviceParts.ContainerName = ""
+	serviceParts.BlobName = ""
+	serviceURL := azblob.NewServiceURL(serviceParts.URL(), t.p)
+
+	// the URL may point at a single blob, rather than at a virtual directory
+	singleBlobName := ""
+	searchPrefix := blobUrlParts.BlobName
+	if searchPrefix != "" && !strings.HasSuffix(searchPrefix, common.AZCOPY_PATH_SEPARATOR_STRING) {
+		singleBlobName = searchPrefix
+		searchPrefix += common.AZCOPY_PATH_SEPARATOR_STRING
+	}
+
+	clientProvidedKey := azblob.ClientProvidedKeyOptions{}
+	if t.cpkOptions.IsSourceEncrypted {
+		clientProvidedKey = common.GetClientProvidedKey(t.cpkOptions)
+	}
+
+	where := fmt.Sprintf("@container='%s' AND %s", containerName, t.includeBlobTags)
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := serviceURL.FindBlobsByTags(t.ctx, nil, nil, &where, marker, nil)
+		if err != nil {
+			return fmt.Errorf("cannot find blobs by tags. Failed with error %s", err.Error())
+		}
+
+		for _, blobItem := range resp.Blobs {
+			relativePath := ""
+			if blobItem.Name != singleBlobName {
+				if !strings.HasPrefix(blobItem.Name, searchPrefix) {
+					continue
+				}
+				relativePath = strings.TrimPrefix(blobItem.Name, searchPrefix)
+				if !t.recursive && strings.Contains(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING) {
+					continue
+				}
+			}
+
+			blobURL := containerURL.NewBlobURL(blobItem.Name)
+			props, err := blobURL.GetProperties(t.ctx, azblob.BlobAccessConditions{}, clientProvidedKey)
+			if err != nil {
+				if stgErr, ok := err.(azblob.StorageError); ok && stgErr.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+					continue // the blob was deleted after the tag query ran
+				}
+				return fmt.Errorf("cannot get the properties of blob %s. Failed with error %s", blobItem.Name, err.Error())
+			}
+
+			if t.doesBlobRepresentAFolder(props.NewMetadata()) {
+				continue
+			}
+
+			storedObject := newStoredObject(
+				preprocessor,
+				getObjectNameOnly(blobItem.Name),
+				relativePath,
+				common.EEntityType.File(),
+				props.LastModified(),
+				props.ContentLength(),
+				props,
+				blobPropertiesResponseAdapter{props},
+				common.FromAzBlobMetadataToCommonMetadata(props.NewMetadata()),
+				containerName,
+			)
+
+			// the tags in the query result are only the ones that matched the expression, so get the full set if we need it
+			if t.s2sPreserveSourceTags {
+				blobTags, err := blobURL.GetTags(t.ctx, nil)
+				if err != nil {
+					return fmt.Errorf("cannot get the tags of blob %s. Failed with error %s", blobItem.Name, err.Error())
+				}
+				blobTagsMap := common.BlobTags{}
+				for _, blobTag := range blobTags.BlobTagSet {
+					blobTagsMap[url.QueryEscape(blobTag.Key)] = url.QueryEscape(blobTag.Value)
+				}
+				storedObject.blobTags = blobTagsMap
+			}
+
+			if t.incrementEnumerationCounter != nil {
+				t.incrementEnumerationCounter(common.EEntityType.File())
+			}
+
+			processErr := processIfPassedFilters(filters, storedObject, processor)
+			_, processErr = getProcessingError(processErr)
+			if processErr != nil {
+				return processErr
+			}
+		}
+
+		// the last page has no next marker at all, which Marker.NotDone would mistake for the first page
+		if resp.NextMarker == nil {
+			break
+		}
+		marker = azblob.Marker{Val: resp.NextMarker}
+	}
+
+	return nil
+}
+
 func newBlobTraverser(rawURL *url.URL, p pipeline.Pipeline, ctx context.Context, recursive, includeDirectoryStubs bool, incrementEnumerationCounter enumerationCounterFunc, s2sPreserveSourceTags bool, cpkOptions common.CpkOptions, includeDeleted, includeSnapshot, includeVersion bool) (t *blobTraverser) {
 	t = &blobTraverser{
 		rawURL:                      rawURL,
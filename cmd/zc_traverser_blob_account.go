@@ -36,6 +36,7 @@ type blobAccountTraverser struct {
 	p                     pipeline.Pipeline
 	ctx                   context.Context
 	containerPattern      string
+	excludeContainerNames []string
 	cachedContainers      []string
 	includeDirectoryStubs bool
 
@@ -76,6 +77,13 @@ func (t *blobAccountTraverser) listContainers() ([]string, error) {
 					}
 				}
 
+				// Excluded containers are dropped here, so they are never traversed
+				if excluded, err := t.isContainerExcluded(v.Name); err != nil {
+					return nil, err
+				} else if excluded {
+					continue
+				}
+
 				cList = append(cList, v.Name)
 			}
 
@@ -90,6 +98,18 @@ func (t *blobAccountTraverser) listContainers() ([]string, error) {
 	}
 }
 
+// isContainerExcluded reports whether the name matches any of the excluded names, each of which may be a pattern
+func (t *blobAccountTraverser) isContainerExcluded(name string) (bool, error) {
+	for _, excluded := range t.excludeContainerNames {
+		if ok, err := containerNameMatchesPattern(name, excluded); err != nil {
+			return false, err
+		} else if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (t *blobAccountTraverser) Traverse(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
 	// listContainers will return the cached container list if containers have already been listed by this traverser.
 	cList, err := t.listContainers()
@@ -115,7 +135,7 @@ func (t *blobAccountTraverser) Traverse(preprocessor objectMorpher, processor ob
 	return nil
 }
 
-func newBlobAccountTraverser(rawURL *url.URL, p pipeline.Pipeline, ctx context.Context, includeDirectoryStubs bool, incrementEnumerationCounter enumerationCounterFunc, s2sPreserveSourceTags bool, cpkOptions common.CpkOptions) (t *blobAccountTraverser) {
+func newBlobAccountTraverser(rawURL *url.URL, p pipeline.Pipeline, ctx context.Context, includeDirectoryStubs bool, incrementEnumerationCounter enumerationCounterFunc, s2sPreserveSourceTags bool, cpkOptions common.CpkOptions, excludeContainerNames []string) (t *blobAccountTraverser) {
 	bURLParts := azblob.NewBlobURLParts(*rawURL)
 	cPattern := bURLParts.ContainerName
 
@@ -130,6 +150,7 @@ func newBlobAccountTraverser(rawURL *url.URL, p pipeline.Pipeline, ctx context.C
 		incrementEnumerationCounter: incrementEnumerationCounter,
 		accountURL:                  azblob.NewServiceURL(bURLParts.URL(), p),
 		containerPattern:            cPattern,
+		excludeContainerNames:       excludeContainerNames,
 		includeDirectoryStubs:       includeDirectoryStubs,
 		s2sPreserveSourceTags:       s2sPreserveSourceTags,
 		cpkOptions:                  cpkOptions,
@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/Azure/azure-storage-azcopy/v10/common/parallel"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -44,6 +45,13 @@ type fileTraverser struct {
 
 	// a generic function to notify that a new stored object has been enumerated
 	incrementEnumerationCounter enumerationCounterFunc
+
+	// if set, directories this many levels beneath the root are not listed
+	maxDepth uint
+}
+
+func (t *fileTraverser) setMaxDepth(maxDepth uint) {
+	t.maxDepth = maxDepth
 }
 
 func (t *fileTraverser) IsDirectory(bool) bool {
@@ -62,9 +70,30 @@ func (t *fileTraverser) getPropertiesIfSingleFile() (*azfile.FileGetPropertiesRe
 	return nil, false
 }
 
+// checkShareSnapshotExists returns an error if the source names a share snapshot that doesn't exist.
+// Otherwise, every directory would just be logged as one that failed to scan, and nothing would be copied
+func (t *fileTraverser) checkShareSnapshotExists(targetURLParts azfile.FileURLParts) error {
+	rootParts := targetURLParts
+	rootParts.DirectoryOrFilePath = ""
+	rootURL := azfile.NewDirectoryURL(rootParts.URL(), t.p)
+
+	_, err := rootURL.ListFilesAndDirectoriesSegment(t.ctx, azfile.Marker{}, azfile.ListFilesAndDirectoriesOptions{MaxResults: 1})
+	if stgErr, ok := err.(azfile.StorageError); ok && stgErr.Response() != nil && stgErr.Response().StatusCode == http.StatusNotFound {
+		return fmt.Errorf("the snapshot %s of share %s does not exist", targetURLParts.ShareSnapshot, targetURLParts.ShareName)
+	}
+	return nil // any other problem is reported by the enumeration itself
+}
+
 func (t *fileTraverser) Traverse(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) (err error) {
 	targetURLParts := azfile.NewFileURLParts(*t.rawURL)
 
+	// a snapshot is enumerated just like the live share, via the sharesnapshot parameter that every URL below carries along
+	if targetURLParts.ShareSnapshot != "" {
+		if err = t.checkShareSnapshotExists(targetURLParts); err != nil {
+			return err
+		}
+	}
+
 	// if not pointing to a share, check if we are pointing to a single file
 	if targetURLParts.DirectoryOrFilePath != "" {
 		// check if the url points to a single file
@@ -186,6 +215,7 @@ func (t *fileTraverser) Traverse(preprocessor objectMorpher, processor objectPro
 
 	// Define how to enumerate its contents
 	// This func must be threadsafe/goroutine safe
+	rootURL := directoryURL.URL()
 	enumerateOneDir := func(dir parallel.Directory, enqueueDir func(parallel.Directory), enqueueOutput func(parallel.DirectoryEntry, error)) error {
 		currentDirURL := dir.(azfile.DirectoryURL)
 		for marker := (azfile.Marker{}); marker.NotDone(); {
@@ -199,8 +229,12 @@ func (t *fileTraverser) Traverse(preprocessor objectMorpher, processor objectPro
 			for _, dirInfo := range lResp.DirectoryItems {
 				enqueueOutput(newAzFileChildFolderEntity(currentDirURL, dirInfo.Name), nil)
 				if t.recursive {
-					// If recursive is turned on, add sub directories to be processed
-					enqueueDir(currentDirURL.NewDirectoryURL(dirInfo.Name))
+					// If recursive is turned on, add sub directories to be processed, unless they are as deep as we go
+					childDirURL := currentDirURL.NewDirectoryURL(dirInfo.Name)
+					childURL := childDirURL.URL()
+					if t.maxDepth == 0 || depthOfRelativePath(strings.TrimPrefix(childURL.Path, rootURL.Path)) < int(t.maxDepth) {
+						enqueueDir(childDirURL)
+					}
 				}
 			}
 
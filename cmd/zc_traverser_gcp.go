@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	gcpUtils "cloud.google.com/go/storage"
+	"github.com/minio/minio-go"
 	"google.golang.org/api/iterator"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -21,6 +23,9 @@ type gcpTraverser struct {
 	gcpURLParts common.GCPURLParts
 	gcpClient   *gcpUtils.Client
 
+	// hmacClient is used in place of gcpClient when authenticating with an HMAC key, which only the XML API accepts
+	hmacClient *minio.Client
+
 	incrementEnumerationCounter enumerationCounterFunc
 }
 
@@ -30,6 +35,10 @@ func (t *gcpTraverser) IsDirectory(isSource bool) bool {
 	if !isSource {
 		return isDirDirect
 	}
+	if t.hmacClient != nil {
+		_, err := t.hmacClient.StatObject(t.gcpURLParts.BucketName, t.gcpURLParts.ObjectKey, minio.StatObjectOptions{})
+		return err != nil
+	}
 	bkt := t.gcpClient.Bucket(t.gcpURLParts.BucketName)
 	obj := bkt.Object(t.gcpURLParts.ObjectKey)
 	//Directories do not have attributes and hence throw error
@@ -46,23 +55,22 @@ func (t *gcpTraverser) Traverse(preprocessor objectMorpher, processor objectProc
 		objectPath := strings.Split(t.gcpURLParts.ObjectKey, "/")
 		objectName := objectPath[len(objectPath)-1]
 
-		attrs, err := t.gcpClient.Bucket(t.gcpURLParts.BucketName).Object(t.gcpURLParts.ObjectKey).Attrs(t.ctx)
+		lmt, size, props, err := t.getObjectProperties(t.gcpURLParts.ObjectKey)
 		if err == nil {
-			glcm.Info(fmt.Sprintf("Bucket: %v, Object: %v, Type: %v\n", attrs.Bucket, attrs.Name, attrs.ContentType))
-			gie := common.GCPObjectInfoExtension{ObjectInfo: *attrs}
 			storedObject := newStoredObject(
 				preprocessor,
 				objectName,
 				"",
 				common.EEntityType.File(),
-				attrs.Updated,
-				attrs.Size,
-				&gie,
+				lmt,
+				size,
+				props,
 				noBlobProps,
-				gie.NewCommonMetadata(),
+				props.NewCommonMetadata(),
 				t.gcpURLParts.BucketName)
 			err = processIfPassedFilters(filters, storedObject,
 				processor)
+			_, err = getProcessingError(err)
 			if err != nil {
 				return err
 			}
@@ -76,64 +84,112 @@ func (t *gcpTraverser) Traverse(preprocessor objectMorpher, processor objectProc
 	}
 	searchPrefix := t.gcpURLParts.ObjectKey
 
-	bkt := t.gcpClient.Bucket(t.gcpURLParts.BucketName)
-	query := &gcpUtils.Query{Prefix: searchPrefix}
-	if !t.recursive {
-		query.Delimiter = "/"
-	}
-	it := bkt.Objects(t.ctx, query)
-
 	//If code reaches here then the URL points to a bucket or a virtual directory
-	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
+	return t.listObjects(searchPrefix, func(name string, lmt time.Time, size int64) error {
+		//Virtual directories alone have "/" as suffix and size as 0
+		if strings.HasSuffix(name, "/") || name == "" {
 			return nil
 		}
-		if err == nil {
-			//Virtual directories alone have "/" as suffix and size as 0
-			if strings.HasSuffix(attrs.Name, "/") || attrs.Name == "" {
-				continue
-			}
-			objectPath := strings.Split(attrs.Name, "/")
-			objectName := objectPath[len(objectPath)-1]
 
-			relativePath := strings.TrimPrefix(attrs.Name, searchPrefix)
+		objectPath := strings.Split(name, "/")
+		objectName := objectPath[len(objectPath)-1]
 
-			oie := common.GCPObjectInfoExtension{ObjectInfo: gcpUtils.ObjectAttrs{}}
+		relativePath := strings.TrimPrefix(name, searchPrefix)
+		if strings.HasSuffix(relativePath, "/") {
+			// As with S3, an object whose name ends in / is treated as a folder, and skipped.
+			return nil
+		}
 
-			if t.getProperties {
-				oi, err := t.gcpClient.Bucket(t.gcpURLParts.BucketName).Object(attrs.Name).Attrs(t.ctx)
-				if err != nil {
-					return err
-				}
-				oie = common.GCPObjectInfoExtension{ObjectInfo: *oi}
+		// default to empty props, but retrieve real ones if required
+		var props gcpObjectProperties = &common.GCPObjectInfoExtension{ObjectInfo: gcpUtils.ObjectAttrs{}}
+		if t.hmacClient != nil {
+			props = &common.GCPHMACObjectInfoExtension{}
+		}
+		if t.getProperties {
+			var err error
+			if _, _, props, err = t.getObjectProperties(name); err != nil {
+				return err
 			}
+		}
 
-			storedObject := newStoredObject(
-				preprocessor,
-				objectName,
-				relativePath,
-				common.EEntityType.File(),
-				attrs.Updated,
-				attrs.Size,
-				&oie,
-				noBlobProps,
-				oie.NewCommonMetadata(),
-				t.gcpURLParts.BucketName)
+		storedObject := newStoredObject(
+			preprocessor,
+			objectName,
+			relativePath,
+			common.EEntityType.File(),
+			lmt,
+			size,
+			props,
+			noBlobProps,
+			props.NewCommonMetadata(),
+			t.gcpURLParts.BucketName)
+
+		err := processIfPassedFilters(filters,
+			storedObject,
+			processor)
+		_, err = getProcessingError(err)
+		return err
+	})
+}
 
-			err = processIfPassedFilters(filters,
-				storedObject,
-				processor)
-			_, err = getProcessingError(err)
-			if err != nil {
+// gcpObjectProperties is implemented by both the JSON API and the XML API flavours of GCP object properties
+type gcpObjectProperties interface {
+	contentPropsProvider
+	NewCommonMetadata() common.Metadata
+}
+
+// getObjectProperties returns the last modified time, size and properties of the named object in the bucket.
+func (t *gcpTraverser) getObjectProperties(name string) (time.Time, int64, gcpObjectProperties, error) {
+	if t.hmacClient != nil {
+		oi, err := t.hmacClient.StatObject(t.gcpURLParts.BucketName, name, minio.StatObjectOptions{})
+		if err != nil {
+			return time.Time{}, 0, nil, err
+		}
+		return oi.LastModified, oi.Size, &common.GCPHMACObjectInfoExtension{ObjectInfoExtension: common.ObjectInfoExtension{ObjectInfo: oi}}, nil
+	}
+
+	attrs, err := t.gcpClient.Bucket(t.gcpURLParts.BucketName).Object(name).Attrs(t.ctx)
+	if err != nil {
+		return time.Time{}, 0, nil, err
+	}
+	return attrs.Updated, attrs.Size, &common.GCPObjectInfoExtension{ObjectInfo: *attrs}, nil
+}
+
+// listObjects calls process for every object under the prefix, following the continuation of the listing through all its pages.
+// Unless the traversal is recursive, only the objects directly under the prefix are listed.
+func (t *gcpTraverser) listObjects(prefix string, process func(name string, lmt time.Time, size int64) error) error {
+	if t.hmacClient != nil {
+		for objectInfo := range t.hmacClient.ListObjects(t.gcpURLParts.BucketName, prefix, t.recursive, t.ctx.Done()) {
+			if objectInfo.Err != nil {
+				return fmt.Errorf("cannot list objects, %v", objectInfo.Err)
+			}
+			if err := process(objectInfo.Key, objectInfo.LastModified, objectInfo.Size); err != nil {
 				return err
 			}
 		}
+		return nil
+	}
+
+	query := &gcpUtils.Query{Prefix: prefix}
+	if !t.recursive {
+		query.Delimiter = "/"
+	}
+	it := t.gcpClient.Bucket(t.gcpURLParts.BucketName).Objects(t.ctx, query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot list objects, %v", err)
+		}
+		if err = process(attrs.Name, attrs.Updated, attrs.Size); err != nil {
+			return err
+		}
 	}
-	return nil
 }
 
-func newGCPTraverser(rawURL *url.URL, ctx context.Context, recursive, getProperties bool, incrementEnumerationCounter enumerationCounterFunc) (*gcpTraverser, error) {
+func newGCPTraverser(credentialType common.CredentialType, rawURL *url.URL, ctx context.Context, recursive, getProperties bool, incrementEnumerationCounter enumerationCounterFunc) (*gcpTraverser, error) {
 	t := &gcpTraverser{
 		rawURL:                      rawURL,
 		ctx:                         ctx,
@@ -148,6 +204,13 @@ func newGCPTraverser(rawURL *url.URL, ctx context.Context, recursive, getPropert
 		t.gcpURLParts = gcpURLParts
 	}
 
+	if credentialType == common.ECredentialType.GoogleHMACKey() {
+		t.hmacClient, err = common.CreateS3Client(t.ctx, common.NewGCPHMACCredentialInfo(), common.CredentialOpOptions{
+			LogError: glcm.Error,
+		}, azcopyScanningLogger)
+		return t, err
+	}
+
 	t.gcpClient, err = common.CreateGCPClient(t.ctx)
 
 	return t, err
@@ -71,7 +71,7 @@ func (t *gcpServiceTraverser) Traverse(preprocessor objectMorpher, processor obj
 		tmpGCPURL := t.gcpURL
 		tmpGCPURL.BucketName = v
 		urlResult := tmpGCPURL.URL()
-		bucketTraverser, err := newGCPTraverser(&urlResult, t.ctx, true, t.getProperties, t.incrementEnumerationCounter)
+		bucketTraverser, err := newGCPTraverser(common.ECredentialType.GoogleAppCredentials(), &urlResult, t.ctx, true, t.getProperties, t.incrementEnumerationCounter)
 
 		if err != nil {
 			return err
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"net/url"
+	"sync/atomic"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
@@ -56,7 +57,7 @@ func (l *listTraverser) Traverse(preprocessor objectMorpher, processor objectPro
 		//   2. a directory entity that needs to be scanned
 		childTraverser, err := l.childTraverserGenerator(childPath)
 		if err != nil {
-			glcm.Info(fmt.Sprintf("Skipping %s due to error %s", childPath, err))
+			WarnStdoutAndScanningLog(fmt.Sprintf("Skipping %s due to error %s", childPath, err))
 			continue
 		}
 
@@ -74,14 +75,19 @@ func (l *listTraverser) Traverse(preprocessor objectMorpher, processor objectPro
 		// case 2: child2 is a directory, and it has items under it such as child2/grandchild1
 		//         the relative path returned by the child traverser would be "grandchild1"
 		//         it should be "child2/grandchild1" instead
+		// the preprocessor sees every object the child finds, before the filters do, so it also tells us whether the path exists at all
+		var found int32
 		childPreProcessor := func(object *StoredObject) {
+			atomic.StoreInt32(&found, 1) // some traversers call their preprocessor from more than one goroutine
 			object.relativePath = common.GenerateFullPath(childPath, object.relativePath)
 		}
 		preProcessorForThisChild := preprocessor.FollowedBy(childPreProcessor)
 
 		err = childTraverser.Traverse(preProcessorForThisChild, processor, filters)
 		if err != nil {
-			glcm.Info(fmt.Sprintf("Skipping %s as it cannot be scanned due to error: %s", childPath, err))
+			WarnStdoutAndScanningLog(fmt.Sprintf("Skipping %s as it cannot be scanned due to error: %s", childPath, err))
+		} else if atomic.LoadInt32(&found) == 0 {
+			WarnStdoutAndScanningLog(fmt.Sprintf("Skipping %s as no file or folder was found at that path", childPath))
 		}
 	}
 
@@ -89,7 +95,7 @@ func (l *listTraverser) Traverse(preprocessor objectMorpher, processor objectPro
 }
 
 func newListTraverser(parent common.ResourceString, parentType common.Location, credential *common.CredentialInfo,
-	ctx *context.Context, recursive, followSymlinks, getProperties bool, listChan chan string,
+	ctx *context.Context, recursive bool, symlinkHandling common.SymlinkHandlingType, symlinkRoot string, getProperties bool, listChan chan string,
 	includeDirectoryStubs bool, incrementEnumerationCounter enumerationCounterFunc, s2sPreserveBlobTags bool,
 	logLevel pipeline.LogLevel, cpkOptions common.CpkOptions) ResourceTraverser {
 	var traverserGenerator childTraverserGenerator
@@ -106,10 +112,10 @@ func newListTraverser(parent common.ResourceString, parentType common.Location,
 			source.Value = common.GenerateFullPath(parent.ValueLocal(), relativeChildPath)
 		}
 
-		// Construct a traverser that goes through the child
-		traverser, err := InitResourceTraverser(source, parentType, ctx, credential, &followSymlinks,
+		// Construct a traverser that goes through the child. Its symlinks are still confined to the root of the parent
+		traverser, err := InitResourceTraverser(source, parentType, ctx, credential, symlinkHandling, symlinkRoot,
 			nil, recursive, getProperties, includeDirectoryStubs, common.EPermanentDeleteOption.None(), incrementEnumerationCounter,
-			nil, s2sPreserveBlobTags, logLevel, cpkOptions, nil /* errorChannel */)
+			nil, false, s2sPreserveBlobTags, logLevel, cpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
 		if err != nil {
 			return nil, err
 		}
@@ -39,13 +39,21 @@ import (
 const MAX_SYMLINKS_TO_FOLLOW = 40
 
 type localTraverser struct {
-	fullPath       string
-	recursive      bool
-	followSymlinks bool
-	appCtx         context.Context
+	fullPath        string
+	recursive       bool
+	symlinkHandling common.SymlinkHandlingType
+	symlinkRoot     string // if set, symlinks whose targets are outside of this folder are not followed
+	appCtx          context.Context
 	// a generic function to notify that a new stored object has been enumerated
 	incrementEnumerationCounter enumerationCounterFunc
 	errorChannel                chan ErrorFileInfo
+
+	// if set, folders this many levels beneath the root are not walked into
+	maxDepth uint
+}
+
+func (t *localTraverser) setMaxDepth(maxDepth uint) {
+	t.maxDepth = maxDepth
 }
 
 func (t *localTraverser) IsDirectory(bool) bool {
@@ -187,7 +195,16 @@ func writeToErrorChannel(errorChannel chan ErrorFileInfo, err ErrorFileInfo) {
 // Separate this from the traverser for two purposes:
 // 1) Cleaner code
 // 2) Easier to test individually than to test the entire traverser.
-func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath.WalkFunc, followSymlinks bool, errorChannel chan ErrorFileInfo) (err error) {
+// Symlinks are handled as symlinkHandling says: skipped, followed (with cycles broken by remembering what has
+// been seen), or passed to walkFunc as they are, with the FileInfo of the link rather than its target.
+// If symlinkRoot is set, symlinks whose targets are outside of it are not followed, with a warning.
+func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath.WalkFunc, symlinkHandling common.SymlinkHandlingType, symlinkRoot string, errorChannel chan ErrorFileInfo) (err error) {
+	return walkWithSymlinksToDepth(appCtx, fullPath, 0, walkFunc, symlinkHandling, symlinkRoot, errorChannel)
+}
+
+// walkWithSymlinksToDepth is WalkWithSymlinks, except that it does not descend into the folders (including those reached
+// through symlinks) that are maxDepth levels beneath fullPath. A maxDepth of 0 means no limit.
+func walkWithSymlinksToDepth(appCtx context.Context, fullPath string, maxDepth uint, walkFunc filepath.WalkFunc, symlinkHandling common.SymlinkHandlingType, symlinkRoot string, errorChannel chan ErrorFileInfo) (err error) {
 
 	// We want to re-queue symlinks up in their evaluated form because filepath.Walk doesn't evaluate them for us.
 	// So, what is the plan of attack?
@@ -205,10 +222,17 @@ func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath
 
 	walkQueue := []walkItem{{fullPath: fullPath, relativeBase: ""}}
 
+	var resolvedSymlinkRoot string
+	if symlinkRoot != "" && symlinkHandling == common.ESymlinkHandlingType.Follow() {
+		if resolvedSymlinkRoot, err = resolveSymlinkRoot(symlinkRoot); err != nil {
+			return err
+		}
+	}
+
 	// do NOT put fullPath: true into the map at this time, because we want to match the semantics of filepath.Walk, where the walkfunc is called for the root
 	// When following symlinks, our current implementation tracks folders and files.  Which may consume GB's of RAM when there are 10s of millions of files.
 	var seenPaths seenPathsRecorder = &nullSeenPathsRecorder{} // uses no RAM
-	if followSymlinks {
+	if symlinkHandling == common.ESymlinkHandlingType.Follow() {
 		seenPaths = &realSeenPathsRecorder{make(map[string]struct{})} // have to use the RAM if we are dealing with symlinks, to prevent cycles
 	}
 
@@ -217,7 +241,12 @@ func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath
 		walkQueue = walkQueue[1:]
 		// walk contents of this queueItem in parallel
 		// (for simplicity of coding, we don't parallelize across multiple queueItems)
-		parallel.Walk(appCtx, queueItem.fullPath, EnumerationParallelism, EnumerationParallelStatFiles, func(filePath string, fileInfo os.FileInfo, fileError error) error {
+		// a folder reached through a symlink is as deep as the symlink, so it has fewer levels left to walk
+		remainingDepth := 0
+		if maxDepth != 0 {
+			remainingDepth = int(maxDepth) - depthOfRelativePath(queueItem.relativeBase)
+		}
+		parallel.WalkToDepth(appCtx, queueItem.fullPath, remainingDepth, EnumerationParallelism, EnumerationParallelStatFiles, func(filePath string, fileInfo os.FileInfo, fileError error) error {
 			if fileError != nil {
 				WarnStdoutAndScanningLog(fmt.Sprintf("Accessing '%s' failed with error: %s", filePath, fileError.Error()))
 				writeToErrorChannel(errorChannel, ErrorFileInfo{FilePath: filePath, FileInfo: fileInfo, ErrorMsg: fileError})
@@ -247,8 +276,14 @@ func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath
 			}
 
 			if fileInfo.Mode()&os.ModeSymlink != 0 {
-				if !followSymlinks {
+				switch symlinkHandling {
+				case common.ESymlinkHandlingType.Skip():
 					return nil // skip it
+				case common.ESymlinkHandlingType.Preserve():
+					// the link itself is the thing to transfer. Nothing is walked through it, so it can't lead to a cycle
+					err := walkFunc(common.GenerateFullPath(fullPath, computedRelativePath), fileInfo, fileError)
+					_, err = getProcessingError(err)
+					return err
 				}
 
 				/*
@@ -283,6 +318,11 @@ func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath
 					return nil
 				}
 
+				if resolvedSymlinkRoot != "" && !isWithinFolder(result, resolvedSymlinkRoot) {
+					warnSymlinkOutsideRoot(filePath, result, symlinkRoot)
+					return nil
+				}
+
 				rStat, err := os.Stat(result)
 				if err != nil {
 					err = fmt.Errorf("Failed to get properties of symlink target at %s: %s", result, err.Error())
@@ -300,10 +340,13 @@ func WalkWithSymlinks(appCtx context.Context, fullPath string, walkFunc filepath
 						if !skipped { // Don't go any deeper (or record it) if we skipped it.
 							seenPaths.Record(common.ToExtendedPath(result))
 							seenPaths.Record(common.ToExtendedPath(slPath)) // Note we've seen the symlink as well. We shouldn't ever have issues if we _don't_ do this because we'll just catch it by symlink result
-							walkQueue = append(walkQueue, walkItem{
-								fullPath:     result,
-								relativeBase: computedRelativePath,
-							})
+							// nor if it is as deep as we go
+							if maxDepth == 0 || depthOfRelativePath(computedRelativePath) < int(maxDepth) {
+								walkQueue = append(walkQueue, walkItem{
+									fullPath:     result,
+									relativeBase: computedRelativePath,
+								})
+							}
 						}
 						// enumerate the FOLDER now (since its presence in seenDirs will prevent its properties getting enumerated later)
 						return err
@@ -401,7 +444,10 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 				}
 
 				var entityType common.EntityType
-				if fileInfo.IsDir() {
+				if fileInfo.Mode()&os.ModeSymlink != 0 {
+					// WalkWithSymlinks only gives us the link itself when we are preserving symlinks
+					entityType = common.EEntityType.Symlink()
+				} else if fileInfo.IsDir() {
 					newFileInfo, err := WrapFolder(filePath, fileInfo)
 					if err != nil {
 						WarnStdoutAndScanningLog(fmt.Sprintf("Failed to get last change of target at %s: %s", filePath, err.Error()))
@@ -416,8 +462,8 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 				}
 
 				relPath := strings.TrimPrefix(strings.TrimPrefix(cleanLocalPath(filePath), cleanLocalPath(t.fullPath)), common.DeterminePathSeparator(t.fullPath))
-				if !t.followSymlinks && fileInfo.Mode()&os.ModeSymlink != 0 {
-					WarnStdoutAndScanningLog(fmt.Sprintf("Skipping over symlink at %s because --follow-symlinks is false", common.GenerateFullPath(t.fullPath, relPath)))
+				if t.symlinkHandling == common.ESymlinkHandlingType.Skip() && fileInfo.Mode()&os.ModeSymlink != 0 {
+					WarnStdoutAndScanningLog(fmt.Sprintf("Skipping over symlink at %s because neither --follow-symlinks nor --preserve-symlinks is set", common.GenerateFullPath(t.fullPath, relPath)))
 					return nil
 				}
 
@@ -443,7 +489,7 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 			}
 
 			// note: Walk includes root, so no need here to separately create StoredObject for root (as we do for other folder-aware sources)
-			return WalkWithSymlinks(t.appCtx, t.fullPath, processFile, t.followSymlinks, t.errorChannel)
+			return walkWithSymlinksToDepth(t.appCtx, t.fullPath, t.maxDepth, processFile, t.symlinkHandling, t.symlinkRoot, t.errorChannel)
 		} else {
 			// if recursive is off, we only need to scan the files immediately under the fullPath
 			// We don't transfer any directory properties here, not even the root. (Because the root's
@@ -454,13 +500,24 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 				return err
 			}
 
+			var resolvedSymlinkRoot string
+			if t.symlinkRoot != "" && t.symlinkHandling == common.ESymlinkHandlingType.Follow() {
+				if resolvedSymlinkRoot, err = resolveSymlinkRoot(t.symlinkRoot); err != nil {
+					return err
+				}
+			}
+
 			// go through the files and return if any of them fail to process
 			for _, singleFile := range files {
 				// This won't change. It's purely to hand info off to STE about where the symlink lives.
 				relativePath := singleFile.Name()
+				entityType := common.EEntityType.File() // TODO: add code path for folders
 				if singleFile.Mode()&os.ModeSymlink != 0 {
-					if !t.followSymlinks {
+					if t.symlinkHandling == common.ESymlinkHandlingType.Skip() {
 						continue
+					} else if t.symlinkHandling == common.ESymlinkHandlingType.Preserve() {
+						// ReadDir uses Lstat, so singleFile already describes the link itself
+						entityType = common.EEntityType.Symlink()
 					} else {
 						// Because this only goes one layer deep, we can just append the filename to fullPath and resolve with it.
 						symlinkPath := common.GenerateFullPath(t.fullPath, singleFile.Name())
@@ -478,6 +535,11 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 							return err
 						}
 
+						if resolvedSymlinkRoot != "" && !isWithinFolder(result, resolvedSymlinkRoot) {
+							warnSymlinkOutsideRoot(symlinkPath, result, t.symlinkRoot)
+							continue
+						}
+
 						// Replace the current FileInfo with
 						singleFile, err = common.OSStat(result)
 
@@ -493,7 +555,7 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 				}
 
 				if t.incrementEnumerationCounter != nil {
-					t.incrementEnumerationCounter(common.EEntityType.File())
+					t.incrementEnumerationCounter(entityType)
 				}
 
 				err := processIfPassedFilters(filters,
@@ -501,7 +563,7 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 						preprocessor,
 						singleFile.Name(),
 						strings.ReplaceAll(relativePath, common.DeterminePathSeparator(t.fullPath), common.AZCOPY_PATH_SEPARATOR_STRING), // Consolidate relative paths to the azcopy path separator for sync
-						common.EEntityType.File(), // TODO: add code path for folders
+						entityType,
 						singleFile.ModTime(),
 						singleFile.Size(),
 						noContentProps, // Local MD5s are computed in the STE, and other props don't apply to local files
@@ -521,17 +583,39 @@ func (t *localTraverser) Traverse(preprocessor objectMorpher, processor objectPr
 	return
 }
 
-func newLocalTraverser(ctx context.Context, fullPath string, recursive bool, followSymlinks bool, incrementEnumerationCounter enumerationCounterFunc, errorChannel chan ErrorFileInfo) *localTraverser {
+func newLocalTraverser(ctx context.Context, fullPath string, recursive bool, symlinkHandling common.SymlinkHandlingType, symlinkRoot string, incrementEnumerationCounter enumerationCounterFunc, errorChannel chan ErrorFileInfo) *localTraverser {
 	traverser := localTraverser{
 		fullPath:                    cleanLocalPath(fullPath),
 		recursive:                   recursive,
-		followSymlinks:              followSymlinks,
+		symlinkHandling:             symlinkHandling,
+		symlinkRoot:                 symlinkRoot,
 		appCtx:                      ctx,
 		incrementEnumerationCounter: incrementEnumerationCounter,
 		errorChannel:                errorChannel}
 	return &traverser
 }
 
+// resolveSymlinkRoot gets the absolute path of the root, with any symlinks in it resolved, so that it can be
+// compared with the resolved targets of the symlinks beneath it
+func resolveSymlinkRoot(symlinkRoot string) (string, error) {
+	root, err := filepath.Abs(symlinkRoot)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(root)
+}
+
+// isWithinFolder tells whether the path is the folder itself, or anything beneath it. Both must be absolute and clean.
+func isWithinFolder(path, folder string) bool {
+	rel, err := filepath.Rel(folder, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func warnSymlinkOutsideRoot(linkPath, target, root string) {
+	WarnStdoutAndScanningLog(fmt.Sprintf("Not following symlink at %s, because its target %s is outside of %s. "+
+		"Set --restrict-symlinks-to-root=false to follow symlinks wherever they lead", linkPath, target, root))
+}
+
 func cleanLocalPath(localPath string) string {
 	localPathSeparator := common.DeterminePathSeparator(localPath)
 	// path.Clean only likes /, and will only handle /. So, we consolidate it to /.
@@ -0,0 +1,269 @@
+// Copyright © 2019 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// urlListTraverser goes through a list of blob URLs, each of which is a source of its own, with its own SAS.
+// Unlike the listTraverser, whose paths are all relative to the one source, the URLs can be in any container, of any account.
+// A URL that cannot be listed does not stop the traversal. It is recorded in failures instead
+type urlListTraverser struct {
+	urls     chan string
+	keep     common.KeepFromUrl
+	failures *urlListFailures
+
+	// generates the traverser for one URL, which has already been split into its container and blob name
+	childTraverserGenerator func(source common.ResourceString) (ResourceTraverser, error)
+	recursive               bool
+}
+
+// There is no source root, since each URL is a source of its own, so there is nothing to be a directory.
+func (l *urlListTraverser) IsDirectory(bool) bool {
+	return false
+}
+
+func (l *urlListTraverser) Traverse(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+	for rawURL := range l.urls {
+		if err := l.traverseURL(rawURL, preprocessor, processor, filters); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// traverseURL lists the blobs at one URL. Only an error from processor is returned, since that should stop the whole job.
+// Any other failure is recorded as a failure of the URL
+func (l *urlListTraverser) traverseURL(rawURL string, preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+	if InferArgumentLocation(rawURL) != common.ELocation.Blob() {
+		l.failures.add(rawURL, errors.New("not a Blob URL"))
+		return nil
+	}
+	source, err := SplitResourceString(rawURL, common.ELocation.Blob())
+	if err != nil {
+		l.failures.add(rawURL, err)
+		return nil
+	}
+	parsedURL, err := url.Parse(source.Value)
+	if err != nil {
+		l.failures.add(rawURL, err)
+		return nil
+	}
+	urlParts := azblob.NewBlobURLParts(*parsedURL)
+	if urlParts.ContainerName == "" {
+		l.failures.add(rawURL, errors.New("the URL does not include a container"))
+		return nil
+	}
+	containerName, blobName := urlParts.ContainerName, urlParts.BlobName
+
+	// the transfers are relative to the container, so that the URLs of one container can share a job part
+	root := source.Clone()
+	urlParts.BlobName = ""
+	containerURL := urlParts.URL()
+	root.Value = containerURL.String()
+
+	childTraverser, err := l.childTraverserGenerator(source)
+	if err != nil {
+		l.failures.add(rawURL, err)
+		return nil
+	}
+	if !l.recursive && childTraverser.IsDirectory(true) {
+		WarnStdoutAndScanningLog(fmt.Sprintf("Skipping %s as it is a directory, and recursive is not set", common.URLStringExtension(rawURL).RedactSecretQueryParamForLogging()))
+		return nil
+	}
+
+	found := false
+	childPreProcessor := func(object *StoredObject) {
+		found = true
+		object.relativePath = common.GenerateFullPath(blobName, object.relativePath)
+		object.sourceRoot = &root
+		object.keptPath = keptPath(l.keep, containerName, object.relativePath)
+	}
+
+	// tell the errors of processor, which stop the job, from those of the listing, which only fail this URL
+	var processorErr error
+	childProcessor := func(object StoredObject) error {
+		processorErr = processor(object)
+		return processorErr
+	}
+
+	err = childTraverser.Traverse(preprocessor.FollowedBy(childPreProcessor), childProcessor, filters)
+	if processorErr != nil {
+		return processorErr
+	} else if err != nil {
+		l.failures.add(rawURL, err)
+	} else if !found {
+		l.failures.add(rawURL, errNoBlobAtUrl)
+	}
+	return nil
+}
+
+var errNoBlobAtUrl = errors.New("no blob was found at that URL")
+
+// keptPath returns the part of a blob's URL that becomes its path at the destination
+func keptPath(keep common.KeepFromUrl, containerName string, blobName string) string {
+	switch keep {
+	case common.EKeepFromUrl.ContainerAndBlobName():
+		return containerName + common.AZCOPY_PATH_SEPARATOR_STRING + blobName
+	case common.EKeepFromUrl.FileName():
+		return path.Base(blobName)
+	default:
+		return blobName
+	}
+}
+
+func newUrlListTraverser(urls chan string, keep common.KeepFromUrl, failures *urlListFailures, ctx *context.Context, recursive bool,
+	getProperties bool, includeDirectoryStubs bool, incrementEnumerationCounter enumerationCounterFunc, s2sPreserveBlobTags bool,
+	logLevel pipeline.LogLevel, cpkOptions common.CpkOptions) ResourceTraverser {
+
+	// each URL carries its own SAS, unless it is public
+	credential := common.CredentialInfo{CredentialType: common.ECredentialType.Anonymous()}
+
+	return &urlListTraverser{
+		urls:     urls,
+		keep:     keep,
+		failures: failures,
+		childTraverserGenerator: func(source common.ResourceString) (ResourceTraverser, error) {
+			return InitResourceTraverser(source, common.ELocation.Blob(), ctx, &credential, common.ESymlinkHandlingType.Skip(), "",
+				nil, recursive, getProperties, includeDirectoryStubs, common.EPermanentDeleteOption.None(), incrementEnumerationCounter,
+				nil, false, s2sPreserveBlobTags, logLevel, cpkOptions, nil /* errorChannel */, "" /* includeBlobTags */, nil /* excludeContainerNames */)
+		},
+		recursive: recursive,
+	}
+}
+
+// urlListFailures records the URLs of list-of-urls that could not be listed. They never become part of the job, so they
+// are added to its summary as failed transfers instead
+type urlListFailures struct {
+	sync.Mutex
+	failed   []common.TransferDetail
+	reported int // how many of failed have been listed in a summary
+}
+
+func (f *urlListFailures) add(rawURL string, err error) {
+	redactedURL := common.URLStringExtension(rawURL).RedactSecretQueryParamForLogging()
+	WarnStdoutAndScanningLog(fmt.Sprintf("Failed to list %s due to error: %s", redactedURL, err))
+
+	errorCode := int32(0)
+	var storageErr azblob.StorageError
+	if err == errNoBlobAtUrl {
+		errorCode = http.StatusNotFound
+	} else if errors.As(err, &storageErr) && storageErr.Response() != nil {
+		errorCode = int32(storageErr.Response().StatusCode)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.failed = append(f.failed, common.TransferDetail{
+		Src:            redactedURL,
+		TransferStatus: common.ETransferStatus.Failed(),
+		ErrorCode:      errorCode,
+	})
+}
+
+// addTo adds the failures to a summary of the job. Like the engine, it only lists the failures that are new since the last
+// summary, but counts them all
+func (f *urlListFailures) addTo(summary *common.ListJobSummaryResponse) {
+	f.Lock()
+	defer f.Unlock()
+	if len(f.failed) == 0 {
+		return
+	}
+
+	summary.TotalTransfers += uint32(len(f.failed))
+	summary.TransfersFailed += uint32(len(f.failed))
+	summary.FailedTransfers = append(summary.FailedTransfers, f.failed[f.reported:]...)
+	f.reported = len(f.failed)
+
+	if summary.JobStatus.IsJobDone() && summary.JobStatus != common.EJobStatus.Cancelled() {
+		summary.JobStatus = summary.JobStatus.EnhanceJobStatusInfo(summary.TransfersSkipped > 0, true, summary.TransfersCompleted > 0)
+	}
+}
+
+// readListOfUrls streams the URLs in the given file, skipping blank lines and comments, like list-of-files does
+func readListOfUrls(fileName string) (chan string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s file passed with the list-of-urls flag", fileName)
+	}
+
+	// unbuffered so this reads as we need it to rather than all at once in bulk
+	urls := make(chan string)
+	go func() {
+		defer close(urls)
+		defer f.Close()
+
+		utf8BOM := string([]byte{0xEF, 0xBB, 0xBF})
+		scanner := bufio.NewScanner(f)
+		for isFirstLine := true; scanner.Scan(); isFirstLine = false {
+			line := strings.TrimSpace(scanner.Text())
+			if isFirstLine {
+				line = strings.TrimPrefix(line, utf8BOM)
+			}
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			urls <- line
+		}
+	}()
+	return urls, nil
+}
+
+// fromToForListOfUrls works out the direction of a copy whose sources are the blobs of list-of-urls.
+// Only the destination has to be inferred, since the sources are always in Blob storage
+func fromToForListOfUrls(dst string, userSpecifiedFromTo string) (common.FromTo, error) {
+	var fromTo common.FromTo
+	if userSpecifiedFromTo != "" {
+		if err := fromTo.Parse(userSpecifiedFromTo); err != nil {
+			return common.EFromTo.Unknown(), fmt.Errorf("invalid --from-to value specified: %q. "+fromToHelpText, userSpecifiedFromTo)
+		}
+	} else {
+		dstLocation := InferArgumentLocation(dst)
+		if dstLocation == common.ELocation.Unknown() {
+			return common.EFromTo.Unknown(), fmt.Errorf("cannot infer destination location of %s. Please specify the --from-to switch",
+				common.URLStringExtension(dst).RedactSecretQueryParamForLogging())
+		}
+		if err := fromTo.Parse(common.ELocation.Blob().String() + dstLocation.String()); err != nil {
+			return common.EFromTo.Unknown(), fmt.Errorf("list-of-urls cannot copy from Blob to %s", dstLocation)
+		}
+	}
+
+	switch fromTo {
+	case common.EFromTo.BlobLocal(), common.EFromTo.BlobBlob(), common.EFromTo.BlobFile():
+		return fromTo, nil
+	default:
+		return common.EFromTo.Unknown(), fmt.Errorf("list-of-urls can only copy from Blob to the local file system, Blob or Azure Files, not %s", fromTo)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type azureCloudSuite struct{}
+
+var _ = chk.Suite(&azureCloudSuite{})
+
+func (s *azureCloudSuite) TestCloudIsResolvedPerSide(c *chk.C) {
+	dir := c.MkDir()
+
+	// each side's cloud is inferred from the endpoint suffix of its own URL
+	raw := getDefaultRawCopyInput(dir, "https://account.blob.core.usgovcloudapi.net/container")
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.sourceCloud, chk.Equals, common.EAzureCloud.Unknown())
+	c.Assert(cooked.destinationCloud, chk.Equals, common.EAzureCloud.USGov())
+	c.Assert(cooked.destinationCloud.EndpointSuffix(), chk.Equals, "core.usgovcloudapi.net")
+	c.Assert(cooked.authenticationCloud().ActiveDirectoryEndpoint(), chk.Equals, "https://login.microsoftonline.us")
+
+	raw = getDefaultRawCopyInput("https://account.blob.core.chinacloudapi.cn/container", dir)
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.sourceCloud, chk.Equals, common.EAzureCloud.China())
+	c.Assert(cooked.authenticationCloud().ActiveDirectoryEndpoint(), chk.Equals, "https://login.chinacloudapi.cn")
+
+	// a custom domain has no suffix to infer from, so the flag decides
+	raw = getDefaultRawCopyInput(dir, "https://storage.contoso.com/container")
+	raw.fromTo = common.EFromTo.LocalBlob().String()
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.destinationCloud, chk.Equals, common.EAzureCloud.Unknown())
+	raw.destinationCloud = "usgov"
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.destinationCloud, chk.Equals, common.EAzureCloud.USGov())
+
+	// but it can't contradict a suffix
+	raw = getDefaultRawCopyInput(dir, "https://account.blob.core.windows.net/container")
+	raw.destinationCloud = "China"
+	_, err = raw.cook()
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "core.windows.net"), chk.Equals, true, chk.Commentf(err.Error()))
+
+	// and only applies to Azure locations
+	raw = getDefaultRawCopyInput(dir, "https://account.blob.core.windows.net/container")
+	raw.sourceCloud = "Public"
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "source-cloud only applies to .*")
+
+	raw = getDefaultRawCopyInput(dir, "https://account.blob.core.windows.net/container")
+	raw.destinationCloud = "Moon"
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "invalid destination-cloud 'Moon'.*")
+}
+
+func (s *azureCloudSuite) TestServiceToServiceCopyAcrossCloudsIsRefused(c *chk.C) {
+	raw := getDefaultRawCopyInput("https://account.blob.core.windows.net/container", "https://account.blob.core.usgovcloudapi.net/container")
+	_, err := raw.cook()
+	c.Assert(err, chk.ErrorMatches, "cannot copy from the Public cloud to the USGov cloud .*")
+
+	// a source outside Azure has no cloud, so any destination can copy from it
+	raw = getDefaultRawCopyInput("https://bucket.s3.amazonaws.com/", "https://account.blob.core.usgovcloudapi.net/container")
+	raw.recursive = true
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.sourceCloud, chk.Equals, common.EAzureCloud.Unknown())
+	c.Assert(cooked.authenticationCloud(), chk.Equals, common.EAzureCloud.USGov())
+
+	// and within a cloud, nothing changes
+	raw = getDefaultRawCopyInput("https://a.blob.core.chinacloudapi.cn/container", "https://b.blob.core.chinacloudapi.cn/container")
+	_, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+}
+
+func (s *azureCloudSuite) TestAutoLoginUsesTheAuthorityOfTheCloud(c *chk.C) {
+	names := []string{common.EEnvironmentVariable.AutoLoginType().Name, common.EEnvironmentVariable.AADEndpoint().Name}
+	original := map[string]string{}
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			original[name] = value
+		}
+		os.Unsetenv(name)
+	}
+	defer func() {
+		for _, name := range names {
+			os.Unsetenv(name)
+		}
+		for name, value := range original {
+			os.Setenv(name, value)
+		}
+	}()
+	os.Setenv(common.EEnvironmentVariable.AutoLoginType().Name, "SPN")
+
+	c.Assert(autoLoginCmdArgs(common.EAzureCloud.Public()).aadEndpoint, chk.Equals, common.DefaultActiveDirectoryEndpoint)
+	c.Assert(autoLoginCmdArgs(common.EAzureCloud.USGov()).aadEndpoint, chk.Equals, "https://login.microsoftonline.us")
+	c.Assert(autoLoginCmdArgs(common.EAzureCloud.China()).aadEndpoint, chk.Equals, "https://login.chinacloudapi.cn")
+	c.Assert(autoLoginCmdArgs(common.EAzureCloud.Unknown()).aadEndpoint, chk.Equals, "")
+
+	// an authority named in the environment wins, e.g. for Azure Stack
+	os.Setenv(common.EEnvironmentVariable.AADEndpoint().Name, "https://login.example")
+	c.Assert(autoLoginCmdArgs(common.EAzureCloud.USGov()).aadEndpoint, chk.Equals, "https://login.example")
+}
+
+func (s *azureCloudSuite) TestTokenFromAnotherCloudIsRefused(c *chk.C) {
+	public := common.OAuthTokenInfo{ActiveDirectoryEndpoint: common.DefaultActiveDirectoryEndpoint}
+	c.Assert(checkTokenCloud(public, common.EAzureCloud.Public(), "destination"), chk.IsNil)
+	c.Assert(checkTokenCloud(public, common.EAzureCloud.Unknown(), "destination"), chk.IsNil)
+	c.Assert(checkTokenCloud(public, common.EAzureCloud.USGov(), "destination"), chk.ErrorMatches,
+		"the OAuth token is from the authority of the Public cloud .* but the destination is in the USGov cloud.*login.microsoftonline.us.*")
+
+	// tokens from authorities of no known cloud, and those that don't record one, are left to the service
+	c.Assert(checkTokenCloud(common.OAuthTokenInfo{ActiveDirectoryEndpoint: "https://adfs.contoso.com/adfs"}, common.EAzureCloud.China(), "source"), chk.IsNil)
+	c.Assert(checkTokenCloud(common.OAuthTokenInfo{}, common.EAzureCloud.China(), "source"), chk.IsNil)
+}
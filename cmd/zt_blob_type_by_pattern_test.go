@@ -0,0 +1,57 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type blobTypeByPatternSuite struct{}
+
+var _ = chk.Suite(&blobTypeByPatternSuite{})
+
+func (s *blobTypeByPatternSuite) TestBlobTypeByPattern(c *chk.C) {
+	patterns, err := parseBlobTypeByPattern("*.log=AppendBlob;*.tar=BlockBlob;disk?.img=PageBlob;*=BlockBlob")
+	c.Assert(err, chk.IsNil)
+
+	cases := map[string]common.BlobType{
+		"app.log":   common.EBlobType.AppendBlob(),
+		"a.tar":     common.EBlobType.BlockBlob(),
+		"disk1.img": common.EBlobType.PageBlob(),
+		"disk.img":  common.EBlobType.BlockBlob(), // the first match wins, which here is the catch-all
+	}
+	for name, expected := range cases {
+		blobType, ok := blobTypeForName(patterns, name)
+		c.Assert(ok, chk.Equals, true)
+		c.Assert(blobType, chk.Equals, expected, chk.Commentf(name))
+	}
+
+	patterns, err = parseBlobTypeByPattern("*.log=AppendBlob")
+	c.Assert(err, chk.IsNil)
+	_, ok := blobTypeForName(patterns, "a.txt")
+	c.Assert(ok, chk.Equals, false)
+
+	for _, invalid := range []string{"*.log", "=AppendBlob", "*.log=Appendix", "*.log=Detect", "[.log=BlockBlob"} {
+		_, err = parseBlobTypeByPattern(invalid)
+		c.Assert(err, chk.NotNil, chk.Commentf(invalid))
+	}
+}
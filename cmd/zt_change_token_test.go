@@ -0,0 +1,108 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type changeTokenSuite struct{}
+
+var _ = chk.Suite(&changeTokenSuite{})
+
+func (s *changeTokenSuite) TestOnlyFilesChangedSinceTheTokenAreProcessed(c *chk.C) {
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	file := func(path string, seconds int) StoredObject {
+		return StoredObject{relativePath: path, entityType: common.EEntityType.File(), lastModifiedTime: base.Add(time.Duration(seconds) * time.Second)}
+	}
+	run := func(since *changeToken, objects ...StoredObject) ([]string, changeToken) {
+		var processed []string
+		tracker := newChangeTracker(since)
+		processor := tracker.wrap(func(object StoredObject) error {
+			processed = append(processed, object.relativePath)
+			return nil
+		})
+		for _, object := range objects {
+			c.Assert(processor(object), chk.IsNil)
+		}
+		return processed, tracker.next("source")
+	}
+
+	// without a token, everything goes through, and the token records the newest files
+	processed, token := run(nil, file("a", 1), file("b", 2), file("c", 2))
+	c.Assert(processed, chk.DeepEquals, []string{"a", "b", "c"})
+	c.Assert(token, chk.DeepEquals, changeToken{Source: "source", MaxLastModified: base.Add(2 * time.Second), FilesAtMax: []string{"/b", "/c"}})
+
+	// a file written in the same second as the newest, after the token was made, still goes through, as do folders
+	processed, token = run(&token, file("a", 1), file("b", 2), file("c", 2), file("d", 2),
+		StoredObject{relativePath: "dir", entityType: common.EEntityType.Folder()})
+	c.Assert(processed, chk.DeepEquals, []string{"d", "dir"})
+	c.Assert(token.FilesAtMax, chk.DeepEquals, []string{"/b", "/c", "/d"}, chk.Commentf("files at the same time as the token are added to it"))
+
+	// nothing changed, so the token stays as it was
+	processed, next := run(&token, file("a", 1), file("b", 2), file("c", 2), file("d", 2))
+	c.Assert(processed, chk.HasLen, 0)
+	c.Assert(next, chk.DeepEquals, token)
+
+	// a newer file moves the token on
+	processed, token = run(&token, file("a", 3), file("b", 2))
+	c.Assert(processed, chk.DeepEquals, []string{"a"})
+	c.Assert(token, chk.DeepEquals, changeToken{Source: "source", MaxLastModified: base.Add(3 * time.Second), FilesAtMax: []string{"/a"}})
+}
+
+func (s *changeTokenSuite) TestACopyWithNothingChangedSucceedsAndKeepsTheToken(c *chk.C) {
+	defer func(previous common.LifecycleMgr) { glcm = previous }(glcm)
+	mockedLcm := &mockedLifecycleManager{exitLog: make(chan string, 1)}
+	glcm = mockedLcm
+
+	dir := c.MkDir()
+	since := changeToken{Source: "source", MaxLastModified: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), FilesAtMax: []string{"/a"}}
+	cca := &CookedCopyCmdArgs{
+		Source:               common.ResourceString{Value: "source"},
+		sinceChangeTokenPath: filepath.Join(dir, "since.json"),
+		changeTokenPath:      filepath.Join(dir, "next.json"),
+		changes:              newChangeTracker(&since),
+	}
+	cca.quitIfUnchangedSinceToken()
+
+	select {
+	case message := <-mockedLcm.exitLog:
+		c.Assert(strings.Contains(message, "nothing to transfer"), chk.Equals, true, chk.Commentf(message))
+	default:
+		c.Fatal("the copy should have exited")
+	}
+	raw, err := ioutil.ReadFile(cca.changeTokenPath)
+	c.Assert(err, chk.IsNil)
+	var saved changeToken
+	c.Assert(json.Unmarshal(raw, &saved), chk.IsNil)
+	c.Assert(saved, chk.DeepEquals, since, chk.Commentf("the token should be saved as it was"))
+
+	// without a token to go by, scheduling nothing is still an error for the caller to report
+	cca.changes = newChangeTracker(nil)
+	cca.quitIfUnchangedSinceToken()
+	c.Assert(mockedLcm.exitLog, chk.HasLen, 0)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type contentTypeMapSuite struct{}
+
+var _ = chk.Suite(&contentTypeMapSuite{})
+
+func writeContentTypeMap(c *chk.C, name string, content string) string {
+	dir, err := ioutil.TempDir("", "AzCopyContentTypeMap")
+	c.Assert(err, chk.IsNil)
+	fileName := filepath.Join(dir, name)
+	c.Assert(ioutil.WriteFile(fileName, []byte(content), 0644), chk.IsNil)
+	return fileName
+}
+
+func (s *contentTypeMapSuite) TestJSONAndCSVMapsAreRead(c *chk.C) {
+	expected := map[string]string{".md": "text/markdown", ".dat": "application/x-dat", ".jsonl": "application/jsonl"}
+
+	jsonFile := writeContentTypeMap(c, "map.json", `{".md": "text/markdown", "DAT": "application/x-dat", ".jsonl": "application/jsonl"}`)
+	defer os.RemoveAll(filepath.Dir(jsonFile))
+	m, err := loadContentTypeMap(jsonFile)
+	c.Assert(err, chk.IsNil)
+	c.Assert(m, chk.DeepEquals, expected)
+
+	csvFile := writeContentTypeMap(c, "map.CSV", "extension,content-type\n.md, text/markdown\n\ndat,application/x-dat\n.jsonl,application/jsonl\n")
+	defer os.RemoveAll(filepath.Dir(csvFile))
+	m, err = loadContentTypeMap(csvFile)
+	c.Assert(err, chk.IsNil)
+	c.Assert(m, chk.DeepEquals, expected)
+}
+
+func (s *contentTypeMapSuite) TestMalformedEntriesAreReported(c *chk.C) {
+	cases := map[string]struct{ name, content, errorPattern string }{
+		"not JSON":          {"map.json", `.md=text/markdown`, "cannot parse content-type-map .*JSON object.*"},
+		"wrong field count": {"map.csv", ".md,text/markdown\n.txt\n", ".*line 2 has 1 fields.*"},
+		"bad extension":     {"map.csv", ".tar.gz,application/gzip\n", "invalid extension '.tar.gz'.*line 1.*"},
+		"empty extension":   {"map.json", `{"": "text/plain"}`, "invalid extension ''.*"},
+		"bad content type":  {"map.csv", ".md,markdown\n", "invalid content type for '.md'.*line 1.*type/subtype.*"},
+		"conflict":          {"map.csv", ".md,text/markdown\n.MD,text/plain\n", ".*gives '.md' two content types.*line 2.*"},
+		"no entries":        {"map.json", `{}`, ".*has no entries"},
+	}
+	for name, tc := range cases {
+		fileName := writeContentTypeMap(c, tc.name, tc.content)
+		_, err := loadContentTypeMap(fileName)
+		os.RemoveAll(filepath.Dir(fileName))
+		c.Assert(err, chk.ErrorMatches, tc.errorPattern, chk.Commentf(name))
+	}
+
+	_, err := loadContentTypeMap(filepath.Join(os.TempDir(), "AzCopyNoSuchContentTypeMap.json"))
+	c.Assert(err, chk.ErrorMatches, "cannot read content-type-map.*")
+}
@@ -174,7 +174,7 @@ func (s *cmdIntegrationSuite) TestDownloadAccount(c *chk.C) {
 
 	// Traverse the account ahead of time and determine the relative paths for testing.
 	relPaths := make([]string, 0) // Use a map for easy lookup
-	blobTraverser := newBlobAccountTraverser(&rawBSU, p, ctx, false, func(common.EntityType) {}, false, common.CpkOptions{})
+	blobTraverser := newBlobAccountTraverser(&rawBSU, p, ctx, false, func(common.EntityType) {}, false, common.CpkOptions{}, nil)
 	processor := func(object StoredObject) error {
 		// Append the container name to the relative path
 		relPath := "/" + object.ContainerName + "/" + object.relativePath
@@ -222,7 +222,7 @@ func (s *cmdIntegrationSuite) TestDownloadAccountWildcard(c *chk.C) {
 
 	// Traverse the account ahead of time and determine the relative paths for testing.
 	relPaths := make([]string, 0) // Use a map for easy lookup
-	blobTraverser := newBlobAccountTraverser(&rawBSU, p, ctx, false, func(common.EntityType) {}, false, common.CpkOptions{})
+	blobTraverser := newBlobAccountTraverser(&rawBSU, p, ctx, false, func(common.EntityType) {}, false, common.CpkOptions{}, nil)
 	processor := func(object StoredObject) error {
 		// Append the container name to the relative path
 		relPath := "/" + object.ContainerName + "/" + object.relativePath
@@ -488,7 +488,7 @@ func (s *cmdIntegrationSuite) TestDownloadBlobContainerWithRegexInclude(c *chk.C
 	containerString := strings.ReplaceAll(rawContainerURLWithSAS.String(), "%00", "*")
 	raw := getDefaultCopyRawInput(containerString, dstDirName)
 	raw.recursive = true
-	raw.includeRegex = "es{4,}"
+	raw.includeRegex = ".*es{4,}.*"
 
 	runCopyAndVerify(c, raw, func(err error) {
 		c.Assert(err, chk.IsNil)
@@ -539,7 +539,7 @@ func (s *cmdIntegrationSuite) TestDownloadBlobContainerWithMultRegexInclude(c *c
 	containerString := strings.ReplaceAll(rawContainerURLWithSAS.String(), "%00", "*")
 	raw := getDefaultCopyRawInput(containerString, dstDirName)
 	raw.recursive = true
-	raw.includeRegex = "es{4,};^zxc"
+	raw.includeRegex = ".*es{4,}.*;zxc.*"
 
 	runCopyAndVerify(c, raw, func(err error) {
 		c.Assert(err, chk.IsNil)
@@ -632,7 +632,7 @@ func (s *cmdIntegrationSuite) TestDownloadBlobContainerWithRegexExclude(c *chk.C
 	containerString := strings.ReplaceAll(rawContainerURLWithSAS.String(), "%00", "*")
 	raw := getDefaultCopyRawInput(containerString, dstDirName)
 	raw.recursive = true
-	raw.excludeRegex = "es{4,}"
+	raw.excludeRegex = ".*es{4,}.*"
 
 	runCopyAndVerify(c, raw, func(err error) {
 		c.Assert(err, chk.IsNil)
@@ -683,7 +683,7 @@ func (s *cmdIntegrationSuite) TestDownloadBlobContainerWithMultRegexExclude(c *c
 	containerString := strings.ReplaceAll(rawContainerURLWithSAS.String(), "%00", "*")
 	raw := getDefaultCopyRawInput(containerString, dstDirName)
 	raw.recursive = true
-	raw.excludeRegex = "es{4,};o(g)"
+	raw.excludeRegex = ".*es{4,}.*;.*o(g).*"
 
 	runCopyAndVerify(c, raw, func(err error) {
 		c.Assert(err, chk.IsNil)
@@ -0,0 +1,149 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type pipeProgressSuite struct{}
+
+var _ = chk.Suite(&pipeProgressSuite{})
+
+func (s *pipeProgressSuite) TestPipeProgressWithoutTotal(c *chk.C) {
+	progress := newPipeProgress(-1)
+	n, err := ioutil.ReadAll(progress.countReads(bytes.NewReader(make([]byte, 3*1024*1024))))
+	c.Assert(err, chk.IsNil)
+	c.Assert(len(n), chk.Equals, 3*1024*1024)
+
+	summary := progress.summary()
+	c.Assert(summary.BytesTransferred, chk.Equals, uint64(3*1024*1024))
+	c.Assert(summary.TotalBytesKnown, chk.Equals, false)
+	c.Assert(summary.PercentComplete, chk.Equals, float32(0))
+
+	// with no total, there's no percentage to show
+	text := progress.builder(false)(common.EOutputFormat.Text())
+	c.Assert(strings.HasPrefix(text, "3.00 MiB piped, Throughput (Mb/s): "), chk.Equals, true, chk.Commentf(text))
+
+	var parsed common.PipeProgress
+	c.Assert(json.Unmarshal([]byte(progress.builder(false)(common.EOutputFormat.Json())), &parsed), chk.IsNil)
+	c.Assert(parsed.BytesTransferred, chk.Equals, uint64(3*1024*1024))
+	c.Assert(parsed.TotalBytesKnown, chk.Equals, false)
+}
+
+func (s *pipeProgressSuite) TestPipeProgressWithTotal(c *chk.C) {
+	progress := newPipeProgress(-1)
+	progress.setTotal(4 * 1024 * 1024) // learnt once the download has started
+	_, err := ioutil.ReadAll(progress.countReads(bytes.NewReader(make([]byte, 1024*1024))))
+	c.Assert(err, chk.IsNil)
+
+	summary := progress.summary()
+	c.Assert(summary.TotalBytesKnown, chk.Equals, true)
+	c.Assert(summary.TotalBytesExpected, chk.Equals, uint64(4*1024*1024))
+	c.Assert(summary.PercentComplete, chk.Equals, float32(25))
+
+	text := progress.builder(false)(common.EOutputFormat.Text())
+	c.Assert(strings.HasPrefix(text, "25.0 %, 1.00 MiB of 4.00 MiB piped"), chk.Equals, true, chk.Commentf(text))
+	final := progress.builder(true)(common.EOutputFormat.Text())
+	c.Assert(strings.HasPrefix(final, "Piped 1.00 MiB in "), chk.Equals, true, chk.Commentf(final))
+
+	// an empty blob is complete as soon as it starts
+	empty := newPipeProgress(0)
+	c.Assert(empty.summary().PercentComplete, chk.Equals, float32(100))
+}
+
+func (s *cmdIntegrationSuite) TestPipeUploadAndDownload(c *chk.C) {
+	bsu := getBSU()
+	containerURL, containerName := createNewContainer(c, bsu)
+	defer deleteContainer(c, containerURL)
+
+	blobName := generateBlobName()
+	rawBlobURLWithSAS := scenarioHelper{}.getRawBlobURLWithSAS(c, containerName, blobName)
+	blobResource, err := SplitResourceString(rawBlobURLWithSAS.String(), common.ELocation.Blob())
+	c.Assert(err, chk.IsNil)
+
+	// more than a block, and not a whole number of them, so that the stream is uploaded in several blocks
+	data := make([]byte, 2*1024*1024+12345)
+	_, err = rand.Read(data)
+	c.Assert(err, chk.IsNil)
+
+	// upload from a stream, whose size isn't known
+	cca := CookedCopyCmdArgs{FromTo: common.EFromTo.PipeBlob(), blockSize: 1024 * 1024}
+	uploadProgress := newPipeProgress(-1)
+	err = cca.processRedirectionUpload(blobResource, cca.blockSize, bytes.NewReader(data), uploadProgress)
+	c.Assert(err, chk.IsNil)
+	c.Assert(uploadProgress.summary().BytesTransferred, chk.Equals, uint64(len(data)))
+
+	blobURL := containerURL.NewBlockBlobURL(blobName)
+	resp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.IsNil)
+	uploaded, err := ioutil.ReadAll(resp.Body(azblob.RetryReaderOptions{}))
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(uploaded, data), chk.Equals, true)
+
+	blockList, err := blobURL.GetBlockList(ctx, azblob.BlockListCommitted, azblob.LeaseAccessConditions{})
+	c.Assert(err, chk.IsNil)
+	c.Assert(len(blockList.CommittedBlocks), chk.Equals, 3)
+
+	// and download it into a buffer, rather than stdout
+	cca = CookedCopyCmdArgs{FromTo: common.EFromTo.BlobPipe()}
+	var buffer bytes.Buffer
+	downloadProgress := newPipeProgress(-1)
+	err = cca.processRedirectionDownload(blobResource, &buffer, downloadProgress)
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), data), chk.Equals, true)
+
+	summary := downloadProgress.summary()
+	c.Assert(summary.TotalBytesKnown, chk.Equals, true)
+	c.Assert(summary.TotalBytesExpected, chk.Equals, uint64(len(data)))
+	c.Assert(summary.PercentComplete, chk.Equals, float32(100))
+}
+
+func (s *pipeProgressSuite) TestOnlyTheDashFormReportsProgress(c *chk.C) {
+	defer func(previous common.LifecycleMgr) { glcm = previous }(glcm)
+
+	for _, stdio := range []bool{false, true} {
+		mockedLcm := mockedLifecycleManager{}
+		mockedLcm.SetOutputFormat(common.EOutputFormat.Text())
+		glcm = &mockedLcm
+
+		raw := getDefaultCopyRawInput(pipeLocation, "https://account.blob.core.windows.net/container/blob")
+		raw.fromTo = common.EFromTo.PipeBlob().String()
+		raw.stdio = stdio
+		_, err := raw.cook()
+		c.Assert(err, chk.IsNil)
+
+		// --from-to PipeBlob has always been silent, so it stays that way
+		expected := common.EOutputFormat.None()
+		if stdio {
+			expected = common.EOutputFormat.Text()
+		}
+		c.Assert(mockedLcm.outputFormat, chk.Equals, expected, chk.Commentf("given as -: %v", stdio))
+	}
+}
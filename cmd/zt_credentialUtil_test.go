@@ -22,6 +22,8 @@ package cmd
 
 import (
 	"context"
+	"net/url"
+	"os"
 	"strings"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -61,6 +63,7 @@ func (s *credentialUtilSuite) TestCheckAuthSafeForTarget(c *chk.C) {
 		{common.ECredentialType.S3AccessKey(), common.ELocation.S3(), "http://s3.cn-north-1.amazonaws.com.cn", "", true},
 		{common.ECredentialType.S3AccessKey(), common.ELocation.S3(), "http://s3.amazonaws.com", "", true},
 		{common.ECredentialType.GoogleAppCredentials(), common.ELocation.GCP(), "http://storage.cloud.google.com", "", true},
+		{common.ECredentialType.GoogleHMACKey(), common.ELocation.GCP(), "http://storage.cloud.google.com", "", true},
 
 		// These should fail (they are not storage)
 		{common.ECredentialType.OAuthToken(), common.ELocation.Blob(), "http://somethingelseinazure.windows.net", "", false},
@@ -76,6 +79,7 @@ func (s *credentialUtilSuite) TestCheckAuthSafeForTarget(c *chk.C) {
 		// Test that we don't want to send an S3 access key to a blob resource type.
 		{common.ECredentialType.S3AccessKey(), common.ELocation.Blob(), "http://abc.example.com", "", false},
 		{common.ECredentialType.GoogleAppCredentials(), common.ELocation.Blob(), "http://abc.example.com", "", false},
+		{common.ECredentialType.GoogleHMACKey(), common.ELocation.Blob(), "http://abc.example.com", "", false},
 
 		// But the same Azure one should pass if the user opts in to them (we don't support any similar override for S3)
 		{common.ECredentialType.OAuthToken(), common.ELocation.Blob(), "http://abc.example.com", "*.foo.com;*.example.com", true},
@@ -115,3 +119,116 @@ func (s *credentialUtilSuite) TestCheckAuthSafeForTargetIsCalledWhenGettingAuthT
 	c.Assert(strings.Contains(err.Error(), "If this URL is in fact an Azure service, you can enable Azure authentication to notblob.example.com."),
 		chk.Equals, true)
 }
+
+func (s *credentialUtilSuite) TestDefaultLoginChoosesFromEnvironment(c *chk.C) {
+	names := []string{"AZURE_CLIENT_ID", "AZURE_TENANT_ID", "AZURE_AUTHORITY_HOST", "AZURE_FEDERATED_TOKEN_FILE",
+		"AZURE_CLIENT_SECRET", "AZURE_CLIENT_CERTIFICATE_PATH", "AZCOPY_MSI_CLIENT_ID"}
+	setEnv := func(values map[string]string) {
+		for _, name := range names {
+			os.Unsetenv(name)
+		}
+		for name, value := range values {
+			os.Setenv(name, value)
+		}
+	}
+	original := map[string]string{}
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			original[name] = value
+		}
+	}
+	defer setEnv(original)
+
+	// a secret means a service principal
+	setEnv(map[string]string{"AZURE_CLIENT_ID": "app", "AZURE_TENANT_ID": "tenant", "AZURE_CLIENT_SECRET": "secret", "AZURE_FEDERATED_TOKEN_FILE": "/token"})
+	lca := defaultLoginCmdArgs(loginCmdArgs{})
+	c.Assert(lca.servicePrincipal, chk.Equals, true)
+	c.Assert(lca.workloadIdentity, chk.Equals, false)
+	c.Assert(lca.applicationID, chk.Equals, "app")
+	c.Assert(lca.tenantID, chk.Equals, "tenant")
+	c.Assert(lca.clientSecret, chk.Equals, "secret")
+
+	// a federated token file means workload identity
+	setEnv(map[string]string{"AZURE_CLIENT_ID": "app", "AZURE_TENANT_ID": "tenant", "AZURE_FEDERATED_TOKEN_FILE": "/token", "AZURE_AUTHORITY_HOST": "https://login.example/"})
+	lca = defaultLoginCmdArgs(loginCmdArgs{})
+	c.Assert(lca.workloadIdentity, chk.Equals, true)
+	c.Assert(lca.applicationID, chk.Equals, "app")
+	c.Assert(lca.federatedTokenFile, chk.Equals, "/token")
+	c.Assert(lca.aadEndpoint, chk.Equals, "https://login.example")
+	c.Assert(lca.validate(), chk.IsNil)
+
+	// and with neither, it's the managed identity, which has no use for a tenant
+	setEnv(map[string]string{"AZURE_TENANT_ID": "tenant", "AZCOPY_MSI_CLIENT_ID": "msi"})
+	lca = defaultLoginCmdArgs(loginCmdArgs{tenantID: "from-config"})
+	c.Assert(lca.identity, chk.Equals, true)
+	c.Assert(lca.identityClientID, chk.Equals, "msi")
+	c.Assert(lca.tenantID, chk.Equals, "")
+	c.Assert(lca.validate(), chk.IsNil)
+}
+
+func (s *credentialUtilSuite) TestAutoLoginIsRefusedForAzureFiles(c *chk.C) {
+	name := common.EEnvironmentVariable.AutoLoginType().Name
+	if original, ok := os.LookupEnv(name); ok {
+		defer os.Setenv(name, original)
+	} else {
+		defer os.Unsetenv(name)
+	}
+	os.Setenv(name, "DEFAULT")
+
+	_, _, err := doGetCredentialTypeForLocation(context.Background(), common.ELocation.File(),
+		"https://myaccount.file.core.windows.net/share/file", "", true, func() common.CredentialType { return common.ECredentialType.Unknown() }, common.CpkOptions{})
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "SAS"), chk.Equals, true)
+}
+
+func (s *credentialUtilSuite) TestGCPCredentialTypeFromEnvironment(c *chk.C) {
+	names := []string{common.EEnvironmentVariable.GoogleAppCredentials().Name,
+		common.EEnvironmentVariable.GoogleHMACAccessID().Name, common.EEnvironmentVariable.GoogleHMACSecret().Name}
+	setEnv := func(values map[string]string) {
+		for _, name := range names {
+			os.Unsetenv(name)
+		}
+		for name, value := range values {
+			os.Setenv(name, value)
+		}
+	}
+	original := map[string]string{}
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			original[name] = value
+		}
+	}
+	defer setEnv(original)
+
+	getCredType := func() (common.CredentialType, error) {
+		credType, _, err := doGetCredentialTypeForLocation(context.Background(), common.ELocation.GCP(), "https://storage.cloud.google.com/bucket/object",
+			"", true, func() common.CredentialType { return common.ECredentialType.Unknown() }, common.CpkOptions{})
+		return credType, err
+	}
+
+	// an HMAC key is used when there's no service account key
+	setEnv(map[string]string{names[1]: "GOOG1EXAMPLE", names[2]: "secret"})
+	credType, err := getCredType()
+	c.Assert(err, chk.IsNil)
+	c.Assert(credType, chk.Equals, common.ECredentialType.GoogleHMACKey())
+
+	// but the service account key takes precedence over it
+	setEnv(map[string]string{names[0]: "/key.json", names[1]: "GOOG1EXAMPLE", names[2]: "secret"})
+	credType, err = getCredType()
+	c.Assert(err, chk.IsNil)
+	c.Assert(credType, chk.Equals, common.ECredentialType.GoogleAppCredentials())
+
+	// and half an HMAC key is no key at all
+	setEnv(map[string]string{names[1]: "GOOG1EXAMPLE"})
+	_, err = getCredType()
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "GOOGLE_HMAC_SECRET"), chk.Equals, true)
+
+	// the traverser for an HMAC key reads the bucket through the XML API, rather than with the JSON API's client
+	setEnv(map[string]string{names[1]: "GOOG1EXAMPLE", names[2]: "secret"})
+	rawURL, _ := url.Parse("https://storage.cloud.google.com/bucket/dir/")
+	t, err := newGCPTraverser(common.ECredentialType.GoogleHMACKey(), rawURL, context.Background(), true, false, func(common.EntityType) {})
+	c.Assert(err, chk.IsNil)
+	c.Assert(t.hmacClient, chk.NotNil)
+	c.Assert(t.gcpClient, chk.IsNil)
+}
@@ -0,0 +1,74 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type destinationTimeTokensSuite struct{}
+
+var _ = chk.Suite(&destinationTimeTokensSuite{})
+
+func (s *destinationTimeTokensSuite) TestTokensAreExpandedInThePathOnly(c *chk.C) {
+	at := time.Date(2023, time.March, 7, 4, 5, 6, 0, time.UTC)
+
+	cases := []struct {
+		destination string
+		location    common.Location
+		expected    string
+	}{
+		{"/backups/{yyyy}/{MM}/{dd}", common.ELocation.Local(), "/backups/2023/03/07"},
+		{"/backups/{yy}{MM}{dd}-{HH}{mm}{ss}.bak", common.ELocation.Local(), "/backups/230307-040506.bak"},
+		{"/backups/plain", common.ELocation.Local(), "/backups/plain"},
+		{"https://a.blob.core.windows.net/c/{yyyy}/{MM}?sv=x&sig=y", common.ELocation.Blob(), "https://a.blob.core.windows.net/c/2023/03?sv=x&sig=y"},
+		{"https://a.blob.core.windows.net/c/%7Byyyy%7D/%7bdd%7d", common.ELocation.Blob(), "https://a.blob.core.windows.net/c/2023/07"},
+		{"https://a.blob.core.windows.net/c/{dd}?comment={ignored}", common.ELocation.Blob(), "https://a.blob.core.windows.net/c/07?comment={ignored}"},
+	}
+	for _, tc := range cases {
+		expanded, err := expandDestinationTimeTokens(tc.destination, tc.location, at)
+		c.Assert(err, chk.IsNil, chk.Commentf(tc.destination))
+		c.Assert(expanded, chk.Equals, tc.expected)
+	}
+}
+
+func (s *destinationTimeTokensSuite) TestTheTimeIsInUTC(c *chk.C) {
+	at := time.Date(2023, time.March, 7, 23, 30, 0, 0, time.FixedZone("behind UTC", -2*60*60))
+	expanded, err := expandDestinationTimeTokens("/backups/{dd}/{HH}", common.ELocation.Local(), at)
+	c.Assert(err, chk.IsNil)
+	c.Assert(expanded, chk.Equals, "/backups/08/01")
+}
+
+func (s *destinationTimeTokensSuite) TestUnknownOrUnbalancedTokensAreRefused(c *chk.C) {
+	for _, destination := range []string{
+		"/backups/{YYYY}",
+		"/backups/{week}",
+		"/backups/{}",
+		"/backups/{yyyy",
+		"/backups/yyyy}",
+		"/backups/{{yyyy}}",
+	} {
+		_, err := expandDestinationTimeTokens(destination, common.ELocation.Local(), time.Now())
+		c.Assert(err, chk.NotNil, chk.Commentf(destination))
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"path"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type emptyDirectoryMarkersSuite struct{}
+
+var _ = chk.Suite(&emptyDirectoryMarkersSuite{})
+
+// markObjects runs objects through the markers of a copy with fromTo, in the given order, and returns the destination
+// path of each object that is passed on, by its source path. Those with no destination path of their own are given
+// their source path, and folders end with a slash
+func markObjects(c *chk.C, fromTo common.FromTo, objects []StoredObject) map[string]string {
+	passedOn := make(map[string]string)
+	processor := func(object StoredObject) error {
+		destination := object.relativePath
+		if object.emptyDirectoryDestination != nil {
+			destination = *object.emptyDirectoryDestination
+		}
+		if object.entityType == common.EEntityType.Folder() {
+			destination += "/"
+		}
+		passedOn[object.relativePath] = destination
+		return nil
+	}
+	finalized := false
+	hold, release := newEmptyDirectoryMarkers("keep", fromTo).wrap(processor, func() error {
+		finalized = true
+		return nil
+	})
+	for _, object := range objects {
+		c.Assert(hold(object), chk.IsNil)
+	}
+	c.Assert(release(), chk.IsNil)
+	c.Assert(finalized, chk.Equals, true)
+	return passedOn
+}
+
+func (s *emptyDirectoryMarkersSuite) TestUploadMarksOnlyEmptyFolders(c *chk.C) {
+	folder := func(relativePath string) StoredObject {
+		return StoredObject{relativePath: relativePath, entityType: common.EEntityType.Folder()}
+	}
+	file := func(relativePath string) StoredObject {
+		return StoredObject{relativePath: relativePath, entityType: common.EEntityType.File()}
+	}
+
+	c.Assert(markObjects(c, common.EFromTo.LocalBlob(), []StoredObject{
+		folder(""),
+		folder("a"),
+		folder("a/empty"),
+		file("a/b/report.txt"), // listed before its folder
+		folder("a/b"),
+		folder("c"),
+		file("top.txt"),
+	}), chk.DeepEquals, map[string]string{
+		"a/empty":        "a/empty/keep/",
+		"a/b/report.txt": "a/b/report.txt",
+		"c":              "c/keep/",
+		"top.txt":        "top.txt",
+	})
+
+	// an empty source folder has a marker of its own
+	c.Assert(markObjects(c, common.EFromTo.LocalBlob(), []StoredObject{folder("")}), chk.DeepEquals, map[string]string{"": "keep/"})
+}
+
+func (s *emptyDirectoryMarkersSuite) TestDownloadTurnsMarkersIntoFolders(c *chk.C) {
+	blob := func(relativePath string, entityType common.EntityType, size int64) StoredObject {
+		return StoredObject{name: path.Base(relativePath), relativePath: relativePath, entityType: entityType, size: size}
+	}
+
+	c.Assert(markObjects(c, common.EFromTo.BlobLocal(), []StoredObject{
+		blob("keep", common.EEntityType.Folder(), 0),
+		blob("a/empty/keep", common.EEntityType.Folder(), 0),
+		blob("a/other/keep", common.EEntityType.File(), 0), // written without hdi_isfolder
+		blob("a/keep/report.txt", common.EEntityType.File(), 10),
+		blob("a/b/keep", common.EEntityType.File(), 10), // a file that only has the marker's name
+		blob("stub", common.EEntityType.Folder(), 0),
+	}), chk.DeepEquals, map[string]string{
+		"keep":              "/",
+		"a/empty/keep":      "a/empty/",
+		"a/other/keep":      "a/other/",
+		"a/keep/report.txt": "a/keep/report.txt",
+		"a/b/keep":          "a/b/keep",
+		"stub":              "stub/",
+	})
+}
+
+func (s *emptyDirectoryMarkersSuite) TestMarkerMustBeAName(c *chk.C) {
+	c.Assert(validateEmptyDirectoryMarker(defaultEmptyDirectoryMarker), chk.IsNil)
+	c.Assert(validateEmptyDirectoryMarker(".keep"), chk.IsNil)
+	for _, marker := range []string{"", ".", "..", "a/keep", `a\keep`} {
+		c.Assert(validateEmptyDirectoryMarker(marker), chk.NotNil, chk.Commentf("marker %q", marker))
+	}
+}
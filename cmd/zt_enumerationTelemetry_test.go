@@ -0,0 +1,126 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type enumerationTelemetrySuite struct{}
+
+var _ = chk.Suite(&enumerationTelemetrySuite{})
+
+// makeTelemetryTree makes a tree whose directories have known numbers of entries
+func makeTelemetryTree(c *chk.C) string {
+	root := c.MkDir()
+	for _, dir := range []string{"d1/d2", "empty"} {
+		c.Assert(os.MkdirAll(filepath.Join(root, dir), 0755), chk.IsNil)
+	}
+	for _, file := range []string{"a.txt", "b.txt", "d1/c.txt", "d1/d2/e.txt", "d1/d2/f.txt"} {
+		c.Assert(os.WriteFile(filepath.Join(root, file), []byte("x"), 0644), chk.IsNil)
+	}
+	return root
+}
+
+func (s *enumerationTelemetrySuite) TestEachListedDirectoryIsRecorded(c *chk.C) {
+	root := makeTelemetryTree(c)
+	path := filepath.Join(c.MkDir(), "telemetry.jsonl")
+
+	telemetry, err := newEnumerationTelemetry(path)
+	c.Assert(err, chk.IsNil)
+	traverser := newLocalTraverser(telemetry.attach(context.Background(), "source"), root, true, common.ESymlinkHandlingType.Skip(), "", nil, nil)
+	c.Assert(traverser.Traverse(noPreProccessor, func(StoredObject) error { return nil }, nil), chk.IsNil)
+	telemetry.close()
+
+	f, err := os.Open(path)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+	records := map[string]directoryListingRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record directoryListingRecord
+		c.Assert(json.Unmarshal(scanner.Bytes(), &record), chk.IsNil)
+		rel, err := filepath.Rel(root, record.Directory)
+		c.Assert(err, chk.IsNil)
+		records[filepath.ToSlash(rel)] = record
+	}
+
+	expected := map[string][2]int{ // entries, and directories among them
+		".":     {4, 2},
+		"d1":    {2, 1},
+		"d1/d2": {2, 0},
+		"empty": {0, 0},
+	}
+	c.Assert(records, chk.HasLen, len(expected))
+	for dir, counts := range expected {
+		record, ok := records[dir]
+		c.Assert(ok, chk.Equals, true, chk.Commentf(dir))
+		c.Assert(record.Side, chk.Equals, "source")
+		c.Assert(record.Entries, chk.Equals, counts[0], chk.Commentf(dir))
+		c.Assert(record.Directories, chk.Equals, counts[1], chk.Commentf(dir))
+		c.Assert(record.DurationMs >= 0, chk.Equals, true)
+		c.Assert(record.Start.IsZero(), chk.Equals, false)
+		c.Assert(record.Worker >= 0 && record.Worker < EnumerationParallelism, chk.Equals, true)
+		c.Assert(record.Error, chk.Equals, "")
+	}
+}
+
+func (s *enumerationTelemetrySuite) TestRecordsCanGoToTheLog(c *chk.C) {
+	root := makeTelemetryTree(c)
+
+	var mu sync.Mutex
+	var lines []string
+	telemetry := &enumerationTelemetry{log: func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, msg)
+	}}
+	traverser := newLocalTraverser(telemetry.attach(context.Background(), "destination"), root, true, common.ESymlinkHandlingType.Skip(), "", nil, nil)
+	c.Assert(traverser.Traverse(noPreProccessor, func(StoredObject) error { return nil }, nil), chk.IsNil)
+
+	c.Assert(lines, chk.HasLen, 4)
+	for _, line := range lines {
+		c.Assert(strings.HasPrefix(line, "Enumeration telemetry: listed destination directory '"), chk.Equals, true, chk.Commentf(line))
+	}
+}
+
+func (s *enumerationTelemetrySuite) TestTelemetryIsOffByDefault(c *chk.C) {
+	telemetry, err := startEnumerationTelemetry("")
+	c.Assert(err, chk.IsNil)
+	c.Assert(telemetry, chk.IsNil)
+
+	ctx := context.Background()
+	c.Assert(telemetry.attach(ctx, "source"), chk.Equals, ctx)
+
+	value, err := enumerationTelemetryFromFlag("log")
+	c.Assert(err, chk.IsNil)
+	c.Assert(value, chk.Equals, "log")
+	_, err = enumerationTelemetryFromFlag(filepath.Join(c.MkDir(), "missing", "telemetry.jsonl"))
+	c.Assert(err, chk.NotNil)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type flattenerSuite struct{}
+
+var _ = chk.Suite(&flattenerSuite{})
+
+// flattenObjects runs objects through a flattener, in the given order, and returns the destination name of each file
+// that is passed on, by its source path
+func flattenObjects(c *chk.C, policy common.FlattenCollisionPolicy, objects []StoredObject) (map[string]string, error) {
+	passedOn := make(map[string]string)
+	processor := func(object StoredObject) error {
+		passedOn[object.relativePath] = object.flattenedName
+		return nil
+	}
+	finalized := false
+	hold, release := newFlattener(policy, common.EFromTo.LocalBlob()).wrap(processor, func() error {
+		finalized = true
+		return nil
+	})
+	for _, object := range objects {
+		c.Assert(hold(object), chk.IsNil)
+	}
+	if err := release(); err != nil {
+		return nil, err
+	}
+	c.Assert(finalized, chk.Equals, true)
+	return passedOn, nil
+}
+
+func flattenerTestObjects() []StoredObject {
+	now := time.Now()
+	file := func(relativePath string, age time.Duration) StoredObject {
+		return StoredObject{relativePath: relativePath, entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-age)}
+	}
+	// listed out of order, to show that the choices don't depend on the order
+	return []StoredObject{
+		{relativePath: "", entityType: common.EEntityType.Folder()}, // the root folder
+		{relativePath: "b", entityType: common.EEntityType.Folder()},
+		file("b/report.txt", time.Hour),
+		file("a/report.txt", 2*time.Hour),
+		file("c/d/report.txt", 3*time.Hour),
+		file("report-2.txt", 4*time.Hour), // has the name that the first rename would give
+		file("a/unique.bin", time.Hour),
+	}
+}
+
+func (s *flattenerSuite) TestFlattenerFailsOnCollision(c *chk.C) {
+	_, err := flattenObjects(c, common.EFlattenCollisionPolicy.Fail(), flattenerTestObjects())
+	c.Assert(err, chk.ErrorMatches, "cannot flatten the copy, because a/report.txt and b/report.txt would both be copied to report.txt.*")
+}
+
+func (s *flattenerSuite) TestFlattenerRenamesCollisions(c *chk.C) {
+	names, err := flattenObjects(c, common.EFlattenCollisionPolicy.Rename(), flattenerTestObjects())
+	c.Assert(err, chk.IsNil)
+	c.Assert(names, chk.DeepEquals, map[string]string{
+		"":               "", // the root folder goes straight through, while the other folder is left out
+		"a/report.txt":   "report.txt",
+		"b/report.txt":   "report-3.txt", // report-2.txt is taken by a file of that name
+		"c/d/report.txt": "report-4.txt",
+		"report-2.txt":   "report-2.txt",
+		"a/unique.bin":   "unique.bin",
+	})
+}
+
+func (s *flattenerSuite) TestFlattenerOverwritesWithMostRecent(c *chk.C) {
+	names, err := flattenObjects(c, common.EFlattenCollisionPolicy.Overwrite(), flattenerTestObjects())
+	c.Assert(err, chk.IsNil)
+	c.Assert(names, chk.DeepEquals, map[string]string{
+		"":             "",
+		"b/report.txt": "report.txt", // the most recently modified of the three
+		"report-2.txt": "report-2.txt",
+		"a/unique.bin": "unique.bin",
+	})
+}
+
+func (s *flattenerSuite) TestFlattenerKeepsVersionsApart(c *chk.C) {
+	names, err := flattenObjects(c, common.EFlattenCollisionPolicy.Fail(), []StoredObject{
+		{relativePath: "a/blob", entityType: common.EEntityType.File(), blobVersionID: "2021-01-01T00:00:00.0000000Z"},
+		{relativePath: "a/blob", entityType: common.EEntityType.File(), blobVersionID: "2022-01-01T00:00:00.0000000Z"},
+	})
+	c.Assert(err, chk.IsNil)
+	c.Assert(names["a/blob"], chk.Equals, "blob")
+}
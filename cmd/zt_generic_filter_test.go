@@ -23,9 +23,13 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-storage-azcopy/v10/common"
 	chk "gopkg.in/check.v1"
 )
 
@@ -37,7 +41,7 @@ func (s *genericFilterSuite) TestIncludeFilter(c *chk.C) {
 	// set up the filters
 	raw := rawSyncCmdArgs{}
 	includePatternList := raw.parsePatterns("*.pdf;*.jpeg;exactName")
-	includeFilter := buildIncludeFilters(includePatternList)[0]
+	includeFilter := buildIncludeFilters(includePatternList, false)[0]
 
 	// test the positive cases
 	filesToPass := []string{"bla.pdf", "fancy.jpeg", "socool.jpeg.pdf", "exactName"}
@@ -58,7 +62,7 @@ func (s *genericFilterSuite) TestExcludeFilter(c *chk.C) {
 	// set up the filters
 	raw := rawSyncCmdArgs{}
 	excludePatternList := raw.parsePatterns("*.pdf;*.jpeg;exactName")
-	excludeFilterList := buildExcludeFilters(excludePatternList, false)
+	excludeFilterList := buildExcludeFilters(excludePatternList, false, false)
 
 	// test the positive cases
 	filesToPass := []string{"bla.pdfe", "fancy.jjpeg", "socool.png", "eexactName"}
@@ -79,6 +83,403 @@ func (s *genericFilterSuite) TestExcludeFilter(c *chk.C) {
 	}
 }
 
+func (s *genericFilterSuite) TestIgnoreCasePattern(c *chk.C) {
+	// set up the filters
+	raw := rawSyncCmdArgs{}
+	patternList := raw.parsePatterns("*.TXT;exactName")
+	caseSensitiveFilter := buildIncludeFilters(patternList, false)[0]
+	caseInsensitiveFilter := buildIncludeFilters(patternList, true)[0]
+	caseInsensitiveExcludeFilters := buildExcludeFilters(patternList, false, true)
+
+	for _, file := range []string{"File.TXT", "file.txt", "FILE.Txt", "EXACTNAME"} {
+		c.Assert(caseInsensitiveFilter.DoesPass(StoredObject{name: file}), chk.Equals, true, chk.Commentf(file))
+		dummyProcessor := &dummyProcessor{}
+		err := processIfPassedFilters(caseInsensitiveExcludeFilters, StoredObject{name: file}, dummyProcessor.process)
+		c.Assert(err, chk.Equals, ignoredError, chk.Commentf(file))
+	}
+
+	// the default remains case-sensitive
+	c.Assert(caseSensitiveFilter.DoesPass(StoredObject{name: "File.TXT"}), chk.Equals, true)
+	for _, file := range []string{"file.txt", "FILE.Txt", "EXACTNAME"} {
+		c.Assert(caseSensitiveFilter.DoesPass(StoredObject{name: file}), chk.Equals, false, chk.Commentf(file))
+	}
+
+	// a case-insensitive filter can't be turned into a (case-sensitive) service-side prefix
+	singlePattern := raw.parsePatterns("File*")
+	c.Assert(FilterSet(buildIncludeFilters(singlePattern, false)).GetEnumerationPreFilter(false), chk.Equals, "File")
+	c.Assert(FilterSet(buildIncludeFilters(singlePattern, true)).GetEnumerationPreFilter(false), chk.Equals, "")
+}
+
+func (s *genericFilterSuite) TestRegexFilterMatchesWholeRelativePath(c *chk.C) {
+	// set up the filters
+	raw := rawSyncCmdArgs{}
+	includeRegexList := raw.parsePatterns(".*\\.pdf;reports/.*;exact\\.txt|other\\.txt")
+	includeFilter := buildRegexFilters(includeRegexList, true)[0]
+
+	// test the positive cases
+	pathsToPass := []string{"bla.pdf", "sub/bla.pdf", "reports/summary.txt", "reports/sub/summary.txt", "exact.txt", "other.txt"}
+	for _, path := range pathsToPass {
+		passed := includeFilter.DoesPass(StoredObject{relativePath: path})
+		c.Assert(passed, chk.Equals, true, chk.Commentf(path))
+	}
+
+	// test the negative cases
+	// the expressions are anchored at both ends, and alternatives are anchored as a whole
+	pathsNotToPass := []string{"bla.pdff", "sub/reports/summary.txt", "reportsummary.txt", "sub/exact.txt", "exact.txt.bak", "other.txt2"}
+	for _, path := range pathsNotToPass {
+		passed := includeFilter.DoesPass(StoredObject{relativePath: path})
+		c.Assert(passed, chk.Equals, false, chk.Commentf(path))
+	}
+}
+
+func (s *genericFilterSuite) TestInvalidRegexIsRejected(c *chk.C) {
+	c.Assert(validateRegexPatterns([]string{"^valid$", "[a-z]+\\.txt"}, "include-regex"), chk.IsNil)
+
+	err := validateRegexPatterns([]string{"^valid$", "[unclosed"}, "exclude-regex")
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "[unclosed"), chk.Equals, true)
+	c.Assert(strings.Contains(err.Error(), "exclude-regex"), chk.Equals, true)
+}
+
+func (s *genericFilterSuite) TestBracesExpandToAlternativePatterns(c *chk.C) {
+	cases := map[string][]string{
+		"file{1,2,3}.txt": {"file1.txt", "file2.txt", "file3.txt"},
+		"{2020,2021}_*":   {"2020_*", "2021_*"},
+		"{a,b{c,d}}x":     {"ax", "bcx", "bdx"}, // nested
+		"{a,b}{1,2}":      {"a1", "a2", "b1", "b2"},
+		"{a,}.txt":        {"a.txt", ".txt"},
+		"*.t?t":           {"*.t?t"},      // no braces, so no change
+		"{GUID}.txt":      {"{GUID}.txt"}, // no comma, so not a group
+		"[{,}]x":          {"[{,}]x"},     // braces are literal inside [...]
+		"\\{a,b\\}":       {"\\{a,b\\}"},  // and when escaped
+	}
+	for pattern, expected := range cases {
+		expanded, err := expandBracePatterns([]string{pattern}, "include-pattern")
+		c.Assert(err, chk.IsNil, chk.Commentf(pattern))
+		c.Assert(expanded, chk.DeepEquals, expected, chk.Commentf(pattern))
+	}
+
+	// the expanded patterns still have their usual meaning
+	patterns, err := expandBracePatterns([]string{"{2020,2021}_*"}, "include-pattern")
+	c.Assert(err, chk.IsNil)
+	filter := buildIncludeFilters(patterns, false)[0]
+	for name, expected := range map[string]bool{"2020_a": true, "2021_b": true, "2022_c": false, "{2020,2021}_d": false} {
+		c.Assert(filter.DoesPass(StoredObject{name: name}), chk.Equals, expected, chk.Commentf(name))
+	}
+}
+
+func (s *genericFilterSuite) TestEscapedBracesMatchLiterally(c *chk.C) {
+	// names with braces in used to be matched as they were. Now the braces must be escaped
+	patterns, err := expandBracePatterns([]string{"report\\{draft\\}.txt", "a\\{1\\,2\\}", "[{]x[}]"}, "include-pattern")
+	c.Assert(err, chk.IsNil)
+	filters := buildIncludeFilters(patterns, false)
+	for name, expected := range map[string]bool{"report{draft}.txt": true, "a{1,2}": true, "a1": false, "{x}": true, "reportdraft.txt": false} {
+		passed := false
+		for _, f := range filters {
+			passed = passed || f.DoesPass(StoredObject{name: name})
+		}
+		c.Assert(passed, chk.Equals, expected, chk.Commentf(name))
+	}
+
+	_, err = expandBracePatterns([]string{"file}.txt"}, "include-pattern")
+	c.Assert(err, chk.ErrorMatches, ".*put a backslash before it.*")
+}
+
+func (s *genericFilterSuite) TestMalformedBracesAreRejected(c *chk.C) {
+	cases := map[string]string{
+		"file{1,2.txt": "unmatched '{' at position 5",
+		"file1,2}.txt": "unmatched '}' at position 8",
+		"{a,{b,c}":     "unmatched '{' at position 1",
+		"{a,b}}":       "unmatched '}' at position 6",
+		strings.Repeat("{a,", 10) + strings.Repeat("}", 10): "nested more than",
+	}
+	for pattern, expected := range cases {
+		_, err := expandBracePatterns([]string{"*.txt", pattern}, "exclude-pattern")
+		c.Assert(err, chk.NotNil, chk.Commentf(pattern))
+		c.Assert(strings.Contains(err.Error(), expected), chk.Equals, true, chk.Commentf(err.Error()))
+		c.Assert(strings.Contains(err.Error(), "exclude-pattern"), chk.Equals, true)
+	}
+}
+
+func (s *genericFilterSuite) TestIncludePatternAndRegexAreORed(c *chk.C) {
+	// set up the filters
+	raw := rawSyncCmdArgs{}
+	patternFilters := buildIncludeFilters(raw.parsePatterns("*.txt"), false)
+	regexFilters := buildRegexFilters(raw.parsePatterns("^reports/.*\\.csv$"), true)
+	filters := combineIncludeFilters(patternFilters, regexFilters)
+	c.Assert(len(filters), chk.Equals, 1)
+
+	// test the positive cases
+	for _, path := range []string{"file.txt", "sub/file.txt", "reports/file.csv"} {
+		passed := filters[0].DoesPass(StoredObject{name: path[strings.LastIndex(path, "/")+1:], relativePath: path})
+		c.Assert(passed, chk.Equals, true, chk.Commentf(path))
+	}
+
+	// test the negative cases
+	for _, path := range []string{"file.csv", "reports/file.log", "sub/reports/file.csv"} {
+		passed := filters[0].DoesPass(StoredObject{name: path[strings.LastIndex(path, "/")+1:], relativePath: path})
+		c.Assert(passed, chk.Equals, false, chk.Commentf(path))
+	}
+}
+
+func (s *genericFilterSuite) TestExtensionFilters(c *chk.C) {
+	patterns, err := parseExtensions("jpg; .PNG;;gif", "include-ext")
+	c.Assert(err, chk.IsNil)
+	c.Assert(patterns, chk.DeepEquals, []string{"*.jpg", "*.PNG", "*.gif"})
+	include := buildExtensionFilters(patterns, true)[0]
+	excludes := buildExtensionFilters(patterns, false)
+
+	cases := map[string]bool{
+		"photo.jpg":     true,
+		"photo.JPG":     true, // extensions are matched case-insensitively
+		"icon.png":      true,
+		"anim.tar.gif":  true,
+		"photo.jpeg":    false,
+		"jpg":           false, // no extension, so it's not included
+		"README":        false,
+		"notes.jpg.txt": false,
+	}
+	for name, matched := range cases {
+		c.Assert(include.DoesPass(StoredObject{name: name}), chk.Equals, matched, chk.Commentf(name))
+
+		passedExcludes := true
+		for _, exclude := range excludes {
+			passedExcludes = passedExcludes && exclude.DoesPass(StoredObject{name: name})
+		}
+		c.Assert(passedExcludes, chk.Equals, !matched, chk.Commentf(name))
+	}
+
+	for _, bad := range []string{".", "*.jpg", "jp?", "dir/jpg", "{jpg,png}"} {
+		_, err := parseExtensions(bad, "exclude-ext")
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+		c.Assert(strings.Contains(err.Error(), "exclude-ext"), chk.Equals, true)
+	}
+}
+
+func (s *genericFilterSuite) TestIncludePatternAndExtensionAreORed(c *chk.C) {
+	patterns, err := parseExtensions("csv", "include-ext")
+	c.Assert(err, chk.IsNil)
+	filters := combineIncludeFilters(buildIncludeFilters([]string{"report*"}, false), buildExtensionFilters(patterns, true), buildRegexFilters(nil, true))
+	c.Assert(len(filters), chk.Equals, 1)
+
+	for name, expected := range map[string]bool{"report.txt": true, "data.CSV": true, "data.txt": false} {
+		c.Assert(filters[0].DoesPass(StoredObject{name: name, relativePath: name}), chk.Equals, expected, chk.Commentf(name))
+	}
+}
+
+func (s *genericFilterSuite) TestIncludePathFilter(c *chk.C) {
+	filter := &includePathFilter{paths: []string{"dir", "top.txt"}}
+
+	for relativePath, expected := range map[string]bool{
+		"dir":             true, // the folder itself
+		"dir/a.txt":       true,
+		"dir/sub/b.txt":   true,
+		"top.txt":         true,
+		"dir2/a.txt":      false, // a prefix of the name alone isn't enough
+		"other/dir/a.txt": false,
+		"":                false,
+	} {
+		c.Assert(filter.DoesPass(StoredObject{relativePath: relativePath}), chk.Equals, expected, chk.Commentf(relativePath))
+	}
+}
+
+func (s *genericFilterSuite) TestIncludePathIsFilteredPerContainerForWildcardSource(c *chk.C) {
+	dst := c.MkDir()
+
+	raw := getDefaultRawCopyInput("https://account.blob.core.windows.net/logs*", dst)
+	raw.includePath = "/dir/;top.txt"
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.includePathPerContainer, chk.DeepEquals, []string{"dir", "top.txt"})
+	c.Assert(cooked.ListOfFilesChannel, chk.IsNil) // a filter, rather than the list, handles them
+
+	// with a single container, include-path is still listed directly
+	raw = getDefaultRawCopyInput("https://account.blob.core.windows.net/logs", dst)
+	raw.includePath = "dir"
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.includePathPerContainer, chk.IsNil)
+	c.Assert(cooked.ListOfFilesChannel, chk.NotNil)
+}
+
+func (s *genericFilterSuite) TestFiltersFromFilesAreTheSameAsInline(c *chk.C) {
+	dir := c.MkDir()
+	write := func(name, content string) string {
+		filePath := filepath.Join(dir, name)
+		c.Assert(ioutil.WriteFile(filePath, []byte(content), 0644), chk.IsNil)
+		return filePath
+	}
+	patterns := write("patterns.txt", "# the files to copy\r\n*.jpg\r\n\r\n  {2020,2021}_*  \r\n")
+	paths := write("paths.txt", "dir\n# not this one\ntop.txt")
+	excluded := write("excluded.txt", "\ndir/skip\n")
+
+	cook := func(include, exclude, includePath, excludePath string) CookedCopyCmdArgs {
+		raw := getDefaultRawCopyInput("https://account.blob.core.windows.net/logs*", dir)
+		raw.include, raw.exclude, raw.includePath, raw.excludePath = include, exclude, includePath, excludePath
+		cooked, err := raw.cook()
+		c.Assert(err, chk.IsNil)
+		return cooked
+	}
+	fromFiles := cook("@"+patterns+";*.pdf", "@"+patterns, "@"+paths, "old;@"+excluded)
+	inline := cook("*.jpg;{2020,2021}_*;*.pdf", "*.jpg;{2020,2021}_*", "dir;top.txt", "old;dir/skip")
+
+	c.Assert(fromFiles.IncludePatterns, chk.DeepEquals, inline.IncludePatterns)
+	c.Assert(fromFiles.ExcludePatterns, chk.DeepEquals, inline.ExcludePatterns)
+	c.Assert(fromFiles.includePathPerContainer, chk.DeepEquals, inline.includePathPerContainer)
+	c.Assert(fromFiles.ExcludePathPatterns, chk.DeepEquals, inline.ExcludePathPatterns)
+	c.Assert(inline.IncludePatterns, chk.DeepEquals, []string{"*.jpg", "2020_*", "2021_*", "*.pdf"})
+
+	// a doubled @ is for names that start with @, and isn't a file
+	expanded, err := expandFilterFiles("@@eaDir;*@2x.png;@@@odd", "exclude-pattern")
+	c.Assert(err, chk.IsNil)
+	c.Assert(expanded, chk.Equals, "@eaDir;*@2x.png;@@odd")
+
+	// a file that can't be read, or that has nothing in it, is an error rather than no filter at all
+	_, err = expandFilterFiles("@"+filepath.Join(dir, "missing.txt"), "include-pattern")
+	c.Assert(err, chk.ErrorMatches, ".*write it as @@.*")
+	_, err = expandFilterFiles("@"+write("empty.txt", "# nothing yet\n\n"), "include-pattern")
+	c.Assert(err, chk.ErrorMatches, ".*has no entries")
+	_, err = expandFilterFiles("@"+write("semicolon.txt", "a;b\n"), "include-pattern")
+	c.Assert(err, chk.ErrorMatches, ".*has an entry with a ';' in it.*")
+}
+
+func (s *genericFilterSuite) TestMetadataFilter(c *chk.C) {
+	conditions, err := parseMetadataConditions("archive=true;Tier=arch*", "include-metadata")
+	c.Assert(err, chk.IsNil)
+	include := buildMetadataFilters(conditions, true)[0]
+	exclude := buildMetadataFilters(conditions, false)[0]
+
+	cases := []struct {
+		metadata common.Metadata
+		matched  bool
+	}{
+		{common.Metadata{"archive": "true"}, true},
+		{common.Metadata{"ARCHIVE": "true"}, true}, // keys are case-insensitive
+		{common.Metadata{"archive": "TRUE"}, false},
+		{common.Metadata{"tier": "archived"}, true},
+		{common.Metadata{"tier": "cool", "other": "true"}, false},
+		{common.Metadata{}, false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		object := StoredObject{name: "file", entityType: common.EEntityType.File(), Metadata: tc.metadata}
+		c.Assert(include.DoesPass(object), chk.Equals, tc.matched, chk.Commentf("%v", tc.metadata))
+		c.Assert(exclude.DoesPass(object), chk.Equals, !tc.matched, chk.Commentf("%v", tc.metadata))
+	}
+
+	// malformed conditions are reported, rather than matching nothing
+	for _, raw := range []string{"archive", "=true", "archive=[true"} {
+		_, err = parseMetadataConditions(raw, "exclude-metadata")
+		c.Assert(err, chk.NotNil, chk.Commentf(raw))
+		c.Assert(strings.Contains(err.Error(), "exclude-metadata"), chk.Equals, true)
+	}
+}
+
+func (s *genericFilterSuite) TestContentTypeFilter(c *chk.C) {
+	patterns, err := parseContentTypePatterns("image/*;Application/PDF", "include-content-type")
+	c.Assert(err, chk.IsNil)
+	include := buildContentTypeFilters(patterns, true)[0]
+	exclude := buildContentTypeFilters(patterns, false)[0]
+
+	cases := []struct {
+		contentType string
+		matched     bool
+	}{
+		{"image/png", true},
+		{"IMAGE/JPEG", true}, // media types are case-insensitive
+		{"application/pdf", true},
+		{"application/pdf; charset=binary", true}, // parameters are ignored
+		{"application/pdfx", false},
+		{"text/plain", false},
+		{"image", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		object := StoredObject{name: "file", entityType: common.EEntityType.File(), contentType: tc.contentType}
+		c.Assert(include.DoesPass(object), chk.Equals, tc.matched, chk.Commentf("%q", tc.contentType))
+		c.Assert(exclude.DoesPass(object), chk.Equals, !tc.matched, chk.Commentf("%q", tc.contentType))
+	}
+
+	// malformed patterns are reported, rather than matching nothing
+	_, err = parseContentTypePatterns("image/[png", "exclude-content-type")
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "exclude-content-type"), chk.Equals, true)
+}
+
+func (s *genericFilterSuite) TestParseFileAttributes(c *chk.C) {
+	// an unknown attribute is reported on every OS, rather than silently matching nothing
+	_, err := parseFileAttributes("HX", "include-attributes")
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "'X'"), chk.Equals, true)
+
+	attributes, err := parseFileAttributes("", "include-attributes")
+	c.Assert(err, chk.IsNil)
+	c.Assert(attributes, chk.HasLen, 0)
+
+	// sets of letters, and letters separated by ';', can be mixed
+	attributes, err = parseFileAttributes("hs;A", "include-attributes")
+	if runtime.GOOS == "windows" {
+		c.Assert(err, chk.IsNil)
+		c.Assert(attributes, chk.DeepEquals, []string{"H", "S", "A"})
+	} else {
+		c.Assert(err, chk.NotNil) // attributes can't be read on this OS
+	}
+}
+
+func (s *genericFilterSuite) TestSizeFilter(c *chk.C) {
+	minSize, err := SizeFilter{}.ParseSize("2k", "min-size")
+	c.Assert(err, chk.IsNil)
+	c.Assert(minSize, chk.Equals, int64(2048))
+	maxSize, err := SizeFilter{}.ParseSize("1048576", "max-size")
+	c.Assert(err, chk.IsNil)
+	c.Assert(maxSize, chk.Equals, int64(1024*1024))
+
+	for _, bad := range []string{"-1", "-2k", "12kb", "k", "1 M"} {
+		_, err = SizeFilter{}.ParseSize(bad, "min-size")
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+	}
+
+	sizeFilter := &SizeFilter{MinSizeBytes: &minSize, MaxSizeBytes: &maxSize}
+	for size, expected := range map[int64]bool{0: false, 2047: false, 2048: true, 1024 * 1024: true, 1024*1024 + 1: false} {
+		passed := sizeFilter.DoesPass(StoredObject{entityType: common.EEntityType.File(), size: size})
+		c.Assert(passed, chk.Equals, expected, chk.Commentf("%d", size))
+	}
+
+	// folders are never filtered by size
+	c.Assert(sizeFilter.DoesPass(StoredObject{entityType: common.EEntityType.Folder()}), chk.Equals, true)
+}
+
+func (s *genericFilterSuite) TestDepthFilter(c *chk.C) {
+	file := common.EEntityType.File()
+	folder := common.EEntityType.Folder()
+	objects := []StoredObject{
+		{relativePath: "", entityType: folder}, // the root, at depth 0
+		{relativePath: "a.txt", entityType: file},
+		{relativePath: "l1", entityType: folder},
+		{relativePath: "l1/b.txt", entityType: file},
+		{relativePath: "l1/l2", entityType: folder},
+		{relativePath: "l1/l2/c.txt", entityType: file},
+		{relativePath: "l1/l2/l3/d.txt", entityType: file},
+	}
+
+	passing := func(f *DepthFilter) []string {
+		result := make([]string, 0)
+		for _, o := range objects {
+			if f.DoesPass(o) {
+				result = append(result, o.relativePath)
+			}
+		}
+		return result
+	}
+
+	c.Assert(passing(&DepthFilter{MinDepth: 2}), chk.DeepEquals, []string{"l1/b.txt", "l1/l2", "l1/l2/c.txt", "l1/l2/l3/d.txt"})
+	c.Assert(passing(&DepthFilter{MaxDepth: 1}), chk.DeepEquals, []string{"", "a.txt", "l1"})
+	c.Assert(passing(&DepthFilter{MinDepth: 2, MaxDepth: 3}), chk.DeepEquals, []string{"l1/b.txt", "l1/l2", "l1/l2/c.txt"})
+
+	// a single file source is at depth 1
+	c.Assert((&DepthFilter{MinDepth: 1, MaxDepth: 1}).DoesPass(StoredObject{relativePath: "", entityType: file}), chk.Equals, true)
+}
+
 func (s *genericFilterSuite) TestDateParsingForIncludeAfter(c *chk.C) {
 	examples := []struct {
 		input                 string // ISO 8601
@@ -131,6 +532,41 @@ func (s *genericFilterSuite) TestDateParsingForIncludeAfter(c *chk.C) {
 	}
 }
 
+func (s *genericFilterSuite) TestRelativeDateSelectsFilesModifiedInThePeriod(c *chk.C) {
+	// a fixed clock, so that what's in the last week doesn't depend on when the test runs
+	now := time.Date(2022, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	threshold, err := IncludeAfterDateFilter{}.ParseTime("-7d", true, now)
+	c.Assert(err, chk.IsNil)
+	filter := &IncludeAfterDateFilter{Threshold: threshold}
+
+	c.Check(filter.DoesPass(StoredObject{name: "today", lastModifiedTime: now.Add(-time.Hour)}), chk.Equals, true)
+	c.Check(filter.DoesPass(StoredObject{name: "sixDaysAgo", lastModifiedTime: now.AddDate(0, 0, -6)}), chk.Equals, true)
+	c.Check(filter.DoesPass(StoredObject{name: "exactlyAWeekAgo", lastModifiedTime: now.AddDate(0, 0, -7)}), chk.Equals, true)
+	c.Check(filter.DoesPass(StoredObject{name: "eightDaysAgo", lastModifiedTime: now.AddDate(0, 0, -8)}), chk.Equals, false)
+
+	for input, expected := range map[string]time.Time{
+		"-24h": now.Add(-24 * time.Hour),
+		"-30m": now.Add(-30 * time.Minute),
+		"+1h":  now.Add(time.Hour),
+	} {
+		parsed, err := IncludeBeforeDateFilter{}.ParseTime(input, false, now)
+		c.Assert(err, chk.IsNil, chk.Commentf(input))
+		c.Check(parsed.Equal(expected), chk.Equals, true, chk.Commentf(input))
+	}
+
+	for _, input := range []string{"-7", "-d", "-7w", "-1.5h", "-7 d", "-"} {
+		_, err := IncludeAfterDateFilter{}.ParseTime(input, true, now)
+		c.Assert(err, chk.NotNil, chk.Commentf(input))
+		c.Check(strings.Contains(err.Error(), "could not parse relative time"), chk.Equals, true, chk.Commentf(input))
+	}
+
+	// absolute times are parsed as they always were
+	parsed, err := IncludeAfterDateFilter{}.ParseTime("2019-01-31T18:30:15Z", true, now)
+	c.Assert(err, chk.IsNil)
+	c.Check(parsed.Equal(time.Date(2019, 1, 31, 18, 30, 15, 0, time.UTC)), chk.Equals, true)
+}
+
 // When daylight savings ends, in the fall, there's one ambiguous hour (in US timezones, for example, the repeated hour is 1 to 2 am
 // on the first Sunday in November).  For the purposes of include-after, we should use the FIRST of the two possible times.
 // (If we use the last, we might miss file changes that happened in the hour before it. This could result in regular running
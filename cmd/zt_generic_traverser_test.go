@@ -26,6 +26,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -213,7 +214,7 @@ func (s *genericTraverserSuite) TestGCPGetProperties(c *chk.C) {
 	// First test against the bucket
 	gcpBucketURL := scenarioHelper{}.getRawGCPBucketURL(c, bucketName)
 
-	traverser, err := newGCPTraverser(&gcpBucketURL, ctx, false, true, func(common.EntityType) {})
+	traverser, err := newGCPTraverser(common.ECredentialType.GoogleAppCredentials(), &gcpBucketURL, ctx, false, true, func(common.EntityType) {})
 	c.Assert(err, chk.IsNil)
 
 	// Embed the check into the processor for ease of use
@@ -236,7 +237,7 @@ func (s *genericTraverserSuite) TestGCPGetProperties(c *chk.C) {
 	// Then, test against the object itself because that's a different codepath.
 	seenContentType = false
 	gcpObjectURL := scenarioHelper{}.getRawGCPObjectURL(c, bucketName, objectName)
-	traverser, err = newGCPTraverser(&gcpObjectURL, ctx, false, true, func(common.EntityType) {})
+	traverser, err = newGCPTraverser(common.ECredentialType.GoogleAppCredentials(), &gcpObjectURL, ctx, false, true, func(common.EntityType) {})
 	c.Assert(err, chk.IsNil)
 
 	err = traverser.Traverse(noPreProccessor, processor, nil)
@@ -276,7 +277,7 @@ func (s *genericTraverserSuite) TestWalkWithSymlinks_ToFolder(c *chk.C) {
 		fileCount++
 		return nil
 	},
-		true, nil), chk.IsNil)
+		common.ESymlinkHandlingType.Follow(), "", nil), chk.IsNil)
 
 	// 3 files live in base, 3 files live in symlink
 	c.Assert(fileCount, chk.Equals, 6)
@@ -312,7 +313,7 @@ func (s *genericTraverserSuite) TestWalkWithSymlinks_ToFile(c *chk.C) {
 		}
 		return nil
 	},
-		true), chk.IsNil)
+		common.ESymlinkHandlingType.Follow()), chk.IsNil)
 	// 1 file is in base, 2 are pointed to by a symlink (the fact that both point to the same file is does NOT prevent us
 	// processing them both. For efficiency of dedupe algorithm, we only dedupe directories, not files).
 	c.Assert(fileCount, chk.Equals, 3)
@@ -341,7 +342,7 @@ func (s *genericTraverserSuite) TestWalkWithSymlinksBreakLoop(c *chk.C) {
 		fileCount++
 		return nil
 	},
-		true, nil), chk.IsNil)
+		common.ESymlinkHandlingType.Follow(), "", nil), chk.IsNil)
 
 	c.Assert(fileCount, chk.Equals, 3)
 }
@@ -371,7 +372,7 @@ func (s *genericTraverserSuite) TestWalkWithSymlinksDedupe(c *chk.C) {
 		fileCount++
 		return nil
 	},
-		true, nil), chk.IsNil)
+		common.ESymlinkHandlingType.Follow(), "", nil), chk.IsNil)
 
 	c.Assert(fileCount, chk.Equals, 6)
 }
@@ -402,7 +403,7 @@ func (s *genericTraverserSuite) TestWalkWithSymlinksMultitarget(c *chk.C) {
 		fileCount++
 		return nil
 	},
-		true, nil), chk.IsNil)
+		common.ESymlinkHandlingType.Follow(), "", nil), chk.IsNil)
 
 	// 3 files live in base, 3 files live in first symlink, second & third symlink is ignored.
 	c.Assert(fileCount, chk.Equals, 6)
@@ -435,12 +436,111 @@ func (s *genericTraverserSuite) TestWalkWithSymlinksToParentAndChild(c *chk.C) {
 		fileCount++
 		return nil
 	},
-		true, nil), chk.IsNil)
+		common.ESymlinkHandlingType.Follow(), "", nil), chk.IsNil)
 
 	// 6 files total live under toroot. tochild should be ignored (or if tochild was traversed first, child will be ignored on toroot).
 	c.Assert(fileCount, chk.Equals, 6)
 }
 
+// When preserving symlinks, the links themselves are walked, and nothing is walked through them
+func (s *genericTraverserSuite) TestWalkWithSymlinksPreserve(c *chk.C) {
+	fileNames := []string{"stonks.txt", "jaws but its a baby shark.mp3", "my crow soft.txt"}
+	tmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(tmpDir)
+	symlinkTmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(symlinkTmpDir)
+
+	scenarioHelper{}.generateLocalFilesFromList(c, tmpDir, fileNames)
+	scenarioHelper{}.generateLocalFilesFromList(c, symlinkTmpDir, fileNames)
+	trySymlink(symlinkTmpDir, filepath.Join(tmpDir, "elsewhere"), c)
+	trySymlink(tmpDir, filepath.Join(tmpDir, "spinloop"), c)
+
+	fileCount := 0
+	linkNames := make([]string, 0)
+	c.Assert(WalkWithSymlinks(context.TODO(), tmpDir, func(path string, fi os.FileInfo, err error) error {
+		c.Assert(err, chk.IsNil)
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			linkNames = append(linkNames, fi.Name())
+			return nil
+		}
+		if fi.IsDir() {
+			return nil
+		}
+
+		fileCount++
+		return nil
+	},
+		common.ESymlinkHandlingType.Preserve(), "", nil), chk.IsNil)
+
+	// only the 3 files in base are found, plus the 2 links, neither of which is followed
+	c.Assert(fileCount, chk.Equals, 3)
+	sort.Strings(linkNames)
+	c.Assert(linkNames, chk.DeepEquals, []string{"elsewhere", "spinloop"})
+}
+
+// When symlinks are restricted to the root, links that lead outside of it are skipped, and links within it are followed
+func (s *genericTraverserSuite) TestWalkWithSymlinksRestrictedToRoot(c *chk.C) {
+	fileNames := []string{"file1.txt", "file2.txt", "file3.txt"}
+	tmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(tmpDir)
+	symlinkTmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(symlinkTmpDir)
+
+	insideDir := filepath.Join(tmpDir, "inside")
+	c.Assert(os.Mkdir(insideDir, os.ModePerm), chk.IsNil)
+	scenarioHelper{}.generateLocalFilesFromList(c, insideDir, fileNames)
+	scenarioHelper{}.generateLocalFilesFromList(c, symlinkTmpDir, fileNames)
+	trySymlink(insideDir, filepath.Join(tmpDir, "toinside"), c)
+	trySymlink(symlinkTmpDir, filepath.Join(tmpDir, "tooutside"), c)
+	trySymlink(filepath.Join(symlinkTmpDir, fileNames[0]), filepath.Join(tmpDir, "tooutsidefile"), c)
+
+	filePaths := make([]string, 0)
+	c.Assert(WalkWithSymlinks(context.TODO(), tmpDir, func(path string, fi os.FileInfo, err error) error {
+		c.Assert(err, chk.IsNil)
+
+		if fi.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(tmpDir, path)
+		c.Assert(err, chk.IsNil)
+		filePaths = append(filePaths, filepath.ToSlash(rel))
+		return nil
+	},
+		common.ESymlinkHandlingType.Follow(), tmpDir, nil), chk.IsNil)
+
+	// toinside is a second route to the files in inside, so they are only seen once, under whichever route came first
+	// nothing is seen through tooutside or tooutsidefile
+	c.Assert(filePaths, chk.HasLen, 3)
+	for _, p := range filePaths {
+		c.Assert(strings.HasPrefix(p, "tooutside"), chk.Equals, false)
+	}
+}
+
+func (s *genericTraverserSuite) TestLocalTraverserStopsAtMaxDepth(c *chk.C) {
+	tmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(tmpDir)
+	symlinkTmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(symlinkTmpDir)
+
+	scenarioHelper{}.generateLocalFilesFromList(c, tmpDir, []string{"a.txt", "l1/b.txt", "l1/l2/c.txt", "l1/l2/l3/d.txt"})
+	scenarioHelper{}.generateLocalFilesFromList(c, symlinkTmpDir, []string{"e.txt", "sub/f.txt"})
+	trySymlink(symlinkTmpDir, filepath.Join(tmpDir, "link"), c)
+
+	traverser := newLocalTraverser(context.TODO(), tmpDir, true, common.ESymlinkHandlingType.Follow(), "", nil, nil)
+	traverser.setMaxDepth(2)
+	found := make([]string, 0)
+	c.Assert(traverser.Traverse(noPreProccessor, func(o StoredObject) error {
+		found = append(found, o.relativePath)
+		return nil
+	}, nil), chk.IsNil)
+	sort.Strings(found)
+
+	// folders two levels down are enumerated, but nothing inside them is, including when they are reached through a symlink
+	c.Assert(found, chk.DeepEquals, []string{"", "a.txt", "l1", "l1/b.txt", "l1/l2", "link", "link/e.txt", "link/sub"})
+}
+
 // validate traversing a single Blob, a single Azure File, and a single local file
 // compare that the traversers get consistent results
 func (s *genericTraverserSuite) TestTraverserWithSingleObject(c *chk.C) {
@@ -484,7 +584,7 @@ func (s *genericTraverserSuite) TestTraverserWithSingleObject(c *chk.C) {
 		scenarioHelper{}.generateLocalFilesFromList(c, dstDirName, blobList)
 
 		// construct a local traverser
-		localTraverser := newLocalTraverser(context.TODO(), filepath.Join(dstDirName, dstFileName), false, false, func(common.EntityType) {}, nil)
+		localTraverser := newLocalTraverser(context.TODO(), filepath.Join(dstDirName, dstFileName), false, common.ESymlinkHandlingType.Skip(), "", func(common.EntityType) {}, nil)
 
 		// invoke the local traversal with a dummy processor
 		localDummyProcessor := dummyProcessor{}
@@ -576,7 +676,7 @@ func (s *genericTraverserSuite) TestTraverserWithSingleObject(c *chk.C) {
 
 			gcpDummyProcessor := dummyProcessor{}
 			gcpURL := scenarioHelper{}.getRawGCPObjectURL(c, bucketNameGCP, storedObjectName)
-			GCPTraverser, err := newGCPTraverser(&gcpURL, ctx, false, false, func(entityType common.EntityType) {})
+			GCPTraverser, err := newGCPTraverser(common.ECredentialType.GoogleAppCredentials(), &gcpURL, ctx, false, false, func(entityType common.EntityType) {})
 			c.Assert(err, chk.IsNil)
 
 			err = GCPTraverser.Traverse(noPreProccessor, gcpDummyProcessor.process, nil)
@@ -644,7 +744,7 @@ func (s *genericTraverserSuite) TestTraverserContainerAndLocalDirectory(c *chk.C
 	// test two scenarios, either recursive or not
 	for _, isRecursiveOn := range []bool{true, false} {
 		// construct a local traverser
-		localTraverser := newLocalTraverser(context.TODO(), dstDirName, isRecursiveOn, false, func(common.EntityType) {}, nil)
+		localTraverser := newLocalTraverser(context.TODO(), dstDirName, isRecursiveOn, common.ESymlinkHandlingType.Skip(), "", func(common.EntityType) {}, nil)
 
 		// invoke the local traversal with an indexer
 		// so that the results are indexed for easy validation
@@ -697,7 +797,7 @@ func (s *genericTraverserSuite) TestTraverserContainerAndLocalDirectory(c *chk.C
 		}
 		if gcpEnabled {
 			rawGCPURL := scenarioHelper{}.getRawGCPBucketURL(c, bucketNameGCP)
-			GCPTraverser, err := newGCPTraverser(&rawGCPURL, ctx, isRecursiveOn, false, func(entityType common.EntityType) {})
+			GCPTraverser, err := newGCPTraverser(common.ECredentialType.GoogleAppCredentials(), &rawGCPURL, ctx, isRecursiveOn, false, func(entityType common.EntityType) {})
 			c.Assert(err, chk.IsNil)
 			err = GCPTraverser.Traverse(noPreProccessor, gcpDummyProcessor.process, nil)
 			c.Assert(err, chk.IsNil)
@@ -805,7 +905,7 @@ func (s *genericTraverserSuite) TestTraverserWithVirtualAndLocalDirectory(c *chk
 	// test two scenarios, either recursive or not
 	for _, isRecursiveOn := range []bool{true, false} {
 		// construct a local traverser
-		localTraverser := newLocalTraverser(context.TODO(), filepath.Join(dstDirName, virDirName), isRecursiveOn, false, func(common.EntityType) {}, nil)
+		localTraverser := newLocalTraverser(context.TODO(), filepath.Join(dstDirName, virDirName), isRecursiveOn, common.ESymlinkHandlingType.Skip(), "", func(common.EntityType) {}, nil)
 
 		// invoke the local traversal with an indexer
 		// so that the results are indexed for easy validation
@@ -869,7 +969,7 @@ func (s *genericTraverserSuite) TestTraverserWithVirtualAndLocalDirectory(c *chk
 		}
 		if gcpEnabled {
 			rawGCPURL := scenarioHelper{}.getRawGCPObjectURL(c, bucketNameGCP, virDirName+"/")
-			GCPTraverser, err := newGCPTraverser(&rawGCPURL, ctx, isRecursiveOn, false, func(common.EntityType) {})
+			GCPTraverser, err := newGCPTraverser(common.ECredentialType.GoogleAppCredentials(), &rawGCPURL, ctx, isRecursiveOn, false, func(common.EntityType) {})
 			c.Assert(err, chk.IsNil)
 			err = GCPTraverser.Traverse(noPreProccessor, gcpDummyProcessor.process, nil)
 			c.Assert(err, chk.IsNil)
@@ -22,6 +22,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -92,13 +93,18 @@ func (m *mockedLifecycleManager) ReportAllJobPartsDone() {
 func (m *mockedLifecycleManager) SetOutputVerbosity(mode common.OutputVerbosity) {
 }
 
+func (m *mockedLifecycleManager) SetOutputWriter(io.Writer) {
+}
+
 func (m *mockedLifecycleManager) Progress(o common.OutputBuilder) {
 	select {
 	case m.progressLog <- o(common.EOutputFormat.Text()):
 	default:
 	}
 }
-func (*mockedLifecycleManager) Init(common.OutputBuilder) {}
+func (*mockedLifecycleManager) ProgressEvent(common.ProgressEvent) {}
+func (*mockedLifecycleManager) EnableProgressEvents()              {}
+func (*mockedLifecycleManager) Init(common.OutputBuilder)          {}
 func (m *mockedLifecycleManager) Info(msg string) {
 	select {
 	case m.infoLog <- msg:
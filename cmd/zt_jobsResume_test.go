@@ -0,0 +1,67 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type jobsResumeSuite struct{}
+
+var _ = chk.Suite(&jobsResumeSuite{})
+
+func (s *jobsResumeSuite) TestDestinationOverrideMustBeCompatible(c *chk.C) {
+	original := "https://account.blob.core.windows.net/container"
+
+	// another container, in another account, with its SAS
+	resource, err := cookDestinationOverride("https://other.blob.core.windows.net/second?sv=2020-10-02&sig=secret", common.EFromTo.LocalBlob(), original)
+	c.Assert(err, chk.IsNil)
+	c.Assert(resource.Value, chk.Equals, "https://other.blob.core.windows.net/second")
+	c.Assert(resource.SAS, chk.Equals, "sig=secret&sv=2020-10-02")
+
+	// a host that doesn't say which service it is, is taken to be the right one
+	_, err = cookDestinationOverride("https://storage.contoso.com/second", common.EFromTo.LocalBlob(), original)
+	c.Assert(err, chk.IsNil)
+
+	// but another service is refused
+	_, err = cookDestinationOverride("https://other.file.core.windows.net/share", common.EFromTo.LocalBlob(), original)
+	c.Assert(err, chk.ErrorMatches, "the job writes to Blob, so it can only be resumed into another Blob destination.*")
+	_, err = cookDestinationOverride(c.MkDir(), common.EFromTo.LocalBlob(), original)
+	c.Assert(err, chk.NotNil)
+
+	// and so is another level, since the transfers' paths are relative to the destination
+	_, err = cookDestinationOverride("https://other.blob.core.windows.net/", common.EFromTo.LocalBlob(), original)
+	c.Assert(err, chk.ErrorMatches, ".* is a container, so the destination it is resumed into must be one too")
+	_, err = cookDestinationOverride("https://other.blob.core.windows.net/container", common.EFromTo.BlobBlob(), "https://account.blob.core.windows.net/")
+	c.Assert(err, chk.ErrorMatches, ".* is an account, so the destination it is resumed into must be one too")
+
+	// a download can be resumed into another folder
+	dir := c.MkDir()
+	resource, err = cookDestinationOverride(dir, common.EFromTo.BlobLocal(), filepath.Join(dir, "original"))
+	c.Assert(err, chk.IsNil)
+	c.Assert(resource.Value, chk.Equals, dir)
+
+	// but a job that writes nowhere can't be
+	_, err = cookDestinationOverride("https://other.blob.core.windows.net/container", common.EFromTo.BlobTrash(), original)
+	c.Assert(err, chk.ErrorMatches, "a BlobTrash job can't be resumed into another destination.*")
+}
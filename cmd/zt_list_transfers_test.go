@@ -0,0 +1,141 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+// listTransfers returns the descriptors that ListTransfers hands out, keyed by relative path
+func listTransfers(c *chk.C, args ...string) map[string]TransferDescriptor {
+	listed := make(map[string]TransferDescriptor)
+	err := ListTransfers(args, func(transfer TransferDescriptor) error {
+		_, seen := listed[transfer.RelativePath]
+		c.Assert(seen, chk.Equals, false)
+		listed[transfer.RelativePath] = transfer
+		return nil
+	})
+	c.Assert(err, chk.IsNil)
+	return listed
+}
+
+func (s *cmdIntegrationSuite) TestListTransfersMatchesFilters(c *chk.C) {
+	bsu := getBSU()
+	containerURL, containerName := createNewContainer(c, bsu)
+	defer deleteContainer(c, containerURL)
+
+	files := []string{
+		"xyz/aaa",
+		"xyz/def",
+		"def",
+		"filea.pdf",
+		"fileb",
+		"sub/filec.pdf",
+	}
+	dirPath := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dirPath)
+	scenarioHelper{}.generateLocalFilesFromList(c, dirPath, files)
+
+	rawContainerURLWithSAS := scenarioHelper{}.getRawContainerURLWithSAS(c, containerName)
+
+	// each call parses its flags afresh, so none carries over into the next
+	for _, x := range []struct {
+		flags          []string
+		shouldTransfer []string
+	}{
+		{[]string{"--include-path=xyz", "--exclude-path=def"}, files[:2]},
+		{[]string{"--include-pattern=*.pdf"}, []string{"filea.pdf", "sub/filec.pdf"}},
+		{[]string{"--exclude-pattern=*.pdf;aaa"}, []string{"xyz/def", "def", "fileb"}},
+		{[]string{"--include-regex=xyz/.*"}, files[:2]},
+		{nil, files},
+	} {
+		args := append([]string{dirPath, rawContainerURLWithSAS.String(), "--recursive"}, x.flags...)
+		listed := listTransfers(c, args...)
+
+		relPaths := make([]string, 0, len(listed))
+		for relPath := range listed {
+			relPaths = append(relPaths, relPath)
+		}
+		expected := append([]string{}, x.shouldTransfer...)
+		sort.Strings(relPaths)
+		sort.Strings(expected)
+		c.Assert(relPaths, chk.DeepEquals, expected, chk.Commentf("flags %v", x.flags))
+
+		for relPath, transfer := range listed {
+			info, err := os.Stat(filepath.Join(dirPath, relPath))
+			c.Assert(err, chk.IsNil)
+			c.Assert(transfer.Size, chk.Equals, info.Size())
+			c.Assert(transfer.LastModifiedTime.Equal(info.ModTime()), chk.Equals, true)
+
+			expectedURL := containerURL.NewBlobURL(filepath.Base(dirPath) + "/" + relPath).URL()
+			c.Assert(transfer.Destination, chk.Equals, expectedURL.String())
+		}
+	}
+}
+
+func (s *cmdIntegrationSuite) TestListTransfersStopsOnHandlerError(c *chk.C) {
+	bsu := getBSU()
+	containerURL, containerName := createNewContainer(c, bsu)
+	defer deleteContainer(c, containerURL)
+
+	dirPath := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dirPath)
+	scenarioHelper{}.generateLocalFilesFromList(c, dirPath, []string{"filea", "fileb", "filec"})
+
+	rawContainerURLWithSAS := scenarioHelper{}.getRawContainerURLWithSAS(c, containerName)
+	stop := errors.New("stop")
+	calls := 0
+	err := ListTransfers([]string{dirPath, rawContainerURLWithSAS.String(), "--recursive"}, func(TransferDescriptor) error {
+		calls++
+		return stop
+	})
+	c.Assert(err, chk.Equals, stop)
+	c.Assert(calls, chk.Equals, 1)
+
+	// nothing was written to the destination
+	listing, err := containerURL.ListBlobsFlatSegment(ctx, azblob.Marker{}, azblob.ListBlobsSegmentOptions{})
+	c.Assert(err, chk.IsNil)
+	c.Assert(listing.Segment.BlobItems, chk.HasLen, 0)
+}
+
+func (s *cmdIntegrationSuite) TestListTransfersLeavesCopyCommandAlone(c *chk.C) {
+	copyCmd, _, err := rootCmd.Find([]string{"copy"})
+	c.Assert(err, chk.IsNil)
+	scanningLogger := azcopyScanningLogger
+
+	// the flags are parsed into a set of ListTransfers' own, even when the arguments turn out to be wrong
+	err = ListTransfers([]string{"--include-pattern=*.pdf", "--recursive", "source"}, func(TransferDescriptor) error { return nil })
+	c.Assert(err, chk.NotNil)
+	c.Assert(copyCmd.PersistentFlags().Changed("include-pattern"), chk.Equals, false)
+	c.Assert(copyCmd.PersistentFlags().Changed("recursive"), chk.Equals, false)
+
+	// flags that would change the whole process are refused
+	for _, flag := range []string{"--s3-endpoint=s3.example.com", "--backup"} {
+		err = ListTransfers([]string{"source", "destination", flag}, func(TransferDescriptor) error { return nil })
+		c.Assert(err, chk.NotNil, chk.Commentf("flag %s", flag))
+	}
+	c.Assert(azcopyScanningLogger, chk.Equals, scanningLogger)
+}
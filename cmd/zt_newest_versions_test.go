@@ -0,0 +1,100 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type newestVersionsSuite struct{}
+
+var _ = chk.Suite(&newestVersionsSuite{})
+
+func (s *newestVersionsSuite) TestOnlyTheNewestVersionsOfEachBlobAreProcessed(c *chk.C) {
+	version := func(path, id string) StoredObject {
+		return StoredObject{relativePath: path, entityType: common.EEntityType.File(), blobVersionID: id}
+	}
+
+	var processed []string
+	finalized := false
+	processor, finalizer := newNewestVersionsKeeper(2).wrap(func(object StoredObject) error {
+		processed = append(processed, object.relativePath+"@"+object.blobVersionID)
+		return nil
+	}, func() error {
+		finalized = true
+		return nil
+	})
+
+	for _, object := range []StoredObject{
+		version("a.txt", "2023-01-01T00:00:00.0000001Z"),
+		version("a.txt", "2023-01-03T00:00:00.0000000Z"),
+		version("a.txt", "2023-01-02T00:00:00.0000000Z"),
+		version("a.txt", "2023-01-05T00:00:00.0000000Z"),
+		version("a.txt", "2023-01-04T00:00:00.0000000Z"),
+		version("b.txt", "2023-01-01T00:00:00.0000000Z"),
+		{relativePath: "dir", entityType: common.EEntityType.Folder()},
+	} {
+		c.Assert(processor(object), chk.IsNil)
+	}
+	c.Assert(processed, chk.DeepEquals, []string{"dir@"}, chk.Commentf("only folders go through before the enumeration is done"))
+
+	c.Assert(finalizer(), chk.IsNil)
+	c.Assert(finalized, chk.Equals, true)
+	c.Assert(processed, chk.DeepEquals, []string{
+		"dir@",
+		"a.txt@2023-01-05T00:00:00.0000000Z",
+		"a.txt@2023-01-04T00:00:00.0000000Z",
+		"b.txt@2023-01-01T00:00:00.0000000Z",
+	})
+}
+
+func (s *newestVersionsSuite) TestMaxVersionsPerBlobIsRefusedWithListOfUrls(c *chk.C) {
+	listFile := filepath.Join(c.MkDir(), "urls.txt")
+	c.Assert(ioutil.WriteFile(listFile, []byte("https://account.blob.core.windows.net/container/a.txt\n"), 0644), chk.IsNil)
+
+	// the blobs of the list are never given to the keeper, so the flag is an error rather than silently doing nothing
+	for _, listVersions := range []bool{false, true} {
+		raw := getDefaultRawCopyInput("", c.MkDir())
+		raw.listOfUrls = listFile
+		raw.listVersions = listVersions
+		raw.maxVersionsPerBlob = 2
+		_, err := raw.cook()
+		c.Assert(err, chk.NotNil)
+		c.Assert(strings.Contains(err.Error(), "list-of-urls"), chk.Equals, true, chk.Commentf(err.Error()))
+	}
+}
+
+func (s *newestVersionsSuite) TestVersionsAreOrderedByTheTimeInTheirID(c *chk.C) {
+	c.Assert(versionIsNewer("2023-01-02T00:00:00.0000000Z", "2023-01-01T23:59:59.9999999Z"), chk.Equals, true)
+	c.Assert(versionIsNewer("2023-01-01T00:00:00.0000000Z", "2023-01-01T00:00:00.0000001Z"), chk.Equals, false)
+
+	// the same time, written differently, falls back to comparing the ids, so that the order is always the same
+	c.Assert(versionIsNewer("2023-01-01T00:00:00.1Z", "2023-01-01T00:00:00.1000000Z"), chk.Equals, true)
+	c.Assert(versionIsNewer("2023-01-01T00:00:00.1000000Z", "2023-01-01T00:00:00.1Z"), chk.Equals, false)
+
+	// a current version without an id is newer than any version that has one
+	c.Assert(versionIsNewer("", "2099-01-01T00:00:00.0000000Z"), chk.Equals, true)
+	c.Assert(versionIsNewer("2099-01-01T00:00:00.0000000Z", ""), chk.Equals, false)
+}
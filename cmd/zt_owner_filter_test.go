@@ -0,0 +1,191 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type ownerFilterSuite struct{}
+
+var _ = chk.Suite(&ownerFilterSuite{})
+
+const (
+	aliceOID = "5f1a7c2e-3b4d-4e6f-8a9b-0c1d2e3f4a5b"
+	bobOID   = "9e8d7c6b-5a4f-4e3d-2c1b-0a9f8e7d6c5b"
+)
+
+// fakeOwners answers owner lookups from a table, and records how many were made at once
+type fakeOwners struct {
+	byPath       map[string][2]string // object id, then user principal name
+	inFlight     int32
+	maxInFlight  int32
+	lookupsByUPN int32
+	lookupsByOID int32
+	failForPath  string
+}
+
+func (f *fakeOwners) lookup(object StoredObject, upn bool) (string, error) {
+	now := atomic.AddInt32(&f.inFlight, 1)
+	defer atomic.AddInt32(&f.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&f.maxInFlight)
+		if now <= max || atomic.CompareAndSwapInt32(&f.maxInFlight, max, now) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond) // long enough for the lookups to overlap
+
+	if object.relativePath == f.failForPath {
+		return "", errors.New("403 AuthorizationPermissionMismatch")
+	}
+	if upn {
+		atomic.AddInt32(&f.lookupsByUPN, 1)
+		return f.byPath[object.relativePath][1], nil
+	}
+	atomic.AddInt32(&f.lookupsByOID, 1)
+	return f.byPath[object.relativePath][0], nil
+}
+
+func (s *ownerFilterSuite) run(filter *ownerFilter, paths []string) ([]string, error) {
+	var passed []string
+	processor, finalizer, _ := filter.wrap(func(object StoredObject) error {
+		passed = append(passed, object.relativePath)
+		return nil
+	}, func() error { return nil })
+
+	for _, p := range paths {
+		if err := processor(StoredObject{relativePath: p, entityType: common.EEntityType.File()}); err != nil {
+			return passed, err
+		}
+	}
+	err := finalizer()
+	sort.Strings(passed)
+	return passed, err
+}
+
+func (s *ownerFilterSuite) owners() *fakeOwners {
+	return &fakeOwners{byPath: map[string][2]string{
+		"a/one.txt": {aliceOID, "alice@contoso.com"},
+		"a/two.txt": {aliceOID, "alice@contoso.com"},
+		"b/one.txt": {bobOID, "bob@contoso.com"},
+		"b/two.txt": {bobOID, "bob@contoso.com"},
+		"c/app.txt": {"1c2b3a49-5867-4e7f-9a8b-7c6d5e4f3a2b", "1c2b3a49-5867-4e7f-9a8b-7c6d5e4f3a2b"}, // a service principal has no name
+	}}
+}
+
+func (s *ownerFilterSuite) TestIncludeByObjectIdOrName(c *chk.C) {
+	owners := s.owners()
+	passed, err := s.run(&ownerFilter{include: []string{aliceOID}, lookup: owners.lookup, concurrency: 4},
+		[]string{"a/one.txt", "b/one.txt", "a/two.txt", "b/two.txt", "c/app.txt"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(passed, chk.DeepEquals, []string{"a/one.txt", "a/two.txt"})
+	c.Assert(owners.lookupsByUPN, chk.Equals, int32(0), chk.Commentf("names are only looked up when a name is given"))
+
+	owners = s.owners()
+	passed, err = s.run(&ownerFilter{include: []string{"BOB@contoso.com"}, lookup: owners.lookup, concurrency: 4},
+		[]string{"a/one.txt", "b/one.txt", "a/two.txt", "b/two.txt", "c/app.txt"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(passed, chk.DeepEquals, []string{"b/one.txt", "b/two.txt"})
+	c.Assert(owners.lookupsByOID, chk.Equals, int32(0))
+}
+
+func (s *ownerFilterSuite) TestExcludeWinsOverInclude(c *chk.C) {
+	owners := s.owners()
+	passed, err := s.run(&ownerFilter{
+		include: []string{"alice@contoso.com", bobOID},
+		exclude: []string{"bob@contoso.com"},
+		lookup:  owners.lookup, concurrency: 2},
+		[]string{"a/one.txt", "b/one.txt", "c/app.txt"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(passed, chk.DeepEquals, []string{"a/one.txt"})
+
+	owners = s.owners()
+	passed, err = s.run(&ownerFilter{exclude: []string{aliceOID}, lookup: owners.lookup, concurrency: 2},
+		[]string{"a/one.txt", "b/one.txt", "c/app.txt"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(passed, chk.DeepEquals, []string{"b/one.txt", "c/app.txt"})
+}
+
+func (s *ownerFilterSuite) TestLookupsAreCappedAtTheConcurrency(c *chk.C) {
+	owners := s.owners()
+	paths := make([]string, 0)
+	for i := 0; i < 8; i++ {
+		for p := range owners.byPath {
+			paths = append(paths, p)
+		}
+	}
+
+	_, err := s.run(&ownerFilter{include: []string{aliceOID}, lookup: owners.lookup, concurrency: 3}, paths)
+	c.Assert(err, chk.IsNil)
+	c.Assert(owners.maxInFlight <= 3, chk.Equals, true, chk.Commentf("%d lookups at once", owners.maxInFlight))
+	c.Assert(owners.maxInFlight > 1, chk.Equals, true, chk.Commentf("lookups should run in parallel"))
+}
+
+func (s *ownerFilterSuite) TestFailedLookupFailsTheEnumeration(c *chk.C) {
+	owners := s.owners()
+	owners.failForPath = "b/one.txt"
+	_, err := s.run(&ownerFilter{include: []string{aliceOID}, lookup: owners.lookup, concurrency: 1},
+		[]string{"a/one.txt", "b/one.txt", "a/two.txt"})
+	c.Assert(err, chk.ErrorMatches, ".*cannot find the owner of b/one.txt.*403.*")
+}
+
+func (s *ownerFilterSuite) TestRootFolderIsKeptWithoutLookup(c *chk.C) {
+	owners := s.owners()
+	var passed []StoredObject
+	processor, finalizer, _ := (&ownerFilter{include: []string{bobOID}, lookup: owners.lookup, concurrency: 1}).wrap(func(object StoredObject) error {
+		passed = append(passed, object)
+		return nil
+	}, func() error { return nil })
+	c.Assert(processor(StoredObject{relativePath: "", entityType: common.EEntityType.Folder()}), chk.IsNil)
+	c.Assert(finalizer(), chk.IsNil)
+	c.Assert(passed, chk.HasLen, 1)
+	c.Assert(owners.lookupsByOID, chk.Equals, int32(0))
+}
+
+func (s *ownerFilterSuite) TestAbandonStopsTheLookups(c *chk.C) {
+	owners := s.owners()
+	before := runtime.NumGoroutine()
+	var processed int32
+	processor, _, abandon := (&ownerFilter{include: []string{aliceOID}, lookup: owners.lookup, concurrency: 4}).wrap(func(object StoredObject) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	}, func() error {
+		c.Error("the finalizer of an abandoned enumeration must not be called")
+		return nil
+	})
+	c.Assert(runtime.NumGoroutine() > before, chk.Equals, true)
+
+	// the traverser fails here, so the finalizer is never called; abandon must still stop the lookups
+	c.Assert(processor(StoredObject{relativePath: "a/one.txt", entityType: common.EEntityType.File()}), chk.IsNil)
+	abandon()
+	c.Assert(runtime.NumGoroutine() <= before, chk.Equals, true, chk.Commentf("the lookup goroutines are left running"))
+	c.Assert(atomic.LoadInt32(&processed) <= 1, chk.Equals, true)
+
+	// what is abandoned stays that way
+	abandon()
+}
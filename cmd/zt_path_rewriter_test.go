@@ -0,0 +1,100 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type pathRewriterSuite struct{}
+
+var _ = chk.Suite(&pathRewriterSuite{})
+
+func (s *pathRewriterSuite) TestPathRewriterRewritesSkipsAndDetectsCollisions(c *chk.C) {
+	// strips the top folder, and skips what is directly in it
+	stripTopFolder := func(relPath string) string {
+		if i := strings.Index(relPath, "/"); i >= 0 {
+			return relPath[i+1:]
+		}
+		return ""
+	}
+	r := newDestinationPathRewriter(stripTopFolder, common.EFromTo.LocalBlob())
+
+	cases := []struct {
+		relativePath string
+		expectedPath string
+		expectedKeep bool
+	}{
+		{"", "", true}, // the source root, which is never rewritten
+		{"a/file1", "file1", true},
+		{"a/sub/file2", "sub/file2", true},
+		{"toplevel", "", false},    // rewritten to "", so skipped
+		{"b/file1", "", false},     // collides with a/file1
+		{"b/FILE1", "FILE1", true}, // blob names are case-sensitive, so this does not collide
+	}
+	for _, tc := range cases {
+		rewritten, keep := r.apply(StoredObject{name: tc.relativePath, relativePath: tc.relativePath})
+		c.Assert(keep, chk.Equals, tc.expectedKeep, chk.Commentf(tc.relativePath))
+		if keep {
+			c.Assert(rewritten.relativePath, chk.Equals, tc.expectedPath)
+		}
+	}
+
+	// whereas a local destination may be case-insensitive
+	r = newDestinationPathRewriter(stripTopFolder, common.EFromTo.BlobLocal())
+	_, keep := r.apply(StoredObject{relativePath: "a/file1"})
+	c.Assert(keep, chk.Equals, true)
+	_, keep = r.apply(StoredObject{relativePath: "b/FILE1"})
+	c.Assert(keep, chk.Equals, !IsDestinationCaseInsensitive(common.EFromTo.BlobLocal()))
+}
+
+func (s *pathRewriterSuite) TestPathMapIsLoadedIntoCookedArgs(c *chk.C) {
+	file, err := ioutil.TempFile("", "AzCopyPathMap")
+	c.Assert(err, chk.IsNil)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`{"dir/a.txt": "a.txt", "top.txt": ""}`)
+	c.Assert(err, chk.IsNil)
+	c.Assert(file.Close(), chk.IsNil)
+
+	raw := getDefaultRawCopyInput("/src", "https://account.blob.core.windows.net/container")
+	raw.pathMap = file.Name()
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.pathRewriter, chk.NotNil)
+	c.Assert(cooked.pathRewriter("dir/a.txt"), chk.Equals, "a.txt")
+	c.Assert(cooked.pathRewriter("top.txt"), chk.Equals, "")
+	c.Assert(cooked.pathRewriter("other.txt"), chk.Equals, "other.txt")
+
+	// without the flag, paths are not rewritten at all
+	raw.pathMap = ""
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.pathRewriter, chk.IsNil)
+
+	raw.pathMap = file.Name() + ".missing"
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "cannot read path map.*")
+}
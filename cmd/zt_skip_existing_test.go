@@ -0,0 +1,82 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type skipExistingSuite struct{}
+
+var _ = chk.Suite(&skipExistingSuite{})
+
+func (s *skipExistingSuite) TestLookupInLocalDestination(c *chk.C) {
+	dstDir, err := ioutil.TempDir("", "skipExisting")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dstDir)
+
+	c.Assert(os.MkdirAll(filepath.Join(dstDir, "sub dir"), 0755), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dstDir, "a.txt"), make([]byte, 10), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dstDir, "sub dir", "b.txt"), make([]byte, 20), 0644), chk.IsNil)
+
+	cca := &CookedCopyCmdArgs{
+		FromTo:      common.EFromTo.BlobLocal(),
+		Destination: common.ResourceString{Value: dstDir},
+	}
+	lookup, err := cca.newDestinationSizeLookup(context.Background())
+	c.Assert(err, chk.IsNil)
+
+	size, exists := lookup.sizeOf("/sub dir/b.txt")
+	c.Assert(exists, chk.Equals, true)
+	c.Assert(size, chk.Equals, int64(20))
+
+	_, exists = lookup.sizeOf("/c.txt")
+	c.Assert(exists, chk.Equals, false)
+	_, exists = lookup.sizeOf("/sub dir") // folders are never skipped
+	c.Assert(exists, chk.Equals, false)
+}
+
+func (s *skipExistingSuite) TestLookupInMissingDestinationFindsNothing(c *chk.C) {
+	cca := &CookedCopyCmdArgs{
+		FromTo:      common.EFromTo.BlobLocal(),
+		Destination: common.ResourceString{Value: filepath.Join(os.TempDir(), "skipExistingMissing", "dir")},
+	}
+	lookup, err := cca.newDestinationSizeLookup(context.Background())
+	c.Assert(err, chk.IsNil)
+	_, exists := lookup.sizeOf("/a.txt")
+	c.Assert(exists, chk.Equals, false)
+}
+
+func (s *skipExistingSuite) TestRemoteFileURLKeepsEscapingAndSAS(c *chk.C) {
+	lookup := &destinationSizeLookup{
+		location: common.ELocation.Blob(),
+		root:     common.ResourceString{Value: "https://account.blob.core.windows.net/container/dir", SAS: "sv=2020&sig=abc"},
+	}
+
+	u, err := lookup.fileURL("/sub%20dir/100%25.txt")
+	c.Assert(err, chk.IsNil)
+	c.Assert(u.String(), chk.Equals, "https://account.blob.core.windows.net/container/dir/sub%20dir/100%25.txt?sv=2020&sig=abc")
+}
@@ -699,7 +699,7 @@ func (s *cmdIntegrationSuite) TestSyncS2SWithIncludeRegexFlag(c *chk.C) {
 	// add special blobs that we wish to include
 	blobsToInclude := []string{"tessssssssssssst.txt", "zxcfile.txt", "subOne/tetingessssss.jpeg", "subOne/subTwo/tessssst.pdf"}
 	scenarioHelper{}.generateBlobsFromList(c, srcContainerURL, blobsToInclude, blockBlobDefaultData)
-	includeString := "es{4,};^zxc"
+	includeString := ".*es{4,}.*;zxc.*"
 
 	// set up interceptor
 	mockedRPC := interceptor{}
@@ -745,7 +745,7 @@ func (s *cmdIntegrationSuite) TestSyncS2SWithExcludeRegexFlag(c *chk.C) {
 	// add special blobs that we wish to exclude
 	blobsToExclude := []string{"tessssssssssssst.txt", "subOne/dogs.jpeg", "subOne/subTwo/tessssst.pdf"}
 	scenarioHelper{}.generateBlobsFromList(c, srcContainerURL, blobsToExclude, blockBlobDefaultData)
-	excludeString := "es{4,};o(g)"
+	excludeString := ".*es{4,}.*;.*o(g).*"
 
 	// set up interceptor
 	mockedRPC := interceptor{}
@@ -783,12 +783,12 @@ func (s *cmdIntegrationSuite) TestSyncS2SWithIncludeAndExcludeRegexFlag(c *chk.C
 	// add special blobs that we wish to include
 	blobsToInclude := []string{"tessssssssssssst.txt", "zxcfile.txt", "subOne/tetingessssss.jpeg"}
 	scenarioHelper{}.generateBlobsFromList(c, srcContainerURL, blobsToInclude, blockBlobDefaultData)
-	includeString := "es{4,};^zxc"
+	includeString := ".*es{4,}.*;zxc.*"
 
 	// add special blobs that we wish to exclude
 	blobsToExclude := []string{"zxca.txt", "subOne/dogs.jpeg", "subOne/subTwo/zxcat.pdf"}
 	scenarioHelper{}.generateBlobsFromList(c, srcContainerURL, blobsToExclude, blockBlobDefaultData)
-	excludeString := "^zxca;o(g)"
+	excludeString := "zxca.*;.*o(g).*"
 
 	// set up interceptor
 	mockedRPC := interceptor{}
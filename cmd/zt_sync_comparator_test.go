@@ -21,8 +21,15 @@
 package cmd
 
 import (
-	chk "gopkg.in/check.v1"
+	"crypto/md5"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
 )
 
 type syncComparatorSuite struct{}
@@ -36,7 +43,7 @@ func (s *syncComparatorSuite) TestSyncSourceComparator(c *chk.C) {
 
 	// set up the indexer as well as the source comparator
 	indexer := newObjectIndexer()
-	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, false)
+	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, false, nil, nil, nil)
 
 	// create a sample destination object
 	sampleDestinationObject := StoredObject{name: "test", relativePath: "/usr/test", lastModifiedTime: time.Now(), md5: destMD5}
@@ -88,7 +95,7 @@ func (s *syncComparatorSuite) TestSyncSrcCompDisableComparator(c *chk.C) {
 
 	// set up the indexer as well as the source comparator
 	indexer := newObjectIndexer()
-	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, true)
+	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, true, nil, nil, nil)
 
 	// test the comparator in case a given source object is not present at the destination
 	// meaning no entry in the index, so the comparator should pass the given object to schedule a transfer
@@ -137,7 +144,7 @@ func (s *syncComparatorSuite) TestSyncDestinationComparator(c *chk.C) {
 
 	// set up the indexer as well as the destination comparator
 	indexer := newObjectIndexer()
-	destinationComparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process, false)
+	destinationComparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process, false, nil, nil, nil)
 
 	// create a sample source object
 	sampleSourceObject := StoredObject{name: "test", relativePath: "/usr/test", lastModifiedTime: time.Now(), md5: srcMD5}
@@ -194,7 +201,7 @@ func (s *syncComparatorSuite) TestSyncDestCompDisableComparison(c *chk.C) {
 
 	// set up the indexer as well as the destination comparator
 	indexer := newObjectIndexer()
-	destinationComparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process, true)
+	destinationComparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process, true, nil, nil, nil)
 
 	// create a sample source object
 	currTime := time.Now()
@@ -237,3 +244,284 @@ func (s *syncComparatorSuite) TestSyncDestCompDisableComparison(c *chk.C) {
 		c.Assert(len(dummyCopyScheduler.record), chk.Equals, key+1)
 	}
 }
+
+func (s *syncComparatorSuite) TestSyncComparatorByMD5(c *chk.C) {
+	dummyCopyScheduler := dummyProcessor{}
+	sameMD5 := []byte{'s'}
+
+	// the destination is local, so its hashes are computed from the files themselves
+	destDir, err := ioutil.TempDir("", "synccomparator")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(destDir)
+	content := []byte("same content at both ends")
+	c.Assert(ioutil.WriteFile(filepath.Join(destDir, "local"), content, 0644), chk.IsNil)
+	localMD5 := md5.Sum(content)
+
+	indexer := newObjectIndexer()
+	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, false, &syncHashComparer{destinationLocalRoot: destDir}, nil, nil)
+
+	// every source object is newer than its destination, which doesn't matter when comparing by MD5
+	newer := time.Now().Add(time.Hour)
+	cases := []struct {
+		source         StoredObject
+		shouldTransfer bool
+	}{
+		{StoredObject{name: "local", relativePath: "local", lastModifiedTime: newer, md5: localMD5[:]}, false},
+		{StoredObject{name: "local", relativePath: "local", lastModifiedTime: newer, md5: sameMD5}, true},
+		{StoredObject{name: "local", relativePath: "local", lastModifiedTime: newer}, true},
+		{StoredObject{name: "missing", relativePath: "missing", lastModifiedTime: newer, md5: sameMD5}, true},
+	}
+
+	for _, tc := range cases {
+		dummyCopyScheduler.record = nil
+		err = indexer.store(StoredObject{name: tc.source.name, relativePath: tc.source.relativePath, lastModifiedTime: time.Now()})
+		c.Assert(err, chk.IsNil)
+		c.Assert(sourceComparator.processIfNecessary(tc.source), chk.IsNil)
+		c.Assert(len(dummyCopyScheduler.record) == 1, chk.Equals, tc.shouldTransfer, chk.Commentf("%+v", tc.source))
+	}
+}
+
+func (s *syncComparatorSuite) TestSyncComparatorByMD5ReusesCachedHashes(c *chk.C) {
+	srcDir := c.MkDir()
+	cachePath := filepath.Join(c.MkDir(), "checksums.json")
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	destinationMD5s := map[string][]byte{}
+	for _, name := range names {
+		content := []byte("content of " + name)
+		c.Assert(ioutil.WriteFile(filepath.Join(srcDir, name), content, 0644), chk.IsNil)
+		sum := md5.Sum(content)
+		destinationMD5s[name] = sum[:]
+	}
+
+	// each run is a sync uploading the local files to a destination that has them all already, with their hashes stored
+	run := func() (comparer *syncHashComparer, differing []string) {
+		comparer = &syncHashComparer{sourceLocalRoot: srcDir, cache: loadChecksumCache(cachePath)}
+		for _, name := range names {
+			source := StoredObject{name: name, relativePath: name}
+			destination := StoredObject{name: name, relativePath: name, md5: destinationMD5s[name]}
+			if comparer.contentDiffers(source, destination) {
+				differing = append(differing, name)
+			}
+		}
+		comparer.finish()
+		return comparer, differing
+	}
+
+	comparer, differing := run()
+	c.Assert(differing, chk.HasLen, 0)
+	c.Assert(comparer.atomicFilesHashed, chk.Equals, uint64(len(names)))
+	c.Assert(comparer.atomicCachedHashes, chk.Equals, uint64(0))
+
+	// nothing has changed, so nothing is read again
+	comparer, differing = run()
+	c.Assert(differing, chk.HasLen, 0)
+	c.Assert(comparer.atomicFilesHashed, chk.Equals, uint64(0))
+	c.Assert(comparer.atomicCachedHashes, chk.Equals, uint64(len(names)))
+
+	// a file whose size has changed, and one whose last modified time has, are read again, and only those
+	c.Assert(ioutil.WriteFile(filepath.Join(srcDir, "b"), []byte("longer new content of b"), 0644), chk.IsNil)
+	later := time.Now().Add(time.Hour)
+	c.Assert(os.Chtimes(filepath.Join(srcDir, "e"), later, later), chk.IsNil)
+	comparer, differing = run()
+	c.Assert(differing, chk.DeepEquals, []string{"b"})
+	c.Assert(comparer.atomicFilesHashed, chk.Equals, uint64(2))
+	c.Assert(comparer.atomicCachedHashes, chk.Equals, uint64(len(names)-2))
+
+	// a cache that can't be read is treated as empty, and replaced
+	c.Assert(ioutil.WriteFile(cachePath, []byte("{not json"), 0644), chk.IsNil)
+	comparer, differing = run()
+	c.Assert(differing, chk.DeepEquals, []string{"b"})
+	c.Assert(comparer.atomicFilesHashed, chk.Equals, uint64(len(names)))
+	comparer, _ = run()
+	c.Assert(comparer.atomicFilesHashed, chk.Equals, uint64(0))
+}
+
+func (s *syncComparatorSuite) TestSyncComparatorExcludesFilesNewerAtDestination(c *chk.C) {
+	now := time.Now()
+	destination := []StoredObject{
+		{name: "edited", relativePath: "edited", entityType: common.EEntityType.File(), lastModifiedTime: now},
+		{name: "stale", relativePath: "stale", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-2 * time.Hour)},
+		{name: "folder", relativePath: "folder", entityType: common.EEntityType.Folder(), lastModifiedTime: now},
+	}
+	source := []StoredObject{
+		{name: "edited", relativePath: "edited", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-time.Hour)},
+		{name: "stale", relativePath: "stale", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-time.Hour)},
+		{name: "folder", relativePath: "folder", entityType: common.EEntityType.Folder(), lastModifiedTime: now.Add(-time.Hour)},
+	}
+
+	// mirror mode would transfer everything, but files newer at the destination are still left alone
+	for _, sourceFirst := range []bool{true, false} {
+		dummyCopyScheduler := dummyProcessor{}
+		dummyCleaner := dummyProcessor{}
+		var destinationNewer []string
+		onDestinationNewer := func(source StoredObject) { destinationNewer = append(destinationNewer, source.relativePath) }
+
+		indexer := newObjectIndexer()
+		if sourceFirst {
+			comparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process, true, nil, nil, onDestinationNewer)
+			for i := range source {
+				c.Assert(indexer.store(source[i]), chk.IsNil)
+				c.Assert(comparator.processIfNecessary(destination[i]), chk.IsNil)
+			}
+		} else {
+			comparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, true, nil, nil, onDestinationNewer)
+			for i := range source {
+				c.Assert(indexer.store(destination[i]), chk.IsNil)
+				c.Assert(comparator.processIfNecessary(source[i]), chk.IsNil)
+			}
+		}
+
+		c.Assert(destinationNewer, chk.DeepEquals, []string{"edited"})
+		c.Assert(len(dummyCopyScheduler.record), chk.Equals, 2)
+		c.Assert(dummyCopyScheduler.record[0].relativePath, chk.Equals, "stale")
+		c.Assert(dummyCopyScheduler.record[1].relativePath, chk.Equals, "folder") // folders have their properties synced as usual
+		c.Assert(len(dummyCleaner.record), chk.Equals, 0)
+	}
+}
+
+func (s *syncComparatorSuite) TestSyncComparatorMirrorsProperties(c *chk.C) {
+	older := time.Now().Add(-time.Hour)
+	destination := StoredObject{name: "same", relativePath: "same", entityType: common.EEntityType.File(), lastModifiedTime: time.Now(),
+		contentType: "text/plain", Metadata: common.Metadata{"Author": "someone"}, blobAccessTier: azblob.AccessTierHot}
+	withProperties := func(contentType string, metadata common.Metadata, tier azblob.AccessTierType) StoredObject {
+		return StoredObject{name: "same", relativePath: "same", entityType: common.EEntityType.File(), lastModifiedTime: older,
+			contentType: contentType, Metadata: metadata, blobAccessTier: tier}
+	}
+
+	cases := []struct {
+		comparer       *syncPropertyComparer
+		source         StoredObject
+		shouldTransfer bool
+	}{
+		// the source is older, so without --mirror nothing is transferred
+		{nil, withProperties("application/json", nil, azblob.AccessTierCool), false},
+		{&syncPropertyComparer{metadata: true, contentType: true}, withProperties("text/plain", common.Metadata{"author": "someone"}, azblob.AccessTierCool), false},
+		{&syncPropertyComparer{metadata: true, contentType: true}, withProperties("application/json", common.Metadata{"author": "someone"}, azblob.AccessTierHot), true},
+		{&syncPropertyComparer{metadata: true}, withProperties("application/json", common.Metadata{"author": "someone"}, azblob.AccessTierHot), false},
+		{&syncPropertyComparer{metadata: true}, withProperties("text/plain", common.Metadata{"author": "someone else"}, azblob.AccessTierHot), true},
+		{&syncPropertyComparer{metadata: true}, withProperties("text/plain", nil, azblob.AccessTierHot), true},
+		{&syncPropertyComparer{contentType: true}, withProperties("text/plain", nil, azblob.AccessTierHot), false},
+		{&syncPropertyComparer{tier: true}, withProperties("text/plain", nil, azblob.AccessTierCool), true},
+		{&syncPropertyComparer{tier: true}, withProperties("text/plain", nil, azblob.AccessTierNone), false}, // e.g. a source on Azure Files
+	}
+
+	for _, tc := range cases {
+		dummyCopyScheduler := dummyProcessor{}
+		indexer := newObjectIndexer()
+		comparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, false, nil, tc.comparer, nil)
+		c.Assert(indexer.store(destination), chk.IsNil)
+		c.Assert(comparator.processIfNecessary(tc.source), chk.IsNil)
+		c.Assert(len(dummyCopyScheduler.record) == 1, chk.Equals, tc.shouldTransfer, chk.Commentf("%+v with %+v", tc.source, tc.comparer))
+	}
+}
+
+func (s *syncComparatorSuite) TestSyncComparatorSchedulesUpdatesSeparately(c *chk.C) {
+	now := time.Now()
+	source := []StoredObject{
+		{name: "changed", relativePath: "changed", entityType: common.EEntityType.File(), lastModifiedTime: now},
+		{name: "same", relativePath: "same", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-2 * time.Hour)},
+		{name: "new", relativePath: "new", entityType: common.EEntityType.File(), lastModifiedTime: now},
+	}
+	destination := []StoredObject{
+		{name: "changed", relativePath: "changed", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-time.Hour)},
+		{name: "same", relativePath: "same", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-time.Hour)},
+	}
+
+	for _, sourceFirst := range []bool{true, false} {
+		dummyCopyScheduler := dummyProcessor{}
+		dummyUpdateScheduler := dummyProcessor{}
+		dummyCleaner := dummyProcessor{}
+
+		indexer := newObjectIndexer()
+		if sourceFirst {
+			comparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process, false, nil, nil, nil)
+			comparator.updateScheduler = dummyUpdateScheduler.process
+			for _, object := range source {
+				c.Assert(indexer.store(object), chk.IsNil)
+			}
+			for _, object := range destination {
+				c.Assert(comparator.processIfNecessary(object), chk.IsNil)
+			}
+			// what is left in the index is new at the destination, and scheduled as in initEnumerator
+			c.Assert(indexer.traverse(dummyCopyScheduler.process, nil), chk.IsNil)
+		} else {
+			comparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process, false, nil, nil, nil)
+			comparator.updateScheduler = dummyUpdateScheduler.process
+			for _, object := range destination {
+				c.Assert(indexer.store(object), chk.IsNil)
+			}
+			for _, object := range source {
+				c.Assert(comparator.processIfNecessary(object), chk.IsNil)
+			}
+		}
+
+		c.Assert(len(dummyUpdateScheduler.record), chk.Equals, 1)
+		c.Assert(dummyUpdateScheduler.record[0].relativePath, chk.Equals, "changed")
+		c.Assert(len(dummyCopyScheduler.record), chk.Equals, 1)
+		c.Assert(dummyCopyScheduler.record[0].relativePath, chk.Equals, "new")
+		c.Assert(len(dummyCleaner.record), chk.Equals, 0)
+	}
+}
+
+func (s *syncComparatorSuite) TestSyncComparatorSortsFilesForCompareReport(c *chk.C) {
+	now := time.Now()
+	source := []StoredObject{
+		{name: "changed", relativePath: "dir/changed", entityType: common.EEntityType.File(), lastModifiedTime: now},
+		{name: "same", relativePath: "same", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-2 * time.Hour)},
+		{name: "new", relativePath: "new", entityType: common.EEntityType.File(), lastModifiedTime: now},
+		{name: "dir", relativePath: "dir", entityType: common.EEntityType.Folder(), lastModifiedTime: now},
+	}
+	destination := []StoredObject{
+		{name: "changed", relativePath: "dir/changed", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-time.Hour)},
+		{name: "same", relativePath: "same", entityType: common.EEntityType.File(), lastModifiedTime: now.Add(-time.Hour)},
+		{name: "extra", relativePath: "extra", entityType: common.EEntityType.File(), lastModifiedTime: now},
+		{name: "dir", relativePath: "dir", entityType: common.EEntityType.Folder(), lastModifiedTime: now.Add(-time.Hour)},
+	}
+
+	for _, sourceFirst := range []bool{true, false} {
+		report := newSyncCompareReport()
+		onlyInSource := recordInSyncCompareReport(&report.OnlyInSource)
+		extra := recordInSyncCompareReport(&report.OnlyInDestination)
+
+		indexer := newObjectIndexer()
+		if sourceFirst {
+			comparator := newSyncDestinationComparator(indexer, onlyInSource, extra, false, nil, nil, nil)
+			comparator.updateScheduler = recordInSyncCompareReport(&report.Differing)
+			comparator.inSyncProcessor = recordInSyncCompareReport(&report.Identical)
+			for _, object := range source {
+				c.Assert(indexer.store(object), chk.IsNil)
+			}
+			for _, object := range destination {
+				c.Assert(comparator.processIfNecessary(object), chk.IsNil)
+			}
+			c.Assert(indexer.traverse(onlyInSource, nil), chk.IsNil)
+		} else {
+			comparator := newSyncSourceComparator(indexer, onlyInSource, false, nil, nil, nil)
+			comparator.updateScheduler = recordInSyncCompareReport(&report.Differing)
+			comparator.inSyncProcessor = recordInSyncCompareReport(&report.Identical)
+			for _, object := range destination {
+				c.Assert(indexer.store(object), chk.IsNil)
+			}
+			for _, object := range source {
+				c.Assert(comparator.processIfNecessary(object), chk.IsNil)
+			}
+			c.Assert(indexer.traverse(extra, nil), chk.IsNil)
+		}
+
+		// the folders are compared, but left out of the report
+		c.Assert(*report, chk.DeepEquals, common.SyncCompareReport{
+			OnlyInSource:      []string{"new"},
+			OnlyInDestination: []string{"extra"},
+			Differing:         []string{"dir/changed"},
+			Identical:         []string{"same"},
+		})
+	}
+
+	// every list is in the JSON, even an empty one, and the files are sorted
+	report := newSyncCompareReport()
+	report.Differing = append(report.Differing, "b", "a")
+	builder := syncCompareReportBuilder(report)
+	c.Assert(builder(common.EOutputFormat.Json()), chk.Equals, `{"OnlyInSource":[],"OnlyInDestination":[],"Differing":["a","b"],"Identical":[]}`)
+	c.Assert(builder(common.EOutputFormat.Text()), chk.Equals, "COMPARE: differing: a\nCOMPARE: differing: b\n"+
+		"COMPARE: files only in source: 0, only in destination: 0, differing: 2, identical: 0")
+}
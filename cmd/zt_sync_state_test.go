@@ -0,0 +1,115 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type syncStateSuite struct{}
+
+var _ = chk.Suite(&syncStateSuite{})
+
+func (s *syncStateSuite) newSyncWithState(dir string) *cookedSyncCmdArgs {
+	return &cookedSyncCmdArgs{
+		source:        common.ResourceString{Value: filepath.Join(dir, "source")},
+		destination:   common.ResourceString{Value: "https://acct.blob.core.windows.net/container", SAS: "sig=secret"},
+		fromTo:        common.EFromTo.LocalBlob(),
+		jobID:         common.NewJobID(),
+		syncStatePath: filepath.Join(dir, "sync.state"),
+	}
+}
+
+func (s *syncStateSuite) TestSyncStatePathFromFlag(c *chk.C) {
+	dir, err := ioutil.TempDir("", "syncstate")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	path, err := syncStatePathFromFlag("")
+	c.Assert(err, chk.IsNil)
+	c.Assert(path, chk.Equals, "")
+
+	path, err = syncStatePathFromFlag(filepath.Join(dir, "sync.state"))
+	c.Assert(err, chk.IsNil)
+	c.Assert(filepath.IsAbs(path), chk.Equals, true)
+
+	_, err = syncStatePathFromFlag(filepath.Join(dir, "missing", "sync.state"))
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *syncStateSuite) TestSyncStateIsIgnoredForOtherRoots(c *chk.C) {
+	dir, err := ioutil.TempDir("", "syncstate")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	mockedLcm := mockedLifecycleManager{infoLog: make(chan string, 50)}
+	glcm = &mockedLcm
+
+	cca := s.newSyncWithState(dir)
+	_, found := cca.readSyncState()
+	c.Assert(found, chk.Equals, false) // there isn't one yet
+
+	cca.saveSyncState()
+	raw, err := ioutil.ReadFile(cca.syncStatePath)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(raw), chk.Not(chk.Matches), ".*secret.*")
+
+	// the state is only for the same source and destination
+	other := s.newSyncWithState(dir)
+	other.destination.Value = "https://acct.blob.core.windows.net/other"
+	_, found = other.readSyncState()
+	c.Assert(found, chk.Equals, false)
+
+	other = s.newSyncWithState(dir)
+	other.fromTo = common.EFromTo.LocalFile()
+	_, found = other.readSyncState()
+	c.Assert(found, chk.Equals, false)
+}
+
+func (s *syncStateSuite) TestSyncStateIsOnlyRemovedOnceEverythingIsTransferred(c *chk.C) {
+	dir, err := ioutil.TempDir("", "syncstate")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	mockedLcm := mockedLifecycleManager{infoLog: make(chan string, 50)}
+	glcm = &mockedLcm
+
+	cca := s.newSyncWithState(dir)
+	cca.saveSyncState()
+
+	status := common.EJobStatus
+	for _, summary := range []common.ListJobSummaryResponse{
+		{JobStatus: status.CompletedWithErrors(), TotalTransfers: 2, TransfersCompleted: 1, TransfersFailed: 1},
+		{JobStatus: status.Cancelled(), TotalTransfers: 2, TransfersCompleted: 1},
+		{JobStatus: status.Completed(), TotalTransfers: 2, TransfersCompleted: 1}, // the other transfer was cancelled
+	} {
+		cca.finishSyncState(summary)
+		_, err = os.Stat(cca.syncStatePath)
+		c.Assert(err, chk.IsNil, chk.Commentf("the state should be kept after %v", summary))
+	}
+
+	cca.finishSyncState(common.ListJobSummaryResponse{JobStatus: status.CompletedWithSkipped(), TotalTransfers: 2, TransfersCompleted: 1, TransfersSkipped: 1})
+	_, err = os.Stat(cca.syncStatePath)
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
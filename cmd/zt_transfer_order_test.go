@@ -0,0 +1,53 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type transferOrderSuite struct{}
+
+var _ = chk.Suite(&transferOrderSuite{})
+
+func (s *transferOrderSuite) TestValidateTransferOrder(c *chk.C) {
+	c.Assert(validateTransferOrder(common.ETransferOrder.Listed(), ""), chk.IsNil)
+	c.Assert(validateTransferOrder(common.ETransferOrder.SmallestFirst(), ""), chk.IsNil)
+	c.Assert(validateTransferOrder(common.ETransferOrder.PatternPriority(), "*.json;index.*"), chk.IsNil)
+
+	// a pattern only makes sense with PatternPriority, and PatternPriority needs one
+	c.Assert(validateTransferOrder(common.ETransferOrder.LargestFirst(), "*.json"), chk.NotNil)
+	c.Assert(validateTransferOrder(common.ETransferOrder.PatternPriority(), ""), chk.NotNil)
+	c.Assert(validateTransferOrder(common.ETransferOrder.PatternPriority(), "*.json;[a-"), chk.NotNil)
+}
+
+func (s *transferOrderSuite) TestPriorityPatternBracesAreExpanded(c *chk.C) {
+	raw := getDefaultRawCopyInput(c.MkDir(), "https://account.blob.core.windows.net/container")
+	raw.transferOrder = common.ETransferOrder.PatternPriority().String()
+	raw.priorityPattern = "{index,main}.*;*.json"
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.priorityPattern, chk.Equals, "index.*;main.*;*.json")
+
+	raw.priorityPattern = "{index,main.*"
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, ".*priority-pattern.*")
+}
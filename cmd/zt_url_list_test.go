@@ -0,0 +1,198 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type urlListSuite struct{}
+
+var _ = chk.Suite(&urlListSuite{})
+
+// urlListChildStub stands in for the traverser of one URL, and finds the given objects, relative to that URL
+type urlListChildStub struct {
+	objects []StoredObject
+	err     error
+}
+
+func (s *urlListChildStub) IsDirectory(bool) bool {
+	return false
+}
+
+func (s *urlListChildStub) Traverse(preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+	for _, object := range s.objects {
+		preprocessor(&object)
+		if err := processor(object); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func newUrlListTraverserForTest(keep common.KeepFromUrl, failures *urlListFailures, children map[string]*urlListChildStub, urls ...string) *urlListTraverser {
+	urlChan := make(chan string, len(urls))
+	for _, u := range urls {
+		urlChan <- u
+	}
+	close(urlChan)
+
+	return &urlListTraverser{
+		urls:     urlChan,
+		keep:     keep,
+		failures: failures,
+		childTraverserGenerator: func(source common.ResourceString) (ResourceTraverser, error) {
+			return children[source.Value], nil
+		},
+		recursive: true,
+	}
+}
+
+func (s *urlListSuite) TestUrlListTraverserListsEachUrlUnderItsContainer(c *chk.C) {
+	children := map[string]*urlListChildStub{
+		"https://acct1.blob.core.windows.net/c1/a.txt": {objects: []StoredObject{{name: "a.txt", entityType: common.EEntityType.File()}}},
+		"https://acct2.blob.core.windows.net/c2/dir": {objects: []StoredObject{
+			{name: "b.txt", relativePath: "b.txt", entityType: common.EEntityType.File()},
+			{name: "c.txt", relativePath: "sub/c.txt", entityType: common.EEntityType.File()},
+		}},
+		"https://acct1.blob.core.windows.net/c1/missing": {},
+		"https://acct1.blob.core.windows.net/c1/denied":  {err: errors.New("403")},
+	}
+	failures := &urlListFailures{}
+	traverser := newUrlListTraverserForTest(common.EKeepFromUrl.ContainerAndBlobName(), failures, children,
+		"https://acct1.blob.core.windows.net/c1/a.txt?sv=2020-02-10&sig=one",
+		"https://acct2.blob.core.windows.net/c2/dir?sv=2020-02-10&sig=two",
+		"https://acct1.blob.core.windows.net/c1/missing?sv=2020-02-10&sig=one",
+		"https://acct1.blob.core.windows.net/c1/denied",
+		"https://acct1.file.core.windows.net/share/f.txt",
+	)
+
+	var listed []StoredObject
+	err := traverser.Traverse(noPreProccessor, func(object StoredObject) error {
+		listed = append(listed, object)
+		return nil
+	}, nil)
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(listed, chk.HasLen, 3)
+	expected := []struct {
+		root         common.ResourceString
+		relativePath string
+		keptPath     string
+	}{
+		{common.ResourceString{Value: "https://acct1.blob.core.windows.net/c1", SAS: "sig=one&sv=2020-02-10"}, "a.txt", "c1/a.txt"},
+		{common.ResourceString{Value: "https://acct2.blob.core.windows.net/c2", SAS: "sig=two&sv=2020-02-10"}, "dir/b.txt", "c2/dir/b.txt"},
+		{common.ResourceString{Value: "https://acct2.blob.core.windows.net/c2", SAS: "sig=two&sv=2020-02-10"}, "dir/sub/c.txt", "c2/dir/sub/c.txt"},
+	}
+	for i, x := range expected {
+		c.Assert(*listed[i].sourceRoot, chk.Equals, x.root)
+		c.Assert(listed[i].relativePath, chk.Equals, x.relativePath)
+		c.Assert(listed[i].keptPath, chk.Equals, x.keptPath)
+	}
+
+	// the URLs that could not be listed are failures, rather than errors that stop the traversal
+	c.Assert(failures.failed, chk.HasLen, 3)
+	c.Assert(failures.failed[0].Src, chk.Equals, "https://acct1.blob.core.windows.net/c1/missing?sig=REDACTED&sv=2020-02-10")
+	c.Assert(failures.failed[0].ErrorCode, chk.Equals, int32(404))
+	c.Assert(failures.failed[1].Src, chk.Equals, "https://acct1.blob.core.windows.net/c1/denied")
+	c.Assert(failures.failed[2].Src, chk.Equals, "https://acct1.file.core.windows.net/share/f.txt")
+}
+
+func (s *urlListSuite) TestUrlListTraverserStopsOnProcessorError(c *chk.C) {
+	children := map[string]*urlListChildStub{
+		"https://acct1.blob.core.windows.net/c1/a.txt": {objects: []StoredObject{{name: "a.txt", entityType: common.EEntityType.File()}}},
+	}
+	failures := &urlListFailures{}
+	traverser := newUrlListTraverserForTest(common.EKeepFromUrl.BlobName(), failures, children,
+		"https://acct1.blob.core.windows.net/c1/a.txt",
+		"https://acct1.blob.core.windows.net/c1/a.txt",
+	)
+
+	stop := errors.New("too many files")
+	calls := 0
+	err := traverser.Traverse(noPreProccessor, func(object StoredObject) error {
+		calls++
+		return stop
+	}, nil)
+	c.Assert(err, chk.Equals, stop)
+	c.Assert(calls, chk.Equals, 1)
+	c.Assert(failures.failed, chk.HasLen, 0)
+}
+
+func (s *urlListSuite) TestKeptPath(c *chk.C) {
+	c.Assert(keptPath(common.EKeepFromUrl.BlobName(), "c1", "dir/a.txt"), chk.Equals, "dir/a.txt")
+	c.Assert(keptPath(common.EKeepFromUrl.ContainerAndBlobName(), "c1", "dir/a.txt"), chk.Equals, "c1/dir/a.txt")
+	c.Assert(keptPath(common.EKeepFromUrl.FileName(), "c1", "dir/a.txt"), chk.Equals, "a.txt")
+}
+
+func (s *urlListSuite) TestFromToForListOfUrls(c *chk.C) {
+	fromTo, err := fromToForListOfUrls("/tmp/dest", "")
+	c.Assert(err, chk.IsNil)
+	c.Assert(fromTo, chk.Equals, common.EFromTo.BlobLocal())
+
+	fromTo, err = fromToForListOfUrls("https://acct.file.core.windows.net/share", "")
+	c.Assert(err, chk.IsNil)
+	c.Assert(fromTo, chk.Equals, common.EFromTo.BlobFile())
+
+	_, err = fromToForListOfUrls("/tmp/dest", "LocalBlob")
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *urlListSuite) TestUrlListFailuresAreAddedToSummary(c *chk.C) {
+	failures := &urlListFailures{}
+	failures.add("https://acct.blob.core.windows.net/c/a", errNoBlobAtUrl)
+
+	summary := common.ListJobSummaryResponse{TotalTransfers: 2, TransfersCompleted: 1}
+	failures.addTo(&summary)
+	c.Assert(summary.TotalTransfers, chk.Equals, uint32(3))
+	c.Assert(summary.TransfersFailed, chk.Equals, uint32(1))
+	c.Assert(summary.FailedTransfers, chk.HasLen, 1)
+
+	// like the engine, later summaries only list the new failures, but count them all
+	failures.add("https://acct.blob.core.windows.net/c/b", errNoBlobAtUrl)
+	summary = common.ListJobSummaryResponse{TotalTransfers: 2, TransfersCompleted: 2, JobStatus: common.EJobStatus.Completed()}
+	failures.addTo(&summary)
+	c.Assert(summary.TransfersFailed, chk.Equals, uint32(2))
+	c.Assert(summary.FailedTransfers, chk.HasLen, 1)
+	c.Assert(summary.FailedTransfers[0].Src, chk.Equals, "https://acct.blob.core.windows.net/c/b")
+	c.Assert(summary.JobStatus, chk.Equals, common.EJobStatus.CompletedWithErrors())
+}
+
+func (s *urlListSuite) TestReadListOfUrlsSkipsBlankLinesAndComments(c *chk.C) {
+	f, err := ioutil.TempFile("", "urls")
+	c.Assert(err, chk.IsNil)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("\xEF\xBB\xBFhttps://acct.blob.core.windows.net/c/a\r\n\n# a comment\n  https://acct.blob.core.windows.net/c/b  \n")
+	c.Assert(err, chk.IsNil)
+	c.Assert(f.Close(), chk.IsNil)
+
+	urls, err := readListOfUrls(f.Name())
+	c.Assert(err, chk.IsNil)
+	var read []string
+	for u := range urls {
+		read = append(read, u)
+	}
+	c.Assert(read, chk.DeepEquals, []string{"https://acct.blob.core.windows.net/c/a", "https://acct.blob.core.windows.net/c/b"})
+}
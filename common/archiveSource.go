@@ -0,0 +1,276 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArchiveSource is a local tar or zip file whose entries are uploaded as if they were files and folders of their own.
+// The entries are read straight out of the archive, without being extracted to disk first
+type ArchiveSource struct {
+	path    string
+	format  ArchiveFormat
+	zip     *zip.ReadCloser
+	entries []*ArchiveSourceEntry
+	byName  map[string]*ArchiveSourceEntry
+
+	// SkippedEntries counts the entries that are neither files nor folders, such as links, and so aren't uploaded
+	SkippedEntries int
+}
+
+// ArchiveSourceEntry is a file or folder in an ArchiveSource
+type ArchiveSourceEntry struct {
+	// Name is the entry's path in the archive, with forward slashes, and without any leading ./ or trailing /
+	Name     string
+	IsFolder bool
+	Size     int64
+	ModTime  time.Time
+
+	tarOffset int64     // where a tar entry's data starts in the archive
+	zipFile   *zip.File // a zip entry
+}
+
+// OpenArchiveSource reads the list of entries of a tar or zip file. For a tar, that means reading every header,
+// but the data in between is skipped over
+func OpenArchiveSource(archivePath string, format ArchiveFormat) (*ArchiveSource, error) {
+	a := &ArchiveSource{path: archivePath, format: format, byName: make(map[string]*ArchiveSourceEntry)}
+	var err error
+	switch format {
+	case EArchiveFormat.Tar():
+		err = a.readTarEntries()
+	case EArchiveFormat.Zip():
+		err = a.readZipEntries()
+	default:
+		err = fmt.Errorf("entries can't be read from archives of format %s", format)
+	}
+	if err != nil {
+		_ = a.Close()
+		return nil, fmt.Errorf("reading the entries of %s: %w", archivePath, err)
+	}
+	return a, nil
+}
+
+func (a *ArchiveSource) readTarEntries() error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// tar.Reader reads exactly one header at a time, and seeks over the data that it isn't asked for,
+	// so after each header the file is positioned where that entry's data starts
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			if isSparseTarEntry(hdr) {
+				return fmt.Errorf("%s is a sparse file, which can't be read in place", hdr.Name)
+			}
+			offset, err := f.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			a.add(&ArchiveSourceEntry{Name: hdr.Name, Size: hdr.Size, ModTime: hdr.ModTime, tarOffset: offset})
+		case tar.TypeDir:
+			a.add(&ArchiveSourceEntry{Name: hdr.Name, IsFolder: true, ModTime: hdr.ModTime})
+		case tar.TypeGNUSparse:
+			return fmt.Errorf("%s is a sparse file, which can't be read in place", hdr.Name)
+		default:
+			a.SkippedEntries++
+		}
+	}
+}
+
+// a sparse entry's data isn't all in one place, since the holes are left out of it
+func isSparseTarEntry(hdr *tar.Header) bool {
+	for k := range hdr.PAXRecords {
+		if strings.HasPrefix(k, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ArchiveSource) readZipEntries() error {
+	var err error
+	a.zip, err = zip.OpenReader(a.path)
+	if err != nil {
+		return err
+	}
+	for _, f := range a.zip.File {
+		if f.FileInfo().IsDir() {
+			a.add(&ArchiveSourceEntry{Name: f.Name, IsFolder: true, ModTime: f.Modified})
+		} else if f.Mode().IsRegular() {
+			a.add(&ArchiveSourceEntry{Name: f.Name, Size: int64(f.UncompressedSize64), ModTime: f.Modified, zipFile: f})
+		} else {
+			a.SkippedEntries++
+		}
+	}
+	return nil
+}
+
+// add records an entry. An archive can hold several entries with the same name. The last of them wins, as it would if
+// the archive was extracted, but it keeps the place of the first
+func (a *ArchiveSource) add(e *ArchiveSourceEntry) {
+	e.Name = CleanArchiveEntryName(e.Name)
+	if e.Name == "" {
+		return // the root folder, as "./" in many tars
+	}
+	if existing, ok := a.byName[e.Name]; ok {
+		*existing = *e
+		return
+	}
+	a.byName[e.Name] = e
+	a.entries = append(a.entries, e)
+}
+
+// CleanArchiveEntryName gives the form of an entry's name that ArchiveSource uses: with forward slashes, and without
+// any leading ./ or trailing /
+func CleanArchiveEntryName(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(name, "/")
+}
+
+// Entries lists the entries, in the order they are in the archive
+func (a *ArchiveSource) Entries() []*ArchiveSourceEntry {
+	return a.entries
+}
+
+// Entry finds an entry by the name it has in Entries
+func (a *ArchiveSource) Entry(name string) (*ArchiveSourceEntry, bool) {
+	e, ok := a.byName[CleanArchiveEntryName(name)]
+	return e, ok
+}
+
+// Open gives access to the content of a file entry. Each call gives an independent reader, so that chunks and
+// retries can read it separately
+func (a *ArchiveSource) Open(e *ArchiveSourceEntry) (CloseableReaderAt, error) {
+	if e.IsFolder {
+		return nil, errors.New("a folder has no content")
+	}
+
+	if e.zipFile == nil || e.zipFile.Method == zip.Store {
+		// the data is stored as it is, all in one place in the archive, so it can be read at any offset
+		offset := e.tarOffset
+		if e.zipFile != nil {
+			var err error
+			if offset, err = e.zipFile.DataOffset(); err != nil {
+				return nil, err
+			}
+		}
+		f, err := os.Open(a.path)
+		if err != nil {
+			return nil, err
+		}
+		return &sectionReadCloser{SectionReader: io.NewSectionReader(f, offset, e.Size), f: f}, nil
+	}
+
+	// compressed data can only be read forwards
+	return &streamReaderAt{open: e.zipFile.Open}, nil
+}
+
+// Close releases the archive
+func (a *ArchiveSource) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+	return nil
+}
+
+type sectionReadCloser struct {
+	*io.SectionReader
+	f *os.File
+}
+
+func (s *sectionReadCloser) Close() error {
+	return s.f.Close()
+}
+
+// streamReaderAt serves ReadAt from a stream that can only be read forwards, such as a compressed entry. Reading in
+// order, as an upload reads its chunks, costs nothing extra. Reading an earlier offset, as a retry may, restarts the
+// stream from the beginning
+type streamReaderAt struct {
+	open func() (io.ReadCloser, error)
+
+	mu  sync.Mutex
+	r   io.ReadCloser
+	pos int64
+}
+
+func (s *streamReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.r == nil || off < s.pos {
+		if s.r != nil {
+			_ = s.r.Close()
+		}
+		r, err := s.open()
+		if err != nil {
+			s.r = nil
+			return 0, err
+		}
+		s.r, s.pos = r, 0
+	}
+	if off > s.pos {
+		skipped, err := io.CopyN(ioutil.Discard, s.r, off-s.pos)
+		s.pos += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := io.ReadFull(s.r, p)
+	s.pos += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *streamReaderAt) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.r == nil {
+		return nil
+	}
+	err := s.r.Close()
+	s.r = nil
+	return err
+}
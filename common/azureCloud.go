@@ -0,0 +1,116 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/JeffreyRichter/enum/enum"
+)
+
+// AzureCloud is one of the Azure clouds. Each has a storage endpoint suffix of its own, and an Azure Active Directory
+// authority of its own, whose tokens the others don't accept
+type AzureCloud uint8
+
+var EAzureCloud = AzureCloud(0)
+
+// Unknown is for locations that aren't in Azure, and Azure hosts that don't end in one of the clouds' endpoint
+// suffixes, such as custom domains
+func (AzureCloud) Unknown() AzureCloud { return AzureCloud(0) }
+func (AzureCloud) Public() AzureCloud  { return AzureCloud(1) }
+func (AzureCloud) USGov() AzureCloud   { return AzureCloud(2) }
+func (AzureCloud) China() AzureCloud   { return AzureCloud(3) }
+
+func (c *AzureCloud) Parse(s string) error {
+	val, err := enum.Parse(reflect.TypeOf(c), s, true)
+	if err == nil {
+		*c = val.(AzureCloud)
+	}
+	return err
+}
+
+func (c AzureCloud) String() string {
+	return enum.StringInt(c, reflect.TypeOf(c))
+}
+
+// EndpointSuffix is the suffix of the cloud's storage endpoints, which follows the account name and the service,
+// e.g. core.windows.net for https://account.blob.core.windows.net
+func (c AzureCloud) EndpointSuffix() string {
+	switch c {
+	case EAzureCloud.Public():
+		return "core.windows.net"
+	case EAzureCloud.USGov():
+		return "core.usgovcloudapi.net"
+	case EAzureCloud.China():
+		return "core.chinacloudapi.cn"
+	default:
+		return ""
+	}
+}
+
+// ActiveDirectoryEndpoint is the authority that issues the OAuth tokens the cloud's storage accepts
+func (c AzureCloud) ActiveDirectoryEndpoint() string {
+	switch c {
+	case EAzureCloud.Public():
+		return DefaultActiveDirectoryEndpoint
+	case EAzureCloud.USGov():
+		return "https://login.microsoftonline.us"
+	case EAzureCloud.China():
+		return "https://login.chinacloudapi.cn"
+	default:
+		return ""
+	}
+}
+
+func knownAzureClouds() []AzureCloud {
+	return []AzureCloud{EAzureCloud.Public(), EAzureCloud.USGov(), EAzureCloud.China()}
+}
+
+// AzureCloudOfHost returns the cloud whose endpoint suffix the host, which may have a port, ends in
+func AzureCloudOfHost(host string) AzureCloud {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, c := range knownAzureClouds() {
+		if strings.HasSuffix(host, "."+c.EndpointSuffix()) {
+			return c
+		}
+	}
+	return EAzureCloud.Unknown()
+}
+
+// AzureCloudOfAuthority returns the cloud whose authority the Active Directory endpoint is. Others, such as those of
+// Azure Stack, are Unknown
+func AzureCloudOfAuthority(activeDirectoryEndpoint string) AzureCloud {
+	u, err := url.Parse(activeDirectoryEndpoint)
+	if err != nil || u.Host == "" {
+		return EAzureCloud.Unknown()
+	}
+	for _, c := range knownAzureClouds() {
+		if known, _ := url.Parse(c.ActiveDirectoryEndpoint()); strings.EqualFold(u.Host, known.Host) {
+			return c
+		}
+	}
+	return EAzureCloud.Unknown()
+}
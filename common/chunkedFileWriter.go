@@ -39,7 +39,8 @@ type ChunkedFileWriter interface {
 	WaitToScheduleChunk(ctx context.Context, id ChunkID, chunkSize int64) error
 
 	// EnqueueChunk hands the given chunkContents over to the ChunkedFileWriter, to be written to disk.
-	// Because ChunkedFileWriter writes sequentially, the actual time of writing is not known to the caller.
+	// Because ChunkedFileWriter writes sequentially, the actual time of writing is not known to the caller
+	// (unless it is writing chunks in place, in which case the chunk is written before this returns).
 	// All the caller knows, is that responsibility for writing the chunk has been passed to the ChunkedFileWriter.
 	// While any error may be returned immediately, errors are more likely to be returned later, on either a subsequent
 	// call to this routine or on the final return to Flush.
@@ -96,6 +97,11 @@ type chunkedFileWriter struct {
 
 	sourceMd5Exists bool
 
+	// if set, each chunk is written straight to its offset in this (the same file as file), as soon as it has been read,
+	// instead of waiting in RAM for the chunks before it. Only used when there's no MD5 to compute, since that needs the
+	// data in order
+	inPlaceFile io.WriterAt
+
 	err error // This field should be set only by workerRoutine
 }
 
@@ -104,7 +110,9 @@ type fileChunk struct {
 	data []byte
 }
 
-func NewChunkedFileWriter(ctx context.Context, slicePool ByteSlicePooler, cacheLimiter CacheLimiter, chunkLogger ChunkStatusLogger, file io.WriteCloser, numChunks uint32, maxBodyRetries int, md5ValidationOption HashValidationOption, sourceMd5Exists bool) ChunkedFileWriter {
+// NewChunkedFileWriter makes a writer for the chunks of one file. If writeChunksInPlace is true, file is an io.WriterAt,
+// and no MD5 hash has to be computed, chunks are written to their offsets as they arrive. Otherwise they are written in order.
+func NewChunkedFileWriter(ctx context.Context, slicePool ByteSlicePooler, cacheLimiter CacheLimiter, chunkLogger ChunkStatusLogger, file io.WriteCloser, numChunks uint32, maxBodyRetries int, md5ValidationOption HashValidationOption, sourceMd5Exists bool, writeChunksInPlace bool) ChunkedFileWriter {
 	// Set max size for buffered channel. The upper limit here is believed to be generous, given worker routine drains it constantly.
 	// Use num chunks in file if lower than the upper limit, to prevent allocating RAM for lots of large channel buffers when dealing with
 	// very large numbers of very small files.
@@ -123,6 +131,9 @@ func NewChunkedFileWriter(ctx context.Context, slicePool ByteSlicePooler, cacheL
 		sourceMd5Exists:         sourceMd5Exists,
 		currentReservedCapacity: 0,
 	}
+	if writerAt, ok := file.(io.WriterAt); ok && writeChunksInPlace && !w.shouldComputeMd5() {
+		w.inPlaceFile = writerAt
+	}
 	go w.workerRoutine(ctx)
 	return w
 }
@@ -168,13 +179,9 @@ func (w *chunkedFileWriter) EnqueueChunk(ctx context.Context, id ChunkID, chunkS
 	defer func() {
 		// cleanup stuff if we abruptly quit
 		if err == nil {
-			return // We've successfully queued, the worker will now takeover
+			return // We've successfully queued (or saved), the worker will now takeover
 		}
-		w.cacheLimiter.Remove(chunkSize) // remove this from the tally of scheduled-but-unsaved bytes
-		atomic.AddInt64(&w.currentReservedCapacity, -chunkSize)
-		w.slicePool.ReturnSlice(buffer)
-		atomic.AddInt32(&w.activeChunkCount, -1)
-		w.chunkLogger.LogChunkStatus(id, EWaitReason.ChunkDone()) // this chunk is all finished
+		w.releaseChunk(fileChunk{id: id, data: buffer})
 	}()
 
 	readStart := time.Now()
@@ -188,6 +195,16 @@ func (w *chunkedFileWriter) EnqueueChunk(ctx context.Context, id ChunkID, chunkS
 	atomic.AddInt32(&w.totalReceivedChunkCount, 1)
 	atomic.AddInt64(&w.totalChunkReceiveMilliseconds, time.Since(readStart).Nanoseconds()/(1000*1000))
 
+	if w.inPlaceFile != nil {
+		// nothing has to wait for the chunks before this one, so save it now, from this goroutine
+		chunk := fileChunk{id: id, data: buffer}
+		if err = w.saveOneChunkInPlace(chunk); err != nil {
+			return err
+		}
+		w.releaseChunk(chunk)
+		return nil
+	}
+
 	// enqueue it
 	w.chunkLogger.LogChunkStatus(id, EWaitReason.Sorting())
 	select {
@@ -249,7 +266,7 @@ func (w *chunkedFileWriter) workerRoutine(ctx context.Context) {
 	nextOffsetToSave := int64(0)
 	unsavedChunksByFileOffset := make(map[int64]fileChunk)
 	md5Hasher := md5.New()
-	if w.md5ValidationOption == EHashValidationOption.NoCheck() || !w.sourceMd5Exists {
+	if !w.shouldComputeMd5() {
 		// save CPU time by not even computing a hash, if we don't want to check it, or have nothing to check it against
 		md5Hasher = &nullHasher{}
 	}
@@ -257,11 +274,7 @@ func (w *chunkedFileWriter) workerRoutine(ctx context.Context) {
 	defer func() {
 		// cleanup stuff if we abruptly quit
 		for _, chunk := range unsavedChunksByFileOffset {
-			w.cacheLimiter.Remove(int64(chunk.id.length)) // remove this from the tally of scheduled-but-unsaved bytes
-			atomic.AddInt64(&w.currentReservedCapacity, -chunk.id.length)
-			w.slicePool.ReturnSlice(chunk.data)
-			atomic.AddInt32(&w.activeChunkCount, -1)
-			w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.ChunkDone()) // this chunk is all finished
+			w.releaseChunk(chunk)
 		}
 		close(w.chunkWriterDone) // must close because many goroutines may be calling the public methods, and all need to be able to tell there's been an error, even tho only one will get the actual error
 		unsavedChunksByFileOffset = nil
@@ -348,13 +361,7 @@ func (w *chunkedFileWriter) setStatusForContiguousAvailableChunks(unsavedChunksB
 
 // Saves one chunk to its destination
 func (w *chunkedFileWriter) saveOneChunk(chunk fileChunk, md5Hasher hash.Hash) error {
-	defer func() {
-		w.cacheLimiter.Remove(int64(len(chunk.data))) // remove this from the tally of scheduled-but-unsaved bytes
-		w.slicePool.ReturnSlice(chunk.data)
-		atomic.AddInt32(&w.activeChunkCount, -1)
-		atomic.AddInt64(&w.currentReservedCapacity, -chunk.id.length)
-		w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.ChunkDone()) // this chunk is all finished
-	}()
+	defer w.releaseChunk(chunk)
 
 	const maxWriteSize = 1024 * 1024
 
@@ -378,6 +385,43 @@ func (w *chunkedFileWriter) saveOneChunk(chunk fileChunk, md5Hasher hash.Hash) e
 	return nil
 }
 
+// Saves one chunk at its own offset in the file, regardless of whether the chunks before it have been saved yet.
+// Safe to call from several goroutines at once, since each writes a different part of the file
+func (w *chunkedFileWriter) saveOneChunkInPlace(chunk fileChunk) error {
+	const maxWriteSize = 1024 * 1024
+
+	w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.DiskIO())
+
+	offset := chunk.id.OffsetInFile()
+	for i := 0; i < len(chunk.data); i += maxWriteSize {
+		slice := chunk.data[i:]
+		if len(slice) > maxWriteSize {
+			slice = slice[:maxWriteSize]
+		}
+
+		_, err := w.inPlaceFile.WriteAt(slice, offset+int64(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Gives back the RAM and the slice of a chunk that has been saved, or never will be
+func (w *chunkedFileWriter) releaseChunk(chunk fileChunk) {
+	w.cacheLimiter.Remove(chunk.id.length) // remove this from the tally of scheduled-but-unsaved bytes
+	atomic.AddInt64(&w.currentReservedCapacity, -chunk.id.length)
+	w.slicePool.ReturnSlice(chunk.data)
+	atomic.AddInt32(&w.activeChunkCount, -1)
+	w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.ChunkDone()) // this chunk is all finished
+}
+
+// An MD5 hash of the file is only worth computing if it is to be checked, and there is something to check it against
+func (w *chunkedFileWriter) shouldComputeMd5() bool {
+	return w.md5ValidationOption != EHashValidationOption.NoCheck() && w.sourceMd5Exists
+}
+
 // We use a less strict cache limit
 // if we have relatively few chunks in progress for THIS file. Why? To try to spread
 // the work in progress across a larger number of files, instead of having it
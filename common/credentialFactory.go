@@ -22,10 +22,13 @@ package common
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"math"
+	"net"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -213,6 +216,12 @@ func CreateS3Credential(ctx context.Context, credInfo CredentialInfo, options Cr
 
 		// create and return s3 credential
 		return credentials.NewStaticV4(accessKeyID, secretAccessKey, sessionToken), nil // S3 uses V4 signature
+	case ECredentialType.GoogleHMACKey():
+		accessID := glcm.GetEnvironmentVariable(EEnvironmentVariable.GoogleHMACAccessID())
+		secret := glcm.GetEnvironmentVariable(EEnvironmentVariable.GoogleHMACSecret())
+
+		// GCP's XML API accepts HMAC keys with the V2 signature
+		return credentials.NewStaticV2(accessID, secret, ""), nil
 	default:
 		options.panicError(fmt.Errorf("invalid state, credential type %v is not supported", credInfo.CredentialType))
 	}
@@ -244,23 +253,60 @@ func refreshBlobFSToken(ctx context.Context, tokenInfo OAuthTokenInfo, tokenCred
 // S3 credential related factory methods
 // ==============================================================================================
 func CreateS3Client(ctx context.Context, credInfo CredentialInfo, option CredentialOpOptions, logger ILogger) (*minio.Client, error) {
+	options := minio.Options{Secure: true, Region: credInfo.S3CredentialInfo.Region}
+	compatibleEndpoint, isCompatible := GetS3CompatibleEndpoint(credInfo.S3CredentialInfo.Endpoint)
+	if isCompatible {
+		options.Secure = compatibleEndpoint.Secure
+		if compatibleEndpoint.ForcePathStyle {
+			options.BucketLookup = minio.BucketLookupPath
+		}
+		if options.Region == "" {
+			// without a region, the client would first look up the location of each bucket, which not every
+			// S3-compatible service implements. Those that ignore the region accept the default of AWS.
+			options.Region = s3DefaultRegion
+		}
+	}
+
 	if credInfo.CredentialType == ECredentialType.S3PublicBucket() {
-		cred := credentials.NewStatic("", "", "", credentials.SignatureAnonymous)
-		return minio.NewWithOptions(credInfo.S3CredentialInfo.Endpoint, &minio.Options{Creds: cred, Secure: true, Region: credInfo.S3CredentialInfo.Region})
+		options.Creds = credentials.NewStatic("", "", "", credentials.SignatureAnonymous)
+	} else {
+		// Support access key
+		credential, err := CreateS3Credential(ctx, credInfo, option)
+		if err != nil {
+			return nil, err
+		}
+		options.Creds = credential
 	}
-	// Support access key
-	credential, err := CreateS3Credential(ctx, credInfo, option)
+	s3Client, err := minio.NewWithOptions(credInfo.S3CredentialInfo.Endpoint, &options)
 	if err != nil {
 		return nil, err
 	}
-	s3Client, err := minio.NewWithCredentials(credInfo.S3CredentialInfo.Endpoint, credential, true, credInfo.S3CredentialInfo.Region)
 
-	if logger != nil {
+	skipSSLVerify := isCompatible && compatibleEndpoint.SkipSSLVerify
+	if skipSSLVerify || GlobalDialLocalAddress != nil {
+		transport := minio.DefaultTransport.(*http.Transport).Clone()
+		if skipSSLVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		if GlobalDialLocalAddress != nil {
+			transport.DialContext = (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+				DualStack: true,
+				LocalAddr: GlobalDialLocalAddress,
+			}).DialContext
+		}
+		s3Client.SetCustomTransport(transport)
+	}
+	if logger != nil && credInfo.CredentialType != ECredentialType.S3PublicBucket() {
 		s3Client.TraceOn(NewS3HTTPTraceLogger(logger, pipeline.LogDebug))
 	}
-	return s3Client, err
+	return s3Client, nil
 }
 
+// the region that S3 clients use for S3-compatible services when the URL names none
+const s3DefaultRegion = "us-east-1"
+
 type S3ClientFactory struct {
 	s3Clients map[CredentialInfo]*minio.Client
 	lock      sync.RWMutex
@@ -301,6 +347,18 @@ func (f *S3ClientFactory) GetS3Client(ctx context.Context, credInfo CredentialIn
 // ====================================================================
 // GCP credential factory related methods
 // ====================================================================
+
+// GCPXMLAPIEndpoint is the S3-compatible endpoint of GCP, through which objects are accessed when authenticating with an HMAC key.
+const GCPXMLAPIEndpoint = "storage.googleapis.com"
+
+// NewGCPHMACCredentialInfo returns the info with which CreateS3Client creates a client for GCP's XML API, authenticated with the HMAC key.
+func NewGCPHMACCredentialInfo() CredentialInfo {
+	return CredentialInfo{
+		CredentialType:   ECredentialType.GoogleHMACKey(),
+		S3CredentialInfo: S3CredentialInfo{Endpoint: GCPXMLAPIEndpoint},
+	}
+}
+
 func CreateGCPClient(ctx context.Context) (*gcpUtils.Client, error) {
 	client, err := gcpUtils.NewClient(ctx)
 	return client, err
@@ -41,12 +41,16 @@ var VisibleEnvironmentVariables = []EnvironmentVariable{
 	EEnvironmentVariable.ConcurrencyValue(),
 	EEnvironmentVariable.TransferInitiationPoolSize(),
 	EEnvironmentVariable.EnumerationPoolSize(),
+	EEnvironmentVariable.SmallFileThreshold(),
+	EEnvironmentVariable.SmallFileConcurrency(),
 	EEnvironmentVariable.DisableHierarchicalScanning(),
 	EEnvironmentVariable.ParallelStatFiles(),
 	EEnvironmentVariable.BufferGB(),
 	EEnvironmentVariable.AWSAccessKeyID(),
 	EEnvironmentVariable.AWSSecretAccessKey(),
 	EEnvironmentVariable.GoogleAppCredentials(),
+	EEnvironmentVariable.GoogleHMACAccessID(),
+	EEnvironmentVariable.GoogleHMACSecret(),
 	EEnvironmentVariable.ShowPerfStates(),
 	EEnvironmentVariable.PacePageBlobs(),
 	EEnvironmentVariable.AutoTuneToCpu(),
@@ -65,7 +69,20 @@ var VisibleEnvironmentVariables = []EnvironmentVariable{
 	EEnvironmentVariable.ManagedIdentityClientID(),
 	EEnvironmentVariable.ManagedIdentityObjectID(),
 	EEnvironmentVariable.ManagedIdentityResourceString(),
+	EEnvironmentVariable.AzureClientID(),
+	EEnvironmentVariable.AzureTenantID(),
+	EEnvironmentVariable.AzureAuthorityHost(),
+	EEnvironmentVariable.AzureFederatedTokenFile(),
+	EEnvironmentVariable.AzureClientSecret(),
+	EEnvironmentVariable.AzureClientCertificatePath(),
+	EEnvironmentVariable.AzureClientCertificatePassword(),
 	EEnvironmentVariable.RequestTryTimeout(),
+	EEnvironmentVariable.RetryMaxTries(),
+	EEnvironmentVariable.RetryDelay(),
+	EEnvironmentVariable.RetryMaxDelay(),
+	EEnvironmentVariable.RetryMultiplier(),
+	EEnvironmentVariable.RetryJitter(),
+	EEnvironmentVariable.RetryHonorRetryAfter(),
 	EEnvironmentVariable.CPKEncryptionKey(),
 	EEnvironmentVariable.CPKEncryptionKeySHA256(),
 	EEnvironmentVariable.DisableSyslog(),
@@ -84,8 +101,10 @@ func (EnvironmentVariable) UserDir() EnvironmentVariable {
 
 func (EnvironmentVariable) AutoLoginType() EnvironmentVariable {
 	return EnvironmentVariable{
-		Name:        "AZCOPY_AUTO_LOGIN_TYPE",
-		Description: "Specify the credential type to access Azure Resource without invoking the login command and using the OS secret store, available values SPN, MSI and DEVICE - sequentially for Service Principal, Managed Service Identity and Device workflow.",
+		Name: "AZCOPY_AUTO_LOGIN_TYPE",
+		Description: "Specify the credential type to access Azure Resource without invoking the login command and using the OS secret store, available values SPN, MSI, DEVICE and DEFAULT - sequentially for Service Principal, Managed Service Identity, Device workflow, " +
+			"and the first of these that the standard AZURE_* environment variables set up: a service principal (AZURE_CLIENT_SECRET or AZURE_CLIENT_CERTIFICATE_PATH), workload identity (AZURE_FEDERATED_TOKEN_FILE) or else managed identity. " +
+			"Like every type here, DEFAULT works with Blob and ADLS Gen2, but not Azure Files, which still needs a SAS.",
 	}
 }
 
@@ -156,6 +175,58 @@ func (EnvironmentVariable) ManagedIdentityResourceString() EnvironmentVariable {
 	}
 }
 
+// For the DEFAULT auto login type. These are the names that the Azure SDKs, and AKS workload identity, use
+func (EnvironmentVariable) AzureClientID() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_CLIENT_ID",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the client ID of the service principal or workload identity application, or of the user-assigned managed identity.",
+	}
+}
+
+func (EnvironmentVariable) AzureTenantID() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_TENANT_ID",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the tenant of the service principal or workload identity application.",
+	}
+}
+
+func (EnvironmentVariable) AzureAuthorityHost() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_AUTHORITY_HOST",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the Azure Active Directory endpoint to use, for clouds other than the public one.",
+	}
+}
+
+func (EnvironmentVariable) AzureFederatedTokenFile() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_FEDERATED_TOKEN_FILE",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the file holding the federated token that workload identity exchanges for access tokens. AKS sets it in pods that use workload identity.",
+	}
+}
+
+func (EnvironmentVariable) AzureClientSecret() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_CLIENT_SECRET",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the client secret of the service principal.",
+		Hidden:      true,
+	}
+}
+
+func (EnvironmentVariable) AzureClientCertificatePath() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_CLIENT_CERTIFICATE_PATH",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the path of the service principal's certificate.",
+	}
+}
+
+func (EnvironmentVariable) AzureClientCertificatePassword() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZURE_CLIENT_CERTIFICATE_PASSWORD",
+		Description: "With AZCOPY_AUTO_LOGIN_TYPE=DEFAULT, the password of the service principal's certificate.",
+		Hidden:      true,
+	}
+}
+
 func (EnvironmentVariable) ConcurrencyValue() EnvironmentVariable {
 	return EnvironmentVariable{
 		Name:        "AZCOPY_CONCURRENCY_VALUE",
@@ -178,12 +249,28 @@ func (EnvironmentVariable) TransferInitiationPoolSize() EnvironmentVariable {
 	}
 }
 
+func (EnvironmentVariable) SmallFileThreshold() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_SMALL_FILE_THRESHOLD",
+		Description: "Files smaller than this many bytes are transferred by a separate pool of workers, sized by AZCOPY_SMALL_FILE_CONCURRENCY, so that large files cannot hold them up. Must be at least 1, and defaults to 262144 (256 KiB). Only has an effect when AZCOPY_SMALL_FILE_CONCURRENCY is set.",
+	}
+}
+
+func (EnvironmentVariable) SmallFileConcurrency() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_SMALL_FILE_CONCURRENCY",
+		Description: "Turns on a separate pool of workers for files smaller than AZCOPY_SMALL_FILE_THRESHOLD, and sets how many of them are in progress at any one time. " +
+			"The pool is in addition to the main pool (see AZCOPY_CONCURRENCY_VALUE), is not auto-tuned, and uses two goroutines and up to one connection per file in progress. " +
+			"Must not be negative. Off (0) by default, so that all files go through the main pool. Values around 256 suit large numbers of tiny files.",
+	}
+}
+
 const azCopyConcurrentScan = "AZCOPY_CONCURRENT_SCAN"
 
 func (EnvironmentVariable) EnumerationPoolSize() EnvironmentVariable {
 	return EnvironmentVariable{
 		Name:        azCopyConcurrentScan,
-		Description: "Controls the (max) degree of parallelism used during scanning. Only affects parallelized enumerators, which include Azure Files/Blobs, and local file systems.",
+		Description: "Controls the (max) degree of parallelism used during scanning. Only affects parallelized enumerators, which include Azure Files/Blobs, and local file systems. When this is more than 1, sibling folders are scanned concurrently, so files may be found, and scheduled for transfer, in a different order from one run to the next. Set it to 1 to scan one folder at a time.",
 	}
 }
 
@@ -299,6 +386,21 @@ func (EnvironmentVariable) GoogleAppCredentials() EnvironmentVariable {
 	}
 }
 
+func (EnvironmentVariable) GoogleHMACAccessID() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "GOOGLE_HMAC_ACCESS_ID",
+		Description: "The access ID of a GCP HMAC key. Used, together with GOOGLE_HMAC_SECRET, to access GCP resources for service to service copy when GOOGLE_APPLICATION_CREDENTIALS is not set.",
+	}
+}
+
+func (EnvironmentVariable) GoogleHMACSecret() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "GOOGLE_HMAC_SECRET",
+		Description: "The secret of the GCP HMAC key given by GOOGLE_HMAC_ACCESS_ID.",
+		Hidden:      true,
+	}
+}
+
 func (EnvironmentVariable) GoogleCloudProject() EnvironmentVariable {
 	return EnvironmentVariable{
 		Name:        "GOOGLE_CLOUD_PROJECT",
@@ -338,6 +440,54 @@ func (EnvironmentVariable) RequestTryTimeout() EnvironmentVariable {
 	}
 }
 
+func (EnvironmentVariable) RetryMaxTries() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_RETRY_MAX_TRIES",
+		DefaultValue: "20",
+		Description:  "Set how many times AzCopy tries each request (e.g. for one chunk of a file) before the transfer it belongs to fails. A value of 1 means no retries.",
+	}
+}
+
+func (EnvironmentVariable) RetryDelay() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_RETRY_DELAY",
+		DefaultValue: "1s",
+		Description:  "Set the delay before the first retry of a request, as a duration such as 500ms or 2s. Later retries wait longer, as controlled by AZCOPY_RETRY_MULTIPLIER.",
+	}
+}
+
+func (EnvironmentVariable) RetryMaxDelay() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_RETRY_MAX_DELAY",
+		DefaultValue: "60s",
+		Description:  "Set the longest delay before any retry of a request, as a duration such as 30s or 2m.",
+	}
+}
+
+func (EnvironmentVariable) RetryMultiplier() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_RETRY_MULTIPLIER",
+		DefaultValue: "2",
+		Description:  "Set how much the delay grows with each retry of a request. Must be at least 1. A value of 1 makes the delay grow linearly.",
+	}
+}
+
+func (EnvironmentVariable) RetryJitter() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_RETRY_JITTER",
+		DefaultValue: "true",
+		Description:  "Set to false to stop AzCopy randomly varying each retry delay. The variation stops requests that failed together from all being retried at the same moment.",
+	}
+}
+
+func (EnvironmentVariable) RetryHonorRetryAfter() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_RETRY_HONOR_RETRY_AFTER",
+		DefaultValue: "true",
+		Description:  "Set to false to stop AzCopy waiting as long as a throttled (503 or 429) response from Blob storage or ADLS Gen 2 asks with its Retry-After header, and use its usual delay instead. Either way, no retry waits longer than AZCOPY_RETRY_MAX_DELAY.",
+	}
+}
+
 func (EnvironmentVariable) CPKEncryptionKey() EnvironmentVariable {
 	return EnvironmentVariable{Name: "CPK_ENCRYPTION_KEY", Hidden: true}
 }
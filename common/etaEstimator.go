@@ -0,0 +1,139 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// ThroughputSample is the rate at which bytes were transferred between one progress sample and the one before it
+type ThroughputSample struct {
+	At             time.Time
+	BytesPerSecond float64
+}
+
+// the most throughput samples that an ETAEstimator keeps. At one sample every two seconds, as the progress output
+// takes them, that's the last hour
+const maxThroughputSeriesLength = 1800
+
+type progressSample struct {
+	at    time.Time
+	bytes uint64 // the bytes transferred up to this sample, never going down
+}
+
+// ETAEstimator predicts how long a job has left from samples of how many bytes it has transferred so far.
+// Its throughput is the average over a sliding window of the latest samples, so that it follows changes of speed
+// without jumping around at every sample. Since transfers take a while to get up to speed, it gives no estimate
+// until the job has been moving bytes for at least its ramp-up period.
+// The throughput measured between each pair of samples is kept too, for callers that want the raw series.
+type ETAEstimator struct {
+	mu      sync.Mutex
+	window  time.Duration
+	rampUp  time.Duration
+	samples []progressSample // oldest first. The oldest may be just outside the window, so that the whole window is covered
+
+	lastReported uint64    // the last byte count given to AddSample, which can go down when a transfer fails
+	firstMoved   time.Time // the last sample before any bytes were transferred. Zero until bytes have been
+	series       []ThroughputSample
+}
+
+// NewETAEstimator returns an estimator that averages throughput over the given window, and that gives no estimate
+// until the job has been transferring for rampUp
+func NewETAEstimator(window, rampUp time.Duration) *ETAEstimator {
+	return &ETAEstimator{window: window, rampUp: rampUp}
+}
+
+// AddSample records that bytesTransferred bytes had been transferred at the given time. Samples must be added in
+// time order. A count lower than the last one (e.g. because a file failed and its bytes no longer count) is taken as
+// no progress, rather than as going backwards
+func (e *ETAEstimator) AddSample(at time.Time, bytesTransferred uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var progress uint64
+	if len(e.samples) > 0 && bytesTransferred > e.lastReported {
+		progress = bytesTransferred - e.lastReported
+	}
+	e.lastReported = bytesTransferred
+
+	if len(e.samples) == 0 {
+		e.samples = append(e.samples, progressSample{at: at})
+		return
+	}
+	previous := e.samples[len(e.samples)-1]
+	if !at.After(previous.at) {
+		return // no time has passed, so there's no rate to measure
+	}
+	if progress > 0 && e.firstMoved.IsZero() {
+		e.firstMoved = previous.at
+	}
+
+	e.series = append(e.series, ThroughputSample{At: at, BytesPerSecond: float64(progress) / at.Sub(previous.at).Seconds()})
+	if len(e.series) > maxThroughputSeriesLength {
+		e.series = e.series[len(e.series)-maxThroughputSeriesLength:]
+	}
+
+	e.samples = append(e.samples, progressSample{at: at, bytes: previous.bytes + progress})
+	// drop the samples that the one after them already shows to be outside the window, or from before the transfer
+	// got going, which would only drag the average down
+	for len(e.samples) > 2 && (at.Sub(e.samples[1].at) >= e.window || !e.samples[1].at.After(e.firstMoved)) {
+		e.samples = e.samples[1:]
+	}
+}
+
+// Throughput returns the average bytes per second over the window. 0 until there have been two samples
+func (e *ETAEstimator) Throughput() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.throughput()
+}
+
+func (e *ETAEstimator) throughput() float64 {
+	if len(e.samples) < 2 {
+		return 0
+	}
+	oldest, newest := e.samples[0], e.samples[len(e.samples)-1]
+	return float64(newest.bytes-oldest.bytes) / newest.at.Sub(oldest.at).Seconds()
+}
+
+// ETA returns how long the given bytes are expected to take at the current throughput. It returns false, for no
+// estimate, while the job is still ramping up, and when nothing is being transferred
+func (e *ETAEstimator) ETA(bytesRemaining uint64) (time.Duration, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.firstMoved.IsZero() || e.samples[len(e.samples)-1].at.Sub(e.firstMoved) < e.rampUp {
+		return 0, false
+	}
+	throughput := e.throughput()
+	if throughput <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(bytesRemaining) / throughput * float64(time.Second)), true
+}
+
+// ThroughputSeries returns the throughput measured between each sample and the one before it, oldest first.
+// Only the latest samples are kept
+func (e *ETAEstimator) ThroughputSeries() []ThroughputSample {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]ThroughputSample(nil), e.series...)
+}
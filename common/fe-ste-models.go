@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"reflect"
 	"regexp"
@@ -52,9 +53,14 @@ const (
 	//  we decided that the best option was to leave it as is, and only relax it if user feedback so requires.
 	DEFAULT_FILE_PERM = 0644 // the os package will handle base-10 for us.
 
-	// Since we haven't updated the Go SDKs to handle CPK just yet, we need to detect CPK related errors
-	// and inform the user that we don't support CPK yet.
+	// The service gives this code when a blob encrypted with a customer provided key (CPK) is read without its key.
+	// We detect it so that we can tell the user how to supply the key.
 	CPK_ERROR_SERVICE_CODE = "BlobUsesCustomerSpecifiedEncryption"
+
+	// CPK_ERROR_HINT tells the user how to read blobs that are encrypted with a customer provided key
+	CPK_ERROR_HINT = "Blobs encrypted with a customer provided key (CPK) can only be read with that key. " +
+		"To download them, use --cpk-by-value, with the key and its SHA256 hash in the CPK_ENCRYPTION_KEY and CPK_ENCRYPTION_KEY_SHA256 environment variables. " +
+		"The service can't read them as the source of a service to service copy."
 )
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -184,6 +190,151 @@ func (p PermanentDeleteOption) ToPermanentDeleteOptionType() azblob.BlobDeleteTy
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// TrailingDotOption says what happens to names that end with a dot, such as "file." or "dir./child", when Azure Files
+// is the source or the destination. Without special handling the Files service silently drops those dots.
+var ETrailingDotOption = TrailingDotOption(0)
+
+type TrailingDotOption uint8
+
+// Enable keeps the dots, by asking the Files service to allow them
+func (TrailingDotOption) Enable() TrailingDotOption { return TrailingDotOption(0) }
+
+// Disable strips the dots from the names written to the destination
+func (TrailingDotOption) Disable() TrailingDotOption { return TrailingDotOption(1) }
+
+func (t *TrailingDotOption) Parse(s string) error {
+	// allow empty to mean "Enable"
+	if s == "" {
+		*t = ETrailingDotOption.Enable()
+		return nil
+	}
+
+	val, err := enum.Parse(reflect.TypeOf(t), s, true)
+	if err == nil {
+		*t = val.(TrailingDotOption)
+	}
+	return err
+}
+
+func (t TrailingDotOption) String() string {
+	return enum.StringInt(t, reflect.TypeOf(t))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ArchiveFormat is the format of a single archive file that a download streams its files into, or that an upload
+// reads its files from. None means the files are files of their own, as usual
+var EArchiveFormat = ArchiveFormat(0)
+
+type ArchiveFormat uint8
+
+// None means there is no archive
+func (ArchiveFormat) None() ArchiveFormat { return ArchiveFormat(0) }
+
+// Tar writes the files into, or reads them from, an uncompressed tar file
+func (ArchiveFormat) Tar() ArchiveFormat { return ArchiveFormat(1) }
+
+// TarGz writes the files into a gzip-compressed tar file. It's only supported as a destination, since the entries of
+// a compressed tar can't be read independently of each other
+func (ArchiveFormat) TarGz() ArchiveFormat { return ArchiveFormat(2) }
+
+// Zip reads the files from a zip file. It's only supported as a source
+func (ArchiveFormat) Zip() ArchiveFormat { return ArchiveFormat(3) }
+
+func (a *ArchiveFormat) Parse(s string) error {
+	// allow empty to mean "None"
+	if s == "" {
+		*a = EArchiveFormat.None()
+		return nil
+	}
+
+	val, err := enum.Parse(reflect.TypeOf(a), s, true)
+	if err == nil {
+		*a = val.(ArchiveFormat)
+	}
+	return err
+}
+
+func (a ArchiveFormat) String() string {
+	return enum.StringInt(a, reflect.TypeOf(a))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FlattenCollisionPolicy says what a copy with --flatten does when files from different folders have the same name,
+// and so would be copied to the same destination path
+var EFlattenCollisionPolicy = FlattenCollisionPolicy(0)
+
+type FlattenCollisionPolicy uint8
+
+// Fail stops the copy before anything is transferred
+func (FlattenCollisionPolicy) Fail() FlattenCollisionPolicy { return FlattenCollisionPolicy(0) }
+
+// Rename copies all of the files, giving each one after the first a numeric suffix
+func (FlattenCollisionPolicy) Rename() FlattenCollisionPolicy { return FlattenCollisionPolicy(1) }
+
+// Overwrite copies only the most recently modified of the files, as if each had been copied over the one before
+func (FlattenCollisionPolicy) Overwrite() FlattenCollisionPolicy { return FlattenCollisionPolicy(2) }
+
+func (f *FlattenCollisionPolicy) Parse(s string) error {
+	// allow empty to mean "Fail"
+	if s == "" {
+		*f = EFlattenCollisionPolicy.Fail()
+		return nil
+	}
+
+	val, err := enum.Parse(reflect.TypeOf(f), s, true)
+	if err == nil {
+		*f = val.(FlattenCollisionPolicy)
+	}
+	return err
+}
+
+func (f FlattenCollisionPolicy) String() string {
+	return enum.StringInt(f, reflect.TypeOf(f))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TransferOrder says in which order the transfers of each part of a job are scheduled. The order is best-effort:
+// transfers run concurrently, and small files have workers of their own, so they can finish in any order
+var ETransferOrder = TransferOrder(0)
+
+type TransferOrder uint8
+
+// Listed schedules the transfers in the order that they were listed at the source
+func (TransferOrder) Listed() TransferOrder { return TransferOrder(0) }
+
+// SmallestFirst schedules the smallest files first
+func (TransferOrder) SmallestFirst() TransferOrder { return TransferOrder(1) }
+
+// LargestFirst schedules the largest files first
+func (TransferOrder) LargestFirst() TransferOrder { return TransferOrder(2) }
+
+// PatternPriority schedules the files whose names match the priority pattern first, and then the others, each in the
+// order that they were listed
+func (TransferOrder) PatternPriority() TransferOrder { return TransferOrder(3) }
+
+func (o *TransferOrder) Parse(s string) error {
+	// allow empty to mean "Listed"
+	if s == "" {
+		*o = ETransferOrder.Listed()
+		return nil
+	}
+
+	val, err := enum.Parse(reflect.TypeOf(o), s, true)
+	if err == nil {
+		*o = val.(TransferOrder)
+	}
+	return err
+}
+
+func (o TransferOrder) String() string {
+	return enum.StringInt(o, reflect.TypeOf(o))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 type DeleteDestination uint32
 
 var EDeleteDestination = DeleteDestination(0)
@@ -265,6 +416,107 @@ func (o OverwriteOption) String() string {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+var ESymlinkHandlingType = SymlinkHandlingType(0)
+
+// SymlinkHandlingType says what to do with the symlinks found when enumerating a local source.
+// The default is to skip them, so that a link to a folder can't unexpectedly pull a whole other tree (or, via a cycle,
+// the same tree again) into the transfer.
+type SymlinkHandlingType uint8
+
+// Skip ignores symlinks, transferring neither the link nor what it points to
+func (SymlinkHandlingType) Skip() SymlinkHandlingType { return SymlinkHandlingType(0) }
+
+// Follow transfers what each symlink points to, as though it were found at the link's location
+func (SymlinkHandlingType) Follow() SymlinkHandlingType { return SymlinkHandlingType(1) }
+
+// Preserve transfers the link itself. It is uploaded as an empty blob recording the link's target in its metadata,
+// and recreated as a symlink when that blob is downloaded with the same option.
+func (SymlinkHandlingType) Preserve() SymlinkHandlingType { return SymlinkHandlingType(2) }
+
+// Determine sets the handling from the values of the --follow-symlinks and --preserve-symlinks flags, which can't both be set
+func (s *SymlinkHandlingType) Determine(follow, preserve bool) error {
+	switch {
+	case follow && preserve:
+		return fmt.Errorf("cannot both follow and preserve symlinks (--follow-symlinks and --preserve-symlinks are mutually exclusive)")
+	case follow:
+		*s = ESymlinkHandlingType.Follow()
+	case preserve:
+		*s = ESymlinkHandlingType.Preserve()
+	default:
+		*s = ESymlinkHandlingType.Skip()
+	}
+	return nil
+}
+
+func (s *SymlinkHandlingType) Parse(str string) error {
+	val, err := enum.Parse(reflect.TypeOf(s), str, true)
+	if err == nil {
+		*s = val.(SymlinkHandlingType)
+	}
+	return err
+}
+
+func (s SymlinkHandlingType) String() string {
+	return enum.StringInt(s, reflect.TypeOf(s))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var ESyncComparator = SyncComparator(0)
+
+// SyncComparator says how sync decides whether a file that exists at both the source and the destination needs to be
+// transferred again
+type SyncComparator uint8
+
+// LastModifiedTime transfers the file if the source was modified more recently than the destination
+func (SyncComparator) LastModifiedTime() SyncComparator { return SyncComparator(0) }
+
+// MD5 transfers the file if the MD5 hashes of the source and the destination differ, or if either hash is not known.
+// Local files have no stored hash, so each one that exists at both ends is read in full to compute its hash.
+func (SyncComparator) MD5() SyncComparator { return SyncComparator(1) }
+
+func (c *SyncComparator) Parse(str string) error {
+	val, err := enum.Parse(reflect.TypeOf(c), str, true)
+	if err == nil {
+		*c = val.(SyncComparator)
+	}
+	return err
+}
+
+func (c SyncComparator) String() string {
+	return enum.StringInt(c, reflect.TypeOf(c))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var EKeepFromUrl = KeepFromUrl(0)
+
+// KeepFromUrl says which part of the URL of a blob, listed with list-of-urls, is kept as its path at the destination
+type KeepFromUrl uint8
+
+// BlobName keeps the name of the blob, which includes its virtual directories, but not its container
+func (KeepFromUrl) BlobName() KeepFromUrl { return KeepFromUrl(0) }
+
+// ContainerAndBlobName keeps the container as well, so that like-named blobs from different containers do not collide
+func (KeepFromUrl) ContainerAndBlobName() KeepFromUrl { return KeepFromUrl(1) }
+
+// FileName keeps only the last segment of the blob's name, so that its virtual directories are dropped
+func (KeepFromUrl) FileName() KeepFromUrl { return KeepFromUrl(2) }
+
+func (k *KeepFromUrl) Parse(str string) error {
+	val, err := enum.Parse(reflect.TypeOf(k), str, true)
+	if err == nil {
+		*k = val.(KeepFromUrl)
+	}
+	return err
+}
+
+func (k KeepFromUrl) String() string {
+	return enum.StringInt(k, reflect.TypeOf(k))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 type OutputFormat uint32
 
 var EOutputFormat = OutputFormat(0)
@@ -287,10 +539,33 @@ func (of OutputFormat) String() string {
 
 var EExitCode = ExitCode(0)
 
+// ExitCode is the exit code of the AzCopy process. For a job that ran to the end, the scheme is:
+//   - 0 (Success) if every transfer completed or was skipped
+//   - 3 (PartialFailure) if some transfers failed, but others completed
+//   - 1 (Error) if the transfers that were attempted all failed, or if the command couldn't run its job at all
+//
+// A job that was cancelled because more of its transfers failed than --max-errors allows exits with 4 (TooManyErrors),
+// however many of them completed.
+//
+// 2 is not used, since that is what the Go runtime exits with after a panic.
 type ExitCode uint32
 
-func (ExitCode) Success() ExitCode { return ExitCode(0) }
-func (ExitCode) Error() ExitCode   { return ExitCode(1) }
+func (ExitCode) Success() ExitCode        { return ExitCode(0) }
+func (ExitCode) Error() ExitCode          { return ExitCode(1) }
+func (ExitCode) PartialFailure() ExitCode { return ExitCode(3) }
+func (ExitCode) TooManyErrors() ExitCode  { return ExitCode(4) }
+
+// ExitCodeForTransfers is the exit code of a job that finished with these counts of completed and failed transfers
+func ExitCodeForTransfers(completed, failed uint32) ExitCode {
+	switch {
+	case failed == 0:
+		return EExitCode.Success()
+	case completed == 0:
+		return EExitCode.Error()
+	default:
+		return EExitCode.PartialFailure()
+	}
+}
 
 // note: if AzCopy exits due to a panic, we don't directly control what the exit code will be. The Go runtime seems to be
 // hard-coded to give an exit code of 2 in that case, but there is discussion of changing it to 1, so it may become
@@ -350,6 +625,30 @@ func (ll LogLevel) ToPipelineLogLevel() pipeline.LogLevel {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+var ELogComponent = LogComponent(0)
+
+// LogComponent is an area of AzCopy's work that can be given a log level of its own, with --log-level-by-component
+type LogComponent uint8
+
+func (LogComponent) Enumeration() LogComponent { return LogComponent(0) } // listing the source and destination
+func (LogComponent) Scheduler() LogComponent   { return LogComponent(1) } // scheduling and starting transfers
+func (LogComponent) Network() LogComponent     { return LogComponent(2) } // requests to, and responses from, the services
+func (LogComponent) Validation() LogComponent  { return LogComponent(3) } // checking transferred data, e.g. its MD5 hash
+
+func (c *LogComponent) Parse(s string) error {
+	val, err := enum.Parse(reflect.TypeOf(c), s, true)
+	if err == nil {
+		*c = val.(LogComponent)
+	}
+	return err
+}
+
+func (c LogComponent) String() string {
+	return enum.StringInt(c, reflect.TypeOf(c))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 var EJobPriority = JobPriority(0)
 
 // JobPriority defines the transfer priorities supported by the Storage Transfer Engine's channels
@@ -465,7 +764,7 @@ func (Location) AllStandardLocations() []Location {
 		ELocation.File(),
 		ELocation.BlobFS(),
 		ELocation.S3(),
-		// TODO: ELocation.GCP
+		ELocation.GCP(),
 	}
 }
 
@@ -606,7 +905,10 @@ func (ft *FromTo) IsPropertyOnlyTransfer() bool {
 	return *ft == EFromTo.BlobNone() || *ft == EFromTo.BlobFSNone() || *ft == EFromTo.FileNone()
 }
 
-// TODO: deletes are not covered by the above Is* routines
+// IsDelete says whether the job removes its sources, rather than transferring them (e.g. BlobTrash)
+func (ft *FromTo) IsDelete() bool {
+	return ft.To() == ELocation.Unknown()
+}
 
 var BenchmarkLmt = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
 
@@ -700,6 +1002,9 @@ func (TransferStatus) TierAvailabilityCheckFailure() TransferStatus { return Tra
 
 func (TransferStatus) Cancelled() TransferStatus { return TransferStatus(-6) }
 
+// Transfer failed because the destination did not meet the condition given with --if-match or --if-none-match.
+func (TransferStatus) PreconditionFailed() TransferStatus { return TransferStatus(-7) }
+
 func (ts TransferStatus) ShouldTransfer() bool {
 	return ts == ETransferStatus.NotStarted() || ts == ETransferStatus.Started() || ts == ETransferStatus.FolderCreated()
 }
@@ -740,6 +1045,131 @@ func (ts *TransferStatus) AtomicStore(newTransferStatus TransferStatus) {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// FailureCategory is the broad kind of error that a transfer failed with, so that the job summary can count failures
+// by kind, and a problem that affects them all, such as an expired SAS or throttling, stands out
+var EFailureCategory = FailureCategory(0)
+
+type FailureCategory uint8
+
+// Other is any failure that none of the other categories describe
+func (FailureCategory) Other() FailureCategory { return FailureCategory(0) }
+
+// Auth is the service turning down the credential, e.g. an expired SAS or a role that doesn't allow the operation
+func (FailureCategory) Auth() FailureCategory { return FailureCategory(1) }
+
+// Throttling is the service being too busy, or the account over its limits, even after retries
+func (FailureCategory) Throttling() FailureCategory { return FailureCategory(2) }
+
+// Network is a request that got no response, e.g. a connection that was refused, reset or timed out
+func (FailureCategory) Network() FailureCategory { return FailureCategory(3) }
+
+// NotFound is the source, or the container or share of the destination, not existing
+func (FailureCategory) NotFound() FailureCategory { return FailureCategory(4) }
+
+// Precondition is a condition of the request not being met, e.g. --if-match, or a source that changed during the transfer
+func (FailureCategory) Precondition() FailureCategory { return FailureCategory(5) }
+
+// ChecksumMismatch is the data not matching its MD5 or CRC64 once it was transferred
+func (FailureCategory) ChecksumMismatch() FailureCategory { return FailureCategory(6) }
+
+func (fc FailureCategory) String() string {
+	return enum.StringInt(fc, reflect.TypeOf(fc))
+}
+
+func (fc *FailureCategory) Parse(s string) error {
+	val, err := enum.Parse(reflect.TypeOf(fc), s, true)
+	if err == nil {
+		*fc = val.(FailureCategory)
+	}
+	return err
+}
+
+func (fc FailureCategory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fc.String())
+}
+
+func (fc *FailureCategory) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return fc.Parse(s)
+}
+
+// FailureCategoryForStatusCode gives the category of a failure from the HTTP status code of the response it came with.
+// It's all there is to go by when the error itself is not known, e.g. for a job read back from its plan files
+func FailureCategoryForStatusCode(statusCode int) FailureCategory {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return EFailureCategory.Auth()
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return EFailureCategory.Throttling()
+	case http.StatusNotFound:
+		return EFailureCategory.NotFound()
+	case http.StatusPreconditionFailed:
+		return EFailureCategory.Precondition()
+	default:
+		return EFailureCategory.Other()
+	}
+}
+
+// failureCategories is the order in which the job summary lists the categories
+var failureCategories = []FailureCategory{EFailureCategory.Auth(), EFailureCategory.Throttling(), EFailureCategory.Network(),
+	EFailureCategory.NotFound(), EFailureCategory.Precondition(), EFailureCategory.ChecksumMismatch(), EFailureCategory.Other()}
+
+// FailureCounts counts failed transfers by their FailureCategory
+type FailureCounts struct {
+	Auth             uint32 `json:",string"`
+	Throttling       uint32 `json:",string"`
+	Network          uint32 `json:",string"`
+	NotFound         uint32 `json:",string"`
+	Precondition     uint32 `json:",string"`
+	ChecksumMismatch uint32 `json:",string"`
+	Other            uint32 `json:",string"`
+}
+
+func (fc *FailureCounts) counter(category FailureCategory) *uint32 {
+	switch category {
+	case EFailureCategory.Auth():
+		return &fc.Auth
+	case EFailureCategory.Throttling():
+		return &fc.Throttling
+	case EFailureCategory.Network():
+		return &fc.Network
+	case EFailureCategory.NotFound():
+		return &fc.NotFound
+	case EFailureCategory.Precondition():
+		return &fc.Precondition
+	case EFailureCategory.ChecksumMismatch():
+		return &fc.ChecksumMismatch
+	default:
+		return &fc.Other
+	}
+}
+
+// Add counts one more failure in the category
+func (fc *FailureCounts) Add(category FailureCategory) {
+	*fc.counter(category)++
+}
+
+// Of gives the number of failures in the category
+func (fc FailureCounts) Of(category FailureCategory) uint32 {
+	return *fc.counter(category)
+}
+
+// String lists the categories that have failures, with their counts, e.g. "Auth: 2, Network: 1". It's empty if there are none
+func (fc FailureCounts) String() string {
+	parts := make([]string, 0, len(failureCategories))
+	for _, category := range failureCategories {
+		if n := fc.Of(category); n > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", category, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 var EBlockBlobTier = BlockBlobTier(0)
 
 type BlockBlobTier uint8
@@ -838,6 +1268,7 @@ func (CredentialType) SharedKey() CredentialType            { return CredentialT
 func (CredentialType) S3AccessKey() CredentialType          { return CredentialType(4) } // For S3, AccessKeyID and SecretAccessKey
 func (CredentialType) GoogleAppCredentials() CredentialType { return CredentialType(5) }
 func (CredentialType) S3PublicBucket() CredentialType       { return CredentialType(6) } // For S3, Anon Credentials & public bucket
+func (CredentialType) GoogleHMACKey() CredentialType        { return CredentialType(8) } // For GCP, HMAC access ID and secret, used through the XML API
 
 func (ct CredentialType) IsAzureOAuth() bool {
 	return ct == ct.OAuthToken() || ct == ct.MDOAuthToken()
@@ -1419,8 +1850,9 @@ var EEntityType = EntityType(0)
 
 type EntityType uint8
 
-func (EntityType) File() EntityType   { return EntityType(0) }
-func (EntityType) Folder() EntityType { return EntityType(1) }
+func (EntityType) File() EntityType    { return EntityType(0) }
+func (EntityType) Folder() EntityType  { return EntityType(1) }
+func (EntityType) Symlink() EntityType { return EntityType(2) }
 
 func (e EntityType) String() string {
 	return enum.StringInt(e, reflect.TypeOf(e))
@@ -2,6 +2,8 @@ package common
 
 import (
 	gcpUtils "cloud.google.com/go/storage"
+	"encoding/base64"
+	"net/http"
 	"strings"
 )
 
@@ -49,3 +51,42 @@ func (gie *GCPObjectInfoExtension) NewCommonMetadata() Metadata {
 	}
 	return md
 }
+
+// GCPHMACObjectInfoExtension holds the properties of an object read through GCP's XML API, as is done when authenticating with an HMAC key.
+// The XML API returns the standard headers as S3 does, but the MD5 and user-defined metadata are returned in GCP's own headers.
+type GCPHMACObjectInfoExtension struct {
+	ObjectInfoExtension
+}
+
+const gcpHashHeader = "x-goog-hash"
+const gcpHashMD5Prefix = "md5="
+
+// ContentMD5 returns the MD5 listed in the x-goog-hash header, which also lists the crc32c of the object.
+func (gie *GCPHMACObjectInfoExtension) ContentMD5() []byte {
+	for _, v := range gie.ObjectInfo.Metadata[http.CanonicalHeaderKey(gcpHashHeader)] {
+		for _, hash := range strings.Split(v, ",") {
+			hash = strings.TrimSpace(hash)
+			if strings.HasPrefix(hash, gcpHashMD5Prefix) {
+				b, err := base64.StdEncoding.DecodeString(hash[len(gcpHashMD5Prefix):])
+				if err != nil {
+					return nil
+				}
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// NewCommonMetadata returns a map of user-defined key/value pairs
+func (gie *GCPHMACObjectInfoExtension) NewCommonMetadata() Metadata {
+	md := Metadata{}
+	for k, v := range gie.ObjectInfo.Metadata {
+		if len(k) > gcpMetadataPrefixLen {
+			if prefix := k[0:gcpMetadataPrefixLen]; strings.EqualFold(prefix, gcpMetadataPrefix) {
+				md[k[gcpMetadataPrefixLen:]] = v[0]
+			}
+		}
+	}
+	return md
+}
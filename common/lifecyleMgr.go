@@ -4,12 +4,14 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -34,6 +36,8 @@ var lcm = func() (lcmgr *lifecycleMgr) {
 		msgHandlerChannel:    make(chan *LCMMsg),
 	}
 
+	lcmgr.output.Store(outputWriter{os.Stdout})
+
 	// kick off the single routine that processes output
 	go lcmgr.processOutputMessage()
 
@@ -51,6 +55,8 @@ var lcm = func() (lcmgr *lifecycleMgr) {
 type LifecycleMgr interface {
 	Init(OutputBuilder)                                          // let the user know the job has started and initial information like log location
 	Progress(OutputBuilder)                                      // print on the same line over and over again, not allowed to float up
+	ProgressEvent(ProgressEvent)                                 // emit structured progress, if EnableProgressEvents was called. Numbers and timestamps the event
+	EnableProgressEvents()                                       // the caller consumes structured progress events
 	Exit(OutputBuilder, ExitCode)                                // indicates successful execution exit after printing, allow user to specify exit code
 	Info(string)                                                 // simple print, allowed to float up
 	Dryrun(OutputBuilder)                                        // print files for dry run mode
@@ -75,6 +81,7 @@ type LifecycleMgr interface {
 	MsgHandlerChannel() <-chan *LCMMsg
 	ReportAllJobPartsDone()
 	SetOutputVerbosity(mode OutputVerbosity)
+	SetOutputWriter(w io.Writer) // write the output here instead of to stdout, which a download to stdout needs for the data
 }
 
 func GetLifecycleMgr() LifecycleMgr {
@@ -91,6 +98,7 @@ type lifecycleMgr struct {
 	e2eAllowOpenChannel   chan struct{}
 	waitEverCalled        int32
 	outputFormat          OutputFormat
+	output                atomic.Value // the outputWriter that the output goes to. Set by SetOutputWriter while the output routine may be reading it
 	logSanitizer          pipeline.LogSanitizer
 	inputQueue            chan userInput // msgs from the user
 	allowWatchInput       bool           // accept user inputs and place then in the inputQueue
@@ -102,6 +110,9 @@ type lifecycleMgr struct {
 	waitForUserResponse   chan bool
 	msgHandlerChannel     chan *LCMMsg
 	OutputVerbosityType   OutputVerbosity
+	progressEventsEnabled bool
+	progressEventMutex    sync.Mutex // so that events reach the queue in the order of their sequence numbers
+	progressEventSequence uint64
 }
 
 type userInput struct {
@@ -265,6 +276,27 @@ func (lcm *lifecycleMgr) Progress(o OutputBuilder) {
 	}
 }
 
+func (lcm *lifecycleMgr) EnableProgressEvents() {
+	lcm.progressEventsEnabled = true
+}
+
+func (lcm *lifecycleMgr) ProgressEvent(e ProgressEvent) {
+	if !lcm.progressEventsEnabled {
+		return
+	}
+
+	lcm.progressEventMutex.Lock()
+	defer lcm.progressEventMutex.Unlock()
+
+	lcm.progressEventSequence++
+	e.Sequence = lcm.progressEventSequence
+	e.TimeStamp = time.Now()
+	lcm.msgQueue <- outputMessage{
+		msgContent: GetJsonStringFromTemplate(e),
+		msgType:    eOutputMessageType.ProgressEvent(),
+	}
+}
+
 func (lcm *lifecycleMgr) Info(msg string) {
 
 	msg = lcm.logSanitizer.SanitizeLogMessage(msg) // sometimes error-like text comes through Info, before the final "we've failed, please stop now" signal comes to Error. So we sanitize in both places.
@@ -434,12 +466,13 @@ func (lcm *lifecycleMgr) processNoneOutput(msgToOutput outputMessage) {
 }
 
 func (lcm *lifecycleMgr) processJSONOutput(msgToOutput outputMessage) {
+	out := lcm.outputWriter()
 	msgType := msgToOutput.msgType
 	questionTime := time.Now()
 
 	// simply output the json message
 	// we assume the msgContent is already formatted correctly
-	fmt.Println(GetJsonStringFromTemplate(newJsonOutputTemplate(msgType, msgToOutput.msgContent,
+	fmt.Fprintln(out, GetJsonStringFromTemplate(newJsonOutputTemplate(msgType, msgToOutput.msgContent,
 		msgToOutput.promptDetails)))
 
 	// exit if needed
@@ -453,12 +486,13 @@ func (lcm *lifecycleMgr) processJSONOutput(msgToOutput outputMessage) {
 }
 
 func (lcm *lifecycleMgr) processTextOutput(msgToOutput outputMessage) {
+	out := lcm.outputWriter()
 	// when a new line needs to overwrite the current line completely
 	// we need to make sure that if the new line is shorter, we properly erase everything from the current line
 	var matchLengthWithSpaces = func(curLineLength, newLineLength int) {
 		if dirtyLeftover := curLineLength - newLineLength; dirtyLeftover > 0 {
 			for i := 0; i < dirtyLeftover; i++ {
-				fmt.Print(" ")
+				fmt.Fprint(out, " ")
 			}
 		}
 	}
@@ -468,7 +502,7 @@ func (lcm *lifecycleMgr) processTextOutput(msgToOutput outputMessage) {
 		// simply print and quit
 		// if no message is intended, avoid adding new lines
 		if msgToOutput.msgContent != "" {
-			fmt.Println("\n" + msgToOutput.msgContent)
+			fmt.Fprintln(out, "\n"+msgToOutput.msgContent)
 		}
 		if msgToOutput.shouldExitProcess() {
 			lcm.closeFunc()
@@ -476,8 +510,8 @@ func (lcm *lifecycleMgr) processTextOutput(msgToOutput outputMessage) {
 		}
 
 	case eOutputMessageType.Progress():
-		fmt.Print("\r")                   // return carriage back to start
-		fmt.Print(msgToOutput.msgContent) // print new progress
+		fmt.Fprint(out, "\r")                   // return carriage back to start
+		fmt.Fprint(out, msgToOutput.msgContent) // print new progress
 
 		// it is possible that the new progress status is somehow shorter than the previous one
 		// in this case we must erase the left over characters from the previous progress
@@ -488,39 +522,39 @@ func (lcm *lifecycleMgr) processTextOutput(msgToOutput outputMessage) {
 	case eOutputMessageType.Init(), eOutputMessageType.Info(), eOutputMessageType.Dryrun(), eOutputMessageType.Response():
 		if lcm.progressCache != "" { // a progress status is already on the last line
 			// print the info from the beginning on current line
-			fmt.Print("\r")
-			fmt.Print(msgToOutput.msgContent)
+			fmt.Fprint(out, "\r")
+			fmt.Fprint(out, msgToOutput.msgContent)
 
 			// it is possible that the info is shorter than the progress status
 			// in this case we must erase the left over characters from the progress status
 			matchLengthWithSpaces(len(lcm.progressCache), len(msgToOutput.msgContent))
 
 			// print the previous progress status again, so that it's on the last line
-			fmt.Print("\n")
-			fmt.Print(lcm.progressCache)
+			fmt.Fprint(out, "\n")
+			fmt.Fprint(out, lcm.progressCache)
 		} else {
-			fmt.Println(msgToOutput.msgContent)
+			fmt.Fprintln(out, msgToOutput.msgContent)
 		}
 	case eOutputMessageType.Prompt():
 		questionTime := time.Now()
 
 		if lcm.progressCache != "" { // a progress status is already on the last line
 			// print the prompt from the beginning on current line
-			fmt.Print("\r")
-			fmt.Print(msgToOutput.msgContent)
+			fmt.Fprint(out, "\r")
+			fmt.Fprint(out, msgToOutput.msgContent)
 
 			// it is possible that the prompt is shorter than the progress status
 			// in this case we must erase the left over characters from the progress status
 			matchLengthWithSpaces(len(lcm.progressCache), len(msgToOutput.msgContent))
 
 		} else {
-			fmt.Print(msgToOutput.msgContent)
+			fmt.Fprint(out, msgToOutput.msgContent)
 		}
 
 		// example output: Please confirm with: [Y] Yes  [N] No  [A] Yes for all  [L] No for all
-		fmt.Print(" Please confirm with:")
+		fmt.Fprint(out, " Please confirm with:")
 		for _, option := range msgToOutput.promptDetails.ResponseOptions {
-			fmt.Printf(" [%s] %s ", strings.ToUpper(option.ResponseString), option.UserFriendlyResponseType)
+			fmt.Fprintf(out, " [%s] %s ", strings.ToUpper(option.ResponseString), option.UserFriendlyResponseType)
 		}
 
 		// read the response to the prompt and send it back through the channel
@@ -679,6 +713,19 @@ func (lcm *lifecycleMgr) SetOutputVerbosity(mode OutputVerbosity) {
 	lcm.OutputVerbosityType = mode
 }
 
+// outputWriter wraps the writer that the output goes to, so that atomic.Value always holds the same type
+type outputWriter struct {
+	io.Writer
+}
+
+func (lcm *lifecycleMgr) SetOutputWriter(w io.Writer) {
+	lcm.output.Store(outputWriter{w})
+}
+
+func (lcm *lifecycleMgr) outputWriter() io.Writer {
+	return lcm.output.Load().(outputWriter).Writer
+}
+
 // captures the common logic of exiting if there's an expected error
 func PanicIfErr(err error) {
 	if err != nil {
@@ -0,0 +1,53 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// GlobalDialLocalAddress is the local address that AzCopy's connections are made from, as set by --local-address, so
+// that they leave by the network interface that has it. Nil lets the OS choose one, by its routing table.
+// Only the IP is set. The port is left to the OS, as for any outgoing connection
+var GlobalDialLocalAddress net.Addr
+
+// ParseLocalAddress parses the IP to make connections from, and checks that it's assigned to this machine, so that a
+// mistyped or unavailable address fails before any work is done, rather than on every connection
+func ParseLocalAddress(raw string) (net.Addr, error) {
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return nil, fmt.Errorf("'%s' is not an IP address", raw)
+	}
+	if ip.IsUnspecified() || ip.IsMulticast() {
+		return nil, errors.New("the local address must be the unicast address of one of this machine's network interfaces")
+	}
+
+	// binding a listener is the most direct check that the OS will let us use the address
+	l, err := net.Listen("tcp", net.JoinHostPort(ip.String(), "0"))
+	if err != nil {
+		return nil, fmt.Errorf("the local address %s is not available on this machine: %w", ip, err)
+	}
+	_ = l.Close()
+
+	return &net.TCPAddr{IP: ip}, nil
+}
@@ -45,8 +45,9 @@ func NewAzCopyLogSanitizer() pipeline.LogSanitizer {
 var sensitiveQueryStringKeys = []string{
 	"sig", // was strings.ToLower(SigAzure), but that isn't fully init-order-safe, see init() method below
 	//omitted because covered by "signature" below strings.ToLower(SigXAmzForAws),
-	"signature", // covers both "signature" and x-amz-signature. The former may be used in AWS authorization headers. Not sure if we ever log those, but may as well redact them if we do
-	"token",     // seems worth removing in case something uses it one day (e.g. if we support a new backend)
+	"signature",           // covers both "signature" and x-amz-signature. The former may be used in AWS authorization headers. Not sure if we ever log those, but may as well redact them if we do
+	"token",               // seems worth removing in case something uses it one day (e.g. if we support a new backend)
+	"x-ms-encryption-key", // the customer provided key (CPK), in case a request is logged as part of an error. Its SHA256 header doesn't match, since it's not followed by : or =
 }
 
 // SanitizeLogLine removes credentials and credential-like strings that are expected to exist
@@ -50,6 +50,79 @@ type ILoggerResetable interface {
 	ILoggerCloser
 }
 
+// IComponentLogger is a logger whose components can each log at a level of their own
+type IComponentLogger interface {
+	ForComponent(component LogComponent) ILogger
+}
+
+// LoggerForComponent returns the logger for the messages of component. That's logger's view of the component, if it
+// has one, or else logger itself
+func LoggerForComponent(logger ILogger, component LogComponent) ILogger {
+	if cl, ok := logger.(IComponentLogger); ok {
+		return cl.ForComponent(component)
+	}
+	return logger
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ComponentLogLevels are the log levels of the components of a job. Those that haven't been given a level of their
+// own log at Default, which is what --log-level sets
+type ComponentLogLevels struct {
+	Default LogLevel
+	levels  map[LogComponent]LogLevel
+}
+
+func NewComponentLogLevels(defaultLevel LogLevel) ComponentLogLevels {
+	return ComponentLogLevels{Default: defaultLevel, levels: map[LogComponent]LogLevel{}}
+}
+
+// Parse sets the levels of the components listed in s, which looks like "enumeration=debug;network=warning"
+func (c *ComponentLogLevels) Parse(s string) error {
+	if c.levels == nil {
+		c.levels = map[LogComponent]LogLevel{}
+	}
+	for _, pair := range strings.Split(s, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, levelName, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("'%s' is not of the form component=level", pair)
+		}
+
+		var component LogComponent
+		if err := component.Parse(strings.TrimSpace(name)); err != nil {
+			return fmt.Errorf("'%s' is not a component that can be logged. Valid components are enumeration, scheduler, network and validation", name)
+		}
+		var level LogLevel
+		if err := level.Parse(strings.TrimSpace(levelName)); err != nil {
+			return fmt.Errorf("'%s' is not a log level for %s: %w", levelName, name, err)
+		}
+		c.levels[component] = level
+	}
+	return nil
+}
+
+// For returns the level at which component logs
+func (c ComponentLogLevels) For(component LogComponent) LogLevel {
+	if level, ok := c.levels[component]; ok {
+		return level
+	}
+	return c.Default
+}
+
+// Max returns the most detailed of the levels. A log file is only needed if that logs something
+func (c ComponentLogLevels) Max() LogLevel {
+	max := c.Default
+	for _, level := range c.levels {
+		if level > max {
+			max = level
+		}
+	}
+	return max
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func NewAppLogger(minimumLevelToLog pipeline.LogLevel, logFileFolder string) ILoggerCloser {
@@ -106,18 +179,26 @@ type jobLogger struct {
 	// maximum loglevel represents the maximum severity of log messages which can be logged to Job Log file.
 	// any message with severity higher than this will be ignored.
 	jobID             JobID
-	minimumLevelToLog pipeline.LogLevel // The maximum customer-desired log level for this job
-	file              *os.File          // The job's log file
-	logFileFolder     string            // The log file's parent folder, needed for opening the file at the right place
-	logger            *log.Logger       // The Job's logger
+	minimumLevelToLog pipeline.LogLevel  // The maximum customer-desired log level for this job
+	componentLevels   ComponentLogLevels // The levels of the components of the job, for ForComponent
+	file              *os.File           // The job's log file
+	logFileFolder     string             // The log file's parent folder, needed for opening the file at the right place
+	logger            *log.Logger        // The Job's logger
 	sanitizer         pipeline.LogSanitizer
 	logFileNameSuffix string // Used to allow more than 1 log per job, ex: front-end and back-end logs should be separate
 }
 
 func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, logFileFolder string, logFileNameSuffix string) ILoggerResetable {
+	return NewComponentJobLogger(jobID, NewComponentLogLevels(minimumLevelToLog), logFileFolder, logFileNameSuffix)
+}
+
+// NewComponentJobLogger is NewJobLogger for a job whose components may log at different levels. What isn't logged for
+// a particular component, through ForComponent, is logged at levels.Default
+func NewComponentJobLogger(jobID JobID, levels ComponentLogLevels, logFileFolder string, logFileNameSuffix string) ILoggerResetable {
 	return &jobLogger{
 		jobID:             jobID,
-		minimumLevelToLog: minimumLevelToLog.ToPipelineLogLevel(),
+		minimumLevelToLog: levels.Default.ToPipelineLogLevel(),
+		componentLevels:   levels,
 		logFileFolder:     logFileFolder,
 		sanitizer:         NewAzCopyLogSanitizer(),
 		logFileNameSuffix: logFileNameSuffix,
@@ -125,7 +206,7 @@ func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, logFileFolder string,
 }
 
 func (jl *jobLogger) OpenLog() {
-	if jl.minimumLevelToLog == pipeline.LogNone {
+	if jl.componentLevels.Max() == ELogLevel.None() {
 		return
 	}
 
@@ -159,7 +240,7 @@ func (jl *jobLogger) ShouldLog(level pipeline.LogLevel) bool {
 }
 
 func (jl *jobLogger) CloseLog() {
-	if jl.minimumLevelToLog == pipeline.LogNone {
+	if jl.componentLevels.Max() == ELogLevel.None() {
 		return
 	}
 
@@ -169,9 +250,12 @@ func (jl *jobLogger) CloseLog() {
 }
 
 func (jl jobLogger) Log(loglevel pipeline.LogLevel, msg string) {
-	// If the logger for Job is not initialized i.e file is not open
-	// or logger instance is not initialized, then initialize it
+	if jl.ShouldLog(loglevel) {
+		jl.write(msg)
+	}
+}
 
+func (jl jobLogger) write(msg string) {
 	// ensure all secrets are redacted
 	msg = jl.sanitizer.SanitizeLogMessage(msg)
 
@@ -180,9 +264,11 @@ func (jl jobLogger) Log(loglevel pipeline.LogLevel, msg string) {
 	if lineEnding != "\n" {
 		msg = strings.Replace(msg, "\n", lineEnding, -1)
 	}
-	if jl.ShouldLog(loglevel) {
-		jl.logger.Println(msg)
-	}
+	jl.logger.Println(msg)
+}
+
+func (jl *jobLogger) ForComponent(component LogComponent) ILogger {
+	return componentLogger{jl: jl, level: jl.componentLevels.For(component).ToPipelineLogLevel()}
 }
 
 func (jl jobLogger) Panic(err error) {
@@ -191,6 +277,27 @@ func (jl jobLogger) Panic(err error) {
 	// We should never reach this line of code!
 }
 
+// componentLogger writes the messages of one component of a job to the job's log, if they are within that
+// component's level
+type componentLogger struct {
+	jl    *jobLogger
+	level pipeline.LogLevel
+}
+
+func (cl componentLogger) ShouldLog(level pipeline.LogLevel) bool {
+	return level != pipeline.LogNone && level <= cl.level
+}
+
+func (cl componentLogger) Log(level pipeline.LogLevel, msg string) {
+	if cl.ShouldLog(level) {
+		cl.jl.write(msg)
+	}
+}
+
+func (cl componentLogger) Panic(err error) {
+	cl.jl.Panic(err)
+}
+
 const TryEquals string = "Try=" // TODO: refactor so that this can be used by the retry policies too?  So that when you search the logs for Try= you are guaranteed to find both types of retry (i.e. request send retries, and body read retries)
 
 func NewReadLogFunc(logger ILogger, fullUrl *url.URL) func(int, error, int64, int64, bool) {
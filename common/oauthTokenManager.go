@@ -33,12 +33,14 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -92,6 +94,7 @@ func newAzcopyHTTPClient() *http.Client {
 				Timeout:   10 * time.Second,
 				KeepAlive: 10 * time.Second,
 				DualStack: true,
+				LocalAddr: GlobalDialLocalAddress,
 			}).Dial, /*Context*/
 			MaxIdleConns:           0, // No limit
 			MaxIdleConnsPerHost:    1000,
@@ -440,6 +443,90 @@ func (credInfo *OAuthTokenInfo) GetNewTokenFromCert(ctx context.Context) (*adal.
 	}
 }
 
+// federatedTokenFileSecret authenticates an application with the federated token in a file, such as the one that AKS
+// workload identity projects into a pod. The file is read each time a token is needed, since it is rotated
+type federatedTokenFileSecret struct {
+	path string
+}
+
+func (s *federatedTokenFileSecret) SetAuthenticationValues(_ *adal.ServicePrincipalToken, v *url.Values) error {
+	federatedToken, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("reading the federated token: %w", err)
+	}
+	v.Set("client_assertion", strings.TrimSpace(string(federatedToken)))
+	v.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	return nil
+}
+
+// workloadIdentityLoginNoUOTM non-interactively logs in as the application that trusts the federated token in tokenFile.
+func workloadIdentityLoginNoUOTM(ctx context.Context, tenantID, activeDirectoryEndpoint, tokenFile, applicationID, resource string) (*OAuthTokenInfo, error) {
+	if tenantID == "" || applicationID == "" || tokenFile == "" {
+		return nil, errors.New("workload identity auth requires a tenant ID, an application (client) ID and a federated token file")
+	}
+
+	if activeDirectoryEndpoint == "" {
+		activeDirectoryEndpoint = DefaultActiveDirectoryEndpoint
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	spt, err := adal.NewServicePrincipalTokenWithSecret(*oauthConfig, applicationID, resource, &federatedTokenFileSecret{path: tokenFile})
+	if err != nil {
+		return nil, err
+	}
+
+	err = spt.RefreshWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// As with a client secret, no refresh token is given, so refreshing means exchanging the federated token again
+	return &OAuthTokenInfo{
+		Token:                   spt.Token(),
+		Tenant:                  tenantID,
+		ActiveDirectoryEndpoint: activeDirectoryEndpoint,
+		ApplicationID:           applicationID,
+		WorkloadIdentity:        true,
+		FederatedTokenFile:      tokenFile,
+	}, nil
+}
+
+// WorkloadIdentityLogin is a UOTM shell for workloadIdentityLoginNoUOTM.
+func (uotm *UserOAuthTokenManager) WorkloadIdentityLogin(tenantID, activeDirectoryEndpoint, tokenFile, applicationID string, persist bool) (*OAuthTokenInfo, error) {
+	oAuthTokenInfo, err := workloadIdentityLoginNoUOTM(context.TODO(), tenantID, activeDirectoryEndpoint, tokenFile, applicationID, Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	uotm.stashedInfo = oAuthTokenInfo
+	if persist {
+		err = uotm.credCache.SaveToken(*oAuthTokenInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return oAuthTokenInfo, nil
+}
+
+// GetNewTokenFromWorkloadIdentity is a refresh shell for workloadIdentityLoginNoUOTM
+func (credInfo *OAuthTokenInfo) GetNewTokenFromWorkloadIdentity(ctx context.Context) (*adal.Token, error) {
+	targetResource := Resource
+	if credInfo.Token.Resource != "" && credInfo.Token.Resource != targetResource {
+		targetResource = credInfo.Token.Resource
+	}
+
+	tokenInfo, err := workloadIdentityLoginNoUOTM(ctx, credInfo.Tenant, credInfo.ActiveDirectoryEndpoint, credInfo.FederatedTokenFile, credInfo.ApplicationID, targetResource)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenInfo.Token, nil
+}
+
 // UserLogin interactively logins in with specified tenantID and activeDirectoryEndpoint, persist indicates whether to
 // cache the token on local disk.
 func (uotm *UserOAuthTokenManager) UserLogin(tenantID, activeDirectoryEndpoint string, persist bool) (*OAuthTokenInfo, error) {
@@ -628,6 +715,10 @@ type OAuthTokenInfo struct {
 	IdentityInfo            IdentityInfo
 	ServicePrincipalName    bool `json:"_spn"`
 	SPNInfo                 SPNInfo
+	// WorkloadIdentity says that the token was got for ApplicationID, in exchange for the federated token in
+	// FederatedTokenFile, as with AKS workload identity
+	WorkloadIdentity   bool   `json:"_workload_identity"`
+	FederatedTokenFile string `json:"_federated_token_file"`
 	// Note: ClientID should be only used for internal integrations through env var with refresh token.
 	// It indicates the Application ID assigned to your app when you registered it with Azure AD.
 	// In this case AzCopy refresh token on behalf of caller.
@@ -680,6 +771,10 @@ func (credInfo *OAuthTokenInfo) Refresh(ctx context.Context) (*adal.Token, error
 		return credInfo.GetNewTokenFromMSI(ctx)
 	}
 
+	if credInfo.WorkloadIdentity {
+		return credInfo.GetNewTokenFromWorkloadIdentity(ctx)
+	}
+
 	if credInfo.ServicePrincipalName {
 		if credInfo.SPNInfo.CertPath != "" {
 			return credInfo.GetNewTokenFromCert(ctx)
@@ -691,7 +786,17 @@ func (credInfo *OAuthTokenInfo) Refresh(ctx context.Context) (*adal.Token, error
 	return credInfo.RefreshTokenWithUserCredential(ctx)
 }
 
-var msiTokenHTTPClient = newAzcopyHTTPClient()
+var msiTokenHTTPClient *http.Client
+var msiTokenHTTPClientOnce sync.Once
+
+// getMSITokenHTTPClient makes the client for IMDS on first use, rather than when the package is loaded, so that it
+// dials from the --local-address, which is only known once the command line has been parsed
+func getMSITokenHTTPClient() *http.Client {
+	msiTokenHTTPClientOnce.Do(func() {
+		msiTokenHTTPClient = newAzcopyHTTPClient()
+	})
+	return msiTokenHTTPClient
+}
 
 // Single instance token store credential cache shared by entire azcopy process.
 var tokenStoreCredCache = NewCredCacheInternalIntegration(CredCacheOptions{
@@ -744,11 +849,12 @@ func (credInfo *OAuthTokenInfo) queryIMDS(ctx context.Context, msiEndpoint strin
 	// Set context.
 	req.WithContext(ctx)
 	// In case of some other process (Http Server) listening at 127.0.0.1:40342 , we do not want to wait forever for it to serve request
-	msiTokenHTTPClient.Timeout = 10 * time.Second
+	client := getMSITokenHTTPClient()
+	client.Timeout = 10 * time.Second
 	// Send request
-	resp, err := msiTokenHTTPClient.Do(req)
+	resp, err := client.Do(req)
 	// Unset the timeout back
-	msiTokenHTTPClient.Timeout = 0
+	client.Timeout = 0
 	return req, resp, err
 }
 
@@ -872,7 +978,7 @@ func (credInfo *OAuthTokenInfo) GetNewTokenFromMSI(ctx context.Context) (*adal.T
 
 		req.Header.Set("Authorization", "Basic "+challengeToken)
 
-		resp, errArcVM = msiTokenHTTPClient.Do(req)
+		resp, errArcVM = getMSITokenHTTPClient().Do(req)
 		if errArcVM != nil {
 			return nil, fmt.Errorf("failed to query token from Arc IMDS endpoint: %v", errArcVM)
 		}
@@ -31,6 +31,8 @@ func (outputMessageType) Prompt() outputMessageType { return outputMessageType(5
 func (outputMessageType) Response() outputMessageType { return outputMessageType(7) } /* Response to LCMMsg (like PerformanceAdjustment)
 //Json with determined fields for output-type json, INFO for other o/p types. */
 
+func (outputMessageType) ProgressEvent() outputMessageType { return outputMessageType(8) } // structured progress, only printed (as json) when asked for with --progress-events
+
 func (o outputMessageType) String() string {
 	return enum.StringInt(o, reflect.TypeOf(o))
 }
@@ -90,6 +92,49 @@ func newJsonOutputTemplate(messageType outputMessageType, messageContent string,
 		MessageContent: messageContent, PromptDetails: promptDetails}
 }
 
+// ProgressEvent is the structured form of a progress report, for callers that consume AzCopy's output to build
+// dashboards and the like. The lifecycle manager numbers the events in the order that they are emitted
+type ProgressEvent struct {
+	Sequence  uint64 `json:",string"`
+	TimeStamp time.Time
+	JobID     JobID
+	JobDone   bool
+
+	TotalTransfers     uint32 `json:",string"`
+	TransfersCompleted uint32 `json:",string"`
+	TransfersFailed    uint32 `json:",string"`
+	TransfersSkipped   uint32 `json:",string"`
+
+	BytesTransferred uint64 `json:",string"`
+	BytesExpected    uint64 `json:",string"`
+
+	// throughput over the interval since the previous progress report, in megabits per second
+	ThroughputMbps float64 `json:",string"`
+
+	// the job's estimated time remaining. 0 while there is no estimate
+	EstimatedSecondsRemaining int64 `json:",string"`
+
+	// only the transfers that failed since the previous event, so that each failure is reported once
+	FailedTransfers []TransferDetail
+}
+
+func NewProgressEvent(summary ListJobSummaryResponse, throughputMbps float64) ProgressEvent {
+	return ProgressEvent{
+		JobID:              summary.JobID,
+		JobDone:            summary.JobStatus.IsJobDone(),
+		TotalTransfers:     summary.TotalTransfers,
+		TransfersCompleted: summary.TransfersCompleted,
+		TransfersFailed:    summary.TransfersFailed,
+		TransfersSkipped:   summary.TransfersSkipped,
+		BytesTransferred:   summary.TotalBytesTransferred,
+		BytesExpected:      summary.TotalBytesExpected,
+		ThroughputMbps:     throughputMbps,
+		FailedTransfers:    summary.FailedTransfers,
+
+		EstimatedSecondsRemaining: summary.EstimatedSecondsRemaining,
+	}
+}
+
 type InitMsgJsonTemplate struct {
 	LogFileLocation string
 	JobID           string
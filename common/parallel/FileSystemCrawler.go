@@ -25,6 +25,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type FileSystemEntry struct {
@@ -48,22 +49,50 @@ type DirReader interface {
 // The items in the CrawResult output channel are FileSystemEntry s.
 // For a wrapper that makes this look more like filepath.Walk, see parallel.Walk.
 func CrawlLocalDirectory(ctx context.Context, root string, parallelism int, reader DirReader) <-chan CrawlResult {
+	return crawlLocalDirectoryToDepth(ctx, root, 0, parallelism, reader)
+}
+
+// crawlLocalDirectoryToDepth is CrawlLocalDirectory, except that it does not descend into the directories that are maxDepth
+// levels beneath the root (they are still output, but their contents are not listed). A maxDepth of 0 means no limit.
+func crawlLocalDirectoryToDepth(ctx context.Context, root string, maxDepth int, parallelism int, reader DirReader) <-chan CrawlResult {
 	return Crawl(ctx,
 		root,
 		func(dir Directory, enqueueDir func(Directory), enqueueOutput func(DirectoryEntry, error)) error {
+			if maxDepth > 0 {
+				enqueueDir = enqueueDirAboveDepth(root, maxDepth, enqueueDir)
+			}
 			return enumerateOneFileSystemDirectory(dir, enqueueDir, enqueueOutput, reader)
 		},
 		parallelism,
 	)
 }
 
+// enqueueDirAboveDepth wraps enqueueDir so that it drops the directories that are maxDepth or more levels beneath the root
+func enqueueDirAboveDepth(root string, maxDepth int, enqueueDir func(Directory)) func(Directory) {
+	return func(dir Directory) {
+		rel, err := filepath.Rel(root, dir.(string))
+		if err == nil && strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+			return
+		}
+		enqueueDir(dir)
+	}
+}
+
 // Walk is similar to filepath.Walk.
 // But note the following difference is how WalkFunc is used:
 // 1. If fileError passed to walkFunc is not nil, then here the filePath passed to that function will usually be ""
 //    (whereas with filepath.Walk it will usually (always?) have a value).
 // 2. If the return value of walkFunc function is not nil, enumeration will always stop, not matter what the type of the error.
 //    (Unlike filepath.WalkFunc, where returning filePath.SkipDir is handled as a special case).
+// 3. When parallelism is more than 1, walkFn is called in no particular order (not lexical order, as with filepath.Walk),
+//    but still once per entry, and never concurrently.
 func Walk(appCtx context.Context, root string, parallelism int, parallelStat bool, walkFn filepath.WalkFunc) {
+	WalkToDepth(appCtx, root, 0, parallelism, parallelStat, walkFn)
+}
+
+// WalkToDepth is Walk, except that it does not descend into the directories that are maxDepth levels beneath the root.
+// Those directories are still passed to walkFn, but nothing inside them is. A maxDepth of 0 means no limit.
+func WalkToDepth(appCtx context.Context, root string, maxDepth int, parallelism int, parallelStat bool, walkFn filepath.WalkFunc) {
 	var ctx context.Context
 	var cancel context.CancelFunc
 	signalRootError := func(e error) {
@@ -100,7 +129,7 @@ func Walk(appCtx context.Context, root string, parallelism int, parallelStat boo
 	defer reader.Close()
 
 	ctx, cancel = context.WithCancel(appCtx)
-	ch := CrawlLocalDirectory(ctx, root, remainingParallelism, reader)
+	ch := crawlLocalDirectoryToDepth(ctx, root, maxDepth, remainingParallelism, reader)
 	for crawlResult := range ch {
 		entry, err := crawlResult.Item()
 		if err == nil {
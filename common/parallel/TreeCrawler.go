@@ -49,11 +49,37 @@ func (r CrawlResult) Item() (interface{}, error) {
 	return r.item, r.err
 }
 
+// DirectoryListing describes how the listing of one directory went, for telemetry
+type DirectoryListing struct {
+	Directory   Directory
+	Worker      int // the index of the crawl worker that listed it, out of the crawl's parallelism
+	Start       time.Time
+	Duration    time.Duration
+	Entries     int // everything output for the directory, including failures, but not the directory itself
+	Directories int // how many of those entries were queued to be listed in turn
+	Err         error
+}
+
+// ListingObserver is told about each directory that a crawl lists, once it has been listed. It is called by the
+// crawl's workers, so must be safe to call concurrently
+type ListingObserver func(DirectoryListing)
+
+type listingObserverKey struct{}
+
+// WithListingObserver returns a context that has crawls using it tell the observer about each directory they list.
+// Without one, crawls don't time their listings
+func WithListingObserver(ctx context.Context, observer ListingObserver) context.Context {
+	return context.WithValue(ctx, listingObserverKey{}, observer)
+}
+
 // must be safe to be simultaneously called by multiple go-routines, each with a different dir
 type EnumerateOneDirFunc func(dir Directory, enqueueDir func(Directory), enqueueOutput func(DirectoryEntry, error)) error
 
 // Crawl crawls an abstract directory tree, using the supplied enumeration function.  May be use for whatever
 // that function can enumerate (i.e. not necessarily a local file system, just anything tree-structured)
+// Up to parallelism directories are enumerated at once, so sibling directories are listed concurrently.
+// Each entry is output exactly once, but the order of the output is not defined: entries of different directories
+// may be interleaved, and a child may be output before its parent directory.
 func Crawl(ctx context.Context, root Directory, worker EnumerateOneDirFunc, parallelism int) <-chan CrawlResult {
 	c := &crawler{
 		unstartedDirs: make([]Directory, 0, 1024),
@@ -168,13 +194,32 @@ func (c *crawler) processOneDirectory(ctx context.Context, workerIndex int) (boo
 	addDir := func(d Directory) {
 		foundDirectories = append(foundDirectories, d)
 	}
+	entryCount := 0
 	addOutput := func(de DirectoryEntry, er error) {
+		entryCount++
 		select {
 		case c.output <- CrawlResult{item: de, err: er}:
 		case <-ctx.Done(): // don't block on full channel if cancelled
 		}
 	}
+	observer, _ := ctx.Value(listingObserverKey{}).(ListingObserver)
+	var start time.Time
+	if observer != nil {
+		start = time.Now()
+	}
 	bodyErr := c.workerBody(toExamine, addDir, addOutput) // this is the worker body supplied by our caller
+	if observer != nil {
+		// the time includes any waits for room in the output channel, which are stalls of the listing too
+		observer(DirectoryListing{
+			Directory:   toExamine,
+			Worker:      workerIndex,
+			Start:       start,
+			Duration:    time.Since(start),
+			Entries:     entryCount,
+			Directories: len(foundDirectories),
+			Err:         bodyErr,
+		})
+	}
 
 	// finally, update shared state (inside the lock)
 	c.cond.L.Lock()
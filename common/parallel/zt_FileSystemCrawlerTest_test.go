@@ -183,3 +183,23 @@ func (s *fileSystemCrawlerSuite) TestRootErrorsAreSignalled(c *chk.C) {
 	})
 	c.Assert(receivedError, chk.Equals, true)
 }
+
+func (s *fileSystemCrawlerSuite) TestWalkToDepthDoesNotListDeeperDirectories(c *chk.C) {
+	dir := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0700), chk.IsNil)
+	for _, f := range []string{"f1", filepath.Join("a", "f2"), filepath.Join("a", "b", "f3"), filepath.Join("a", "b", "c", "f4")} {
+		c.Assert(os.WriteFile(filepath.Join(dir, f), nil, 0600), chk.IsNil)
+	}
+
+	found := make(map[string]bool)
+	WalkToDepth(context.TODO(), dir, 2, 4, false, func(path string, _ os.FileInfo, fileErr error) error {
+		c.Assert(fileErr, chk.IsNil)
+		rel, err := filepath.Rel(dir, path)
+		c.Assert(err, chk.IsNil)
+		found[filepath.ToSlash(rel)] = true
+		return nil
+	})
+
+	// a/b is two levels down, so it is walked to, but not into
+	c.Assert(found, chk.DeepEquals, map[string]bool{".": true, "f1": true, "a": true, "a/f2": true, "a/b": true})
+}
@@ -143,6 +143,7 @@ type CopyJobPartOrderRequest struct {
 	PreserveSMBPermissions         PreservePermissionsOption
 	PreserveSMBInfo                bool
 	PreservePOSIXProperties        bool
+	PreserveXattrs                 bool // keep the user-namespace extended attributes of local files in blob metadata
 	S2SGetPropertiesInBackend      bool
 	S2SSourceChangeValidation      bool
 	DestLengthValidation           bool
@@ -150,6 +151,19 @@ type CopyJobPartOrderRequest struct {
 	S2SPreserveBlobTags            bool
 	CpkOptions                     CpkOptions
 	SetPropertiesFlags             SetPropertiesFlags
+	PerTransferTimeout             time.Duration // fail a transfer that makes no progress for this long. 0 means never
+	ManifestOutput                 string        // if set, a manifest of the job's transferred and failed files is written to this path
+	MaxErrors                      uint32        // if not 0, the job is cancelled once more than this many of its transfers have failed
+	SnapshotSourceFirst            bool          // copy each source blob from a snapshot of it, taken just before, which is deleted afterwards
+	VerifyCrc64                    bool          // check the CRC64 that the service returns for uploaded data against one computed of what was sent
+	MetadataOnly                   bool          // give existing destination blobs the metadata, tags and HTTP headers of their sources, without copying any data
+	DestinationArchive             ArchiveFormat // stream a download into a single archive file, at the destination root
+	SourceArchive                  ArchiveFormat // upload the entries of a single archive file, at the source root
+	TransferOrder                  TransferOrder // the order in which the transfers of each part are scheduled
+	FollowSourceRedirects          bool          // let downloads follow a bounded number of redirects from their source, keeping their range
+	DownloadRangesInPlace          bool          // write each downloaded range to its place in a sparse file as it arrives, rather than in order
+	AllowTrailingDot               bool          // keep the trailing dots of Azure Files names, rather than letting the service strip them
+	PriorityPattern                string        // for the PatternPriority order, the patterns of the names of the files that are scheduled first, separated by ;
 
 	// S2SSourceCredentialType will override CredentialInfo.CredentialType for use on the source.
 	// As a result, CredentialInfo.OAuthTokenInfo may end up being fulfilled even _if_ CredentialInfo.CredentialType is _not_ OAuth.
@@ -203,24 +217,31 @@ type ListRequest struct {
 
 // This struct represents the optional attribute for blob request header
 type BlobTransferAttributes struct {
-	BlobType                 BlobType              // The type of a blob - BlockBlob, PageBlob, AppendBlob
-	ContentType              string                // The content type specified for the blob.
-	ContentEncoding          string                // Specifies which content encodings have been applied to the blob.
-	ContentLanguage          string                // Specifies the language of the content
-	ContentDisposition       string                // Specifies the content disposition
-	CacheControl             string                // Specifies the cache control header
-	BlockBlobTier            BlockBlobTier         // Specifies the tier to set on the block blobs.
-	PageBlobTier             PageBlobTier          // Specifies the tier to set on the page blobs.
-	Metadata                 string                // User-defined Name-value pairs associated with the blob
-	NoGuessMimeType          bool                  // represents user decision to interpret the content-encoding from source file
-	PreserveLastModifiedTime bool                  // when downloading, tell engine to set file's timestamp to timestamp of blob
-	PutMd5                   bool                  // when uploading, should we create and PUT Content-MD5 hashes
-	MD5ValidationOption      HashValidationOption  // when downloading, how strictly should we validate MD5 hashes?
-	BlockSizeInBytes         int64                 // when uploading/downloading/copying, specify the size of each chunk
-	DeleteSnapshotsOption    DeleteSnapshotsOption // when deleting, specify what to do with the snapshots
-	BlobTagsString           string                // when user explicitly provides blob tags
-	PermanentDeleteOption    PermanentDeleteOption // Permanently deletes soft-deleted snapshots when indicated by user
-	RehydratePriority        RehydratePriorityType // rehydrate priority of blob
+	BlobType                     BlobType              // The type of a blob - BlockBlob, PageBlob, AppendBlob
+	ContentType                  string                // The content type specified for the blob.
+	ContentEncoding              string                // Specifies which content encodings have been applied to the blob.
+	ContentLanguage              string                // Specifies the language of the content
+	ContentDisposition           string                // Specifies the content disposition
+	CacheControl                 string                // Specifies the cache control header
+	BlockBlobTier                BlockBlobTier         // Specifies the tier to set on the block blobs.
+	PageBlobTier                 PageBlobTier          // Specifies the tier to set on the page blobs.
+	Metadata                     string                // User-defined Name-value pairs associated with the blob
+	NoGuessMimeType              bool                  // represents user decision to interpret the content-encoding from source file
+	NoGuessMimeTypeFromExtension bool                  // when guessing the content-type, only look at the content of the file, not at its extension
+	PreserveLastModifiedTime     bool                  // when downloading, tell engine to set file's timestamp to timestamp of blob
+	PutMd5                       bool                  // when uploading, should we create and PUT Content-MD5 hashes
+	MD5ValidationOption          HashValidationOption  // when downloading, how strictly should we validate MD5 hashes?
+	BlockSizeInBytes             int64                 // when uploading/downloading/copying, specify the size of each chunk
+	DeleteSnapshotsOption        DeleteSnapshotsOption // when deleting, specify what to do with the snapshots
+	BlobTagsString               string                // when user explicitly provides blob tags
+	PermanentDeleteOption        PermanentDeleteOption // Permanently deletes soft-deleted snapshots when indicated by user
+	RehydratePriority            RehydratePriorityType // rehydrate priority of blob
+	IfMatchETag                  string                // when writing a blob, only do so if its ETag is this one, or if it exists at all for *
+	IfNoneMatchETag              string                // when writing a blob, only do so if its ETag isn't this one, or if it doesn't exist at all for *
+	ImmutabilityPolicyUntil      time.Time             // when writing a blob, give it an unlocked immutability policy that lasts until then. Zero for none
+	LegalHold                    bool                  // when writing a blob, put it under a legal hold
+	ContentTypeMap               string                // when uploading, the content type of each file extension, as a JSON object
+	DefaultContentType           string                // when uploading with a ContentTypeMap, the content type of the extensions it doesn't list
 }
 
 type JobIDDetails struct {
@@ -263,6 +284,11 @@ type ListJobSummaryResponse struct {
 	TransfersCompleted uint32 `json:",string"`
 	TransfersFailed    uint32 `json:",string"`
 	TransfersSkipped   uint32 `json:",string"`
+	// failed transfers whose destination did not meet the condition given with --if-match or --if-none-match.
+	// These are also counted in TransfersFailed
+	TransfersPreconditionFailed uint32 `json:",string"`
+	// the failed transfers, counted by the kind of error that each failed with. The counts add up to TransfersFailed
+	FailuresByCategory FailureCounts
 
 	// includes bytes sent in retries (i.e. has double counting, if there are retries) and in failed transfers
 	BytesOverWire uint64 `json:",string"`
@@ -285,6 +311,23 @@ type ListJobSummaryResponse struct {
 	ServerBusyPercentage   float32 `json:",string"`
 	NetworkErrorPercentage float32 `json:",string"`
 
+	// the rate that --auto-tune-throughput settled on, for use when choosing a fixed --cap-mbps.
+	// 0 if the throughput was not auto-tuned
+	AutoTunedThroughputMbps float32 `json:",string"`
+
+	// the concurrency that --auto-tune-throughput settled on, for use when choosing a fixed AZCOPY_CONCURRENCY_VALUE.
+	// 0 if the throughput was not auto-tuned
+	AutoTunedConcurrency int `json:",string"`
+
+	// how long the job is expected to take to finish, from its throughput over the last few seconds.
+	// 0 while there is no estimate: until scanning is done, while the job is getting up to speed, and once it is done.
+	// Like the network stats, zero if read outside the process running the job
+	EstimatedSecondsRemaining int64 `json:",string"`
+
+	// files that were downloaded with --preserve-posix-properties, but whose mode or ownership could not be set at the destination.
+	// Such files still count as completed, since their content was transferred
+	POSIXPropertiesNotRestored uint32 `json:",string"`
+
 	FailedTransfers  []TransferDetail
 	SkippedTransfers []TransferDetail
 	PerfConstraint   PerfConstraint
@@ -292,6 +335,15 @@ type ListJobSummaryResponse struct {
 
 	PerformanceAdvice []PerformanceAdvice
 	IsCleanupJob      bool
+
+	// Only set for move jobs, once the job is done and the sources of the successful transfers have been deleted.
+	// Like IsCleanupJob, only FE knows these.
+	SourcesDeleted        uint32 `json:",string"`
+	SourceDeletionsFailed uint32 `json:",string"`
+
+	// why the job cancelled itself before it was done. E.g. because more of its transfers failed than --max-errors allows.
+	// Empty for a job that ran to the end, or that the user cancelled
+	AbortReason string
 }
 
 // wraps the standard ListJobSummaryResponse with sync-specific stats
@@ -299,6 +351,56 @@ type ListSyncJobSummaryResponse struct {
 	ListJobSummaryResponse
 	DeleteTotalTransfers     uint32 `json:",string"`
 	DeleteTransfersCompleted uint32 `json:",string"`
+	DeleteTransfersDeclined  uint32 `json:",string"` // extra files at the destination that the user chose to keep, when prompted
+	SkippedDestinationNewer  uint32 `json:",string"` // files not transferred because they were more recent at the destination
+
+	// how many files were listed at each end, to compare them. Both are 0 when the sync resumed its job instead
+	FilesScannedAtSource      uint64 `json:",string"`
+	FilesScannedAtDestination uint64 `json:",string"`
+}
+
+// DryrunSummary totals what a dry run would have done. It is the content of the message that ends a dry run, which
+// (unlike the summary of a real job) has no JobID
+type DryrunSummary struct {
+	FileCount   uint64 `json:",string"`
+	FolderCount uint64 `json:",string"`
+	TotalBytes  uint64 `json:",string"`
+}
+
+// SyncDryrunSummary is what ends a dry run of sync. Besides the totals of what would be transferred, it sorts the files
+// into those that would be added to the destination, those that would replace a destination file that differs from
+// them, and the destination files that would be deleted
+type SyncDryrunSummary struct {
+	DryrunSummary
+	FilesToAdd    uint64 `json:",string"`
+	BytesToAdd    uint64 `json:",string"`
+	FilesToUpdate uint64 `json:",string"`
+	BytesToUpdate uint64 `json:",string"`
+	FilesToDelete uint64 `json:",string"`
+	BytesToDelete uint64 `json:",string"`
+}
+
+// SyncCompareReport is what ends sync --compare-only. It lists the relative paths of the files found at either end,
+// sorted by how they compare: the files at only one end, the files at both that the comparator finds differ (and so
+// would be transferred by a sync), and those it finds identical. Folders are left out, since they have no content
+type SyncCompareReport struct {
+	OnlyInSource      []string
+	OnlyInDestination []string
+	Differing         []string
+	Identical         []string
+}
+
+// PipeProgress is the progress of an upload from stdin, or a download to stdout. Those don't run as jobs, and so report
+// this instead of a job summary, both as they go and when they end
+type PipeProgress struct {
+	BytesTransferred uint64 `json:",string"`
+	// the size of what is being piped. Only known for downloads, since an upload from stdin ends whenever stdin does
+	TotalBytesKnown    bool
+	TotalBytesExpected uint64  `json:",string"`
+	PercentComplete    float32 // 0 while the total isn't known
+	ElapsedSeconds     float64
+	// the average throughput since the start, in megabits per second
+	Throughput float64
 }
 
 type ListJobTransfersRequest struct {
@@ -313,6 +415,8 @@ type ResumeJobRequest struct {
 	IncludeTransfer map[string]int
 	ExcludeTransfer map[string]int
 	CredentialInfo  CredentialInfo
+	// the destination to resume the job into, instead of its own. Empty for its own. Its SAS is DestinationSAS
+	DestinationOverride ResourceString
 }
 
 // represents the Details and details of a single transfer
@@ -322,7 +426,10 @@ type TransferDetail struct {
 	IsFolderProperties bool
 	TransferStatus     TransferStatus
 	TransferSize       uint64
-	ErrorCode          int32 `json:",string"`
+	ErrorCode          int32           `json:",string"`
+	ErrorMessage       string          `json:",omitempty"` // why the transfer failed, if it did and the reason is known
+	FailureCategory    FailureCategory `json:",omitempty"` // the kind of error the transfer failed with, if it did
+	ContentMD5         []byte          `json:",omitempty"` // the MD5 of the file's content, if it is known
 }
 
 type CancelPauseResumeResponse struct {
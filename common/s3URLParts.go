@@ -52,7 +52,6 @@ type S3URLParts struct {
 
 	isPathStyle bool
 	isDualStack bool
-	// TODO: Other S3 compatible service which might be with IP endpoint style
 }
 
 const s3HostPattern = "^(?P<bucketName>.+\\.)?s3[.-](?P<dualStackOrRegionOrAWSDomain>[a-z0-9-]+)\\.(?P<regionOrAWSDomainOrCom>[a-z0-9-]+)"
@@ -64,12 +63,63 @@ const s3EssentialHostPart = "amazonaws.com"
 
 var s3HostRegex = regexp.MustCompile(s3HostPattern)
 
+// S3CompatibleEndpoint is a service other than AWS that implements the S3 API, such as MinIO or Wasabi.
+// URLs on its host are read as S3 URLs, although they follow none of the naming of AWS.
+type S3CompatibleEndpoint struct {
+	Host           string // the host of the service, with its port if it has one
+	Secure         bool   // whether the service is reached over HTTPS
+	ForcePathStyle bool   // buckets are named in the path of URLs only, never as a subdomain of the host
+	SkipSSLVerify  bool   // accepts any certificate from the service, such as the self-signed one of a self-hosted service
+}
+
+// the S3-compatible endpoint given on the command line, if any. It is set before any URL is parsed
+var s3CompatibleEndpoint *S3CompatibleEndpoint
+
+// SetS3CompatibleEndpoint makes URLs on the host of the endpoint read as S3 URLs. Nil goes back to recognising AWS only.
+func SetS3CompatibleEndpoint(e *S3CompatibleEndpoint) {
+	if e != nil {
+		e.Host = strings.ToLower(e.Host)
+	}
+	s3CompatibleEndpoint = e
+}
+
+// GetS3CompatibleEndpoint returns the S3-compatible endpoint whose host is the given one, if it is that of the endpoint set by SetS3CompatibleEndpoint
+func GetS3CompatibleEndpoint(host string) (S3CompatibleEndpoint, bool) {
+	if e := s3CompatibleEndpoint; e != nil && strings.EqualFold(host, e.Host) {
+		return *e, true
+	}
+	return S3CompatibleEndpoint{}, false
+}
+
+// matchS3CompatibleEndpoint checks whether the host is on the S3-compatible endpoint. If the host names a bucket
+// as a subdomain of the endpoint, that bucket is returned too
+func matchS3CompatibleEndpoint(host string) (bucketName string, isCompatible bool) {
+	e := s3CompatibleEndpoint
+	if e == nil {
+		return "", false
+	}
+	if host == e.Host {
+		return "", true
+	}
+	if !e.ForcePathStyle && strings.HasSuffix(host, "."+e.Host) {
+		return strings.TrimSuffix(host, "."+e.Host), true
+	}
+	return "", false
+}
+
 // IsS3URL verfies if a given URL points to S3 URL supported by AzCopy-v10
 func IsS3URL(u url.URL) bool {
 	if _, isS3URL := findS3URLMatches(strings.ToLower(u.Host)); isS3URL {
 		return true
 	}
-	return false
+	_, isCompatible := matchS3CompatibleEndpoint(strings.ToLower(u.Host))
+	return isCompatible
+}
+
+// IsS3CompatibleURL checks whether the URL is on the S3-compatible endpoint set by SetS3CompatibleEndpoint, rather than on AWS
+func IsS3CompatibleURL(u url.URL) bool {
+	_, isCompatible := matchS3CompatibleEndpoint(strings.ToLower(u.Host))
+	return isCompatible
 }
 
 func findS3URLMatches(host string) (matches []string, isS3Host bool) {
@@ -85,11 +135,6 @@ func NewS3URLParts(u url.URL) (S3URLParts, error) {
 	// S3's bucket name should be in lower case
 	host := strings.ToLower(u.Host)
 
-	matchSlices, isS3URL := findS3URLMatches(host)
-	if !isS3URL {
-		return S3URLParts{}, errors.New(invalidS3URLErrorMessage)
-	}
-
 	path := u.Path
 	// Remove the initial '/' if exists
 	if path != "" && path[0] == '/' {
@@ -101,6 +146,26 @@ func NewS3URLParts(u url.URL) (S3URLParts, error) {
 		Host:   host,
 	}
 
+	if bucketName, isCompatible := matchS3CompatibleEndpoint(host); isCompatible {
+		// The host of a service other than AWS names no region. Many such services ignore the region anyway,
+		// so it is left empty, for the client to use a default that they accept.
+		up.Endpoint = s3CompatibleEndpoint.Host
+		if bucketName != "" {
+			up.BucketName = bucketName
+			up.ObjectKey = path
+		} else {
+			up.isPathStyle = true
+			up.BucketName, up.ObjectKey = splitS3Path(path)
+		}
+		up.parseQuery(u)
+		return up, nil
+	}
+
+	matchSlices, isS3URL := findS3URLMatches(host)
+	if !isS3URL {
+		return S3URLParts{}, errors.New(invalidS3URLErrorMessage)
+	}
+
 	// Check what's the path style, and parse accordingly.
 	if matchSlices[1] != "" { // Go's implementatoin is a bit strange, even if the first subexp fail to be matched, "" will be returned for that sub exp
 		// In this case, it would be in virtual-hosted-style URL, and has host prefix like bucket.s3[-.]
@@ -111,14 +176,7 @@ func NewS3URLParts(u url.URL) (S3URLParts, error) {
 	} else {
 		// In this case, it would be in path-style URL. Host prefix like s3[-.], and path contains the bucket name and object id.
 		up.isPathStyle = true
-
-		if bucketEndIndex := strings.Index(path, "/"); bucketEndIndex != -1 {
-			up.BucketName = path[:bucketEndIndex]
-			up.ObjectKey = path[bucketEndIndex+1:]
-		} else {
-			up.BucketName = path
-		}
-
+		up.BucketName, up.ObjectKey = splitS3Path(path)
 		up.Endpoint = host
 	}
 	// Check if dualstack is contained in host name
@@ -131,18 +189,30 @@ func NewS3URLParts(u url.URL) (S3URLParts, error) {
 		up.Region = matchSlices[2]
 	}
 
+	up.parseQuery(u)
+
+	return up, nil
+}
+
+// splitS3Path splits the path of a path-style URL into the bucket and the key of the object
+func splitS3Path(path string) (bucketName, objectKey string) {
+	if bucketEndIndex := strings.Index(path, "/"); bucketEndIndex != -1 {
+		return path[:bucketEndIndex], path[bucketEndIndex+1:]
+	}
+	return path, ""
+}
+
+func (p *S3URLParts) parseQuery(u url.URL) {
 	// Convert the query parameters to a case-sensitive map & trim whitespace
 	paramsMap := u.Query()
 
 	if versionStr, ok := caseInsensitiveValues(paramsMap).Get(versionQueryParamKey); ok {
-		up.Version = versionStr[0]
+		p.Version = versionStr[0]
 		// If we recognized the query parameter, remove it from the map
 		delete(paramsMap, versionQueryParamKey)
 	}
 
-	up.UnparsedParams = paramsMap.Encode()
-
-	return up, nil
+	p.UnparsedParams = paramsMap.Encode()
 }
 
 // URL returns a URL object whose fields are initialized from the S3URLParts fields.
@@ -205,3 +205,52 @@ func (s *s3URLPartsTestSuite) TestS3URLParseNegative(c *chk.C) {
 	c.Assert(err, chk.NotNil)
 	c.Assert(strings.Contains(err.Error(), invalidS3URLErrorMessage), chk.Equals, true)
 }
+
+func (s *s3URLPartsTestSuite) TestS3CompatibleURLParse(c *chk.C) {
+	SetS3CompatibleEndpoint(&S3CompatibleEndpoint{Host: "MinIO.example.com:9000", Secure: true})
+	defer SetS3CompatibleEndpoint(nil)
+
+	u, _ := url.Parse("https://minio.example.com:9000/bucket/keydir/keyname")
+	c.Assert(IsS3URL(*u), chk.Equals, true)
+	p, err := NewS3URLParts(*u)
+	c.Assert(err, chk.IsNil)
+	c.Assert(p.Endpoint, chk.Equals, "minio.example.com:9000")
+	c.Assert(p.BucketName, chk.Equals, "bucket")
+	c.Assert(p.ObjectKey, chk.Equals, "keydir/keyname")
+	c.Assert(p.Region, chk.Equals, "")
+	c.Assert(p.String(), chk.Equals, "https://minio.example.com:9000/bucket/keydir/keyname")
+
+	u, _ = url.Parse("https://bucket.minio.example.com:9000/keyname?versionId=abc")
+	p, err = NewS3URLParts(*u)
+	c.Assert(err, chk.IsNil)
+	c.Assert(p.Endpoint, chk.Equals, "minio.example.com:9000")
+	c.Assert(p.BucketName, chk.Equals, "bucket")
+	c.Assert(p.ObjectKey, chk.Equals, "keyname")
+	c.Assert(p.Version, chk.Equals, "abc")
+	c.Assert(p.String(), chk.Equals, "https://bucket.minio.example.com:9000/keyname?versionId=abc")
+
+	// AWS is still recognised
+	u, _ = url.Parse("https://bucket.s3.amazonaws.com/keyname")
+	p, err = NewS3URLParts(*u)
+	c.Assert(err, chk.IsNil)
+	c.Assert(p.Endpoint, chk.Equals, "s3.amazonaws.com")
+
+	// other hosts, including another port of the same one, are not
+	u, _ = url.Parse("https://minio.example.com:9001/bucket")
+	c.Assert(IsS3URL(*u), chk.Equals, false)
+}
+
+func (s *s3URLPartsTestSuite) TestS3CompatibleURLParseForcedPathStyle(c *chk.C) {
+	SetS3CompatibleEndpoint(&S3CompatibleEndpoint{Host: "minio.example.com", ForcePathStyle: true})
+	defer SetS3CompatibleEndpoint(nil)
+
+	u, _ := url.Parse("http://minio.example.com/bucket")
+	p, err := NewS3URLParts(*u)
+	c.Assert(err, chk.IsNil)
+	c.Assert(p.BucketName, chk.Equals, "bucket")
+	c.Assert(p.IsBucketSyntactically(), chk.Equals, true)
+
+	// with path-style forced, a subdomain is not a bucket
+	u, _ = url.Parse("http://bucket.minio.example.com/keyname")
+	c.Assert(IsS3URL(*u), chk.Equals, false)
+}
@@ -2,11 +2,21 @@ package common
 
 import (
 	"github.com/Azure/azure-storage-blob-go/azblob"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"time"
 )
 
-const ( // POSIX property metadata
+// POSIX property metadata.
+// With --preserve-posix-properties, uploads record what stat (or statx) says about each file in the blob's metadata, under
+// these keys. All values are decimal strings: IDs and modes as unsigned integers, and times as nanoseconds since the Unix
+// epoch. The key names match those used by blobfuse, so that blobs written by either tool can be read by the other.
+//
+// Since the keys share the metadata namespace with the user's own metadata, they never replace metadata that the user
+// supplied (see tryAddMetadata). On download, values that don't parse are treated as not being POSIX properties at all,
+// so that nothing is restored from them.
+const (
 	POSIXNlinkMeta         = "posix_nlink"
 	POSIXINodeMeta         = "posix_ino"
 	POSIXCTimeMeta         = "posix_ctime"
@@ -29,9 +39,31 @@ const ( // POSIX property metadata
 	LINUXStatxMaskMeta     = "linux_statx_mask"
 )
 
+// POSIXSymlinkTargetMeta holds the target of a symlink uploaded with --preserve-symlinks, on a blob that also has
+// POSIXSymlinkMeta set to "true". Since metadata values must be ASCII, the target is stored path-escaped
+// (see EncodeSymlinkTarget).
+const POSIXSymlinkTargetMeta = "posix_symlink_target"
+
+// EncodeSymlinkTarget escapes a symlink target for storage under POSIXSymlinkTargetMeta.
+// Separators are left alone, so that the stored value stays readable.
+func EncodeSymlinkTarget(target string) string {
+	return (&url.URL{Path: filepath.ToSlash(target)}).EscapedPath()
+}
+
+// DecodeSymlinkTarget reverses EncodeSymlinkTarget, returning the target in the form used by the local OS
+func DecodeSymlinkTarget(value string) (string, error) {
+	target, err := url.PathUnescape(value)
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(target), nil
+}
+
+// AllLinuxProperties lists every metadata key that AddStatToBlobMetadata may write
 var AllLinuxProperties = []string{
 	POSIXNlinkMeta,
 	POSIXINodeMeta,
+	POSIXCTimeMeta,
 	LINUXBTimeMeta,
 	POSIXBlockDeviceMeta,
 	POSIXCharDeviceMeta,
@@ -45,6 +77,10 @@ var AllLinuxProperties = []string{
 	POSIXOwnerMeta,
 	POSIXGroupMeta,
 	POSIXModeMeta,
+	POSIXModTimeMeta,
+	LINUXAttributeMeta,
+	LINUXAttributeMaskMeta,
+	LINUXStatxMaskMeta,
 }
 
 //goland:noinspection GoCommentStart
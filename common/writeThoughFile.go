@@ -41,6 +41,22 @@ const PreserveOwnerDefault = true
 var RootDriveRegex = regexp.MustCompile(`(?i)(^[A-Z]:\/?$)`)
 var RootShareRegex = regexp.MustCompile(`(^\/\/[^\/]*\/?$)`)
 
+// CreateSparseFileOfSizeWithWriteThroughOption is CreateFileOfSizeWithWriteThroughOption, except that the file's space is not
+// allocated up front. Where the OS and file system support it, the file is sparse, so that its parts can be written in any
+// order without the file system first filling the gaps before them with zeros
+func CreateSparseFileOfSizeWithWriteThroughOption(destinationPath string, fileSize int64, writeThrough bool, t FolderCreationTracker, forceIfReadOnly bool) (*os.File, error) {
+	f, err := CreateFileOfSizeWithWriteThroughOption(destinationPath, 0, writeThrough, t, forceIfReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	tryMarkSparse(f)
+	if err = f.Truncate(fileSize); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
 func CreateParentDirectoryIfNotExist(destinationPath string, tracker FolderCreationTracker) error {
 	// find the parent directory
 	directory := destinationPath[:strings.LastIndex(destinationPath, DeterminePathSeparator(destinationPath))]
@@ -61,6 +61,9 @@ func CreateFileOfSizeWithWriteThroughOption(destinationPath string, fileSize int
 	return f, nil
 }
 
+// tryMarkSparse does nothing on this OS, where files are sparse anyway if they are only truncated to size
+func tryMarkSparse(f *os.File) {}
+
 func SetBackupMode(enable bool, fromTo FromTo) error {
 	// n/a on this platform
 	return nil
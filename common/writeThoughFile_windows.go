@@ -157,6 +157,13 @@ func CreateFileOfSizeWithWriteThroughOption(destinationPath string, fileSize int
 	return f, nil
 }
 
+// tryMarkSparse asks the file system to keep the unwritten parts of the file as holes, rather than filling them with
+// zeros when something after them is written. File systems that can't do that (e.g. FAT) just don't, so errors are ignored
+func tryMarkSparse(f *os.File) {
+	var bytesReturned uint32
+	_ = windows.DeviceIoControl(windows.Handle(f.Fd()), windows.FSCTL_SET_SPARSE, nil, 0, nil, 0, &bytesReturned, nil)
+}
+
 func makeInheritSa() *windows.SecurityAttributes {
 	var sa windows.SecurityAttributes
 	sa.Length = uint32(unsafe.Sizeof(sa))
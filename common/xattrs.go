@@ -0,0 +1,102 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// XattrsMeta is the metadata key under which preserve-xattrs saves the extended attributes of a file, as the base64 of
+// a JSON object of their names and values. One key holds them all, because xattr names, unlike metadata keys, may
+// contain dots
+const XattrsMeta = "linux_xattrs"
+
+// MaxBlobMetadataBytes is the most that the keys and values of a blob's metadata may add up to
+const MaxBlobMetadataBytes = 8 * 1024
+
+// userXattrPrefix is the namespace of the extended attributes that preserve-xattrs keeps. The others (security.,
+// system. and trusted.) belong to the OS, and usually can't be set by the user on the other end anyway
+const userXattrPrefix = "user."
+
+// IsUserXattr says whether the named extended attribute is in the user namespace
+func IsUserXattr(name string) bool {
+	return strings.HasPrefix(name, userXattrPrefix)
+}
+
+// AddXattrsToBlobMetadata saves the user-namespace extended attributes among xattrs in metadata. It returns an error,
+// and leaves metadata as it was, if that would make the metadata bigger than a blob can have
+func AddXattrsToBlobMetadata(xattrs map[string][]byte, metadata azblob.Metadata) error {
+	userXattrs := make(map[string][]byte)
+	for name, value := range xattrs {
+		if IsUserXattr(name) {
+			userXattrs[name] = value
+		}
+	}
+	if len(userXattrs) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(userXattrs)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	size := len(XattrsMeta) + len(encoded)
+	for k, v := range metadata {
+		if k != XattrsMeta {
+			size += len(k) + len(v)
+		}
+	}
+	if size > MaxBlobMetadataBytes {
+		return fmt.Errorf("the %d extended attributes would make the metadata %d bytes, more than the %d that a blob can have", len(userXattrs), size, MaxBlobMetadataBytes)
+	}
+
+	metadata[XattrsMeta] = encoded
+	return nil
+}
+
+// ReadXattrsFromMetadata returns the user-namespace extended attributes that AddXattrsToBlobMetadata saved in
+// metadata. It returns nil if there are none
+func ReadXattrsFromMetadata(metadata Metadata) (map[string][]byte, error) {
+	encoded, ok := metadata[XattrsMeta]
+	if !ok {
+		return nil, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("the %s metadata is not valid base64: %w", XattrsMeta, err)
+	}
+	var xattrs map[string][]byte
+	if err = json.Unmarshal(raw, &xattrs); err != nil {
+		return nil, fmt.Errorf("the %s metadata is not a valid set of extended attributes: %w", XattrsMeta, err)
+	}
+	for name := range xattrs {
+		if !IsUserXattr(name) {
+			delete(xattrs, name) // only ever restore into the user namespace, whoever wrote the metadata
+		}
+	}
+	return xattrs, nil
+}
@@ -0,0 +1,163 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type archiveSourceSuite struct{}
+
+var _ = chk.Suite(&archiveSourceSuite{})
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// readAllInChunks reads an entry the way an upload does: in order, a chunk at a time, through ReadAt
+func readAllInChunks(c *chk.C, r io.ReaderAt, size int64, chunkSize int64) []byte {
+	var out []byte
+	for off := int64(0); off < size; off += chunkSize {
+		n := chunkSize
+		if off+n > size {
+			n = size - off
+		}
+		buf := make([]byte, n)
+		read, err := r.ReadAt(buf, off)
+		if err == io.EOF && int64(read) == n {
+			err = nil
+		}
+		c.Assert(err, chk.IsNil)
+		out = append(out, buf...)
+	}
+	return out
+}
+
+func (s *archiveSourceSuite) TestTarEntriesAreReadInPlace(c *chk.C) {
+	large := randomBytes(300 * 1024)
+	archivePath := filepath.Join(c.MkDir(), "source.tar")
+	f, err := os.Create(archivePath)
+	c.Assert(err, chk.IsNil)
+	tw := tar.NewWriter(f)
+	write := func(hdr *tar.Header, content []byte) {
+		hdr.Size = int64(len(content))
+		c.Assert(tw.WriteHeader(hdr), chk.IsNil)
+		_, err := tw.Write(content)
+		c.Assert(err, chk.IsNil)
+	}
+	write(&tar.Header{Typeflag: tar.TypeDir, Name: "./", Mode: 0755}, nil) // the root, which isn't an entry of its own
+	write(&tar.Header{Typeflag: tar.TypeDir, Name: "./dir/", Mode: 0755}, nil)
+	write(&tar.Header{Typeflag: tar.TypeReg, Name: "./dir/large.bin", Mode: 0644}, large)
+	write(&tar.Header{Typeflag: tar.TypeReg, Name: "top.txt", Mode: 0644}, []byte("first"))
+	write(&tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "top.txt", Mode: 0777}, nil)
+	write(&tar.Header{Typeflag: tar.TypeReg, Name: "top.txt", Mode: 0644}, []byte("second, which wins"))
+	c.Assert(tw.Close(), chk.IsNil)
+	c.Assert(f.Close(), chk.IsNil)
+
+	a, err := OpenArchiveSource(archivePath, EArchiveFormat.Tar())
+	c.Assert(err, chk.IsNil)
+	defer a.Close()
+
+	var names []string
+	for _, e := range a.Entries() {
+		names = append(names, e.Name)
+	}
+	c.Assert(names, chk.DeepEquals, []string{"dir", "dir/large.bin", "top.txt"})
+	c.Assert(a.SkippedEntries, chk.Equals, 1)
+
+	dir, ok := a.Entry("dir")
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(dir.IsFolder, chk.Equals, true)
+
+	e, ok := a.Entry("/dir/large.bin") // as the relative path of a transfer has it
+	c.Assert(ok, chk.Equals, true)
+	r, err := a.Open(e)
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(readAllInChunks(c, r, e.Size, 64*1024), large), chk.Equals, true)
+	c.Assert(r.Close(), chk.IsNil)
+
+	e, _ = a.Entry("top.txt")
+	r, err = a.Open(e)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(readAllInChunks(c, r, e.Size, 4)), chk.Equals, "second, which wins")
+	c.Assert(r.Close(), chk.IsNil)
+}
+
+func (s *archiveSourceSuite) TestZipEntriesAreReadStoredOrCompressed(c *chk.C) {
+	stored := randomBytes(100 * 1024)
+	compressed := bytes.Repeat([]byte("compressible "), 20*1024)
+	archivePath := filepath.Join(c.MkDir(), "source.zip")
+	f, err := os.Create(archivePath)
+	c.Assert(err, chk.IsNil)
+	zw := zip.NewWriter(f)
+	_, err = zw.CreateHeader(&zip.FileHeader{Name: "dir/", Method: zip.Store})
+	c.Assert(err, chk.IsNil)
+	for name, entry := range map[string]struct {
+		method  uint16
+		content []byte
+	}{
+		"dir/stored.bin":     {zip.Store, stored},
+		"dir/compressed.txt": {zip.Deflate, compressed},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: entry.method})
+		c.Assert(err, chk.IsNil)
+		_, err = w.Write(entry.content)
+		c.Assert(err, chk.IsNil)
+	}
+	c.Assert(zw.Close(), chk.IsNil)
+	c.Assert(f.Close(), chk.IsNil)
+
+	a, err := OpenArchiveSource(archivePath, EArchiveFormat.Zip())
+	c.Assert(err, chk.IsNil)
+	defer a.Close()
+	c.Assert(a.Entries(), chk.HasLen, 3)
+
+	e, ok := a.Entry("dir/stored.bin")
+	c.Assert(ok, chk.Equals, true)
+	r, err := a.Open(e)
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(readAllInChunks(c, r, e.Size, 30*1024), stored), chk.Equals, true)
+	c.Assert(r.Close(), chk.IsNil)
+
+	e, ok = a.Entry("dir/compressed.txt")
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(e.Size, chk.Equals, int64(len(compressed)))
+	r, err = a.Open(e)
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(readAllInChunks(c, r, e.Size, 30*1024), compressed), chk.Equals, true)
+
+	// a retry reads an earlier chunk again, which restarts the decompression
+	again := make([]byte, 1000)
+	_, err = r.ReadAt(again, 5000)
+	c.Assert(err, chk.IsNil)
+	c.Assert(again, chk.DeepEquals, compressed[5000:6000])
+	c.Assert(r.Close(), chk.IsNil)
+}
@@ -0,0 +1,56 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type azureCloudSuite struct{}
+
+var _ = chk.Suite(&azureCloudSuite{})
+
+func (s *azureCloudSuite) TestCloudOfHost(c *chk.C) {
+	for host, expected := range map[string]AzureCloud{
+		"account.blob.core.windows.net":       EAzureCloud.Public(),
+		"account.dfs.core.windows.net:443":    EAzureCloud.Public(),
+		"ACCOUNT.FILE.CORE.USGOVCLOUDAPI.NET": EAzureCloud.USGov(),
+		"account.blob.core.chinacloudapi.cn":  EAzureCloud.China(),
+		"storage.contoso.com":                 EAzureCloud.Unknown(),
+		"core.windows.net.contoso.com":        EAzureCloud.Unknown(),
+		"account.blob.notcore.windows.net":    EAzureCloud.Unknown(),
+		"127.0.0.1:10000":                     EAzureCloud.Unknown(),
+	} {
+		c.Assert(AzureCloudOfHost(host), chk.Equals, expected, chk.Commentf(host))
+	}
+}
+
+func (s *azureCloudSuite) TestCloudOfAuthority(c *chk.C) {
+	for _, cloud := range []AzureCloud{EAzureCloud.Public(), EAzureCloud.USGov(), EAzureCloud.China()} {
+		c.Assert(AzureCloudOfAuthority(cloud.ActiveDirectoryEndpoint()), chk.Equals, cloud)
+		c.Assert(AzureCloudOfAuthority(cloud.ActiveDirectoryEndpoint()+"/"), chk.Equals, cloud)
+	}
+	c.Assert(AzureCloudOfAuthority("https://adfs.contoso.com/adfs"), chk.Equals, EAzureCloud.Unknown())
+	c.Assert(AzureCloudOfAuthority(""), chk.Equals, EAzureCloud.Unknown())
+
+	var cloud AzureCloud
+	c.Assert(cloud.Parse("usgov"), chk.IsNil)
+	c.Assert(cloud, chk.Equals, EAzureCloud.USGov())
+}
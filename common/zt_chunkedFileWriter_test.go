@@ -0,0 +1,116 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type chunkedFileWriterSuite struct{}
+
+var _ = chk.Suite(&chunkedFileWriterSuite{})
+
+// chunkedFileWriterTestData makes the content of a file of numChunks chunks, the last of which is shorter than the others
+func chunkedFileWriterTestData(chunkSize int64, numChunks int) []byte {
+	data := make([]byte, chunkSize*int64(numChunks)-123)
+	rand.Read(data)
+	return data
+}
+
+// enqueueChunkForTest schedules and enqueues the chunk at the given index, and reports whether it's in the file afterwards
+func enqueueChunkForTest(c *chk.C, ctx context.Context, w ChunkedFileWriter, f *os.File, data []byte, chunkSize int64, index int) (isInFile bool) {
+	offset := int64(index) * chunkSize
+	length := chunkSize
+	if offset+length > int64(len(data)) {
+		length = int64(len(data)) - offset
+	}
+	id := NewChunkID(f.Name(), offset, length)
+	c.Assert(w.WaitToScheduleChunk(ctx, id, length), chk.IsNil)
+	c.Assert(w.EnqueueChunk(ctx, id, length, bytes.NewReader(data[offset:offset+length]), false), chk.IsNil)
+
+	inFile := make([]byte, length)
+	_, err := f.ReadAt(inFile, offset)
+	c.Assert(err, chk.IsNil)
+	return bytes.Equal(inFile, data[offset:offset+length])
+}
+
+func (s *chunkedFileWriterSuite) TestChunksAreWrittenInPlaceAsTheyArrive(c *chk.C) {
+	const chunkSize = 4 * 1024
+	const numChunks = 10
+	data := chunkedFileWriterTestData(chunkSize, numChunks)
+
+	f, err := CreateSparseFileOfSizeWithWriteThroughOption(filepath.Join(c.MkDir(), "file"), int64(len(data)), false, nil, false)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+	info, err := f.Stat()
+	c.Assert(err, chk.IsNil)
+	c.Assert(info.Size(), chk.Equals, int64(len(data)))
+
+	// there's only room in RAM for one chunk, so the chunks can only arrive last first if each is written as soon as it arrives
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	logger := NewChunkStatusLogger(NewJobID(), NewNullCpuMonitor(), "", false)
+	w := NewChunkedFileWriter(ctx, NewMultiSizeSlicePool(chunkSize), NewCacheLimiter(chunkSize), logger, f,
+		numChunks, 0, EHashValidationOption.NoCheck(), false, true)
+	for i := numChunks - 1; i >= 0; i-- {
+		c.Assert(enqueueChunkForTest(c, ctx, w, f, data, chunkSize, i), chk.Equals, true, chk.Commentf("chunk %d", i))
+	}
+	_, err = w.Flush(ctx)
+	c.Assert(err, chk.IsNil)
+
+	written, err := os.ReadFile(f.Name())
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(written, data), chk.Equals, true)
+}
+
+func (s *chunkedFileWriterSuite) TestChunksAreWrittenInOrderWhenTheirHashIsChecked(c *chk.C) {
+	const chunkSize = 4 * 1024
+	const numChunks = 4
+	data := chunkedFileWriterTestData(chunkSize, numChunks)
+
+	f, err := CreateSparseFileOfSizeWithWriteThroughOption(filepath.Join(c.MkDir(), "file"), int64(len(data)), false, nil, false)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+
+	// the MD5 hash can only be computed in order, so the chunks wait for the ones before them, even though in place was asked for
+	ctx := context.Background()
+	logger := NewChunkStatusLogger(NewJobID(), NewNullCpuMonitor(), "", false)
+	w := NewChunkedFileWriter(ctx, NewMultiSizeSlicePool(chunkSize), NewCacheLimiter(numChunks*chunkSize), logger, f,
+		numChunks, 0, EHashValidationOption.FailIfDifferent(), true, true)
+	for i := numChunks - 1; i >= 1; i-- {
+		c.Assert(enqueueChunkForTest(c, ctx, w, f, data, chunkSize, i), chk.Equals, false, chk.Commentf("chunk %d", i))
+	}
+	enqueueChunkForTest(c, ctx, w, f, data, chunkSize, 0)
+	md5OfWritten, err := w.Flush(ctx)
+	c.Assert(err, chk.IsNil)
+
+	written, err := os.ReadFile(f.Name())
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(written, data), chk.Equals, true)
+	c.Assert(md5OfWritten, chk.HasLen, 16)
+}
@@ -0,0 +1,101 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type componentLoggerSuite struct{}
+
+var _ = chk.Suite(&componentLoggerSuite{})
+
+func (s *componentLoggerSuite) TestOnlyEnabledComponentLogsDebug(c *chk.C) {
+	dir := c.MkDir()
+	levels := NewComponentLogLevels(ELogLevel.Info())
+	c.Assert(levels.Parse("enumeration=DEBUG; network=warning"), chk.IsNil)
+
+	jobID := NewJobID()
+	logger := NewComponentJobLogger(jobID, levels, dir, "")
+	logger.OpenLog()
+	for _, component := range []LogComponent{ELogComponent.Enumeration(), ELogComponent.Scheduler(), ELogComponent.Network(), ELogComponent.Validation()} {
+		componentLogger := LoggerForComponent(logger, component)
+		componentLogger.Log(pipeline.LogDebug, "debug from "+component.String())
+		componentLogger.Log(pipeline.LogInfo, "info from "+component.String())
+	}
+	logger.Log(pipeline.LogDebug, "debug from the job")
+	logger.Log(pipeline.LogInfo, "info from the job")
+	logger.CloseLog()
+
+	raw, err := os.ReadFile(filepath.Join(dir, jobID.String()+".log"))
+	c.Assert(err, chk.IsNil)
+	log := string(raw)
+
+	c.Check(strings.Contains(log, "debug from Enumeration"), chk.Equals, true)
+	c.Check(strings.Contains(log, "debug from Scheduler"), chk.Equals, false)
+	c.Check(strings.Contains(log, "debug from Network"), chk.Equals, false)
+	c.Check(strings.Contains(log, "debug from Validation"), chk.Equals, false)
+	c.Check(strings.Contains(log, "debug from the job"), chk.Equals, false)
+
+	// the others log at the default level, except network, which was made quieter
+	c.Check(strings.Contains(log, "info from Enumeration"), chk.Equals, true)
+	c.Check(strings.Contains(log, "info from Scheduler"), chk.Equals, true)
+	c.Check(strings.Contains(log, "info from Network"), chk.Equals, false)
+	c.Check(strings.Contains(log, "info from Validation"), chk.Equals, true)
+	c.Check(strings.Contains(log, "info from the job"), chk.Equals, true)
+}
+
+func (s *componentLoggerSuite) TestLogFileIsKeptForAComponentWhenDefaultIsNone(c *chk.C) {
+	dir := c.MkDir()
+	levels := NewComponentLogLevels(ELogLevel.None())
+	c.Assert(levels.Parse("validation=warning"), chk.IsNil)
+	c.Assert(levels.Max(), chk.Equals, ELogLevel.Warning())
+
+	jobID := NewJobID()
+	logger := NewComponentJobLogger(jobID, levels, dir, "")
+	logger.OpenLog()
+	LoggerForComponent(logger, ELogComponent.Validation()).Log(pipeline.LogWarning, "MD5 missing")
+	logger.Log(pipeline.LogError, "not for the log")
+	logger.CloseLog()
+
+	raw, err := os.ReadFile(filepath.Join(dir, jobID.String()+".log"))
+	c.Assert(err, chk.IsNil)
+	c.Check(strings.Contains(string(raw), "MD5 missing"), chk.Equals, true)
+	c.Check(strings.Contains(string(raw), "not for the log"), chk.Equals, false)
+}
+
+func (s *componentLoggerSuite) TestComponentLogLevelsParse(c *chk.C) {
+	levels := NewComponentLogLevels(ELogLevel.Info())
+	c.Assert(levels.Parse(""), chk.IsNil)
+	c.Assert(levels.For(ELogComponent.Network()), chk.Equals, ELogLevel.Info())
+
+	c.Assert(levels.Parse("Scheduler=None"), chk.IsNil)
+	c.Assert(levels.For(ELogComponent.Scheduler()), chk.Equals, ELogLevel.None())
+	c.Assert(levels.Max(), chk.Equals, ELogLevel.Info())
+
+	c.Assert(levels.Parse("network"), chk.NotNil)
+	c.Assert(levels.Parse("disk=debug"), chk.ErrorMatches, ".*not a component.*")
+	c.Assert(levels.Parse("network=loud"), chk.NotNil)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"math"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type etaEstimatorSuite struct{}
+
+var _ = chk.Suite(&etaEstimatorSuite{})
+
+func (s *etaEstimatorSuite) TestETAConvergesAfterRampUp(c *chk.C) {
+	const total = 3000 * 1024 * 1024
+	const steadyRate = 10 * 1024 * 1024 // bytes per second, once ramped up
+	const interval = 2 * time.Second
+
+	e := NewETAEstimator(30*time.Second, 10*time.Second)
+	start := time.Now()
+	var done uint64
+
+	// scanning, with nothing transferred yet
+	for i := 0; i < 3; i++ {
+		e.AddSample(start.Add(time.Duration(i)*interval), done)
+		_, ok := e.ETA(total - done)
+		c.Assert(ok, chk.Equals, false)
+	}
+
+	// the first seconds of transferring are slow, and give no estimate, rather than one that's far too long
+	moving := start.Add(2 * interval)
+	rampUp := []uint64{steadyRate / 20, steadyRate / 5, steadyRate / 2, steadyRate}
+	for i, rate := range rampUp {
+		done += rate * uint64(interval/time.Second)
+		at := moving.Add(time.Duration(i+1) * interval)
+		e.AddSample(at, done)
+		_, ok := e.ETA(total - done)
+		c.Assert(ok, chk.Equals, at.Sub(moving) >= 10*time.Second, chk.Commentf("at %v", at.Sub(moving)))
+	}
+
+	// at a steady rate, the estimate gets ever closer to the truth, and is spot on once the window has only steady samples
+	previousError := math.Inf(1)
+	for i := len(rampUp); i < len(rampUp)+20; i++ {
+		done += steadyRate * uint64(interval/time.Second)
+		e.AddSample(moving.Add(time.Duration(i+1)*interval), done)
+
+		eta, ok := e.ETA(total - done)
+		c.Assert(ok, chk.Equals, true)
+		actual := float64(total-done) / steadyRate
+		relativeError := math.Abs(eta.Seconds()-actual) / actual
+		c.Assert(relativeError <= previousError, chk.Equals, true, chk.Commentf("error went up to %v at sample %d", relativeError, i))
+		previousError = relativeError
+	}
+	c.Assert(previousError < 0.001, chk.Equals, true, chk.Commentf("error is still %v", previousError))
+	c.Assert(e.Throughput(), chk.Equals, float64(steadyRate))
+}
+
+func (s *etaEstimatorSuite) TestBytesGoingDownCountAsNoProgress(c *chk.C) {
+	e := NewETAEstimator(time.Minute, 0)
+	start := time.Now()
+	e.AddSample(start, 0)
+	e.AddSample(start.Add(time.Second), 1000)
+	e.AddSample(start.Add(2*time.Second), 400) // a file failed, and its bytes no longer count
+	e.AddSample(start.Add(3*time.Second), 1400)
+
+	series := e.ThroughputSeries()
+	c.Assert(series, chk.HasLen, 3)
+	c.Assert(series[0].BytesPerSecond, chk.Equals, float64(1000))
+	c.Assert(series[1].BytesPerSecond, chk.Equals, float64(0))
+	c.Assert(series[2].BytesPerSecond, chk.Equals, float64(1000))
+	c.Assert(series[2].At, chk.Equals, start.Add(3*time.Second))
+
+	// 2000 bytes of progress over the 3 seconds
+	eta, ok := e.ETA(2000)
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(eta, chk.Equals, 3*time.Second)
+}
+
+func (s *etaEstimatorSuite) TestNoETAWhenNothingIsMoving(c *chk.C) {
+	e := NewETAEstimator(4*time.Second, 0)
+	start := time.Now()
+	e.AddSample(start, 0)
+	e.AddSample(start.Add(time.Second), 500)
+	for i := 2; i < 10; i++ {
+		e.AddSample(start.Add(time.Duration(i)*time.Second), 500)
+	}
+
+	c.Assert(e.Throughput(), chk.Equals, float64(0))
+	_, ok := e.ETA(1000)
+	c.Assert(ok, chk.Equals, false)
+}
@@ -0,0 +1,50 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type exitCodeSuite struct{}
+
+var _ = chk.Suite(&exitCodeSuite{})
+
+func (s *exitCodeSuite) TestExitCodeForTransfers(c *chk.C) {
+	cases := []struct {
+		name              string
+		completed, failed uint32
+		expected          ExitCode
+	}{
+		{"fully successful", 10, 0, EExitCode.Success()},
+		{"nothing to transfer", 0, 0, EExitCode.Success()}, // e.g. every file was skipped
+		{"partially failed", 9, 1, EExitCode.PartialFailure()},
+		{"fully failed", 0, 10, EExitCode.Error()},
+	}
+
+	for _, tc := range cases {
+		c.Assert(ExitCodeForTransfers(tc.completed, tc.failed), chk.Equals, tc.expected, chk.Commentf(tc.name))
+	}
+
+	// a partial failure must not be mistaken for a panic, which the Go runtime reports as 2
+	c.Assert(EExitCode.PartialFailure(), chk.Not(chk.Equals), ExitCode(2))
+	c.Assert(EExitCode.PartialFailure(), chk.Not(chk.Equals), EExitCode.Error())
+}
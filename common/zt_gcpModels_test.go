@@ -0,0 +1,47 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"net/http"
+
+	"github.com/minio/minio-go"
+	chk "gopkg.in/check.v1"
+)
+
+type gcpModelsTestSuite struct{}
+
+var _ = chk.Suite(&gcpModelsTestSuite{})
+
+func (s *gcpModelsTestSuite) TestHMACObjectPropertiesComeFromGCPHeaders(c *chk.C) {
+	// this is how the XML API returns an object's hashes and user-defined metadata, when it's read with an HMAC key
+	header := http.Header{}
+	header.Add("X-Goog-Hash", "crc32c=n03x6A==, md5=Ojk9c3dhfxgoKVVHYwFbHQ==")
+	header.Add("X-Goog-Meta-Owner", "team-a")
+	header.Add("Content-Type", "text/plain")
+
+	gie := GCPHMACObjectInfoExtension{ObjectInfoExtension{ObjectInfo: minio.ObjectInfo{Metadata: header}}}
+	c.Assert(gie.ContentMD5(), chk.DeepEquals, []byte{0x3a, 0x39, 0x3d, 0x73, 0x77, 0x61, 0x7f, 0x18, 0x28, 0x29, 0x55, 0x47, 0x63, 0x01, 0x5b, 0x1d})
+	c.Assert(gie.NewCommonMetadata(), chk.DeepEquals, Metadata{"Owner": "team-a"})
+
+	// an object without an MD5, such as a composite one, only lists its crc32c
+	header.Set("X-Goog-Hash", "crc32c=n03x6A==")
+	c.Assert(gie.ContentMD5(), chk.IsNil)
+}
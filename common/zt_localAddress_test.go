@@ -0,0 +1,48 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"net"
+
+	chk "gopkg.in/check.v1"
+)
+
+type localAddressSuite struct{}
+
+var _ = chk.Suite(&localAddressSuite{})
+
+func (s *localAddressSuite) TestParseLocalAddress(c *chk.C) {
+	addr, err := ParseLocalAddress("127.0.0.1")
+	c.Assert(err, chk.IsNil)
+	c.Assert(addr.(*net.TCPAddr).IP.String(), chk.Equals, "127.0.0.1")
+	c.Assert(addr.(*net.TCPAddr).Port, chk.Equals, 0)
+
+	_, err = ParseLocalAddress("not-an-ip")
+	c.Assert(err, chk.ErrorMatches, ".*not an IP address.*")
+
+	_, err = ParseLocalAddress("0.0.0.0")
+	c.Assert(err, chk.ErrorMatches, ".*unicast address.*")
+
+	// in a range reserved for documentation, so never assigned to a machine
+	_, err = ParseLocalAddress("192.0.2.1")
+	c.Assert(err, chk.ErrorMatches, ".*not available on this machine.*")
+}
@@ -59,6 +59,8 @@ func (s *logSanitizerSuite) TestLogSanitizer(c *chk.C) {
 		{"Foo=x;Signature=bar", "Foo=x;Signature=-REDACTED-"},                                                     // not in a query string
 		{"Foo : x, Signature : bar, Other: z", "Foo : x, Signature : -REDACTED-, Other: z"},                       // not in a query string, with commas and spaces
 
+		{"   X-Ms-Encryption-Key: [a2V5+/a2V5=]\n   X-Ms-Encryption-Key-Sha256: [aGFzaA==]", "   X-Ms-Encryption-Key: -REDACTED-\n   X-Ms-Encryption-Key-Sha256: [aGFzaA==]"}, // customer provided key in a logged request, but not its hash
+
 		// two replacements in same string
 		{"http://foo?sig=somevalue and http://bar?sig=othervalue BlahBlah", "http://foo?sig=-REDACTED- and http://bar?sig=-REDACTED- BlahBlah"},
 
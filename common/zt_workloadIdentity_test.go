@@ -0,0 +1,157 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type workloadIdentitySuite struct{}
+
+var _ = chk.Suite(&workloadIdentitySuite{})
+
+// fakeTokenEndpoint stands in for Azure AD, giving out a new access token, that expires after lifetime, each time it
+// is given the federated token that it expects
+type fakeTokenEndpoint struct {
+	mu                sync.Mutex
+	expectedAssertion string
+	lifetime          time.Duration
+	issued            int
+	expiries          map[string]time.Time
+}
+
+func (f *fakeTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil ||
+		r.PostForm.Get("client_assertion") != f.expectedAssertion ||
+		r.PostForm.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" ||
+		r.PostForm.Get("client_id") != "the-app" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.issued++
+	accessToken := fmt.Sprintf("access-%d", f.issued)
+	expiry := time.Now().Add(f.lifetime)
+	f.expiries[accessToken] = expiry
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":"%d","expires_on":"%d","resource":%q}`,
+		accessToken, int(f.lifetime.Seconds()), expiry.Unix(), r.PostForm.Get("resource"))
+}
+
+func (f *fakeTokenEndpoint) expiryOf(accessToken string) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.expiries[accessToken]
+}
+
+// authorizationRecorder is the end of a pipeline, which records the Authorization header of each request instead of
+// sending it
+type authorizationRecorder struct {
+	mu   sync.Mutex
+	last string
+}
+
+func (a *authorizationRecorder) New(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.Policy {
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		a.mu.Lock()
+		a.last = request.Header.Get("Authorization")
+		a.mu.Unlock()
+		return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}}), nil
+	})
+}
+
+func (s *workloadIdentitySuite) TestFederatedTokenIsExchangedAndRefreshedBeforeExpiry(c *chk.C) {
+	tokenFile := filepath.Join(c.MkDir(), "federated-token")
+	c.Assert(ioutil.WriteFile(tokenFile, []byte("federated-1\n"), 0600), chk.IsNil)
+
+	endpoint := &fakeTokenEndpoint{expectedAssertion: "federated-1", lifetime: 4 * time.Second, expiries: map[string]time.Time{}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	tokenInfo, err := workloadIdentityLoginNoUOTM(context.Background(), "the-tenant", server.URL, tokenFile, "the-app", Resource)
+	c.Assert(err, chk.IsNil)
+	c.Assert(tokenInfo.AccessToken, chk.Equals, "access-1")
+	c.Assert(tokenInfo.WorkloadIdentity, chk.Equals, true)
+
+	// the federated token is rotated, as AKS does, and refreshes must use the new one
+	c.Assert(ioutil.WriteFile(tokenFile, []byte("federated-2"), 0600), chk.IsNil)
+	endpoint.mu.Lock()
+	endpoint.expectedAssertion = "federated-2"
+	endpoint.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	credential := CreateBlobCredential(ctx, CredentialInfo{CredentialType: ECredentialType.OAuthToken(), OAuthTokenInfo: *tokenInfo}, CredentialOpOptions{
+		LogError: func(msg string) { c.Log(msg) },
+		Cancel:   cancel,
+	})
+
+	recorder := &authorizationRecorder{}
+	p := pipeline.NewPipeline([]pipeline.Factory{credential, recorder}, pipeline.Options{})
+	authorizationNow := func() string {
+		u, _ := http.NewRequest(http.MethodGet, "https://account.blob.core.windows.net/container/blob", nil)
+		_, err := p.Do(ctx, nil, pipeline.Request{Request: u})
+		c.Assert(err, chk.IsNil)
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return recorder.last
+	}
+
+	// the credential refreshes as soon as it's made, and then again well before each token expires
+	first := authorizationNow()
+	c.Assert(first, chk.Equals, "Bearer access-2")
+	firstExpiry := endpoint.expiryOf("access-2")
+	for authorizationNow() == first {
+		c.Assert(time.Now().Before(firstExpiry), chk.Equals, true, chk.Commentf("the token was not refreshed before it expired"))
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(authorizationNow(), chk.Equals, "Bearer access-3")
+	c.Assert(time.Now().Before(firstExpiry), chk.Equals, true)
+}
+
+func (s *workloadIdentitySuite) TestWorkloadIdentityNeedsTenantApplicationAndTokenFile(c *chk.C) {
+	for _, missing := range []string{"tenant", "app", "file"} {
+		tenant, app, file := "the-tenant", "the-app", filepath.Join(os.TempDir(), "token")
+		switch missing {
+		case "tenant":
+			tenant = ""
+		case "app":
+			app = ""
+		case "file":
+			file = ""
+		}
+		_, err := workloadIdentityLoginNoUOTM(context.Background(), tenant, DefaultActiveDirectoryEndpoint, file, app, Resource)
+		c.Assert(err, chk.NotNil, chk.Commentf("without the %s", missing))
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type xattrsSuite struct{}
+
+var _ = chk.Suite(&xattrsSuite{})
+
+func (s *xattrsSuite) TestUserXattrsRoundTripThroughMetadata(c *chk.C) {
+	metadata := azblob.Metadata{"author": "someone"}
+	err := AddXattrsToBlobMetadata(map[string][]byte{
+		"user.origin":      []byte("scanner-3"),
+		"user.binary":      {0, 1, 2, 0xff},
+		"security.selinux": []byte("system_u:object_r:user_home_t:s0"),
+		"trusted.overlay":  []byte("y"),
+	}, metadata)
+	c.Assert(err, chk.IsNil)
+	c.Assert(metadata["author"], chk.Equals, "someone")
+
+	xattrs, err := ReadXattrsFromMetadata(Metadata(metadata))
+	c.Assert(err, chk.IsNil)
+	c.Assert(xattrs, chk.DeepEquals, map[string][]byte{
+		"user.origin": []byte("scanner-3"),
+		"user.binary": {0, 1, 2, 0xff},
+	})
+}
+
+func (s *xattrsSuite) TestNoUserXattrsAddsNothing(c *chk.C) {
+	metadata := azblob.Metadata{}
+	c.Assert(AddXattrsToBlobMetadata(map[string][]byte{"security.capability": {1}}, metadata), chk.IsNil)
+	c.Assert(metadata, chk.HasLen, 0)
+
+	xattrs, err := ReadXattrsFromMetadata(Metadata(metadata))
+	c.Assert(err, chk.IsNil)
+	c.Assert(xattrs, chk.IsNil)
+}
+
+func (s *xattrsSuite) TestOversizedXattrsLeaveMetadataAlone(c *chk.C) {
+	metadata := azblob.Metadata{"author": "someone"}
+	err := AddXattrsToBlobMetadata(map[string][]byte{"user.big": []byte(strings.Repeat("x", MaxBlobMetadataBytes))}, metadata)
+	c.Assert(err, chk.NotNil)
+	c.Assert(metadata, chk.DeepEquals, azblob.Metadata{"author": "someone"})
+}
+
+func (s *xattrsSuite) TestOnlyUserXattrsAreRestored(c *chk.C) {
+	// metadata written by something other than AzCopy mustn't get attributes into the OS's namespaces
+	forged := Metadata{XattrsMeta: "eyJ1c2VyLmEiOiJNUT09IiwidHJ1c3RlZC5iIjoiTWc9PSJ9"} // {"user.a":"MQ==","trusted.b":"Mg=="}
+
+	xattrs, err := ReadXattrsFromMetadata(forged)
+	c.Assert(err, chk.IsNil)
+	c.Assert(xattrs, chk.DeepEquals, map[string][]byte{"user.a": []byte("1")})
+
+	_, err = ReadXattrsFromMetadata(Metadata{XattrsMeta: "not base64!"})
+	c.Assert(err, chk.NotNil)
+}
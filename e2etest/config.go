@@ -99,6 +99,53 @@ func (GlobalInputManager) TestSummaryLogPath() string {
 	return os.Getenv("AZCOPY_E2E_TEST_SUMMARY_LOG")
 }
 
+// GetGCPProjectID returns the project in which GCP buckets are created for the tests.
+// The buckets are accessed with the service account key file named by GOOGLE_APPLICATION_CREDENTIALS, which AzCopy uses too.
+// Scenarios with a GCP source are skipped unless both are set.
+func (GlobalInputManager) GetGCPProjectID() string {
+	if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" {
+		return ""
+	}
+	return os.Getenv("GOOGLE_CLOUD_PROJECT")
+}
+
+// GetS3CompatibleEndpoint returns the URL of an S3-compatible service, such as MinIO, in which the buckets of S3 sources are created.
+// The buckets are accessed with AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, which AzCopy uses too.
+// Scenarios with an S3 source are skipped unless all three are set. Azure reads the objects from the service, so it must be reachable from the internet.
+func (GlobalInputManager) GetS3CompatibleEndpoint() string {
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "" {
+		return ""
+	}
+	return os.Getenv("AZCOPY_E2E_S3_ENDPOINT")
+}
+
+// S3SkipSSLVerify tells whether the certificate of the S3-compatible service is left unverified, as for a self-signed one
+func (GlobalInputManager) S3SkipSSLVerify() bool {
+	raw := os.Getenv("AZCOPY_E2E_S3_SKIP_SSL_VERIFY")
+	if raw == "" {
+		return false
+	}
+
+	result, err := strconv.ParseBool(raw)
+	if err != nil {
+		panic("If AZCOPY_E2E_S3_SKIP_SSL_VERIFY is set, it must be a boolean")
+	}
+
+	return result
+}
+
+// GetEncryptionScope returns an encryption scope of the standard account, under which the tests may write blobs.
+// Tests that need one are skipped unless AZCOPY_E2E_ENCRYPTION_SCOPE is set.
+func (GlobalInputManager) GetEncryptionScope() string {
+	return os.Getenv("AZCOPY_E2E_ENCRYPTION_SCOPE")
+}
+
+// GetImmutableContainerURL returns the SAS URL of a container with version-level immutability support, to which the
+// tests may upload blobs with immutability policies. Tests that need one are skipped unless AZCOPY_E2E_IMMUTABLE_CONTAINER_URL is set.
+func (GlobalInputManager) GetImmutableContainerURL() string {
+	return os.Getenv("AZCOPY_E2E_IMMUTABLE_CONTAINER_URL")
+}
+
 var EAccountType = AccountType(0)
 
 type AccountType uint8
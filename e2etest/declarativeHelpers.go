@@ -25,6 +25,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/JeffreyRichter/enum/enum"
@@ -143,34 +144,113 @@ type params struct {
 	recursive                 bool
 	invertedAsSubdir          bool // this flag is INVERTED, because it is TRUE by default. todo: use pointers instead?
 	includePath               string
+	listOfFiles               string // the path of a manifest file, usually written by a hook, that lists the relative paths to transfer
+	listOfUrls                string // the path of a file that lists the URLs of the blobs to transfer, in place of the source
+	keepFromUrl               string
 	includePattern            string
 	includeAfter              string
-	includeAttributes         string
+	includeBefore             string
+	minSize                   string
+	maxSize                   string
+	minDepth                  uint // of the files and folders to copy, beneath the source. 0 leaves it open
+	maxDepth                  uint
+	includeAttributes         string // Windows file attributes, as letters. E.g. "HS"
+	includeRegex              string
+	includeExt                string // bare extensions, e.g. "jpg;.png"
+	excludeExt                string
 	excludePath               string
 	excludePattern            string
+	excludeRegex              string
+	ignoreCasePattern         bool
 	excludeAttributes         string
 	capMbps                   float32
+	autoTuneThroughput        bool
 	blockSizeMB               float32
 	deleteDestination         common.DeleteDestination
+	syncComparator            common.SyncComparator // how sync decides whether a file at both ends is transferred
+	excludeIfDestinationNewer bool                  // sync leaves alone the files that are more recent at the destination
+	overwrite                 common.OverwriteOption
 	s2sSourceChangeValidation bool
 	metadata                  string
 	cancelFromStdin           bool
 	backupMode                bool
 	preserveSMBPermissions    bool
+	preservePermissions       bool // between ADLS Gen 2 accounts, copies the ACLs of files and folders
 	preserveSMBInfo           bool
+	preserveLMT               bool // when downloading, sets the time of each file to that of its source
 	preservePOSIXProperties   bool
+	preserveXattrs            bool                       // keeps the user.* extended attributes of local files in blob metadata
+	symlinkHandling           common.SymlinkHandlingType // skips symlinks by default, like AzCopy itself
+	followSymlinksOutsideRoot bool                       // turns off --restrict-symlinks-to-root, which AzCopy has on by default
 	relativeSourcePath        string
 	blobTags                  string
+	includeBlobTags           string
+	listVersions              bool                          // copies every version of each blob, each to a name starting with its version id
+	maxVersionsPerBlob        uint                          // with listVersions, copies only the newest this many versions of each blob
+	changeToken               string                        // the file in which a copy saves its change token
+	sinceChangeToken          string                        // the file with the change token of an earlier copy, so that only the files changed since are transferred
+	includeOwner              string                        // copies only the ADLS Gen 2 paths owned by these principals
+	excludeOwner              string                        // skips the ADLS Gen 2 paths owned by these principals
+	snapshotSourceFirst       bool                          // copies each source blob from a snapshot of it, taken just before
+	verifyCrc64               bool                          // checks the CRC64 that Blob returns for uploaded data against the data sent
+	metadataOnly              bool                          // sets the properties of existing destination blobs from their sources, without copying data
+	ifMatch                   string                        // only writes destination blobs that have this ETag, or that exist for *
+	ifNoneMatch               string                        // only writes destination blobs that don't have this ETag, or that don't exist for *
+	immutabilityPolicyUntil   string                        // gives each destination blob an immutability policy that lasts until then
+	legalHold                 bool                          // puts each destination blob under a legal hold
+	destinationArchive        common.ArchiveFormat          // streams a download into a single archive file, rather than a file per source file
+	sourceArchive             common.ArchiveFormat          // uploads the entries of a single archive file, rather than the files in a folder
+	flatten                   bool                          // copies every file directly under the destination, without its folders
+	flattenCollisions         common.FlattenCollisionPolicy // with flatten, what happens to files with the same name
+	preserveEmptyDirectories  bool                          // keeps empty folders in Blob with marker blobs, and recreates them from the markers
+	emptyDirectoryMarker      string                        // the name of those markers, when not the default
+	decompress                bool                          // decompresses downloads whose content-encoding is gzip or deflate
+	excludeRootFolder         bool                          // leaves out the source root folder, while still copying what's in it
+	destinationTimeTokens     bool                          // expands {yyyy} and the like in the destination to the job's start time
+	trailingDot               common.TrailingDotOption      // whether names ending with a dot keep it, when Azure Files is involved
+	excludeContainer          string                        // containers to skip, when the source is a whole account
 	blobType                  string
+	blobTypeByPattern         string // pattern=BlobType pairs, choosing the type of each uploaded blob by its name
+	maxFileCount              uint64 // the most files that a copy may schedule. 0 means no limit
+	skipExistingSameSize      bool
+	includeMetadata           string // key=value pairs. Only source files with any of this metadata are copied
+	excludeMetadata           string
+	includeContentType        string // content types, which may have wildcards. Only source files of these types are copied
+	excludeContentType        string
+	contentType               string // forces the content-type of uploaded files
+	noGuessMimeTypeFromExt    bool   // detects the content-type of uploaded files from their content only
+	contentTypeMapFile        string // a file giving the content-type of uploaded files by extension
+	defaultContentType        string // the content-type of uploaded files whose extensions aren't in contentTypeMapFile
+	contentEncoding           string // like contentType, these headers are set on each uploaded file
+	contentLanguage           string
+	contentDisposition        string
+	cacheControl              string
+	s3Endpoint                string // the S3-compatible service of an S3 source. Set by the source itself
+	s3ForcePathStyle          bool
+	s3SkipSSLVerify           bool
 	stripTopDir               bool
+	stripTopDirFlag           string // sets --strip-top-dir, which takes precedence over stripTopDir (a trailing /* on the source)
 	s2sPreserveBlobTags       bool
+	perTransferTimeout        time.Duration
+	manifestOutput            string
+	maxErrors                 uint32 // a copy cancels itself once more transfers than this have failed. 0 means no limit
 	cpkByName                 string
 	cpkByValue                bool
 	isObjectDir               bool
 	debugSkipFiles            []string // a list of localized filepaths to skip over on the first run in the STE.
+	checkpointPath            string   // the folder in which the job keeps its plan files
+	resumeFrom                string   // with eOperation.Resume(), resume the job from the plan files in this folder, rather than by its ID
+	resumeDestination         string   // with eOperation.Resume(), resume the job into this destination, with its SAS, rather than its own. Its test checks the files itself
+	syncStatePath             string   // with eOperation.Resume(), a sync resumes by being run again with this same sync-state, rather than by jobs resume
 	s2sPreserveAccessTier     bool
-	accessTier                azblob.AccessTierType
+	accessTier                azblob.AccessTierType // of the source blobs that the test creates
+	blockBlobTier             common.BlockBlobTier  // the tier to upload or copy block blobs to, overriding any preserved tier
+	putMd5                    bool
 	checkMd5                  common.HashValidationOption
+	dryRun                    bool // only plan the transfers. Validation then checks the planned transfers, and that nothing reached the destination
+	enumerationParallelism    int  // caps how many folders AzCopy scans at once. 0 leaves AzCopy's default
+	contentValidationSample   int  // with eValidate.Content(), compare only this many of the transferred files. 0 compares them all
+	quiet                     bool // run with --output-level=quiet, so the outcome is known only from the exit code. Only for manual runs, since scenarios validate the printed summary
 
 	destNull bool
 
@@ -202,6 +282,9 @@ func (Operation) Copy() Operation        { return Operation(1) }
 func (Operation) Sync() Operation        { return Operation(1 << 1) }
 func (Operation) CopyAndSync() Operation { return eOperation.Copy() | eOperation.Sync() }
 func (Operation) Remove() Operation      { return Operation(1 << 2) }
+func (Operation) Move() Operation        { return Operation(1 << 3) } // Move is a copy that then deletes the sources that were transferred successfully
+func (Operation) Mirror() Operation      { return Operation(1 << 4) } // Mirror is a sync that also corrects the properties of files whose content is in sync
+func (Operation) Compare() Operation     { return Operation(1 << 5) } // Compare is a sync with --compare-only, which reports how the files differ instead of transferring them
 func (Operation) Resume() Operation      { return Operation(1 << 7) } // Resume should only ever be combined with Copy or Sync, and is a mid-job cancel/resume.
 
 func (o Operation) String() string {
@@ -347,6 +430,16 @@ func (TestFromTo) AllRemove() TestFromTo {
 	}
 }
 
+// AllResumableRemove is AllRemove without ADLS Gen 2, whose removes don't run as jobs, so they can't be resumed
+func (TestFromTo) AllResumableRemove() TestFromTo {
+	result := TestFromTo{}.AllRemove()
+	result.desc = "AllResumableRemove"
+	result.filter = func(ft common.FromTo) bool {
+		return ft.From() != common.ELocation.BlobFS()
+	}
+	return result
+}
+
 func (TestFromTo) AllSync() TestFromTo {
 	return TestFromTo{
 		desc:      "AllSync",
@@ -428,8 +521,8 @@ func (tft TestFromTo) getValues(op Operation) []common.FromTo {
 				continue // this pairing wasn't valid
 			}
 
-			// if we are doing sync, skip combos that are not currently valid for sync
-			if op == eOperation.Sync() {
+			// if we are doing sync (or comparing, as sync does), skip combos that are not currently valid for sync
+			if op == eOperation.Sync() || op == eOperation.Compare() {
 				switch fromTo {
 				case common.EFromTo.BlobBlob(),
 					common.EFromTo.FileFile(),
@@ -445,10 +538,37 @@ func (tft TestFromTo) getValues(op Operation) []common.FromTo {
 				}
 			}
 
+			// mirror compares the properties listed at both ends, so it needs both of them to be remote
+			if op == eOperation.Mirror() && !fromTo.IsS2S() {
+				continue
+			}
+
+			// move can only delete its sources from local, Blob, Files and ADLS Gen 2
+			if op == eOperation.Move() {
+				switch fromTo.From() {
+				case common.ELocation.Local(),
+					common.ELocation.Blob(),
+					common.ELocation.File(),
+					common.ELocation.BlobFS():
+					// do nothing, these are fine
+				default:
+					continue // not supported for move
+				}
+			}
+
+			// GCP scenarios need a GCP project to create their source buckets in
+			if fromTo.From() == common.ELocation.GCP() && (GlobalInputManager{}).GetGCPProjectID() == "" {
+				continue
+			}
+
+			// S3 scenarios create their source buckets in an S3-compatible service, such as MinIO
+			if fromTo.From() == common.ELocation.S3() && (GlobalInputManager{}).GetS3CompatibleEndpoint() == "" {
+				continue
+			}
+
 			// TODO: remove this temp block
 			// temp
-			if fromTo.From() == common.ELocation.S3() ||
-				fromTo.From() == common.ELocation.BlobFS() || fromTo.To() == common.ELocation.BlobFS() {
+			if fromTo.From() == common.ELocation.BlobFS() || fromTo.To() == common.ELocation.BlobFS() {
 				continue // until we implement the declarativeResourceManagers
 			}
 
@@ -484,6 +604,11 @@ func (Validate) Auto() Validate { return Validate(0) }
 // BasicPlusContent also validates the file content
 func (Validate) AutoPlusContent() Validate { return Validate(1) }
 
+// Content validates everything that Auto does, and then checks that each transferred file has exactly the same content
+// at the destination as at the source. Both are read as streams, so large files are fine. To save time, set
+// params.contentValidationSample to check only some of the files
+func (Validate) Content() Validate { return Validate(2) }
+
 func (v Validate) String() string {
 	return enum.StringInt(v, reflect.TypeOf(v))
 }
@@ -492,6 +617,12 @@ func (v Validate) String() string {
 
 // hookHelper is functions that hooks can call to influence test execution
 // NOTE: this interface will have to actively evolve as we discover what we need our hooks to do.
+//
+// The methods that create, change, delete or read files act on the resources directly, with no coordination with
+// AzCopy. So they are only deterministic when AzCopy isn't running: in beforeTestRun, beforeRunJob, beforeResumeHook
+// and afterValidation. In beforeOpenFirstFile, AzCopy won't have opened any file yet, but may already be scanning the
+// destination, so a change made there may or may not be seen. confirmDeletion and progressEvent are called as AzCopy
+// runs, so they should only read.
 type hookHelper interface {
 	// FromTo returns the fromTo for the scenario
 	FromTo() common.FromTo
@@ -509,8 +640,19 @@ type hookHelper interface {
 
 	// CreateFile creates a specified file (overwriting what was already there of the same name)
 	// This is intended to be used in hook functions for pre or mid transfer adjustments.
+	// With atSource false, it is also the way to give the destination a stale or changed copy of a file.
 	CreateFile(f *testObject, atSource bool)
 
+	// DeleteDestinationFile deletes the file at relPath, relative to the destination (as for CreateFile)
+	DeleteDestinationFile(relPath string)
+
+	// GetDestinationFileContent returns the content of the file at relPath, relative to the destination
+	GetDestinationFileContent(relPath string) []byte
+
+	// GetDestinationFileProperties returns the properties of the file at relPath, relative to the destination,
+	// or nil if there is no such file
+	GetDestinationFileProperties(relPath string) *objectProperties
+
 	// CancelAndResume tells the runner to cancel the running AzCopy job (with "cancel" to stdin) and the resume the job
 	CancelAndResume()
 
@@ -523,14 +665,41 @@ type hookHelper interface {
 	// Assert gives access to the asserter
 	GetAsserter() asserter
 
+	// GetSource returns the source Resource Manager
+	GetSource() resourceManager
+
 	// GetDestination returns the destination Resource Manager
 	GetDestination() resourceManager
+
+	// GetFinalStatus returns the summary of the job, as reported at the end of the latest run of AzCopy
+	GetFinalStatus() common.ListSyncJobSummaryResponse
+
+	// GetCompareReport returns the report that ended the run of AzCopy, for eOperation.Compare(). It is nil for other operations
+	GetCompareReport() *common.SyncCompareReport
+
+	// RunAzCopy runs AzCopy once more, apart from the scenario's own run, with the operation, parameters, source and
+	// destination given. It's for tests that need a second run, such as a download of what the scenario uploaded.
+	// The test fails if AzCopy can't be run at all; whether the run did what it should is for the caller to check,
+	// from the result and error returned. An empty source is for parameters that list the sources, e.g. listOfUrls.
+	// Like the scenario's own run, it's given the destination paths of the rewritePath hook, if there is one
+	RunAzCopy(operation Operation, p params, source, destination string) (CopyOrSyncCommandResult, error)
 }
 
 // /////
 
 type hookFunc func(h hookHelper)
 
+// deletionPromptFunc decides how the test answers AzCopy's prompt to delete the destination file at relativePath.
+// It returns true to approve the deletion, and false to deny it
+type deletionPromptFunc func(h hookHelper, relativePath string) bool
+
+// progressEventFunc receives one of the structured progress events that AzCopy emits as the job runs
+type progressEventFunc func(h hookHelper, e common.ProgressEvent)
+
+// pathRewriteFunc gives the destination path of the file or folder at relPath, relative to the source root.
+// Returning "" means the file or folder is skipped
+type pathRewriteFunc func(relPath string) string
+
 // hooks contains functions that are called at various points in the running of the test, so that we can do
 // custom behaviour (for those func that are not nil).
 // NOTE: the funcs you provide here must be threadsafe, because RunScenarios works in parallel for all its scenarios
@@ -552,4 +721,19 @@ type hooks struct {
 
 	// called after AzCopy finishes running & validation of transfer states completes.
 	afterValidation hookFunc
+
+	// called each time AzCopy prompts to delete an extra file at the destination, when deleteDestination is prompt.
+	// Must be set if deleteDestination is prompt
+	confirmDeletion deletionPromptFunc
+
+	// called, in order, with each structured progress event of the job. Setting it turns the events on
+	progressEvent progressEventFunc
+
+	// rewrites the destination path of each file and folder. It's applied to every source file up front, and AzCopy is
+	// given the results. Validation then expects each file listed in testFiles at its rewritten path
+	rewritePath pathRewriteFunc
+
+	// like rewritePath, but AzCopy isn't given the results. It says how AzCopy itself is expected to rename files,
+	// e.g. when it strips trailing dots, so that validation looks for each file under that name
+	expectedDestinationName pathRewriteFunc
 }
@@ -116,6 +116,11 @@ func (a filesResourceAdapter) toHeaders(c asserter, share azfile.ShareURL) azfil
 		headers.SMBProperties.FileLastWriteTime = &lwt
 	}
 
+	if a.obj.creationProperties.creationTime != nil {
+		ct := *a.obj.creationProperties.creationTime
+		headers.SMBProperties.FileCreationTime = &ct
+	}
+
 	props := a.obj.creationProperties.contentHeaders
 	if props == nil {
 		return headers
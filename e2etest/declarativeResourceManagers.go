@@ -21,15 +21,21 @@
 package e2etest
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strings"
 
+	gcpUtils "cloud.google.com/go/storage"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/azure-storage-file-go/azfile"
+	"github.com/minio/minio-go"
 )
 
 func assertNoStripTopDir(stripTopDir bool) {
@@ -77,6 +83,12 @@ type resourceManager interface {
 	// Download
 	downloadContent(a asserter, options downloadContentOptions) []byte
 
+	// openContent is like downloadContent, but streams the content, so that large files need not be held in memory
+	openContent(a asserter, options downloadContentOptions) io.ReadCloser
+
+	// deleteFile deletes the one file at relPath, relative to the location
+	deleteFile(a asserter, relPath string)
+
 	// cleanup gets rid of everything that setup created
 	// (Takes no param, because the resourceManager is expected to track its own state. E.g. "what did I make")
 	cleanup(a asserter)
@@ -196,12 +208,201 @@ func (r *resourceLocal) downloadContent(_ asserter, _ downloadContentOptions) []
 	panic("Not Implemented")
 }
 
+func (r *resourceLocal) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	f, err := os.Open(filepath.Join(r.dirPath, options.resourceRelPath))
+	a.AssertNoErr(err)
+	return f
+}
+
+func (r *resourceLocal) deleteFile(a asserter, relPath string) {
+	a.AssertNoErr(os.Remove(filepath.Join(r.dirPath, relPath)), "deleting "+relPath)
+}
+
 func (r *resourceLocal) createSourceSnapshot(a asserter) {
 	panic("Not Implemented")
 }
 
 // /////
 
+// resourceGCPBucket is a GCP bucket. GCP is only supported as a source
+type resourceGCPBucket struct {
+	bucket *gcpUtils.BucketHandle
+	rawURL *url.URL
+}
+
+func (r *resourceGCPBucket) createLocation(a asserter, s *scenario) {
+	bucket, _, rawURL := TestResourceFactory{}.CreateNewGCPBucket(a)
+	r.bucket = bucket
+	r.rawURL = &rawURL
+	if s.GetModifiableParameters().relativeSourcePath != "" {
+		r.appendSourcePath(s.GetModifiableParameters().relativeSourcePath, false)
+	}
+}
+
+func (r *resourceGCPBucket) createFiles(a asserter, s *scenario, isSource bool) {
+	scenarioHelper{}.generateGCPObjectsFromList(a, r.bucket, generateFromListOptions{
+		fs:          s.fs.allObjects(isSource),
+		defaultSize: s.fs.defaultSize,
+	})
+}
+
+func (r *resourceGCPBucket) createFile(a asserter, o *testObject, s *scenario, isSource bool) {
+	scenarioHelper{}.generateGCPObjectsFromList(a, r.bucket, generateFromListOptions{
+		fs:          []*testObject{o},
+		defaultSize: s.fs.defaultSize,
+	})
+}
+
+func (r *resourceGCPBucket) cleanup(a asserter) {
+	if r.bucket != nil {
+		deleteGCPBucket(a, r.bucket)
+	}
+}
+
+func (r *resourceGCPBucket) getParam(stripTopDir bool, withSas bool, withFile string) string {
+	assertNoStripTopDir(stripTopDir)
+	uri := *r.rawURL // GCP has no SAS; AzCopy authenticates with GOOGLE_APPLICATION_CREDENTIALS
+	if withFile != "" {
+		uri.Path += "/" + withFile
+	}
+	return uri.String()
+}
+
+func (r *resourceGCPBucket) getSAS() string {
+	return ""
+}
+
+func (r *resourceGCPBucket) isContainerLike() bool {
+	return true
+}
+
+func (r *resourceGCPBucket) appendSourcePath(filePath string, _ bool) {
+	r.rawURL.Path += "/" + filePath
+}
+
+func (r *resourceGCPBucket) getAllProperties(a asserter) map[string]*objectProperties {
+	return scenarioHelper{}.enumerateGCPObjectProperties(a, r.bucket)
+}
+
+func (r *resourceGCPBucket) downloadContent(a asserter, options downloadContentOptions) []byte {
+	reader, err := r.bucket.Object(options.resourceRelPath).NewReader(ctx)
+	a.AssertNoErr(err)
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	a.AssertNoErr(err)
+	return data
+}
+
+func (r *resourceGCPBucket) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	reader, err := r.bucket.Object(options.resourceRelPath).NewReader(ctx)
+	a.AssertNoErr(err)
+	return reader
+}
+
+func (r *resourceGCPBucket) deleteFile(a asserter, relPath string) {
+	a.AssertNoErr(r.bucket.Object(relPath).Delete(ctx), "deleting "+relPath)
+}
+
+func (r *resourceGCPBucket) createSourceSnapshot(a asserter) {
+	panic("Not Implemented")
+}
+
+// /////
+
+// resourceS3Bucket is a bucket in the S3-compatible service given by AZCOPY_E2E_S3_ENDPOINT, rather than in AWS
+type resourceS3Bucket struct {
+	client     *minio.Client
+	bucketName string
+	rawURL     *url.URL
+}
+
+func (r *resourceS3Bucket) createLocation(a asserter, s *scenario) {
+	client, name, rawURL := TestResourceFactory{}.CreateNewS3CompatibleBucket(a)
+	r.client = client
+	r.bucketName = name
+	r.rawURL = &rawURL
+
+	// AzCopy only reads URLs on the service as S3 URLs when it is told the endpoint
+	p := s.GetModifiableParameters()
+	p.s3Endpoint = (&url.URL{Scheme: rawURL.Scheme, Host: rawURL.Host}).String()
+	p.s3ForcePathStyle = true
+	p.s3SkipSSLVerify = GlobalInputManager{}.S3SkipSSLVerify()
+	if p.relativeSourcePath != "" {
+		r.appendSourcePath(p.relativeSourcePath, false)
+	}
+}
+
+func (r *resourceS3Bucket) createFiles(a asserter, s *scenario, isSource bool) {
+	scenarioHelper{}.generateS3ObjectsFromList(a, r.client, r.bucketName, generateFromListOptions{
+		fs:          s.fs.allObjects(isSource),
+		defaultSize: s.fs.defaultSize,
+	})
+}
+
+func (r *resourceS3Bucket) createFile(a asserter, o *testObject, s *scenario, isSource bool) {
+	scenarioHelper{}.generateS3ObjectsFromList(a, r.client, r.bucketName, generateFromListOptions{
+		fs:          []*testObject{o},
+		defaultSize: s.fs.defaultSize,
+	})
+}
+
+func (r *resourceS3Bucket) cleanup(a asserter) {
+	if r.client != nil {
+		deleteBucket(a, r.client, r.bucketName, false)
+	}
+}
+
+func (r *resourceS3Bucket) getParam(stripTopDir bool, withSas bool, withFile string) string {
+	assertNoStripTopDir(stripTopDir)
+	uri := *r.rawURL // S3 has no SAS; AzCopy authenticates with AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	if withFile != "" {
+		uri.Path += "/" + withFile
+	}
+	return uri.String()
+}
+
+func (r *resourceS3Bucket) getSAS() string {
+	return ""
+}
+
+func (r *resourceS3Bucket) isContainerLike() bool {
+	return true
+}
+
+func (r *resourceS3Bucket) appendSourcePath(filePath string, _ bool) {
+	r.rawURL.Path += "/" + filePath
+}
+
+func (r *resourceS3Bucket) getAllProperties(a asserter) map[string]*objectProperties {
+	return scenarioHelper{}.enumerateS3ObjectProperties(a, r.client, r.bucketName)
+}
+
+func (r *resourceS3Bucket) downloadContent(a asserter, options downloadContentOptions) []byte {
+	reader := r.openContent(a, options)
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	a.AssertNoErr(err)
+	return data
+}
+
+func (r *resourceS3Bucket) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	object, err := r.client.GetObject(r.bucketName, options.resourceRelPath, minio.GetObjectOptions{})
+	a.AssertNoErr(err)
+	return object
+}
+
+func (r *resourceS3Bucket) deleteFile(a asserter, relPath string) {
+	a.AssertNoErr(r.client.RemoveObject(r.bucketName, relPath), "deleting "+relPath)
+}
+
+func (r *resourceS3Bucket) createSourceSnapshot(a asserter) {
+	panic("Not Implemented")
+}
+
+// /////
+
 type resourceBlobContainer struct {
 	accountType  AccountType
 	containerURL *azblob.ContainerURL
@@ -302,6 +503,16 @@ func (r *resourceBlobContainer) downloadContent(a asserter, options downloadCont
 	return scenarioHelper{}.downloadBlobContent(a, options)
 }
 
+func (r *resourceBlobContainer) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	options.containerURL = *r.containerURL
+	return scenarioHelper{}.openBlobContent(a, options)
+}
+
+func (r *resourceBlobContainer) deleteFile(a asserter, relPath string) {
+	_, err := r.containerURL.NewBlobURL(relPath).Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	a.AssertNoErr(err, "deleting "+relPath)
+}
+
 func (r *resourceBlobContainer) createSourceSnapshot(a asserter) {
 	panic("Not Implemented")
 }
@@ -398,6 +609,20 @@ func (r *resourceAzureFileShare) downloadContent(a asserter, options downloadCon
 	})
 }
 
+func (r *resourceAzureFileShare) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	return scenarioHelper{}.openFileContent(a, downloadContentOptions{
+		resourceRelPath: options.resourceRelPath,
+		downloadFileContentOptions: downloadFileContentOptions{
+			shareURL: *r.shareURL,
+		},
+	})
+}
+
+func (r *resourceAzureFileShare) deleteFile(a asserter, relPath string) {
+	_, err := r.shareURL.NewRootDirectoryURL().NewFileURL(relPath).Delete(ctx)
+	a.AssertNoErr(err, "deleting "+relPath)
+}
+
 func (r *resourceAzureFileShare) createSourceSnapshot(a asserter) {
 	r.snapshotID = TestResourceFactory{}.CreateNewFileShareSnapshot(a, *r.shareURL)
 }
@@ -435,6 +660,10 @@ func (r *resourceManagedDisk) downloadContent(a asserter, options downloadConten
 	panic("md testing currently does not involve custom content; just a zeroed out disk")
 }
 
+func (r *resourceManagedDisk) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	panic("md testing currently does not involve custom content; just a zeroed out disk")
+}
+
 // cleanup also usurps traditional resourceManager functionality.
 func (r *resourceManagedDisk) cleanup(a asserter) {
 	// revoking access isn't required and causes funky behaviour for testing that might require a distributed mutex.
@@ -465,6 +694,10 @@ func (r *resourceManagedDisk) appendSourcePath(s string, b bool) {
 	panic("resourceManagedDisk is a single file")
 }
 
+func (r *resourceManagedDisk) deleteFile(a asserter, relPath string) {
+	panic("Not Implemented")
+}
+
 func (r *resourceManagedDisk) createSourceSnapshot(a asserter) {
 	// TODO implement me
 	panic("cannot snapshot a managed disk")
@@ -510,7 +743,13 @@ func (r *resourceDummy) downloadContent(a asserter, options downloadContentOptio
 	return make([]byte, 0)
 }
 
+func (r *resourceDummy) openContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	return ioutil.NopCloser(bytes.NewReader(make([]byte, 0)))
+}
+
 func (r *resourceDummy) appendSourcePath(_ string, _ bool) {
 }
 
+func (r *resourceDummy) deleteFile(a asserter, relPath string) {}
+
 func (r *resourceDummy) createSourceSnapshot(a asserter) {}
@@ -22,13 +22,20 @@ package e2etest
 
 import (
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Azure/azure-storage-azcopy/v10/cmd"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-azcopy/v10/sddl"
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -64,6 +71,15 @@ type scenarioState struct {
 	source resourceManager
 	dest   resourceManager
 	result *CopyOrSyncCommandResult
+
+	// the answers given to AzCopy's prompts to delete extra destination files
+	deletionDecisions *deletionDecisions
+}
+
+// deletionDecisions holds the answers given to AzCopy's prompts to delete extra destination files
+type deletionDecisions struct {
+	sync.Mutex
+	approved map[string]bool // keyed by relative path. True means the deletion was approved
 }
 
 // Run runs one test scenario
@@ -96,6 +112,12 @@ func (s *scenario) Run() {
 		return
 	}
 
+	// what would be transferred, before anything is
+	s.validateListTransfers()
+	if s.a.Failed() {
+		return
+	}
+
 	// execute
 	s.runAzCopy()
 	if s.a.Failed() {
@@ -112,7 +134,11 @@ func (s *scenario) Run() {
 			return
 		}
 
-		s.resumeAzCopy()
+		if s.p.syncStatePath != "" {
+			s.resumeSyncFromState()
+		} else {
+			s.resumeAzCopy()
+		}
 	}
 	if s.a.Failed() {
 		return // resume failed. No point in running validation
@@ -124,7 +150,9 @@ func (s *scenario) Run() {
 		return // no point in doing more validation
 	}
 
-	if !s.p.destNull {
+	// the files in a destination archive aren't files at the destination, so their tests must check the archive themselves
+	// nor are the files of a job resumed into another destination all in one place
+	if !s.p.destNull && !s.p.dryRun && s.operation != eOperation.Compare() && s.p.destinationArchive == common.EArchiveFormat.None() && s.p.resumeDestination == "" {
 		s.validateProperties()
 		if s.a.Failed() {
 			return // no point in doing more validation
@@ -133,6 +161,9 @@ func (s *scenario) Run() {
 		if s.validate&eValidate.AutoPlusContent() != 0 {
 			s.validateContent()
 		}
+		if s.validate&eValidate.Content() != 0 {
+			s.validateContentMatchesSource()
+		}
 	}
 
 	s.runHook(s.hs.afterValidation)
@@ -182,8 +213,9 @@ func (s *scenario) assignSourceAndDest() {
 			s.a.Error("Not implementd yet for blob FS")
 			return &resourceDummy{}
 		case common.ELocation.S3():
-			s.a.Error("Not implementd yet for S3")
-			return &resourceDummy{}
+			return &resourceS3Bucket{}
+		case common.ELocation.GCP():
+			return &resourceGCPBucket{}
 		case common.ELocation.Unknown():
 			return &resourceDummy{}
 		default:
@@ -214,6 +246,22 @@ func (s *scenario) runAzCopy() {
 		}
 	}
 
+	if s.p.deleteDestination == common.EDeleteDestination.Prompt() {
+		s.a.Assert(s.hs.confirmDeletion != nil, equals(), true, "the confirmDeletion hook must be set, to answer delete-destination prompts")
+		s.state.deletionDecisions = &deletionDecisions{approved: make(map[string]bool)}
+		r.SetPromptResponder(s.answerDeletionPrompt)
+	}
+
+	if s.hs.progressEvent != nil {
+		r.SetProgressEventHandler(func(e common.ProgressEvent) { s.hs.progressEvent(s, e) })
+	}
+
+	if s.hs.rewritePath != nil {
+		pathMap := s.writePathMap()
+		defer os.Remove(pathMap)
+		r.flags["path-map"] = pathMap
+	}
+
 	needsSAS := func(credType common.CredentialType) bool {
 		return credType == common.ECredentialType.Anonymous() || credType == common.ECredentialType.MDOAuthToken()
 	}
@@ -243,6 +291,61 @@ func (s *scenario) runAzCopy() {
 	s.state.result = &result
 }
 
+// writePathMap saves, in a temporary file, the destination path that the rewritePath hook gives each source file, in
+// the form that AzCopy's path-map flag reads
+func (s *scenario) writePathMap() string {
+	pathMap := make(map[string]string)
+	for _, f := range s.fs.allObjects(true) {
+		if f.name != "" {
+			pathMap[f.name] = s.hs.rewritePath(f.name)
+		}
+	}
+	raw, err := json.Marshal(pathMap)
+	s.a.AssertNoErr(err, "saving path map")
+
+	file, err := ioutil.TempFile("", "AzCopyPathMap")
+	s.a.AssertNoErr(err, "creating path map file")
+	defer file.Close()
+	_, err = file.Write(raw)
+	s.a.AssertNoErr(err, "writing path map file")
+	return file.Name()
+}
+
+// destinationName gives the name that the test object of the given name is expected to have at the destination
+func (s *scenario) destinationName(name string) string {
+	rename := s.destinationRename()
+	if rename == nil || name == "" {
+		return name
+	}
+	return rename(name)
+}
+
+// destinationRename is how the destination names are expected to differ from the source names, if they do
+func (s *scenario) destinationRename() pathRewriteFunc {
+	if s.hs.rewritePath != nil {
+		return s.hs.rewritePath
+	}
+	return s.hs.expectedDestinationName
+}
+
+// answerDeletionPrompt answers one of AzCopy's prompts to delete an extra destination file, as decided by the confirmDeletion hook
+func (s *scenario) answerDeletionPrompt(details common.PromptDetails) string {
+	if details.PromptType != common.EPromptType.DeleteDestination() {
+		return "" // not a prompt we know how to answer, so let AzCopy take its default
+	}
+
+	approved := s.hs.confirmDeletion(s, details.PromptTarget)
+
+	s.state.deletionDecisions.Lock()
+	defer s.state.deletionDecisions.Unlock()
+	s.state.deletionDecisions.approved[details.PromptTarget] = approved
+
+	if approved {
+		return common.EResponseOption.Yes().ResponseString
+	}
+	return common.EResponseOption.No().ResponseString
+}
+
 func (s *scenario) resumeAzCopy() {
 	s.chToStdin = make(chan string) // unubuffered seems the most predictable for our usages
 	defer close(s.chToStdin)
@@ -267,9 +370,19 @@ func (s *scenario) resumeAzCopy() {
 		}
 	}
 
+	jobID := s.state.result.jobID.String()
+	if s.p.resumeFrom != "" {
+		r.flags["resume-from"] = s.p.resumeFrom
+		jobID = "" // AzCopy finds the job from the plan files in the folder
+	}
+	if s.p.resumeDestination != "" {
+		r.flags["destination"] = s.p.resumeDestination
+		delete(r.flags, "destination-sas") // the SAS comes with the new destination
+	}
+
 	result, wasClean, err := r.ExecuteAzCopyCommand(
 		eOperation.Resume(),
-		s.state.result.jobID.String(),
+		jobID,
 		"",
 		false,
 		afterStart,
@@ -279,10 +392,22 @@ func (s *scenario) resumeAzCopy() {
 	if !wasClean {
 		s.a.AssertNoErr(err, "running AzCopy")
 	}
+	if s.p.resumeFrom != "" {
+		result.checkpointPath = s.p.resumeFrom
+	}
 
 	s.state.result = &result
 }
 
+// resumeSyncFromState runs the sync again, with the same sync-state, so that it resumes its job, rather than scanning again
+func (s *scenario) resumeSyncFromState() {
+	firstRun := s.p
+	defer func() { s.p = firstRun }()
+
+	s.p.debugSkipFiles = nil // they only apply to the first run
+	s.runAzCopy()
+}
+
 func (s *scenario) validateRemove() {
 	removedFiles := s.fs.toTestObjects(s.fs.shouldTransfer, false)
 	props := s.state.source.getAllProperties(s.a)
@@ -295,15 +420,178 @@ func (s *scenario) validateRemove() {
 		}
 	}
 }
+
+// validateDryrun checks that a dry run planned exactly the transfers that a real run would have made, and that it
+// did not actually transfer (or remove) anything.
+func (s *scenario) validateDryrun() {
+	s.a.Assert(s.state.result.isDryrun, equals(), true, "expected the output of a dry run")
+	if s.a.Failed() {
+		return
+	}
+
+	isSrcEncoded := s.fromTo.From().IsRemote()
+	isDstEncoded := s.fromTo.To().IsRemote()
+	_, _, expectFolders, expectRootFolder, addedDirAtDest := s.getTransferInfo()
+
+	// the planned transfers hold paths relative to the source and destination, rather than full paths
+	dstPrefix := ""
+	if addedDirAtDest != "" {
+		dstPrefix = "/" + addedDirAtDest
+	}
+	planned := make([]common.TransferDetail, 0, len(s.state.result.dryrunTransfers))
+	for _, t := range s.state.result.dryrunTransfers {
+		planned = append(planned, common.TransferDetail{
+			Src:                t.Source,
+			Dst:                t.Destination,
+			IsFolderProperties: t.EntityType == common.EEntityType.Folder(),
+		})
+	}
+
+	expected := s.fs.getForStatus(common.ETransferStatus.Success(), expectFolders, expectRootFolder)
+	Validator{}.ValidateCopyTransfersAreScheduled(s.a, isSrcEncoded, isDstEncoded, "", dstPrefix, expected, planned, common.ETransferStatus.Success(), s.FromTo(), s.srcAccountType, s.destAccountType, s.destinationRename())
+
+	// where the dry run reports totals, they must add up to what it planned
+	if summary := s.state.result.dryrunSummary; summary != nil {
+		expectedSummary := common.DryrunSummary{}
+		for _, f := range expected {
+			if f.isFolder() {
+				expectedSummary.FolderCount++
+			} else {
+				expectedSummary.FileCount++
+				expectedSummary.TotalBytes += uint64(f.creationProperties.sizeBytes(s.a, s.fs.defaultSize))
+			}
+		}
+		s.a.Assert(*summary, equals(), expectedSummary, "totals reported by the dry run")
+	}
+
+	// nothing may have changed: every source is still there, and none of them has reached the destination
+	srcProps := s.state.source.getAllProperties(s.a)
+	var dstProps map[string]*objectProperties
+	if s.operation != eOperation.Remove() && !s.p.destNull {
+		dstProps = s.state.dest.getAllProperties(s.a)
+	}
+	normalize := func(props map[string]*objectProperties) map[string]bool {
+		out := make(map[string]bool, len(props))
+		for name := range props {
+			out[strings.ReplaceAll(name, "\\", "/")] = true
+		}
+		return out
+	}
+	atSource, atDest := normalize(srcProps), normalize(dstProps)
+	for _, f := range s.fs.toTestObjects(s.fs.shouldTransfer, false) {
+		if f.isFolder() {
+			continue
+		}
+		s.a.Assert(atSource[f.name], equals(), true, fmt.Sprintf("expected source '%s' to be left in place by the dry run", f.name))
+		s.a.Assert(atDest[path.Join(addedDirAtDest, s.destinationName(f.name))], equals(), false, fmt.Sprintf("expected '%s' not to be transferred by the dry run", f.name))
+	}
+}
+
+// validateCompare checks that a comparison reported each file of the source exactly once, in one of the categories of
+// files at the source, and that it transferred none of those that are only at the source. Which category each file
+// belongs in depends on what the test did to the destination, so that is left to the test's hooks to check
+func (s *scenario) validateCompare() {
+	report := s.state.result.compareReport
+	s.a.Assert(report != nil, equals(), true, "expected the report of a comparison")
+	if s.a.Failed() {
+		return
+	}
+
+	timesReported := make(map[string]int)
+	for _, list := range [][]string{report.OnlyInSource, report.Differing, report.Identical} {
+		for _, name := range list {
+			timesReported[name]++
+		}
+	}
+	for _, f := range s.fs.toTestObjects(s.fs.shouldTransfer, false) {
+		if f.isFolder() {
+			continue
+		}
+		s.a.Assert(timesReported[f.name], equals(), 1, fmt.Sprintf("expected '%s' to be reported once, as a file at the source", f.name))
+	}
+
+	atDest := make(map[string]bool)
+	for name := range s.state.dest.getAllProperties(s.a) {
+		atDest[strings.ReplaceAll(name, "\\", "/")] = true
+	}
+	for _, name := range report.OnlyInSource {
+		s.a.Assert(atDest[name], equals(), false, fmt.Sprintf("expected '%s' not to be transferred by the comparison", name))
+	}
+}
+
+// validateMoveSources checks that a move deleted the source of each file that it transferred, and only those.
+// Folders are not deleted by a move, so they are not checked.
+func (s *scenario) validateMoveSources() {
+	props := s.state.source.getAllProperties(s.a)
+	remaining := make(map[string]bool, len(props))
+	for name := range props {
+		remaining[strings.ReplaceAll(name, "\\", "/")] = true
+	}
+
+	for _, f := range s.fs.toTestObjects(s.fs.shouldTransfer, false) {
+		if !f.isFolder() {
+			s.a.Assert(remaining[f.name], equals(), false, fmt.Sprintf("expected source '%s' to be deleted by the move", f.name))
+		}
+	}
+
+	var kept []*testObject
+	kept = append(kept, s.fs.toTestObjects(s.fs.shouldIgnore, false)...)
+	kept = append(kept, s.fs.toTestObjects(s.fs.shouldSkip, false)...)
+	kept = append(kept, s.fs.toTestObjects(s.fs.shouldFail, true)...)
+	for _, f := range kept {
+		if !f.isFolder() {
+			s.a.Assert(remaining[f.name], equals(), true, fmt.Sprintf("expected source '%s' to be kept by the move, since it was not transferred", f.name))
+		}
+	}
+}
+
+// validateDeletionPrompts checks that AzCopy prompted for each destination-only file, that it deleted exactly those whose
+// deletion was approved, and that its summary counts the approved and denied deletions separately
+func (s *scenario) validateDeletionPrompts() {
+	s.state.deletionDecisions.Lock()
+	defer s.state.deletionDecisions.Unlock()
+
+	remaining := s.state.dest.getAllProperties(s.a)
+	approvedCount, deniedCount := uint32(0), uint32(0)
+	for _, f := range s.fs.toTestObjects(s.fs.destinationOnly, false) {
+		if f.isFolder() {
+			continue // sync does not delete folders
+		}
+		approved, prompted := s.state.deletionDecisions.approved[f.name]
+		s.a.Assert(prompted, equals(), true, fmt.Sprintf("expected a prompt to delete the destination-only file '%s'", f.name))
+		_, exists := remaining[f.name]
+		if approved {
+			approvedCount++
+			s.a.Assert(exists, equals(), false, fmt.Sprintf("expected '%s' to be deleted, since its deletion was approved", f.name))
+		} else {
+			deniedCount++
+			s.a.Assert(exists, equals(), true, fmt.Sprintf("expected '%s' to be kept, since its deletion was denied", f.name))
+		}
+	}
+
+	s.a.Assert(s.state.result.finalStatus.DeleteTransfersCompleted, equals(), approvedCount, "number of deletions in the summary")
+	s.a.Assert(s.state.result.finalStatus.DeleteTransfersDeclined, equals(), deniedCount, "number of declined deletions in the summary")
+}
+
 func (s *scenario) validateTransferStates() {
+	if s.p.dryRun {
+		s.validateDryrun()
+		return
+	}
+
 	if s.operation == eOperation.Remove() {
 		s.validateRemove()
 		return
 	}
 
-	if s.p.deleteDestination != common.EDeleteDestination.False() {
+	if s.operation == eOperation.Compare() {
+		s.validateCompare()
+		return
+	}
+
+	if s.p.deleteDestination == common.EDeleteDestination.True() {
 		// TODO: implement deleteDestinationValidation
-		panic("validation of deleteDestination behaviour is not yet implemented in the declarative test runner")
+		panic("validation of deleteDestination=true behaviour is not yet implemented in the declarative test runner")
 	}
 
 	isSrcEncoded := s.fromTo.From().IsRemote() // TODO: is this right, reviewers?
@@ -321,17 +609,110 @@ func (s *scenario) validateTransferStates() {
 		actualTransfers, err := s.state.result.GetTransferList(statusToTest)
 		s.a.AssertNoErr(err)
 
-		Validator{}.ValidateCopyTransfersAreScheduled(s.a, isSrcEncoded, isDstEncoded, srcRoot, dstRoot, expectedTransfers, actualTransfers, statusToTest, s.FromTo(), s.srcAccountType, s.destAccountType)
+		Validator{}.ValidateCopyTransfersAreScheduled(s.a, isSrcEncoded, isDstEncoded, srcRoot, dstRoot, expectedTransfers, actualTransfers, statusToTest, s.FromTo(), s.srcAccountType, s.destAccountType, s.destinationRename())
 		// TODO: how are we going to validate folder transfers????
 	}
 
+	if s.operation == eOperation.Move() {
+		s.validateMoveSources()
+	}
+
+	if s.p.deleteDestination == common.EDeleteDestination.Prompt() {
+		s.validateDeletionPrompts()
+	}
+
 	// TODO: for failures, consider validating the failure messages (for which we have expected values, in s.fs; but don't currently have a good way to get
 	//    the actual values from the test run
 }
 
+// listTransfersFlags are the flags that the filter scenarios use. When a scenario uses no others, cmd.ListTransfers must
+// list exactly the files that the job then transfers, or fails to transfer
+var listTransfersFlags = map[string]bool{
+	"recursive":           true,
+	"as-subdir":           true,
+	"strip-top-dir":       true,
+	"include-path":        true,
+	"list-of-files":       true,
+	"exclude-path":        true,
+	"include-pattern":     true,
+	"exclude-pattern":     true,
+	"include-after":       true,
+	"include-before":      true,
+	"min-size":            true,
+	"max-size":            true,
+	"include-regex":       true,
+	"exclude-regex":       true,
+	"include-ext":         true,
+	"exclude-ext":         true,
+	"ignore-case-pattern": true,
+	"include-attributes":  true,
+	"exclude-attributes":  true,
+	"min-depth":           true,
+	"max-depth":           true,
+	"include-metadata":    true,
+	"exclude-metadata":    true,
+	"include-owner":       true,
+	"exclude-owner":       true,
+}
+
+// validateListTransfers checks that cmd.ListTransfers, given the same arguments as the copy, lists the files that the
+// scenario expects the copy to transfer. It's only done for the copies that use no flags other than listTransfersFlags,
+// so that the filter scenarios check the listing too
+func (s *scenario) validateListTransfers() {
+	if s.operation != eOperation.Copy() || s.needResume || s.hs.rewritePath != nil || s.hs.beforeOpenFirstFile != nil ||
+		s.credTypes[0] != common.ECredentialType.Anonymous() || s.credTypes[1] != common.ECredentialType.Anonymous() {
+		return
+	}
+	tf := s.GetTestFiles()
+	if tf.objectTarget != "" || tf.destTarget != "" {
+		return
+	}
+
+	r := newTestRunner()
+	r.SetAllFlags(s.p, s.operation)
+	args := []string{s.state.source.getParam(s.stripTopDir, true, ""), s.state.dest.getParam(false, true, "")}
+	for key, value := range r.flags {
+		if !listTransfersFlags[key] {
+			return
+		}
+		args = append(args, "--"+key+"="+value)
+	}
+
+	listed := make([]string, 0)
+	err := cmd.ListTransfers(args, func(transfer cmd.TransferDescriptor) error {
+		if transfer.EntityType != common.EEntityType.File() {
+			return nil
+		}
+		name := transfer.RelativePath
+		if s.fromTo.From().IsRemote() {
+			var err error
+			if name, err = url.PathUnescape(name); err != nil {
+				return err
+			}
+		}
+		listed = append(listed, name)
+		return nil
+	})
+	s.a.AssertNoErr(err, "listing transfers")
+
+	expected := make([]string, 0)
+	for _, status := range []common.TransferStatus{common.ETransferStatus.Success(), common.ETransferStatus.Failed()} {
+		for _, f := range s.fs.getForStatus(status, false, false) {
+			expected = append(expected, f.name)
+		}
+	}
+	sort.Strings(listed)
+	sort.Strings(expected)
+	s.a.Assert(listed, equals(), expected, "ListTransfers must list the files that the copy transfers")
+}
+
 func (s *scenario) getTransferInfo() (srcRoot string, dstRoot string, expectFolders bool, expectedRootFolder bool, addedDirAtDest string) {
 	srcRoot = s.state.source.getParam(false, false, "")
 	dstRoot = s.state.dest.getParam(false, false, "")
+	if s.p.resumeDestination != "" {
+		// the job reports all its transfers at the destination that it was resumed into
+		dstRoot = strings.Split(s.p.resumeDestination, "?")[0]
+	}
 
 	// do we expect folder transfers
 	expectFolders = (s.fromTo.From().IsFolderAware() &&
@@ -345,8 +726,13 @@ func (s *scenario) getTransferInfo() (srcRoot string, dstRoot string, expectFold
 	areBothContainerLike := s.state.source.isContainerLike() && s.state.dest.isContainerLike()
 
 	tf := s.GetTestFiles()
-	if s.stripTopDir || s.operation == eOperation.Sync() || areBothContainerLike {
-		// Sync always acts like stripTopDir is true.
+	stripTopDir := s.stripTopDir
+	if s.p.stripTopDirFlag != "" {
+		// --strip-top-dir takes precedence over the trailing /*
+		stripTopDir = s.p.stripTopDirFlag == "true"
+	}
+	if stripTopDir || s.operation == eOperation.Sync() || s.operation == eOperation.Mirror() || s.operation == eOperation.Compare() || areBothContainerLike {
+		// Sync (and so mirror and compare) always acts like stripTopDir is true.
 		// For copies between two container-like locations, we don't expect the root directory to be transferred, regardless of stripTopDir.
 		// Yes, this is arguably inconsistent. But its the way its always been, and it does seem to match user expectations for copies
 		// of that kind.
@@ -397,7 +783,7 @@ func (s *scenario) validateProperties() {
 			destProps = s.state.dest.getAllProperties(s.a)
 		}
 
-		destName := fixSlashes(path.Join(addedDirAtDest, f.name), s.fromTo.To())
+		destName := fixSlashes(path.Join(addedDirAtDest, s.destinationName(f.name)), s.fromTo.To())
 		actual, ok := destProps[destName]
 		if !ok {
 			// this shouldn't happen, because we only run if validateTransferStates passed, but check anyway
@@ -452,7 +838,7 @@ func (s *scenario) validateContent() {
 		}
 		if !f.isFolder() {
 			expectedContentMD5 := f.creationProperties.contentHeaders.contentMD5
-			resourceRelPath := fixSlashes(path.Join(addedDirAtDest, f.name), s.fromTo.To())
+			resourceRelPath := fixSlashes(path.Join(addedDirAtDest, s.destinationName(f.name)), s.fromTo.To())
 			actualContent := s.state.dest.downloadContent(s.a, downloadContentOptions{
 				resourceRelPath: resourceRelPath,
 				downloadBlobContentOptions: downloadBlobContentOptions{
@@ -469,6 +855,93 @@ func (s *scenario) validateContent() {
 	}
 }
 
+// validateContentMatchesSource compares the content of each transferred file (or an evenly-spread sample of them, if
+// params.contentValidationSample is set) at the destination with its content at the source
+func (s *scenario) validateContentMatchesSource() {
+	_, _, expectFolders, expectRootFolder, addedDirAtDest := s.getTransferInfo()
+
+	files := make([]*testObject, 0)
+	for _, f := range s.fs.getForStatus(common.ETransferStatus.Success(), expectFolders, expectRootFolder) {
+		if !f.isFolder() {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	if sampleSize := s.p.contentValidationSample; sampleSize > 0 && sampleSize < len(files) {
+		sample := make([]*testObject, 0, sampleSize)
+		for i := 0; i < sampleSize; i++ {
+			sample = append(sample, files[i*len(files)/sampleSize])
+		}
+		files = sample
+	}
+
+	for _, f := range files {
+		srcContent := s.state.source.openContent(s.a, downloadContentOptions{resourceRelPath: fixSlashes(f.name, s.fromTo.From())})
+		dstContent := s.state.dest.openContent(s.a, downloadContentOptions{
+			resourceRelPath: fixSlashes(path.Join(addedDirAtDest, s.destinationName(f.name)), s.fromTo.To()),
+			downloadBlobContentOptions: downloadBlobContentOptions{
+				cpkInfo:      common.GetCpkInfo(s.p.cpkByValue),
+				cpkScopeInfo: common.GetCpkScopeInfo(s.p.cpkByName),
+			},
+		})
+		if s.a.Failed() {
+			return
+		}
+
+		mismatchOffset, same, err := compareContent(srcContent, dstContent)
+		_ = srcContent.Close()
+		_ = dstContent.Close()
+		s.a.AssertNoErr(err, "reading content of "+f.name)
+		s.a.Assert(same, equals(), true, fmt.Sprintf("content of '%s' differs between source and destination, starting at byte %d", f.name, mismatchOffset))
+	}
+}
+
+// compareContent reads both streams to the end (a buffer at a time, so that big files need not fit in memory), and reports
+// whether they hold the same bytes. If not, it returns the offset of the first byte that differs
+func compareContent(expected, actual io.Reader) (mismatchOffset int64, same bool, err error) {
+	const bufferSize = 4 * 1024 * 1024
+	expectedBuf := make([]byte, bufferSize)
+	actualBuf := make([]byte, bufferSize)
+
+	readChunk := func(r io.Reader, buf []byte) (int, bool, error) {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, true, nil
+		}
+		return n, false, err
+	}
+
+	for {
+		expectedN, expectedDone, err := readChunk(expected, expectedBuf)
+		if err != nil {
+			return mismatchOffset, false, err
+		}
+		actualN, actualDone, err := readChunk(actual, actualBuf)
+		if err != nil {
+			return mismatchOffset, false, err
+		}
+
+		for i := 0; i < expectedN && i < actualN; i++ {
+			if expectedBuf[i] != actualBuf[i] {
+				return mismatchOffset + int64(i), false, nil
+			}
+		}
+		if expectedN != actualN {
+			// one is shorter than the other
+			if expectedN < actualN {
+				return mismatchOffset + int64(expectedN), false, nil
+			}
+			return mismatchOffset + int64(actualN), false, nil
+		}
+		mismatchOffset += int64(expectedN)
+
+		if expectedDone || actualDone {
+			return mismatchOffset, expectedDone == actualDone, nil
+		}
+	}
+}
+
 // // Individual property validation routines
 
 func (s *scenario) validateMetadata(expected, actual map[string]string, isFolder bool) {
@@ -476,6 +949,13 @@ func (s *scenario) validateMetadata(expected, actual map[string]string, isFolder
 		delete(expected, "hdi_isfolder")
 		delete(actual, "hdi_isfolder")
 	}
+	if s.p.preservePOSIXProperties {
+		// AzCopy adds these itself, from what it finds on the source file system
+		common.ClearStatFromBlobMetadata(actual)
+	}
+	if s.p.preserveXattrs {
+		delete(actual, common.XattrsMeta)
+	}
 
 	s.a.Assert(len(expected), equals(), len(actual), "Both should have same number of metadata entries")
 	for key := range expected {
@@ -655,6 +1135,29 @@ func (s *scenario) CreateFile(f *testObject, atSource bool) {
 	}
 }
 
+func (s *scenario) DeleteDestinationFile(relPath string) {
+	s.state.dest.deleteFile(s.a, relPath)
+}
+
+func (s *scenario) GetDestinationFileContent(relPath string) []byte {
+	reader := s.state.dest.openContent(s.a, downloadContentOptions{
+		resourceRelPath: relPath,
+		downloadBlobContentOptions: downloadBlobContentOptions{
+			cpkInfo:      common.GetCpkInfo(s.p.cpkByValue),
+			cpkScopeInfo: common.GetCpkScopeInfo(s.p.cpkByName),
+		},
+	})
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	s.a.AssertNoErr(err, "reading "+relPath+" at the destination")
+	return content
+}
+
+func (s *scenario) GetDestinationFileProperties(relPath string) *objectProperties {
+	return s.state.dest.getAllProperties(s.a)[relPath]
+}
+
 func (s *scenario) CreateSourceSnapshot() {
 	s.state.source.createSourceSnapshot(s.a)
 }
@@ -673,6 +1176,45 @@ func (s *scenario) GetAsserter() asserter {
 	return s.a
 }
 
+func (s *scenario) GetFinalStatus() common.ListSyncJobSummaryResponse {
+	return s.state.result.finalStatus
+}
+
+func (s *scenario) GetCompareReport() *common.SyncCompareReport {
+	return s.state.result.compareReport
+}
+
+func (s *scenario) RunAzCopy(operation Operation, p params, source, destination string) (CopyOrSyncCommandResult, error) {
+	r := newTestRunner()
+	r.SetAllFlags(p, operation)
+	if s.hs.rewritePath != nil {
+		pathMap := s.writePathMap()
+		defer os.Remove(pathMap)
+		r.flags["path-map"] = pathMap
+	}
+	return runAzCopyWithRunner(s.a, r, operation, source, destination)
+}
+
+// runAzCopyWithParams runs AzCopy once, as hookHelper.RunAzCopy does. It's also for tests that can't be scenarios at
+// all, such as those that work on several containers of an account
+func runAzCopyWithParams(a asserter, operation Operation, p params, source, destination string) (CopyOrSyncCommandResult, error) {
+	r := newTestRunner()
+	r.SetAllFlags(p, operation)
+	return runAzCopyWithRunner(a, r, operation, source, destination)
+}
+
+func runAzCopyWithRunner(a asserter, r TestRunner, operation Operation, source, destination string) (CopyOrSyncCommandResult, error) {
+	result, wasClean, err := r.ExecuteAzCopyCommand(operation, source, destination, false, func() string { return "" }, nil)
+	if !wasClean {
+		a.AssertNoErr(err, "running AzCopy")
+	}
+	return result, err
+}
+
+func (s *scenario) GetSource() resourceManager {
+	return s.state.source
+}
+
 func (s *scenario) GetDestination() resourceManager {
 	return s.state.dest
 }
@@ -90,6 +90,7 @@ type objectProperties struct {
 	nameValueMetadata  map[string]string
 	blobTags           common.BlobTags
 	blobType           common.BlobType
+	accessTier         azblob.AccessTierType // only read back from blobs; it is not compared by the usual validation
 	creationTime       *time.Time
 	lastWriteTime      *time.Time
 	smbAttributes      *uint32
@@ -324,6 +325,10 @@ type testFiles struct {
 
 	// files/folders that we expect to be skipped due to an overwrite setting
 	shouldSkip []interface{}
+
+	// files that are created only at the destination, so that sync's delete-destination option has something to delete.
+	// Whether each is expected to be deleted depends on the deleteDestination param (and, for prompt, on the confirmDeletion hook)
+	destinationOnly []interface{}
 }
 
 func (tf testFiles) cloneShouldTransfers() testFiles {
@@ -347,6 +352,7 @@ func (tf testFiles) DeepCopy() testFiles {
 	ret.shouldIgnore = tf.copyList(tf.shouldIgnore)
 	ret.shouldFail = tf.copyList(tf.shouldFail)
 	ret.shouldSkip = tf.copyList(tf.shouldSkip)
+	ret.destinationOnly = tf.copyList(tf.destinationOnly)
 	return ret
 }
 
@@ -393,8 +399,9 @@ func (tf *testFiles) allObjects(isSource bool) []*testObject {
 		result = append(result, tf.toTestObjects(tf.shouldFail, true)...)    // these must also be present at the source. Their transferring is expected to fail
 		return result
 	}
-	// destination only needs the things that overwrite will skip
-	return tf.toTestObjects(tf.shouldSkip, false)
+	// destination only needs the things that overwrite will skip, and the things that don't exist at the source
+	result := tf.toTestObjects(tf.shouldSkip, false)
+	return append(result, tf.toTestObjects(tf.destinationOnly, false)...)
 }
 
 func (tf *testFiles) getForStatus(status common.TransferStatus, expectFolders bool, expectRootFolder bool) []*testObject {
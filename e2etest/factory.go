@@ -22,19 +22,27 @@ package e2etest
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	gcpUtils "cloud.google.com/go/storage"
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/ste"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/azure-storage-file-go/azfile"
 	"github.com/google/uuid"
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
 )
 
 // provide convenient methods to get access to test resources such as accounts, containers/shares, directories
@@ -60,8 +68,27 @@ func (TestResourceFactory) GetFileServiceURL(accountType AccountType) azfile.Ser
 	if err != nil {
 		panic(err)
 	}
-	pipeline := azfile.NewPipeline(credential, azfile.PipelineOptions{})
-	return azfile.NewServiceURL(*u, pipeline)
+	return azfile.NewServiceURL(*u, newTestFilePipeline(credential))
+}
+
+// newTestFilePipeline is azfile.NewPipeline, plus the policy that AzCopy uses to keep trailing dots in names, switched on
+// for every request, so that tests can create and check files such as "file.". It comes before the credential, which signs the headers it adds
+func newTestFilePipeline(c azfile.Credential) pipeline.Pipeline {
+	f := []pipeline.Factory{
+		azfile.NewTelemetryPolicyFactory(azfile.TelemetryOptions{}),
+		azfile.NewUniqueRequestIDPolicyFactory(),
+		azfile.NewRetryPolicyFactory(azfile.RetryOptions{}),
+		pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+			return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+				return next.Do(context.WithValue(ctx, ste.AllowTrailingDot, true), request)
+			}
+		}),
+		ste.NewTrailingDotPolicyFactory(),
+		c,
+		azfile.NewRequestLogPolicyFactory(azfile.RequestLogOptions{}),
+		pipeline.MethodFactoryMarker(),
+	}
+	return pipeline.NewPipeline(f, pipeline.Options{})
 }
 
 func (TestResourceFactory) GetDatalakeServiceURL(accountType AccountType) azbfs.ServiceURL {
@@ -177,6 +204,41 @@ func (TestResourceFactory) CreateNewFileShare(c asserter, accountType AccountTyp
 	return fileShare, name, TestResourceFactory{}.GetFileShareULWithSAS(c, accountType, name).URL()
 }
 
+func (TestResourceFactory) CreateNewGCPBucket(c asserter) (bucket *gcpUtils.BucketHandle, name string, rawURL url.URL) {
+	client, err := gcpUtils.NewClient(context.Background())
+	c.AssertNoErr(err)
+
+	name = TestResourceNameGenerator{}.GenerateContainerName(c)
+	bucket = client.Bucket(name)
+	err = bucket.Create(context.Background(), GlobalInputManager{}.GetGCPProjectID(), nil)
+	c.AssertNoErr(err)
+	return bucket, name, url.URL{Scheme: "https", Host: "storage.cloud.google.com", Path: "/" + name}
+}
+
+// CreateNewS3CompatibleBucket creates a bucket in the S3-compatible service given by AZCOPY_E2E_S3_ENDPOINT. Its URL is path-style,
+// since self-hosted services seldom give buckets DNS names of their own
+func (TestResourceFactory) CreateNewS3CompatibleBucket(c asserter) (client *minio.Client, name string, rawURL url.URL) {
+	endpoint, err := url.Parse(GlobalInputManager{}.GetS3CompatibleEndpoint())
+	c.AssertNoErr(err)
+
+	client, err = minio.NewWithOptions(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	c.AssertNoErr(err)
+	if (GlobalInputManager{}).S3SkipSSLVerify() {
+		transport := minio.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		client.SetCustomTransport(transport)
+	}
+
+	name = TestResourceNameGenerator{}.GenerateContainerName(c)
+	c.AssertNoErr(client.MakeBucket(name, ""))
+	return client, name, url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: "/" + name}
+}
+
 func (TestResourceFactory) CreateNewFileShareSnapshot(c asserter, fileShare azfile.ShareURL) (snapshotID string) {
 	resp, err := fileShare.CreateSnapshot(context.TODO(), azfile.Metadata{})
 	c.AssertNoErr(err)
@@ -34,8 +34,10 @@ import (
 	"strings"
 	"time"
 
+	gcpUtils "cloud.google.com/go/storage"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/minio/minio-go/pkg/credentials"
+	"google.golang.org/api/iterator"
 	chk "gopkg.in/check.v1"
 
 	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
@@ -283,7 +285,7 @@ func newNullFolderCreationTracker() ste.FolderCreationTracker {
 func generateParentsForAzureFile(c asserter, fileURL azfile.FileURL) {
 	accountName, accountKey := GlobalInputManager{}.GetAccountAndKey(EAccountType.Standard())
 	credential, _ := azfile.NewSharedKeyCredential(accountName, accountKey)
-	err := ste.AzureFileParentDirCreator{}.CreateParentDirToRoot(ctx, fileURL, azfile.NewPipeline(credential, azfile.PipelineOptions{}), newNullFolderCreationTracker())
+	err := ste.AzureFileParentDirCreator{}.CreateParentDirToRoot(ctx, fileURL, newTestFilePipeline(credential), newNullFolderCreationTracker())
 	c.AssertNoErr(err)
 }
 
@@ -316,6 +318,20 @@ func deleteContainer(c asserter, container azblob.ContainerURL) {
 	c.Assert(resp.StatusCode(), equals(), 202)
 }
 
+func deleteGCPBucket(c asserter, bucket *gcpUtils.BucketHandle) {
+	// a bucket can only be deleted once it is empty
+	it := bucket.Objects(ctx, nil)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		c.AssertNoErr(err)
+		c.AssertNoErr(bucket.Object(attrs.Name).Delete(ctx))
+	}
+	c.AssertNoErr(bucket.Delete(ctx))
+}
+
 // nolint
 func deleteFilesystem(c asserter, filesystem azbfs.FileSystemURL) {
 	resp, err := filesystem.Delete(ctx)
@@ -571,7 +587,7 @@ func getFileServiceURLWithSAS(c asserter, credential azfile.SharedKeyCredential)
 	fullURL, err := url.Parse(rawURL)
 	c.AssertNoErr(err)
 
-	return azfile.NewServiceURL(*fullURL, azfile.NewPipeline(azfile.NewAnonymousCredential(), azfile.PipelineOptions{}))
+	return azfile.NewServiceURL(*fullURL, newTestFilePipeline(azfile.NewAnonymousCredential()))
 }
 
 // nolint
@@ -594,7 +610,7 @@ func getShareURLWithSAS(c asserter, credential azfile.SharedKeyCredential, share
 	c.AssertNoErr(err)
 
 	// TODO perhaps we need a global default pipeline
-	return azfile.NewShareURL(*fullURL, azfile.NewPipeline(azfile.NewAnonymousCredential(), azfile.PipelineOptions{}))
+	return azfile.NewShareURL(*fullURL, newTestFilePipeline(azfile.NewAnonymousCredential()))
 }
 
 // nolint
@@ -21,13 +21,17 @@
 package e2etest
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
@@ -36,6 +40,13 @@ import (
 // the flag names should be captured here so that in case they change, only 1 place needs to be updated
 type TestRunner struct {
 	flags map[string]string
+	env   []string // environment variables, as name=value, to set on top of our own environment
+
+	// answers the prompts that AzCopy writes to stdout. Returns the response to send to AzCopy's stdin
+	promptResponder func(details common.PromptDetails) string
+
+	// receives, in order, the structured progress events that AzCopy writes to stdout
+	progressEventHandler func(e common.ProgressEvent)
 }
 
 func newTestRunner() TestRunner {
@@ -53,6 +64,9 @@ var isLaunchedByDebugger = func() bool {
 }()
 
 func (t *TestRunner) SetAllFlags(p params, o Operation) {
+	// the environment variables come from the params too, so those of an earlier call mustn't carry over to this one
+	t.env = nil
+
 	set := func(key string, value interface{}, dflt interface{}, formats ...string) {
 		if value == dflt {
 			return // nothing to do. The flag is not supposed to be set
@@ -69,35 +83,121 @@ func (t *TestRunner) SetAllFlags(p params, o Operation) {
 	// TODO: TODO: nakulkar-msft there will be many more to add here
 	set("recursive", p.recursive, false)
 	set("as-subdir", !p.invertedAsSubdir, true)
+	set("strip-top-dir", p.stripTopDirFlag, "")
 	set("include-path", p.includePath, "")
+	set("list-of-files", p.listOfFiles, "")
 	set("exclude-path", p.excludePath, "")
 	set("include-pattern", p.includePattern, "")
 	set("exclude-pattern", p.excludePattern, "")
 	set("include-after", p.includeAfter, "")
+	set("include-before", p.includeBefore, "")
+	set("min-size", p.minSize, "")
+	set("max-size", p.maxSize, "")
+	set("include-regex", p.includeRegex, "")
+	set("exclude-regex", p.excludeRegex, "")
+	set("include-ext", p.includeExt, "")
+	set("exclude-ext", p.excludeExt, "")
+	set("ignore-case-pattern", p.ignoreCasePattern, false)
+	set("include-attributes", p.includeAttributes, "")
+	set("exclude-attributes", p.excludeAttributes, "")
 	set("include-pattern", p.includePattern, "")
 	set("exclude-path", p.excludePath, "")
 	set("exclude-pattern", p.excludePattern, "")
 	set("cap-mbps", p.capMbps, float32(0))
+	set("auto-tune-throughput", p.autoTuneThroughput, false)
 	set("block-size-mb", p.blockSizeMB, float32(0))
 	set("s2s-detect-source-changed", p.s2sSourceChangeValidation, false)
 	set("metadata", p.metadata, "")
 	set("cancel-from-stdin", p.cancelFromStdin, false)
 	set("preserve-smb-info", p.preserveSMBInfo, false)
 	set("preserve-smb-permissions", p.preserveSMBPermissions, false)
+	set("preserve-permissions", p.preservePermissions, false)
 	set("backup", p.backupMode, false)
 	set("blob-tags", p.blobTags, "")
+	set("include-blob-tags", p.includeBlobTags, "")
 	set("blob-type", p.blobType, "")
 	set("s2s-preserve-blob-tags", p.s2sPreserveBlobTags, false)
 	set("cpk-by-name", p.cpkByName, "")
 	set("cpk-by-value", p.cpkByValue, false)
 	set("is-object-dir", p.isObjectDir, false)
 	set("debug-skip-files", strings.Join(p.debugSkipFiles, ";"), "")
+	set("checkpoint-path", p.checkpointPath, "")
+	set("put-md5", p.putMd5, false)
 	set("check-md5", p.checkMd5.String(), "FailIfDifferent")
-	if o == eOperation.Copy() {
+	set("dry-run", p.dryRun, false)
+	if p.quiet {
+		set("output-level", "quiet", "")
+	}
+	if p.enumerationParallelism != 0 {
+		t.env = append(t.env, common.EEnvironmentVariable.EnumerationPoolSize().Name+"="+strconv.Itoa(p.enumerationParallelism))
+	}
+	if o == eOperation.Copy() || o == eOperation.Move() {
 		set("s2s-preserve-access-tier", p.s2sPreserveAccessTier, true)
+		set("block-blob-tier", p.blockBlobTier.String(), common.EBlockBlobTier.None().String())
+		set("overwrite", p.overwrite.String(), common.EOverwriteOption.True().String())
 		set("preserve-posix-properties", p.preservePOSIXProperties, "")
-	} else if o == eOperation.Sync() {
+		set("preserve-xattrs", p.preserveXattrs, false)
+		set("follow-symlinks", p.symlinkHandling == common.ESymlinkHandlingType.Follow(), false)
+		set("preserve-symlinks", p.symlinkHandling == common.ESymlinkHandlingType.Preserve(), false)
+		set("restrict-symlinks-to-root", !p.followSymlinksOutsideRoot, true)
+		set("exclude-container", p.excludeContainer, "")
+		set("list-versions", p.listVersions, false)
+		set("max-versions-per-blob", p.maxVersionsPerBlob, uint(0))
+		set("change-token", p.changeToken, "")
+		set("since-change-token", p.sinceChangeToken, "")
+		set("include-owner", p.includeOwner, "")
+		set("exclude-owner", p.excludeOwner, "")
+		set("snapshot-source-first", p.snapshotSourceFirst, false)
+		set("verify-crc64", p.verifyCrc64, false)
+		set("metadata-only", p.metadataOnly, false)
+		set("if-match", p.ifMatch, "")
+		set("if-none-match", p.ifNoneMatch, "")
+		set("immutability-policy-until", p.immutabilityPolicyUntil, "")
+		set("legal-hold", p.legalHold, false)
+		set("destination-archive", p.destinationArchive.String(), common.EArchiveFormat.None().String())
+		set("source-archive", p.sourceArchive.String(), common.EArchiveFormat.None().String())
+		set("flatten", p.flatten, false)
+		set("flatten-collisions", p.flattenCollisions.String(), common.EFlattenCollisionPolicy.Fail().String())
+		set("preserve-empty-directories", p.preserveEmptyDirectories, false)
+		set("empty-directory-marker", p.emptyDirectoryMarker, "")
+		set("decompress", p.decompress, false)
+		set("exclude-root-folder", p.excludeRootFolder, false)
+		set("destination-time-tokens", p.destinationTimeTokens, false)
+		set("trailing-dot", p.trailingDot.String(), common.ETrailingDotOption.Enable().String())
+		set("content-type", p.contentType, "")
+		set("content-encoding", p.contentEncoding, "")
+		set("content-language", p.contentLanguage, "")
+		set("content-disposition", p.contentDisposition, "")
+		set("cache-control", p.cacheControl, "")
+		set("blob-type-by-pattern", p.blobTypeByPattern, "")
+		set("s3-endpoint", p.s3Endpoint, "")
+		set("s3-force-path-style", p.s3ForcePathStyle, false)
+		set("s3-skip-ssl-verify", p.s3SkipSSLVerify, false)
+		set("no-guess-mime-type-from-extension", p.noGuessMimeTypeFromExt, false)
+		set("content-type-map", p.contentTypeMapFile, "")
+		set("default-content-type", p.defaultContentType, "")
+		set("preserve-last-modified-time", p.preserveLMT, false)
+		set("max-file-count", p.maxFileCount, uint64(0))
+		set("min-depth", p.minDepth, uint(0))
+		set("max-depth", p.maxDepth, uint(0))
+		set("skip-existing-with-same-size", p.skipExistingSameSize, false)
+		set("per-transfer-timeout", p.perTransferTimeout, time.Duration(0))
+		set("manifest-output", p.manifestOutput, "")
+		set("max-errors", p.maxErrors, uint32(0))
+		set("include-metadata", p.includeMetadata, "")
+		set("exclude-metadata", p.excludeMetadata, "")
+		set("include-content-type", p.includeContentType, "")
+		set("exclude-content-type", p.excludeContentType, "")
+		set("list-of-urls", p.listOfUrls, "")
+		set("keep-from-url", p.keepFromUrl, "")
+	} else if o == eOperation.Sync() || o == eOperation.Mirror() || o == eOperation.Compare() {
+		set("mirror", o == eOperation.Mirror(), false)
+		set("compare-only", o == eOperation.Compare(), false)
 		set("preserve-posix-properties", p.preservePOSIXProperties, false)
+		set("delete-destination", p.deleteDestination.String(), common.EDeleteDestination.False().String())
+		set("compare-by", p.syncComparator.String(), common.ESyncComparator.LastModifiedTime().String())
+		set("exclude-if-destination-newer", p.excludeIfDestinationNewer, false)
+		set("sync-state", p.syncStatePath, "")
 	}
 }
 
@@ -105,6 +205,17 @@ func (t *TestRunner) SetAwaitOpenFlag() {
 	t.flags["await-open"] = "true"
 }
 
+// SetPromptResponder makes the runner answer each prompt from AzCopy with whatever the responder returns
+func (t *TestRunner) SetPromptResponder(responder func(details common.PromptDetails) string) {
+	t.promptResponder = responder
+}
+
+// SetProgressEventHandler asks AzCopy for structured progress events, and passes each one to the handler as it is written
+func (t *TestRunner) SetProgressEventHandler(handler func(e common.ProgressEvent)) {
+	t.flags["progress-events"] = "true"
+	t.progressEventHandler = handler
+}
+
 func (t *TestRunner) computeArgs() []string {
 	args := make([]string, 0)
 	for key, value := range t.flags {
@@ -138,6 +249,36 @@ func (t *TestRunner) execDebuggableWithOutput(name string, args []string, env []
 	c.Stdout = &stdout
 	c.Stderr = &stderr
 
+	// if we answer prompts or pass on events, we must watch stdout as it is written, rather than just reading it all at the end
+	if t.promptResponder != nil || t.progressEventHandler != nil {
+		watchedStdout, stdoutWatcher := io.Pipe()
+		c.Stdout = io.MultiWriter(&stdout, stdoutWatcher)
+		watcherDone := make(chan struct{})
+		go func() {
+			defer close(watcherDone)
+			scanner := bufio.NewScanner(watchedStdout)
+			for scanner.Scan() {
+				msg := common.JsonOutputTemplate{}
+				if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+					continue
+				}
+				if msg.MessageType == "Prompt" && t.promptResponder != nil {
+					_, _ = stdin.Write([]byte(t.promptResponder(msg.PromptDetails) + "\n"))
+				} else if msg.MessageType == "ProgressEvent" && t.progressEventHandler != nil {
+					e := common.ProgressEvent{}
+					if json.Unmarshal([]byte(msg.MessageContent), &e) == nil {
+						t.progressEventHandler(e)
+					}
+				}
+			}
+			_, _ = io.Copy(ioutil.Discard, watchedStdout) // keep AzCopy's output flowing, even if a line was too long to scan
+		}()
+		defer func() {
+			_ = stdoutWatcher.Close()
+			<-watcherDone // so that every event has been handled by the time we return
+		}()
+	}
+
 	// instead of err := c.Run(), we do the following
 	runErr := c.Start()
 	if runErr == nil {
@@ -197,10 +338,12 @@ func (t *TestRunner) ExecuteAzCopyCommand(operation Operation, src, dst string,
 	switch operation {
 	case eOperation.Copy():
 		verb = "copy"
-	case eOperation.Sync():
+	case eOperation.Sync(), eOperation.Mirror(), eOperation.Compare():
 		verb = "sync"
 	case eOperation.Remove():
 		verb = "remove"
+	case eOperation.Move():
+		verb = "move"
 	case eOperation.Resume():
 		verb = "jobs resume"
 	default:
@@ -212,12 +355,18 @@ func (t *TestRunner) ExecuteAzCopyCommand(operation Operation, src, dst string,
 		args = args[:2]
 	} else if operation == eOperation.Resume() {
 		args = args[:3]
+		if src == "" {
+			args = args[:2] // the job is found from its checkpoint folder, instead of by its ID
+		}
+	} else if src == "" {
+		args = append(strings.Split(verb, " "), dst) // the sources are listed in a file, e.g. with list-of-urls
 	}
 	args = append(args, t.computeArgs()...)
 
 	// pass along existing environment variables (because $HOME doesn't come along if we just use the OAuth vars, that can be troublesome!)
 	env := make([]string, len(os.Environ()))
 	copy(env, os.Environ())
+	env = append(env, t.env...)
 
 	// paste in OAuth environment variables
 	if needsOAuth {
@@ -247,12 +396,22 @@ func (t *TestRunner) ExecuteAzCopyCommand(operation Operation, src, dst string,
 		}
 	}
 
+	if wasClean && t.flags["output-level"] == "quiet" {
+		// nothing is printed, so there is only the exit code to go on
+		r := CopyOrSyncCommandResult{}
+		if ee, ok := err.(*exec.ExitError); ok {
+			r.exitCode = ee.ExitCode()
+		}
+		return r, true, err
+	}
+
 	if wasClean {
 		// either it succeeded, for it returned a failure code in a clean (non-panic) way.
 		// In both cases, we want out to be parsed, to get us the job ID.  E.g. maybe 1 transfer out of several failed,
 		// and that's what we'er actually testing for (so can't treat this as a fatal error).
 		r, ok := newCopyOrSyncCommandResult(string(out))
 		if ok {
+			r.checkpointPath = t.flags["checkpoint-path"]
 			return r, true, err
 		} else {
 			err = fmt.Errorf("could not parse AzCopy output. Run error, if any, was '%w'", err)
@@ -279,8 +438,20 @@ func (t *TestRunner) ExecuteJobsShowCommand(jobID common.JobID) (JobsShowCommand
 }
 
 type CopyOrSyncCommandResult struct {
-	jobID       common.JobID
-	finalStatus common.ListSyncJobSummaryResponse
+	jobID          common.JobID
+	finalStatus    common.ListSyncJobSummaryResponse
+	checkpointPath string // where the job's plan files are, if not in the usual location
+	exitCode       int    // only set for runs with --output-level=quiet, which print nothing else
+
+	// the transfers that a dry run printed. A dry run has no job, so jobID and finalStatus are not set
+	isDryrun        bool
+	dryrunTransfers []common.CopyTransfer
+	dryrunSummary   *common.DryrunSummary // only for the dry runs that report totals at the end
+	// the same totals, along with the files that a dry run of sync would add, update and delete. Those are 0 for other commands
+	syncDryrunSummary *common.SyncDryrunSummary
+
+	// the files sorted by how they compare, for a sync with --compare-only. Like a dry run, it has no job
+	compareReport *common.SyncCompareReport
 }
 
 func newCopyOrSyncCommandResult(rawOutput string) (CopyOrSyncCommandResult, bool) {
@@ -298,18 +469,52 @@ func newCopyOrSyncCommandResult(rawOutput string) (CopyOrSyncCommandResult, bool
 		return CopyOrSyncCommandResult{}, false
 	}
 
+	// a dry run prints one message per planned transfer, and then exits without a job summary
+	dryrunTransfers := make([]common.CopyTransfer, 0)
+	for _, line := range lines {
+		msg := common.JsonOutputTemplate{}
+		if json.Unmarshal([]byte(line), &msg) != nil || msg.MessageType != "Dryrun" {
+			continue
+		}
+		transfer := common.CopyTransfer{}
+		if err = json.Unmarshal([]byte(msg.MessageContent), &transfer); err != nil {
+			return CopyOrSyncCommandResult{}, false
+		}
+		dryrunTransfers = append(dryrunTransfers, transfer)
+	}
+	if finalMsg.MessageType == "EndOfJob" && finalMsg.MessageContent == "" {
+		return CopyOrSyncCommandResult{isDryrun: true, dryrunTransfers: dryrunTransfers}, true
+	}
+
 	jobSummary := common.ListSyncJobSummaryResponse{} // this is a superset of ListJobSummaryResponse, so works for both copy and sync
 	err = json.Unmarshal([]byte(finalMsg.MessageContent), &jobSummary)
 	if err != nil {
 		return CopyOrSyncCommandResult{}, false
 	}
 
+	// the totals that end a dry run have no JobID, unlike a job summary, and neither does the report that ends a comparison.
+	// The report's lists are always there, even when empty, so it's the only end message to have OnlyInSource
+	if finalMsg.MessageType == "EndOfJob" && jobSummary.JobID == (common.JobID{}) {
+		compareReport := &common.SyncCompareReport{}
+		if json.Unmarshal([]byte(finalMsg.MessageContent), compareReport) == nil && compareReport.OnlyInSource != nil {
+			return CopyOrSyncCommandResult{compareReport: compareReport}, true
+		}
+		dryrunSummary := &common.SyncDryrunSummary{} // a superset of DryrunSummary, so works for every command
+		if err = json.Unmarshal([]byte(finalMsg.MessageContent), dryrunSummary); err != nil {
+			return CopyOrSyncCommandResult{}, false
+		}
+		return CopyOrSyncCommandResult{isDryrun: true, dryrunTransfers: dryrunTransfers, dryrunSummary: &dryrunSummary.DryrunSummary, syncDryrunSummary: dryrunSummary}, true
+	}
+
 	return CopyOrSyncCommandResult{jobID: jobSummary.JobID, finalStatus: jobSummary}, true
 }
 
 func (c *CopyOrSyncCommandResult) GetTransferList(status common.TransferStatus) ([]common.TransferDetail, error) {
 	runner := newTestRunner()
 	runner.SetTransferStatusFlag(status.String())
+	if c.checkpointPath != "" {
+		runner.flags["checkpoint-path"] = c.checkpointPath // that's where the job's plan files are
+	}
 
 	// invoke AzCopy to get the status from the plan files
 	result, err := runner.ExecuteJobsShowCommand(c.jobID)
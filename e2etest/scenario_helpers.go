@@ -26,6 +26,7 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -35,9 +36,11 @@ import (
 	"strings"
 	"time"
 
+	gcpUtils "cloud.google.com/go/storage"
 	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
 	"github.com/Azure/azure-storage-azcopy/v10/sddl"
 	"github.com/minio/minio-go"
+	"google.golang.org/api/iterator"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -510,6 +513,7 @@ func (s scenarioHelper) enumerateContainerBlobProperties(a asserter, containerUR
 			}
 
 			props.blobType = common.FromAzBlobType(blobInfo.Properties.BlobType)
+			props.accessTier = bp.AccessTier
 
 			result[relativePath] = &props
 		}
@@ -520,13 +524,143 @@ func (s scenarioHelper) enumerateContainerBlobProperties(a asserter, containerUR
 	return result
 }
 
-func (s scenarioHelper) downloadBlobContent(a asserter, options downloadContentOptions) []byte {
-	blobURL := options.containerURL.NewBlobURL(options.resourceRelPath)
-	cpk := common.ToClientProvidedKeyOptions(options.cpkInfo, options.cpkScopeInfo)
-	downloadResp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, cpk)
-	a.AssertNoErr(err)
+// create the demanded GCP objects. Like blob, GCP has no real folders
+func (scenarioHelper) generateGCPObjectsFromList(c asserter, bucket *gcpUtils.BucketHandle, options generateFromListOptions) {
+	for _, o := range options.fs {
+		if o.isFolder() {
+			continue
+		}
+		_, sourceData := getRandomDataAndReader(o.creationProperties.sizeBytes(c, options.defaultSize))
+
+		// GCP computes the MD5 itself, and AzCopy carries it over, so expect it at the destination too
+		contentMD5 := md5.Sum(sourceData)
+		if o.creationProperties.contentHeaders == nil {
+			o.creationProperties.contentHeaders = &contentHeaders{}
+		}
+		o.creationProperties.contentHeaders.contentMD5 = contentMD5[:]
+
+		h := o.creationProperties.contentHeaders
+		w := bucket.Object(o.name).NewWriter(ctx)
+		w.ContentType = sval(h.contentType)
+		w.ContentEncoding = sval(h.contentEncoding)
+		w.ContentLanguage = sval(h.contentLanguage)
+		w.ContentDisposition = sval(h.contentDisposition)
+		w.CacheControl = sval(h.cacheControl)
+		w.MD5 = contentMD5[:]
+		w.Metadata = o.creationProperties.nameValueMetadata
+
+		_, err := w.Write(sourceData)
+		c.AssertNoErr(err)
+		c.AssertNoErr(w.Close())
+	}
+
+	// sleep a bit so that the objects' lmts are guaranteed to be in the past
+	time.Sleep(time.Millisecond * 1050)
+}
+
+func (scenarioHelper) enumerateGCPObjectProperties(a asserter, bucket *gcpUtils.BucketHandle) map[string]*objectProperties {
+	result := make(map[string]*objectProperties)
+
+	// the iterator follows the continuation of the listing through all its pages
+	it := bucket.Objects(ctx, nil)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		a.AssertNoErr(err)
+
+		h := contentHeaders{
+			cacheControl:       &attrs.CacheControl,
+			contentDisposition: &attrs.ContentDisposition,
+			contentEncoding:    &attrs.ContentEncoding,
+			contentLanguage:    &attrs.ContentLanguage,
+			contentType:        &attrs.ContentType,
+			contentMD5:         attrs.MD5,
+		}
+		size := attrs.Size
+		lmt := attrs.Updated
+
+		result[attrs.Name] = &objectProperties{
+			isFolder:          false, // no folders in GCP
+			size:              &size,
+			contentHeaders:    &h,
+			nameValueMetadata: attrs.Metadata,
+			lastWriteTime:     &lmt,
+		}
+	}
+
+	return result
+}
 
-	retryReader := downloadResp.Body(azblob.RetryReaderOptions{})
+// create the demanded S3 objects. Like blob, S3 has no real folders
+func (scenarioHelper) generateS3ObjectsFromList(c asserter, client *minio.Client, bucketName string, options generateFromListOptions) {
+	for _, o := range options.fs {
+		if o.isFolder() {
+			continue
+		}
+		size := o.creationProperties.sizeBytes(c, options.defaultSize)
+		r, _ := getRandomDataAndReader(size)
+
+		putOptions := minio.PutObjectOptions{UserMetadata: o.creationProperties.nameValueMetadata}
+		if h := o.creationProperties.contentHeaders; h != nil {
+			putOptions.ContentType = sval(h.contentType)
+			putOptions.ContentEncoding = sval(h.contentEncoding)
+			putOptions.ContentLanguage = sval(h.contentLanguage)
+			putOptions.ContentDisposition = sval(h.contentDisposition)
+			putOptions.CacheControl = sval(h.cacheControl)
+		}
+
+		n, err := client.PutObjectWithContext(ctx, bucketName, o.name, r, int64(size), putOptions)
+		c.AssertNoErr(err)
+		c.Assert(n, equals(), int64(size))
+	}
+
+	// sleep a bit so that the objects' lmts are guaranteed to be in the past
+	time.Sleep(time.Millisecond * 1050)
+}
+
+func (scenarioHelper) enumerateS3ObjectProperties(a asserter, client *minio.Client, bucketName string) map[string]*objectProperties {
+	result := make(map[string]*objectProperties)
+
+	for object := range client.ListObjectsV2(bucketName, "", true, ctx.Done()) {
+		a.AssertNoErr(object.Err)
+
+		// the listing has no headers or metadata, so get them from the object itself
+		info, err := client.StatObject(bucketName, object.Key, minio.StatObjectOptions{})
+		a.AssertNoErr(err)
+		oie := common.ObjectInfoExtension{ObjectInfo: info}
+
+		cacheControl, contentDisposition := oie.CacheControl(), oie.ContentDisposition()
+		contentEncoding, contentLanguage, contentType := oie.ContentEncoding(), oie.ContentLanguage(), oie.ContentType()
+		h := contentHeaders{
+			cacheControl:       &cacheControl,
+			contentDisposition: &contentDisposition,
+			contentEncoding:    &contentEncoding,
+			contentLanguage:    &contentLanguage,
+			contentType:        &contentType,
+		}
+		metadata := make(map[string]string)
+		for k, v := range oie.NewCommonMetadata() {
+			metadata[strings.ToLower(k)] = v // S3 returns the keys capitalised, as HTTP headers
+		}
+		size := info.Size
+		lmt := info.LastModified
+
+		result[object.Key] = &objectProperties{
+			isFolder:          false, // no folders in S3
+			size:              &size,
+			contentHeaders:    &h,
+			nameValueMetadata: metadata,
+			lastWriteTime:     &lmt,
+		}
+	}
+
+	return result
+}
+
+func (s scenarioHelper) downloadBlobContent(a asserter, options downloadContentOptions) []byte {
+	retryReader := s.openBlobContent(a, options)
 	defer retryReader.Close()
 
 	destData, err := ioutil.ReadAll(retryReader)
@@ -534,6 +668,16 @@ func (s scenarioHelper) downloadBlobContent(a asserter, options downloadContentO
 	return destData[:]
 }
 
+// openBlobContent starts a download of the blob, returning its content as a stream
+func (scenarioHelper) openBlobContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	blobURL := options.containerURL.NewBlobURL(options.resourceRelPath)
+	cpk := common.ToClientProvidedKeyOptions(options.cpkInfo, options.cpkScopeInfo)
+	downloadResp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, cpk)
+	a.AssertNoErr(err)
+
+	return downloadResp.Body(azblob.RetryReaderOptions{})
+}
+
 // nolint
 func (scenarioHelper) generatePageBlobsFromList(c asserter, containerURL azblob.ContainerURL, blobList []string, data string) {
 	for _, blobName := range blobList {
@@ -696,7 +840,7 @@ func (scenarioHelper) generateAzureFilesFromList(c asserter, options *generateAz
 				c.AssertNoErr(err)
 			}
 
-			if f.creationProperties.smbPermissionsSddl != nil || f.creationProperties.smbAttributes != nil || f.creationProperties.lastWriteTime != nil {
+			if f.creationProperties.smbPermissionsSddl != nil || f.creationProperties.smbAttributes != nil || f.creationProperties.lastWriteTime != nil || f.creationProperties.creationTime != nil {
 				_, err := dir.SetProperties(ctx, ad.toHeaders(c, options.shareURL).SMBProperties)
 				c.AssertNoErr(err)
 
@@ -714,13 +858,6 @@ func (scenarioHelper) generateAzureFilesFromList(c asserter, options *generateAz
 				}
 			}
 
-			// set other properties
-			// TODO: do we need a SetProperties method on dir...?  Discuss with zezha-msft
-			if f.creationProperties.creationTime != nil {
-				panic("setting these properties isn't implmented yet for folders in the test harnesss")
-				// TODO: nakulkar-msft the attributes stuff will need to be implemented here before attributes can be tested on Azure Files
-			}
-
 			// TODO: I'm pretty sure we don't prserve lastWritetime or contentProperties (headers) for folders, so the above if statement doesn't test those
 			//    Is that the correct decision?
 		} else {
@@ -754,7 +891,7 @@ func (scenarioHelper) generateAzureFilesFromList(c asserter, options *generateAz
 				c.Failed()
 			}
 
-			if f.creationProperties.smbPermissionsSddl != nil || f.creationProperties.smbAttributes != nil || f.creationProperties.lastWriteTime != nil {
+			if f.creationProperties.smbPermissionsSddl != nil || f.creationProperties.smbAttributes != nil || f.creationProperties.lastWriteTime != nil || f.creationProperties.creationTime != nil {
 				/*
 					via Jason Shay:
 					Providing securityKey/SDDL during 'PUT File' and 'PUT Properties' can and will provide different results/semantics.
@@ -903,19 +1040,23 @@ func (s scenarioHelper) enumerateShareFileProperties(a asserter, shareURL azfile
 }
 
 func (s scenarioHelper) downloadFileContent(a asserter, options downloadContentOptions) []byte {
-	fileURL := options.shareURL.NewRootDirectoryURL().NewFileURL(options.resourceRelPath)
-	downloadResp, err := fileURL.Download(ctx, 0, azfile.CountToEnd, false)
-	a.AssertNoErr(err)
-
-	retryReader := downloadResp.Body(azfile.RetryReaderOptions{})
+	retryReader := s.openFileContent(a, options)
 	defer retryReader.Close() // The client must close the response body when finished with it
 
 	destData, err := ioutil.ReadAll(retryReader)
 	a.AssertNoErr(err)
-	downloadResp.Body(azfile.RetryReaderOptions{})
 	return destData
 }
 
+// openFileContent starts a download of the Azure file, returning its content as a stream
+func (scenarioHelper) openFileContent(a asserter, options downloadContentOptions) io.ReadCloser {
+	fileURL := options.shareURL.NewRootDirectoryURL().NewFileURL(options.resourceRelPath)
+	downloadResp, err := fileURL.Download(ctx, 0, azfile.CountToEnd, false)
+	a.AssertNoErr(err)
+
+	return downloadResp.Body(azfile.RetryReaderOptions{})
+}
+
 // nolint
 func (scenarioHelper) generateBFSPathsFromList(c asserter, filesystemURL azbfs.FileSystemURL, fileList []string) {
 	for _, bfsPath := range fileList {
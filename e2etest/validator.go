@@ -55,7 +55,7 @@ func (Validator) ValidateRemoveTransfer(c asserter, isSrcEncoded bool, isDstEnco
 	// TODO: Think of how to validate files in case of remove
 }
 func (Validator) ValidateCopyTransfersAreScheduled(c asserter, isSrcEncoded bool, isDstEncoded bool,
-	sourcePrefix string, destinationPrefix string, expectedTransfers []*testObject, actualTransfers []common.TransferDetail, statusToTest common.TransferStatus, fromTo common.FromTo, srcAccountType, dstAccountType AccountType) {
+	sourcePrefix string, destinationPrefix string, expectedTransfers []*testObject, actualTransfers []common.TransferDetail, statusToTest common.TransferStatus, fromTo common.FromTo, srcAccountType, dstAccountType AccountType, rewritePath pathRewriteFunc) {
 
 	sourcePrefix = makeSlashesComparable(sourcePrefix)
 	destinationPrefix = makeSlashesComparable(destinationPrefix)
@@ -122,8 +122,12 @@ func (Validator) ValidateCopyTransfersAreScheduled(c asserter, isSrcEncoded bool
 		}
 
 		if transfer.Dst != os.DevNull { // Don't check if the destination is NUL-- It won't be correct.
-			// the relative paths should be equal
-			c.Assert(srcRelativeFilePath, equals(), dstRelativeFilePath)
+			// the relative paths should be equal, unless the destination path was rewritten
+			expectedDstRelativeFilePath := srcRelativeFilePath
+			if rewritePath != nil && srcRelativeFilePath != "" {
+				expectedDstRelativeFilePath = rewritePath(srcRelativeFilePath)
+			}
+			c.Assert(dstRelativeFilePath, equals(), expectedDstRelativeFilePath)
 		}
 
 		// look up the path from the expected transfers, make sure it exists
@@ -21,6 +21,8 @@
 package e2etest
 
 import (
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -336,3 +338,29 @@ func TestBasic_CopyWithShareRoot(t *testing.T) {
 		"",
 	)
 }
+
+// ================================  Sync: Delete Destination  =========================================================
+func TestBasic_SyncPromptsBeforeDeletingExtraDestinationFiles(t *testing.T) {
+	RunScenarios(t, eOperation.Sync(), eTestFromTo.Other(common.EFromTo.LocalBlob(), common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		deleteDestination: common.EDeleteDestination.Prompt(),
+	}, &hooks{
+		confirmDeletion: func(h hookHelper, relativePath string) bool {
+			return strings.HasPrefix(path.Base(relativePath), "approved")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("file1.txt"),
+			folder("fldr"),
+			f("fldr/file2.txt"),
+		},
+		destinationOnly: []interface{}{
+			f("approved1.txt"),
+			f("denied1.txt"),
+			f("fldr/approved2.txt"),
+			f("fldr/denied2.txt"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
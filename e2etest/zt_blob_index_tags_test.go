@@ -23,6 +23,7 @@ package e2etest
 import (
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"testing"
+	"time"
 )
 
 // ================================  Copy: Setting Tags ========================================================
@@ -201,3 +202,96 @@ func TestTags_PreserveTagsSpecialCharactersDuringSync(t *testing.T) {
 			},
 		}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
+
+// ================================  Copy: Filtering by Tags ========================================================
+func TestTags_IncludeBlobTags(t *testing.T) {
+	RunScenarios(
+		t,
+		eOperation.Copy(),
+		eTestFromTo.Other(common.EFromTo.BlobBlob()),
+		eValidate.Auto(),
+		anonymousAuthOnly,
+		anonymousAuthOnly,
+		params{
+			recursive:           true,
+			includeBlobTags:     "\"project\" = 'alpha' AND \"year\" >= '2021'",
+			s2sPreserveBlobTags: true,
+		}, &hooks{
+			beforeRunJob: func(h hookHelper) {
+				// the blob index is updated asynchronously after the tags are set, so give it a moment to catch up
+				time.Sleep(10 * time.Second)
+			},
+		}, testFiles{
+			defaultSize: "1K",
+			shouldIgnore: []interface{}{
+				"untagged.txt",
+				f("otherproject.txt", with{blobTags: "project=beta&year=2021"}),
+				f("tooold.txt", with{blobTags: "project=alpha&year=2020"}),
+			},
+			shouldTransfer: []interface{}{
+				f("file1.txt", with{blobTags: "project=alpha&year=2021"}),
+				f("sub/file2.txt", with{blobTags: "project=alpha&year=2022&extra=tag"}),
+			},
+		}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestTags_PreserveTagsReadBackFromDestination(t *testing.T) {
+	expectedTags := map[string]common.BlobTags{
+		"file1.txt":       {"foo": "bar", "baz": "blah"},
+		"fdlr1/file2.txt": {"project": "alpha", "year": "2021"},
+	}
+
+	RunScenarios(
+		t,
+		eOperation.Copy(),
+		eTestFromTo.Other(common.EFromTo.BlobBlob()),
+		eValidate.Auto(),
+		anonymousAuthOnly,
+		anonymousAuthOnly,
+		params{
+			recursive:           true,
+			s2sPreserveBlobTags: true,
+		}, &hooks{
+			afterValidation: func(h hookHelper) {
+				// read the tags back from the destination, rather than trusting the job's view of what it wrote
+				props := h.GetDestination().getAllProperties(h.GetAsserter())
+				for name, tags := range expectedTags {
+					p, ok := props[name]
+					h.GetAsserter().Assert(ok, equals(), true, "destination blob "+name+" should exist")
+					if ok {
+						h.GetAsserter().Assert(p.blobTags, equals(), tags, "tags of destination blob "+name)
+					}
+				}
+			},
+		}, testFiles{
+			defaultSize: "1K",
+			shouldTransfer: []interface{}{
+				folder(""),
+				folder("fdlr1"),
+				f("file1.txt", with{blobTags: "foo=bar&baz=blah"}),
+				f("fdlr1/file2.txt", with{blobTags: "project=alpha&year=2021"}),
+			},
+		}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestTags_PreserveTagsIgnoredForNonBlobDestination(t *testing.T) {
+	RunScenarios(
+		t,
+		eOperation.Copy(),
+		eTestFromTo.Other(common.EFromTo.BlobFile(), common.EFromTo.BlobLocal()),
+		eValidate.Auto(),
+		anonymousAuthOnly,
+		anonymousAuthOnly,
+		params{
+			recursive:           true,
+			s2sPreserveBlobTags: true, // only warns, since the destination cannot hold tags
+		}, nil, testFiles{
+			defaultSize: "1K",
+			shouldTransfer: []interface{}{
+				folder(""),
+				"file1.txt",
+				folder("fdlr1"),
+				"fdlr1/file2.txt",
+			},
+		}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
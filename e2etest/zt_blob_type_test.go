@@ -0,0 +1,123 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// assertBlobTypes checks the type of each named blob at the destination
+func assertBlobTypes(expected map[string]common.BlobType) func(h hookHelper) {
+	return func(h hookHelper) {
+		a := h.GetAsserter()
+		props := h.GetDestination().getAllProperties(a)
+		for name, blobType := range expected {
+			p, ok := props[name]
+			a.Assert(ok, equals(), true, "blob "+name+" exists")
+			if ok {
+				a.Assert(p.blobType, equals(), blobType, "type of blob "+name)
+			}
+		}
+	}
+}
+
+func TestBlobType_UploadAsEachType(t *testing.T) {
+	for _, blobType := range []common.BlobType{common.EBlobType.BlockBlob(), common.EBlobType.AppendBlob(), common.EBlobType.PageBlob()} {
+		blobType := blobType
+		t.Run(blobType.String(), func(t *testing.T) {
+			RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+				recursive: true,
+				blobType:  blobType.String(),
+			}, &hooks{
+				afterValidation: assertBlobTypes(map[string]common.BlobType{"data.bin": blobType}),
+			}, testFiles{
+				defaultSize: "1K", // a whole number of pages, so that it can be a page blob
+				shouldTransfer: []interface{}{
+					"data.bin",
+				},
+			}, EAccountType.Standard(), EAccountType.Standard(), "")
+		})
+	}
+}
+
+func TestBlobType_DetectUploadsVHDsAsPageBlobs(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		blobType:  common.EBlobType.Detect().String(),
+	}, &hooks{
+		afterValidation: assertBlobTypes(map[string]common.BlobType{
+			"disk.vhd": common.EBlobType.PageBlob(),
+			"data.bin": common.EBlobType.BlockBlob(),
+		}),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"disk.vhd",
+			"data.bin",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestBlobType_ByPattern(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		blobTypeByPattern: "*.log=AppendBlob;*.tar=BlockBlob",
+		blobType:          common.EBlobType.BlockBlob().String(),
+	}, &hooks{
+		afterValidation: assertBlobTypes(map[string]common.BlobType{
+			"app.log":    common.EBlobType.AppendBlob(),
+			"backup.tar": common.EBlobType.BlockBlob(),
+			"other.txt":  common.EBlobType.BlockBlob(), // matches no pattern, so --blob-type applies
+		}),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"app.log",
+			"backup.tar",
+			"other.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestBlobType_PageBlobOfUnalignedSizeFailsClearly(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		blobTypeByPattern: "*.img=PageBlob",
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// the test files' sizes are always whole KiB, so trim this one to a size that is no whole number of pages
+			srcDir := h.GetSource().getParam(false, false, "")
+			h.GetAsserter().AssertNoErr(os.Truncate(filepath.Join(srcDir, "unaligned.img"), 1000), "trimming unaligned.img")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"aligned.img",
+		},
+		shouldFail: []interface{}{
+			f("unaligned.img", withError{"not a multiple of 512 bytes"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,163 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// TestBlobVersions_EachCopiedToItsOwnName uploads a blob, then writes over it twice so that it has three versions,
+// and checks that --list-versions downloads every one of them, each to a name that starts with its version id
+func TestBlobVersions_EachCopiedToItsOwnName(t *testing.T) {
+	const blobName = "versioned.txt"
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:        true,
+		invertedAsSubdir: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+			blobURL := containerURL.NewBlockBlobURL(blobName)
+
+			for _, content := range []string{"second version", "third version"} {
+				resp, err := blobURL.Upload(ctx, strings.NewReader(content), azblob.BlobHTTPHeaders{}, azblob.Metadata{},
+					azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil, azblob.ClientProvidedKeyOptions{}, azblob.ImmutabilityPolicyOptions{})
+				a.AssertNoErr(err, "writing a new version of "+blobName)
+				if resp.VersionID() == "" {
+					a.Skip("blob versioning is not enabled on the test account")
+					return
+				}
+			}
+
+			// find what each version holds
+			expected := map[string]string{}
+			listResp, err := containerURL.ListBlobsFlatSegment(ctx, azblob.Marker{}, azblob.ListBlobsSegmentOptions{
+				Prefix: blobName, Details: azblob.BlobListingDetails{Versions: true}})
+			a.AssertNoErr(err, "listing the versions of "+blobName)
+			for _, item := range listResp.Segment.BlobItems {
+				if item.Name != blobName || item.VersionID == nil {
+					continue
+				}
+				downloadResp, err := blobURL.WithVersionID(*item.VersionID).Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+				a.AssertNoErr(err, "downloading version "+*item.VersionID)
+				content, err := ioutil.ReadAll(downloadResp.Body(azblob.RetryReaderOptions{}))
+				a.AssertNoErr(err, "reading version "+*item.VersionID)
+				expected[strings.ReplaceAll(*item.VersionID, ":", "-")+"-"+blobName] = string(content)
+			}
+			a.Assert(len(expected), equals(), 3, "versions of "+blobName)
+
+			// both a single blob and its container list the same versions
+			for _, source := range []string{h.GetDestination().getParam(false, true, blobName), h.GetDestination().getParam(false, true, "")} {
+				downloadDir := TestResourceFactory{}.CreateLocalDirectory(a)
+				defer os.RemoveAll(downloadDir)
+
+				// the scenario can't expect one source file to become several, so the versions are downloaded by a run of their own
+				result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, listVersions: true}, source, downloadDir)
+				a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "downloading the versions")
+				a.Assert(result.finalStatus.TransfersCompleted, equals(), uint32(len(expected)), "each version is a transfer of its own")
+
+				for name, content := range expected {
+					actual, err := ioutil.ReadFile(filepath.Join(downloadDir, name))
+					a.AssertNoErr(err, "reading downloaded version "+name)
+					a.Assert(string(actual), equals(), content, "content of downloaded version "+name)
+				}
+				_, err := os.Stat(filepath.Join(downloadDir, blobName))
+				a.Assert(os.IsNotExist(err), equals(), true, "no version should be written under the blob's own name")
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			blobName,
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestBlobVersions_OnlyNewestAreCopied gives a blob five versions, and checks that --max-versions-per-blob=2 downloads
+// just the two with the latest version ids
+func TestBlobVersions_OnlyNewestAreCopied(t *testing.T) {
+	const blobName = "versioned.txt"
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:        true,
+		invertedAsSubdir: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+			blobURL := containerURL.NewBlockBlobURL(blobName)
+
+			for i := 2; i <= 5; i++ {
+				resp, err := blobURL.Upload(ctx, strings.NewReader(fmt.Sprintf("version %d", i)), azblob.BlobHTTPHeaders{}, azblob.Metadata{},
+					azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil, azblob.ClientProvidedKeyOptions{}, azblob.ImmutabilityPolicyOptions{})
+				a.AssertNoErr(err, "writing a new version of "+blobName)
+				if resp.VersionID() == "" {
+					a.Skip("blob versioning is not enabled on the test account")
+					return
+				}
+			}
+
+			listResp, err := containerURL.ListBlobsFlatSegment(ctx, azblob.Marker{}, azblob.ListBlobsSegmentOptions{
+				Prefix: blobName, Details: azblob.BlobListingDetails{Versions: true}})
+			a.AssertNoErr(err, "listing the versions of "+blobName)
+			versionIDs := make([]string, 0)
+			for _, item := range listResp.Segment.BlobItems {
+				if item.Name == blobName && item.VersionID != nil {
+					versionIDs = append(versionIDs, *item.VersionID)
+				}
+			}
+			a.Assert(len(versionIDs), equals(), 5, "versions of "+blobName)
+			sort.Strings(versionIDs) // version ids are times of the same length, so this is oldest first
+
+			downloadDir := TestResourceFactory{}.CreateLocalDirectory(a)
+			defer os.RemoveAll(downloadDir)
+
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, listVersions: true, maxVersionsPerBlob: 2},
+				h.GetDestination().getParam(false, true, ""), downloadDir)
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "downloading the newest versions")
+			a.Assert(result.finalStatus.TransfersCompleted, equals(), uint32(2), "only the two newest versions are transferred")
+
+			for i, versionID := range versionIDs {
+				_, err := os.Stat(filepath.Join(downloadDir, strings.ReplaceAll(versionID, ":", "-")+"-"+blobName))
+				if i >= len(versionIDs)-2 {
+					a.AssertNoErr(err, "finding downloaded version "+versionID)
+				} else {
+					a.Assert(os.IsNotExist(err), equals(), true, "older version "+versionID+" should not have been downloaded")
+				}
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			blobName,
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,73 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-file-go/azfile"
+)
+
+// TestChangeToken_TransfersOnlyTheFilesChangedSince uploads the older files while saving a change token, and then
+// uploads from that token, which must only transfer the newer files. The first copy leaves out the newer files with
+// include-before, so that the token covers only the files that the filter let through
+func TestChangeToken_TransfersOnlyTheFilesChangedSince(t *testing.T) {
+	tokenDir, err := ioutil.TempDir("", "changetoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tokenDir)
+	tokenPath := filepath.Join(tokenDir, "change.token")
+
+	modified := func(age time.Duration) createOnly {
+		return createOnly{with{lastWriteTime: time.Now().Add(-age)}}
+	}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:        true,
+		sinceChangeToken: tokenPath, // one scenario only, since they would share this file
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			_, err := h.RunAzCopy(eOperation.Copy(), params{
+				recursive:     true,
+				changeToken:   tokenPath,
+				includeBefore: time.Now().UTC().Add(-time.Hour).Format(azfile.ISO8601),
+			}, h.GetSource().getParam(false, false, ""), h.GetDestination().getParam(false, true, ""))
+			a.AssertNoErr(err, "copying the older files")
+			_, err = os.Stat(tokenPath)
+			a.AssertNoErr(err, "the first copy should have saved a change token")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filec",
+			"fold1/filed",
+		},
+		shouldIgnore: []interface{}{
+			f("filea", modified(2*time.Hour)),
+			f("fold1/fileb", modified(2*time.Hour)),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -21,8 +21,13 @@
 package e2etest
 
 import (
-	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
 	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
 // Scenarios to consider for copy
@@ -38,7 +43,7 @@ import (
 func TestClient_ProvidedScopeUpload(t *testing.T) {
 	cpkByName := "blobgokeytestscope"
 	verifyOnlyProps := verifyOnly{with{cpkByName: cpkByName}}
-	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.LocalBlob(), common.EFromTo.LocalFile()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
 		recursive: true,
 		cpkByName: cpkByName,
 	}, nil, testFiles{
@@ -57,6 +62,29 @@ func TestClient_ProvidedScopeUpload(t *testing.T) {
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
 
+// TestClient_ProvidedScopeAppliesToWholeBlocks uploads files in several blocks, as well as in one shot, so that the scope
+// must be given when the block list is committed, and not only when each block is staged
+func TestClient_ProvidedScopeAppliesToWholeBlocks(t *testing.T) {
+	cpkByName := GlobalInputManager{}.GetEncryptionScope()
+	if cpkByName == "" {
+		t.Skip("AZCOPY_E2E_ENCRYPTION_SCOPE is not set")
+	}
+	verifyOnlyProps := verifyOnly{with{cpkByName: cpkByName}}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:   true,
+		cpkByName:   cpkByName,
+		blockSizeMB: 0.25,
+	}, nil, testFiles{
+		defaultSize: "1M", // in 4 blocks
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("multiblock1", verifyOnlyProps),
+			f("dir/multiblock2", verifyOnlyProps),
+			f("oneshot", with{size: "100K"}, verifyOnlyProps),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
 func TestClient_ProvidedScopeS2S(t *testing.T) {
 	cpkByName := "blobgokeytestscope"
 	verifyOnlyProps := verifyOnly{with{cpkByName: cpkByName}}
@@ -148,3 +176,53 @@ func TestClient_ProvidedKeyDownload(t *testing.T) {
 		},
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
+
+// Blobs uploaded with a provided key can be downloaded with the same key, and give back what was uploaded.
+// Without the key, they can't be read at all. This needs a real key in the environment, so it only runs when one is set
+func TestClient_ProvidedKeyRoundTrip(t *testing.T) {
+	if os.Getenv(common.EEnvironmentVariable.CPKEncryptionKey().Name) == "" || os.Getenv(common.EEnvironmentVariable.CPKEncryptionKeySHA256().Name) == "" {
+		t.Skip("set " + common.EEnvironmentVariable.CPKEncryptionKey().Name + " and " + common.EEnvironmentVariable.CPKEncryptionKeySHA256().Name + " to run this test")
+	}
+
+	files := []string{"file1", "dir/file2"}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:  true,
+		cpkByValue: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+			downloadDir := t.TempDir()
+
+			// the scenario uploaded the files under a folder named after the source folder
+			uploaded := h.GetDestination().getParam(false, true, filepath.Base(srcDir))
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, cpkByValue: true}, uploaded, downloadDir)
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "downloading with the key")
+			for _, name := range files {
+				original, err := os.ReadFile(filepath.Join(srcDir, name))
+				a.AssertNoErr(err)
+				downloaded, err := os.ReadFile(filepath.Join(downloadDir, filepath.Base(srcDir), name))
+				a.AssertNoErr(err, "reading "+name+" as downloaded with its key")
+				a.Assert(bytes.Equal(downloaded, original), equals(), true, "the content of "+name+" should survive the round trip")
+			}
+
+			// without the key, AzCopy may not get as far as scheduling the transfer, and so fail as a whole. That is
+			// what RunAzCopy treats as the run going wrong, so this run is made directly
+			withoutKey := filepath.Join(downloadDir, "withoutKey")
+			r := newTestRunner()
+			r.SetAllFlags(params{}, eOperation.Copy())
+			result, wasClean, _ := r.ExecuteAzCopyCommand(eOperation.Copy(), h.GetDestination().getParam(false, true, path.Join(filepath.Base(srcDir), files[0])), withoutKey, false, func() string { return "" }, nil)
+			a.Assert(wasClean && result.finalStatus.TransfersFailed == 0, equals(), false, "downloading without the key should fail")
+			_, err := os.Stat(withoutKey)
+			a.Assert(os.IsNotExist(err), equals(), true, "nothing should be downloaded without the key")
+		},
+	}, testFiles{
+		defaultSize: "100K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			files[0],
+			folder("dir"),
+			files[1],
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
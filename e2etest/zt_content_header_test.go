@@ -21,8 +21,13 @@
 package e2etest
 
 import (
-	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
 var fileExtensions = []string{".exe", ".cpp", ".java", ".py", ".go", ".mp3", ".mp4", ".pdf", ".gzip", ".txt", ".dat", ".bat", ".xlsx"}
@@ -99,3 +104,165 @@ func TestHeader_SourceBlobEmptyBlob(t *testing.T) {
 		},
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
+
+// oddlyNamedFiles have extensions that say something different from their content, or nothing at all
+var oddlyNamedFiles = []string{"page.html", "picture.png", "data.weirdext", "noextension"}
+
+// writePlainTextSources replaces the random content of the source files with plain text, so that what is detected
+// from the content is known. The size stays the same
+func writePlainTextSources(h hookHelper) {
+	srcDir := h.GetSource().getParam(false, false, "")
+	for _, name := range oddlyNamedFiles {
+		err := ioutil.WriteFile(filepath.Join(srcDir, name), []byte(strings.Repeat("abcdefgh", 128)), common.DEFAULT_FILE_PERM)
+		h.GetAsserter().AssertNoErr(err, "writing "+name)
+	}
+}
+
+func contentTypeTestFiles(contentTypeOf func(name string) string) testFiles {
+	files := make([]interface{}, 0, len(oddlyNamedFiles))
+	for _, name := range oddlyNamedFiles {
+		files = append(files, f(name, with{contentType: contentTypeOf(name)}))
+	}
+	return testFiles{
+		defaultSize:    "1K",
+		shouldTransfer: files,
+	}
+}
+
+func TestHeader_ContentTypeGuessedFromExtensionThenContent(t *testing.T) {
+	guessed := map[string]string{
+		"page.html":     "text/html",
+		"picture.png":   "image/png",
+		"data.weirdext": "text/plain", // not a known extension, so detected from the content
+		"noextension":   "text/plain",
+	}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: writePlainTextSources,
+	}, contentTypeTestFiles(func(name string) string { return guessed[name] }), EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestHeader_ContentTypeFromContentOnly(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:              true,
+		noGuessMimeTypeFromExt: true,
+	}, &hooks{
+		beforeRunJob: writePlainTextSources,
+	}, contentTypeTestFiles(func(string) string { return "text/plain" }), EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestHeader_ForcedContentTypeWins checks that a forced content-type is used for every file, whatever its extension
+// or content, and that only the property is affected, not the data
+func TestHeader_ForcedContentTypeWins(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:              true,
+		contentType:            "application/x-forced",
+		noGuessMimeTypeFromExt: true,
+	}, &hooks{
+		beforeRunJob: writePlainTextSources,
+	}, contentTypeTestFiles(func(string) string { return "application/x-forced" }), EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// assertDestinationHeaders checks that every file at the destination has the given content headers and metadata
+func assertDestinationHeaders(expected contentHeaders, expectedMetadata map[string]string) func(h hookHelper) {
+	return func(h hookHelper) {
+		a := h.GetAsserter()
+		for name, p := range h.GetDestination().getAllProperties(a) {
+			if p.isFolder {
+				continue
+			}
+			a.Assert(p.contentHeaders != nil, equals(), true, "headers of "+name)
+			if p.contentHeaders == nil {
+				continue
+			}
+			actual := *p.contentHeaders
+			a.Assert(actual.cacheControl, equals(), expected.cacheControl, "cache-control of "+name)
+			a.Assert(actual.contentDisposition, equals(), expected.contentDisposition, "content-disposition of "+name)
+			a.Assert(actual.contentEncoding, equals(), expected.contentEncoding, "content-encoding of "+name)
+			a.Assert(actual.contentLanguage, equals(), expected.contentLanguage, "content-language of "+name)
+			for k, v := range expectedMetadata {
+				a.Assert(p.nameValueMetadata[k], equals(), v, "metadata "+k+" of "+name)
+			}
+		}
+	}
+}
+
+// TestHeader_SetOnUpload checks that the content headers and metadata given on the command line are set on each
+// uploaded file, rather than anything guessed from the file itself
+func TestHeader_SetOnUpload(t *testing.T) {
+	cacheControl := "public, max-age=3600"
+	contentDisposition := "attachment"
+	contentEncoding := "identity"
+	contentLanguage := "en-GB"
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob(), common.EFromTo.LocalFile()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		cacheControl:       cacheControl,
+		contentDisposition: contentDisposition,
+		contentEncoding:    contentEncoding,
+		contentLanguage:    contentLanguage,
+		metadata:           "owner=e2e;purpose=headers",
+	}, &hooks{
+		afterValidation: assertDestinationHeaders(contentHeaders{
+			cacheControl:       &cacheControl,
+			contentDisposition: &contentDisposition,
+			contentEncoding:    &contentEncoding,
+			contentLanguage:    &contentLanguage,
+		}, map[string]string{"owner": "e2e", "purpose": "headers"}),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"page.html",
+			"folder/picture.png",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// writeContentTypeMap saves a content-type-map file, named so that AzCopy reads it as CSV or JSON
+func writeContentTypeMap(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "AzCopyContentTypeMap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(fileName, []byte(content), common.DEFAULT_FILE_PERM); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestHeader_ContentTypeMapThenDetection(t *testing.T) {
+	mapFile := writeContentTypeMap(t, "types.csv", "extension,content-type\n.HTML,application/x-custom-html\nweirdext,application/x-weird\n")
+	defer os.RemoveAll(filepath.Dir(mapFile))
+	expected := map[string]string{
+		"page.html":     "application/x-custom-html", // the map wins over the built-in types
+		"picture.png":   "image/png",                 // not in the map, so detected as usual
+		"data.weirdext": "application/x-weird",
+		"noextension":   "text/plain",
+	}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		contentTypeMapFile: mapFile,
+	}, &hooks{
+		beforeRunJob: writePlainTextSources,
+	}, contentTypeTestFiles(func(name string) string { return expected[name] }), EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestHeader_ContentTypeMapThenDefault(t *testing.T) {
+	mapFile := writeContentTypeMap(t, "types.json", `{".html": "application/x-custom-html", ".weirdext": "application/x-weird"}`)
+	defer os.RemoveAll(filepath.Dir(mapFile))
+	expected := map[string]string{
+		"page.html":     "application/x-custom-html",
+		"picture.png":   "application/x-unmapped", // not in the map, so given the default instead of being detected
+		"data.weirdext": "application/x-weird",
+		"noextension":   "application/x-unmapped",
+	}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		contentTypeMapFile: mapFile,
+		defaultContentType: "application/x-unmapped",
+	}, &hooks{
+		beforeRunJob: writePlainTextSources,
+	}, contentTypeTestFiles(func(name string) string { return expected[name] }), EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,84 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// the content types of the blobs are set by the hook, after the framework creates them, so that names and extensions
+// say nothing about which blobs are images
+func setContentTypesAtSource(h hookHelper, contentTypes map[string]string) {
+	for name, contentType := range contentTypes {
+		h.CreateFile(f(name, with{contentType: contentType}), true)
+	}
+}
+
+var contentTypeFilterSources = map[string]string{
+	"photo.dat":       "image/jpeg",
+	"sub/diagram":     "IMAGE/SVG+XML",
+	"report.bin":      "application/pdf",
+	"sub/notes.txt":   "text/plain; charset=utf-8",
+	"sub/archive.img": "application/octet-stream",
+}
+
+// Blob listings include the content types, so these filters need no extra requests. Both wildcard and exact types match
+func TestFilter_IncludeContentType(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob(), common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		includeContentType: "image/*;application/pdf",
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) { setContentTypesAtSource(h, contentTypeFilterSources) },
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"photo.dat",
+			"sub/diagram",
+			"report.bin",
+		},
+		shouldIgnore: []interface{}{
+			"sub/notes.txt",
+			"sub/archive.img",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestFilter_ExcludeContentType(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob(), common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		excludeContentType: "image/*;text/plain",
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) { setContentTypesAtSource(h, contentTypeFilterSources) },
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"report.bin",
+			"sub/archive.img",
+		},
+		shouldIgnore: []interface{}{
+			"photo.dat",
+			"sub/diagram",
+			"sub/notes.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
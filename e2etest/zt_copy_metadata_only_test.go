@@ -0,0 +1,76 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// With --metadata-only, the destination blob gets the source's metadata and content type, but keeps its own data,
+// which is deliberately different from the source's here so that any copying of it would show. A source with no
+// blob at the destination fails, since there is nothing to set its metadata on
+func TestCopy_MetadataOnlyLeavesDataUntouched(t *testing.T) {
+	destinationData := []byte("the destination's own data, which must not be replaced")
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:    true,
+		metadataOnly: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+
+			_, err := containerURL.NewBlockBlobURL("retagged.txt").Upload(ctx, bytes.NewReader(destinationData),
+				azblob.BlobHTTPHeaders{ContentType: "application/octet-stream"}, azblob.Metadata{"owner": "someone else"},
+				azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil, azblob.ClientProvidedKeyOptions{}, azblob.ImmutabilityPolicyOptions{})
+			a.AssertNoErr(err, "creating the destination blob")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			blobURL := h.GetDestination().(*resourceBlobContainer).containerURL.NewBlobURL("retagged.txt")
+
+			resp, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+			a.AssertNoErr(err, "downloading the destination blob")
+			body := resp.Body(azblob.RetryReaderOptions{})
+			defer body.Close()
+			data, err := io.ReadAll(body)
+			a.AssertNoErr(err, "reading the destination blob")
+			a.Assert(data, equals(), destinationData, "metadata-only should leave the destination's data as it was")
+
+			expectedMD5 := md5.Sum(destinationData)
+			a.Assert(resp.ContentMD5(), equals(), expectedMD5[:], "metadata-only should keep the Content-MD5 of the destination's data")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("retagged.txt", with{contentType: "text/plain", nameValueMetadata: map[string]string{"owner": "me"}}),
+		},
+		shouldFail: []interface{}{
+			f("missing.txt", withError{"the destination blob does not exist"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
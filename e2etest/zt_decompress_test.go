@@ -0,0 +1,87 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// TestDecompress_GzipBlobIsDownloadedDecompressed downloads a gzipped blob, with a Content-Encoding of gzip, and a blob
+// with an encoding that AzCopy can't decompress, and checks that --decompress saves the first decompressed, under its
+// name without .gz, and the second just as it is stored
+func TestDecompress_GzipBlobIsDownloadedDecompressed(t *testing.T) {
+	// random, so that it hardly compresses, and still takes several 4 MB chunks once gzipped.
+	// So it's decompressed as it streams in, rather than all at once
+	_, original := getRandomDataAndReader(10 * 1024 * 1024)
+	notDecompressible := []byte("stored as is")
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:   true,
+		stripTopDir: true,
+		decompress:  true,
+		blockSizeMB: 4,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			var gzipped bytes.Buffer
+			gz := gzip.NewWriter(&gzipped)
+			_, err := gz.Write(original)
+			a.AssertNoErr(err, "gzipping the content")
+			a.AssertNoErr(gz.Close(), "gzipping the content")
+
+			// the scenario's files are replaced by encoded ones
+			containerURL := h.GetSource().(*resourceBlobContainer).containerURL
+			for name, blob := range map[string]struct {
+				content  []byte
+				encoding string
+			}{
+				"notes.txt.gz": {gzipped.Bytes(), "gzip"},
+				"notes.br":     {notDecompressible, "br"},
+			} {
+				_, err := azblob.UploadBufferToBlockBlob(ctx, blob.content, containerURL.NewBlockBlobURL(name), azblob.UploadToBlockBlobOptions{
+					BlockSize:       4 * 1024 * 1024,
+					BlobHTTPHeaders: azblob.BlobHTTPHeaders{ContentEncoding: blob.encoding},
+				})
+				a.AssertNoErr(err, "uploading "+name)
+			}
+		},
+		// an encoding that can't be decompressed doesn't fail the transfer; the blob keeps its name
+		expectedDestinationName: func(relPath string) string {
+			return strings.TrimSuffix(relPath, ".gz")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			a.Assert(bytes.Equal(h.GetDestinationFileContent("notes.txt"), original), equals(), true, "the gzipped blob should be downloaded decompressed")
+			a.Assert(string(h.GetDestinationFileContent("notes.br")), equals(), string(notDecompressible), "a blob that can't be decompressed should be downloaded as it is")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"notes.txt.gz",
+			"notes.br",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
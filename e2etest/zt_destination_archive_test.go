@@ -0,0 +1,98 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// validateArchiveEntries checks that the destination archive holds an entry, of the right size, for each file that
+// should have been transferred, and nothing else
+func validateArchiveEntries(h hookHelper, archiveName string, format common.ArchiveFormat) {
+	a := h.GetAsserter()
+	tf := h.GetTestFiles()
+
+	f, err := os.Open(filepath.Join(h.GetDestination().(*resourceLocal).dirPath, archiveName))
+	a.AssertNoErr(err, "opening the destination archive")
+	defer f.Close()
+
+	var r io.Reader = f
+	if format == common.EArchiveFormat.TarGz() {
+		gz, err := gzip.NewReader(f)
+		a.AssertNoErr(err, "reading the gzip header")
+		r = gz
+	}
+
+	actual := make(map[string]int)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		a.AssertNoErr(err, "reading the archive")
+		n, err := io.Copy(ioutil.Discard, tr) // read the whole entry, to be sure that the archive is complete
+		a.AssertNoErr(err, "reading entry "+hdr.Name)
+		a.Assert(n, equals(), hdr.Size, "length of entry "+hdr.Name)
+		actual[hdr.Name] = int(hdr.Size)
+	}
+
+	expected := make(map[string]int)
+	for _, o := range tf.getForStatus(common.ETransferStatus.Success(), false, false) {
+		expected[o.name] = o.creationProperties.sizeBytes(a, tf.defaultSize)
+	}
+	a.Assert(actual, equals(), expected, "entries of the destination archive")
+}
+
+func TestCopy_DestinationArchiveHoldsTransferredFiles(t *testing.T) {
+	for _, format := range []common.ArchiveFormat{common.EArchiveFormat.Tar(), common.EArchiveFormat.TarGz()} {
+		format := format
+		archiveName := "backup.tar"
+		if format == common.EArchiveFormat.TarGz() {
+			archiveName = "backup.tar.gz"
+		}
+
+		RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+			recursive:          true,
+			destinationArchive: format,
+		}, &hooks{
+			afterValidation: func(h hookHelper) { validateArchiveEntries(h, archiveName, format) },
+		}, testFiles{
+			defaultSize: "1K",
+			destTarget:  archiveName, // the archive is the destination, and each file is an entry in it
+			shouldTransfer: []interface{}{
+				"top.txt",
+				f("empty.txt", with{size: "0"}),
+				"dir/a.txt",
+				"dir/sub/b.txt",
+				f("dir/large.bin", with{size: "20M"}), // many chunks, which must stream into the entry in order
+			},
+		}, EAccountType.Standard(), EAccountType.Standard(), "")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// TestDestinationConditions_WritesOnlyTheBlobsThatMeetThem uploads the files again, once with if-none-match=* after
+// deleting one of the blobs, and once with if-match set to the ETag of one of them. The blobs that don't meet the
+// condition are left as they are, and their transfers fail with PreconditionFailed, which the summary counts
+func TestDestinationConditions_WritesOnlyTheBlobsThatMeetThem(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+			// the copy puts the files under the name of the source directory
+			srcDir := filepath.Base(h.GetSource().(*resourceLocal).dirPath)
+			existingURL := containerURL.NewBlobURL(srcDir + "/filea")
+			deletedURL := containerURL.NewBlobURL(srcDir + "/fileb")
+
+			getETag := func(blobURL azblob.BlobURL) azblob.ETag {
+				props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+				a.AssertNoErr(err, "getting the properties of "+blobURL.String())
+				return props.ETag()
+			}
+			upload := func(p params) CopyOrSyncCommandResult {
+				p.recursive = true
+				result, _ := h.RunAzCopy(eOperation.Copy(), p, h.GetSource().getParam(false, false, ""), h.GetDestination().getParam(false, true, ""))
+				return result
+			}
+			assertPreconditionFailed := func(result CopyOrSyncCommandResult, name string) {
+				a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(1), "only the blob that doesn't meet the condition fails")
+				a.Assert(result.finalStatus.TransfersPreconditionFailed, equals(), uint32(1), "the failure should be counted as a precondition failure")
+				failed, err := result.GetTransferList(common.ETransferStatus.PreconditionFailed())
+				a.AssertNoErr(err, "listing the transfers that failed on the precondition")
+				a.Assert(len(failed), equals(), 1, "transfers that failed on the precondition")
+				if len(failed) == 1 {
+					a.Assert(strings.HasSuffix(strings.Split(failed[0].Dst, "?")[0], "/"+name), equals(), true, "the transfer that failed on the precondition")
+				}
+			}
+
+			// if-none-match=* never replaces a blob that exists, but writes one that doesn't
+			existingETag := getETag(existingURL)
+			_, err := deletedURL.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+			a.AssertNoErr(err, "deleting fileb")
+			result := upload(params{ifNoneMatch: "*"})
+			assertPreconditionFailed(result, "filea")
+			a.Assert(result.finalStatus.TransfersCompleted, equals(), uint32(1), "the deleted blob should be written again")
+			a.Assert(getETag(existingURL), equals(), existingETag, "the existing blob should be left as it is")
+
+			// if-match replaces only the blob with the given ETag
+			otherETag := getETag(deletedURL)
+			result = upload(params{ifMatch: string(existingETag)})
+			assertPreconditionFailed(result, "fileb")
+			a.Assert(getETag(existingURL) != existingETag, equals(), true, "the blob with the matching ETag should be replaced")
+			a.Assert(getETag(deletedURL), equals(), otherETag, "the blob with another ETag should be left as it is")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			"fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
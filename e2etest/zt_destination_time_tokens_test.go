@@ -0,0 +1,80 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// TestDestinationTimeTokens_FilesLandUnderTheExpandedDate uploads to backups/{yyyy}/{MM}/{dd}, and checks that the
+// files land under today's date. One of them is given a destination path with {dd} in it by the path rewriter, which
+// must be kept as it is, since only the destination root is expanded. The scenario's own run, without the tokens,
+// uploads to the container root; the run under test is the second one, since the scenario can't know the date that
+// the job will expand the tokens to
+func TestDestinationTimeTokens_FilesLandUnderTheExpandedDate(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		rewritePath: func(name string) string {
+			if name == "filea" {
+				return "renamed/{dd}/filea"
+			}
+			return name
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+
+			dayBefore := time.Now().UTC().Format("2006/01/02")
+			result, err := h.RunAzCopy(eOperation.Copy(), params{recursive: true, destinationTimeTokens: true},
+				h.GetSource().getParam(true, false, ""), h.GetDestination().getParam(false, true, "backups/{yyyy}/{MM}/{dd}"))
+			a.AssertNoErr(err, "running AzCopy with time tokens in the destination")
+			a.Assert(result.finalStatus.TransfersCompleted, equals(), uint32(2), "uploading under the expanded date")
+			dayAfter := time.Now().UTC().Format("2006/01/02")
+
+			listing, err := containerURL.ListBlobsFlatSegment(ctx, azblob.Marker{}, azblob.ListBlobsSegmentOptions{Prefix: "backups/"})
+			a.AssertNoErr(err, "listing what was uploaded")
+			names := make(map[string]bool)
+			for _, blob := range listing.Segment.BlobItems {
+				names[blob.Name] = true
+			}
+			a.Assert(len(names), equals(), 2, "the files should all be under one date")
+
+			// the job may have started just before midnight
+			for _, day := range []string{dayBefore, dayAfter} {
+				if names["backups/"+day+"/sub/fileb"] {
+					a.Assert(names["backups/"+day+"/renamed/{dd}/filea"], equals(), true, "the rewritten path should be left unexpanded")
+					return
+				}
+			}
+			a.Error("the files were not uploaded under the expanded date")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			"sub/fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -21,8 +21,12 @@
 package e2etest
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
 // Purpose: Tests for the filtering functionality (when enumerating sources)
@@ -74,6 +78,49 @@ func TestFilter_IncludePath(t *testing.T) {
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
 
+// TestFilter_ListOfFiles tests a list-of-files manifest, which selects the same things as the include-path in TestFilter_IncludePath.
+// The manifest uses Windows line endings, and has comments, a blank line and an entry that does not exist, none of which should fail the job.
+func TestFilter_ListOfFiles(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			manifest, err := ioutil.TempFile("", "AzCopyListOfFiles")
+			h.GetAsserter().AssertNoErr(err)
+			defer manifest.Close()
+
+			_, err = manifest.WriteString("# files to transfer\r\n" +
+				"wantedfile\r\n" +
+				"\r\n" +
+				"sub/subsub\r\n" +
+				"   # an indented comment\r\n" +
+				"doesnotexist\r\n")
+			h.GetAsserter().AssertNoErr(err)
+
+			h.GetModifiableParameters().listOfFiles = manifest.Name()
+		},
+		afterValidation: func(h hookHelper) {
+			_ = os.Remove(h.GetModifiableParameters().listOfFiles)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""),
+			"filea",
+			"wantedfileabc",
+			"sub/filea",
+			folder("sub/subsubsub"),
+			"othersub/wantedfile",
+		},
+		shouldTransfer: []interface{}{
+			"wantedfile",
+			folder("sub/subsub"),
+			"sub/subsub/filea",
+			"sub/subsub/fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
 // TestFilter_IncludeAfter test the include-after parameter
 func TestFilter_IncludeAfter(t *testing.T) {
 	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
@@ -107,6 +154,127 @@ func TestFilter_IncludeAfter(t *testing.T) {
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
 
+// TestFilter_IncludeBefore test the include-before parameter
+func TestFilter_IncludeBefore(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// let LMTs of existing file age a little (so they are definitely older than our include-before)
+			time.Sleep(4 * time.Second)
+
+			// set includeBefore to "now"
+			scenarioParams := h.GetModifiableParameters()
+			scenarioParams.includeBefore = time.Now().Format(time.RFC3339)
+
+			// wait a moment, so that LMTs of the files we are about to create will be definitely > our include-before
+			time.Sleep(4 * time.Second)
+
+			// re-create the "shouldIgnore" files, after our includeBefore time.
+			tf := h.GetTestFiles()
+			fs := testFiles{
+				defaultSize:  tf.defaultSize,
+				shouldIgnore: tf.shouldIgnore,
+			}
+			h.CreateFiles(fs, true, false, false)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"fileb",
+		},
+		shouldTransfer: []interface{}{
+			"filea",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_IncludeAfterAndBefore tests that include-after and include-before combine (with AND semantics) into a time window
+func TestFilter_IncludeAfterAndBefore(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			tf := h.GetTestFiles()
+			scenarioParams := h.GetModifiableParameters()
+
+			// filea is now older than the window
+			time.Sleep(4 * time.Second)
+			scenarioParams.includeAfter = time.Now().Format(time.RFC3339)
+			time.Sleep(4 * time.Second)
+
+			// re-create fileb, so that it lands inside the window
+			h.CreateFiles(testFiles{defaultSize: tf.defaultSize, shouldTransfer: tf.shouldTransfer}, true, false, false)
+
+			time.Sleep(4 * time.Second)
+			scenarioParams.includeBefore = time.Now().Format(time.RFC3339)
+			time.Sleep(4 * time.Second)
+
+			// re-create filec, so that it is newer than the window
+			h.CreateFiles(testFiles{defaultSize: tf.defaultSize, shouldIgnore: []interface{}{"filec"}}, true, false, false)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"filea",
+			"filec",
+		},
+		shouldTransfer: []interface{}{
+			"fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_SizeRange tests that min-size and max-size filter files by their size, and leave folders alone
+func TestFilter_SizeRange(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		minSize:   "2K",
+		maxSize:   "5M",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"tiny",                           // uses the default size, which is below the range
+			f("huge", with{size: "10M"}),     // above the range
+			f("sub/huge", with{size: "10M"}), // recursive=true, so files in subdirectories are filtered too
+		},
+		shouldTransfer: []interface{}{
+			folder(""), // folders are not affected by the size filter
+			folder("sub"),
+			f("medium", with{size: "1M"}),
+			f("sub/medium", with{size: "1M"}),
+			f("lowerbound", with{size: "2K"}), // the range is inclusive at both ends
+			f("upperbound", with{size: "5M"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_DepthRange tests that min-depth and max-depth filter files and folders by their depth beneath the source.
+// The root folder is at depth 0, so, like any folder that is out of range, its properties are not transferred
+func TestFilter_DepthRange(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		minDepth:  2,
+		maxDepth:  3,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""),
+			"a.txt",          // depth 1
+			folder("l1"),     // depth 1
+			"l1/l2/l3/d.txt", // depth 4
+			folder("l1/l2/l3/l4"),
+			"l1/l2/l3/l4/e.txt",
+		},
+		shouldTransfer: []interface{}{
+			"l1/b.txt", // depth 2
+			folder("l1/l2"),
+			"l1/l2/c.txt", // depth 3
+			folder("l1/l2/l3"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
 func TestFilter_RemoveFile(t *testing.T) {
 	RunScenarios(t, eOperation.Remove(), eTestFromTo.AllRemove(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
 		relativeSourcePath: "file2.txt",
@@ -144,6 +312,217 @@ func TestFilter_IncludePattern(t *testing.T) {
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
 
+// TestFilter_IncludePatternContent tests that the files picked by include-pattern arrive with the content of their sources.
+// The files are all alike, so checking a few of them is enough
+func TestFilter_IncludePatternContent(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:               true,
+		includePattern:          "*.txt;2020*",
+		contentValidationSample: 3,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"A2020log",
+		},
+		shouldTransfer: []interface{}{
+			folder("subdir"),
+			"2020_file1",
+			"file2.txt",
+			"subdir/2020_file3",
+			"subdir/file4.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_SizeRangeContent tests that the files within the min-size and max-size range arrive with the content of their
+// sources, whatever their size within the range
+func TestFilter_SizeRangeContent(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		minSize:   "2K",
+		maxSize:   "5M",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"tiny",
+			f("huge", with{size: "10M"}),
+		},
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("lowerbound", with{size: "2K"}),
+			f("medium", with{size: "1M"}),
+			f("upperbound", with{size: "5M"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_IncludePatternWithBraces tests that a brace group in include-pattern matches the union of its alternatives
+func TestFilter_IncludePatternWithBraces(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob(), common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		includePattern: "{2020,2021}_*",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder("subdir"),
+			"2020_file1",
+			"2021_file2",
+			"subdir/2021_file3",
+		},
+		shouldIgnore: []interface{}{
+			"2022_file4",
+			"{2020,2021}_file5", // the braces are not matched literally
+			"A2020_file6",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_IncludeRegex tests that include-regex is matched against the whole relative path, and is implicitly anchored at both ends
+func TestFilter_IncludeRegex(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:    true,
+		includeRegex: ".*\\.txt;keep.*",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""), // the regex filter applies to folders too, and these folders' relative paths don't match
+			folder("subdir"),
+			"file1.log",
+			"file2.txt.log",
+			"notkeep",
+		},
+		shouldTransfer: []interface{}{
+			"file3.txt",
+			"subdir/file4.txt", // because recursive=true and .* matches the folder too
+			"keepme",
+			folder("keepdir"),
+			"keepdir/file5.log", // matched by keep.*, since the relative path starts with keepdir
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_ExcludeRegex tests that exclude-regex is matched against the whole relative path, and is implicitly anchored at both ends
+func TestFilter_ExcludeRegex(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:    true,
+		excludeRegex: ".*\\.log;skip.*",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"file1.log",
+			"subdir/file2.log",
+			"skipme.txt",
+			folder("skipdir"),
+			"skipdir/file3.txt",
+		},
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("subdir"),
+			"file4.txt",
+			"file5.log.txt",
+			"subdir/file6.txt",
+			"subdir/skipme.txt", // skip.* must match from the start of the relative path, which here is subdir
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_IncludeRegexAndPattern tests that include-regex and include-pattern are ORed together
+func TestFilter_IncludeRegexAndPattern(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		includePattern: "*.txt",
+		includeRegex:   "^reports/.*\\.csv$",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""), // the include pattern only applies to files, so no folders are transferred
+			"file1.csv",
+			"file2.log",
+			"reports/file3.log",
+		},
+		shouldTransfer: []interface{}{
+			"file4.txt",
+			"reports/file5.txt",
+			"reports/file6.csv",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_IgnoreCasePattern tests that, with ignore-case-pattern, include-pattern and exclude-pattern match regardless of case.
+// Blob is used as both source and destination, because it's case-preserving. Names that differ only in case would
+// collide in case-insensitive locations, such as Azure Files
+func TestFilter_IgnoreCasePattern(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		includePattern:    "*.txt",
+		excludePattern:    "skip*",
+		ignoreCasePattern: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"file.log",
+			"SKIP.txt",
+			"Skip.TXT",
+		},
+		shouldTransfer: []interface{}{
+			"File.TXT",
+			"file.txt",
+			"FILE.Txt",
+			"sub/File.TXT",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_IncludeExt tests that include-ext matches extensions case-insensitively, with or without their leading
+// dot, and that files with no extension are not included
+func TestFilter_IncludeExt(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:  true,
+		includeExt: "jpg;.PNG",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""), // like include-pattern, include-ext only applies to files, so no folders are transferred
+			"anim.gif",
+			"photo.jpeg",
+			"README",
+			"jpg",
+			"notes.jpg.txt",
+		},
+		shouldTransfer: []interface{}{
+			"a.jpg",
+			"b.JPG",
+			"c.png",
+			"d.Png",
+			"sub/e.jpg",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_ExcludeExt tests that exclude-ext matches extensions case-insensitively, and leaves files with no
+// extension alone
+func TestFilter_ExcludeExt(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:  true,
+		excludeExt: ".tmp;LOG",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"a.tmp",
+			"b.TMP",
+			"c.log",
+			"sub/d.Log",
+		},
+		shouldTransfer: []interface{}{
+			folder("sub"),
+			"README",
+			"tmp",
+			"e.txt",
+			"sub/f.jpg",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
 func TestFilter_RemoveFolder(t *testing.T) {
 	RunScenarios(t, eOperation.Remove(), eTestFromTo.AllRemove(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
 		recursive:          true,
@@ -177,6 +556,27 @@ func TestFilter_RemoveContainer(t *testing.T) {
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
 
+// A dry run of remove lists what it would delete, within relativeSourcePath and recursively, with totals that add up.
+// Validation of a dry run checks that nothing was actually deleted
+func TestFilter_RemoveFolderDryRun(t *testing.T) {
+	RunScenarios(t, eOperation.Remove(), eTestFromTo.AllRemove(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		relativeSourcePath: "folder2/",
+		dryRun:             true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"file1.txt",
+			f("folder1/file11.txt", with{size: "2K"}),
+			"folder1/file12.txt",
+		},
+		shouldIgnore: []interface{}{
+			"folder2/file21.txt",
+			"folder2/file22.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
 func TestFilter_ExcludePath(t *testing.T) {
 	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
 		recursive:   true,
@@ -304,3 +704,93 @@ func TestFilter_CombineCommonFilters(t *testing.T) {
 		},
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
+
+// A dry run must plan exactly what a real run would transfer, so it goes through the same filters.
+// The validation of a dry run checks the planned transfers against shouldTransfer, and that nothing was transferred.
+func TestFilter_DryRunCombineFilters(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		dryRun:         true,
+		includePattern: "*.txt",
+		excludePattern: "goat*",
+		excludePath:    "dog",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"fox.pdf",
+			"goat.txt",
+			"dog/frog.txt",
+			"donkey/goat.txt",
+		},
+		shouldTransfer: []interface{}{
+			"frog.txt",
+			"donkey/frog.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestFilter_DryRunSizeRange(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		dryRun:    true,
+		minSize:   "2K",
+		maxSize:   "5M",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"tiny",
+			f("huge", with{size: "10M"}),
+		},
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("medium", with{size: "1M"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestFilter_DryRunIncludeAfter(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		dryRun:    true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// as in TestFilter_IncludeAfter, age the existing files, then re-create the shouldTransfer ones after include-after
+			time.Sleep(4 * time.Second)
+			scenarioParams := h.GetModifiableParameters()
+			scenarioParams.includeAfter = time.Now().Format(time.RFC3339)
+			time.Sleep(4 * time.Second)
+
+			fs := h.GetTestFiles().cloneShouldTransfers()
+			h.CreateFiles(fs, true, true, false)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"filea",
+		},
+		shouldTransfer: []interface{}{
+			"fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFilter_ExcludeRootFolder checks that --exclude-root-folder leaves out the transfer of the source folder itself,
+// even between folder-aware locations, where it would otherwise be transferred, while what's in it still is.
+// Where folders aren't transferred at all, as to Blob, the flag changes nothing
+func TestFilter_ExcludeRootFolder(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalFile(), common.EFromTo.FileFile(), common.EFromTo.FileLocal(), common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		excludeRootFolder: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""),
+		},
+		shouldTransfer: []interface{}{
+			"filea",
+			folder("sub"),
+			"sub/fileb",
+			folder("sub/empty"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
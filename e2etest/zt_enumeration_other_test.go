@@ -21,8 +21,18 @@
 package e2etest
 
 import (
-	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/google/uuid"
 )
 
 // Purpose: Other tests for enumeration of sources, NOT including filtering
@@ -42,3 +52,171 @@ func TestEnumeration_DirectoryStubsAreNotDownloaded(t *testing.T) {
 		},
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
+
+// wideDeepTree returns the files (and folders) of a tree that is width folders wide at each of depth levels.
+// Every folder holds one .txt and one .log file
+func wideDeepTree(width, depth int) (txt []interface{}, log []interface{}) {
+	var addLevel func(dir string, level int)
+	addLevel = func(dir string, level int) {
+		txt = append(txt, f(dir+"file.txt"))
+		log = append(log, f(dir+"file.log"))
+		if level == depth {
+			return
+		}
+		for i := 0; i < width; i++ {
+			addLevel(fmt.Sprintf("%sdir%d/", dir, i), level+1)
+		}
+	}
+	addLevel("", 0)
+	return
+}
+
+// Purpose: Check that scanning many folders at once finds every file exactly once, and that filters apply just as they do
+// when folders are scanned one at a time. The validator fails the test if any file is missing, or is transferred twice.
+// The elapsed times are logged (not asserted, since they depend on the test machine) to show the effect of the parallelism.
+func TestEnumeration_ParallelScanOfWideDeepTree(t *testing.T) {
+	txt, log := wideDeepTree(4, 4)
+
+	for _, parallelism := range []int{1, 32} {
+		started := make(map[string]time.Time)
+		mu := &sync.Mutex{}
+
+		RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob(), common.EFromTo.BlobLocal(), common.EFromTo.FileLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+			recursive:              true,
+			includePattern:         "*.txt",
+			enumerationParallelism: parallelism,
+			disableParallelTesting: true, // so that the timings are not skewed by other scenarios
+		}, &hooks{
+			beforeRunJob: func(h hookHelper) {
+				mu.Lock()
+				defer mu.Unlock()
+				started[h.GetAsserter().CompactScenarioName()] = time.Now()
+			},
+			afterValidation: func(h hookHelper) {
+				mu.Lock()
+				defer mu.Unlock()
+				name := h.GetAsserter().CompactScenarioName()
+				t.Logf("%s: scan parallelism %d, %d files, took %v", name, parallelism, len(txt), time.Since(started[name]))
+			},
+		}, testFiles{
+			defaultSize:    "1K",
+			shouldTransfer: txt,
+			shouldIgnore:   log,
+		}, EAccountType.Standard(), EAccountType.Standard(), "")
+	}
+}
+
+// Purpose: Check that --exclude-container drops whole containers while an account is enumerated.
+// The declarative framework works on one container at a time, so this test makes its own containers, which all share a
+// unique prefix, and copies from the account with a wildcard for that prefix.
+// It uses a dry run, since the planned transfers are enough to show which containers were enumerated.
+func TestEnumeration_ExcludeContainerAtAccountScope(t *testing.T) {
+	a := &testingAsserter{t: t, fullScenarioName: t.Name(), compactScenarioName: t.Name()}
+
+	prefix := "excl" + strings.Replace(uuid.New().String(), "-", "", -1)[:12]
+	kept := []string{prefix + "-keep", prefix + "-other"}
+	excluded := []string{prefix + "-skip1", prefix + "-skip2", prefix + "-logs"}
+
+	serviceURL := TestResourceFactory{}.GetBlobServiceURL(EAccountType.Standard())
+	scenarioHelper{}.generateBlobContainersAndBlobsFromLists(a, serviceURL, append(append([]string{}, kept...), excluded...), []*testObject{
+		f("a.txt"),
+		f("dir/b.txt"),
+	})
+	defer func() {
+		for _, name := range append(append([]string{}, kept...), excluded...) {
+			_, _ = serviceURL.NewContainerURL(name).Delete(context.Background(), azblob.ContainerAccessConditions{})
+		}
+	}()
+
+	source := TestResourceFactory{}.GetBlobServiceURLWithSAS(a, EAccountType.Standard()).URL()
+	source.Path = "/" + prefix + "*"
+
+	expected := make(map[string]bool)
+	for _, container := range kept {
+		expected["/"+container+"/a.txt"] = true
+		expected["/"+container+"/dir/b.txt"] = true
+	}
+
+	dstDir := TestResourceFactory{}.CreateLocalDirectory(a)
+	defer os.RemoveAll(dstDir)
+
+	result, _ := runAzCopyWithParams(a, eOperation.Copy(), params{
+		recursive:        true,
+		dryRun:           true,
+		excludeContainer: prefix + "-skip*;" + prefix + "-logs",
+	}, source.String(), dstDir)
+	a.Assert(result.isDryrun, equals(), true, "expected a dry run")
+
+	actual := make(map[string]bool)
+	for _, transfer := range result.dryrunTransfers {
+		container := strings.Split(strings.TrimPrefix(transfer.Source, "/"), "/")[0]
+		for _, name := range excluded {
+			a.Assert(container, notEquals(), name, fmt.Sprintf("nothing in excluded container %s should be enumerated, but found %s", name, transfer.Source))
+		}
+		actual[transfer.Source] = true
+	}
+	a.Assert(actual, equals(), expected, "planned transfers")
+}
+
+// TestEnumeration_WildcardContainerName tests that a wildcard in the container name copies the contents of all the matching
+// containers, and only those, each into a folder named after its container. include-path and exclude-path apply within each
+// of them, since their paths do not include the container name.
+func TestEnumeration_WildcardContainerName(t *testing.T) {
+	a := &testingAsserter{t: t, fullScenarioName: t.Name(), compactScenarioName: t.Name()}
+
+	prefix := "wild" + strings.Replace(uuid.New().String(), "-", "", -1)[:12]
+	matching := []string{prefix + "logs2023", prefix + "logs2024"}
+	other := prefix + "other"
+
+	serviceURL := TestResourceFactory{}.GetBlobServiceURL(EAccountType.Standard())
+	scenarioHelper{}.generateBlobContainersAndBlobsFromLists(a, serviceURL, append(append([]string{}, matching...), other), []*testObject{
+		f("a.txt"),
+		f("dir/b.txt"),
+		f("dir/skip/c.txt"),
+		f("dir2/d.txt"),
+	})
+	defer func() {
+		for _, name := range append(append([]string{}, matching...), other) {
+			_, _ = serviceURL.NewContainerURL(name).Delete(context.Background(), azblob.ContainerAccessConditions{})
+		}
+	}()
+
+	source := TestResourceFactory{}.GetBlobServiceURLWithSAS(a, EAccountType.Standard()).URL()
+	source.Path = "/" + prefix + "logs*"
+
+	for _, tc := range []struct {
+		includePath string
+		excludePath string
+		expected    []string
+	}{
+		{"", "", []string{"a.txt", "dir/b.txt", "dir/skip/c.txt", "dir2/d.txt"}},
+		{"dir", "", []string{"dir/b.txt", "dir/skip/c.txt"}}, // not dir2, whose name merely starts with dir
+		{"dir;a.txt", "dir/skip", []string{"a.txt", "dir/b.txt"}},
+	} {
+		dstDir := TestResourceFactory{}.CreateLocalDirectory(a)
+		defer os.RemoveAll(dstDir)
+
+		runAzCopyWithParams(a, eOperation.Copy(), params{
+			recursive:   true,
+			includePath: tc.includePath,
+			excludePath: tc.excludePath,
+		}, source.String(), dstDir)
+
+		expected := make(map[string]bool)
+		for _, container := range matching {
+			for _, name := range tc.expected {
+				expected[container+"/"+name] = true
+			}
+		}
+		actual := make(map[string]bool)
+		err := filepath.Walk(dstDir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				rel, _ := filepath.Rel(dstDir, path)
+				actual[filepath.ToSlash(rel)] = true
+			}
+			return err
+		})
+		a.AssertNoErr(err, "listing the destination")
+		a.Assert(actual, equals(), expected, fmt.Sprintf("copied files, with include-path '%s' and exclude-path '%s'", tc.includePath, tc.excludePath))
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// Purpose: Tests that a quiet job, e.g. one run by cron, reports its outcome through its exit code
+
+func TestExitCode_QuietJobReportsOutcome(t *testing.T) {
+	a := &testingAsserter{t: t, fullScenarioName: t.Name(), compactScenarioName: t.Name()}
+
+	containerURL, containerName, _ := TestResourceFactory{}.CreateNewContainer(a, azblob.PublicAccessNone, EAccountType.Standard())
+	defer containerURL.Delete(context.Background(), azblob.ContainerAccessConditions{})
+	for _, name := range []string{"a.txt", "b.txt"} {
+		_, err := azblob.UploadBufferToBlockBlob(ctx, []byte("content of "+name), containerURL.NewBlockBlobURL(name), azblob.UploadToBlockBlobOptions{})
+		a.AssertNoErr(err, "creating "+name)
+	}
+	blobURL := func(blob string) string {
+		u := TestResourceFactory{}.GetBlobURLWithSAS(a, EAccountType.Standard(), containerName, blob).URL()
+		return u.String()
+	}
+
+	workDir := TestResourceFactory{}.CreateLocalDirectory(a)
+	defer os.RemoveAll(workDir)
+
+	// the missing blobs fail as transfers, without stopping the rest of the job
+	cases := map[string]struct {
+		blobs    []string
+		expected common.ExitCode
+	}{
+		"FullySuccessful": {[]string{"a.txt", "b.txt"}, common.EExitCode.Success()},
+		"PartiallyFailed": {[]string{"a.txt", "missing.txt"}, common.EExitCode.PartialFailure()},
+		"FullyFailed":     {[]string{"missing.txt", "also-missing.txt"}, common.EExitCode.Error()},
+	}
+	for name, c := range cases {
+		urls := make([]string, 0, len(c.blobs))
+		for _, blob := range c.blobs {
+			urls = append(urls, blobURL(blob))
+		}
+		listFile := filepath.Join(workDir, name+".txt")
+		a.AssertNoErr(ioutil.WriteFile(listFile, []byte(strings.Join(urls, "\n")), 0644))
+		logDir := filepath.Join(workDir, name+"-logs")
+		dstDir := filepath.Join(workDir, name)
+
+		r := newTestRunner()
+		r.SetAllFlags(params{listOfUrls: listFile, quiet: true}, eOperation.Copy())
+		r.env = append(r.env, common.EEnvironmentVariable.LogLocation().Name+"="+logDir)
+		result, _ := runAzCopyWithRunner(a, r, eOperation.Copy(), "", dstDir)
+		a.Assert(common.ExitCode(result.exitCode), equals(), c.expected, name+": exit code")
+
+		// nothing was printed, but the summary is still in the job log
+		logs, err := filepath.Glob(filepath.Join(logDir, "*.log"))
+		a.AssertNoErr(err)
+		foundSummary := false
+		for _, log := range logs {
+			content, err := ioutil.ReadFile(log)
+			a.AssertNoErr(err)
+			foundSummary = foundSummary || strings.Contains(string(content), "Final Job Status:")
+		}
+		a.Assert(foundSummary, equals(), true, name+": the job log should have the summary")
+	}
+}
+
+// Purpose: Tests that a job that fails on more files than --max-errors allows is cancelled, rather than left to fail on all of them
+
+func TestExitCode_TooManyErrorsCancelsTheJob(t *testing.T) {
+	a := &testingAsserter{t: t, fullScenarioName: t.Name(), compactScenarioName: t.Name()}
+
+	containerURL, containerName, _ := TestResourceFactory{}.CreateNewContainer(a, azblob.PublicAccessNone, EAccountType.Standard())
+	defer containerURL.Delete(context.Background(), azblob.ContainerAccessConditions{})
+
+	workDir := TestResourceFactory{}.CreateLocalDirectory(a)
+	defer os.RemoveAll(workDir)
+
+	// none of the blobs exist, so every transfer fails, and the job should be cancelled well before it has tried them all
+	const blobCount = 500
+	urls := make([]string, 0, blobCount)
+	for i := 0; i < blobCount; i++ {
+		u := TestResourceFactory{}.GetBlobURLWithSAS(a, EAccountType.Standard(), containerName, fmt.Sprintf("missing-%d.txt", i)).URL()
+		urls = append(urls, u.String())
+	}
+	listFile := filepath.Join(workDir, "list.txt")
+	a.AssertNoErr(ioutil.WriteFile(listFile, []byte(strings.Join(urls, "\n")), 0644))
+
+	for _, quiet := range []bool{false, true} {
+		result, _ := runAzCopyWithParams(a, eOperation.Copy(), params{listOfUrls: listFile, maxErrors: 3, quiet: quiet}, "", filepath.Join(workDir, fmt.Sprintf("quiet-%v", quiet)))
+		if quiet {
+			a.Assert(common.ExitCode(result.exitCode), equals(), common.EExitCode.TooManyErrors(), "exit code")
+			continue
+		}
+
+		a.Assert(result.finalStatus.JobStatus, equals(), common.EJobStatus.Cancelled(), "the job should be cancelled")
+		a.Assert(result.finalStatus.AbortReason, equals(), "more than 3 transfers failed", "why the job was cancelled")
+		a.Assert(result.finalStatus.TransfersFailed > 3, equals(), true, "the job should go on until more than 3 transfers have failed")
+		a.Assert(result.finalStatus.TransfersFailed < blobCount, equals(), true, "the job should not try every transfer")
+	}
+}
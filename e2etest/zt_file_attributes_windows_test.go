@@ -0,0 +1,104 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// fileAttributes are set on the source files by the beforeRunJob hook. The plain file is made Normal, rather than
+// being left with the Archive attribute that new files get, so that it has none of the attributes that are filtered on
+var fileAttributes = map[string][]string{
+	"hidden.txt":       {"H"},
+	"system.txt":       {"S"},
+	"hiddenSystem.txt": {"H", "S"},
+	"plain.txt":        {"N"},
+}
+
+func setFileAttributes(h hookHelper) {
+	a := h.GetAsserter()
+	srcDir := h.GetSource().getParam(false, false, "")
+	for name, attributes := range fileAttributes {
+		a.AssertNoErr(osScenarioHelper{}.setAttributesForLocalFile(filepath.Join(srcDir, name), attributes), "setting attributes of "+name)
+	}
+}
+
+func TestFileAttributes_Include(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		includeAttributes: "H",
+	}, &hooks{
+		beforeRunJob: setFileAttributes,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"hidden.txt",
+			"hiddenSystem.txt",
+		},
+		shouldIgnore: []interface{}{
+			"system.txt",
+			"plain.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestFileAttributes_ExcludeLetterSet(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		excludeAttributes: "HS", // the same as H;S
+	}, &hooks{
+		beforeRunJob: setFileAttributes,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"plain.txt",
+		},
+		shouldIgnore: []interface{}{
+			"hidden.txt",
+			"system.txt",
+			"hiddenSystem.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFileAttributes_ExcludeTakesPrecedence checks that a file which matches both lists is excluded
+func TestFileAttributes_ExcludeTakesPrecedence(t *testing.T) {
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		includeAttributes: "H",
+		excludeAttributes: "S",
+	}, &hooks{
+		beforeRunJob: setFileAttributes,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"hidden.txt",
+		},
+		shouldIgnore: []interface{}{
+			"hiddenSystem.txt",
+			"system.txt",
+			"plain.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,108 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// flattenedNames gives the destination names of the files of the flatten tests, which are all copied directly under
+// the top directory
+func flattenedNames(names map[string]string) pathRewriteFunc {
+	return func(name string) string {
+		if flattened, ok := names[name]; ok {
+			return flattened
+		}
+		return name
+	}
+}
+
+// TestFlatten_RenameMakesCollidingNamesUnique uploads files with the same name from different folders, flattened, and
+// checks that they are numbered in the order of their source paths. The .log file is excluded, to show that filters
+// still apply to the source paths. Afterwards, the same files are copied with the default collision policy, which
+// must copy nothing at all
+func TestFlatten_RenameMakesCollidingNamesUnique(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		flatten:           true,
+		flattenCollisions: common.EFlattenCollisionPolicy.Rename(),
+		excludePattern:    "*.log",
+	}, &hooks{
+		expectedDestinationName: flattenedNames(map[string]string{
+			"a/report.txt":   "report.txt",
+			"b/report.txt":   "report-2.txt",
+			"c/d/report.txt": "report-3.txt",
+			"c/d/filea":      "filea",
+		}),
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			// a whole-job failure isn't something a scenario can expect, so this copy is a run of its own
+			_, err := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, flatten: true, excludePattern: "*.log"},
+				h.GetSource().getParam(false, false, ""), h.GetDestination().getParam(false, true, "Fail"))
+			a.Assert(err, notEquals(), nil, "a flattened copy of files with the same name should fail by default")
+			for name := range h.GetDestination().getAllProperties(a) {
+				a.Assert(strings.HasPrefix(name, "Fail/"), equals(), false, "nothing should be copied when the names collide")
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"a/report.txt",
+			"b/report.txt",
+			"c/d/report.txt",
+			"c/d/filea",
+		},
+		shouldIgnore: []interface{}{
+			"e/skip.log",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestFlatten_OverwriteKeepsTheMostRecentFile uploads files with the same name from different folders, flattened, and
+// checks that only the most recently modified of them is copied. The others are dropped before they're scheduled
+func TestFlatten_OverwriteKeepsTheMostRecentFile(t *testing.T) {
+	modified := func(age time.Duration) createOnly {
+		return createOnly{with{lastWriteTime: time.Now().Add(-age)}}
+	}
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		flatten:           true,
+		flattenCollisions: common.EFlattenCollisionPolicy.Overwrite(),
+	}, &hooks{
+		expectedDestinationName: flattenedNames(map[string]string{
+			"b/report.txt": "report.txt",
+			"c/d/filea":    "filea",
+		}),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("b/report.txt", modified(time.Hour)),
+			"c/d/filea",
+		},
+		shouldIgnore: []interface{}{
+			f("a/report.txt", modified(2*time.Hour)),
+			f("c/d/report.txt", modified(3*time.Hour)),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,65 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Purpose: Tests that are specific to GCP as a source. GCP also takes part in the general tests that use all sources,
+// such as the filter tests. All of these are skipped unless GOOGLE_APPLICATION_CREDENTIALS and GOOGLE_CLOUD_PROJECT are set.
+
+func TestGCP_VirtualDirectoriesAreFlattenedToPaths(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.GCPBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""), // GCP has no real folders, so the framework expects none of these at the destination
+			"filea",
+			folder("a"),
+			"a/fileb",
+			folder("a/b"),
+			folder("a/b/c"),
+			"a/b/c/filec",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestGCP_ContentPropertiesArePreserved(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.GCPBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("filea", with{
+				contentType:        "text/plain",
+				contentEncoding:    "identity",
+				contentLanguage:    "en",
+				contentDisposition: "inline",
+				cacheControl:       "no-cache",
+				nameValueMetadata:  map[string]string{"foo": "abc", "bar": "def"},
+			}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
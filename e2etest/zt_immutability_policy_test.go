@@ -0,0 +1,92 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"net/url"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/google/uuid"
+)
+
+// TestImmutabilityPolicy_IsSetOnUploadedBlobs uploads the files to a container with version-level immutability
+// support, with an immutability policy and a legal hold, and reads the properties of the blobs to check that they
+// have both. The blobs are released and deleted afterwards. Since the test containers don't have that support,
+// the container is given by AZCOPY_E2E_IMMUTABLE_CONTAINER_URL, and the test is skipped without it
+func TestImmutabilityPolicy_IsSetOnUploadedBlobs(t *testing.T) {
+	containerSAS := GlobalInputManager{}.GetImmutableContainerURL()
+	if containerSAS == "" {
+		t.Skip("AZCOPY_E2E_IMMUTABLE_CONTAINER_URL is not set")
+	}
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			u, err := url.Parse(containerSAS)
+			a.AssertNoErr(err, "parsing AZCOPY_E2E_IMMUTABLE_CONTAINER_URL")
+			containerURL := azblob.NewContainerURL(*u, azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{}))
+			prefix := "immutability-" + uuid.New().String()
+			dst := *u
+			dst.Path = path.Join(dst.Path, prefix)
+
+			// the scenario's own destination is a test container, without the support, so the upload under test is a run of its own
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, immutabilityPolicyUntil: "+1d", legalHold: true}, h.GetSource().getParam(false, false, ""), dst.String())
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "failed uploads with an immutability policy")
+
+			listed := 0
+			for marker := (azblob.Marker{}); marker.NotDone(); {
+				resp, err := containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: prefix + "/"})
+				a.AssertNoErr(err, "listing the uploaded blobs")
+				marker = resp.NextMarker
+				for _, item := range resp.Segment.BlobItems {
+					listed++
+					blobURL := containerURL.NewBlobURL(item.Name)
+					props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+					a.AssertNoErr(err, "getting the properties of "+item.Name)
+					if err == nil {
+						expiresOn := props.ImmutabilityPolicyExpiresOn()
+						a.Assert(expiresOn.After(time.Now().Add(23*time.Hour)), equals(), true, "the immutability policy of "+item.Name+" should last about a day")
+						a.Assert(props.ImmutabilityPolicyMode(), equals(), azblob.BlobImmutabilityPolicyModeUnlocked, "the immutability policy of "+item.Name+" should be unlocked")
+						a.Assert(props.LegalHold(), equals(), "true", item.Name+" should be under a legal hold")
+					}
+
+					// the policy is unlocked, so it can be removed, and then the blob deleted
+					_, _ = blobURL.SetLegalHold(ctx, false)
+					_, _ = blobURL.DeleteImmutabilityPolicy(ctx)
+					_, _ = blobURL.Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+				}
+			}
+			a.Assert(listed, equals(), 2, "uploaded blobs")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			folder("fold1"),
+			f("fold1/fileb"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
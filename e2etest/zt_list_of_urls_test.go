@@ -0,0 +1,122 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/google/uuid"
+)
+
+// Purpose: Tests for copying from a list of full URLs, given with --list-of-urls
+
+func TestListOfUrls_BlobsFromSeveralContainers(t *testing.T) {
+	a := &testingAsserter{t: t, fullScenarioName: t.Name(), compactScenarioName: t.Name()}
+
+	prefix := "urls" + strings.Replace(uuid.New().String(), "-", "", -1)[:12]
+	containers := []string{prefix + "-one", prefix + "-two"}
+
+	serviceURL := TestResourceFactory{}.GetBlobServiceURL(EAccountType.Standard())
+	scenarioHelper{}.generateBlobContainersAndBlobsFromLists(a, serviceURL, containers, []*testObject{
+		f("a.txt"),
+		f("dir/b.txt"),
+	})
+	defer func() {
+		for _, name := range containers {
+			_, _ = serviceURL.NewContainerURL(name).Delete(context.Background(), azblob.ContainerAccessConditions{})
+		}
+	}()
+
+	blobURL := func(container, blob string) string {
+		u := TestResourceFactory{}.GetBlobURLWithSAS(a, EAccountType.Standard(), container, blob).URL()
+		return u.String()
+	}
+
+	listDir := TestResourceFactory{}.CreateLocalDirectory(a)
+	defer os.RemoveAll(listDir)
+
+	cases := map[string]struct {
+		urls     []string
+		expected []string
+	}{
+		// with only the blob names kept, the URLs must not name the same blob in two containers
+		"BlobName": {
+			urls: []string{
+				blobURL(containers[0], "a.txt"),
+				blobURL(containers[1], "dir"), // a virtual directory, copied recursively
+				blobURL(containers[1], "missing.txt"),
+			},
+			expected: []string{"a.txt", "dir/b.txt"},
+		},
+		"ContainerAndBlobName": {
+			urls: []string{
+				blobURL(containers[0], "a.txt"),
+				blobURL(containers[0], "dir/b.txt"),
+				blobURL(containers[1], "dir"),
+				blobURL(containers[1], "missing.txt"),
+			},
+			expected: []string{
+				containers[0] + "/a.txt",
+				containers[0] + "/dir/b.txt",
+				containers[1] + "/dir/b.txt",
+			},
+		},
+	}
+	for keep, c := range cases {
+		listFile := filepath.Join(listDir, keep+".txt")
+		lines := append([]string{"# a comment, and a blank line, that are both skipped", ""}, c.urls...)
+		a.AssertNoErr(ioutil.WriteFile(listFile, []byte(strings.Join(lines, "\n")), 0644))
+
+		dstDir := TestResourceFactory{}.CreateLocalDirectory(a)
+		defer os.RemoveAll(dstDir)
+
+		result, _ := runAzCopyWithParams(a, eOperation.Copy(), params{
+			recursive:   true,
+			listOfUrls:  listFile,
+			keepFromUrl: keep,
+		}, "", dstDir)
+
+		// the missing blob is reported as a failure, without stopping the rest of the job
+		a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(1), fmt.Sprintf("failed transfers, keeping %s", keep))
+
+		actual := make(map[string]bool)
+		a.AssertNoErr(filepath.Walk(dstDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dstDir, path)
+			actual[filepath.ToSlash(rel)] = true
+			return err
+		}))
+		expectedSet := make(map[string]bool)
+		for _, name := range c.expected {
+			expectedSet[name] = true
+		}
+		a.Assert(actual, equals(), expectedSet, fmt.Sprintf("downloaded files, keeping %s", keep))
+	}
+}
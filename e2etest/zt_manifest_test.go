@@ -0,0 +1,161 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Purpose: Tests for manifest-output, which lists the transferred files, and the failed ones, when the job ends
+
+type manifestLine struct {
+	Source       string
+	Destination  string
+	Size         uint64
+	ContentMD5   string
+	ErrorCode    int32
+	ErrorMessage string
+}
+
+// readManifest returns the lines of the manifest, by the path of their source relative to srcDir
+func readManifest(a asserter, path string, srcDir string) map[string]manifestLine {
+	f, err := os.Open(path)
+	a.AssertNoErr(err, "opening manifest "+path)
+	defer f.Close()
+
+	lines := make(map[string]manifestLine)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line manifestLine
+		a.AssertNoErr(json.Unmarshal(scanner.Bytes(), &line), "parsing manifest line "+scanner.Text())
+		name := strings.TrimPrefix(filepath.ToSlash(line.Source), filepath.ToSlash(srcDir)+"/")
+		lines[name] = line
+	}
+	a.AssertNoErr(scanner.Err(), "reading manifest "+path)
+	return lines
+}
+
+func TestManifest_ListsTransferredAndFailedFiles(t *testing.T) {
+	manifestDir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(manifestDir)
+	manifestPath := filepath.Join(manifestDir, "manifest.jsonl")
+	transferred := []string{"a.txt", "dir/b.txt", "aligned.img"}
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		putMd5:            true,
+		blobTypeByPattern: "*.img=PageBlob",
+		manifestOutput:    manifestPath,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// the test files' sizes are always whole KiB, so trim this one to a size that can't be a page blob. It then fails
+			srcDir := h.GetSource().getParam(false, false, "")
+			h.GetAsserter().AssertNoErr(os.Truncate(filepath.Join(srcDir, "unaligned.img"), 1000), "trimming unaligned.img")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+
+			// the manifest lists exactly the files that should have been transferred, and no folders
+			lines := readManifest(a, manifestPath, srcDir)
+			a.Assert(len(lines), equals(), len(transferred), "number of lines in the manifest")
+			for _, name := range transferred {
+				line, ok := lines[name]
+				a.Assert(ok, equals(), true, name+" should be in the manifest")
+				if !ok {
+					continue
+				}
+				data, err := ioutil.ReadFile(filepath.Join(srcDir, filepath.FromSlash(name)))
+				a.AssertNoErr(err, "reading source file "+name)
+				hash := md5.Sum(data)
+				a.Assert(line.Size, equals(), uint64(len(data)), "size of "+name)
+				a.Assert(line.ContentMD5, equals(), base64.StdEncoding.EncodeToString(hash[:]), "MD5 of "+name)
+				a.Assert(strings.HasSuffix(line.Destination, "/"+name) || strings.Contains(line.Destination, "/"+name+"?"), equals(), true, "destination of "+name)
+				a.Assert(line.ErrorMessage, equals(), "", "error of transferred file "+name)
+			}
+
+			// and the failed one is in the manifest alongside it, with the reason
+			failed := readManifest(a, filepath.Join(manifestDir, "manifest.failed.jsonl"), srcDir)
+			a.Assert(len(failed), equals(), 1, "number of lines in the failed manifest")
+			line, ok := failed["unaligned.img"]
+			a.Assert(ok, equals(), true, "unaligned.img should be in the failed manifest")
+			a.Assert(strings.Contains(line.ErrorMessage, "not a multiple of 512 bytes"), equals(), true, "reason in the failed manifest: "+line.ErrorMessage)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"a.txt",
+			folder("dir"),
+			"dir/b.txt",
+			"aligned.img",
+		},
+		shouldFail: []interface{}{
+			f("unaligned.img", withError{"not a multiple of 512 bytes"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestManifest_EmptyJob tests that a job with nothing to transfer still writes its (empty) manifests, so that a missing
+// manifest can only mean that the job didn't finish
+func TestManifest_EmptyJob(t *testing.T) {
+	manifestDir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(manifestDir)
+	manifestPath := filepath.Join(manifestDir, "manifest.jsonl")
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		includePattern: "nothing-matches-this",
+		manifestOutput: manifestPath,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			for _, path := range []string{manifestPath, filepath.Join(manifestDir, "manifest.failed.jsonl")} {
+				fi, err := os.Stat(path)
+				a.AssertNoErr(err, "the manifest should exist: "+path)
+				if err == nil {
+					a.Assert(fi.Size(), equals(), int64(0), "size of "+path)
+				}
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			"a.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,62 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TestMaxFileCount_StopsJobWithTooManyFiles copies with a limit of exactly the number of files, which copies them all.
+// Before that, a copy with a limit below the number of files must fail without copying anything. That one is run apart
+// from the scenario, since a scenario can't expect the whole job to fail
+func TestMaxFileCount_StopsJobWithTooManyFiles(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:    true,
+		maxFileCount: 5,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+
+			_, err := h.RunAzCopy(eOperation.Copy(), params{recursive: true, maxFileCount: 2},
+				h.GetSource().getParam(false, false, ""), h.GetDestination().getParam(false, true, ""))
+			a.Assert(err != nil, equals(), true, "copying more files than max-file-count allows should fail")
+			if err != nil {
+				a.Assert(strings.Contains(err.Error(), "after finding 3 files to transfer"), equals(), true, "the error should give the count reached: "+err.Error())
+			}
+
+			for name, props := range h.GetDestination().getAllProperties(a) {
+				a.Assert(props.isFolder, equals(), true, "nothing should have been copied by the job that was stopped, but found "+name)
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"file1",
+			"file2",
+			"file3",
+			"dir/file4",
+			"dir/file5",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,72 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Blob listings include metadata, so include-metadata works from the listing alone, with wildcards in the values
+func TestFilter_IncludeMetadata(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob(), common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:       true,
+		includeMetadata: "archive=true;tier=arch*",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("archived.txt", with{nameValueMetadata: map[string]string{"archive": "true"}}),
+			f("sub/archiving.txt", with{nameValueMetadata: map[string]string{"tier": "archiving", "owner": "me"}}),
+		},
+		shouldIgnore: []interface{}{
+			"untagged.txt",
+			f("current.txt", with{nameValueMetadata: map[string]string{"archive": "false"}}),
+			f("sub/cool.txt", with{nameValueMetadata: map[string]string{"tier": "cool"}}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// Metadata that is set on blobs after they were uploaded, as tagging tools do, is what exclude-metadata sees
+func TestFilter_ExcludeMetadataSetAfterUpload(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob(), common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:       true,
+		excludeMetadata: "archive=true",
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// re-create the files that are to be ignored, this time with the metadata that excludes them
+			for _, name := range []string{"old.txt", "sub/old.txt"} {
+				h.CreateFile(f(name, with{nameValueMetadata: map[string]string{"archive": "true"}}), true)
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"new.txt",
+			"sub/new.txt",
+			f("sub/kept.txt", with{nameValueMetadata: map[string]string{"archive": "false"}}),
+		},
+		shouldIgnore: []interface{}{
+			"old.txt",
+			"sub/old.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
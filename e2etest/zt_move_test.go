@@ -0,0 +1,82 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Purpose: Tests for the move command, which is a copy that then deletes the sources that were transferred successfully
+
+func TestMove_AllPairs(t *testing.T) {
+	RunScenarios(t, eOperation.Move(), eTestFromTo.AllPairs(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			folder("fold1"),
+			f("fold1/fileb"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestMove_KeepsFilteredSources(t *testing.T) {
+	RunScenarios(t, eOperation.Move(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		includePattern: "*.txt",
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea.txt",
+			"fold1/fileb.txt",
+		},
+		shouldIgnore: []interface{}{
+			"filec.pdf",
+			"fold1/filed.jpg",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestMove_KeepsFailedSources(t *testing.T) {
+	// a block blob cannot overwrite an append blob, so that transfer fails, and its source must not be deleted
+	RunScenarios(t, eOperation.Move(), eTestFromTo.Other(common.EFromTo.BlobBlob(), common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:              true,
+		blobType:               common.EBlobType.BlockBlob().String(),
+		stripTopDir:            true,
+		disableParallelTesting: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			h.CreateFile(f("failed.txt", with{blobType: common.EBlobType.AppendBlob()}), false)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("moved.txt"),
+		},
+		shouldFail: []interface{}{
+			f("failed.txt", with{blobType: common.EBlobType.BlockBlob()}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
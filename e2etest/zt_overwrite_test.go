@@ -20,8 +20,79 @@
 
 package e2etest
 
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
 // Purpose: Tests of overwrite logic
 
 // Might be able to use afterStart hook in these tests, since it can be used to send arbitrary text to the apps stdin
 // We currently use it to set "open" in the case where --await-open was on the command line, but the afterStart func in
 // execDebuggableWithOutput is not limited to that purpose. _Whatever_ it returns will be sent to AzCopy's stdin.
+
+// TestOverwrite_IfSourceNewer checks that, with --overwrite=ifSourceNewer, existing destination files are replaced only
+// when the source was modified after them
+func TestOverwrite_IfSourceNewer(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesDownAndS2S(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		overwrite: common.EOverwriteOption.IfSourceNewer(),
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// Setup wrote the source before the destination, so every destination file is at least as new as its source.
+			// Make one older than its source. Service LMTs are only accurate to the second, so wait long enough to be sure
+			// the rewritten source is strictly newer
+			h.CreateFile(f("sourceIsNewer.txt"), false)
+			time.Sleep(2 * time.Second)
+			h.CreateFile(f("sourceIsNewer.txt"), true)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"sourceIsNewer.txt",
+			"notAtDestination.txt",
+		},
+		shouldSkip: []interface{}{
+			folder(""),               // the destination folder already exists, so its properties are left alone
+			"destinationIsNewer.txt", // also covers equal LMTs, if the source and destination were written in the same second
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestOverwrite_IfSourceNewerJudgesDestinationAsItIsAtRunTime changes the destination just before the job runs, and
+// checks that --overwrite=ifSourceNewer compares against the changed destination, not the one the test set up
+func TestOverwrite_IfSourceNewerJudgesDestinationAsItIsAtRunTime(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesDownAndS2S(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		overwrite: common.EOverwriteOption.IfSourceNewer(),
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+
+			// give the destination its own, larger, content for a file that is newer there. It must survive the job
+			h.CreateFile(f("destinationIsNewer.txt", with{size: "2K"}), false)
+			a.Assert(len(h.GetDestinationFileContent("destinationIsNewer.txt")), equals(), 2048, "destination content before the job")
+
+			// a newer destination copy would be kept too, but once it's deleted there's nothing to compare with, so the source is copied
+			h.CreateFile(f("goneFromDestination.txt", with{size: "2K"}), false)
+			h.DeleteDestinationFile("goneFromDestination.txt")
+			a.Assert(h.GetDestinationFileProperties("goneFromDestination.txt") == nil, equals(), true, "the deleted file should be gone from the destination")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			a.Assert(len(h.GetDestinationFileContent("destinationIsNewer.txt")), equals(), 2048, "the newer destination file should not be overwritten")
+			a.Assert(len(h.GetDestinationFileContent("goneFromDestination.txt")), equals(), 1024, "the deleted destination file should be copied again")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"goneFromDestination.txt",
+		},
+		shouldSkip: []interface{}{
+			folder(""),
+			"destinationIsNewer.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
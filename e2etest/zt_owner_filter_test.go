@@ -0,0 +1,83 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// ownedByFilterTestOwner is the object id that the owner filter tests make the owner of some files. No such principal
+// needs to exist, for it to own them
+const ownedByFilterTestOwner = "7b3e9d1f-2a4c-4e6b-8d0f-1a3c5e7b9d2f"
+
+// giveOwnerFilterTestFilesTheirOwner is a beforeRunJob hook, that makes ownedByFilterTestOwner the owner of the files
+// whose names say so
+func giveOwnerFilterTestFilesTheirOwner(h hookHelper) {
+	a := h.GetAsserter()
+	for _, name := range []string{"owned.txt", "dir/owned.txt"} {
+		pathURL := adlsDirectoryURL(a, h.GetSource(), name) // the dfs API for the access control of a path is the same for files and folders
+		accessControl, err := pathURL.GetAccessControl(ctx)
+		a.AssertNoErr(err, "getting the access control of "+name)
+		accessControl.Owner = ownedByFilterTestOwner
+		accessControl.Permissions = "" // only one of the ACL and the permissions can be set, and the ACL has them all
+		_, err = pathURL.SetAccessControl(ctx, accessControl)
+		a.AssertNoErr(err, "setting the owner of "+name)
+	}
+}
+
+func TestOwnerFilter_IncludeOwnerCopiesOnlyTheirPaths(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:    true,
+		includeOwner: ownedByFilterTestOwner,
+	}, &hooks{
+		beforeRunJob: giveOwnerFilterTestFilesTheirOwner,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"owned.txt",
+			"dir/owned.txt",
+		},
+		shouldIgnore: []interface{}{
+			"other.txt",
+			"dir/other.txt",
+		},
+	}, EAccountType.HierarchicalNamespaceEnabled(), EAccountType.Standard(), "")
+}
+
+func TestOwnerFilter_ExcludeOwnerSkipsTheirPaths(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:    true,
+		excludeOwner: ownedByFilterTestOwner,
+	}, &hooks{
+		beforeRunJob: giveOwnerFilterTestFilesTheirOwner,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"other.txt",
+			"dir/other.txt",
+		},
+		shouldIgnore: []interface{}{
+			"owned.txt",
+			"dir/owned.txt",
+		},
+	}, EAccountType.HierarchicalNamespaceEnabled(), EAccountType.Standard(), "")
+}
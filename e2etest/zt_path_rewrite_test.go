@@ -0,0 +1,65 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// stripLeadingDir drops the first folder from each path. Whatever is not in a folder is skipped
+func stripLeadingDir(relPath string) string {
+	if i := strings.Index(relPath, "/"); i >= 0 {
+		return relPath[i+1:]
+	}
+	return ""
+}
+
+func TestPathRewrite_StripLeadingDir(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:        true,
+		invertedAsSubdir: true,
+	}, &hooks{
+		rewritePath: stripLeadingDir,
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			layout := make([]string, 0)
+			for name := range h.GetDestination().getAllProperties(a) {
+				layout = append(layout, name)
+			}
+			sort.Strings(layout)
+			a.Assert(strings.Join(layout, ";"), equals(), "a.txt;sub/b.txt;sub/c.txt", "destination layout")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"dir/a.txt",     // to a.txt
+			"dir/sub/b.txt", // to sub/b.txt
+			"dir/sub/c.txt",
+		},
+		shouldIgnore: []interface{}{
+			"top.txt", // rewritten to "", so skipped
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
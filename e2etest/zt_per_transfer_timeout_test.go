@@ -0,0 +1,50 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Purpose: Tests for per-transfer-timeout, which fails a transfer that has stopped making progress.
+// Stalling a real transfer is not practical from here, so the stall itself is covered by the unit tests of the watchdog in ste.
+
+// TestPerTransferTimeout_LargeFilesOutliveTheTimeout tests that the timeout is not a deadline. Each of the larger files takes
+// longer than the timeout to transfer in all, but keeps making progress, so none of them fail.
+func TestPerTransferTimeout_LargeFilesOutliveTheTimeout(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob(), common.EFromTo.BlobLocal(), common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:          true,
+		perTransferTimeout: time.Second,
+		capMbps:            40, // 5 MB/s, so each 16M file takes more than the timeout
+		blockSizeMB:        1,
+	}, nil, testFiles{
+		defaultSize: "16M",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"big1",
+			"big2",
+			f("small", with{size: "1K"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -26,6 +26,19 @@ import (
 	"testing"
 )
 
+// assertDestinationTier checks that all the blobs at the destination are in the expected access tier
+func assertDestinationTier(expected azblob.AccessTierType) func(h hookHelper) {
+	return func(h hookHelper) {
+		a := h.GetAsserter()
+		for name, props := range h.GetDestination().getAllProperties(a) {
+			if props.isFolder {
+				continue
+			}
+			a.Assert(props.accessTier, equals(), expected, "access tier of "+name)
+		}
+	}
+}
+
 func TestTier_V2ToClassicAccount(t *testing.T) {
 
 	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
@@ -85,3 +98,88 @@ func TestTier_V2ToClassicAccountNoPreserveCool(t *testing.T) {
 		},
 	}, EAccountType.Classic(), EAccountType.Standard(), "")
 }
+
+func TestTier_PreservedOnBlobToBlobCopy(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:             true,
+		s2sPreserveAccessTier: true,
+		accessTier:            azblob.AccessTierCool,
+	}, &hooks{
+		afterValidation: assertDestinationTier(azblob.AccessTierCool),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			f("dir/fileb"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestTier_NotPreservedOnBlobToBlobCopy(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:             true,
+		s2sPreserveAccessTier: false,
+		accessTier:            azblob.AccessTierCool,
+	}, &hooks{
+		afterValidation: assertDestinationTier(azblob.AccessTierHot), // the account's default
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestTier_SetOnUpload(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:     true,
+		blockBlobTier: common.EBlockBlobTier.Cool(),
+	}, &hooks{
+		afterValidation: assertDestinationTier(azblob.AccessTierCool),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			folder("dir"),
+			f("dir/fileb"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestTier_SetOnCopyOverridesPreservedTier(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:             true,
+		s2sPreserveAccessTier: true,
+		accessTier:            azblob.AccessTierHot,
+		blockBlobTier:         common.EBlockBlobTier.Cool(),
+	}, &hooks{
+		afterValidation: assertDestinationTier(azblob.AccessTierCool),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestTier_ArchivedSourceFails tests that blobs in the Archive tier fail, rather than being copied, since they can't be
+// read until they are rehydrated
+func TestTier_ArchivedSourceFails(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:             true,
+		s2sPreserveAccessTier: true,
+		accessTier:            azblob.AccessTierArchive,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+		},
+		shouldFail: []interface{}{
+			f("filea", withError{"BlobArchived"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
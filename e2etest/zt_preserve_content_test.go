@@ -21,9 +21,14 @@
 package e2etest
 
 import (
+	"context"
+	"crypto/md5"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
 )
 
 // Purpose: Tests for preserving the content of transferred files. (Including use of MD5 hashes to allow error detection)
@@ -68,6 +73,126 @@ func TestContent_BlobToBlob(t *testing.T) {
 	}, EAccountType.Standard(), EAccountType.Standard(), "")
 }
 
+func TestContent_PutMd5StoresHashOfEveryBlock(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:   true,
+		putMd5:      true,
+		blockSizeMB: 1, // so the hash is computed over many chunks, as they are read
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+			props := h.GetDestination().getAllProperties(a)
+			for _, name := range []string{"filea", "folder1/fileb"} {
+				data, err := ioutil.ReadFile(filepath.Join(srcDir, filepath.FromSlash(name)))
+				a.AssertNoErr(err, "reading source file "+name)
+				expected := md5.Sum(data)
+
+				p, ok := props[name]
+				a.Assert(ok, equals(), true, "destination blob "+name+" should exist")
+				if ok {
+					a.Assert(p.contentHeaders.contentMD5, equals(), expected[:], "Content-MD5 of "+name)
+				}
+			}
+		},
+	}, testFiles{
+		defaultSize: "5M",
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			folder("folder1"),
+			f("folder1/fileb"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// corruptSourceMd5s gives the source blob "corrupt" a Content-MD5 that does not match its content, and removes the
+// Content-MD5 of the source blob "missing". The source blob "intact" keeps its correct hash
+func corruptSourceMd5s(h hookHelper) {
+	a := h.GetAsserter()
+	container := h.GetSource().(*resourceBlobContainer).containerURL
+	for name, hash := range map[string][]byte{
+		"corrupt": make([]byte, md5.Size),
+		"missing": nil,
+	} {
+		blobURL := container.NewBlobURL(name)
+		props, err := blobURL.GetProperties(context.Background(), azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+		a.AssertNoErr(err, "getting the properties of source blob "+name)
+		headers := props.NewHTTPHeaders()
+		headers.ContentMD5 = hash
+		_, err = blobURL.SetHTTPHeaders(context.Background(), headers, azblob.BlobAccessConditions{})
+		a.AssertNoErr(err, "setting the Content-MD5 of source blob "+name)
+	}
+}
+
+func TestContent_CheckMd5NoCheck(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		checkMd5:  common.EHashValidationOption.NoCheck(),
+	}, &hooks{
+		beforeRunJob: corruptSourceMd5s,
+	}, testFiles{
+		defaultSize: "1M",
+		shouldTransfer: []interface{}{
+			"intact",
+			"corrupt",
+			"missing",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestContent_CheckMd5LogOnly(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		checkMd5:  common.EHashValidationOption.LogOnly(),
+	}, &hooks{
+		beforeRunJob: corruptSourceMd5s,
+	}, testFiles{
+		defaultSize: "1M",
+		shouldTransfer: []interface{}{
+			"intact",
+			"corrupt", // the mismatch is only logged
+			"missing",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestContent_CheckMd5FailIfDifferent(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		checkMd5:  common.EHashValidationOption.FailIfDifferent(),
+	}, &hooks{
+		beforeRunJob: corruptSourceMd5s,
+	}, testFiles{
+		defaultSize: "1M",
+		shouldTransfer: []interface{}{
+			"intact",
+			"missing", // nothing to compare against, which this mode allows
+		},
+		shouldFail: []interface{}{
+			"corrupt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestContent_CheckMd5FailIfDifferentOrMissing(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		checkMd5:  common.EHashValidationOption.FailIfDifferentOrMissing(),
+	}, &hooks{
+		beforeRunJob: corruptSourceMd5s,
+	}, testFiles{
+		defaultSize: "1M",
+		shouldTransfer: []interface{}{
+			"intact",
+		},
+		shouldFail: []interface{}{
+			"corrupt",
+			"missing",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
 //func TestChange_ValidateFileContentAtRemote(t *testing.T) {
 //	RunScenarios(
 //		t,
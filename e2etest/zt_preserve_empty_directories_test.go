@@ -0,0 +1,106 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TestPreserveEmptyDirectories_RoundTrip uploads a folder that has empty folders in it with --preserve-empty-directories,
+// which writes a marker blob into each of them, and then downloads it twice: with the flag, which recreates the empty
+// folders from the markers, and without it, which leaves the markers out, since they are directory stubs
+func TestPreserveEmptyDirectories_RoundTrip(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+			emptyFolders := []string{"empty", "a/empty", "a/b/c"}
+			for _, folder := range emptyFolders {
+				a.AssertNoErr(os.MkdirAll(filepath.Join(srcDir, filepath.FromSlash(folder)), 0755), "creating an empty source folder")
+			}
+
+			copyWith := func(p params, source, destination string) {
+				p.recursive = true
+				result, _ := h.RunAzCopy(eOperation.Copy(), p, source, destination)
+				a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "failed transfers")
+			}
+			localFolders := func(root string) (folders []string, files []string) {
+				folders, files = make([]string, 0), make([]string, 0)
+				a.AssertNoErr(filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+					if err != nil || p == root {
+						return err
+					}
+					rel := filepath.ToSlash(strings.TrimPrefix(p, root+string(os.PathSeparator)))
+					if info.IsDir() {
+						folders = append(folders, rel)
+					} else {
+						files = append(files, rel)
+					}
+					return nil
+				}), "listing the downloaded files")
+				sort.Strings(folders)
+				sort.Strings(files)
+				return folders, files
+			}
+
+			// the upload writes a marker into each empty folder, and into no other
+			copyWith(params{invertedAsSubdir: true, preserveEmptyDirectories: true}, srcDir, h.GetDestination().getParam(false, true, "kept"))
+			markers := make([]string, 0)
+			for name, props := range h.GetDestination().getAllProperties(a) {
+				if strings.HasPrefix(name, "kept/") && strings.HasSuffix(name, "/.azcopy-empty-dir") {
+					markers = append(markers, strings.TrimSuffix(strings.TrimPrefix(name, "kept/"), "/.azcopy-empty-dir"))
+					a.Assert(props.nameValueMetadata["hdi_isfolder"], equals(), "true", "markers should be directory stubs")
+				}
+			}
+			sort.Strings(markers)
+			expectedFolders := append([]string{}, emptyFolders...)
+			sort.Strings(expectedFolders)
+			a.Assert(markers, equals(), expectedFolders, "the folders given markers")
+
+			// a download with the flag recreates the empty folders, and doesn't download the markers
+			downloadDir := t.TempDir()
+			copyWith(params{invertedAsSubdir: true, preserveEmptyDirectories: true}, h.GetDestination().getParam(false, true, "kept"), downloadDir)
+			folders, files := localFolders(downloadDir)
+			a.Assert(folders, equals(), []string{"a", "a/b", "a/b/c", "a/empty", "empty"}, "the downloaded folders")
+			a.Assert(files, equals(), []string{"filea", "fileb"}, "the downloaded files, without the markers")
+
+			// a download without it leaves the markers out, as it does other directory stubs
+			plainDir := t.TempDir()
+			copyWith(params{invertedAsSubdir: true}, h.GetDestination().getParam(false, true, "kept"), plainDir)
+			folders, files = localFolders(plainDir)
+			a.Assert(folders, equals(), []string{}, "no folders are downloaded without the flag, since none have files in them")
+			a.Assert(files, equals(), []string{"filea", "fileb"}, "the downloaded files, without the markers")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			"fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,86 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Blob last modified times have whole seconds, and some file systems round to 2 seconds, so allow for that
+const lmtTolerance = 2 * time.Second
+
+// waitSoThatDownloadTimeDiffers makes sure that the time of a download is well after the times of its sources
+func waitSoThatDownloadTimeDiffers(h hookHelper) {
+	time.Sleep(3 * lmtTolerance)
+}
+
+// assertLMTsPreserved checks, for each file, whether its time at the destination is that of its source
+func assertLMTsPreserved(expectPreserved bool) func(h hookHelper) {
+	return func(h hookHelper) {
+		a := h.GetAsserter()
+		srcProps := h.GetSource().getAllProperties(a)
+		for name, dstProps := range h.GetDestination().getAllProperties(a) {
+			src, ok := srcProps[name]
+			if !ok || src.isFolder || src.lastWriteTime == nil || dstProps.lastWriteTime == nil {
+				continue
+			}
+			diff := dstProps.lastWriteTime.Sub(*src.lastWriteTime)
+			if diff < 0 {
+				diff = -diff
+			}
+			a.Assert(diff <= lmtTolerance, equals(), expectPreserved, "time of "+name+" is that of its source, differing by "+diff.String())
+		}
+	}
+}
+
+func TestPreserveLMT_DownloadKeepsSourceTimes(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:   true,
+		preserveLMT: true,
+	}, &hooks{
+		beforeRunJob:    waitSoThatDownloadTimeDiffers,
+		afterValidation: assertLMTsPreserved(true),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			"dir/fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestPreserveLMT_DownloadGetsCurrentTimesByDefault(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob:    waitSoThatDownloadTimeDiffers,
+		afterValidation: assertLMTsPreserved(false),
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			"dir/fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
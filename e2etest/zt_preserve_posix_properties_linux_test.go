@@ -0,0 +1,84 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TestProperties_POSIXModesSurviveRoundTrip uploads files with non-default modes to Blob, downloads them again, and
+// checks that the downloaded copies got the same modes as the originals
+func TestProperties_POSIXModesSurviveRoundTrip(t *testing.T) {
+	modes := map[string]os.FileMode{
+		"readOnly.txt":       0444,
+		"ownerOnly.txt":      0600,
+		"executable.sh":      0755,
+		"sub/groupWrite.txt": 0664,
+	}
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:               true,
+		preservePOSIXProperties: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			srcDir := h.GetSource().getParam(false, false, "")
+			for name, mode := range modes {
+				h.GetAsserter().AssertNoErr(os.Chmod(filepath.Join(srcDir, name), mode), "setting mode of "+name)
+			}
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			restoreDir := TestResourceFactory{}.CreateLocalDirectory(a)
+			defer os.RemoveAll(restoreDir)
+
+			// download what was uploaded, restoring the POSIX properties from the blobs' metadata
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, preservePOSIXProperties: true},
+				h.GetDestination().getParam(false, true, ""), restoreDir)
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "downloading the uploaded files")
+			a.Assert(result.finalStatus.POSIXPropertiesNotRestored, equals(), uint32(0), "files whose POSIX properties were not restored")
+
+			// the upload put the files under a folder named after the source folder
+			restoredRoot := filepath.Join(restoreDir, filepath.Base(h.GetSource().getParam(false, false, "")))
+			for name, mode := range modes {
+				info, err := os.Stat(filepath.Join(restoredRoot, name))
+				a.AssertNoErr(err, "finding restored file "+name)
+				if err == nil {
+					a.Assert(info.Mode().Perm(), equals(), mode, fmt.Sprintf("mode of restored file %s", name))
+				}
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("sub"),
+			"readOnly.txt",
+			"ownerOnly.txt",
+			"executable.sh",
+			"sub/groupWrite.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -21,8 +21,11 @@
 package e2etest
 
 import (
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
@@ -73,3 +76,52 @@ func TestProperties_HNSACLs(t *testing.T) {
 		},
 	}, EAccountType.HierarchicalNamespaceEnabled(), EAccountType.HierarchicalNamespaceEnabled(), "")
 }
+
+// adlsDirectoryURL addresses a directory in an HNS container through the dfs endpoint, which is the one that handles ACLs
+func adlsDirectoryURL(a asserter, container resourceManager, dir string) azbfs.DirectoryURL {
+	dirURL, err := url.Parse(container.getParam(false, true, dir))
+	a.AssertNoErr(err)
+	dirURL.Host = strings.Replace(dirURL.Host, ".blob", ".dfs", 1)
+	return azbfs.NewDirectoryURL(*dirURL, azbfs.NewPipeline(azbfs.NewAnonymousCredential(), azbfs.PipelineOptions{}))
+}
+
+func TestProperties_HNSDirectoryACLsWithDefaults(t *testing.T) {
+	// written in the order in which the service returns the entries, so that they can be compared as strings
+	const namedUser = "0d9a4c3e-5b1f-4e2a-9c7d-3f6b8e1a2c40"
+	customACLs := map[string]string{
+		"dir": "user::rwx,user:" + namedUser + ":r-x,group::r-x,mask::r-x,other::---," +
+			"default:user::rwx,default:user:" + namedUser + ":r-x,default:group::r-x,default:mask::r-x,default:other::---",
+		"dir/sub": "user::rwx,group::rwx,other::r--,default:user::rwx,default:group::---,default:other::---",
+	}
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:           true,
+		preservePermissions: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			for dir, acl := range customACLs {
+				_, err := adlsDirectoryURL(a, h.GetSource(), dir).SetAccessControl(ctx, azbfs.BlobFSAccessControl{ACL: acl})
+				a.AssertNoErr(err)
+			}
+		},
+		afterValidation: func(h hookHelper) {
+			// each directory of the tree has the access and default ACL of its source, rather than what it inherited
+			a := h.GetAsserter()
+			for dir, acl := range customACLs {
+				actual, err := adlsDirectoryURL(a, h.GetDestination(), dir).GetAccessControl(ctx)
+				a.AssertNoErr(err)
+				a.Assert(actual.ACL, equals(), acl, "ACL of "+dir)
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("dir"),
+			"dir/file1",
+			folder("dir/sub"),
+			"dir/sub/file2",
+		},
+	}, EAccountType.HierarchicalNamespaceEnabled(), EAccountType.HierarchicalNamespaceEnabled(), "")
+}
@@ -0,0 +1,97 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-file-go/azfile"
+)
+
+// Files and folders keep their creation time and attributes between shares, and files also keep their last write time.
+// Permissions are preserved alongside, to check that the two compose
+func TestProperties_SMBInfoPreservedBetweenShares(t *testing.T) {
+	fileCreated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fileWritten := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	folderCreated := time.Date(2019, 11, 12, 13, 14, 15, 0, time.UTC)
+	hidden := uint32(azfile.FileAttributeHidden)
+	readOnly := uint32(azfile.FileAttributeReadonly)
+
+	// in creation order, so that the folder exists before the file within it
+	sourceObjects := []*testObject{
+		folder("fold1", with{creationTime: folderCreated, smbAttributes: hidden, smbPermissionsSddl: sddlForAdministrators}),
+		f("filea", with{creationTime: fileCreated, lastWriteTime: fileWritten, smbAttributes: hidden, smbPermissionsSddl: sddlForSystem}),
+		f("fold1/fileb", with{creationTime: fileCreated, lastWriteTime: fileWritten, smbAttributes: readOnly}),
+	}
+
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.FileFile()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:              true,
+		preserveSMBInfo:        true,
+		preserveSMBPermissions: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			for _, o := range sourceObjects {
+				h.CreateFile(o, true)
+			}
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			root := h.GetDestination().(*resourceAzureFileShare).shareURL.NewRootDirectoryURL()
+
+			for _, o := range sourceObjects {
+				var created, written, attributes string
+				if o.isFolder() {
+					props, err := root.NewDirectoryURL(o.name).GetProperties(ctx)
+					a.AssertNoErr(err, "getting the properties of "+o.name)
+					created, written, attributes = props.FileCreationTime(), props.FileLastWriteTime(), props.FileAttributes()
+				} else {
+					props, err := root.NewFileURL(o.name).GetProperties(ctx)
+					a.AssertNoErr(err, "getting the properties of "+o.name)
+					created, written, attributes = props.FileCreationTime(), props.FileLastWriteTime(), props.FileAttributes()
+				}
+
+				creationTime, err := time.Parse(azfile.ISO8601, created)
+				a.AssertNoErr(err)
+				a.Assert(creationTime.Equal(*o.creationProperties.creationTime), equals(), true, "the creation time of "+o.name+" should be preserved")
+
+				// the last write time of folders is not preserved, since it changes as their contents are written
+				if !o.isFolder() {
+					lastWriteTime, err := time.Parse(azfile.ISO8601, written)
+					a.AssertNoErr(err)
+					a.Assert(lastWriteTime.Equal(*o.creationProperties.lastWriteTime), equals(), true, "the last write time of "+o.name+" should be preserved")
+				}
+
+				// the service may add attributes of its own, such as Archive, so just check that ours are there
+				expected := azfile.FileAttributeFlags(*o.creationProperties.smbAttributes)
+				a.Assert(azfile.ParseFileAttributeFlagsString(attributes)&expected, equals(), expected, "the attributes of "+o.name+" should be preserved")
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"filea",
+			folder("fold1"),
+			"fold1/fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,88 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/sddl"
+)
+
+// SDDLs made only of well known SIDs, so that they mean the same thing on any share, whatever machine runs the test
+const sddlForSystem = "O:S-1-5-18G:S-1-5-18D:(A;;FA;;;S-1-5-18)(A;;FA;;;S-1-5-32-544)"
+const sddlForAdministrators = "O:S-1-5-32-544G:S-1-5-32-544D:(A;;FA;;;S-1-5-32-544)(A;;0x1200a9;;;S-1-5-11)"
+
+// Identical descriptors should be registered with the destination share once, so files that had the same SDDL
+// at the source also share one permission key at the destination
+func TestProperties_SMBPermissionsPreservedBetweenShares(t *testing.T) {
+	sddlOf := map[string]string{
+		"filea":       sddlForSystem,
+		"fileb":       sddlForSystem,
+		"fold1/filec": sddlForSystem,
+		"filed":       sddlForAdministrators,
+	}
+
+	RunScenarios(t, eOperation.CopyAndSync(), eTestFromTo.Other(common.EFromTo.FileFile()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:              true,
+		preserveSMBInfo:        true,
+		preserveSMBPermissions: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			for name, perm := range sddlOf {
+				h.CreateFile(f(name, with{smbPermissionsSddl: perm}), true)
+			}
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			shareURL := h.GetDestination().(*resourceAzureFileShare).shareURL
+
+			keyOf := map[string]string{}
+			for name, expected := range sddlOf {
+				props, err := shareURL.NewRootDirectoryURL().NewFileURL(name).GetProperties(ctx)
+				a.AssertNoErr(err, "getting the properties of "+name)
+				keyOf[name] = props.FilePermissionKey()
+
+				perm, err := shareURL.GetPermission(ctx, props.FilePermissionKey())
+				a.AssertNoErr(err, "getting the permission of "+name)
+
+				actualSDDL, err := sddl.ParseSDDL(perm.Permission)
+				a.AssertNoErr(err)
+				expectedSDDL, err := sddl.ParseSDDL(expected)
+				a.AssertNoErr(err)
+				a.Assert(actualSDDL.Compare(expectedSDDL), equals(), true, "the SDDL of "+name+" should be preserved")
+			}
+
+			a.Assert(keyOf["fileb"], equals(), keyOf["filea"], "identical SDDLs should share a permission key")
+			a.Assert(keyOf["fold1/filec"], equals(), keyOf["filea"], "identical SDDLs should share a permission key")
+			a.Assert(keyOf["filed"] == keyOf["filea"], equals(), false, "different SDDLs should not share a permission key")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"filea",
+			"fileb",
+			folder("fold1"),
+			"fold1/filec",
+			"filed",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
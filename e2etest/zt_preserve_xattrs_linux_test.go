@@ -0,0 +1,88 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TestProperties_XattrsSurviveRoundTrip uploads files with user extended attributes to Blob, downloads them again, and
+// checks that the downloaded copies got the same attributes
+func TestProperties_XattrsSurviveRoundTrip(t *testing.T) {
+	xattrs := map[string]map[string][]byte{
+		"tagged.txt":     {"user.origin": []byte("scanner-3"), "user.checksum": []byte{0, 1, 2, 0xff}},
+		"sub/tagged.txt": {"user.reviewed.by": []byte("someone")},
+		"plain.txt":      {},
+	}
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		preserveXattrs: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			srcDir := h.GetSource().getParam(false, false, "")
+			for name, attrs := range xattrs {
+				for attr, value := range attrs {
+					h.GetAsserter().AssertNoErr(unix.Setxattr(filepath.Join(srcDir, name), attr, value, 0), "setting "+attr+" on "+name)
+				}
+			}
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			restoreDir := TestResourceFactory{}.CreateLocalDirectory(a)
+			defer os.RemoveAll(restoreDir)
+
+			// download what was uploaded, restoring the extended attributes from the blobs' metadata
+			result, err := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, preserveXattrs: true}, h.GetDestination().getParam(false, true, ""), restoreDir)
+			a.AssertNoErr(err, "running AzCopy to download the uploaded files")
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "downloading the uploaded files")
+
+			// the upload put the files under a folder named after the source folder
+			restoredRoot := filepath.Join(restoreDir, filepath.Base(h.GetSource().getParam(false, false, "")))
+			for name, attrs := range xattrs {
+				path := filepath.Join(restoredRoot, name)
+				for attr, value := range attrs {
+					buf := make([]byte, 256)
+					n, err := unix.Getxattr(path, attr, buf)
+					a.AssertNoErr(err, "reading "+attr+" of restored file "+name)
+					if err == nil {
+						a.Assert(bytes.Equal(buf[:n], value), equals(), true, "value of "+attr+" of restored file "+name)
+					}
+				}
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("sub"),
+			"tagged.txt",
+			"sub/tagged.txt",
+			"plain.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,70 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+func TestProgressEvents_FinalCountsMatchScheduledTransfers(t *testing.T) {
+	var events []common.ProgressEvent // the hook is only ever called from one goroutine, and the events are all in by the time validation runs
+
+	files := []interface{}{
+		"filea",
+		"fileb",
+		"filec",
+		"dir/filed",
+		"dir/filee",
+	}
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		progressEvent: func(h hookHelper, e common.ProgressEvent) {
+			events = append(events, e)
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			a.Assert(len(events) > 0, equals(), true, "at least the final progress event should have been emitted")
+			if a.Failed() {
+				return
+			}
+
+			for i := 1; i < len(events); i++ {
+				a.Assert(events[i].Sequence > events[i-1].Sequence, equals(), true, "sequence numbers should increase")
+				a.Assert(events[i].TimeStamp.Before(events[i-1].TimeStamp), equals(), false, "timestamps should not go backwards")
+			}
+
+			final := events[len(events)-1]
+			a.Assert(final.JobDone, equals(), true, "the final event should be for the finished job")
+			a.Assert(final.TotalTransfers, equals(), uint32(len(files)), "transfers scheduled")
+			a.Assert(final.TransfersCompleted, equals(), uint32(len(files)), "transfers completed")
+			a.Assert(final.TransfersFailed, equals(), uint32(0), "transfers failed")
+			a.Assert(final.BytesTransferred, equals(), uint64(len(files)*1024), "bytes transferred")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+
+		shouldTransfer: files,
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
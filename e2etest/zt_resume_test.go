@@ -1,7 +1,13 @@
 package e2etest
 
 import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -124,3 +130,223 @@ func TestResume_PublicSource_ContainerTarget(t *testing.T) {
 		EAccountType.Standard(), EAccountType.Standard(), "",
 	)
 }
+
+// TestResume_FromCheckpointPath keeps the job's plan files in a folder of the test's choosing, and resumes the job from
+// there, without giving its ID. Only the transfers that were cancelled should run again, and the file that the original
+// job's filter excluded should stay excluded
+func TestResume_FromCheckpointPath(t *testing.T) {
+	checkpointPath := t.TempDir()
+	longAgo := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	forEachDestFile := func(h hookHelper, f func(name string, path string, info os.FileInfo)) {
+		root := h.GetDestination().getParam(false, false, "")
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				f(info.Name(), path, info)
+			}
+			return err
+		})
+		h.GetAsserter().AssertNoErr(err, "walking the destination")
+	}
+
+	RunScenarios(t, eOperation.Copy()|eOperation.Resume(), eTestFromTo.Other(common.EFromTo.BlobLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		excludePattern: "*.log",
+		checkpointPath: checkpointPath,
+		resumeFrom:     checkpointPath,
+		debugSkipFiles: []string{
+			"/fileb",
+			"/filec",
+		},
+	}, &hooks{
+		beforeResumeHook: func(h hookHelper) {
+			a := h.GetAsserter()
+			plans, err := filepath.Glob(filepath.Join(checkpointPath, "*.steV*"))
+			a.AssertNoErr(err, "listing the checkpoint folder")
+			a.Assert(len(plans) > 0, equals(), true, "the job's plan files should be in the checkpoint folder")
+
+			// backdate what the first run transferred, so that it shows if the resumed job transfers it again
+			forEachDestFile(h, func(name string, path string, _ os.FileInfo) {
+				a.AssertNoErr(os.Chtimes(path, longAgo, longAgo), "backdating "+name)
+			})
+		},
+		afterValidation: func(h hookHelper) {
+			untouched := make(map[string]bool)
+			forEachDestFile(h, func(name string, _ string, info os.FileInfo) {
+				untouched[name] = info.ModTime().Equal(longAgo)
+			})
+			h.GetAsserter().Assert(untouched, equals(), map[string]bool{
+				"filea": true,
+				"fileb": false,
+				"filec": false,
+				"filed": true,
+			}, "only the cancelled transfers should have run again, and the excluded file never")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			f("fileb"),
+			f("filec"),
+			f("filed"),
+		},
+		shouldIgnore: []interface{}{
+			f("excluded.log"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestResume_SyncFromSyncState tests that a sync that did not complete is resumed, by running it again with its sync-state,
+// without the source and destination being scanned again
+func TestResume_SyncFromSyncState(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "syncstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+	statePath := filepath.Join(stateDir, "sync.state")
+
+	RunScenarios(t, eOperation.Sync()|eOperation.Resume(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:     true,
+		syncStatePath: statePath, // one scenario only, since they would share this file
+		debugSkipFiles: []string{
+			"/fileb",
+			"/filec",
+		},
+	}, &hooks{
+		beforeResumeHook: func(h hookHelper) {
+			a := h.GetAsserter()
+			a.Assert(h.GetFinalStatus().FilesScannedAtSource > 0, equals(), true, "the first sync should have scanned the source")
+			_, err := os.Stat(statePath)
+			a.AssertNoErr(err, "the sync state should be kept, since some transfers did not complete")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			status := h.GetFinalStatus()
+			a.Assert(status.FilesScannedAtSource, equals(), uint64(0), "the resumed sync should not scan the source")
+			a.Assert(status.FilesScannedAtDestination, equals(), uint64(0), "the resumed sync should not scan the destination")
+			_, err := os.Stat(statePath)
+			a.Assert(os.IsNotExist(err), equals(), true, "the sync state should be removed once everything is transferred")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			f("fileb"),
+			f("filec"),
+			f("filed"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestResume_RemoveFromCheckpoint interrupts a recursive remove, and resumes it from its plan files. The resumed job
+// should delete only what the first run did not, and should still delete the folder whose deletion the first run queued
+// but could not complete, since a file in it was left behind
+func TestResume_RemoveFromCheckpoint(t *testing.T) {
+	RunScenarios(t, eOperation.Remove()|eOperation.Resume(), eTestFromTo.AllResumableRemove(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		debugSkipFiles: []string{
+			"fileb",
+			"fold1/filec",
+		},
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// each scenario needs a folder of its own, since a folder with more than one job can't be resumed from
+			p := h.GetModifiableParameters()
+			p.checkpointPath = t.TempDir()
+			p.resumeFrom = p.checkpointPath
+		},
+		beforeResumeHook: func(h hookHelper) {
+			a := h.GetAsserter()
+			remaining := h.GetSource().getAllProperties(a)
+			for _, name := range []string{"fileb", "fold1/filec"} {
+				_, ok := remaining[name]
+				a.Assert(ok, equals(), true, name+" should not have been deleted by the interrupted run")
+			}
+			_, ok := remaining["filea"]
+			a.Assert(ok, equals(), false, "filea should have been deleted by the interrupted run")
+
+			// put back a file that was already deleted. If the resumed job deletes it again, it re-attempted a completed deletion
+			h.CreateFile(f("filea"), true)
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			remaining := h.GetSource().getAllProperties(a)
+			_, ok := remaining["filea"]
+			a.Assert(ok, equals(), true, "the resumed job should not delete again what the first run deleted")
+			for _, name := range []string{"fileb", "filed", "fold1/filec", "fold1"} {
+				_, ok := remaining[name]
+				a.Assert(ok, equals(), false, name+" should have been deleted by the resumed job")
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+
+		shouldTransfer: []interface{}{
+			folder(""),
+			f("filea"),
+			f("fileb"),
+			f("filed"),
+			folder("fold1"),
+			f("fold1/filec"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestResume_IntoAnotherContainer interrupts an upload, and resumes it into a second container. The transfers that
+// didn't complete should land there, and those that did should stay where they were, in the original container
+func TestResume_IntoAnotherContainer(t *testing.T) {
+	var second azblob.ContainerURL
+	blobNames := func(a asserter, containerURL azblob.ContainerURL) map[string]bool {
+		names := map[string]bool{}
+		for marker := (azblob.Marker{}); marker.NotDone(); {
+			resp, err := containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{})
+			a.AssertNoErr(err, "listing "+containerURL.String())
+			if err != nil {
+				break
+			}
+			for _, blob := range resp.Segment.BlobItems {
+				names[path.Base(blob.Name)] = true
+			}
+			marker = resp.NextMarker
+		}
+		return names
+	}
+
+	RunScenarios(t, eOperation.Copy()|eOperation.Resume(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		debugSkipFiles: []string{
+			"/fileb",
+			"/filec",
+		},
+	}, &hooks{
+		beforeResumeHook: func(h hookHelper) {
+			var rawURL url.URL
+			second, _, rawURL = TestResourceFactory{}.CreateNewContainer(h.GetAsserter(), azblob.PublicAccessNone, EAccountType.Standard())
+			h.GetModifiableParameters().resumeDestination = rawURL.String()
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			defer second.Delete(ctx, azblob.ContainerAccessConditions{})
+
+			original := *h.GetDestination().(*resourceBlobContainer).containerURL
+			a.Assert(blobNames(a, original), equals(), map[string]bool{"filea": true, "filed": true},
+				"the files that the first run uploaded should stay in the original container, and no more should be written there")
+			a.Assert(blobNames(a, second), equals(), map[string]bool{"fileb": true, "filec": true},
+				"the files that the first run didn't upload should be in the container the job was resumed into")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+
+		shouldTransfer: []interface{}{
+			f("filea"),
+			f("fileb"),
+			f("filec"),
+			f("filed"),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
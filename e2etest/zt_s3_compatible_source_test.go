@@ -0,0 +1,65 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Purpose: Tests of S3-compatible services other than AWS as a source, such as MinIO. All of these are skipped unless
+// AZCOPY_E2E_S3_ENDPOINT, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are set. Set AZCOPY_E2E_S3_SKIP_SSL_VERIFY too
+// for a service with a self-signed certificate.
+
+func TestS3Compatible_CopyToBlob(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.S3Blob()), eValidate.AutoPlusContent(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""), // S3 has no real folders, so the framework expects none of these at the destination
+			"filea",
+			folder("a"),
+			"a/fileb",
+			folder("a/b"),
+			"a/b/filec",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestS3Compatible_ContentPropertiesArePreserved(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.S3Blob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("filea", with{
+				contentType:        "text/plain",
+				contentEncoding:    "identity",
+				contentLanguage:    "en",
+				contentDisposition: "inline",
+				cacheControl:       "no-cache",
+				nameValueMetadata:  map[string]string{"foo": "abc", "bar": "def"},
+			}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,146 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-file-go/azfile"
+)
+
+// Purpose: Tests for copying from an Azure Files share snapshot, given by the sharesnapshot parameter of the source URL
+
+// snapshotThenChangeLiveShare snapshots the source share, which then becomes the source of the job. After that, the live
+// share is changed, so that only a copy of the snapshot will match what the test files expect: "deleted.txt" and
+// "sub/deleted.txt" are deleted, "changed.txt" is made bigger, and "added.txt" is created
+func snapshotThenChangeLiveShare(h hookHelper) {
+	a := h.GetAsserter()
+	h.CreateSourceSnapshot()
+
+	root := h.GetSource().(*resourceAzureFileShare).shareURL.NewRootDirectoryURL()
+	for _, name := range []string{"deleted.txt", "sub/deleted.txt"} {
+		parts := strings.Split(name, "/")
+		dir := root
+		for _, d := range parts[:len(parts)-1] {
+			dir = dir.NewDirectoryURL(d)
+		}
+		_, err := dir.NewFileURL(parts[len(parts)-1]).Delete(context.Background())
+		a.AssertNoErr(err, "deleting "+name+" from the live share")
+	}
+	h.CreateFile(f("changed.txt", with{size: "2K"}), true)
+	h.CreateFile(f("added.txt"), true)
+}
+
+// expectSnapshotSizeOfChangedFile checks that "changed.txt" was downloaded as it was in the snapshot
+func expectSnapshotSizeOfChangedFile(h hookHelper) {
+	a := h.GetAsserter()
+	info, err := os.Stat(filepath.Join(h.GetDestination().getParam(false, false, ""), "changed.txt"))
+	a.AssertNoErr(err, "finding changed.txt at the destination")
+	if err == nil {
+		a.Assert(info.Size(), equals(), int64(1024), "changed.txt should have its size in the snapshot")
+	}
+}
+
+func TestShareSnapshot_CopiesSnapshotNotLiveShare(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.FileLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob:    snapshotThenChangeLiveShare,
+		afterValidation: expectSnapshotSizeOfChangedFile,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"changed.txt",
+			"deleted.txt",
+			"kept.txt",
+			folder("sub"),
+			"sub/deleted.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestShareSnapshot_WithFilters tests that filters apply to a snapshot just as they do to the live share
+func TestShareSnapshot_WithFilters(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.FileLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		includePattern: "*.txt",
+		excludePath:    "sub/skipped",
+	}, &hooks{
+		beforeRunJob:    snapshotThenChangeLiveShare,
+		afterValidation: expectSnapshotSizeOfChangedFile,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldIgnore: []interface{}{
+			folder(""), // the include pattern only applies to files, so no folders are transferred
+			"notes.log",
+			"sub/skipped/a.txt",
+		},
+		shouldTransfer: []interface{}{
+			"changed.txt",
+			"deleted.txt",
+			"sub/deleted.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestShareSnapshot_MissingSnapshotIsAnError copies from a snapshot that doesn't exist, which must fail without writing
+// anything. That copy is run apart from the scenario, since a scenario can't expect the whole job to fail. The scenario
+// then copies the live share, to the same destination, as usual
+func TestShareSnapshot_MissingSnapshotIsAnError(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.FileLocal()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+
+			source, err := url.Parse(h.GetSource().getParam(false, true, ""))
+			a.AssertNoErr(err)
+			parts := azfile.NewFileURLParts(*source)
+			parts.ShareSnapshot = "2000-01-01T00:00:00.0000000Z"
+			missing := parts.URL()
+
+			destination := h.GetDestination().getParam(false, false, "")
+			_, err = h.RunAzCopy(eOperation.Copy(), params{recursive: true}, missing.String(), destination)
+			a.Assert(err != nil, equals(), true, "copying from a snapshot that does not exist should fail")
+			if err != nil {
+				a.Assert(strings.Contains(err.Error(), "does not exist"), equals(), true, "the error should say that the snapshot does not exist: "+err.Error())
+			}
+
+			written, err := ioutil.ReadDir(destination)
+			a.AssertNoErr(err, "listing the destination")
+			a.Assert(len(written), equals(), 0, "nothing should be written by a copy from a snapshot that does not exist")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"file1",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
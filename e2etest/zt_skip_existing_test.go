@@ -0,0 +1,75 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Purpose: Tests for skip-existing-with-same-size, which skips the destination files that already exist with the source's size
+
+func TestSkipExisting_SameSizeIsSkippedOtherSizesTransfer(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob(), common.EFromTo.FileFile()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:            true,
+		skipExistingSameSize: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			// the content of these differs from the source, but only the sizes are compared. So the ones with the
+			// same size are skipped anyway, which is the intended trade-off
+			h.CreateFile(f("same.txt"), false)
+			h.CreateFile(f("sub dir/same.txt"), false) // needs its path to be escaped, at a remote destination
+			h.CreateFile(f("bigger.txt", with{size: "2K"}), false)
+			h.CreateFile(f("empty.txt", with{size: "0"}), false)
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"bigger.txt",
+			"empty.txt",
+			"new.txt",
+			folder("sub dir"),
+			"sub dir/new.txt",
+		},
+		shouldSkip: []interface{}{
+			"same.txt",
+			"sub dir/same.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestSkipExisting_EmptyDestination tests that, when the destination has nothing in it yet, everything is copied
+func TestSkipExisting_EmptyDestination(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.AllSourcesToOneDest(), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:            true,
+		skipExistingSameSize: true,
+	}, nil, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"file1",
+			folder("dir"),
+			"dir/file2",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,88 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// sourceSnapshotCount counts the snapshots in the blob container that is the source of the scenario
+func sourceSnapshotCount(h hookHelper) int {
+	a := h.GetAsserter()
+	containerURL := h.GetSource().(*resourceBlobContainer).containerURL
+	count := 0
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{
+			Details: azblob.BlobListingDetails{Snapshots: true}})
+		a.AssertNoErr(err, "listing the snapshots of the source")
+		for _, item := range resp.Segment.BlobItems {
+			if item.Snapshot != "" {
+				count++
+			}
+		}
+		marker = resp.NextMarker
+	}
+	return count
+}
+
+// TestSnapshotSource_CopiesWhatWasThereWhenSnapshotted writes over the source blob after AzCopy has snapshotted it,
+// but before the copy starts, and checks that the destination gets the content from before the write,
+// and that the snapshot is gone afterwards
+func TestSnapshotSource_CopiesWhatWasThereWhenSnapshotted(t *testing.T) {
+	const blobName = "filea"
+	var snapshottedContent []byte
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:           true,
+		snapshotSourceFirst: true,
+	}, &hooks{
+		beforeOpenFirstFile: func(h hookHelper) {
+			a := h.GetAsserter()
+			for deadline := time.Now().Add(time.Minute); sourceSnapshotCount(h) == 0; time.Sleep(time.Second) {
+				if time.Now().After(deadline) {
+					a.Error("AzCopy did not snapshot the source")
+					return
+				}
+			}
+			snapshottedContent = h.GetSource().downloadContent(a, downloadContentOptions{resourceRelPath: blobName})
+
+			// new random content, written to the blob that AzCopy has scanned and snapshotted, but not yet copied
+			h.CreateFile(f(blobName), true)
+			a.Assert(bytes.Equal(h.GetSource().downloadContent(a, downloadContentOptions{resourceRelPath: blobName}), snapshottedContent),
+				equals(), false, "the source should have changed")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			a.Assert(bytes.Equal(h.GetDestinationFileContent(blobName), snapshottedContent), equals(), true,
+				"the destination should hold what the source held when it was snapshotted")
+			a.Assert(sourceSnapshotCount(h), equals(), 0, "the snapshot of the source should have been deleted")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			blobName,
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,136 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// writeSourceArchive makes a tar or zip file holding the given folders and files, in the given order
+func writeSourceArchive(a asserter, archivePath string, format common.ArchiveFormat, folders []string, names []string, contents map[string][]byte) {
+	f, err := os.Create(archivePath)
+	a.AssertNoErr(err, "creating the source archive")
+	defer f.Close()
+
+	if format == common.EArchiveFormat.Zip() {
+		zw := zip.NewWriter(f)
+		for _, name := range folders {
+			_, err = zw.Create(name + "/")
+			a.AssertNoErr(err, "adding folder "+name)
+		}
+		for _, name := range names {
+			w, err := zw.Create(name) // compressed, so it's read as a stream
+			a.AssertNoErr(err, "adding entry "+name)
+			_, err = w.Write(contents[name])
+			a.AssertNoErr(err, "adding entry "+name)
+		}
+		a.AssertNoErr(zw.Close(), "finishing the zip")
+		return
+	}
+
+	tw := tar.NewWriter(f)
+	for _, name := range folders {
+		a.AssertNoErr(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: 0755}), "adding folder "+name)
+	}
+	for _, name := range names {
+		a.AssertNoErr(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(contents[name])), Mode: 0644}), "adding entry "+name)
+		_, err = tw.Write(contents[name])
+		a.AssertNoErr(err, "adding entry "+name)
+	}
+	a.AssertNoErr(tw.Close(), "finishing the tar")
+}
+
+// TestSourceArchive_EntriesAreUploadedAsBlobs uploads a tar and a zip with source-archive, and checks that each entry
+// that passes the include and exclude patterns becomes a blob of its own, with the entry's content. The scenario's
+// sources are a folder, not an archive, so its own run only gives the test a container; the archives are uploaded by
+// runs of their own
+func TestSourceArchive_EntriesAreUploadedAsBlobs(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+
+			_, large := getRandomDataAndReader(5 * 1024 * 1024) // several chunks, at a 1 MB block size
+			contents := map[string][]byte{
+				"readme.txt":          []byte("read me"),
+				"dir/a.txt":           []byte("a"),
+				"dir/sub/large.bin":   large,
+				"dir/skip-me.txt":     []byte("excluded by name"),
+				"dir/sub/picture.png": []byte("not included"),
+			}
+			names := []string{"readme.txt", "dir/a.txt", "dir/sub/large.bin", "dir/skip-me.txt", "dir/sub/picture.png"}
+			expected := []string{"readme.txt", "dir/a.txt", "dir/sub/large.bin"}
+
+			for _, format := range []common.ArchiveFormat{common.EArchiveFormat.Tar(), common.EArchiveFormat.Zip()} {
+				archiveDir := TestResourceFactory{}.CreateLocalDirectory(a)
+				defer os.RemoveAll(archiveDir)
+				archivePath := filepath.Join(archiveDir, "source."+format.String())
+				writeSourceArchive(a, archivePath, format, []string{"dir", "dir/sub"}, names, contents)
+
+				prefix := "expanded-" + format.String() + "/"
+				result, _ := h.RunAzCopy(eOperation.Copy(), params{
+					recursive:      true,
+					sourceArchive:  format,
+					includePattern: "*.txt;*.bin",
+					excludePattern: "skip*",
+					blockSizeMB:    1,
+				}, archivePath, h.GetDestination().getParam(false, true, prefix))
+				a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "uploading the entries of the "+format.String())
+
+				listing, err := containerURL.ListBlobsFlatSegment(ctx, azblob.Marker{}, azblob.ListBlobsSegmentOptions{Prefix: prefix})
+				a.AssertNoErr(err, "listing what was uploaded")
+				uploaded := make(map[string]bool)
+				for _, blob := range listing.Segment.BlobItems {
+					uploaded[blob.Name[len(prefix):]] = true
+				}
+				want := make(map[string]bool)
+				for _, name := range expected {
+					want[name] = true
+				}
+				a.Assert(uploaded, equals(), want, "the blobs made from the entries of the "+format.String())
+
+				for _, name := range expected {
+					resp, err := containerURL.NewBlobURL(prefix+name).Download(ctx, 0, 0, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+					a.AssertNoErr(err, "downloading "+name)
+					body, err := ioutil.ReadAll(resp.Body(azblob.RetryReaderOptions{}))
+					a.AssertNoErr(err, "downloading "+name)
+					a.Assert(bytes.Equal(body, contents[name]), equals(), true, "content of "+name)
+				}
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
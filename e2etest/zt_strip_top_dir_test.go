@@ -0,0 +1,56 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// nestedFiles is a source with files at several depths, so that where each lands shows whether the top directory was kept
+var nestedFiles = testFiles{
+	defaultSize: "1K",
+	shouldTransfer: []interface{}{
+		"top.txt",
+		"dir/nested.txt",
+		"dir/sub/deep.txt",
+	},
+}
+
+// TestStripTopDir_TrueLeavesOutTopDirWithoutWildcard copies a source folder without a trailing /*, which would normally
+// put it under a folder of its own name. With --strip-top-dir=true, its files go straight under the container
+func TestStripTopDir_TrueLeavesOutTopDirWithoutWildcard(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:       true,
+		stripTopDirFlag: "true",
+	}, nil, nestedFiles, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestStripTopDir_FalseKeepsTopDirWithWildcard copies a source folder with a trailing /*, which would normally put its
+// files straight under the destination. With --strip-top-dir=false, they go under a folder named after the source folder
+func TestStripTopDir_FalseKeepsTopDirWithWildcard(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:       true,
+		stripTopDir:     true,
+		stripTopDirFlag: "false",
+	}, nil, nestedFiles, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,207 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// The symlink tests all upload the same small graph of links:
+//
+//	b.txt
+//	dir/a.txt
+//	dir/loop      -> ..      (a cycle, back to the root of the source)
+//	linkToFile    -> b.txt
+//	linkToDir     -> <a folder outside the source>, which holds c.txt, and again -> itself (another cycle)
+//
+// The test files name each link, so that the framework creates something with that name at the source. The
+// beforeRunJob hook then swaps those placeholders for the real links.
+type symlinkGraph struct {
+	outsideDir string
+}
+
+func newSymlinkGraph(t *testing.T) symlinkGraph {
+	outsideDir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(outsideDir, "c.txt"), []byte("outside the source"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(outsideDir, "again")); err != nil {
+		t.Fatal(err)
+	}
+	return symlinkGraph{outsideDir: outsideDir}
+}
+
+// links returns each link's path, relative to the source root, and its target
+func (g symlinkGraph) links() map[string]string {
+	return map[string]string{
+		"dir/loop":   "..",
+		"linkToFile": "b.txt",
+		"linkToDir":  g.outsideDir,
+	}
+}
+
+func (g symlinkGraph) createLinks(h hookHelper) {
+	a := h.GetAsserter()
+	srcDir := h.GetSource().getParam(false, false, "")
+	for name, target := range g.links() {
+		linkPath := filepath.Join(srcDir, filepath.FromSlash(name))
+		a.AssertNoErr(os.RemoveAll(linkPath), "removing placeholder for "+name)
+		a.AssertNoErr(os.Symlink(target, linkPath), "creating symlink "+name)
+	}
+}
+
+func TestSymlinks_SkippedByDefault(t *testing.T) {
+	g := newSymlinkGraph(t)
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: g.createLinks,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("dir"),
+			"dir/a.txt",
+			"b.txt",
+		},
+		shouldIgnore: []interface{}{
+			"dir/loop",
+			"linkToFile",
+			"linkToDir",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestSymlinks_Followed(t *testing.T) {
+	g := newSymlinkGraph(t)
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:                 true,
+		symlinkHandling:           common.ESymlinkHandlingType.Follow(),
+		followSymlinksOutsideRoot: true,
+	}, &hooks{
+		beforeRunJob: g.createLinks,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("dir"),
+			"dir/a.txt",
+			"b.txt",
+			"linkToFile",        // with the content of b.txt
+			folder("linkToDir"), // and the folder outside the source, under the name of the link
+			"linkToDir/c.txt",
+		},
+		shouldIgnore: []interface{}{
+			"dir/loop", // leads back to the root, which is already being transferred, so isn't followed again. Nor is linkToDir/again
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// By default, links are only followed if they lead somewhere inside the source. linkToDir leads out of it, so it is
+// skipped with a warning, which doesn't fail the job
+func TestSymlinks_FollowedOnlyWithinRoot(t *testing.T) {
+	g := newSymlinkGraph(t)
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:       true,
+		symlinkHandling: common.ESymlinkHandlingType.Follow(),
+	}, &hooks{
+		beforeRunJob: g.createLinks,
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			status := h.GetFinalStatus()
+			a.Assert(status.TransfersFailed, equals(), uint32(0), "skipping a link that leads outside the source should not fail a transfer")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("dir"),
+			"dir/a.txt",
+			"b.txt",
+			"linkToFile", // leads to b.txt, which is inside the source, so is followed
+		},
+		shouldIgnore: []interface{}{
+			"dir/loop",
+			"linkToDir",
+			"linkToDir/c.txt",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// TestSymlinks_PreservedAndRecreated uploads the links themselves, then downloads them again, and checks that they
+// come back as links to the same targets. The exception is linkToDir, whose target is an absolute path, and so is
+// refused on download rather than planted on the downloading machine
+func TestSymlinks_PreservedAndRecreated(t *testing.T) {
+	g := newSymlinkGraph(t)
+
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:       true,
+		symlinkHandling: common.ESymlinkHandlingType.Preserve(),
+	}, &hooks{
+		beforeRunJob: g.createLinks,
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			restoreDir := TestResourceFactory{}.CreateLocalDirectory(a)
+			defer os.RemoveAll(restoreDir)
+
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, symlinkHandling: common.ESymlinkHandlingType.Preserve()},
+				h.GetDestination().getParam(false, true, ""), restoreDir)
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(1), "downloading the uploaded links, of which only linkToDir should fail")
+
+			// the upload put everything under a folder named after the source folder
+			restoredRoot := filepath.Join(restoreDir, filepath.Base(h.GetSource().getParam(false, false, "")))
+			_, err := os.Lstat(filepath.Join(restoredRoot, "linkToDir"))
+			a.Assert(os.IsNotExist(err), equals(), true, "linkToDir leads outside of the destination, so it should not be created")
+			for name, target := range g.links() {
+				if name == "linkToDir" {
+					continue
+				}
+				actual, err := os.Readlink(filepath.Join(restoredRoot, filepath.FromSlash(name)))
+				a.AssertNoErr(err, "reading restored symlink "+name)
+				a.Assert(actual, equals(), target, "target of restored symlink "+name)
+			}
+			info, err := os.Lstat(filepath.Join(restoredRoot, "b.txt"))
+			a.AssertNoErr(err, "finding restored file b.txt")
+			if err == nil {
+				a.Assert(info.Mode().IsRegular(), equals(), true, "b.txt should be restored as a regular file")
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			folder("dir"),
+			"dir/a.txt",
+			"b.txt",
+			"dir/loop", // each link is sent as a link, so nothing is walked through it, and the cycles don't matter
+			"linkToFile",
+			"linkToDir",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
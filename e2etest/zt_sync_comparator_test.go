@@ -0,0 +1,140 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// uploadSourcesWithMD5 puts a copy of each source file at the destination, with its MD5, before the sync runs. The
+// framework would give files that it creates at the destination content of their own, so AzCopy makes the copies
+func uploadSourcesWithMD5(h hookHelper) {
+	result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, putMd5: true},
+		h.GetSource().getParam(false, false, ""), h.GetDestination().getParam(false, true, ""))
+	h.GetAsserter().Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "uploading the sources before the sync")
+}
+
+// uploadThenTouchSources uploads the source files, as uploadSourcesWithMD5 does. Then every source file is made newer
+// than its copy, and "changed" is also given new content. So only "changed" really differs, although all the source
+// files look more recent
+func uploadThenTouchSources(h hookHelper) {
+	a := h.GetAsserter()
+	srcDir := h.GetSource().getParam(false, false, "")
+	uploadSourcesWithMD5(h)
+
+	a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "changed"), bytes.Repeat([]byte("x"), 1024), 0644), "changing a source file")
+	newer := time.Now().Add(time.Hour)
+	for _, name := range []string{"filea", "fileb", "changed"} {
+		a.AssertNoErr(os.Chtimes(filepath.Join(srcDir, name), newer, newer), "touching source file "+name)
+	}
+}
+
+func TestSync_CompareByMD5SkipsTouchedButIdenticalFiles(t *testing.T) {
+	RunScenarios(t, eOperation.Sync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:      true,
+		syncComparator: common.ESyncComparator.MD5(),
+	}, &hooks{
+		beforeRunJob: uploadThenTouchSources,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"changed",
+		},
+		shouldSkip: []interface{}{
+			"filea",
+			"fileb",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestSync_CompareByLastModifiedTimeTransfersTouchedFiles(t *testing.T) {
+	RunScenarios(t, eOperation.Sync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true, // the default comparator, by last modified time
+	}, &hooks{
+		beforeRunJob: uploadThenTouchSources,
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"filea",
+			"fileb",
+			"changed",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+// With exclude-if-destination-newer, a file that was edited at the destination after the source was last changed is
+// kept, even though comparing by MD5 finds that it differs. A file that is newer at the source is still synced, and
+// delete-destination still deletes a destination file that isn't at the source, however recent it is
+func TestSync_ExcludeIfDestinationNewerKeepsDestinationEdits(t *testing.T) {
+	const edited = "edited at the destination"
+	RunScenarios(t, eOperation.Sync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:                 true,
+		syncComparator:            common.ESyncComparator.MD5(),
+		excludeIfDestinationNewer: true,
+		deleteDestination:         common.EDeleteDestination.True(),
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+			uploadSourcesWithMD5(h)
+
+			// the source of "kept" is older than its destination, which is then edited
+			older := time.Now().Add(-time.Hour)
+			a.AssertNoErr(os.Chtimes(filepath.Join(srcDir, "kept"), older, older), "making a source file older")
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+			for name, content := range map[string]string{"kept": edited, "extra": "only at the destination"} {
+				_, err := azblob.UploadBufferToBlockBlob(ctx, []byte(content), containerURL.NewBlockBlobURL(name), azblob.UploadToBlockBlobOptions{})
+				a.AssertNoErr(err, "writing "+name+" at the destination")
+			}
+
+			// while "changed" is changed at the source, after its destination
+			newer := time.Now().Add(time.Hour)
+			a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "changed"), bytes.Repeat([]byte("x"), 1024), 0644), "changing a source file")
+			a.AssertNoErr(os.Chtimes(filepath.Join(srcDir, "changed"), newer, newer), "making a source file newer")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+
+			kept := h.GetDestination().downloadContent(a, downloadContentOptions{resourceRelPath: "kept"})
+			a.Assert(string(kept), equals(), edited, "a file that is newer at the destination should not be overwritten")
+
+			_, err := containerURL.NewBlobURL("extra").GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+			a.Assert(err, notEquals(), nil, "a destination file that isn't at the source should still be deleted")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"changed",
+		},
+		shouldSkip: []interface{}{
+			"kept",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
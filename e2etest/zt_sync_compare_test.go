@@ -0,0 +1,90 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package e2etest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TestCompare_SortsFilesByHowTheyDiffer uploads the files, and then makes the two ends diverge before comparing them:
+// "onlyAtSource" is deleted from the destination, "onlyAtDestination" is added there, "changed" gets new content at the
+// source and "touched" only a new last modified time. So comparing by last modified time finds two files that differ,
+// where comparing by MD5 finds one. Neither comparison may change the destination
+func TestCompare_SortsFilesByHowTheyDiffer(t *testing.T) {
+	RunScenarios(t, eOperation.Compare(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+
+			uploadSourcesWithMD5(h)
+			h.DeleteDestinationFile("onlyAtSource")
+			h.CreateFile(f("onlyAtDestination"), false)
+			newer := time.Now().Add(time.Hour)
+			a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "changed"), bytes.Repeat([]byte("x"), 2048), 0644), "changing a source file")
+			for _, name := range []string{"touched", "changed"} {
+				a.AssertNoErr(os.Chtimes(filepath.Join(srcDir, name), newer, newer), "touching source file "+name)
+			}
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			report := h.GetCompareReport()
+			a.Assert(*report, equals(), common.SyncCompareReport{
+				OnlyInSource:      []string{"onlyAtSource"},
+				OnlyInDestination: []string{"onlyAtDestination"},
+				Differing:         []string{"changed", "touched"},
+				Identical:         []string{"same"},
+			}, "the files sorted by last modified time")
+
+			result, _ := h.RunAzCopy(eOperation.Compare(), params{recursive: true, syncComparator: common.ESyncComparator.MD5()},
+				h.GetSource().getParam(false, false, ""), h.GetDestination().getParam(false, true, ""))
+			a.Assert(result.compareReport, notEquals(), (*common.SyncCompareReport)(nil), "comparing by MD5 should end with its report")
+			if result.compareReport != nil {
+				a.Assert(*result.compareReport, equals(), common.SyncCompareReport{
+					OnlyInSource:      []string{"onlyAtSource"},
+					OnlyInDestination: []string{"onlyAtDestination"},
+					Differing:         []string{"changed"},
+					Identical:         []string{"same", "touched"},
+				}, "the files sorted by MD5")
+			}
+
+			// the destination is as the test left it
+			a.Assert(h.GetDestinationFileProperties("onlyAtSource") == nil, equals(), true, "a comparison should not upload anything")
+			a.Assert(h.GetDestinationFileProperties("onlyAtDestination") != nil, equals(), true, "a comparison should not delete anything")
+			a.Assert(len(h.GetDestinationFileContent("changed")), equals(), 1024, "a comparison should not overwrite anything")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"same",
+			"touched",
+			"changed",
+			"onlyAtSource",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,89 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// TestSync_IncludeAfterOnlyTransfersFilesInTheWindow syncs only the files changed in the last day. A file that changed
+// before then is left alone at the destination, even though delete-destination is on, while a file that is
+// really gone from the source is still deleted
+func TestSync_IncludeAfterOnlyTransfersFilesInTheWindow(t *testing.T) {
+	RunScenarios(t, eOperation.Sync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:         true,
+		deleteDestination: common.EDeleteDestination.Prompt(),
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+
+			// the files to be skipped are already at the destination, as the framework puts them there. So is "changed"
+			h.CreateFile(f("changed"), false)
+
+			// then change the sources: "old" changes outside the window, and "changed" inside it.
+			// "recent" is in the window too, but its copy at the destination is newer, so the comparison still skips it
+			now := time.Now()
+			a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "old"), bytes.Repeat([]byte("o"), 1024), 0644), "changing old")
+			a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "changed"), bytes.Repeat([]byte("c"), 1024), 0644), "changing changed")
+			for name, lmt := range map[string]time.Time{"old": now.Add(-48 * time.Hour), "recent": now.Add(-time.Hour), "changed": now.Add(time.Hour)} {
+				a.AssertNoErr(os.Chtimes(filepath.Join(srcDir, name), lmt, lmt), "setting the time of "+name)
+			}
+
+			h.GetModifiableParameters().includeAfter = now.Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+		},
+		confirmDeletion: func(h hookHelper, relativePath string) bool {
+			if name := path.Base(relativePath); name == "old" || name == "recent" {
+				h.GetAsserter().Error("sync offered to delete " + name + ", which still exists at the source")
+				return false
+			}
+			return true
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			remaining := h.GetDestination().getAllProperties(a)
+			for _, name := range []string{"old", "recent", "changed"} {
+				_, exists := remaining[name]
+				a.Assert(exists, equals(), true, name+" should still be at the destination")
+			}
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"changed",
+		},
+		shouldSkip: []interface{}{
+			"old",
+			"recent",
+		},
+		destinationOnly: []interface{}{
+			"gone", // not at the source at all, whatever its time, so it's still deleted
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,96 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// TestSync_DryrunSortsFilesToAddUpdateAndDelete changes the source after the sync, and then checks what a dry run
+// would do about it. Only "changed" has new content, so comparing by MD5 finds one file to update where comparing by
+// last modified time finds two. Neither of the dry runs may change the destination
+func TestSync_DryrunSortsFilesToAddUpdateAndDelete(t *testing.T) {
+	RunScenarios(t, eOperation.Sync(), eTestFromTo.Other(common.EFromTo.LocalBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+		putMd5:    true,
+	}, &hooks{
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			srcDir := h.GetSource().getParam(false, false, "")
+
+			newer := time.Now().Add(time.Hour)
+			a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "changed"), bytes.Repeat([]byte("x"), 2048), 0644), "changing a source file")
+			for _, name := range []string{"touched", "changed"} {
+				a.AssertNoErr(os.Chtimes(filepath.Join(srcDir, name), newer, newer), "touching source file "+name)
+			}
+			a.AssertNoErr(os.Remove(filepath.Join(srcDir, "removed")), "removing a source file")
+			a.AssertNoErr(ioutil.WriteFile(filepath.Join(srcDir, "added"), bytes.Repeat([]byte("y"), 3000), 0644), "adding a source file")
+
+			dryrun := func(comparator common.SyncComparator) common.SyncDryrunSummary {
+				result, _ := h.RunAzCopy(eOperation.Sync(), params{recursive: true, dryRun: true, deleteDestination: common.EDeleteDestination.True(), syncComparator: comparator},
+					srcDir, h.GetDestination().getParam(false, true, ""))
+				a.Assert(result.syncDryrunSummary, notEquals(), (*common.SyncDryrunSummary)(nil), "the dry run should end with its totals")
+				if result.syncDryrunSummary == nil {
+					return common.SyncDryrunSummary{}
+				}
+				return *result.syncDryrunSummary
+			}
+
+			summary := dryrun(common.ESyncComparator.LastModifiedTime())
+			a.Assert(summary.FilesToAdd, equals(), uint64(1), "files to add")
+			a.Assert(summary.BytesToAdd, equals(), uint64(3000), "bytes to add")
+			a.Assert(summary.FilesToUpdate, equals(), uint64(2), "files to update, by last modified time")
+			a.Assert(summary.BytesToUpdate, equals(), uint64(1024+2048), "bytes to update, by last modified time")
+			a.Assert(summary.FilesToDelete, equals(), uint64(1), "files to delete")
+			a.Assert(summary.BytesToDelete, equals(), uint64(1024), "bytes to delete")
+			a.Assert(summary.FileCount, equals(), uint64(3), "files to transfer, by last modified time")
+
+			summary = dryrun(common.ESyncComparator.MD5())
+			a.Assert(summary.FilesToAdd, equals(), uint64(1), "files to add")
+			a.Assert(summary.FilesToUpdate, equals(), uint64(1), "files to update, by MD5")
+			a.Assert(summary.BytesToUpdate, equals(), uint64(2048), "bytes to update, by MD5")
+			a.Assert(summary.FilesToDelete, equals(), uint64(1), "files to delete")
+
+			// the destination is as the first sync left it
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+			_, err := containerURL.NewBlobURL("removed").GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+			a.AssertNoErr(err, "a dry run should not delete anything")
+			_, err = containerURL.NewBlobURL("added").GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+			a.Assert(err, notEquals(), nil, "a dry run should not upload anything")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			"same",
+			"touched",
+			"changed",
+			"removed",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -0,0 +1,71 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// A plain sync skips the destination files below, because they were changed after the source. Mirror still corrects
+// their content type and metadata, since their content is in sync but their properties are not
+func TestSync_MirrorCorrectsPropertiesOfFilesInSync(t *testing.T) {
+	RunScenarios(t, eOperation.Mirror(), eTestFromTo.Other(common.EFromTo.BlobBlob()), eValidate.Auto(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive: true,
+	}, &hooks{
+		beforeRunJob: func(h hookHelper) {
+			a := h.GetAsserter()
+
+			// the framework would give the files it creates at the destination content of their own, so AzCopy makes the copies
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true}, h.GetSource().getParam(false, true, ""), h.GetDestination().getParam(false, true, ""))
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "copying the sources before the mirror")
+
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+			_, err := containerURL.NewBlobURL("retyped.txt").SetHTTPHeaders(ctx, azblob.BlobHTTPHeaders{ContentType: "application/octet-stream"}, azblob.BlobAccessConditions{})
+			a.AssertNoErr(err, "changing the content type at the destination")
+			_, err = containerURL.NewBlobURL("retagged.txt").SetMetadata(ctx, azblob.Metadata{"owner": "someone else"}, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+			a.AssertNoErr(err, "changing the metadata at the destination")
+		},
+		afterValidation: func(h hookHelper) {
+			a := h.GetAsserter()
+			containerURL := h.GetDestination().(*resourceBlobContainer).containerURL
+
+			props, err := containerURL.NewBlobURL("retyped.txt").GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+			a.AssertNoErr(err, "getting the properties of the retyped file")
+			a.Assert(props.ContentType(), equals(), "text/plain", "mirror should correct the content type")
+
+			props, err = containerURL.NewBlobURL("retagged.txt").GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+			a.AssertNoErr(err, "getting the properties of the retagged file")
+			a.Assert(props.NewMetadata()["owner"], equals(), "me", "mirror should correct the metadata")
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			f("retyped.txt", with{contentType: "text/plain"}),
+			f("retagged.txt", with{contentType: "text/plain", nameValueMetadata: map[string]string{"owner": "me"}}),
+		},
+		shouldSkip: []interface{}{
+			f("same.txt", with{contentType: "text/plain"}),
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
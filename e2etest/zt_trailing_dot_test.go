@@ -0,0 +1,121 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package e2etest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Unless it's asked not to, the Files service drops the dot from the end of a name, so "file." would silently become "file"
+
+func skipDottedLocalNamesOnWindows(h hookHelper) {
+	if fromTo := h.FromTo(); fromTo.From() == common.ELocation.Local() && runtime.GOOS == "windows" {
+		h.SkipTest() // Windows can't have local files whose names end with a dot
+	}
+}
+
+// stripTrailingDots is the name AzCopy is expected to give each file when --trailing-dot=Disable
+func stripTrailingDots(relPath string) string {
+	names := strings.Split(relPath, "/")
+	for i, name := range names {
+		names[i] = strings.TrimRight(name, ".")
+	}
+	return strings.Join(names, "/")
+}
+
+func assertDestinationHas(h hookHelper, present []string, absent []string) {
+	a := h.GetAsserter()
+	props := h.GetDestination().getAllProperties(a)
+	for _, name := range present {
+		_, ok := props[name]
+		a.Assert(ok, equals(), true, "expected '"+name+"' at the destination")
+	}
+	for _, name := range absent {
+		_, ok := props[name]
+		a.Assert(ok, equals(), false, "expected no '"+name+"' at the destination")
+	}
+}
+
+func TestTrailingDot_KeptByDefault(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.FileFile(), common.EFromTo.LocalFile()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:        true,
+		invertedAsSubdir: true,
+	}, &hooks{
+		beforeTestRun: skipDottedLocalNamesOnWindows,
+		afterValidation: func(h hookHelper) {
+			assertDestinationHas(h, []string{"file.", "dir.", "dir./child"}, []string{"file", "dir", "dir/child"})
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"file.",
+			folder("dir."),
+			"dir./child",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
+
+func TestTrailingDot_StrippedWhenDisabled(t *testing.T) {
+	RunScenarios(t, eOperation.Copy(), eTestFromTo.Other(common.EFromTo.FileFile(), common.EFromTo.LocalFile()), eValidate.Auto()|eValidate.Content(), anonymousAuthOnly, anonymousAuthOnly, params{
+		recursive:        true,
+		invertedAsSubdir: true,
+		trailingDot:      common.ETrailingDotOption.Disable(),
+	}, &hooks{
+		beforeTestRun:           skipDottedLocalNamesOnWindows,
+		expectedDestinationName: stripTrailingDots,
+		afterValidation: func(h hookHelper) {
+			assertDestinationHas(h, []string{"file", "dir", "dir/child"}, []string{"file.", "dir.", "dir./child"})
+
+			if h.FromTo() != common.EFromTo.LocalFile() {
+				return
+			}
+			// names that only differ by their trailing dots would land on the same file, so one of them is skipped
+			a := h.GetAsserter()
+			clashDir := TestResourceFactory{}.CreateLocalDirectory(a)
+			defer os.RemoveAll(clashDir)
+			for _, name := range []string{"clash", "clash."} {
+				a.AssertNoErr(ioutil.WriteFile(filepath.Join(clashDir, name), []byte(name), 0644), "creating "+name)
+			}
+
+			result, _ := h.RunAzCopy(eOperation.Copy(), params{recursive: true, invertedAsSubdir: true, trailingDot: common.ETrailingDotOption.Disable()},
+				clashDir, h.GetDestination().getParam(false, true, ""))
+			a.Assert(result.finalStatus.TransfersFailed, equals(), uint32(0), "a clash is skipped, rather than failed")
+			a.Assert(result.finalStatus.TransfersCompleted, equals(), uint32(1), "only one of the clashing names is copied")
+			assertDestinationHas(h, []string{"clash"}, []string{"clash."})
+		},
+	}, testFiles{
+		defaultSize: "1K",
+		shouldTransfer: []interface{}{
+			folder(""),
+			"file.",
+			folder("dir."),
+			"dir./child",
+		},
+	}, EAccountType.Standard(), EAccountType.Standard(), "")
+}
@@ -90,6 +90,12 @@ var JobsAdmin interface {
 	// returns the current value of bytesOverWire.
 	BytesOverWire() int64
 
+	// AutoTunedThroughputMbps returns the rate that the throughput tuner has settled on, or 0 if the throughput is not auto-tuned
+	AutoTunedThroughputMbps() float32
+
+	// AutoTunedConcurrency returns the concurrency that the tuner has settled on, or 0 if the throughput is not auto-tuned
+	AutoTunedConcurrency() int
+
 	LogToJobLog(msg string, level pipeline.LogLevel)
 
 	//DeleteJob(jobID common.JobID)
@@ -106,7 +112,10 @@ var JobsAdmin interface {
 	ListJobs(givenStatus common.JobStatus) common.ListJobsResponse
 }
 
-func initJobsAdmin(appCtx context.Context, concurrency ste.ConcurrencySettings, targetRateInMegaBitsPerSec float64, azcopyJobPlanFolder string, azcopyLogPathFolder string, providePerfAdvice bool) {
+// the rate at which an auto-tuned throughput starts, before it has seen how much the service will take
+const autoTunedThroughputInitialMegaBitsPerSec = 100
+
+func initJobsAdmin(appCtx context.Context, concurrency ste.ConcurrencySettings, targetRateInMegaBitsPerSec float64, autoTuneThroughput bool, bandwidthSchedule *ste.BandwidthSchedule, azcopyJobPlanFolder string, azcopyLogPathFolder string, providePerfAdvice bool) {
 	if JobsAdmin != nil {
 		panic("initJobsAdmin was already called once")
 	}
@@ -124,7 +133,22 @@ func initJobsAdmin(appCtx context.Context, concurrency ste.ConcurrencySettings,
 	// use the "networking mega" (based on powers of 10, not powers of 2, since that's what mega means in networking context)
 	targetRateInBytesPerSec := int64(targetRateInMegaBitsPerSec * 1000 * 1000 / 8)
 	unusedExpectedCoarseRequestByteCount := int64(0)
-	pacer := ste.NewTokenBucketPacer(targetRateInBytesPerSec, unusedExpectedCoarseRequestByteCount)
+	var pacer ste.PacerAdmin
+	if autoTuneThroughput {
+		// start low, and let the pacer find the rate that the service will take. Any cap is the most that it will try
+		initialRateInBytesPerSec := int64(autoTunedThroughputInitialMegaBitsPerSec * 1000 * 1000 / 8)
+		if targetRateInBytesPerSec > 0 && targetRateInBytesPerSec < initialRateInBytesPerSec {
+			initialRateInBytesPerSec = targetRateInBytesPerSec
+		}
+		pacer = ste.NewThroughputAutoPacer(initialRateInBytesPerSec, targetRateInBytesPerSec, common.AzcopyCurrentJobLogger)
+	} else if bandwidthSchedule != nil {
+		// the schedule takes over from here, starting with the rate for now. Outside its windows, the rate is the cap
+		start := time.Now()
+		pacer = ste.NewTokenBucketPacer(bandwidthSchedule.BytesPerSecondAt(start, targetRateInBytesPerSec), unusedExpectedCoarseRequestByteCount)
+		go bandwidthSchedule.Apply(appCtx, pacer, targetRateInBytesPerSec, start, time.NewTicker(ste.BandwidthScheduleInterval).C, common.AzcopyCurrentJobLogger)
+	} else {
+		pacer = ste.NewTokenBucketPacer(targetRateInBytesPerSec, unusedExpectedCoarseRequestByteCount)
+	}
 	// Note: as at July 2019, we don't currently have a shutdown method/event on JobsAdmin where this pacer
 	// could be shut down. But, it's global anyway, so we just leave it running until application exit.
 
@@ -141,6 +165,7 @@ func initJobsAdmin(appCtx context.Context, concurrency ste.ConcurrencySettings,
 		cpuMonitor:              cpuMon,
 		appCtx:                  appCtx,
 		commandLineMbpsCap:      targetRateInMegaBitsPerSec,
+		autoTuneThroughput:      autoTuneThroughput,
 		provideBenchmarkResults: providePerfAdvice,
 	}
 	// create new context with the defaultService api version set as value to serviceAPIVersionOverride in the app context.
@@ -200,7 +225,8 @@ func getMaxRamForChunks() int64 {
 
 func (ja *jobsAdmin) createConcurrencyTuner() ste.ConcurrencyTuner {
 	if ja.concurrency.AutoTuneMainPool() {
-		t := ste.NewAutoConcurrencyTuner(ja.concurrency.InitialMainPoolSize, ja.concurrency.MaxMainPoolSize.Value, ja.provideBenchmarkResults)
+		// when auto-tuning throughput, 503s mean we have gone past what the service will take, so the concurrency must come down too
+		t := ste.NewAutoConcurrencyTuner(ja.concurrency.InitialMainPoolSize, ja.concurrency.MaxMainPoolSize.Value, ja.provideBenchmarkResults, ja.autoTuneThroughput)
 		if !t.RequestCallbackWhenStable(func() { ja.recordTuningCompleted(true) }) {
 			panic("could not register tuning completion callback")
 		}
@@ -255,6 +281,7 @@ type jobsAdmin struct {
 	fileCountLimiter        common.CacheLimiter
 	concurrencyTuner        ste.ConcurrencyTuner
 	commandLineMbpsCap      float64
+	autoTuneThroughput      bool
 	provideBenchmarkResults bool
 	cpuMonitor              common.CPUMonitor
 	jobLogger               common.ILoggerResetable
@@ -339,6 +366,21 @@ func (ja *jobsAdmin) BytesOverWire() int64 {
 	return ja.pacer.GetTotalTraffic()
 }
 
+func (ja *jobsAdmin) AutoTunedThroughputMbps() float32 {
+	if tuner, ok := ja.pacer.(ste.ThroughputAutoPacer); ok {
+		return float32(tuner.SteadyStateBytesPerSecond()*8) / (1000 * 1000)
+	}
+	return 0
+}
+
+func (ja *jobsAdmin) AutoTunedConcurrency() int {
+	if !ja.autoTuneThroughput || ja.concurrencyTuner == nil {
+		return 0
+	}
+	_, concurrency := ja.concurrencyTuner.GetFinalState()
+	return concurrency
+}
+
 func (ja *jobsAdmin) UpdateTargetBandwidth(newTarget int64) {
 	if newTarget < 0 {
 		return
@@ -55,9 +55,9 @@ func ToFixed(num float64, precision int) float64 {
 }
 
 // MainSTE initializes the Storage Transfer Engine
-func MainSTE(concurrency ste.ConcurrencySettings, targetRateInMegaBitsPerSec float64, azcopyJobPlanFolder, azcopyLogPathFolder string, providePerfAdvice bool) error {
+func MainSTE(concurrency ste.ConcurrencySettings, targetRateInMegaBitsPerSec float64, autoTuneThroughput bool, bandwidthSchedule *ste.BandwidthSchedule, azcopyJobPlanFolder, azcopyLogPathFolder string, providePerfAdvice bool) error {
 	// Initialize the JobsAdmin, resurrect Job plan files
-	initJobsAdmin(steCtx, concurrency, targetRateInMegaBitsPerSec, azcopyJobPlanFolder, azcopyLogPathFolder, providePerfAdvice)
+	initJobsAdmin(steCtx, concurrency, targetRateInMegaBitsPerSec, autoTuneThroughput, bandwidthSchedule, azcopyJobPlanFolder, azcopyLogPathFolder, providePerfAdvice)
 	// No need to read the existing JobPartPlan files since Azcopy is running in process
 	// JobsAdmin.ResurrectJobParts()
 	// TODO: We may want to list listen first and terminate if there is already an instance listening
@@ -179,6 +179,8 @@ func ExecuteNewCopyJobPartOrder(order common.CopyJobPartOrderRequest) common.Cop
 		ste.InMemoryTransitJobState{
 			CredentialInfo:          order.CredentialInfo,
 			S2SSourceCredentialType: order.S2SSourceCredentialType,
+			ManifestOutput:          order.ManifestOutput,
+			MaxErrors:               order.MaxErrors,
 		})
 	// Supply no plan MMF because we don't have one, and AddJobPart will create one on its own.
 	jm.AddJobPart(order.PartNum, jppfn, nil, order.SourceRoot.SAS, order.DestinationRoot.SAS, true, nil) // Add this part to the Job and schedule its transfers
@@ -322,6 +324,14 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 		}
 	}
 
+	// A destination archive is written from scratch, so the entries that it had before the job stopped would be lost
+	if jpm.Plan().DestinationArchive != common.EArchiveFormat.None() {
+		return common.CancelPauseResumeResponse{
+			CancelledPauseResumed: false,
+			ErrorMsg:              fmt.Sprintf("cannot resume job with JobId %s, because it writes to a destination archive. Run the copy again instead", req.JobID),
+		}
+	}
+
 	// If the credential type is is Anonymous, to resume the Job destinationSAS / sourceSAS needs to be provided
 	// Depending on the FromType, sourceSAS or destinationSAS is checked.
 	if req.CredentialInfo.CredentialType == common.ECredentialType.Anonymous() {
@@ -392,6 +402,26 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 		common.EJobStatus.CompletedWithErrorsAndSkipped(),
 		common.EJobStatus.Cancelled(),
 		common.EJobStatus.Paused():
+		// Re-target every part at the other destination, before any of the transfers are scheduled again. Only the
+		// transfers that didn't complete run again, so the job's files end up split across the two destinations
+		if req.DestinationOverride.Value != "" {
+			plan := jpm.Plan()
+			original := string(plan.DestinationRoot[:plan.DestinationRootLength])
+			for p := ste.PartNumber(0); true; p++ {
+				partMgr, found := jm.JobPartMgr(p)
+				if !found {
+					break
+				}
+				if err := partMgr.Plan().SetDestinationRoot(req.DestinationOverride); err != nil {
+					return common.CancelPauseResumeResponse{
+						CancelledPauseResumed: false,
+						ErrorMsg:              fmt.Sprintf("cannot resume job with JobId %s into %s. %s", req.JobID, req.DestinationOverride.Value, err.Error()),
+					}
+				}
+			}
+			jm.Log(pipeline.LogWarning, fmt.Sprintf("Resuming the job into %s, instead of %s. The transfers that completed before stay in %s", req.DestinationOverride.Value, original, original))
+		}
+
 		// go func() {
 		// Navigate through transfers and schedule them independently
 		// This is done to avoid FE to get blocked until all the transfers have been scheduled
@@ -493,10 +523,14 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 	js.CompleteJobOrdered = js.CompleteJobOrdered || jm.AllTransfersScheduled()
 
 	js.BytesOverWire = uint64(JobsAdmin.BytesOverWire())
+	js.AutoTunedThroughputMbps = JobsAdmin.AutoTunedThroughputMbps()
+	js.AutoTunedConcurrency = JobsAdmin.AutoTunedConcurrency()
+	estimateTimeRemaining(jm, &js)
 
 	// Get the number of active go routines performing the transfer or executing the chunk Func
 	// TODO: added for debugging purpose. remove later (is covered by GetPerfInfo now anyway)
 	js.ActiveConnections = jm.ActiveConnections()
+	js.POSIXPropertiesNotRestored = jm.POSIXPropertiesNotRestored()
 
 	js.PerfStrings, js.PerfConstraint = jm.GetPerfInfo()
 
@@ -563,7 +597,7 @@ func resurrectJobSummary(jm ste.IJobMgr) common.ListJobSummaryResponse {
 			jppt := jpp.Transfer(t)
 			js.TotalBytesEnumerated += uint64(jppt.SourceSize)
 
-			if jppt.EntityType == common.EEntityType.File() {
+			if jppt.EntityType != common.EEntityType.Folder() { // preserved symlinks count as files
 				js.FileTransfers++
 			} else {
 				js.FolderPropertyTransfers++
@@ -584,6 +618,7 @@ func resurrectJobSummary(jm ste.IJobMgr) common.ListJobSummaryResponse {
 				common.ETransferStatus.TierAvailabilityCheckFailure(),
 				common.ETransferStatus.BlobTierFailure():
 				js.TransfersFailed++
+				js.FailuresByCategory.Add(common.FailureCategoryForStatusCode(int(jppt.ErrorCode())))
 				// getting the source and destination for failed transfer at position - index
 				src, dst, isFolder := jpp.TransferSrcDstStrings(t)
 				// appending to list of failed transfer
@@ -594,6 +629,18 @@ func resurrectJobSummary(jm ste.IJobMgr) common.ListJobSummaryResponse {
 						IsFolderProperties: isFolder,
 						TransferStatus:     common.ETransferStatus.Failed(),
 						ErrorCode:          jppt.ErrorCode()}) // TODO: Optimize
+			case common.ETransferStatus.PreconditionFailed():
+				js.TransfersFailed++
+				js.TransfersPreconditionFailed++
+				js.FailuresByCategory.Add(common.EFailureCategory.Precondition())
+				src, dst, isFolder := jpp.TransferSrcDstStrings(t)
+				js.FailedTransfers = append(js.FailedTransfers,
+					common.TransferDetail{
+						Src:                src,
+						Dst:                dst,
+						IsFolderProperties: isFolder,
+						TransferStatus:     jppt.TransferStatus(),
+						ErrorCode:          jppt.ErrorCode()})
 			case common.ETransferStatus.SkippedEntityAlreadyExists(),
 				common.ETransferStatus.SkippedBlobHasSnapshots():
 				js.TransfersSkipped++
@@ -626,10 +673,13 @@ func resurrectJobSummary(jm ste.IJobMgr) common.ListJobSummaryResponse {
 	js.CompleteJobOrdered = js.CompleteJobOrdered || jm.AllTransfersScheduled()
 
 	js.BytesOverWire = uint64(JobsAdmin.BytesOverWire())
+	js.AutoTunedThroughputMbps = JobsAdmin.AutoTunedThroughputMbps()
+	js.AutoTunedConcurrency = JobsAdmin.AutoTunedConcurrency()
 
 	// Get the number of active go routines performing the transfer or executing the chunk Func
 	// TODO: added for debugging purpose. remove later (is covered by GetPerfInfo now anyway)
 	js.ActiveConnections = jm.ActiveConnections()
+	js.POSIXPropertiesNotRestored = jm.POSIXPropertiesNotRestored()
 
 	js.PerfStrings, js.PerfConstraint = jm.GetPerfInfo()
 
@@ -776,3 +826,16 @@ func GetJobFromTo(r common.GetJobFromToRequest) common.GetJobFromToResponse {
 		Destination: destination,
 	}
 }
+
+// estimateTimeRemaining feeds the job's progress to its ETA estimator, and puts the estimate, if there is one, into the
+// summary. Nothing is estimated until scanning is done, since until then the bytes remaining keep growing
+func estimateTimeRemaining(jm ste.IJobMgr, js *common.ListJobSummaryResponse) {
+	estimator := jm.ETAEstimator()
+	estimator.AddSample(time.Now(), js.TotalBytesTransferred)
+	if !js.CompleteJobOrdered || js.TotalBytesTransferred >= js.TotalBytesExpected {
+		return
+	}
+	if eta, ok := estimator.ETA(js.TotalBytesExpected - js.TotalBytesTransferred); ok {
+		js.EstimatedSecondsRemaining = int64(math.Ceil(eta.Seconds()))
+	}
+}
@@ -1,9 +1,13 @@
 package ste
 
 import (
+	"errors"
+	"io"
+	"net"
 	"net/http"
 
 	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/azure-storage-file-go/azfile"
 )
@@ -41,3 +45,50 @@ func (errex ErrorEx) MSRequestID() string {
 	}
 	return ""
 }
+
+// CopySourceErrorCode gets the error code that the source of a copy from URL failed with, when the failure was the source's.
+// Returns "" if there isn't one
+func (errex ErrorEx) CopySourceErrorCode() string {
+	if respErr, ok := errex.error.(hasResponse); ok {
+		r := respErr.Response()
+		if r != nil {
+			return r.Header.Get("X-Ms-Copy-Source-Error-Code")
+		}
+	}
+	return ""
+}
+
+// isDestinationConditionNotMet says whether err is Blob turning down a write because of its If-Match or If-None-Match
+// header. A mismatched ETag gets 412, while If-None-Match: * gets 409 BlobAlreadyExists when the blob is there.
+// Errors that other errors wrap are looked at too, since the folder and symlink senders wrap theirs
+func isDestinationConditionNotMet(err error) bool {
+	var storageErr azblob.StorageError
+	if !errors.As(err, &storageErr) || storageErr.Response() == nil {
+		return false
+	}
+	return storageErr.Response().StatusCode == http.StatusPreconditionFailed ||
+		storageErr.ServiceCode() == azblob.ServiceCodeBlobAlreadyExists
+}
+
+// failureCategoryOf says what kind of error a transfer failed with, for the job summary. A response from the service is
+// categorized by its status code. Errors that other errors wrap are looked at too
+func failureCategoryOf(err error, failureStatus common.TransferStatus) common.FailureCategory {
+	if failureStatus == common.ETransferStatus.PreconditionFailed() {
+		return common.EFailureCategory.Precondition()
+	}
+	if errors.Is(err, errMd5Mismatch) || errors.Is(err, errCrc64Mismatch) {
+		return common.EFailureCategory.ChecksumMismatch()
+	}
+
+	var respErr hasResponse
+	if errors.As(err, &respErr) && respErr.Response() != nil {
+		return common.FailureCategoryForStatusCode(respErr.Response().StatusCode)
+	}
+
+	// no response at all means the request didn't get through, or the connection broke while the body was read
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return common.EFailureCategory.Network()
+	}
+	return common.EFailureCategory.Other()
+}
@@ -2,8 +2,10 @@ package ste
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -13,12 +15,13 @@ import (
 // dataSchemaVersion defines the data schema version of JobPart order files supported by
 // current version of azcopy
 // To be Incremented every time when we release azcopy with changed dataSchema
-const DataSchemaVersion common.Version = 17
+const DataSchemaVersion common.Version = 32
 
 const (
-	CustomHeaderMaxBytes = 256
-	MetadataMaxBytes     = 1000 // If > 65536, then jobPartPlanBlobData's MetadataLength field's type must change
-	BlobTagsMaxByte      = 4000
+	CustomHeaderMaxBytes   = 256
+	MetadataMaxBytes       = 1000 // If > 65536, then jobPartPlanBlobData's MetadataLength field's type must change
+	BlobTagsMaxByte        = 4000
+	ContentTypeMapMaxBytes = 8000
 )
 
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -66,6 +69,9 @@ type JobPartPlanHeader struct {
 	PreservePermissions     common.PreservePermissionsOption
 	PreserveSMBInfo         bool
 	PreservePOSIXProperties bool
+	// PreserveXattrs says that the user-namespace extended attributes of local files are kept in blob metadata when
+	// uploading, and restored from it when downloading
+	PreserveXattrs bool
 	// S2SGetPropertiesInBackend represents whether to enable get S3 objects' or Azure files' properties during s2s copy in backend.
 	S2SGetPropertiesInBackend bool
 	// S2SSourceChangeValidation represents whether user wants to check if source has changed after enumerating.
@@ -74,6 +80,36 @@ type JobPartPlanHeader struct {
 	DestLengthValidation bool
 	// S2SInvalidMetadataHandleOption represents how user wants to handle invalid metadata.
 	S2SInvalidMetadataHandleOption common.InvalidMetadataHandleOption
+	// PerTransferTimeout is how long a transfer may go without making progress before it is failed. 0 means no timeout.
+	PerTransferTimeout time.Duration
+	// FollowSourceRedirects says that downloads follow a bounded number of redirects from their source, such as those of
+	// a CDN endpoint in front of it, keeping their range
+	FollowSourceRedirects bool
+	// DownloadRangesInPlace says that the ranges of each download are written to their places in a sparse file as they
+	// arrive, rather than held in RAM until they can be written in order. Not for files whose MD5 hash is checked
+	DownloadRangesInPlace bool
+	// AllowTrailingDot says that requests to Azure Files keep the trailing dots in their names (see AllowTrailingDot)
+	AllowTrailingDot bool
+	// SnapshotSourceFirst says that each source blob is snapshotted before it's copied, and copied from that snapshot,
+	// so that writes to the blob during the copy don't affect it
+	SnapshotSourceFirst bool
+	// VerifyCrc64 says that uploads to Blob check the CRC64 that the service computed of each block, page or blob it
+	// received against one computed of the data that was sent
+	VerifyCrc64 bool
+	// MetadataOnly says that each existing destination blob is given the metadata, tags and HTTP headers of its source,
+	// and no data is copied
+	MetadataOnly bool
+	// DestinationArchive says that a download streams its files into a single archive, at the destination root,
+	// rather than writing each to a file of its own
+	DestinationArchive common.ArchiveFormat
+	// SourceArchive says that an upload reads its files from the entries of a single archive, at the source root,
+	// rather than from files of their own
+	SourceArchive common.ArchiveFormat
+	// TransferOrder is the order in which the part's transfers are scheduled. For PatternPriority, the files whose names
+	// match one of the semicolon-separated patterns in PriorityPattern are scheduled first
+	TransferOrder         common.TransferOrder
+	PriorityPatternLength uint16
+	PriorityPattern       [CustomHeaderMaxBytes]byte
 
 	// Any fields below this comment are NOT constants; they may change over as the job part is processed.
 	// Care must be taken to read/write to these fields in a thread-safe way!
@@ -98,6 +134,25 @@ func (jpph *JobPartPlanHeader) JobStatus() common.JobStatus {
 	return jpph.atomicJobStatus.AtomicLoad()
 }
 
+// SetDestinationRoot re-targets the part's transfers at another destination root, for a job that is resumed into
+// another destination. It is the one exception to the root being constant, and must only be called before any of the
+// part's transfers are scheduled. The plan file keeps the new root, so that later resumes write there too
+func (jpph *JobPartPlanHeader) SetDestinationRoot(root common.ResourceString) error {
+	if len(root.Value) > len(jpph.DestinationRoot) {
+		return fmt.Errorf("the destination is too long: it can be at most %d characters", len(jpph.DestinationRoot))
+	}
+	if len(root.ExtraQuery) > len(jpph.DestExtraQuery) {
+		return fmt.Errorf("the query of the destination is too long: it can be at most %d characters", len(jpph.DestExtraQuery))
+	}
+	jpph.DestinationRoot = [len(jpph.DestinationRoot)]byte{}
+	copy(jpph.DestinationRoot[:], root.Value)
+	jpph.DestinationRootLength = uint16(len(root.Value))
+	jpph.DestExtraQuery = [len(jpph.DestExtraQuery)]byte{}
+	copy(jpph.DestExtraQuery[:], root.ExtraQuery)
+	jpph.DestExtraQueryLength = uint16(len(root.ExtraQuery))
+	return nil
+}
+
 // SetJobStatus sets the job status in JobPartPlanHeader in thread-safe manner
 func (jpph *JobPartPlanHeader) SetJobStatus(newJobStatus common.JobStatus) {
 	jpph.atomicJobStatus.AtomicStore(newJobStatus)
@@ -276,6 +331,8 @@ type JobPartPlanDstBlob struct {
 	BlobType common.BlobType
 	// represents user decision to interpret the content-encoding from source file
 	NoGuessMimeType bool
+	// when guessing the content-type, only the content of the file is looked at, not its extension
+	NoGuessMimeTypeFromExtension bool
 
 	// Specifies the length of MIME content type of the blob
 	ContentTypeLength uint16
@@ -325,6 +382,25 @@ type JobPartPlanDstBlob struct {
 	CpkScopeInfo       [CustomHeaderMaxBytes]byte
 	CpkScopeInfoLength uint16
 
+	// The conditions that a destination blob must meet to be written: the ETag it must have, and one it mustn't.
+	// Either may be *, which stands for the blob existing at all
+	IfMatchETagLength     uint16
+	IfMatchETag           [CustomHeaderMaxBytes]byte
+	IfNoneMatchETagLength uint16
+	IfNoneMatchETag       [CustomHeaderMaxBytes]byte
+
+	// The immutability policy that each written blob is given: when it lasts until, in Unix nanoseconds (0 for no
+	// policy), and whether the blob is also put under a legal hold
+	ImmutabilityPolicyUntil int64
+	LegalHold               bool
+
+	// The content type of each file extension, as a JSON object, ahead of any other mapping. Extensions it doesn't
+	// list get DefaultContentType, when there is one, or are detected as usual
+	ContentTypeMapLength     uint16
+	ContentTypeMap           [ContentTypeMapMaxBytes]byte
+	DefaultContentTypeLength uint16
+	DefaultContentType       [CustomHeaderMaxBytes]byte
+
 	// Specifies the maximum size of block which determines the number of chunks and chunk size of a transfer
 	BlockSize int64
 
@@ -113,6 +113,21 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 	if len(order.BlobAttributes.BlobTagsString) > len(JobPartPlanDstBlob{}.BlobTags) {
 		panic(fmt.Errorf("blob tags string is too large: %q", order.BlobAttributes.BlobTagsString))
 	}
+	if len(order.BlobAttributes.IfMatchETag) > len(JobPartPlanDstBlob{}.IfMatchETag) {
+		panic(fmt.Errorf("if-match ETag is too large: %q", order.BlobAttributes.IfMatchETag))
+	}
+	if len(order.BlobAttributes.IfNoneMatchETag) > len(JobPartPlanDstBlob{}.IfNoneMatchETag) {
+		panic(fmt.Errorf("if-none-match ETag is too large: %q", order.BlobAttributes.IfNoneMatchETag))
+	}
+	if len(order.BlobAttributes.ContentTypeMap) > len(JobPartPlanDstBlob{}.ContentTypeMap) {
+		panic(fmt.Errorf("content type map is too large: %q", order.BlobAttributes.ContentTypeMap))
+	}
+	if len(order.BlobAttributes.DefaultContentType) > len(JobPartPlanDstBlob{}.DefaultContentType) {
+		panic(fmt.Errorf("default content type is too large: %q", order.BlobAttributes.DefaultContentType))
+	}
+	if len(order.PriorityPattern) > len(JobPartPlanHeader{}.PriorityPattern) {
+		panic(fmt.Errorf("priority pattern is too large: %q", order.PriorityPattern))
+	}
 
 	// This nested function writes a structure value to an io.Writer & returns the number of bytes written
 	writeValue := func(writer io.Writer, v interface{}) int64 {
@@ -185,23 +200,29 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		NumTransfers:           uint32(len(order.Transfers.List)),
 		LogLevel:               order.LogLevel,
 		DstBlobData: JobPartPlanDstBlob{
-			BlobType:                 order.BlobAttributes.BlobType,
-			NoGuessMimeType:          order.BlobAttributes.NoGuessMimeType,
-			ContentTypeLength:        uint16(len(order.BlobAttributes.ContentType)),
-			ContentEncodingLength:    uint16(len(order.BlobAttributes.ContentEncoding)),
-			ContentDispositionLength: uint16(len(order.BlobAttributes.ContentDisposition)),
-			ContentLanguageLength:    uint16(len(order.BlobAttributes.ContentLanguage)),
-			CacheControlLength:       uint16(len(order.BlobAttributes.CacheControl)),
-			PutMd5:                   order.BlobAttributes.PutMd5, // here because it relates to uploads (blob destination)
-			BlockBlobTier:            order.BlobAttributes.BlockBlobTier,
-			PageBlobTier:             order.BlobAttributes.PageBlobTier,
-			MetadataLength:           uint16(len(order.BlobAttributes.Metadata)),
-			BlockSize:                blockSize,
-			BlobTagsLength:           uint16(len(order.BlobAttributes.BlobTagsString)),
-			CpkInfo:                  order.CpkOptions.CpkInfo,
-			CpkScopeInfoLength:       uint16(len(order.CpkOptions.CpkScopeInfo)),
-			IsSourceEncrypted:        order.CpkOptions.IsSourceEncrypted,
-			SetPropertiesFlags:       order.SetPropertiesFlags,
+			BlobType:                     order.BlobAttributes.BlobType,
+			NoGuessMimeType:              order.BlobAttributes.NoGuessMimeType,
+			NoGuessMimeTypeFromExtension: order.BlobAttributes.NoGuessMimeTypeFromExtension,
+			ContentTypeLength:            uint16(len(order.BlobAttributes.ContentType)),
+			ContentEncodingLength:        uint16(len(order.BlobAttributes.ContentEncoding)),
+			ContentDispositionLength:     uint16(len(order.BlobAttributes.ContentDisposition)),
+			ContentLanguageLength:        uint16(len(order.BlobAttributes.ContentLanguage)),
+			CacheControlLength:           uint16(len(order.BlobAttributes.CacheControl)),
+			PutMd5:                       order.BlobAttributes.PutMd5, // here because it relates to uploads (blob destination)
+			BlockBlobTier:                order.BlobAttributes.BlockBlobTier,
+			PageBlobTier:                 order.BlobAttributes.PageBlobTier,
+			MetadataLength:               uint16(len(order.BlobAttributes.Metadata)),
+			BlockSize:                    blockSize,
+			BlobTagsLength:               uint16(len(order.BlobAttributes.BlobTagsString)),
+			CpkInfo:                      order.CpkOptions.CpkInfo,
+			CpkScopeInfoLength:           uint16(len(order.CpkOptions.CpkScopeInfo)),
+			IsSourceEncrypted:            order.CpkOptions.IsSourceEncrypted,
+			IfMatchETagLength:            uint16(len(order.BlobAttributes.IfMatchETag)),
+			IfNoneMatchETagLength:        uint16(len(order.BlobAttributes.IfNoneMatchETag)),
+			LegalHold:                    order.BlobAttributes.LegalHold,
+			ContentTypeMapLength:         uint16(len(order.BlobAttributes.ContentTypeMap)),
+			DefaultContentTypeLength:     uint16(len(order.BlobAttributes.DefaultContentType)),
+			SetPropertiesFlags:           order.SetPropertiesFlags,
 		},
 		DstLocalData: JobPartPlanDstLocal{
 			PreserveLastModifiedTime: order.BlobAttributes.PreserveLastModifiedTime,
@@ -210,11 +231,23 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		PreservePermissions:     order.PreserveSMBPermissions,
 		PreserveSMBInfo:         order.PreserveSMBInfo,
 		PreservePOSIXProperties: order.PreservePOSIXProperties,
+		PreserveXattrs:          order.PreserveXattrs,
 		// For S2S copy, per JobPartPlan info
 		S2SGetPropertiesInBackend:      order.S2SGetPropertiesInBackend,
 		S2SSourceChangeValidation:      order.S2SSourceChangeValidation,
 		S2SInvalidMetadataHandleOption: order.S2SInvalidMetadataHandleOption,
 		DestLengthValidation:           order.DestLengthValidation,
+		PerTransferTimeout:             order.PerTransferTimeout,
+		FollowSourceRedirects:          order.FollowSourceRedirects,
+		DownloadRangesInPlace:          order.DownloadRangesInPlace,
+		AllowTrailingDot:               order.AllowTrailingDot,
+		SnapshotSourceFirst:            order.SnapshotSourceFirst,
+		VerifyCrc64:                    order.VerifyCrc64,
+		MetadataOnly:                   order.MetadataOnly,
+		DestinationArchive:             order.DestinationArchive,
+		SourceArchive:                  order.SourceArchive,
+		TransferOrder:                  order.TransferOrder,
+		PriorityPatternLength:          uint16(len(order.PriorityPattern)),
 		atomicJobStatus:                common.EJobStatus.InProgress(), // We default to InProgress
 		DeleteSnapshotsOption:          order.BlobAttributes.DeleteSnapshotsOption,
 		PermanentDeleteOption:          order.BlobAttributes.PermanentDeleteOption,
@@ -235,6 +268,14 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 	copy(jpph.DstBlobData.Metadata[:], order.BlobAttributes.Metadata)
 	copy(jpph.DstBlobData.BlobTags[:], order.BlobAttributes.BlobTagsString)
 	copy(jpph.DstBlobData.CpkScopeInfo[:], order.CpkOptions.CpkScopeInfo)
+	copy(jpph.DstBlobData.IfMatchETag[:], order.BlobAttributes.IfMatchETag)
+	copy(jpph.DstBlobData.IfNoneMatchETag[:], order.BlobAttributes.IfNoneMatchETag)
+	copy(jpph.DstBlobData.ContentTypeMap[:], order.BlobAttributes.ContentTypeMap)
+	copy(jpph.DstBlobData.DefaultContentType[:], order.BlobAttributes.DefaultContentType)
+	copy(jpph.PriorityPattern[:], order.PriorityPattern)
+	if !order.BlobAttributes.ImmutabilityPolicyUntil.IsZero() {
+		jpph.DstBlobData.ImmutabilityPolicyUntil = order.BlobAttributes.ImmutabilityPolicyUntil.UnixNano()
+	}
 
 	eof += writeValue(file, &jpph)
 
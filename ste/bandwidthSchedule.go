@@ -0,0 +1,150 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// BandwidthScheduleInterval is how often the time of day is checked against a BandwidthSchedule
+const BandwidthScheduleInterval = 30 * time.Second
+
+// BandwidthSchedule caps the transfer rate by the time of day, e.g. to limit it during business hours but not at night.
+// The times are read from the local clock of the machine that runs AzCopy, in its local time zone (which is taken from
+// the TZ environment variable, where that is set). So "09:00" is 9 o'clock as the clock on the wall shows it, both
+// before and after a change to or from daylight saving time.
+// A window may wrap past midnight (e.g. 17:00-09:00). Where windows overlap, the first one given applies. At times
+// that no window covers, the rate is whatever it would be without a schedule.
+type BandwidthSchedule struct {
+	windows []bandwidthWindow
+}
+
+type bandwidthWindow struct {
+	start, end     time.Duration // since midnight. When end is not after start, the window wraps past midnight
+	bytesPerSecond int64         // 0 means that the rate is not capped
+}
+
+func (w bandwidthWindow) contains(sinceMidnight time.Duration) bool {
+	if w.start < w.end {
+		return sinceMidnight >= w.start && sinceMidnight < w.end
+	}
+	return sinceMidnight >= w.start || sinceMidnight < w.end // wraps past midnight, or covers the whole day when start == end
+}
+
+// ParseBandwidthSchedule reads a schedule given as comma-separated windows, each of which is HH:MM-HH:MM=cap, where the
+// cap is in megabits per second, or is "unlimited". E.g. 09:00-17:00=50,17:00-09:00=unlimited
+func ParseBandwidthSchedule(raw string) (*BandwidthSchedule, error) {
+	s := &BandwidthSchedule{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		times := strings.SplitN(entry, "=", 2)
+		startAndEnd := strings.Split(times[0], "-")
+		if len(times) != 2 || len(startAndEnd) != 2 {
+			return nil, fmt.Errorf("invalid bandwidth schedule window '%s'. Each window must be HH:MM-HH:MM=megabits per second, or =unlimited", entry)
+		}
+
+		w := bandwidthWindow{}
+		var err error
+		if w.start, err = parseTimeOfDay(startAndEnd[0]); err != nil {
+			return nil, fmt.Errorf("invalid start of bandwidth schedule window '%s': %w", entry, err)
+		}
+		if w.end, err = parseTimeOfDay(startAndEnd[1]); err != nil {
+			return nil, fmt.Errorf("invalid end of bandwidth schedule window '%s': %w", entry, err)
+		}
+
+		limit := strings.TrimSpace(times[1])
+		if !strings.EqualFold(limit, "unlimited") {
+			mbps, err := strconv.ParseFloat(limit, 64)
+			if err != nil || mbps <= 0 {
+				return nil, fmt.Errorf("invalid cap in bandwidth schedule window '%s'. It must be a number of megabits per second greater than 0, or unlimited", entry)
+			}
+			// use the "networking mega" (based on powers of 10, not powers of 2), as cap-mbps does
+			w.bytesPerSecond = int64(mbps * 1000 * 1000 / 8)
+		}
+		s.windows = append(s.windows, w)
+	}
+
+	if len(s.windows) == 0 {
+		return nil, fmt.Errorf("the bandwidth schedule has no windows")
+	}
+	return s, nil
+}
+
+func parseTimeOfDay(raw string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a time of day in the form HH:MM", raw)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// BytesPerSecondAt returns the cap at the given time, in its own time zone. Outside all the windows, that's otherwise.
+// 0 means that the rate is not capped
+func (s *BandwidthSchedule) BytesPerSecondAt(t time.Time, otherwise int64) int64 {
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	for _, w := range s.windows {
+		if w.contains(sinceMidnight) {
+			return w.bytesPerSecond
+		}
+	}
+	return otherwise
+}
+
+// Apply keeps the target rate of the pacer to the one that the schedule gives, checking the time each time that ticks
+// delivers it, until ctx is done. The pacer must have been created with the rate for start. A change takes effect
+// mid-job, since the pacer just releases bytes at the new rate from then on.
+func (s *BandwidthSchedule) Apply(ctx context.Context, p PacerAdmin, otherwise int64, start time.Time, ticks <-chan time.Time, logger common.ILogger) {
+	applied := s.BytesPerSecondAt(start, otherwise)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticks:
+			target := s.BytesPerSecondAt(now, otherwise)
+			if target == applied {
+				continue
+			}
+			p.UpdateTargetBytesPerSecond(target)
+			applied = target
+			if logger.ShouldLog(pipeline.LogInfo) {
+				logger.Log(pipeline.LogInfo, fmt.Sprintf("The bandwidth schedule changed the cap at %s to %s",
+					now.Format("15:04"), formatBytesPerSecondAsMbps(target)))
+			}
+		}
+	}
+}
+
+func formatBytesPerSecondAsMbps(bytesPerSecond int64) string {
+	if bytesPerSecond == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%.2f Mbps", float64(bytesPerSecond*8)/(1000*1000))
+}
@@ -45,6 +45,15 @@ func (i *ConfiguredInt) GetDescription() string {
 	}
 }
 
+// checkAtLeast returns an error if the value is below min. It's for the values that the engine can't work with
+// otherwise, which are better rejected when they are read than failing, or quietly doing nothing, later
+func (i *ConfiguredInt) checkAtLeast(min int) error {
+	if i.Value < min {
+		return fmt.Errorf("the env %s %d is invalid, it must be at least %d", i.EnvVarName, i.Value, min)
+	}
+	return nil
+}
+
 // tryNewConfiguredInt populates a ConfiguredInt from an environment variable, or returns nil if env var is not set
 func tryNewConfiguredInt(envVar common.EnvironmentVariable) *ConfiguredInt {
 	override := common.GetLifecycleMgr().GetEnvironmentVariable(envVar)
@@ -103,6 +112,15 @@ type ConcurrencySettings struct {
 	// (i.e. creates chunkfuncs)
 	TransferInitiationPoolSize *ConfiguredInt
 
+	// SmallFileThreshold is the size, in bytes, below which a file is transferred by the small-file pool
+	// instead of the main one
+	SmallFileThreshold *ConfiguredInt
+
+	// SmallFileConcurrency is the size of the small-file pool. It is the number of goroutines that initiate
+	// small-file transfers, and also the number that run their chunks, so a few slow large files cannot
+	// hold up many quick small ones. It is 0, meaning there is no such pool, unless the user asks for one
+	SmallFileConcurrency *ConfiguredInt
+
 	// EnumerationPoolSize is size of auxiliary goroutine pool used in enumerators (only some of which are in fact parallelized)
 	EnumerationPoolSize *ConfiguredInt
 
@@ -126,6 +144,11 @@ type ConcurrencySettings struct {
 	CheckCpuWhenTuning *ConfiguredBool
 }
 
+// IsSmallFile says whether a file of the given size should be transferred by the small-file pool
+func (c ConcurrencySettings) IsSmallFile(size int64) bool {
+	return c.SmallFileConcurrency.Value > 0 && size < int64(c.SmallFileThreshold.Value)
+}
+
 // AutoTuneMainPool says whether the main pool size should by dynamically tuned
 func (c ConcurrencySettings) AutoTuneMainPool() bool {
 	return c.MaxMainPoolSize.Value > c.InitialMainPoolSize
@@ -133,6 +156,14 @@ func (c ConcurrencySettings) AutoTuneMainPool() bool {
 
 const defaultTransferInitiationPoolSize = 64
 const defaultEnumerationPoolSize = 16
+
+// files under 256 KiB fit in a single chunk, even with the smallest block sizes people tend to use, so they are
+// dominated by per-request latency rather than bandwidth, and are worth running with much higher concurrency
+const defaultSmallFileThreshold = 256 * 1024
+
+// The small-file pool is opt-in. Its goroutines and connections are on top of the main pool's, which is sized by
+// AZCOPY_CONCURRENCY_VALUE or auto-tuned, so having it by default would override what the user chose there
+const defaultSmallFileConcurrency = 0
 const concurrentFilesFloor = 32
 
 // NewConcurrencySettings gets concurrency settings by referring to the
@@ -146,13 +177,15 @@ func NewConcurrencySettings(maxFileAndSocketHandles int, requestAutoTuneGRs bool
 		InitialMainPoolSize:        initialMainPoolSize,
 		MaxMainPoolSize:            maxMainPoolSize,
 		TransferInitiationPoolSize: getTransferInitiationPoolSize(),
+		SmallFileThreshold:         getSmallFileThreshold(),
+		SmallFileConcurrency:       getSmallFileConcurrency(),
 		EnumerationPoolSize:        GetEnumerationPoolSize(),
 		ParallelStatFiles:          GetParallelStatFiles(),
 		CheckCpuWhenTuning:         getCheckCpuUsageWhenTuning(),
 	}
 
 	s.MaxOpenDownloadFiles = getMaxOpenPayloadFiles(maxFileAndSocketHandles,
-		maxMainPoolSize.Value+s.TransferInitiationPoolSize.Value+s.EnumerationPoolSize.Value)
+		maxMainPoolSize.Value+s.TransferInitiationPoolSize.Value+2*s.SmallFileConcurrency.Value+s.EnumerationPoolSize.Value)
 
 	// Set the max idle connections that we allow. If there are any more idle connections
 	// than this, they will be closed, and then will result in creation of new connections
@@ -168,7 +201,8 @@ func NewConcurrencySettings(maxFileAndSocketHandles int, requestAutoTuneGRs bool
 	// on Windows when this value was set to 500 but there were 1000 to 2000 goroutines in the
 	// main pool size.  Using DialContext appears to mitigate that issue, so the value
 	// we compute here is really just to reduce unneeded make and break of connections)
-	s.MaxIdleConnections = maxMainPoolSize.Value
+	// The small-file pool's connections are in addition to the main pool's.
+	s.MaxIdleConnections = maxMainPoolSize.Value + s.SmallFileConcurrency.Value
 
 	return s
 }
@@ -230,6 +264,34 @@ func getTransferInitiationPoolSize() *ConfiguredInt {
 	return &ConfiguredInt{defaultTransferInitiationPoolSize, false, envVar.Name, "hard-coded default"}
 }
 
+func getSmallFileThreshold() *ConfiguredInt {
+	envVar := common.EEnvironmentVariable.SmallFileThreshold()
+
+	if c := tryNewConfiguredInt(envVar); c != nil {
+		// a threshold of 0 or less would quietly send no files to the pool
+		if err := c.checkAtLeast(1); err != nil {
+			log.Fatal(err)
+		}
+		return c
+	}
+
+	return &ConfiguredInt{defaultSmallFileThreshold, false, envVar.Name, "hard-coded default"}
+}
+
+func getSmallFileConcurrency() *ConfiguredInt {
+	envVar := common.EEnvironmentVariable.SmallFileConcurrency()
+
+	if c := tryNewConfiguredInt(envVar); c != nil {
+		// a negative pool size can't be made into the pool's channels
+		if err := c.checkAtLeast(0); err != nil {
+			log.Fatal(err)
+		}
+		return c
+	}
+
+	return &ConfiguredInt{defaultSmallFileConcurrency, false, envVar.Name, "hard-coded default"}
+}
+
 func GetEnumerationPoolSize() *ConfiguredInt {
 	envVar := common.EEnvironmentVariable.EnumerationPoolSize()
 
@@ -79,9 +79,13 @@ type autoConcurrencyTuner struct {
 	finalConcurrency    int
 	lockFinal           sync.Mutex
 	isBenchmarking      bool
+	backOffOnRetries    bool
 }
 
-func NewAutoConcurrencyTuner(initial, max int, isBenchmarking bool) ConcurrencyTuner {
+// NewAutoConcurrencyTuner creates a tuner that probes upwards from initial, towards max.
+// If backOffOnRetries is set, any retries (e.g. 503s) seen after an increase are treated as a sign
+// that the increase was too aggressive, so the tuner settles below the level at which the service throttles us.
+func NewAutoConcurrencyTuner(initial, max int, isBenchmarking bool, backOffOnRetries bool) ConcurrencyTuner {
 	t := &autoConcurrencyTuner{
 		observations: make(chan struct {
 			mbps      int
@@ -96,6 +100,7 @@ func NewAutoConcurrencyTuner(initial, max int, isBenchmarking bool) ConcurrencyT
 		callbacksWhenStable: make(chan func(), 1000),
 		lockFinal:           sync.Mutex{},
 		isBenchmarking:      isBenchmarking,
+		backOffOnRetries:    backOffOnRetries,
 	}
 	go t.worker()
 	return t
@@ -189,12 +194,14 @@ func (t *autoConcurrencyTuner) worker() {
 			everSawHighCpu = true // this doesn't stop us probing higher concurrency, since sometimes that works even when CPU looks high, but it does change the way we report the result
 		}
 
+		sawRetry := atomic.SwapInt64(&t.atomicRetryCount, 0) > 0
+		throttled := t.backOffOnRetries && sawRetry
+
 		if t.isBenchmarking {
 			// Be a little more aggressive if we are tuning for benchmarking purposes (as opposed to day to day use)
 
 			// If we are seeing retries (within "normal" concurrency range) then for benchmarking purposes we don't want to back off.
 			// (Since if we back off the retries might stop and then they won't be reported on as a limiting factor.)
-			sawRetry := atomic.SwapInt64(&t.atomicRetryCount, 0) > 0
 			dontBackoffRegardless = sawRetry && concurrency <= 256
 
 			// Workaround for variable throughput when targeting 20 Gbps account limit (concurrency around 64 didn't seem to give stable throughput in some tests)
@@ -203,13 +210,13 @@ func (t *autoConcurrencyTuner) worker() {
 		}
 
 		// decide what to do based on the measurement
-		if lastSpeed > desiredNewSpeed || probeHigherRegardless {
+		if (lastSpeed > desiredNewSpeed || probeHigherRegardless) && !throttled {
 			// Our concurrency change gave the hoped-for speed increase, so loop around and see if another increase will also work,
 			// unless already at max
 			if atMax {
 				break
 			}
-		} else if dontBackoffRegardless {
+		} else if dontBackoffRegardless && !throttled {
 			// nothing more we can do
 			break
 		} else {
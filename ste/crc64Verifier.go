@@ -0,0 +1,77 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"net/http"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// azureStorageCrc64Table is for the CRC64 that Azure Storage computes of the data it's sent, which it returns,
+// little-endian, in x-ms-content-crc64
+var azureStorageCrc64Table = crc64.MakeTable(0x9A6C9329AC4BC9B5)
+
+var errCrc64Mismatch = errors.New("the CRC64 that the Blob service computed of the data it received did not match the CRC64 of the data that was sent. " +
+	"This means that the data was corrupted on its way to the service")
+
+// chunkCrc64 returns the CRC64, as Azure Storage computes it, of the chunk's data, or nil if the transfer doesn't
+// verify CRC64s. It must be called before the chunk is sent, while its prefetched data is still there to read
+func chunkCrc64(jptm IJobPartTransferMgr, reader common.SingleChunkReader) []byte {
+	if !jptm.VerifyCrc64() {
+		return nil
+	}
+	h := crc64.New(azureStorageCrc64Table)
+	reader.WriteBufferTo(h)
+	sent := make([]byte, crc64.Size)
+	binary.LittleEndian.PutUint64(sent, h.Sum64())
+	return sent
+}
+
+// responseCrc64 gets the CRC64 from a response whose type has no accessor for it
+func responseCrc64(resp *http.Response) []byte {
+	if resp == nil {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(resp.Header.Get("x-ms-content-crc64"))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// verifyCrc64 checks the CRC64 that the service computed of what it received against sent, the one computed by
+// chunkCrc64. Not every response carries the service's CRC64, and where there's none, or sent is nil because the
+// transfer doesn't verify CRC64s, the data is accepted as it would have been anyway
+func verifyCrc64(sent []byte, received []byte) error {
+	if sent == nil || len(received) == 0 {
+		return nil
+	}
+	if !bytes.Equal(sent, received) {
+		return fmt.Errorf("%w (sent %x, received %x)", errCrc64Mismatch, sent, received)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// destinationArchive streams the files of a download into a single tar file, optionally gzipped, instead of writing
+// each to a file of its own.
+//
+// A tar can only be written serially, so transfers take turns at it. The transfer whose turn it is holds the archive
+// from when it writes its entry's header until its epilogue closes the entry. Its chunks are still downloaded in
+// parallel, and the usual chunked file writer puts them into the entry in order, so large files stream through
+// without being held in memory. Other transfers wait for their turn before they schedule any chunks, so they don't
+// take any of the RAM that the file in flight needs. The cost is that only one file is in flight at once.
+type destinationArchive struct {
+	path   string
+	format common.ArchiveFormat
+	turn   chan struct{} // holds a value while a transfer has the archive
+
+	mu     sync.Mutex // guards the fields below, since an entry is closed on a different goroutine from where it's written
+	file   *os.File
+	gz     *gzip.Writer
+	tw     *tar.Writer
+	closed bool
+}
+
+func newDestinationArchive(path string, format common.ArchiveFormat) *destinationArchive {
+	return &destinationArchive{
+		path:   path,
+		format: format,
+		turn:   make(chan struct{}, 1),
+	}
+}
+
+// acquire waits for the transfer's turn at the archive. The archive file is created when the first turn is taken
+func (a *destinationArchive) acquire(ctx context.Context) error {
+	select {
+	case a.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err := a.ensureOpen()
+	if err != nil {
+		a.release()
+	}
+	return err
+}
+
+func (a *destinationArchive) release() {
+	<-a.turn
+}
+
+func (a *destinationArchive) ensureOpen() error {
+	if a.closed {
+		return errors.New("the destination archive has already been closed")
+	}
+	if a.tw != nil {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(a.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	a.file, err = common.OSOpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, common.DEFAULT_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = a.file
+	if a.format == common.EArchiveFormat.TarGz() {
+		a.gz = gzip.NewWriter(a.file)
+		w = a.gz
+	}
+	a.tw = tar.NewWriter(w)
+	return nil
+}
+
+// AddEntry writes an entry that has no content, such as a folder, a symlink or an empty file
+func (a *destinationArchive) AddEntry(ctx context.Context, hdr *tar.Header) error {
+	err := a.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.release()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.tw.WriteHeader(hdr)
+}
+
+// OpenEntry waits for the transfer's turn, then writes the header of its entry. The transfer keeps the archive until
+// it closes the returned writer, which it must do even if it fails
+func (a *destinationArchive) OpenEntry(ctx context.Context, hdr *tar.Header) (io.WriteCloser, error) {
+	err := a.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err = a.tw.WriteHeader(hdr)
+	if err != nil {
+		a.release()
+		return nil, err
+	}
+	return &archiveEntryWriter{archive: a, remaining: hdr.Size}, nil
+}
+
+// Close writes the end of the archive, and closes its file. It's called when the job is done
+func (a *destinationArchive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	if a.tw == nil {
+		return nil // no transfer ever took a turn, so there's no file
+	}
+
+	err := a.tw.Close()
+	if a.gz != nil {
+		if gzErr := a.gz.Close(); err == nil {
+			err = gzErr
+		}
+	}
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// archiveEntryWriter writes the content of one entry of a destinationArchive
+type archiveEntryWriter struct {
+	archive   *destinationArchive
+	remaining int64
+	closed    bool
+}
+
+func (w *archiveEntryWriter) Write(p []byte) (int, error) {
+	w.archive.mu.Lock()
+	defer w.archive.mu.Unlock()
+	if w.closed {
+		return 0, errors.New("the archive entry has already been closed")
+	}
+
+	n, err := w.archive.tw.Write(p)
+	w.remaining -= int64(n)
+	return n, err
+}
+
+// Close finishes the entry, and gives the archive to the next transfer. If the transfer failed part way through, the
+// rest of the entry is filled with zeros, since a tar can't take back an entry once its header has been written
+func (w *archiveEntryWriter) Close() error {
+	w.archive.mu.Lock()
+	defer w.archive.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer w.archive.release()
+
+	var err error
+	if w.remaining > 0 {
+		_, err = io.CopyN(w.archive.tw, zeroReader{}, w.remaining)
+		w.remaining = 0
+	}
+	if err == nil {
+		err = w.archive.tw.Flush()
+	}
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// newArchiveFileHeader is the header of the entry for a file transfer
+func newArchiveFileHeader(jptm IJobPartTransferMgr, name string) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     jptm.Info().SourceSize,
+		Mode:     common.DEFAULT_FILE_PERM,
+		ModTime:  jptm.LastModifiedTime(),
+	}
+}
+
+// archiveEntryName is the name of a transfer's entry in the destination archive: its destination path, relative to
+// the archive, with the forward slashes that tar uses. A single file, whose destination is the archive itself, is
+// named after its source, if that's given
+func archiveEntryName(relativeDestination string, source string) string {
+	name := strings.Trim(filepath.ToSlash(relativeDestination), "/")
+	if name == "" && source != "" {
+		if u, err := url.Parse(source); err == nil {
+			name = path.Base(u.Path)
+		}
+	}
+	return name
+}
@@ -69,6 +69,31 @@ func (bd *blobDownloader) Prologue(jptm IJobPartTransferMgr, srcPipeline pipelin
 
 func (bd *blobDownloader) Epilogue() {
 	_ = bd.filePacer.Close()
+
+	if bd.jptm != nil && bd.jptm.IsLive() && bd.txInfo.PreservePOSIXProperties {
+		// Like the SMB properties for Azure Files, this only applies on some OSes. See downloader-blob_linux.go.
+		// The content is already safely at the destination, so failing to set these doesn't fail the transfer
+		if updl, ok := interface{}(bd).(unixPropertyAwareDownloader); ok {
+			if err := updl.PutUNIXProperties(bd.txInfo.SrcMetadata, bd.txInfo); err != nil {
+				bd.jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Could not restore POSIX properties: "+err.Error())
+				bd.jptm.ReportPOSIXPropertiesNotRestored()
+			}
+		}
+	}
+
+	if bd.jptm != nil && bd.jptm.IsLive() && bd.txInfo.PreserveXattrs {
+		// likewise, extended attributes that can't be set are only warned about. See downloader-blob_linux.go
+		if xdl, ok := interface{}(bd).(xattrAwareDownloader); ok {
+			if err := xdl.PutXattrs(bd.txInfo.SrcMetadata, bd.txInfo); err != nil {
+				bd.jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Could not restore extended attributes: "+err.Error())
+			}
+		}
+	}
+}
+
+func (bd *blobDownloader) SetFolderProperties(jptm IJobPartTransferMgr) error {
+	// no-op (the folders of a blob download are directory stubs and empty folder markers, which have no properties to preserve)
+	return nil
 }
 
 // Returns a chunk-func for blob downloads
@@ -96,7 +121,7 @@ func (bd *blobDownloader) GenerateDownloadFunc(jptm IJobPartTransferMgr, srcPipe
 		// something inherent in the nature of REST downloads. So, as at March 2018, we are just living
 		// with it as known issue when downloading paced blobs.
 		jptm.LogChunkStatus(id, common.EWaitReason.FilePacer())
-		if err := bd.filePacer.RequestTrafficAllocation(jptm.Context(), length); err != nil {
+		if err := waitForPacer(jptm.Context(), bd.filePacer, length); err != nil {
 			jptm.FailActiveDownload("Pacing block", err)
 		}
 
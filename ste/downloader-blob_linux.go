@@ -0,0 +1,83 @@
+//go:build linux
+// +build linux
+
+package ste
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// This file implements the linux-triggered unixPropertyAwareDownloader interface.
+
+func (bd *blobDownloader) PutUNIXProperties(metadata common.Metadata, txInfo TransferInfo) error {
+	if txInfo.Destination == common.Dev_Null {
+		return nil // Do nothing.
+	}
+
+	stat, err := common.ReadStatFromMetadata(metadata.ToAzBlobMetadata(), txInfo.SourceSize)
+	if err != nil {
+		return fmt.Errorf("failed to read POSIX properties from the source metadata: %w", err)
+	}
+
+	failures := make([]string, 0)
+
+	// set the ownership first, because on some systems chown clears the setuid and setgid bits of the mode
+	_, hasOwner := metadata[common.POSIXOwnerMeta]
+	_, hasGroup := metadata[common.POSIXGroupMeta]
+	if hasOwner || hasGroup {
+		uid, gid := -1, -1 // -1 leaves that ID as it is
+		if hasOwner {
+			uid = int(stat.Owner())
+		}
+		if hasGroup {
+			gid = int(stat.Group())
+		}
+		if err := unix.Chown(txInfo.Destination, uid, gid); err != nil {
+			failures = append(failures, fmt.Sprintf("set owner %d and group %d: %s", uid, gid, err))
+		}
+	}
+
+	if _, hasMode := metadata[common.POSIXModeMeta]; hasMode {
+		// Only the permission bits, and setuid, setgid and sticky, apply. The file type bits describe the source,
+		// and what we downloaded is always a regular file.
+		mode := stat.FileMode() & 07777
+		if err := unix.Chmod(txInfo.Destination, mode); err != nil {
+			failures = append(failures, fmt.Sprintf("set mode %04o: %s", mode, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// This implements the linux-triggered xattrAwareDownloader interface.
+
+func (bd *blobDownloader) PutXattrs(metadata common.Metadata, txInfo TransferInfo) error {
+	if txInfo.Destination == common.Dev_Null {
+		return nil // Do nothing.
+	}
+
+	xattrs, err := common.ReadXattrsFromMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	failures := make([]string, 0)
+	for name, value := range xattrs {
+		if err := unix.Setxattr(txInfo.Destination, name, value, 0); err != nil {
+			failures = append(failures, fmt.Sprintf("set %s: %s", name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -54,6 +54,20 @@ type smbPropertyAwareDownloader interface {
 	PutSMBProperties(sip ISMBPropertyBearingSourceInfoProvider, txInfo TransferInfo) error
 }
 
+// unixPropertyAwareDownloader is a linux-triggered interface.
+// Code outside of linux-specific files shouldn't implement this ever.
+type unixPropertyAwareDownloader interface {
+	// PutUNIXProperties sets the mode, owner and group recorded in the source's metadata on the downloaded file.
+	// Properties missing from the metadata are left as they are
+	PutUNIXProperties(metadata common.Metadata, txInfo TransferInfo) error
+}
+
+// xattrAwareDownloader is a linux-triggered interface, like unixPropertyAwareDownloader.
+type xattrAwareDownloader interface {
+	// PutXattrs sets the user-namespace extended attributes recorded in the source's metadata on the downloaded file
+	PutXattrs(metadata common.Metadata, txInfo TransferInfo) error
+}
+
 type downloaderFactory func() downloader
 
 func createDownloadChunkFunc(jptm IJobPartTransferMgr, id common.ChunkID, body func()) chunkFunc {
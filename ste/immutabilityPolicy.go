@@ -0,0 +1,71 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// immutabilityPolicyToApply returns the immutability policy and legal hold to give the destination blob as it's
+// written. A write never shortens a retention that the blob already has: if an earlier job, or an earlier attempt at
+// this transfer, gave the blob a policy that lasts longer than the one asked for, that later date is kept
+func immutabilityPolicyToApply(jptm IJobPartTransferMgr, destBlobURL azblob.BlobURL, cpk azblob.ClientProvidedKeyOptions) azblob.ImmutabilityPolicyOptions {
+	policy := jptm.ImmutabilityPolicy()
+	if policy.ImmutabilityPolicyUntilDate == nil {
+		return policy
+	}
+
+	props, err := destBlobURL.GetProperties(jptm.Context(), azblob.BlobAccessConditions{}, cpk)
+	if err != nil {
+		return policy // most often because the blob doesn't exist yet. If it can't be read for another reason, the write will say why
+	}
+	existing := props.ImmutabilityPolicyExpiresOn()
+	if existing.After(*policy.ImmutabilityPolicyUntilDate) {
+		if jptm.ShouldLog(pipeline.LogInfo) {
+			jptm.Log(pipeline.LogInfo, fmt.Sprintf("Keeping the immutability policy of the destination blob until %s, since it lasts longer than the one asked for", existing.UTC()))
+		}
+		policy.ImmutabilityPolicyUntilDate = &existing
+		if props.ImmutabilityPolicyMode() == azblob.BlobImmutabilityPolicyModeLocked {
+			policy.ImmutabilityPolicyMode = azblob.BlobImmutabilityPolicyModeLocked // a locked policy can't be unlocked either
+		}
+	}
+	return policy
+}
+
+// applyImmutabilityPolicy gives a blob whose data has all been written its immutability policy and legal hold. Page
+// and append blobs are written a piece at a time after they're created, which the policy would stop, so they are only
+// protected once they're complete
+func applyImmutabilityPolicy(jptm IJobPartTransferMgr, destBlobURL azblob.BlobURL, cpk azblob.ClientProvidedKeyOptions) error {
+	policy := immutabilityPolicyToApply(jptm, destBlobURL, cpk)
+	if policy.ImmutabilityPolicyUntilDate != nil {
+		if _, err := destBlobURL.SetImmutabilityPolicy(jptm.Context(), *policy.ImmutabilityPolicyUntilDate, policy.ImmutabilityPolicyMode, nil); err != nil {
+			return err
+		}
+	}
+	if policy.LegalHold != nil {
+		if _, err := destBlobURL.SetLegalHold(jptm.Context(), *policy.LegalHold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -21,8 +21,10 @@
 package ste
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
@@ -43,6 +45,10 @@ type jobStatusManager struct {
 	xferDone        chan xferDoneMsg
 	xferDoneDrained chan struct{} // To signal that all xferDone have been processed
 	statusMgrDone   chan struct{} // To signal statusManager has closed
+
+	// the manifest of transferred files, if the job has one. Opened when first needed. Only used by the statusManager's goroutine
+	manifest     *transferManifest
+	manifestDone bool // true once the manifest is closed, or has failed, after which nothing more is written to it
 }
 
 func (jm *jobMgr) waitToDrainXferDone() {
@@ -117,6 +123,9 @@ func (jm *jobMgr) handleStatusUpdateMessage() {
 			if !ok { //Channel is closed, all transfers have been attended.
 				jstm.xferDone = nil
 
+				// flush the manifest before anyone is told that the job is done, in case they then read it
+				jm.closeManifest()
+
 				//close drainXferDone so that other components can know no further updates happen
 				allXferDoneHandled = true
 				close(jstm.xferDoneDrained)
@@ -134,12 +143,20 @@ func (jm *jobMgr) handleStatusUpdateMessage() {
 				common.ETransferStatus.TierAvailabilityCheckFailure(),
 				common.ETransferStatus.BlobTierFailure():
 				js.TransfersFailed++
+				js.FailuresByCategory.Add(msg.FailureCategory)
+				js.FailedTransfers = append(js.FailedTransfers, msg)
+			case common.ETransferStatus.PreconditionFailed():
+				js.TransfersFailed++
+				js.TransfersPreconditionFailed++
+				js.FailuresByCategory.Add(common.EFailureCategory.Precondition())
 				js.FailedTransfers = append(js.FailedTransfers, msg)
 			case common.ETransferStatus.SkippedEntityAlreadyExists(),
 				common.ETransferStatus.SkippedBlobHasSnapshots():
 				js.TransfersSkipped++
 				js.SkippedTransfers = append(js.SkippedTransfers, msg)
 			}
+			jm.addToManifest(msg)
+			jm.abortIfTooManyErrors()
 
 		case <-jstm.listReq:
 			/* Display stats */
@@ -162,3 +179,20 @@ func (jm *jobMgr) handleStatusUpdateMessage() {
 		}
 	}
 }
+
+// abortIfTooManyErrors cancels the job once more of its transfers have failed than its MaxErrors allows. The job then winds
+// down as it would if the user had cancelled it: its in-flight transfers stop, and its manifest and summary are still written.
+// Only called by the statusManager's goroutine
+func (jm *jobMgr) abortIfTooManyErrors() {
+	js := &jm.jstm.js
+	maxErrors := jm.getInMemoryTransitJobState().MaxErrors
+	if maxErrors == 0 || js.TransfersFailed <= maxErrors || js.AbortReason != "" {
+		return
+	}
+
+	js.AbortReason = fmt.Sprintf("more than %d transfers failed", maxErrors)
+	msg := fmt.Sprintf("Cancelling the job, because %s, which is the most that --max-errors allows", js.AbortReason)
+	jm.Log(pipeline.LogError, msg)
+	common.GetLifecycleMgr().Info(msg)
+	jm.CancelPauseJobOrder(common.EJobStatus.Cancelling())
+}
@@ -48,6 +48,10 @@ type InMemoryTransitJobState struct {
 	CredentialInfo common.CredentialInfo
 	// S2SSourceCredentialType can override the CredentialInfo.CredentialType when being used for the source (e.g. Source Info Provider and when using GetS2SSourceBlobTokenCredential)
 	S2SSourceCredentialType common.CredentialType
+	// ManifestOutput is where to write the manifest of the job's transferred and failed files. Empty means no manifest
+	ManifestOutput string
+	// MaxErrors is how many transfers can fail before the job cancels itself. 0 means no limit
+	MaxErrors uint32
 }
 
 type IJobMgr interface {
@@ -65,6 +69,7 @@ type IJobMgr interface {
 	ResetAllTransfersScheduled()
 	Reset(context.Context, string) IJobMgr
 	PipelineLogInfo() pipeline.LogOptions
+	ComponentLogger(component common.LogComponent) common.ILogger
 	ReportJobPartDone(jobPartProgressInfo)
 	Context() context.Context
 	Cancel()
@@ -93,12 +98,19 @@ type IJobMgr interface {
 	/* Ported from jobsAdmin() */
 	ScheduleTransfer(priority common.JobPriority, jptm IJobPartTransferMgr)
 	ScheduleChunk(priority common.JobPriority, chunkFunc chunkFunc)
+	ScheduleSmallFileChunk(chunkFunc chunkFunc)
+	IsSmallFile(size int64) bool
+	DestinationArchive(path string, format common.ArchiveFormat) *destinationArchive
+	SourceArchive(path string, format common.ArchiveFormat) (*common.ArchiveSource, error)
 
 	/* Some comment */
 	IterateJobParts(readonly bool, f func(k common.PartNumber, v IJobPartMgr))
 	TransferDirection() common.TransferDirection
 	AddSuccessfulBytesInActiveFiles(n int64)
 	SuccessfulBytesInActiveFiles() uint64
+	ReportPOSIXPropertiesNotRestored()
+	POSIXPropertiesNotRestored() uint32
+	ETAEstimator() *common.ETAEstimator
 	CancelPauseJobOrder(desiredJobStatus common.JobStatus) common.CancelPauseResumeResponse
 	IsDaemon() bool
 
@@ -129,6 +141,8 @@ func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx conte
 	// Create normal & low transfer/chunk channels
 	normalTransferCh, normalChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
 	lowTransferCh, lowChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
+	// Small files, of either priority, have transfer/chunk channels of their own, served by the small-file pool
+	smallFileTransferCh, smallFileChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
 
 	// atomicAllTransfersScheduled is set to 1 since this api is also called when new job part is ordered.
 	enableChunkLogOutput := level.ToPipelineLogLevel() == pipeline.LogDebug
@@ -148,29 +162,40 @@ func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx conte
 		jobLogger.OpenLog()
 	}
 
+	// when the throughput is auto-tuned, the pacer is the tuner, and it needs to hear about every 503
+	var throughputTuner retryNotificationReceiver
+	if autoPacer, ok := pacer.(ThroughputAutoPacer); ok {
+		throughputTuner = autoPacer
+	}
+
 	jm := jobMgr{jobID: jobID, jobPartMgrs: newJobPartToJobPartMgr(), include: map[string]int{}, exclude: map[string]int{},
 		httpClient:           NewAzcopyHTTPClient(concurrency.MaxIdleConnections),
 		logger:               jobLogger,
 		chunkStatusLogger:    common.NewChunkStatusLogger(jobID, cpuMon, logFileFolder, enableChunkLogOutput),
 		concurrency:          concurrency,
 		overwritePrompter:    newOverwritePrompter(),
-		pipelineNetworkStats: newPipelineNetworkStats(tuner), // let the stats coordinate with the concurrency tuner
+		pipelineNetworkStats: newPipelineNetworkStats(tuner, throughputTuner), // let the stats coordinate with the concurrency and throughput tuners
+		etaEstimator:         common.NewETAEstimator(etaWindow, etaRampUp),
 		initMu:               &sync.Mutex{},
 		jobPartProgress:      jobPartProgressCh,
 		reportCancelCh:       make(chan struct{}, 1),
 		coordinatorChannels: CoordinatorChannels{
-			partsChannel:     partsCh,
-			normalTransferCh: normalTransferCh,
-			lowTransferCh:    lowTransferCh,
+			partsChannel:        partsCh,
+			normalTransferCh:    normalTransferCh,
+			lowTransferCh:       lowTransferCh,
+			smallFileTransferCh: smallFileTransferCh,
 		},
 		xferChannels: XferChannels{
-			partsChannel:     partsCh,
-			normalTransferCh: normalTransferCh,
-			lowTransferCh:    lowTransferCh,
-			normalChunckCh:   normalChunkCh,
-			lowChunkCh:       lowChunkCh,
-			closeTransferCh:  make(chan struct{}, 100),
-			scheduleCloseCh:  make(chan struct{}, 1),
+			partsChannel:        partsCh,
+			normalTransferCh:    normalTransferCh,
+			lowTransferCh:       lowTransferCh,
+			smallFileTransferCh: smallFileTransferCh,
+			normalChunckCh:      normalChunkCh,
+			lowChunkCh:          lowChunkCh,
+			smallFileChunkCh:    smallFileChunkCh,
+			closeTransferCh:     make(chan struct{}, 100),
+			closeSmallFileCh:    make(chan struct{}, 2*concurrency.SmallFileConcurrency.Value),
+			scheduleCloseCh:     make(chan struct{}, 1),
 		},
 		poolSizingChannels: poolSizingChannels{ // all deliberately unbuffered, because pool sizer routine works in lock-step with these - processing them as they happen, never catching up on populated buffer later
 			entryNotificationCh: make(chan struct{}),
@@ -196,9 +221,8 @@ func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx conte
 	// (so that transfer initiation can't starve out progress on already-scheduled chunks.
 	// (Not sure whether that can really happen, but this protects against it anyway.)
 	// Perhaps MORE importantly, doing this separately gives us more CONTROL over how we interact with the file system.
-	for cc := 0; cc < concurrency.TransferInitiationPoolSize.Value; cc++ {
-		go jm.transferProcessor(cc)
-	}
+	// Small files get a pool of their own, for both of those jobs.
+	jm.startTransferProcessors()
 
 	go jm.reportJobPartDoneHandler()
 	go jm.handleStatusUpdateMessage()
@@ -251,6 +275,14 @@ func (jm *jobMgr) logConcurrencyParameters() {
 		jm.concurrency.TransferInitiationPoolSize.Value,
 		jm.concurrency.TransferInitiationPoolSize.GetDescription()))
 
+	jm.logger.Log(level, fmt.Sprintf("Small file threshold: %d bytes (%s)",
+		jm.concurrency.SmallFileThreshold.Value,
+		jm.concurrency.SmallFileThreshold.GetDescription()))
+
+	jm.logger.Log(level, fmt.Sprintf("Max concurrent small file routines: %d (%s)",
+		jm.concurrency.SmallFileConcurrency.Value,
+		jm.concurrency.SmallFileConcurrency.GetDescription()))
+
 	jm.logger.Log(level, fmt.Sprintf("Max enumeration routines: %d (%s)",
 		jm.concurrency.EnumerationPoolSize.Value,
 		jm.concurrency.EnumerationPoolSize.GetDescription()))
@@ -285,9 +317,10 @@ type jobMgr struct {
 	atomicSuccessfulBytesInActiveFiles int64 // atomic 64-bit values should always be at the start of a struct to ensure alignment
 	atomicCurrentMainPoolSize          int32
 	// atomicAllTransfersScheduled defines whether all job parts have been iterated and resumed or not
-	atomicAllTransfersScheduled     int32
-	atomicFinalPartOrderedIndicator int32
-	atomicTransferDirection         common.TransferDirection
+	atomicAllTransfersScheduled      int32
+	atomicFinalPartOrderedIndicator  int32
+	atomicTransferDirection          common.TransferDirection
+	atomicPOSIXPropertiesNotRestored uint32
 
 	concurrency          ConcurrencySettings
 	logger               common.ILoggerResetable
@@ -296,6 +329,8 @@ type jobMgr struct {
 	ctx                  context.Context
 	cancel               context.CancelFunc
 	pipelineNetworkStats *PipelineNetworkStats
+	// predicts when the job will finish, from the progress summaries taken of it
+	etaEstimator *common.ETAEstimator
 
 	// Share the same HTTP Client across all job parts, so that the we maximize re-use of
 	// its internal connection pool
@@ -322,6 +357,15 @@ type jobMgr struct {
 	// must have a single instance of this, for the whole job
 	folderCreationTracker FolderCreationTracker
 
+	// the archive that all the job's files are streamed into, if it has one. Created by the first transfer to use it
+	destinationArchiveMu sync.Mutex
+	destinationArchive   *destinationArchive
+
+	// the archive that all the job's files are read from, if it has one. Opened by the first transfer to use it
+	sourceArchiveMu  sync.Mutex
+	sourceArchive    *common.ArchiveSource
+	sourceArchiveErr error
+
 	initMu    *sync.Mutex
 	initState *jobMgrInitState
 
@@ -657,6 +701,10 @@ func (jm *jobMgr) reportJobPartDoneHandler() {
 					jm.Log(pipeline.LogInfo, fmt.Sprintf("%s %s successfully completed, cancelled or paused", partDescription, jm.jobID.String()))
 				}
 
+				// the archive must be complete before the job is seen to be done, since the front end may exit then
+				archiveFinished := jm.closeDestinationArchive()
+				jm.closeSourceArchive()
+
 				switch part0Plan.JobStatus() {
 				case common.EJobStatus.Cancelling():
 					part0Plan.SetJobStatus(common.EJobStatus.Cancelled())
@@ -665,7 +713,7 @@ func (jm *jobMgr) reportJobPartDoneHandler() {
 					}
 				case common.EJobStatus.InProgress():
 					part0Plan.SetJobStatus((common.EJobStatus).EnhanceJobStatusInfo(jobProgressInfo.transfersSkipped > 0,
-						jobProgressInfo.transfersFailed > 0,
+						jobProgressInfo.transfersFailed > 0 || !archiveFinished,
 						jobProgressInfo.transfersCompleted > 0))
 				}
 
@@ -699,11 +747,17 @@ func (jm *jobMgr) Cancel()                                 { jm.cancel() }
 func (jm *jobMgr) ShouldLog(level pipeline.LogLevel) bool  { return jm.logger.ShouldLog(level) }
 func (jm *jobMgr) Log(level pipeline.LogLevel, msg string) { jm.logger.Log(level, msg) }
 func (jm *jobMgr) PipelineLogInfo() pipeline.LogOptions {
+	network := jm.ComponentLogger(common.ELogComponent.Network())
 	return pipeline.LogOptions{
-		Log:       jm.Log,
-		ShouldLog: func(level pipeline.LogLevel) bool { return level <= jm.logger.MinimumLogLevel() },
+		Log:       network.Log,
+		ShouldLog: network.ShouldLog,
 	}
 }
+
+// ComponentLogger returns the logger for messages about one component of the job, which logs at that component's level
+func (jm *jobMgr) ComponentLogger(component common.LogComponent) common.ILogger {
+	return common.LoggerForComponent(jm.logger, component)
+}
 func (jm *jobMgr) Panic(err error) { jm.logger.Panic(err) }
 func (jm *jobMgr) CloseLog() {
 	jm.logger.CloseLog()
@@ -765,19 +819,23 @@ func (jm *jobMgr) cleanupChunkStatusLogger() {
 /* Infra ported to jobManager from JobsAdmin */
 
 type CoordinatorChannels struct {
-	partsChannel     chan<- IJobPartMgr         // Write Only
-	normalTransferCh chan<- IJobPartTransferMgr // Write-only
-	lowTransferCh    chan<- IJobPartTransferMgr // Write-only
+	partsChannel        chan<- IJobPartMgr         // Write Only
+	normalTransferCh    chan<- IJobPartTransferMgr // Write-only
+	lowTransferCh       chan<- IJobPartTransferMgr // Write-only
+	smallFileTransferCh chan<- IJobPartTransferMgr // Write-only
 }
 
 type XferChannels struct {
-	partsChannel     <-chan IJobPartMgr         // Read only
-	normalTransferCh <-chan IJobPartTransferMgr // Read-only
-	lowTransferCh    <-chan IJobPartTransferMgr // Read-only
-	normalChunckCh   chan chunkFunc             // Read-write
-	lowChunkCh       chan chunkFunc             // Read-write
-	closeTransferCh  chan struct{}
-	scheduleCloseCh  chan struct{}
+	partsChannel        <-chan IJobPartMgr         // Read only
+	normalTransferCh    <-chan IJobPartTransferMgr // Read-only
+	lowTransferCh       <-chan IJobPartTransferMgr // Read-only
+	smallFileTransferCh <-chan IJobPartTransferMgr // Read-only
+	normalChunckCh      chan chunkFunc             // Read-write
+	lowChunkCh          chan chunkFunc             // Read-write
+	smallFileChunkCh    chan chunkFunc             // Read-write
+	closeTransferCh     chan struct{}
+	closeSmallFileCh    chan struct{}
+	scheduleCloseCh     chan struct{}
 }
 
 type poolSizingChannels struct {
@@ -796,6 +854,12 @@ func (jm *jobMgr) CurrentMainPoolSize() int {
 }
 
 func (jm *jobMgr) ScheduleTransfer(priority common.JobPriority, jptm IJobPartTransferMgr) {
+	if jptm.IsSmallFile() {
+		// small files bypass the priority channels, so that they are never queued behind large ones
+		jm.coordinatorChannels.smallFileTransferCh <- jptm
+		return
+	}
+
 	switch priority { // priority determines which channel handles the job part's transfers
 	case common.EJobPriority.Normal():
 		// jptm.SetChunkChannel(ja.xferChannels.normalChunckCh)
@@ -819,6 +883,65 @@ func (jm *jobMgr) ScheduleChunk(priority common.JobPriority, chunkFunc chunkFunc
 	}
 }
 
+// ScheduleSmallFileChunk queues a chunk of a small file, for the small-file pool
+func (jm *jobMgr) ScheduleSmallFileChunk(chunkFunc chunkFunc) {
+	jm.xferChannels.smallFileChunkCh <- chunkFunc
+}
+
+// IsSmallFile says whether a file of the given size is transferred by the small-file pool
+func (jm *jobMgr) IsSmallFile(size int64) bool {
+	return jm.concurrency.IsSmallFile(size)
+}
+
+// DestinationArchive returns the single archive that the job's files are streamed into, creating it on first use
+func (jm *jobMgr) DestinationArchive(path string, format common.ArchiveFormat) *destinationArchive {
+	jm.destinationArchiveMu.Lock()
+	defer jm.destinationArchiveMu.Unlock()
+	if jm.destinationArchive == nil {
+		jm.destinationArchive = newDestinationArchive(path, format)
+	}
+	return jm.destinationArchive
+}
+
+// closeDestinationArchive finishes the job's destination archive, if it has one. Returns false if that failed, in
+// which case the archive is unusable, however many of its transfers succeeded
+func (jm *jobMgr) closeDestinationArchive() bool {
+	jm.destinationArchiveMu.Lock()
+	archive := jm.destinationArchive
+	jm.destinationArchiveMu.Unlock()
+	if archive == nil {
+		return true
+	}
+
+	err := archive.Close()
+	if err != nil {
+		jm.Log(pipeline.LogError, fmt.Sprintf("Failed to finish the destination archive %s: %s", archive.path, err))
+		common.GetLifecycleMgr().Info("Failed to finish the destination archive " + archive.path + ": " + err.Error())
+		return false
+	}
+	return true
+}
+
+// SourceArchive returns the single archive that the job's files are read from, opening it on first use
+func (jm *jobMgr) SourceArchive(path string, format common.ArchiveFormat) (*common.ArchiveSource, error) {
+	jm.sourceArchiveMu.Lock()
+	defer jm.sourceArchiveMu.Unlock()
+	if jm.sourceArchive == nil && jm.sourceArchiveErr == nil {
+		jm.sourceArchive, jm.sourceArchiveErr = common.OpenArchiveSource(path, format)
+	}
+	return jm.sourceArchive, jm.sourceArchiveErr
+}
+
+func (jm *jobMgr) closeSourceArchive() {
+	jm.sourceArchiveMu.Lock()
+	defer jm.sourceArchiveMu.Unlock()
+	if jm.sourceArchive != nil {
+		_ = jm.sourceArchive.Close()
+		jm.sourceArchive = nil
+	}
+	jm.sourceArchiveErr = nil
+}
+
 // QueueJobParts puts the given JobPartManager into the partChannel
 // from where this JobPartMgr will be picked by a routine and
 // its transfers will be scheduled
@@ -844,6 +967,10 @@ func (jm *jobMgr) cleanupTransferRoutine() {
 	for cc := 0; cc < jm.concurrency.TransferInitiationPoolSize.Value; cc++ {
 		jm.xferChannels.closeTransferCh <- struct{}{}
 	}
+	// one for each of the small-file pool's transfer and chunk processors
+	for cc := 0; cc < 2*jm.concurrency.SmallFileConcurrency.Value; cc++ {
+		jm.xferChannels.closeSmallFileCh <- struct{}{}
+	}
 }
 
 // worker that sizes the chunkProcessor pool, dynamically if necessary
@@ -997,25 +1124,38 @@ func (jm *jobMgr) chunkProcessor(workerID int) {
 	}
 }
 
-// separate from the chunkProcessor, this dedicated worker that reads in and executes transfer initiation jobs
-// (which in turn schedule chunks that get picked up by chunkProcessor)
-func (jm *jobMgr) transferProcessor(workerID int) {
-	startTransfer := func(jptm IJobPartTransferMgr) {
-		if jptm.WasCanceled() {
-			if jptm.ShouldLog(pipeline.LogInfo) {
-				jptm.Log(pipeline.LogInfo, fmt.Sprintf(" is not picked up worked %d because transfer was cancelled", workerID))
-			}
-			jptm.SetStatus(common.ETransferStatus.Cancelled())
-			jptm.ReportTransferDone()
-		} else {
-			// TODO fix preceding space
-			if jptm.ShouldLog(pipeline.LogInfo) {
-				jptm.Log(pipeline.LogInfo, fmt.Sprintf("has worker %d which is processing TRANSFER %d", workerID, jptm.(*jobPartTransferMgr).transferIndex))
-			}
-			jptm.StartJobXfer()
+// startTransferProcessors spins up the transfer initiation workers, and the whole of the small-file pool.
+// (The main pool's chunk processors are started by the poolSizer, once there is a job part to work on.)
+func (jm *jobMgr) startTransferProcessors() {
+	for cc := 0; cc < jm.concurrency.TransferInitiationPoolSize.Value; cc++ {
+		go jm.transferProcessor(cc)
+	}
+	for cc := 0; cc < jm.concurrency.SmallFileConcurrency.Value; cc++ {
+		go jm.smallFileTransferProcessor(cc)
+		go jm.smallFileChunkProcessor(cc)
+	}
+}
+
+// startTransfer is how the transfer initiation workers, of both pools, run each transfer they pick up
+func (jm *jobMgr) startTransfer(workerID int, jptm IJobPartTransferMgr) {
+	if jptm.WasCanceled() {
+		if scheduler := jptm.ComponentLogger(common.ELogComponent.Scheduler()); scheduler.ShouldLog(pipeline.LogInfo) {
+			scheduler.Log(pipeline.LogInfo, fmt.Sprintf(" is not picked up worked %d because transfer was cancelled", workerID))
+		}
+		jptm.SetStatus(common.ETransferStatus.Cancelled())
+		jptm.ReportTransferDone()
+	} else {
+		// TODO fix preceding space
+		if scheduler := jptm.ComponentLogger(common.ELogComponent.Scheduler()); scheduler.ShouldLog(pipeline.LogInfo) {
+			scheduler.Log(pipeline.LogInfo, fmt.Sprintf("has worker %d which is processing TRANSFER %d", workerID, jptm.(*jobPartTransferMgr).transferIndex))
 		}
+		jptm.StartJobXfer()
 	}
+}
 
+// separate from the chunkProcessor, this dedicated worker that reads in and executes transfer initiation jobs
+// (which in turn schedule chunks that get picked up by chunkProcessor)
+func (jm *jobMgr) transferProcessor(workerID int) {
 	for {
 		// No scaleback check here, because this routine runs only in a small number of goroutines, so no need to kill them off
 		select {
@@ -1024,12 +1164,12 @@ func (jm *jobMgr) transferProcessor(workerID int) {
 			return
 
 		case jptm := <-jm.xferChannels.normalTransferCh:
-			startTransfer(jptm)
+			jm.startTransfer(workerID, jptm)
 
 		default:
 			select {
 			case jptm := <-jm.xferChannels.lowTransferCh:
-				startTransfer(jptm)
+				jm.startTransfer(workerID, jptm)
 			default:
 				time.Sleep(10 * time.Millisecond) // Sleep before looping around
 			}
@@ -1037,6 +1177,32 @@ func (jm *jobMgr) transferProcessor(workerID int) {
 	}
 }
 
+// smallFileTransferProcessor initiates the transfers of small files. It has just one channel to read, so, unlike
+// transferProcessor, it can simply block on it
+func (jm *jobMgr) smallFileTransferProcessor(workerID int) {
+	for {
+		select {
+		case <-jm.xferChannels.closeSmallFileCh:
+			return
+		case jptm := <-jm.xferChannels.smallFileTransferCh:
+			jm.startTransfer(workerID, jptm)
+		}
+	}
+}
+
+// smallFileChunkProcessor executes the chunks of small files. The small-file pool is a fixed size, so, unlike
+// chunkProcessor, it has no scalebacks to look out for
+func (jm *jobMgr) smallFileChunkProcessor(workerID int) {
+	for {
+		select {
+		case <-jm.xferChannels.closeSmallFileCh:
+			return
+		case chunkFunc := <-jm.xferChannels.smallFileChunkCh:
+			chunkFunc(workerID)
+		}
+	}
+}
+
 func (jm *jobMgr) IterateJobParts(readonly bool, f func(k common.PartNumber, v IJobPartMgr)) {
 	jm.jobPartMgrs.Iterate(readonly, f)
 }
@@ -1057,6 +1223,27 @@ func (jm *jobMgr) SuccessfulBytesInActiveFiles() uint64 {
 	return uint64(n)
 }
 
+// ReportPOSIXPropertiesNotRestored counts a downloaded file whose POSIX properties could not be set
+func (jm *jobMgr) ReportPOSIXPropertiesNotRestored() {
+	atomic.AddUint32(&jm.atomicPOSIXPropertiesNotRestored, 1)
+}
+
+func (jm *jobMgr) POSIXPropertiesNotRestored() uint32 {
+	return atomic.LoadUint32(&jm.atomicPOSIXPropertiesNotRestored)
+}
+
+// the throughput behind the job's ETA is averaged over etaWindow, and there is no ETA until the job has been
+// transferring for etaRampUp, since its first few seconds are slower than the rest
+const (
+	etaWindow = 30 * time.Second
+	etaRampUp = 10 * time.Second
+)
+
+// ETAEstimator returns the estimator that the job's progress summaries feed, and take their ETA from
+func (jm *jobMgr) ETAEstimator() *common.ETAEstimator {
+	return jm.etaEstimator
+}
+
 func (jm *jobMgr) CancelPauseJobOrder(desiredJobStatus common.JobStatus) common.CancelPauseResumeResponse {
 	verb := common.IffString(desiredJobStatus == common.EJobStatus.Paused(), "pause", "cancel")
 	jobID := jm.jobID
@@ -2,6 +2,8 @@ package ste
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"mime"
 	"net"
@@ -34,6 +36,7 @@ type IJobPartMgr interface {
 	GetOverwriteOption() common.OverwriteOption
 	GetForceIfReadOnly() bool
 	AutoDecompress() bool
+	DownloadRangesInPlace() bool
 	ScheduleChunks(chunkFunc chunkFunc)
 	RescheduleTransfer(jptm IJobPartTransferMgr)
 	BlobTypeOverride() common.BlobType
@@ -52,6 +55,7 @@ type IJobPartMgr interface {
 	ExclusiveDestinationMap() *common.ExclusiveStringMap
 	ChunkStatusLogger() common.ChunkStatusLogger
 	common.ILogger
+	ComponentLogger(component common.LogComponent) common.ILogger
 	SourceProviderPipeline() pipeline.Pipeline
 	SourceCredential() pipeline.Factory
 	getOverwritePrompter() *overwritePrompter
@@ -61,6 +65,8 @@ type IJobPartMgr interface {
 	CpkInfo() common.CpkInfo
 	CpkScopeInfo() common.CpkScopeInfo
 	IsSourceEncrypted() bool
+	DestinationConditions() azblob.ModifiedAccessConditions
+	ImmutabilityPolicy() azblob.ImmutabilityPolicyOptions
 	/* Status Manager Updates */
 	SendXferDoneMsg(msg xferDoneMsg)
 	PropertiesToTransfer() common.SetPropertiesFlags
@@ -91,6 +97,35 @@ func NewVersionPolicyFactory() pipeline.Factory {
 	})
 }
 
+// maxSourceRedirects is how many redirects a read may follow, when the job lets downloads follow the redirects of their
+// sources, so that a redirect loop fails rather than spinning
+const maxSourceRedirects = 5
+
+// withSourceRedirects returns a client that shares the connections of client, but lets reads follow redirects, such as
+// those of a CDN endpoint in front of the source. The range is kept, so that a ranged read still gets only its range.
+// Other requests are redirected as they would be by client
+func withSourceRedirects(client *http.Client) *http.Client {
+	redirecting := *client
+	redirecting.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			if len(via) >= 10 { // the limit of http.Client's default policy, which client has
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+		if len(via) > maxSourceRedirects {
+			return fmt.Errorf("stopped after %d redirects, which may be a redirect loop", maxSourceRedirects)
+		}
+		for _, header := range []string{"Range", "x-ms-range"} {
+			if value := via[0].Header.Get(header); value != "" {
+				req.Header.Set(header, value)
+			}
+		}
+		return nil
+	}
+	return &redirecting
+}
+
 // NewAzcopyHTTPClient creates a new HTTP client.
 // We must minimize use of this, and instead maximize re-use of the returned client object.
 // Why? Because that makes our connection pooling more efficient, and prevents us exhausting the
@@ -104,6 +139,7 @@ func NewAzcopyHTTPClient(maxIdleConns int) *http.Client {
 				Timeout:   30 * time.Second,
 				KeepAlive: 30 * time.Second,
 				DualStack: true,
+				LocalAddr: common.GlobalDialLocalAddress, // when set, only addresses of the same family are dialled
 			}).DialContext,
 			MaxIdleConns:           0, // No limit
 			MaxIdleConnsPerHost:    maxIdleConns,
@@ -224,6 +260,7 @@ func NewFilePipeline(c azfile.Credential, o azfile.PipelineOptions, r azfile.Ret
 		c,
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
 		NewVersionPolicyFactory(),
+		NewTrailingDotPolicyFactory(), // after the version policy, since it may need a newer version
 		NewRequestLogPolicyFactory(RequestLogOptions{
 			LogWarningIfTryOverThreshold: o.RequestLog.LogWarningIfTryOverThreshold,
 			SyslogDisabled:               common.IsForceLoggingDisabled(),
@@ -280,6 +317,11 @@ type jobPartMgr struct {
 
 	blobTypeOverride common.BlobType // User specified blob type
 
+	noGuessMimeTypeFromExtension bool // detect the content type from the content alone
+
+	contentTypeMap     map[string]string // the content type of each extension it lists, ahead of any other mapping
+	defaultContentType string            // the content type of the extensions that contentTypeMap doesn't list
+
 	preserveLastModifiedTime bool
 
 	newJobXfer newJobXfer // Method used to start the transfer
@@ -316,6 +358,12 @@ type jobPartMgr struct {
 
 	cpkOptions common.CpkOptions
 
+	// the conditions, from --if-match and --if-none-match, that a destination blob must meet to be written
+	destinationConditions azblob.ModifiedAccessConditions
+
+	// the immutability policy and legal hold, from --immutability-policy-until and --legal-hold, that written blobs are given
+	immutabilityPolicy azblob.ImmutabilityPolicyOptions
+
 	closeOnCompletion chan struct{}
 
 	SetPropertiesFlags common.SetPropertiesFlags
@@ -346,6 +394,9 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 	// partplan file is opened and mapped when job part is added
 	// jpm.planMMF = jpm.filename.Map() // Open the job part plan file & memory-map it in
 	plan := jpm.planMMF.Plan()
+	if plan.AllowTrailingDot {
+		jobCtx = context.WithValue(jobCtx, AllowTrailingDot, true)
+	}
 	if plan.PartNum == 0 && plan.NumTransfers == 0 {
 		/* This will wind down the transfer and report summary */
 		plan.SetJobStatus(common.EJobStatus.Completed())
@@ -370,6 +421,7 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 	}
 
 	jpm.putMd5 = dstData.PutMd5
+	jpm.noGuessMimeTypeFromExtension = dstData.NoGuessMimeTypeFromExtension
 	jpm.blockBlobTier = dstData.BlockBlobTier
 	jpm.pageBlobTier = dstData.PageBlobTier
 
@@ -399,23 +451,48 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 		IsSourceEncrypted: dstData.IsSourceEncrypted,
 	}
 
+	jpm.destinationConditions = azblob.ModifiedAccessConditions{
+		IfMatch:     azblob.ETag(dstData.IfMatchETag[:dstData.IfMatchETagLength]),
+		IfNoneMatch: azblob.ETag(dstData.IfNoneMatchETag[:dstData.IfNoneMatchETagLength]),
+	}
+
+	jpm.contentTypeMap = nil
+	if dstData.ContentTypeMapLength > 0 {
+		// the map was checked when the job was planned, so it can only fail to parse if the plan file is damaged
+		if err := json.Unmarshal(dstData.ContentTypeMap[:dstData.ContentTypeMapLength], &jpm.contentTypeMap); err != nil {
+			panic(fmt.Errorf("cannot parse the content type map of job part %d: %w", plan.PartNum, err))
+		}
+	}
+	jpm.defaultContentType = string(dstData.DefaultContentType[:dstData.DefaultContentTypeLength])
+
+	jpm.immutabilityPolicy = azblob.ImmutabilityPolicyOptions{}
+	if dstData.ImmutabilityPolicyUntil != 0 {
+		until := time.Unix(0, dstData.ImmutabilityPolicyUntil).UTC()
+		jpm.immutabilityPolicy.ImmutabilityPolicyUntilDate = &until
+		jpm.immutabilityPolicy.ImmutabilityPolicyMode = azblob.BlobImmutabilityPolicyModeUnlocked
+	}
+	if dstData.LegalHold {
+		legalHold := true
+		jpm.immutabilityPolicy.LegalHold = &legalHold
+	}
+
 	jpm.SetPropertiesFlags = dstData.SetPropertiesFlags
 	jpm.RehydratePriority = plan.RehydratePriority
 
 	jpm.preserveLastModifiedTime = plan.DstLocalData.PreserveLastModifiedTime
 
 	jpm.blobTypeOverride = plan.DstBlobData.BlobType
-	jpm.newJobXfer = computeJobXfer(plan.FromTo, plan.DstBlobData.BlobType)
+	jpm.newJobXfer = computeJobXfer(plan.FromTo, plan.DstBlobData.BlobType, plan.MetadataOnly)
 
 	jpm.priority = plan.Priority
 
 	jpm.createPipelines(jobCtx) // pipeline is created per job part manager
 
 	// *** Schedule this job part's transfers ***
-	for t := uint32(0); t < plan.NumTransfers; t++ {
+	for _, t := range jpm.transferSchedulingOrder(plan) {
 		jppt := plan.Transfer(t)
 		ts := jppt.TransferStatus()
-		if ts == common.ETransferStatus.Success() {
+		if ts == common.ETransferStatus.Success() && !isDeferredFolderDeletion(plan.FromTo, jppt.EntityType) {
 			jpm.ReportTransferDone(ts) // Don't schedule an already-completed/failed transfer
 			continue
 		}
@@ -448,13 +525,19 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 			jobPartMgr:          jpm,
 			jobPartPlanTransfer: jppt,
 			transferIndex:       t,
-			ctx:                 transferCtx,
+			smallFile:           jppt.EntityType == common.EEntityType.File() && jpm.jobMgr.IsSmallFile(jppt.SourceSize),
 			cancel:              transferCancel,
 			// TODO: insert the factory func interface in jptm.
 			// numChunks will be set by the transfer's prologue method
 		}
-		if jpm.ShouldLog(pipeline.LogInfo) {
-			jpm.Log(pipeline.LogInfo, fmt.Sprintf("scheduling JobID=%v, Part#=%d, Transfer#=%d, priority=%v", plan.JobID, plan.PartNum, t, plan.Priority))
+		// count the retries of every request that the transfer makes, so that we can log the total when it is done
+		jptm.ctx = withRetryCounter(transferCtx, &jptm.atomicRetryCount)
+		if plan.PerTransferTimeout > 0 {
+			jptm.watchdog = newProgressWatchdog(plan.PerTransferTimeout, jptm.failStalledTransfer)
+			jptm.ctx = withProgressWatchdog(jptm.ctx, jptm.watchdog)
+		}
+		if scheduler := jpm.ComponentLogger(common.ELogComponent.Scheduler()); scheduler.ShouldLog(pipeline.LogInfo) {
+			scheduler.Log(pipeline.LogInfo, fmt.Sprintf("scheduling JobID=%v, Part#=%d, Transfer#=%d, priority=%v", plan.JobID, plan.PartNum, t, plan.Priority))
 		}
 
 		// ===== TEST KNOB
@@ -504,6 +587,13 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 	}
 }
 
+// isDeferredFolderDeletion says whether the transfer deletes a folder from Azure Files. Such a transfer is marked done as soon
+// as the deletion is queued, because the folder can only be deleted after the files in it. So when the job is resumed,
+// the folder may still be there, and its deletion is queued again. Deleting a folder that is already gone succeeds.
+func isDeferredFolderDeletion(fromTo common.FromTo, entityType common.EntityType) bool {
+	return fromTo.IsDelete() && fromTo.From() == common.ELocation.File() && entityType == common.EEntityType.Folder()
+}
+
 func (jpm *jobPartMgr) ScheduleChunks(chunkFunc chunkFunc) {
 	jpm.jobMgr.ScheduleChunk(jpm.priority, chunkFunc)
 }
@@ -522,6 +612,10 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 	if jpm.credInfo.CredentialType == common.ECredentialType.Unknown() {
 		credInfo = jpm.jobMgr.getInMemoryTransitJobState().CredentialInfo
 	}
+	httpClient := jpm.jobMgr.HttpClient()
+	if jpm.Plan().FollowSourceRedirects {
+		httpClient = withSourceRedirects(httpClient)
+	}
 	userAgent := common.UserAgent
 	if fromTo.From() == common.ELocation.S3() {
 		userAgent = common.S3ImportUserAgent
@@ -540,12 +634,8 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 		Cancel:   jpm.jobMgr.Cancel,
 	}
 	// TODO: Consider to remove XferRetryPolicy and Options?
-	xferRetryOption := XferRetryOptions{
-		Policy:        0,
-		MaxTries:      UploadMaxTries, // TODO: Consider to unify options.
-		TryTimeout:    UploadTryTimeout,
-		RetryDelay:    UploadRetryDelay,
-		MaxRetryDelay: UploadMaxRetryDelay}
+	xferRetryOption := RequestRetryOptions // TODO: Consider to unify options.
+	xferRetryOption.TryTimeout = UploadTryTimeout
 
 	var statsAccForSip *PipelineNetworkStats = nil // we don't accumulate stats on the source info provider
 
@@ -576,7 +666,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 			},
 			xferRetryOption,
 			jpm.pacer,
-			jpm.jobMgr.HttpClient(),
+			httpClient,
 			statsAccForSip)
 
 		// Consider the ADLSG2->ADLSG2 ACLs case
@@ -591,7 +681,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				},
 				xferRetryOption,
 				jpm.pacer,
-				jpm.jobMgr.HttpClient(),
+				httpClient,
 				statsAccForSip)
 		}
 	}
@@ -607,13 +697,13 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 			},
 			azfile.RetryOptions{
 				Policy:        azfile.RetryPolicyExponential,
-				MaxTries:      UploadMaxTries,
-				TryTimeout:    UploadTryTimeout,
-				RetryDelay:    UploadRetryDelay,
-				MaxRetryDelay: UploadMaxRetryDelay,
+				MaxTries:      xferRetryOption.MaxTries,
+				TryTimeout:    xferRetryOption.TryTimeout,
+				RetryDelay:    xferRetryOption.RetryDelay,
+				MaxRetryDelay: xferRetryOption.MaxRetryDelay,
 			},
 			jpm.pacer,
-			jpm.jobMgr.HttpClient(),
+			httpClient,
 			statsAccForSip)
 	}
 
@@ -633,7 +723,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 			},
 			xferRetryOption,
 			jpm.pacer,
-			jpm.jobMgr.HttpClient(),
+			httpClient,
 			jpm.jobMgr.PipelineNetworkStats())
 
 		// Consider the ADLSG2->ADLSG2 ACLs case
@@ -649,7 +739,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				},
 				xferRetryOption,
 				jpm.pacer,
-				jpm.jobMgr.HttpClient(),
+				httpClient,
 				statsAccForSip)
 		}
 	// Create pipeline for Azure BlobFS.
@@ -667,7 +757,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 			},
 			xferRetryOption,
 			jpm.pacer,
-			jpm.jobMgr.HttpClient(),
+			httpClient,
 			jpm.jobMgr.PipelineNetworkStats())
 	// Create pipeline for Azure File.
 	case common.EFromTo.FileTrash(), common.EFromTo.FileLocal(), common.EFromTo.LocalFile(), common.EFromTo.BenchmarkFile(),
@@ -682,13 +772,13 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 			},
 			azfile.RetryOptions{
 				Policy:        azfile.RetryPolicyExponential,
-				MaxTries:      UploadMaxTries,
-				TryTimeout:    UploadTryTimeout,
-				RetryDelay:    UploadRetryDelay,
-				MaxRetryDelay: UploadMaxRetryDelay,
+				MaxTries:      xferRetryOption.MaxTries,
+				TryTimeout:    xferRetryOption.TryTimeout,
+				RetryDelay:    xferRetryOption.RetryDelay,
+				MaxRetryDelay: xferRetryOption.MaxRetryDelay,
 			},
 			jpm.pacer,
-			jpm.jobMgr.HttpClient(),
+			httpClient,
 			jpm.jobMgr.PipelineNetworkStats())
 	default:
 		panic(fmt.Errorf("Unrecognized from-to: %q", fromTo.String()))
@@ -727,6 +817,10 @@ func (jpm *jobPartMgr) AutoDecompress() bool {
 	return jpm.Plan().AutoDecompress
 }
 
+func (jpm *jobPartMgr) DownloadRangesInPlace() bool {
+	return jpm.Plan().DownloadRangesInPlace
+}
+
 func (jpm *jobPartMgr) resourceDstData(fullFilePath string, dataFileToXfer []byte) (headers common.ResourceHTTPHeaders,
 	metadata common.Metadata, blobTags common.BlobTags, cpkOptions common.CpkOptions) {
 	if jpm.planMMF.Plan().DstBlobData.NoGuessMimeType {
@@ -763,8 +857,20 @@ var builtinTypes = map[string]string{
 }
 
 func (jpm *jobPartMgr) inferContentType(fullFilePath string, dataFileToXfer []byte) string {
+	if jpm.noGuessMimeTypeFromExtension {
+		return strings.Split(http.DetectContentType(dataFileToXfer), ";")[0]
+	}
+
 	fileExtension := filepath.Ext(fullFilePath)
 
+	if jpm.contentTypeMap != nil {
+		if contentType, ok := jpm.contentTypeMap[strings.ToLower(fileExtension)]; ok {
+			return contentType
+		}
+		if jpm.defaultContentType != "" {
+			return jpm.defaultContentType
+		}
+	}
 	if contentType, ok := EnvironmentMimeMap[strings.ToLower(fileExtension)]; ok {
 		return contentType
 	}
@@ -806,6 +912,14 @@ func (jpm *jobPartMgr) IsSourceEncrypted() bool {
 	return jpm.cpkOptions.IsSourceEncrypted
 }
 
+func (jpm *jobPartMgr) DestinationConditions() azblob.ModifiedAccessConditions {
+	return jpm.destinationConditions
+}
+
+func (jpm *jobPartMgr) ImmutabilityPolicy() azblob.ImmutabilityPolicyOptions {
+	return jpm.immutabilityPolicy
+}
+
 func (jpm *jobPartMgr) PropertiesToTransfer() common.SetPropertiesFlags {
 	return jpm.SetPropertiesFlags
 }
@@ -850,7 +964,7 @@ func (jpm *jobPartMgr) updateJobPartProgress(status common.TransferStatus) {
 	switch status {
 	case common.ETransferStatus.Success():
 		atomic.AddUint32(&jpm.atomicTransfersCompleted, 1)
-	case common.ETransferStatus.Failed(), common.ETransferStatus.BlobTierFailure():
+	case common.ETransferStatus.Failed(), common.ETransferStatus.BlobTierFailure(), common.ETransferStatus.PreconditionFailed():
 		atomic.AddUint32(&jpm.atomicTransfersFailed, 1)
 	case common.ETransferStatus.SkippedEntityAlreadyExists(), common.ETransferStatus.SkippedBlobHasSnapshots():
 		atomic.AddUint32(&jpm.atomicTransfersSkipped, 1)
@@ -925,6 +1039,9 @@ func (jpm *jobPartMgr) ReleaseAConnection() {
 func (jpm *jobPartMgr) ShouldLog(level pipeline.LogLevel) bool  { return jpm.jobMgr.ShouldLog(level) }
 func (jpm *jobPartMgr) Log(level pipeline.LogLevel, msg string) { jpm.jobMgr.Log(level, msg) }
 func (jpm *jobPartMgr) Panic(err error)                         { jpm.jobMgr.Panic(err) }
+func (jpm *jobPartMgr) ComponentLogger(component common.LogComponent) common.ILogger {
+	return jpm.jobMgr.ComponentLogger(component)
+}
 func (jpm *jobPartMgr) ChunkStatusLogger() common.ChunkStatusLogger {
 	return jpm.jobMgr.ChunkStatusLogger()
 }
@@ -60,3 +60,16 @@ func (s *jobPartMgrTestSuite) TestInferContentType(c *chk.C) {
 		c.Assert(strings.Contains(contentType, expectedType), chk.Equals, true)
 	}
 }
+
+func (s *jobPartMgrTestSuite) TestContentTypeMapComesFirst(c *chk.C) {
+	partMgr := jobPartMgr{}
+	partMgr.contentTypeMap = map[string]string{".html": "application/x-custom-html", ".weird": "application/x-weird"}
+
+	c.Assert(partMgr.inferContentType("/a/page.HTML", make([]byte, 5)), chk.Equals, "application/x-custom-html")
+	c.Assert(partMgr.inferContentType("/a/b.weird", make([]byte, 5)), chk.Equals, "application/x-weird")
+	c.Assert(partMgr.inferContentType("/a/b.png", make([]byte, 5)), chk.Equals, "image/png") // not mapped, so detected
+
+	partMgr.defaultContentType = "application/x-unmapped"
+	c.Assert(partMgr.inferContentType("/a/b.png", make([]byte, 5)), chk.Equals, "application/x-unmapped")
+	c.Assert(partMgr.inferContentType("/a/noextension", make([]byte, 5)), chk.Equals, "application/x-unmapped")
+}
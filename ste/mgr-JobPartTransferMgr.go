@@ -39,6 +39,10 @@ type IJobPartTransferMgr interface {
 	GetOverwriteOption() common.OverwriteOption
 	GetForceIfReadOnly() bool
 	ShouldDecompress() bool
+	CannotDecompress() error
+	// DownloadRangesInPlace says whether the ranges of a download are written straight to their place in a sparse file,
+	// as they arrive, rather than in order
+	DownloadRangesInPlace() bool
 	GetSourceCompressionType() (common.CompressionType, error)
 	ReportChunkDone(id common.ChunkID) (lastChunk bool, chunksDone uint32)
 	TransferStatusIgnoringCancellation() common.TransferStatus
@@ -49,6 +53,13 @@ type IJobPartTransferMgr interface {
 	ReportTransferDone() uint32
 	RescheduleTransfer()
 	ScheduleChunks(chunkFunc chunkFunc)
+	IsSmallFile() bool
+	// DestinationArchive returns the archive that the job streams its files into, and the name of this transfer's
+	// entry in it. The archive is nil when each file is written to a file of its own
+	DestinationArchive() (archive *destinationArchive, entryName string)
+	// SourceArchiveEntry returns the archive that the job reads its files from, and this transfer's entry in it.
+	// The archive is nil when each file is read from a file of its own
+	SourceArchiveEntry() (archive *common.ArchiveSource, entry *common.ArchiveSourceEntry, err error)
 	SetDestinationIsModified()
 	Cancel()
 	WasCanceled() bool
@@ -80,6 +91,7 @@ type IJobPartTransferMgr interface {
 	LogChunkStatus(id common.ChunkID, reason common.WaitReason)
 	ChunkStatusLogger() common.ChunkStatusLogger
 	LogAtLevelForCurrentTransfer(level pipeline.LogLevel, msg string)
+	ComponentLogger(component common.LogComponent) transferComponentLogger
 	GetOverwritePrompter() *overwritePrompter
 	GetFolderCreationTracker() FolderCreationTracker
 	common.ILogger
@@ -96,6 +108,17 @@ type IJobPartTransferMgr interface {
 	PropertiesToTransfer() common.SetPropertiesFlags
 	ResetSourceSize() // sets source size to 0 (made to be used by setProperties command to make number of bytes transferred = 0)
 	SuccessfulBytesTransferred() int64
+	ReportPOSIXPropertiesNotRestored()
+	RecordContentMD5(hash []byte)
+	SnapshotSourceFirst() bool
+	UseSourceSnapshot(snapshot string, deleteSnapshot func())
+	VerifyCrc64() bool
+	// DestinationConditions are the conditions, from --if-match and --if-none-match, that a destination blob must meet
+	// for the write that creates or replaces it. Empty if there are none
+	DestinationConditions() azblob.BlobAccessConditions
+	// ImmutabilityPolicy is the immutability policy and legal hold, from --immutability-policy-until and --legal-hold,
+	// that each destination blob is given when it's written. Empty if there are none
+	ImmutabilityPolicy() azblob.ImmutabilityPolicyOptions
 }
 
 type TransferInfo struct {
@@ -108,6 +131,7 @@ type TransferInfo struct {
 	PreserveSMBPermissions  common.PreservePermissionsOption
 	PreserveSMBInfo         bool
 	PreservePOSIXProperties bool
+	PreserveXattrs          bool
 
 	// Transfer info for S2S copy
 	SrcProperties
@@ -150,6 +174,8 @@ func (i TransferInfo) ShouldTransferLastWriteTime() bool {
 func (i TransferInfo) entityTypeLogIndicator() string {
 	if i.IsFolderPropertiesTransfer() {
 		return "(folder properties) "
+	} else if i.EntityType == common.EEntityType.Symlink() {
+		return "(symlink) "
 	} else {
 		return ""
 	}
@@ -176,6 +202,9 @@ type jobPartTransferMgr struct {
 	// NumberOfChunksDone determines the final cancellation or completion of a transfer
 	atomicChunksDone uint32
 
+	// how many times the requests made by this transfer have been retried
+	atomicRetryCount int32
+
 	// used defensively to protect against accidental double counting
 	atomicCompletionIndicator uint32
 
@@ -189,16 +218,37 @@ type jobPartTransferMgr struct {
 	jobPartPlanTransfer *JobPartPlanTransfer
 	transferIndex       uint32
 
+	// whether this transfer, and its chunks, are run by the job's small-file pool rather than its main pool
+	smallFile bool
+
 	// the context of this transfer; allows any failing chunk to cancel the whole transfer
 	ctx context.Context
 
 	// Call cancel to cancel the transfer
 	cancel context.CancelFunc
 
+	// fails the transfer if it makes no progress for the job's per-transfer timeout. Nil if there is no such timeout
+	watchdog *progressWatchdog
+
+	// the MD5 of the content, as a []byte, as computed while it was transferred. Reported, with the transfer, for the job's manifest
+	contentMD5 atomic.Value
+
+	// the first error message logged for this transfer, as a string. Reported, with the transfer, for the job's manifest
+	firstErrorMessage atomic.Value
+
+	// the kind of error this transfer failed with, as a common.FailureCategory, if failActiveTransfer failed it.
+	// Reported, with the transfer, for the job summary
+	failureCategory atomic.Value
+
 	numChunks uint32
 
 	transferInfo *TransferInfo
 
+	// the source as it was before UseSourceSnapshot pointed the transfer at a snapshot of it, and the func that deletes
+	// that snapshot once the transfer is done. Empty and nil unless a snapshot was used
+	sourceBeforeSnapshot string
+	deleteSourceSnapshot func()
+
 	actionAfterLastChunk func()
 
 	/*
@@ -234,9 +284,24 @@ func (jptm *jobPartTransferMgr) FromTo() common.FromTo {
 }
 
 func (jptm *jobPartTransferMgr) StartJobXfer() {
+	jptm.watchdog.Start()
 	jptm.jobPartMgr.StartJobXfer(jptm)
 }
 
+// failStalledTransfer is called by the watchdog when the transfer has gone for the whole per-transfer timeout without
+// making any progress. Failing it cancels its context, so that its outstanding chunks give up, and the rest of the job
+// can carry on. Since the transfer is marked as failed, it is retried if the job is resumed.
+func (jptm *jobPartTransferMgr) failStalledTransfer() {
+	err := fmt.Errorf("the transfer made no progress for %v, which is the per-transfer timeout", jptm.watchdog.timeout)
+	typ := transferErrorCodeDownloadFailed
+	if isUpload, isCopy := jptm.TempJudgeUploadOrCopy(); isUpload {
+		typ = transferErrorCodeUploadFailed
+	} else if isCopy {
+		typ = transferErrorCodeCopyFailed
+	}
+	jptm.failActiveTransfer(typ, "waiting for the transfer to make progress", err, common.ETransferStatus.Failed())
+}
+
 func (jptm *jobPartTransferMgr) GetOverwriteOption() common.OverwriteOption {
 	return jptm.jobPartMgr.GetOverwriteOption()
 }
@@ -245,14 +310,30 @@ func (jptm *jobPartTransferMgr) GetForceIfReadOnly() bool {
 	return jptm.jobPartMgr.GetForceIfReadOnly()
 }
 
+// ShouldDecompress says whether the file is to be decompressed as it is downloaded. Only files whose content-encoding is
+// one that can be decompressed are. Those with any other encoding are saved as they are
 func (jptm *jobPartTransferMgr) ShouldDecompress() bool {
 	if jptm.jobPartMgr.AutoDecompress() {
 		ct, _ := jptm.GetSourceCompressionType()
-		return ct != common.ECompressionType.None()
+		return ct == common.ECompressionType.GZip() || ct == common.ECompressionType.ZLib()
 	}
 	return false
 }
 
+// CannotDecompress returns why the file won't be decompressed, when decompression was asked for, but the file's
+// content-encoding is one that AzCopy can't decompress. Nil otherwise
+func (jptm *jobPartTransferMgr) CannotDecompress() error {
+	if !jptm.jobPartMgr.AutoDecompress() {
+		return nil
+	}
+	_, err := jptm.GetSourceCompressionType()
+	return err
+}
+
+func (jptm *jobPartTransferMgr) DownloadRangesInPlace() bool {
+	return jptm.jobPartMgr.DownloadRangesInPlace()
+}
+
 func (jptm *jobPartTransferMgr) GetSourceCompressionType() (common.CompressionType, error) {
 	encoding := jptm.Info().SrcHTTPHeaders.ContentEncoding
 	return common.GetCompressionType(encoding)
@@ -379,6 +460,7 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 		PreserveSMBPermissions:         plan.PreservePermissions,
 		PreserveSMBInfo:                plan.PreserveSMBInfo,
 		PreservePOSIXProperties:        plan.PreservePOSIXProperties,
+		PreserveXattrs:                 plan.PreserveXattrs,
 		S2SGetPropertiesInBackend:      s2sGetPropertiesInBackend,
 		S2SSourceChangeValidation:      s2sSourceChangeValidation,
 		S2SInvalidMetadataHandleOption: s2sInvalidMetadataHandleOption,
@@ -485,9 +567,50 @@ func (jptm *jobPartTransferMgr) RescheduleTransfer() {
 }
 
 func (jptm *jobPartTransferMgr) ScheduleChunks(chunkFunc chunkFunc) {
+	if jptm.smallFile {
+		jptm.jobPartMgr.(*jobPartMgr).jobMgr.ScheduleSmallFileChunk(chunkFunc)
+		return
+	}
 	jptm.jobPartMgr.ScheduleChunks(chunkFunc)
 }
 
+func (jptm *jobPartTransferMgr) IsSmallFile() bool {
+	return jptm.smallFile
+}
+
+func (jptm *jobPartTransferMgr) DestinationArchive() (*destinationArchive, string) {
+	plan := jptm.jobPartMgr.Plan()
+	if plan.DestinationArchive == common.EArchiveFormat.None() {
+		return nil, ""
+	}
+	_, relDest := plan.TransferSrcDstRelatives(jptm.transferIndex)
+	archive := jptm.jobPartMgr.(*jobPartMgr).jobMgr.DestinationArchive(jptm.GetDestinationRoot(), plan.DestinationArchive)
+	info := jptm.Info()
+	source := info.Source
+	if info.IsFolderPropertiesTransfer() {
+		source = "" // so that the root folder, which is the archive itself, gets no name
+	}
+	return archive, archiveEntryName(relDest, source)
+}
+
+func (jptm *jobPartTransferMgr) SourceArchiveEntry() (*common.ArchiveSource, *common.ArchiveSourceEntry, error) {
+	plan := jptm.jobPartMgr.Plan()
+	if plan.SourceArchive == common.EArchiveFormat.None() {
+		return nil, nil, nil
+	}
+	archivePath := string(plan.SourceRoot[:plan.SourceRootLength])
+	archive, err := jptm.jobPartMgr.(*jobPartMgr).jobMgr.SourceArchive(archivePath, plan.SourceArchive)
+	if err != nil {
+		return nil, nil, err
+	}
+	relSource, _ := plan.TransferSrcDstRelatives(jptm.transferIndex)
+	entry, ok := archive.Entry(relSource)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s has no entry named %s", archivePath, common.CleanArchiveEntryName(relSource))
+	}
+	return archive, entry, nil
+}
+
 func (jptm *jobPartTransferMgr) ResourceDstData(dataFileToXfer []byte) (headers common.ResourceHTTPHeaders, metadata common.Metadata, blobTags common.BlobTags, cpkOptions common.CpkOptions) {
 	return jptm.jobPartMgr.(*jobPartMgr).resourceDstData(jptm.Info().Source, dataFileToXfer)
 }
@@ -543,6 +666,20 @@ func (jptm *jobPartTransferMgr) IsSourceEncrypted() bool {
 	return jptm.jobPartMgr.IsSourceEncrypted()
 }
 
+func (jptm *jobPartTransferMgr) DestinationConditions() azblob.BlobAccessConditions {
+	return azblob.BlobAccessConditions{ModifiedAccessConditions: jptm.jobPartMgr.DestinationConditions()}
+}
+
+func (jptm *jobPartTransferMgr) ImmutabilityPolicy() azblob.ImmutabilityPolicyOptions {
+	return jptm.jobPartMgr.ImmutabilityPolicy()
+}
+
+// hasDestinationConditions says whether the destination's writes were made conditional with --if-match or --if-none-match
+func (jptm *jobPartTransferMgr) hasDestinationConditions() bool {
+	c := jptm.jobPartMgr.DestinationConditions()
+	return c.IfMatch != azblob.ETagNone || c.IfNoneMatch != azblob.ETagNone
+}
+
 func (jptm *jobPartTransferMgr) PropertiesToTransfer() common.SetPropertiesFlags {
 	return jptm.jobPartMgr.PropertiesToTransfer()
 }
@@ -551,6 +688,36 @@ func (jptm *jobPartTransferMgr) ResetSourceSize() {
 	jptm.transferInfo.SourceSize = 0
 }
 
+// SnapshotSourceFirst says whether each source blob should be copied from a snapshot of it, taken just before
+func (jptm *jobPartTransferMgr) SnapshotSourceFirst() bool {
+	return jptm.jobPartMgr.Plan().SnapshotSourceFirst
+}
+
+// VerifyCrc64 says whether uploads should check the CRC64 that the service returns against one computed of the data sent
+func (jptm *jobPartTransferMgr) VerifyCrc64() bool {
+	return jptm.jobPartMgr.Plan().VerifyCrc64
+}
+
+// UseSourceSnapshot makes the rest of the transfer read from the given snapshot of the source, and arranges for
+// deleteSnapshot to be called when the transfer is done, however it ends
+func (jptm *jobPartTransferMgr) UseSourceSnapshot(snapshot string, deleteSnapshot func()) {
+	jptm.Info() // makes sure transferInfo is set
+	jptm.sourceBeforeSnapshot = jptm.transferInfo.Source
+
+	sURL, err := url.Parse(jptm.transferInfo.Source)
+	if err != nil {
+		panic(err)
+	}
+	snapshotQuery := "snapshot=" + url.QueryEscape(snapshot)
+	if len(sURL.RawQuery) > 0 {
+		sURL.RawQuery += "&" + snapshotQuery
+	} else {
+		sURL.RawQuery = snapshotQuery
+	}
+	jptm.transferInfo.Source = sURL.String()
+	jptm.deleteSourceSnapshot = deleteSnapshot
+}
+
 // JobHasLowFileCount returns an estimate of whether we only have a very small number of files in the overall job
 // (An "estimate" because it actually only looks at the current job part)
 func (jptm *jobPartTransferMgr) JobHasLowFileCount() bool {
@@ -580,6 +747,7 @@ func (jptm *jobPartTransferMgr) ReportChunkDone(id common.ChunkID) (lastChunk bo
 	id.SetCompletionNotificationSent()
 
 	// track progress
+	jptm.watchdog.ReportProgress()
 	if jptm.IsLive() {
 		atomic.AddInt64(&jptm.atomicSuccessfulBytes, id.Length())
 		jptm.jobPartMgr.(*jobPartMgr).jobMgr.AddSuccessfulBytesInActiveFiles(id.Length())
@@ -785,14 +953,30 @@ func (jptm *jobPartTransferMgr) failActiveTransfer(typ transferErrorCode, descri
 
 		if serviceCode == common.CPK_ERROR_SERVICE_CODE {
 			cpkAccessFailureLogGLCM.Do(func() {
-				common.GetLifecycleMgr().Info("One or more transfers have failed because AzCopy currently does not support blobs encrypted with customer provided keys (CPK). " +
-					"If you wish to access CPK-encrypted blobs, we recommend using one of the Azure Storage SDKs to do so.")
+				common.GetLifecycleMgr().Info("One or more transfers have failed because their source blobs are encrypted with a customer provided key that wasn't given. " +
+					common.CPK_ERROR_HINT)
 			})
 		}
 
+		// when the archived blob is the source of a copy from URL, its code comes in the x-ms-copy-source-error-code header
+		archived := string(azblob.ServiceCodeBlobArchived)
+		if copySourceCode := (ErrorEx{err}).CopySourceErrorCode(); serviceCode == archived || copySourceCode == archived {
+			archivedSourceFailureLogGLCM.Do(func() {
+				common.GetLifecycleMgr().Info("One or more transfers have failed because their source blobs are in the Archive tier. " +
+					"Archived blobs can't be read until they are rehydrated to the Hot or Cool tier, which can take hours. " +
+					"To rehydrate them, use azcopy set-properties with --block-blob-tier, then copy them once that has finished.")
+			})
+		}
+
+		// a write that the destination turned down on its --if-match or --if-none-match condition is told apart from other failures
+		if failureStatus == common.ETransferStatus.Failed() && jptm.hasDestinationConditions() && isDestinationConditionNotMet(err) {
+			failureStatus = common.ETransferStatus.PreconditionFailed()
+		}
+
 		requestID := ErrorEx{err}.MSRequestID()
 		fullMsg := fmt.Sprintf("%s. When %s. X-Ms-Request-Id: %s\n", msg, descriptionOfWhereErrorOccurred, requestID) // trailing \n to separate it better from any later, unrelated, log lines
 		jptm.logTransferError(typ, jptm.Info().Source, jptm.Info().Destination, fullMsg, status)
+		jptm.failureCategory.Store(failureCategoryOf(err, failureStatus))
 		jptm.SetStatus(failureStatus)
 		jptm.SetErrorCode(int32(status)) // TODO: what are the rules about when this needs to be set, and doesn't need to be (e.g. for earlier failures)?
 		// If the status code was 403, it means there was an authentication error and we exit.
@@ -825,8 +1009,35 @@ func (jptm *jobPartTransferMgr) PipelineLogInfo() pipeline.LogOptions {
 }
 
 func (jptm *jobPartTransferMgr) Log(level pipeline.LogLevel, msg string) {
+	jptm.logTo(jptm.jobPartMgr, level, msg)
+}
+
+func (jptm *jobPartTransferMgr) logTo(logger common.ILogger, level pipeline.LogLevel, msg string) {
 	plan := jptm.jobPartMgr.Plan()
-	jptm.jobPartMgr.Log(level, fmt.Sprintf("%s: [P#%d-T#%d] ", common.LogLevel(level), plan.PartNum, jptm.transferIndex)+msg)
+	logger.Log(level, fmt.Sprintf("%s: [P#%d-T#%d] ", common.LogLevel(level), plan.PartNum, jptm.transferIndex)+msg)
+}
+
+// ComponentLogger returns a logger for the messages of this transfer that are about one component of the job. It
+// logs them at that component's level, in the same form as Log and LogAtLevelForCurrentTransfer
+func (jptm *jobPartTransferMgr) ComponentLogger(component common.LogComponent) transferComponentLogger {
+	return transferComponentLogger{jptm: jptm, logger: jptm.jobPartMgr.ComponentLogger(component)}
+}
+
+type transferComponentLogger struct {
+	jptm   *jobPartTransferMgr
+	logger common.ILogger
+}
+
+func (l transferComponentLogger) ShouldLog(level pipeline.LogLevel) bool {
+	return l.logger.ShouldLog(level)
+}
+
+func (l transferComponentLogger) Log(level pipeline.LogLevel, msg string) {
+	l.jptm.logTo(l.logger, level, msg)
+}
+
+func (l transferComponentLogger) LogAtLevelForCurrentTransfer(level pipeline.LogLevel, msg string) {
+	l.Log(level, l.jptm.currentTransferMessage(msg))
 }
 
 func (jptm *jobPartTransferMgr) ErrorCodeAndString(err error) (int, string) {
@@ -851,13 +1062,15 @@ const (
 )
 
 func (jptm *jobPartTransferMgr) LogAtLevelForCurrentTransfer(level pipeline.LogLevel, msg string) {
+	jptm.Log(level, jptm.currentTransferMessage(msg))
+}
+
+func (jptm *jobPartTransferMgr) currentTransferMessage(msg string) string {
 	// order of log elements here is mirrored, with some more added, in logTransferError
 	info := jptm.Info()
-	fullMsg := common.URLStringExtension(info.Source).RedactSecretQueryParamForLogging() + " " + info.entityTypeLogIndicator() +
+	return common.URLStringExtension(info.Source).RedactSecretQueryParamForLogging() + " " + info.entityTypeLogIndicator() +
 		msg +
 		" Dst: " + common.URLStringExtension(info.Destination).RedactSecretQueryParamForLogging()
-
-	jptm.Log(level, fullMsg)
 }
 
 func (jptm *jobPartTransferMgr) logTransferError(errorCode transferErrorCode, source, destination, errorMsg string, status int) {
@@ -866,6 +1079,7 @@ func (jptm *jobPartTransferMgr) logTransferError(errorCode transferErrorCode, so
 	msg := fmt.Sprintf("%v: %v", errorCode, info.entityTypeLogIndicator()) + common.URLStringExtension(source).RedactSecretQueryParamForLogging() +
 		fmt.Sprintf(" : %03d : %s\n   Dst: ", status, errorMsg) + common.URLStringExtension(destination).RedactSecretQueryParamForLogging()
 	jptm.Log(pipeline.LogError, msg)
+	jptm.firstErrorMessage.CompareAndSwap(nil, strings.TrimSpace(errorMsg))
 }
 
 func (jptm *jobPartTransferMgr) LogUploadError(source, destination, errorMsg string, status int) {
@@ -923,6 +1137,7 @@ func (jptm *jobPartTransferMgr) Panic(err error) { jptm.jobPartMgr.Panic(err) }
 func (jptm *jobPartTransferMgr) ReportTransferDone() uint32 {
 	// In case of context leak in job part transfer manager.
 	jptm.Cancel()
+	jptm.watchdog.Stop()
 
 	// defensive programming check, to make sure this method is not called twice for the same transfer
 	// (since if it was, job would count us as TWO completions, and maybe miss another transfer that
@@ -935,6 +1150,17 @@ func (jptm *jobPartTransferMgr) ReportTransferDone() uint32 {
 		panic("cannot report the same transfer done twice")
 	}
 
+	if retries := atomic.LoadInt32(&jptm.atomicRetryCount); retries > 0 && jptm.ShouldLog(pipeline.LogInfo) {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Requests for this transfer were retried %d times.", retries))
+	}
+
+	// the snapshot of the source, if one was taken, must go whether or not the transfer worked. The transfer is then
+	// reported against the source it was asked for, not the snapshot
+	if jptm.deleteSourceSnapshot != nil {
+		jptm.deleteSourceSnapshot()
+		jptm.transferInfo.Source = jptm.sourceBeforeSnapshot
+	}
+
 	// Update Status Manager
 	jptm.jobPartMgr.SendXferDoneMsg(xferDoneMsg{Src: jptm.Info().Source,
 		Dst:                jptm.Info().Destination,
@@ -942,6 +1168,9 @@ func (jptm *jobPartTransferMgr) ReportTransferDone() uint32 {
 		TransferStatus:     jptm.jobPartPlanTransfer.TransferStatus(),
 		TransferSize:       uint64(jptm.Info().SourceSize),
 		ErrorCode:          jptm.ErrorCode(),
+		ErrorMessage:       jptm.errorMessage(),
+		FailureCategory:    jptm.failureCategoryOrGuess(),
+		ContentMD5:         jptm.knownContentMD5(),
 	})
 
 	return jptm.jobPartMgr.ReportTransferDone(jptm.jobPartPlanTransfer.TransferStatus())
@@ -977,4 +1206,41 @@ func (jptm *jobPartTransferMgr) ShouldInferContentType() bool {
 
 func (jptm *jobPartTransferMgr) SuccessfulBytesTransferred() int64 {
 	return atomic.LoadInt64(&jptm.atomicSuccessfulBytes)
-}
\ No newline at end of file
+}
+
+// RecordContentMD5 records the MD5 of the content, as computed during the transfer, so that it can be reported
+// when the transfer is done
+func (jptm *jobPartTransferMgr) RecordContentMD5(hash []byte) {
+	jptm.contentMD5.Store(hash)
+}
+
+// knownContentMD5 is the MD5 computed during the transfer, if there was one. Else it is the source's MD5, if it has one
+func (jptm *jobPartTransferMgr) knownContentMD5() []byte {
+	if hash, _ := jptm.contentMD5.Load().([]byte); len(hash) > 0 {
+		return hash
+	}
+	return jptm.Info().SrcHTTPHeaders.ContentMD5
+}
+
+func (jptm *jobPartTransferMgr) errorMessage() string {
+	msg, _ := jptm.firstErrorMessage.Load().(string)
+	return msg
+}
+
+// failureCategoryOrGuess gives the kind of error this transfer failed with. The transfers that fail other than through
+// failActiveTransfer only have their status code to go by. Meaningless unless the transfer failed
+func (jptm *jobPartTransferMgr) failureCategoryOrGuess() common.FailureCategory {
+	if category, ok := jptm.failureCategory.Load().(common.FailureCategory); ok {
+		return category
+	}
+	if jptm.TransferStatusIgnoringCancellation() == common.ETransferStatus.PreconditionFailed() {
+		return common.EFailureCategory.Precondition()
+	}
+	return common.FailureCategoryForStatusCode(int(jptm.ErrorCode()))
+}
+
+// ReportPOSIXPropertiesNotRestored records, for the job summary, that this transfer's POSIX properties could not be set
+// at the destination. It does not affect the status of the transfer
+func (jptm *jobPartTransferMgr) ReportPOSIXPropertiesNotRestored() {
+	jptm.jobPartMgr.(*jobPartMgr).jobMgr.ReportPOSIXPropertiesNotRestored()
+}
@@ -36,33 +36,39 @@ type pacedReadSeeker struct {
 
 	body io.Reader // Seeking is required to support retries
 	p    pacer
+
+	// told about every successful read, if the transfer has a per-transfer timeout. Else nil
+	watchdog *progressWatchdog
 }
 
 func newPacedRequestBody(ctx context.Context, requestBody io.ReadSeeker, p pacer) io.ReadSeeker {
 	if p == nil {
 		panic("p must not be nil")
 	}
-	return &pacedReadSeeker{ctx: ctx, body: requestBody, p: p}
+	return &pacedReadSeeker{ctx: ctx, body: requestBody, p: p, watchdog: progressWatchdogFrom(ctx)}
 }
 
 func newPacedResponseBody(ctx context.Context, responseBody io.ReadCloser, p pacer) io.ReadCloser {
 	if p == nil {
 		panic("p must not be nil")
 	}
-	return &pacedReadSeeker{ctx: ctx, body: responseBody, p: p}
+	return &pacedReadSeeker{ctx: ctx, body: responseBody, p: p, watchdog: progressWatchdogFrom(ctx)}
 }
 
 func (prs *pacedReadSeeker) Read(p []byte) (int, error) {
 	requestedCount := len(p)
 
 	// blocks until we are allowed to process the bytes
-	err := prs.p.RequestTrafficAllocation(prs.ctx, int64(requestedCount))
+	err := waitForPacer(prs.ctx, prs.p, int64(requestedCount))
 	if err != nil {
 		return 0, err
 	}
 
 	// process them
 	n, err := prs.body.Read(p)
+	if n > 0 {
+		prs.watchdog.ReportProgress()
+	}
 
 	// "return" any unused tokens to the pacer (e.g. if we hit eof before the end of our buffer p)
 	excess := requestedCount - n
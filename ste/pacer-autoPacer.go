@@ -36,12 +36,25 @@ type autopacer interface {
 	retryNotificationReceiver
 }
 
+// ThroughputAutoPacer paces all the traffic of a job, at a rate that it chooses for itself from the 503s it is told about.
+// It is used, instead of a fixed cap, when the user asks for the throughput to be auto-tuned.
+type ThroughputAutoPacer interface {
+	PacerAdmin
+	retryNotificationReceiver
+
+	// SteadyStateBytesPerSecond returns the rate around which the pacer has settled. Recent rates count the most.
+	SteadyStateBytesPerSecond() int64
+}
+
 // autoTokenBucketPacer is a pacer which automatically seeks the right rate, based on retry (503)
 // statuses received from the target service.
 type autoTokenBucketPacer struct {
+	atomicSteadyStateBytesPerSecond int64 // first, to align 64 bit integers for 32 bit arch
 	*tokenBucketPacer
 	lastPeakBytesPerSecond  float32
 	lastPeakTime            time.Time
+	maxBytesPerSecond       float32
+	inSlowStart             bool // true until the first decrease, for pacers that start from a guess rather than a known rate
 	done                    chan struct{}
 	atomicRetriesInInterval int32
 	logger                  common.ILogger
@@ -65,7 +78,16 @@ const (
 	stableZoneStart    = 0.95
 	stableZoneEnd      = 1.05
 
+	// Until the first 503, a pacer that started from a guess has no peak to seek, so it ramps up much faster.
+	// Like TCP's slow start, which it is named after, it is only "slow" in that it starts low.
+	slowStartFactor = 0.25
+
+	// How much each interval's rate counts towards the steady state rate. The rate cycles between decreases,
+	// so this is small enough to average over a few of those cycles.
+	steadyStateWeight = 0.05
+
 	pageBlobThroughputTunerString = "Page blob throughput tuner"
+	jobThroughputTunerString      = "Throughput tuner"
 
 	maxPacerGbps           = 100
 	maxPacerBytesPerSecond = maxPacerGbps * 1000 * 1000 * 1000 / 8
@@ -101,12 +123,34 @@ func newAutoPacer(bytesPerSecond int64, expectedBytesPerRequest int64, isFair bo
 		panic("Fair pacing requires additive increase (AIMD), which is not yet supported by this pacer")
 	}
 
-	a := &autoTokenBucketPacer{
-		tokenBucketPacer:       NewTokenBucketPacer(bytesPerSecond, expectedBytesPerRequest),
-		lastPeakBytesPerSecond: float32(bytesPerSecond),
-		done:                   make(chan struct{}),
-		logger:                 logger,
-		logPrefix:              logPrefix,
+	a := newAutoTokenBucketPacer(bytesPerSecond, expectedBytesPerRequest, logger, logPrefix)
+
+	go a.rateTunerBody()
+
+	return a
+}
+
+// newAutoTokenBucketPacer makes the pacer, but doesn't start tuning it
+func newAutoTokenBucketPacer(bytesPerSecond int64, expectedBytesPerRequest int64, logger common.ILogger, logPrefix string) *autoTokenBucketPacer {
+	return &autoTokenBucketPacer{
+		tokenBucketPacer:                NewTokenBucketPacer(bytesPerSecond, expectedBytesPerRequest),
+		lastPeakBytesPerSecond:          float32(bytesPerSecond),
+		maxBytesPerSecond:               maxPacerBytesPerSecond,
+		done:                            make(chan struct{}),
+		atomicSteadyStateBytesPerSecond: bytesPerSecond,
+		logger:                          logger,
+		logPrefix:                       logPrefix,
+	}
+}
+
+// NewThroughputAutoPacer returns a pacer for all the traffic of a job, which starts at the given (conservative) rate and
+// ramps up until the service starts to respond with 503s. After that, it tunes itself in the same way as the page blob pacer.
+// A maxBytesPerSecond of 0 means that the rate is not capped.
+func NewThroughputAutoPacer(initialBytesPerSecond int64, maxBytesPerSecond int64, logger common.ILogger) ThroughputAutoPacer {
+	a := newAutoTokenBucketPacer(initialBytesPerSecond, 0, logger, jobThroughputTunerString)
+	a.inSlowStart = true
+	if maxBytesPerSecond > 0 && maxBytesPerSecond < maxPacerBytesPerSecond {
+		a.maxBytesPerSecond = float32(maxBytesPerSecond)
 	}
 
 	go a.rateTunerBody()
@@ -125,6 +169,10 @@ func (a *autoTokenBucketPacer) RetryCallback() {
 	atomic.AddInt32(&a.atomicRetriesInInterval, 1)
 }
 
+func (a *autoTokenBucketPacer) SteadyStateBytesPerSecond() int64 {
+	return atomic.LoadInt64(&a.atomicSteadyStateBytesPerSecond)
+}
+
 func (a *autoTokenBucketPacer) rateTunerBody() {
 	for {
 		select {
@@ -134,24 +182,34 @@ func (a *autoTokenBucketPacer) rateTunerBody() {
 			// continue looping
 		}
 
-		retriesInCompletedInterval := atomic.SwapInt32(&a.atomicRetriesInInterval, 0)
-		if retriesInCompletedInterval > 0 {
-			a.decreaseRate()
-			a.logRate()
-		} else {
-			a.increaseRate()
-			a.logRate()
-		}
+		a.tune(time.Now())
 	}
 }
 
-func (a *autoTokenBucketPacer) decreaseRate() {
-	if time.Since(a.lastPeakTime) < deadBandDuration {
+// tune adjusts the rate once, at the end of each tuning interval. It takes the time as a parameter, so that tests
+// can simulate many intervals without waiting for them
+func (a *autoTokenBucketPacer) tune(now time.Time) {
+	retriesInCompletedInterval := atomic.SwapInt32(&a.atomicRetriesInInterval, 0)
+	if retriesInCompletedInterval > 0 {
+		a.decreaseRate(now)
+	} else {
+		a.increaseRate()
+	}
+	a.logRate()
+
+	common.AtomicMorphInt64(&a.atomicSteadyStateBytesPerSecond, func(steadyState int64) (int64, interface{}) {
+		return steadyState + int64(steadyStateWeight*float32(a.targetBytesPerSecond()-steadyState)), nil
+	})
+}
+
+func (a *autoTokenBucketPacer) decreaseRate(now time.Time) {
+	if now.Sub(a.lastPeakTime) < deadBandDuration {
 		return // don't do another decrease so soon, since doing so would cause us to overreact
 	}
 	existingRate := float32(a.targetBytesPerSecond())
 	a.lastPeakBytesPerSecond = existingRate
-	a.lastPeakTime = time.Now()
+	a.lastPeakTime = now
+	a.inSlowStart = false
 	newRate := existingRate * decreaseFactor
 	a.tokenBucketPacer.setTargetBytesPerSecond(int64(newRate))
 }
@@ -160,6 +218,9 @@ func (a *autoTokenBucketPacer) increaseRate() {
 	existingRate := float32(a.targetBytesPerSecond())
 	var newRate float32
 	switch {
+	case a.inSlowStart:
+		// we don't know where the limit is yet, so find it quickly
+		newRate = existingRate * (1 + slowStartFactor)
 	case existingRate < stableZoneStart*a.lastPeakBytesPerSecond:
 		// fast increase when below previous peak, to get us back there (if possible) quickly, with minimal loss of throughput
 		newRate = existingRate + fastRecoveryFactor*(a.lastPeakBytesPerSecond-existingRate)
@@ -176,8 +237,10 @@ func (a *autoTokenBucketPacer) increaseRate() {
 	// we just keep increasing our rate for ever. And if that other constraint is temporary and goes away,
 	// then suddenly well be at a crazy high rate that takes too long to step back down to reality (and or get
 	// integer overflow issues).
-	if newRate < maxPacerBytesPerSecond {
+	if newRate < a.maxBytesPerSecond {
 		a.tokenBucketPacer.setTargetBytesPerSecond(int64(newRate))
+	} else if existingRate < a.maxBytesPerSecond {
+		a.tokenBucketPacer.setTargetBytesPerSecond(int64(a.maxBytesPerSecond)) // so that a cap given by the user is reached exactly
 	}
 }
 
@@ -0,0 +1,150 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressWatchdog calls onStall once no progress has been reported for the whole of its timeout.
+// It is not a deadline: each report of progress gives the watched work another full timeout
+// in which to make more. Time spent in a wait that the watched work has said is deliberate,
+// such as for the pacer, does not count either.
+type progressWatchdog struct {
+	timeout time.Duration
+	onStall func()
+
+	start              time.Time
+	atomicLastProgress int64 // time since start at which progress was last reported, in nanoseconds
+	atomicWaiting      int32 // how many deliberate waits are in progress
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+// newProgressWatchdog makes a watchdog that does not watch anything until Start is called
+func newProgressWatchdog(timeout time.Duration, onStall func()) *progressWatchdog {
+	return &progressWatchdog{timeout: timeout, onStall: onStall, start: time.Now()}
+}
+
+// Start begins the watch, with a full timeout in which the first progress must be made
+func (w *progressWatchdog) Start() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped || w.timer != nil {
+		return
+	}
+	w.ReportProgress()
+	w.timer = time.AfterFunc(w.timeout, w.check)
+}
+
+// ReportProgress restarts the timeout. It is cheap enough to call for every read, and it is safe to call on a nil watchdog
+func (w *progressWatchdog) ReportProgress() {
+	if w == nil {
+		return
+	}
+	atomic.StoreInt64(&w.atomicLastProgress, int64(time.Since(w.start)))
+}
+
+// beginWait says that the watched work is about to wait for something that isn't a sign of it being stuck. Until the
+// matching endWait, the watchdog does not fire. Safe to call on a nil watchdog
+func (w *progressWatchdog) beginWait() {
+	if w == nil {
+		return
+	}
+	atomic.AddInt32(&w.atomicWaiting, 1)
+}
+
+// endWait ends a wait started by beginWait, and gives the work a full timeout from then in which to make progress
+func (w *progressWatchdog) endWait() {
+	if w == nil {
+		return
+	}
+	w.ReportProgress()
+	atomic.AddInt32(&w.atomicWaiting, -1)
+}
+
+// Stop ends the watch, after which onStall will not be called
+func (w *progressWatchdog) Stop() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// check runs when the timer fires. Rather than resetting the timer on every report of progress, we let it fire
+// and then, if there has been progress in the meantime, re-arm it for the rest of the timeout since that progress
+func (w *progressWatchdog) check() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	idle := time.Since(w.start) - time.Duration(atomic.LoadInt64(&w.atomicLastProgress))
+	if atomic.LoadInt32(&w.atomicWaiting) > 0 {
+		idle = 0 // still waiting, so look again a full timeout from now
+	}
+	if idle < w.timeout {
+		w.timer.Reset(w.timeout - idle)
+		w.mu.Unlock()
+		return
+	}
+	w.stopped = true
+	w.mu.Unlock()
+
+	w.onStall()
+}
+
+var progressWatchdogContextKey = contextKey{"progressWatchdog"}
+
+// withProgressWatchdog returns a context that carries the watchdog, so that the readers of request and response bodies,
+// made with that context (or one derived from it), can tell it about each read
+func withProgressWatchdog(ctx context.Context, w *progressWatchdog) context.Context {
+	return context.WithValue(ctx, progressWatchdogContextKey, w)
+}
+
+// progressWatchdogFrom returns the context's watchdog, or nil if it has none
+func progressWatchdogFrom(ctx context.Context) *progressWatchdog {
+	w, _ := ctx.Value(progressWatchdogContextKey).(*progressWatchdog)
+	return w
+}
+
+// waitForPacer blocks until p allows byteCount bytes to be processed. The time spent waiting isn't held against the
+// per-transfer timeout of the transfer that ctx belongs to, since being held back by the pacer (e.g. by cap-mbps)
+// doesn't mean the transfer is stuck
+func waitForPacer(ctx context.Context, p pacer, byteCount int64) error {
+	w := progressWatchdogFrom(ctx)
+	w.beginWait()
+	defer w.endWait()
+	return p.RequestTrafficAllocation(ctx, byteCount)
+}
@@ -2,6 +2,7 @@ package ste
 
 import (
 	"context"
+	"fmt"
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"net/url"
 	"sync"
@@ -19,9 +20,6 @@ type securityInfoPersistenceManager struct {
 	ctx    context.Context
 }
 
-// Files supports SDDLs up to and equal to 8kb. Because this isn't KiB, We're going to infer that it's 8x1000, not 8x1024.
-var filesServiceMaxSDDLSize = 8000
-
 func newSecurityInfoPersistenceManager(ctx context.Context) *securityInfoPersistenceManager {
 	return &securityInfoPersistenceManager{
 		sipmMu: &sync.RWMutex{},
@@ -55,6 +53,10 @@ func (sipm *securityInfoPersistenceManager) PutSDDL(sddlString string, shareURL
 	cResp, err := shareURL.CreatePermission(sipm.ctx, sddlString)
 
 	if err != nil {
+		if isPermissionStoreFull(err) {
+			return "", fmt.Errorf("the share %s cannot store any more distinct permissions (SDDLs). "+
+				"Reduce the number of distinct permissions at the source, or copy to a different share: %w", fileURLParts.ShareName, err)
+		}
 		return "", err
 	}
 
@@ -105,3 +107,14 @@ func (sipm *securityInfoPersistenceManager) GetSDDLFromID(id string, shareURL ur
 
 	return si.Permission, nil
 }
+
+// serviceCodeFilePermissionLimitExceeded is what CreatePermission fails with when the share's permission store has no
+// room for another descriptor. The SDK has no constant for it
+const serviceCodeFilePermissionLimitExceeded azfile.ServiceCodeType = "FilePermissionLimitExceeded"
+
+// isPermissionStoreFull reports whether CreatePermission failed because the share's permission store is full. Other
+// limits, such as that on the size of one descriptor, have codes of their own, and are reported as they are
+func isPermissionStoreFull(err error) bool {
+	stgErr, ok := err.(azfile.StorageError)
+	return ok && stgErr.ServiceCode() == serviceCodeFilePermissionLimitExceeded
+}
@@ -47,6 +47,9 @@ type appendBlobSenderBase struct {
 	blobTagsToApply azblob.BlobTagsMap
 	cpkToApply      azblob.ClientProvidedKeyOptions
 
+	// setTagsSeparately is true when the tags are set by Set Blob Tags once the blob is created, rather than by the create itself
+	setTagsSeparately bool
+
 	soleChunkFuncSemaphore *semaphore.Weighted
 }
 
@@ -91,6 +94,7 @@ func newAppendBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pip
 		headersToApply:         props.SrcHTTPHeaders.ToAzBlobHTTPHeaders(),
 		metadataToApply:        props.SrcMetadata.ToAzBlobMetadata(),
 		blobTagsToApply:        props.SrcBlobTags.ToAzBlobTagsMap(),
+		setTagsSeparately:      tagsPreservedFromOtherAccount(jptm, destURL, props.SrcBlobTags),
 		cpkToApply:             cpkToApply,
 		soleChunkFuncSemaphore: semaphore.NewWeighted(1)}, nil
 }
@@ -148,11 +152,11 @@ func (s *appendBlobSenderBase) Prologue(ps common.PrologueState) (destinationMod
 	}
 
 	blobTags := s.blobTagsToApply
-	separateSetTagsRequired := separateSetTagsRequired(blobTags)
+	separateSetTagsRequired := s.setTagsSeparately || separateSetTagsRequired(blobTags)
 	if separateSetTagsRequired || len(blobTags) == 0 {
 		blobTags = nil
 	}
-	if _, err := s.destAppendBlobURL.Create(s.jptm.Context(), s.headersToApply, s.metadataToApply, azblob.BlobAccessConditions{}, blobTags, s.cpkToApply, azblob.ImmutabilityPolicyOptions{}); err != nil {
+	if _, err := s.destAppendBlobURL.Create(s.jptm.Context(), s.headersToApply, s.metadataToApply, s.jptm.DestinationConditions(), blobTags, s.cpkToApply, azblob.ImmutabilityPolicyOptions{}); err != nil {
 		s.jptm.FailActiveSend("Creating blob", err)
 		return
 	}
@@ -160,14 +164,19 @@ func (s *appendBlobSenderBase) Prologue(ps common.PrologueState) (destinationMod
 
 	if separateSetTagsRequired {
 		if _, err := s.destAppendBlobURL.SetTags(s.jptm.Context(), nil, nil, nil, s.blobTagsToApply); err != nil {
-			s.jptm.Log(pipeline.LogWarning, err.Error())
+			s.jptm.Log(pipeline.LogWarning, "Could not set the blob index tags of the destination: "+err.Error())
 		}
 	}
 	return
 }
 
 func (s *appendBlobSenderBase) Epilogue() {
-	// Empty function because you don't have to commit on an append blob
+	// there is no commit on an append blob, so all that's left is to protect it, if asked to
+	if s.jptm.IsLive() {
+		if err := applyImmutabilityPolicy(s.jptm, s.destAppendBlobURL.BlobURL, s.cpkToApply); err != nil {
+			s.jptm.FailActiveSend("Setting immutability policy", err)
+		}
+	}
 }
 
 func (s *appendBlobSenderBase) Cleanup() {
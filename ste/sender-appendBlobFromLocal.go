@@ -48,6 +48,10 @@ func (u *appendBlobUploader) Prologue(ps common.PrologueState) (destinationModif
 		}
 	}
 
+	if u.jptm.Info().PreserveXattrs {
+		u.metadataToApply = addXattrsToMetadata(u.jptm, u.sip, u.metadataToApply)
+	}
+
 	return u.appendBlobSenderBase.Prologue(ps)
 }
 
@@ -67,8 +71,9 @@ func (u *appendBlobUploader) Md5Channel() chan<- []byte {
 func (u *appendBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int32, reader common.SingleChunkReader, chunkIsWholeFile bool) chunkFunc {
 	appendBlockFromLocal := func() {
 		u.jptm.LogChunkStatus(id, common.EWaitReason.Body())
+		sentCrc64 := chunkCrc64(u.jptm, reader)
 		body := newPacedRequestBody(u.jptm.Context(), reader, u.pacer)
-		_, err := u.destAppendBlobURL.AppendBlock(u.jptm.Context(), body,
+		resp, err := u.destAppendBlobURL.AppendBlock(u.jptm.Context(), body,
 			azblob.AppendBlobAccessConditions{
 				AppendPositionAccessConditions: azblob.AppendPositionAccessConditions{IfAppendPositionEqual: id.OffsetInFile()},
 			}, nil, u.cpkToApply)
@@ -76,6 +81,10 @@ func (u *appendBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex in
 			u.jptm.FailActiveUpload("Appending block", err)
 			return
 		}
+		if err = verifyCrc64(sentCrc64, resp.XMsContentCrc64()); err != nil {
+			u.jptm.FailActiveUpload("Verifying appended block CRC64", err)
+			return
+		}
 	}
 
 	return u.generateAppendBlockToRemoteFunc(id, appendBlockFromLocal)
@@ -285,7 +285,10 @@ func (u *azureFileSenderBase) addPermissionsToHeaders(info TransferInfo, destUrl
 		}
 	}
 
-	if u.headersToApply.PermissionString != nil && len(*u.headersToApply.PermissionString) > filesServiceMaxSDDLSize {
+	// Register the SDDL with the destination share, rather than sending it inline with every file.
+	// The persistence manager caches the resulting key per share, so a descriptor shared by many files
+	// is only created once, and SDDLs over the 8kb header limit can still be applied.
+	if u.headersToApply.PermissionString != nil && *u.headersToApply.PermissionString != "" {
 		fURLParts := azfile.NewFileURLParts(destUrl)
 		fURLParts.DirectoryOrFilePath = ""
 		shareURL := azfile.NewShareURL(fURLParts.URL(), u.pipeline)
@@ -297,8 +300,8 @@ func (u *azureFileSenderBase) addPermissionsToHeaders(info TransferInfo, destUrl
 			return "Putting permissions", err
 		}
 
-		ePermString := ""
-		u.headersToApply.PermissionString = &ePermString
+		// The key replaces the string. The SDK refuses both, even if the string is empty
+		u.headersToApply.PermissionString = nil
 	}
 	return "", nil
 }
@@ -371,11 +374,54 @@ func (u *azureFileSenderBase) GetDestinationLength() (int64, error) {
 	return prop.ContentLength(), nil
 }
 
+// EnsureFolderExists creates the folder, if it's not there already. When this job is the one to create it,
+// the folder's metadata and SMB properties go in the create call, rather than being set afterwards.
 func (u *azureFileSenderBase) EnsureFolderExists() error {
-	return AzureFileParentDirCreator{}.CreateDirToRoot(u.ctx, u.dirURL(), u.pipeline, u.jptm.GetFolderCreationTracker())
+	creator := AzureFileParentDirCreator{}
+	t := u.jptm.GetFolderCreationTracker()
+
+	isShareRoot := azfile.NewFileURLParts(u.dirURL().URL()).DirectoryOrFilePath == ""
+	_, err := u.dirURL().GetProperties(u.ctx)
+	if err == nil {
+		// It exists already, so its properties will be set by SetFolderProperties, as usual
+		return nil
+	} else if stgErr, ok := err.(azfile.StorageError); isShareRoot || !ok || stgErr.Response() == nil || stgErr.Response().StatusCode != http.StatusNotFound {
+		// We can't tell if it exists (e.g. we lack read permission), so leave it to the general purpose creator
+		return creator.CreateDirToRoot(u.ctx, u.dirURL(), u.pipeline, t)
+	}
+
+	err = creator.CreateDirToRoot(u.ctx, creator.getParentDirectoryURL(u.dirURL(), u.pipeline), u.pipeline, t)
+	if err != nil {
+		return err
+	}
+
+	err = u.addFolderPropertiesToHeaders()
+	if err != nil {
+		return err
+	}
+
+	_, err = u.dirURL().Create(u.ctx, u.metadataToApply, u.headersToApply.SMBProperties)
+	if stgErr, ok := err.(azfile.StorageError); ok && stgErr.ServiceCode() == azfile.ServiceCodeResourceAlreadyExists {
+		// A file transfer created it in the meantime, so set the properties in the usual way
+		return nil
+	} else if err != nil {
+		return err
+	}
+	t.RecordCreation(u.DirUrlToString())
+
+	// As for files, permissions given at creation can be adjusted by the service, so they must be set again
+	// with update semantics. The same call re-sends the SMB info, since the API takes them together.
+	if u.jptm.Info().PreserveSMBPermissions.IsTruthy() {
+		_, err = u.dirURL().SetProperties(u.ctx, u.headersToApply.SMBProperties)
+		if err != nil {
+			return err
+		}
+	}
+
+	return folderPropertiesSetInCreation{}
 }
 
-func (u *azureFileSenderBase) SetFolderProperties() error {
+func (u *azureFileSenderBase) addFolderPropertiesToHeaders() error {
 	info := u.jptm.Info()
 
 	_, err := u.addPermissionsToHeaders(info, u.dirURL().URL())
@@ -384,6 +430,11 @@ func (u *azureFileSenderBase) SetFolderProperties() error {
 	}
 
 	_, err = u.addSMBPropertiesToHeaders(info, u.dirURL().URL())
+	return err
+}
+
+func (u *azureFileSenderBase) SetFolderProperties() error {
+	err := u.addFolderPropertiesToHeaders()
 	if err != nil {
 		return err
 	}
@@ -61,7 +61,7 @@ func (u *urlToAzureFileCopier) GenerateCopyFunc(id common.ChunkID, blockIndex in
 		// upload the range (including application of global pacing. We don't have a separate wait reason for global pacing
 		// so just do it inside the S2SCopyOnWire state)
 		u.jptm.LogChunkStatus(id, common.EWaitReason.S2SCopyOnWire())
-		if err := u.pacer.RequestTrafficAllocation(u.jptm.Context(), adjustedChunkSize); err != nil {
+		if err := waitForPacer(u.jptm.Context(), u.pacer, adjustedChunkSize); err != nil {
 			u.jptm.FailActiveUpload("Pacing block (global level)", err)
 		}
 		_, err := u.fileURL().UploadRangeFromURL(
@@ -0,0 +1,83 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// copyAccessControl puts the ACL of the source on the destination, for a transfer between two ADLS Gen 2 (HNS)
+// accounts that preserves permissions. The ACL is copied whole, so for a directory that includes its default ACL,
+// which is what the files and directories that are created in it later start out with.
+func copyAccessControl(jptm IJobPartTransferMgr, sip ISourceInfoProvider, destination url.URL) error {
+	// We know for a fact our source is a "blob".
+	acl, err := sip.(*blobSourceInfoProvider).AccessControl()
+	if err != nil {
+		return fmt.Errorf("when getting the source ACL: %w", err)
+	}
+	acl.Permissions = "" // Since we're sending the full ACL, Permissions is irrelevant.
+
+	bURLParts := azblob.NewBlobURLParts(destination)
+	bURLParts.BlobName = strings.TrimSuffix(bURLParts.BlobName, "/") // BlobFS does not like when we target a folder with the /
+	bURLParts.Host = strings.ReplaceAll(bURLParts.Host, ".blob", ".dfs")
+	// todo: jank, and violates the principle of interfaces
+	fileURL := azbfs.NewFileURL(bURLParts.URL(), jptm.(*jobPartTransferMgr).jobPartMgr.(*jobPartMgr).secondaryPipeline)
+
+	if _, err = fileURL.SetAccessControl(jptm.Context(), acl); err != nil {
+		return fmt.Errorf("when putting the ACL: %w", err)
+	}
+
+	// The service takes any object ID in an ACL entry, without checking that it is a principal of the account's tenant.
+	// So we can't tell whether the principals exist at the destination, but when the ACL has moved to another account
+	// it is worth a warning that they might not.
+	if sourceURL, err := url.Parse(jptm.Info().Source); err == nil && !strings.EqualFold(sourceURL.Host, destination.Host) {
+		if principals := namedPrincipals(acl.ACL); len(principals) > 0 {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, fmt.Sprintf("The ACL names the principals %s, which were copied by object ID. "+
+				"Entries for any that are not in the tenant of the destination account will not grant access to anyone", strings.Join(principals, ", ")))
+		}
+	}
+	return nil
+}
+
+// namedPrincipals lists, once each, the users and groups that the entries of an ACL name (in either the access or the
+// default ACL). The entries for the owning user and group, the mask and others name no one.
+func namedPrincipals(acl string) []string {
+	principals := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(acl, ",") {
+		parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(entry), "default:"), ":")
+		if len(parts) != 3 || (parts[0] != "user" && parts[0] != "group") || parts[1] == "" {
+			continue
+		}
+		principal := parts[0] + ":" + parts[1]
+		if !seen[principal] {
+			seen[principal] = true
+			principals = append(principals, principal)
+		}
+	}
+	return principals
+}
@@ -55,6 +55,7 @@ func newBlobFolderSender(jptm IJobPartTransferMgr, destination string, p pipelin
 
 func (b *blobFolderSender) EnsureFolderExists() error {
 	t := b.jptm.GetFolderCreationTracker()
+	conditions := b.jptm.DestinationConditions()
 
 	_, err := b.destination.GetProperties(b.jptm.Context(), azblob.BlobAccessConditions{}, b.cpkToApply)
 	if err != nil {
@@ -70,10 +71,12 @@ func (b *blobFolderSender) EnsureFolderExists() error {
 			If so, we should delete the old blob, and create a new one in it's place with all of our fancy new properties.
 		*/
 		if t.ShouldSetProperties(b.DirUrlToString(), b.jptm.GetOverwriteOption(), b.jptm.GetOverwritePrompter()) {
-			_, err := b.destination.Delete(b.jptm.Context(), azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+			// the existing blob is what --if-match or --if-none-match is about, so it's the delete that they go with
+			_, err := b.destination.Delete(b.jptm.Context(), azblob.DeleteSnapshotsOptionNone, conditions)
 			if err != nil {
 				return fmt.Errorf("when deleting existing blob: %w", err)
 			}
+			conditions = azblob.BlobAccessConditions{}
 		} else {
 			/*
 				We don't want to prompt the user again, and we're not going to write properties. So, we should kill the
@@ -94,7 +97,7 @@ func (b *blobFolderSender) EnsureFolderExists() error {
 		strings.NewReader(""),
 		b.headersToAppply,
 		b.metadataToApply,
-		azblob.BlobAccessConditions{},
+		conditions,
 		azblob.DefaultAccessTier, // It doesn't make sense to use a special access tier, the blob will be 0 bytes.
 		b.blobTagsToApply,
 		b.cpkToApply,
@@ -103,6 +106,13 @@ func (b *blobFolderSender) EnsureFolderExists() error {
 		return fmt.Errorf("when creating folder: %w", err)
 	}
 
+	// Folders have ACLs of their own in ADLS Gen 2, including the default ACL that their contents inherit
+	if b.jptm.FromTo() == common.EFromTo.BlobBlob() && b.jptm.Info().PreserveSMBPermissions.IsTruthy() {
+		if err = copyAccessControl(b.jptm, b.sip, b.destination.URL()); err != nil {
+			return fmt.Errorf("when copying folder ACLs: %w", err)
+		}
+	}
+
 	t.RecordCreation(b.DirUrlToString())
 
 	return folderPropertiesSetInCreation{}
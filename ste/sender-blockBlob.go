@@ -26,13 +26,11 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
-	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -58,6 +56,9 @@ type blockBlobSenderBase struct {
 	blobTagsToApply azblob.BlobTagsMap
 	cpkToApply      azblob.ClientProvidedKeyOptions
 
+	// setTagsSeparately is true when the tags are set by Set Blob Tags once the blob is written, rather than by the write itself
+	setTagsSeparately bool
+
 	atomicChunksWritten    int32
 	atomicPutListIndicator int32
 	muBlockIDs             *sync.Mutex
@@ -139,19 +140,20 @@ func newBlockBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pipe
 	cpkToApply := common.ToClientProvidedKeyOptions(jptm.CpkInfo(), jptm.CpkScopeInfo())
 
 	return &blockBlobSenderBase{
-		jptm:             jptm,
-		sip:              srcInfoProvider,
-		destBlockBlobURL: destBlockBlobURL,
-		chunkSize:        chunkSize,
-		numChunks:        numChunks,
-		pacer:            pacer,
-		blockIDs:         make([]string, numChunks),
-		headersToApply:   props.SrcHTTPHeaders.ToAzBlobHTTPHeaders(),
-		metadataToApply:  props.SrcMetadata.ToAzBlobMetadata(),
-		blobTagsToApply:  props.SrcBlobTags.ToAzBlobTagsMap(),
-		destBlobTier:     destBlobTier,
-		cpkToApply:       cpkToApply,
-		muBlockIDs:       &sync.Mutex{}}, nil
+		jptm:              jptm,
+		sip:               srcInfoProvider,
+		destBlockBlobURL:  destBlockBlobURL,
+		chunkSize:         chunkSize,
+		numChunks:         numChunks,
+		pacer:             pacer,
+		blockIDs:          make([]string, numChunks),
+		headersToApply:    props.SrcHTTPHeaders.ToAzBlobHTTPHeaders(),
+		metadataToApply:   props.SrcMetadata.ToAzBlobMetadata(),
+		blobTagsToApply:   props.SrcBlobTags.ToAzBlobTagsMap(),
+		destBlobTier:      destBlobTier,
+		cpkToApply:        cpkToApply,
+		setTagsSeparately: tagsPreservedFromOtherAccount(jptm, destURL, props.SrcBlobTags),
+		muBlockIDs:        &sync.Mutex{}}, nil
 }
 
 func (s *blockBlobSenderBase) SendableEntityType() common.EntityType {
@@ -200,7 +202,7 @@ func (s *blockBlobSenderBase) Epilogue() {
 		}
 
 		blobTags := s.blobTagsToApply
-		separateSetTagsRequired := separateSetTagsRequired(blobTags)
+		separateSetTagsRequired := s.setTagsSeparately || separateSetTagsRequired(blobTags)
 		if separateSetTagsRequired || len(blobTags) == 0 {
 			blobTags = nil
 		}
@@ -211,35 +213,23 @@ func (s *blockBlobSenderBase) Epilogue() {
 			destBlobTier = azblob.AccessTierNone
 		}
 
-		if _, err := s.destBlockBlobURL.CommitBlockList(jptm.Context(), blockIDs, s.headersToApply, s.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, s.cpkToApply, azblob.ImmutabilityPolicyOptions{}); err != nil {
+		if _, err := s.destBlockBlobURL.CommitBlockList(jptm.Context(), blockIDs, s.headersToApply, s.metadataToApply, jptm.DestinationConditions(), destBlobTier, blobTags, s.cpkToApply,
+			immutabilityPolicyToApply(jptm, s.destBlockBlobURL.BlobURL, s.cpkToApply)); err != nil {
 			jptm.FailActiveSend("Committing block list", err)
 			return
 		}
 
 		if separateSetTagsRequired {
 			if _, err := s.destBlockBlobURL.SetTags(jptm.Context(), nil, nil, nil, s.blobTagsToApply); err != nil {
-				s.jptm.Log(pipeline.LogWarning, err.Error())
+				s.jptm.Log(pipeline.LogWarning, "Could not set the blob index tags of the destination: "+err.Error())
 			}
 		}
 	}
 
 	// Upload ADLS Gen 2 ACLs
 	if jptm.FromTo() == common.EFromTo.BlobBlob() && jptm.Info().PreserveSMBPermissions.IsTruthy() {
-		bURLParts := azblob.NewBlobURLParts(s.destBlockBlobURL.URL())
-		bURLParts.BlobName = strings.TrimSuffix(bURLParts.BlobName, "/") // BlobFS does not like when we target a folder with the /
-		bURLParts.Host = strings.ReplaceAll(bURLParts.Host, ".blob", ".dfs")
-		// todo: jank, and violates the principle of interfaces
-		fileURL := azbfs.NewFileURL(bURLParts.URL(), s.jptm.(*jobPartTransferMgr).jobPartMgr.(*jobPartMgr).secondaryPipeline)
-
-		// We know for a fact our source is a "blob".
-		acl, err := s.sip.(*blobSourceInfoProvider).AccessControl()
-		if err != nil {
-			jptm.FailActiveSend("Grabbing source ACLs", err)
-		}
-		acl.Permissions = "" // Since we're sending the full ACL, Permissions is irrelevant.
-		_, err = fileURL.SetAccessControl(jptm.Context(), acl)
-		if err != nil {
-			jptm.FailActiveSend("Putting ACLs", err)
+		if err := copyAccessControl(jptm, s.sip, s.destBlockBlobURL.URL()); err != nil {
+			jptm.FailActiveSend("Copying ACLs", err)
 		}
 	}
 }
@@ -247,6 +237,12 @@ func (s *blockBlobSenderBase) Epilogue() {
 func (s *blockBlobSenderBase) Cleanup() {
 	jptm := s.jptm
 
+	// a blob that turned down the write on its --if-match or --if-none-match condition is the one the condition
+	// was there to protect, so it's left as it is
+	if jptm.TransferStatusIgnoringCancellation() == common.ETransferStatus.PreconditionFailed() {
+		return
+	}
+
 	// Cleanup
 	if jptm.IsDeadInflight() && atomic.LoadInt32(&s.atomicChunksWritten) != 0 {
 		// there is a possibility that some uncommitted blocks will be there
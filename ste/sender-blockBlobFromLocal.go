@@ -61,6 +61,10 @@ func (s *blockBlobUploader) Prologue(ps common.PrologueState) (destinationModifi
 		}
 	}
 
+	if s.jptm.Info().PreserveXattrs {
+		s.metadataToApply = addXattrsToMetadata(s.jptm, s.sip, s.metadataToApply)
+	}
+
 	return s.blockBlobSenderBase.Prologue(ps)
 }
 
@@ -93,12 +97,17 @@ func (u *blockBlobUploader) generatePutBlock(id common.ChunkID, blockIndex int32
 
 		// step 3: put block to remote
 		u.jptm.LogChunkStatus(id, common.EWaitReason.Body())
+		sentCrc64 := chunkCrc64(u.jptm, reader)
 		body := newPacedRequestBody(u.jptm.Context(), reader, u.pacer)
-		_, err := u.destBlockBlobURL.StageBlock(u.jptm.Context(), encodedBlockID, body, azblob.LeaseAccessConditions{}, nil, u.cpkToApply)
+		resp, err := u.destBlockBlobURL.StageBlock(u.jptm.Context(), encodedBlockID, body, azblob.LeaseAccessConditions{}, nil, u.cpkToApply)
 		if err != nil {
 			u.jptm.FailActiveUpload("Staging block", err)
 			return
 		}
+		if err = verifyCrc64(sentCrc64, resp.XMsContentCrc64()); err != nil {
+			u.jptm.FailActiveUpload("Verifying block CRC64", err)
+			return
+		}
 
 		atomic.AddInt32(&u.atomicChunksWritten, 1)
 	})
@@ -130,7 +139,7 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 		}
 
 		if jptm.Info().SourceSize == 0 {
-			_, err = u.destBlockBlobURL.Upload(jptm.Context(), bytes.NewReader(nil), u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, u.cpkToApply, azblob.ImmutabilityPolicyOptions{})
+			_, err = u.destBlockBlobURL.Upload(jptm.Context(), bytes.NewReader(nil), u.headersToApply, u.metadataToApply, jptm.DestinationConditions(), destBlobTier, blobTags, u.cpkToApply, immutabilityPolicyToApply(jptm, u.destBlockBlobURL.BlobURL, u.cpkToApply))
 		} else {
 			// File with content
 
@@ -143,9 +152,17 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 			u.headersToApply.ContentMD5 = md5Hash
 
 			// Upload the file
+			sentCrc64 := chunkCrc64(jptm, reader)
 			body := newPacedRequestBody(jptm.Context(), reader, u.pacer)
-			_, err = u.destBlockBlobURL.Upload(jptm.Context(), body, u.headersToApply, u.metadataToApply,
-				azblob.BlobAccessConditions{}, u.destBlobTier, blobTags, u.cpkToApply, azblob.ImmutabilityPolicyOptions{})
+			var resp *azblob.BlockBlobUploadResponse
+			resp, err = u.destBlockBlobURL.Upload(jptm.Context(), body, u.headersToApply, u.metadataToApply,
+				jptm.DestinationConditions(), u.destBlobTier, blobTags, u.cpkToApply, immutabilityPolicyToApply(jptm, u.destBlockBlobURL.BlobURL, u.cpkToApply))
+			if err == nil {
+				if err = verifyCrc64(sentCrc64, responseCrc64(resp.Response())); err != nil {
+					jptm.FailActiveUpload("Verifying blob CRC64", err)
+					return
+				}
+			}
 		}
 
 		// if the put blob is a failure, update the transfer status to failed
@@ -158,7 +175,7 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 
 		if separateSetTagsRequired {
 			if _, err := u.destBlockBlobURL.SetTags(jptm.Context(), nil, nil, nil, u.blobTagsToApply); err != nil {
-				u.jptm.Log(pipeline.LogWarning, err.Error())
+				u.jptm.Log(pipeline.LogWarning, "Could not set the blob index tags of the destination: "+err.Error())
 			}
 		}
 	})
@@ -96,7 +96,7 @@ func (c *urlToBlockBlobCopier) generateCreateEmptyBlob(id common.ChunkID) chunkF
 		}
 
 		blobTags := c.blobTagsToApply
-		separateSetTagsRequired := separateSetTagsRequired(blobTags)
+		separateSetTagsRequired := c.setTagsSeparately || separateSetTagsRequired(blobTags)
 		if separateSetTagsRequired || len(blobTags) == 0 {
 			blobTags = nil
 		}
@@ -107,7 +107,7 @@ func (c *urlToBlockBlobCopier) generateCreateEmptyBlob(id common.ChunkID) chunkF
 			destBlobTier = azblob.AccessTierNone
 		}
 
-		if _, err := c.destBlockBlobURL.Upload(c.jptm.Context(), bytes.NewReader(nil), c.headersToApply, c.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, c.cpkToApply, azblob.ImmutabilityPolicyOptions{}); err != nil {
+		if _, err := c.destBlockBlobURL.Upload(c.jptm.Context(), bytes.NewReader(nil), c.headersToApply, c.metadataToApply, c.jptm.DestinationConditions(), destBlobTier, blobTags, c.cpkToApply, immutabilityPolicyToApply(jptm, c.destBlockBlobURL.BlobURL, c.cpkToApply)); err != nil {
 			jptm.FailActiveSend("Creating empty blob", err)
 			return
 		}
@@ -116,7 +116,7 @@ func (c *urlToBlockBlobCopier) generateCreateEmptyBlob(id common.ChunkID) chunkF
 
 		if separateSetTagsRequired {
 			if _, err := c.destBlockBlobURL.SetTags(jptm.Context(), nil, nil, nil, c.blobTagsToApply); err != nil {
-				c.jptm.Log(pipeline.LogWarning, err.Error())
+				c.jptm.Log(pipeline.LogWarning, "Could not set the blob index tags of the destination: "+err.Error())
 			}
 		}
 	})
@@ -134,7 +134,7 @@ func (c *urlToBlockBlobCopier) generatePutBlockFromURL(id common.ChunkID, blockI
 		// step 3: put block to remote
 		c.jptm.LogChunkStatus(id, common.EWaitReason.S2SCopyOnWire())
 
-		if err := c.pacer.RequestTrafficAllocation(c.jptm.Context(), adjustedChunkSize); err != nil {
+		if err := waitForPacer(c.jptm.Context(), c.pacer, adjustedChunkSize); err != nil {
 			c.jptm.FailActiveUpload("Pacing block", err)
 		}
 		_, err := c.destBlockBlobURL.StageBlockFromURL(c.jptm.Context(), encodedBlockID, c.srcURL,
@@ -159,7 +159,7 @@ func (c *urlToBlockBlobCopier) generateStartPutBlobFromURL(id common.ChunkID, bl
 		}
 
 		blobTags := c.blobTagsToApply
-		separateSetTagsRequired := separateSetTagsRequired(blobTags)
+		separateSetTagsRequired := c.setTagsSeparately || separateSetTagsRequired(blobTags)
 		if separateSetTagsRequired || len(blobTags) == 0 {
 			blobTags = nil
 		}
@@ -170,12 +170,12 @@ func (c *urlToBlockBlobCopier) generateStartPutBlobFromURL(id common.ChunkID, bl
 			destBlobTier = azblob.AccessTierNone
 		}
 
-		if err := c.pacer.RequestTrafficAllocation(c.jptm.Context(), adjustedChunkSize); err != nil {
+		if err := waitForPacer(c.jptm.Context(), c.pacer, adjustedChunkSize); err != nil {
 			c.jptm.FailActiveUpload("Pacing block", err)
 		}
 
 		_, err := c.destBlockBlobURL.PutBlobFromURL(c.jptm.Context(), c.headersToApply, c.srcURL, c.metadataToApply,
-			azblob.ModifiedAccessConditions{}, azblob.BlobAccessConditions{}, nil, nil, destBlobTier, blobTags,
+			azblob.ModifiedAccessConditions{}, c.jptm.DestinationConditions(), nil, nil, destBlobTier, blobTags,
 			c.cpkToApply, c.jptm.GetS2SSourceBlobTokenCredential())
 
 		if err != nil {
@@ -187,9 +187,14 @@ func (c *urlToBlockBlobCopier) generateStartPutBlobFromURL(id common.ChunkID, bl
 
 		if separateSetTagsRequired {
 			if _, err := c.destBlockBlobURL.SetTags(c.jptm.Context(), nil, nil, nil, c.blobTagsToApply); err != nil {
-				c.jptm.Log(pipeline.LogWarning, err.Error())
+				c.jptm.Log(pipeline.LogWarning, "Could not set the blob index tags of the destination: "+err.Error())
 			}
 		}
+
+		// Put Blob from URL can't set an immutability policy itself, so it's set once the blob is written
+		if err := applyImmutabilityPolicy(c.jptm, c.destBlockBlobURL.BlobURL, c.cpkToApply); err != nil {
+			c.jptm.FailActiveSend("Setting immutability policy", err)
+		}
 	})
 }
 
@@ -52,6 +52,9 @@ type pageBlobSenderBase struct {
 	blobTagsToApply azblob.BlobTagsMap
 	cpkToApply      azblob.ClientProvidedKeyOptions
 
+	// setTagsSeparately is true when the tags are set by Set Blob Tags once the blob is created, rather than by the create itself
+	setTagsSeparately bool
+
 	destBlobTier azblob.AccessTierType
 	// filePacer is necessary because page blobs have per-blob throughput limits. The limits depend on
 	// what type of page blob it is (e.g. premium) and can be significantly lower than the blob account limit.
@@ -134,6 +137,7 @@ func newPageBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pipel
 		headersToApply:         props.SrcHTTPHeaders.ToAzBlobHTTPHeaders(),
 		metadataToApply:        props.SrcMetadata.ToAzBlobMetadata(),
 		blobTagsToApply:        props.SrcBlobTags.ToAzBlobTagsMap(),
+		setTagsSeparately:      tagsPreservedFromOtherAccount(jptm, destURL, props.SrcBlobTags),
 		destBlobTier:           destBlobTier,
 		filePacer:              NewNullAutoPacer(), // defer creation of real one to Prologue
 		cpkToApply:             cpkToApply,
@@ -231,7 +235,7 @@ func (s *pageBlobSenderBase) Prologue(ps common.PrologueState) (destinationModif
 	}
 
 	blobTags := s.blobTagsToApply
-	separateSetTagsRequired := separateSetTagsRequired(blobTags)
+	separateSetTagsRequired := s.setTagsSeparately || separateSetTagsRequired(blobTags)
 	if separateSetTagsRequired || len(blobTags) == 0 {
 		blobTags = nil
 	}
@@ -246,7 +250,7 @@ func (s *pageBlobSenderBase) Prologue(ps common.PrologueState) (destinationModif
 		0,
 		s.headersToApply,
 		s.metadataToApply,
-		azblob.BlobAccessConditions{},
+		s.jptm.DestinationConditions(),
 		destBlobTier,
 		blobTags,
 		s.cpkToApply,
@@ -260,7 +264,7 @@ func (s *pageBlobSenderBase) Prologue(ps common.PrologueState) (destinationModif
 
 	if separateSetTagsRequired {
 		if _, err := s.destPageBlobURL.SetTags(s.jptm.Context(), nil, nil, nil, s.blobTagsToApply); err != nil {
-			s.jptm.Log(pipeline.LogWarning, err.Error())
+			s.jptm.Log(pipeline.LogWarning, "Could not set the blob index tags of the destination: "+err.Error())
 		}
 	}
 
@@ -269,6 +273,12 @@ func (s *pageBlobSenderBase) Prologue(ps common.PrologueState) (destinationModif
 
 func (s *pageBlobSenderBase) Epilogue() {
 	_ = s.filePacer.Close() // release resources
+
+	if s.jptm.IsLive() {
+		if err := applyImmutabilityPolicy(s.jptm, s.destPageBlobURL.BlobURL, s.cpkToApply); err != nil {
+			s.jptm.FailActiveSend("Setting immutability policy", err)
+		}
+	}
 }
 
 func (s *pageBlobSenderBase) Cleanup() {
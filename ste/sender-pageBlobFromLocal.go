@@ -36,6 +36,12 @@ type pageBlobUploader struct {
 }
 
 func newPageBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeline.Pipeline, pacer pacer, sip ISourceInfoProvider) (sender, error) {
+	// the service only takes whole pages, so catch this now, rather than with an obscure error from the service later on
+	if srcSize := jptm.Info().SourceSize; srcSize%azblob.PageBlobPageBytes != 0 {
+		return nil, fmt.Errorf("cannot upload this file as a page blob, because its size (%d bytes) is not a multiple of %d bytes. "+
+			"Upload it as another type of blob, with --blob-type or --blob-type-by-pattern", srcSize, azblob.PageBlobPageBytes)
+	}
+
 	senderBase, err := newPageBlobSenderBase(jptm, destination, p, pacer, sip, azblob.AccessTierNone)
 	if err != nil {
 		return nil, err
@@ -59,6 +65,10 @@ func (u *pageBlobUploader) Prologue(ps common.PrologueState) (destinationModifie
 		}
 	}
 
+	if u.jptm.Info().PreserveXattrs {
+		u.metadataToApply = addXattrsToMetadata(u.jptm, u.sip, u.metadataToApply)
+	}
+
 	return u.pageBlobSenderBase.Prologue(ps)
 }
 
@@ -104,19 +114,24 @@ func (u *pageBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int3
 		// Note that this level of control here is specific to the individual page blob, and is additional
 		// to the application-wide pacing that we (optionally) do below when writing the response body.
 		jptm.LogChunkStatus(id, common.EWaitReason.FilePacer())
-		if err := u.filePacer.RequestTrafficAllocation(jptm.Context(), reader.Length()); err != nil {
+		if err := waitForPacer(jptm.Context(), u.filePacer, reader.Length()); err != nil {
 			jptm.FailActiveUpload("Pacing block", err)
 		}
 
 		// send it
 		jptm.LogChunkStatus(id, common.EWaitReason.Body())
+		sentCrc64 := chunkCrc64(jptm, reader)
 		body := newPacedRequestBody(jptm.Context(), reader, u.pacer)
 		enrichedContext := withRetryNotification(jptm.Context(), u.filePacer)
-		_, err := u.destPageBlobURL.UploadPages(enrichedContext, id.OffsetInFile(), body, azblob.PageBlobAccessConditions{}, nil, u.cpkToApply)
+		resp, err := u.destPageBlobURL.UploadPages(enrichedContext, id.OffsetInFile(), body, azblob.PageBlobAccessConditions{}, nil, u.cpkToApply)
 		if err != nil {
 			jptm.FailActiveUpload("Uploading page", err)
 			return
 		}
+		if err = verifyCrc64(sentCrc64, resp.XMsContentCrc64()); err != nil {
+			jptm.FailActiveUpload("Verifying page CRC64", err)
+			return
+		}
 	})
 }
 
@@ -104,7 +104,7 @@ func (c *urlToPageBlobCopier) GenerateCopyFunc(id common.ChunkID, blockIndex int
 		// Note that this level of control here is specific to the individual page blob, and is additional
 		// to the application-wide pacing that we do with c.pacer
 		c.jptm.LogChunkStatus(id, common.EWaitReason.FilePacer())
-		if err := c.filePacer.RequestTrafficAllocation(c.jptm.Context(), adjustedChunkSize); err != nil {
+		if err := waitForPacer(c.jptm.Context(), c.filePacer, adjustedChunkSize); err != nil {
 			c.jptm.FailActiveUpload("Pacing block (file level)", err)
 		}
 
@@ -117,7 +117,7 @@ func (c *urlToPageBlobCopier) GenerateCopyFunc(id common.ChunkID, blockIndex int
 		// upload the page (including application of global pacing. We don't have a separate wait reason for global pacing
 		// so just do it inside the S2SCopyOnWire state)
 		c.jptm.LogChunkStatus(id, common.EWaitReason.S2SCopyOnWire())
-		if err := c.pacer.RequestTrafficAllocation(c.jptm.Context(), adjustedChunkSize); err != nil {
+		if err := waitForPacer(c.jptm.Context(), c.pacer, adjustedChunkSize); err != nil {
 			c.jptm.FailActiveUpload("Pacing block (global level)", err)
 		}
 		_, err := c.destPageBlobURL.UploadPagesFromURL(
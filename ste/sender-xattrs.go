@@ -0,0 +1,51 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// addXattrsToMetadata returns metadata with the user-namespace extended attributes of the source added, for
+// preserve-xattrs. Sources that don't have extended attributes get metadata back as it was. So do those whose
+// attributes can't be read, or wouldn't fit; that is warned about, but the content is still sent.
+func addXattrsToMetadata(jptm IJobPartTransferMgr, sip ISourceInfoProvider, metadata azblob.Metadata) azblob.Metadata {
+	xattrSIP, ok := sip.(IXattrBearingSourceInfoProvider)
+	if !ok {
+		return metadata
+	}
+
+	xattrs, err := xattrSIP.GetXattrs()
+	if err != nil {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Extended attributes not preserved, because they could not be read: "+err.Error())
+		return metadata
+	}
+
+	// Clone the metadata before we write to it, we shouldn't be writing to the same metadata as every other blob.
+	withXattrs := common.Metadata(metadata).Clone().ToAzBlobMetadata()
+	if err = common.AddXattrsToBlobMetadata(xattrs, withXattrs); err != nil {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Extended attributes not preserved: "+err.Error())
+		return metadata
+	}
+	return withXattrs
+}
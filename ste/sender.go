@@ -22,6 +22,8 @@ package ste
 
 import (
 	"errors"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
@@ -75,6 +77,13 @@ type folderSender interface {
 	DirUrlToString() string // This is only used in folder tracking, so this should trim the SAS token.
 }
 
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// symlinkSender is a sender that can record a symlink at the destination, rather than the content of what it points to
+/////////////////////////////////////////////////////////////////////////////////////////////////
+type symlinkSender interface {
+	SendSymlink(linkTarget string) error
+}
+
 // We wrote properties at creation time.
 type folderPropertiesSetInCreation struct{}
 
@@ -133,6 +142,7 @@ func newMd5Channel() chan []byte {
 func tryPutMd5Hash(jptm IJobPartTransferMgr, md5Channel <-chan []byte, worker func(hash []byte) error) {
 	md5Hash, ok := <-md5Channel
 	if ok {
+		jptm.RecordContentMD5(md5Hash)
 		err := worker(md5Hash)
 		if err != nil {
 			jptm.FailActiveUpload("Setting hash", err)
@@ -200,7 +210,10 @@ func newBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeline.Pi
 	override := jptm.BlobTypeOverride()
 	intendedType := override.ToAzBlobType()
 
-	if override == common.EBlobType.Detect() {
+	if chosen := jptm.Info().SrcBlobType; chosen != azblob.BlobNone {
+		// chosen for this file in particular, by --blob-type-by-pattern, since a local source has no blob type of its own
+		intendedType = chosen
+	} else if override == common.EBlobType.Detect() {
 		intendedType = inferBlobType(jptm.Info().Source, azblob.BlobBlockBlob)
 		// jptm.LogTransferInfo(fmt.Sprintf("Autodetected %s blob type as %s.", jptm.Info().Source , intendedType))
 		// TODO: Log these? @JohnRusk and @zezha-msft this creates quite a bit of spam in the logs but is important info.
@@ -211,6 +224,10 @@ func newBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeline.Pi
 		return newBlobFolderSender(jptm, destination, p, pacer, sip)
 	}
 
+	if jptm.Info().EntityType == common.EEntityType.Symlink() {
+		return newBlobSymlinkSender(jptm, destination, p, pacer, sip)
+	}
+
 	switch intendedType {
 	case azblob.BlobBlockBlob:
 		return newBlockBlobUploader(jptm, destination, p, pacer, sip)
@@ -225,6 +242,17 @@ func newBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeline.Pi
 
 const TagsHeaderMaxLength = 2000
 
+// tagsPreservedFromOtherAccount returns true when the tags to apply were preserved from a source blob in another account.
+// Such tags are set by Set Blob Tags once the destination is written, as are tags too long for the header, so that a
+// destination that refuses them fails only the tagging (which is logged), not the copy.
+func tagsPreservedFromOtherAccount(jptm IJobPartTransferMgr, destURL *url.URL, tags common.BlobTags) bool {
+	if jptm.FromTo() != common.EFromTo.BlobBlob() || len(tags) == 0 {
+		return false
+	}
+	srcURL, err := url.Parse(jptm.Info().Source)
+	return err == nil && !strings.EqualFold(srcURL.Host, destURL.Host)
+}
+
 // If length of tags <= 2kb, pass it in the header x-ms-tags. Else do a separate SetTags call
 func separateSetTagsRequired(tagsMap azblob.BlobTagsMap) bool {
 	tagsLength := 0
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/minio/minio-go"
 	"golang.org/x/oauth2/google"
 	"io/ioutil"
 	"net/url"
@@ -19,6 +20,9 @@ type gcpSourceInfoProvider struct {
 
 	gcpClient   *gcpUtils.Client
 	gcpURLParts common.GCPURLParts
+
+	// hmacClient is used in place of gcpClient when authenticating with an HMAC key, which only the XML API accepts
+	hmacClient *minio.Client
 }
 
 var gcpClientFactory = common.NewGCPClientFactory()
@@ -37,6 +41,19 @@ func newGCPSourceInfoProvider(jptm IJobPartTransferMgr) (ISourceInfoProvider, er
 		return nil, err
 	}
 
+	glcm := common.GetLifecycleMgr()
+	if glcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleAppCredentials()) == "" {
+		p.hmacClient, err = s3ClientFactory.GetS3Client(p.jptm.Context(), common.NewGCPHMACCredentialInfo(), common.CredentialOpOptions{
+			LogInfo:  func(str string) { p.jptm.Log(pipeline.LogInfo, str) },
+			LogError: func(str string) { p.jptm.Log(pipeline.LogError, str) },
+			Panic:    func(err error) { panic(err) },
+		}, jptm)
+		if err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+
 	p.gcpClient, err = gcpClientFactory.GetGCPClient(
 		p.jptm.Context(),
 		common.CredentialInfo{
@@ -51,7 +68,6 @@ func newGCPSourceInfoProvider(jptm IJobPartTransferMgr) (ISourceInfoProvider, er
 	if err != nil {
 		return nil, err
 	}
-	glcm := common.GetLifecycleMgr()
 	jsonKey, err = ioutil.ReadFile(glcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleAppCredentials()))
 	if err != nil {
 		return nil, fmt.Errorf("Cannot read JSON key file. Please verify you have correctly set GOOGLE_APPLICATION_CREDENTIALS environment variable")
@@ -60,6 +76,9 @@ func newGCPSourceInfoProvider(jptm IJobPartTransferMgr) (ISourceInfoProvider, er
 }
 
 func (p *gcpSourceInfoProvider) PreSignedSourceURL() (*url.URL, error) {
+	if p.hmacClient != nil {
+		return p.hmacClient.PresignedGetObject(p.gcpURLParts.BucketName, p.gcpURLParts.ObjectKey, defaultPresignExpires, url.Values{})
+	}
 
 	conf, err := google.JWTConfigFromJSON(jsonKey)
 	if err != nil {
@@ -91,7 +110,24 @@ func (p *gcpSourceInfoProvider) Properties() (*SrcProperties, error) {
 		SrcHTTPHeaders: p.transferInfo.SrcHTTPHeaders,
 		SrcMetadata:    p.transferInfo.SrcMetadata,
 	}
-	if p.transferInfo.S2SGetPropertiesInBackend {
+	if p.transferInfo.S2SGetPropertiesInBackend && p.hmacClient != nil {
+		objectInfo, err := p.hmacClient.StatObject(p.gcpURLParts.BucketName, p.gcpURLParts.ObjectKey, minio.StatObjectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		oie := common.GCPHMACObjectInfoExtension{ObjectInfoExtension: common.ObjectInfoExtension{ObjectInfo: objectInfo}}
+		srcProperties = SrcProperties{
+			SrcHTTPHeaders: common.ResourceHTTPHeaders{
+				ContentType:        oie.ContentType(),
+				ContentEncoding:    oie.ContentEncoding(),
+				ContentDisposition: oie.ContentDisposition(),
+				ContentLanguage:    oie.ContentLanguage(),
+				CacheControl:       oie.CacheControl(),
+				ContentMD5:         oie.ContentMD5(),
+			},
+			SrcMetadata: oie.NewCommonMetadata(),
+		}
+	} else if p.transferInfo.S2SGetPropertiesInBackend {
 		objectInfo, err := p.gcpClient.Bucket(p.gcpURLParts.BucketName).Object(p.gcpURLParts.ObjectKey).Attrs(p.jptm.Context())
 		if err != nil {
 			return nil, err
@@ -155,6 +191,13 @@ func (p *gcpSourceInfoProvider) IsLocal() bool {
 }
 
 func (p *gcpSourceInfoProvider) GetFreshFileLastModifiedTime() (time.Time, error) {
+	if p.hmacClient != nil {
+		objectInfo, err := p.hmacClient.StatObject(p.gcpURLParts.BucketName, p.gcpURLParts.ObjectKey, minio.StatObjectOptions{})
+		if err != nil {
+			return time.Time{}, err
+		}
+		return objectInfo.LastModified, nil
+	}
 	objectInfo, err := p.gcpClient.Bucket(p.gcpURLParts.BucketName).Object(p.gcpURLParts.ObjectKey).Attrs(p.jptm.Context())
 	if err != nil {
 		return time.Time{}, err
@@ -34,6 +34,13 @@ type localFileSourceInfoProvider struct {
 }
 
 func newLocalSourceInfoProvider(jptm IJobPartTransferMgr) (ISourceInfoProvider, error) {
+	archive, entry, err := jptm.SourceArchiveEntry()
+	if err != nil {
+		return nil, err
+	}
+	if archive != nil {
+		return &archiveEntrySourceInfoProvider{localFileSourceInfoProvider{jptm, jptm.Info()}, archive, entry}, nil
+	}
 	return &localFileSourceInfoProvider{jptm, jptm.Info()}, nil
 }
 
@@ -80,3 +87,19 @@ func (f localFileSourceInfoProvider) GetFreshFileLastModifiedTime() (time.Time,
 func (f localFileSourceInfoProvider) EntityType() common.EntityType {
 	return f.transferInfo.EntityType
 }
+
+// Source info provider for the entries of a local archive, which are read in place, rather than extracted first
+type archiveEntrySourceInfoProvider struct {
+	localFileSourceInfoProvider // for the properties, which like those of any local file come from the job's settings
+	archive                     *common.ArchiveSource
+	entry                       *common.ArchiveSourceEntry
+}
+
+func (f archiveEntrySourceInfoProvider) OpenSourceFile() (common.CloseableReaderAt, error) {
+	return f.archive.Open(f.entry)
+}
+
+// GetFreshFileLastModifiedTime is the entry's own time, since the entries of an archive don't change while it is read
+func (f archiveEntrySourceInfoProvider) GetFreshFileLastModifiedTime() (time.Time, error) {
+	return f.entry.ModTime, nil
+}
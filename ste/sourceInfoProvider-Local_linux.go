@@ -4,9 +4,12 @@
 package ste
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"golang.org/x/sys/unix"
-	"time"
 )
 
 func (f localFileSourceInfoProvider) HasUNIXProperties() bool {
@@ -162,3 +165,72 @@ func (s statTAdapter) MTime() time.Time {
 func (s statTAdapter) CTime() time.Time {
 	return time.Unix(s.Ctim.Unix())
 }
+
+func (f localFileSourceInfoProvider) GetXattrs() (map[string][]byte, error) {
+	names, err := listXattrs(f.transferInfo.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string][]byte, len(names))
+	for _, name := range names {
+		value, err := getXattr(f.transferInfo.Source, name)
+		if err == unix.ENODATA {
+			continue // removed since we listed it
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read extended attribute %s: %w", name, err)
+		}
+		xattrs[name] = value
+	}
+	return xattrs, nil
+}
+
+// listXattrs returns the names of the extended attributes of the file at path. The size is asked for first, and asked
+// again if attributes were added in between
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := unix.Listxattr(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size)
+		size, err = unix.Listxattr(path, buf)
+		if err == unix.ERANGE {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// the names are NUL-terminated, one after the other
+		names := make([]string, 0)
+		for _, name := range strings.Split(string(buf[:size]), "\x00") {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+}
+
+// getXattr returns the value of the named extended attribute of the file at path
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := unix.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size)
+		size, err = unix.Getxattr(path, name, buf)
+		if err == unix.ERANGE {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	}
+}
@@ -98,6 +98,14 @@ type IUNIXPropertyBearingSourceInfoProvider interface {
 	HasUNIXProperties() bool
 }
 
+// IXattrBearingSourceInfoProvider is for sources whose files have extended attributes. Only local files on Linux do
+type IXattrBearingSourceInfoProvider interface {
+	ISourceInfoProvider
+
+	// GetXattrs returns the names and values of the file's extended attributes, in every namespace that can be read
+	GetXattrs() (map[string][]byte, error)
+}
+
 type ICustomLocalOpener interface {
 	ISourceInfoProvider
 	Open(path string) (*os.File, error)
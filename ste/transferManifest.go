@@ -0,0 +1,196 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// manifestRecord is one line of a manifest
+type manifestRecord struct {
+	Source       string
+	Destination  string
+	Size         uint64
+	ContentMD5   string `json:",omitempty"` // base64, as in a Content-MD5 header
+	ErrorCode    int32  `json:",omitempty"`
+	ErrorMessage string `json:",omitempty"`
+	// the kind of error the transfer failed with, as the job summary counts it
+	ErrorCategory string `json:",omitempty"`
+}
+
+// transferManifest writes, as JSON Lines, a record of each file that the job transferred. The files that failed
+// are written to a second manifest, alongside the first, with the reason for each failure.
+// Folders are left out, since their properties are all that is transferred for them.
+type transferManifest struct {
+	transferred *jsonLinesFile
+	failed      *jsonLinesFile
+}
+
+// failedManifestPath is where the failed files go, given where the transferred ones go. E.g. manifest.failed.jsonl for manifest.jsonl
+func failedManifestPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".failed" + ext
+}
+
+func newTransferManifest(path string) (*transferManifest, error) {
+	transferred, err := createJSONLinesFile(path)
+	if err != nil {
+		return nil, err
+	}
+	failed, err := createJSONLinesFile(failedManifestPath(path))
+	if err != nil {
+		_ = transferred.Close()
+		return nil, err
+	}
+	return &transferManifest{transferred: transferred, failed: failed}, nil
+}
+
+// Add records the outcome of a transfer. Transfers that were neither successful nor failed (e.g. skipped ones) are not recorded
+func (m *transferManifest) Add(msg xferDoneMsg) error {
+	if msg.IsFolderProperties {
+		return nil
+	}
+
+	// the SAS tokens, if any, are not for the manifest
+	record := manifestRecord{
+		Source:      common.URLStringExtension(msg.Src).RedactSecretQueryParamForLogging(),
+		Destination: common.URLStringExtension(msg.Dst).RedactSecretQueryParamForLogging(),
+		Size:        msg.TransferSize,
+	}
+	if len(msg.ContentMD5) > 0 {
+		record.ContentMD5 = base64.StdEncoding.EncodeToString(msg.ContentMD5)
+	}
+
+	switch msg.TransferStatus {
+	case common.ETransferStatus.Success():
+		return m.transferred.Write(record)
+	case common.ETransferStatus.Failed(),
+		common.ETransferStatus.TierAvailabilityCheckFailure(),
+		common.ETransferStatus.BlobTierFailure(),
+		common.ETransferStatus.PreconditionFailed():
+		record.ErrorCode = msg.ErrorCode
+		record.ErrorMessage = msg.ErrorMessage
+		record.ErrorCategory = msg.FailureCategory.String()
+		return m.failed.Write(record)
+	}
+	return nil
+}
+
+// Close flushes both manifests, and closes them
+func (m *transferManifest) Close() error {
+	err := m.transferred.Close()
+	if failedErr := m.failed.Close(); err == nil {
+		err = failedErr
+	}
+	return err
+}
+
+// jsonLinesFile writes one JSON value per line, buffered
+type jsonLinesFile struct {
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+}
+
+func createJSONLinesFile(path string) (*jsonLinesFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	enc := json.NewEncoder(buf) // which ends each value with a newline
+	enc.SetEscapeHTML(false)    // URLs are more readable with their &s intact
+	return &jsonLinesFile{file: file, buf: buf, enc: enc}, nil
+}
+
+func (f *jsonLinesFile) Write(v interface{}) error {
+	return f.enc.Encode(v)
+}
+
+func (f *jsonLinesFile) Close() error {
+	err := f.buf.Flush()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// addToManifest records the transfer in the job's manifest, if it has one, opening the manifest on first use.
+// It is only called by the status manager's goroutine, which owns the manifest. If the manifest can't be written,
+// the problem is reported once, and the job carries on without it.
+func (jm *jobMgr) addToManifest(msg xferDoneMsg) {
+	if !jm.openManifest() {
+		return
+	}
+	if err := jm.jstm.manifest.Add(msg); err != nil {
+		jm.reportManifestFailure(err)
+	}
+}
+
+// closeManifest flushes and closes the job's manifest, once all its transfers are done. That includes jobs that end with
+// some failures, or that are cancelled. A job with no transfers still gets an (empty) manifest, so its absence is never ambiguous
+func (jm *jobMgr) closeManifest() {
+	if !jm.openManifest() {
+		return
+	}
+	if err := jm.jstm.manifest.Close(); err != nil {
+		jm.reportManifestFailure(err)
+	}
+	jm.jstm.manifest = nil
+	jm.jstm.manifestDone = true
+}
+
+// openManifest returns true if there is a manifest that can be written to, opening it if that has not been done yet
+func (jm *jobMgr) openManifest() bool {
+	jstm := jm.jstm
+	if jstm.manifestDone {
+		return false
+	}
+	if jstm.manifest == nil {
+		path := jm.getInMemoryTransitJobState().ManifestOutput
+		if path == "" {
+			return false
+		}
+		manifest, err := newTransferManifest(path)
+		if err != nil {
+			jm.reportManifestFailure(err)
+			return false
+		}
+		jstm.manifest = manifest
+	}
+	return true
+}
+
+func (jm *jobMgr) reportManifestFailure(err error) {
+	jm.jstm.manifestDone = true
+	msg := fmt.Sprintf("Failed to write the manifest of transferred files: %s", err)
+	jm.Log(pipeline.LogError, msg)
+	common.GetLifecycleMgr().Info(msg)
+}
@@ -0,0 +1,116 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// orderedTransfer is what the transfer order needs to know of a transfer
+type orderedTransfer struct {
+	isFile bool
+	size   int64
+	name   string // only needed for PatternPriority
+}
+
+// transferSchedulingOrder returns the indices of transfers, in the order that they are to be scheduled. Only files are
+// reordered: folders and the like are quick, and may create the folders that files go in, so they come first, in the
+// order they were listed. Files that the order doesn't tell apart, such as two of the same size, keep the order they
+// were listed in too.
+//
+// This only orders the transfers of one job part, since parts are scheduled as they are made, while the enumeration
+// goes on. It's also best-effort within a part: transfers run concurrently, and small files have workers of their own
+func transferSchedulingOrder(transfers []orderedTransfer, order common.TransferOrder, priorityPatterns []string) []uint32 {
+	indices := make([]uint32, len(transfers))
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+	if order == common.ETransferOrder.Listed() {
+		return indices
+	}
+
+	// the rank of a transfer: lower ranks are scheduled first
+	rank := func(t orderedTransfer) int64 {
+		switch order {
+		case common.ETransferOrder.SmallestFirst():
+			return t.size
+		case common.ETransferOrder.LargestFirst():
+			return -t.size
+		case common.ETransferOrder.PatternPriority():
+			if matchesPriorityPattern(priorityPatterns, t.name) {
+				return 0
+			}
+			return 1
+		default:
+			panic("undefined transfer order")
+		}
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		a, b := transfers[indices[i]], transfers[indices[j]]
+		if a.isFile != b.isFile {
+			return !a.isFile
+		}
+		return a.isFile && rank(a) < rank(b)
+	})
+	return indices
+}
+
+// matchesPriorityPattern says whether a file name matches any of the patterns, in the way that --include-pattern does.
+// Any braces in the patterns were expanded into alternatives before they were saved in the plan
+func matchesPriorityPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// transferSchedulingOrder returns the indices of the part's transfers, in the order in which the plan says that
+// they are to be scheduled
+func (jpm *jobPartMgr) transferSchedulingOrder(plan *JobPartPlanHeader) []uint32 {
+	var patterns []string
+	if plan.TransferOrder == common.ETransferOrder.PatternPriority() {
+		patterns = strings.Split(string(plan.PriorityPattern[:plan.PriorityPatternLength]), ";")
+	}
+
+	transfers := make([]orderedTransfer, plan.NumTransfers)
+	if plan.TransferOrder != common.ETransferOrder.Listed() {
+		for t := range transfers {
+			jppt := plan.Transfer(uint32(t))
+			transfers[t] = orderedTransfer{isFile: jppt.EntityType == common.EEntityType.File(), size: jppt.SourceSize}
+			if patterns != nil {
+				relSrc, _ := plan.TransferSrcDstRelatives(uint32(t))
+				if plan.FromTo.From().IsRemote() {
+					if unescaped, err := url.PathUnescape(relSrc); err == nil {
+						relSrc = unescaped
+					}
+				}
+				transfers[t].name = path.Base(strings.ReplaceAll(relSrc, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING))
+			}
+		}
+	}
+	return transferSchedulingOrder(transfers, plan.TransferOrder, patterns)
+}
@@ -181,6 +181,8 @@ func anyToRemote(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer, sen
 
 	if info.IsFolderPropertiesTransfer() {
 		anyToRemote_folder(jptm, info, p, pacer, senderFactory, sipf)
+	} else if info.EntityType == common.EEntityType.Symlink() {
+		anyToRemote_symlink(jptm, info, p, pacer, senderFactory, sipf)
 	} else {
 		anyToRemote_file(jptm, info, p, pacer, senderFactory, sipf)
 	}
@@ -203,7 +205,18 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 		return
 	}
 
-	// step 2a. Create sender
+	// step 2a. Snapshot the source, if asked to, so that writers can't change it while it's copied
+	if jptm.SnapshotSourceFirst() && jptm.FromTo() == common.EFromTo.BlobBlob() {
+		if err := snapshotSource(jptm); err != nil {
+			jptm.LogSendError(info.Source, info.Destination, "Could not snapshot the source. "+err.Error(), 0)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+			return
+		}
+		info = jptm.Info()
+	}
+
+	// step 2b. Create sender
 	srcInfoProvider, err := sipf(jptm)
 	if err != nil {
 		jptm.LogSendError(info.Source, info.Destination, err.Error(), 0)
@@ -223,7 +236,7 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 		return
 	}
 
-	// step 2b. Read chunk size and count from the sender (since it may have applied its own defaults and/or calculations to produce these values
+	// step 2c. Read chunk size and count from the sender (since it may have applied its own defaults and/or calculations to produce these values
 	numChunks := s.NumChunks()
 	if jptm.ShouldLog(pipeline.LogInfo) {
 		jptm.LogTransferStart(info.Source, info.Destination, fmt.Sprintf("Specified chunk size %d", s.ChunkSize()))
@@ -254,7 +267,7 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 				parsed.RawQuery = ""
 				shouldOverwrite = jptm.GetOverwritePrompter().ShouldOverwrite(parsed.String(), common.EEntityType.File())
 			} else if jptm.GetOverwriteOption() == common.EOverwriteOption.IfSourceNewer() {
-				// only overwrite if source lmt is newer (after) the destination
+				// only overwrite if source lmt is newer (after) the destination. If they are equal, the file is skipped
 				if jptm.LastModifiedTime().After(dstLmt) {
 					shouldOverwrite = true
 				}
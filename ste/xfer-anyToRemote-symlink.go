@@ -0,0 +1,94 @@
+package ste
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// anyToRemote_symlink handles uploads of the symlinks found when enumerating with --preserve-symlinks.
+// The link itself is sent, not what it points to, so there are no chunks to schedule.
+func anyToRemote_symlink(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pipeline, pacer pacer, senderFactory senderFactory, sipf sourceInfoProviderFactory) {
+
+	// step 1. perform initial checks
+	if jptm.WasCanceled() {
+		/* This is earliest we detect that jptm has been cancelled before we reach destination */
+		jptm.SetStatus(common.ETransferStatus.Cancelled())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	// step 2. Create sender
+	srcInfoProvider, err := sipf(jptm)
+	if err != nil {
+		jptm.LogSendError(info.Source, info.Destination, err.Error(), 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+	if !srcInfoProvider.IsLocal() {
+		panic("configuration error. Symlinks can only be sent from a local source")
+	}
+
+	baseSender, err := senderFactory(jptm, info.Destination, p, pacer, srcInfoProvider)
+	if err != nil {
+		jptm.LogSendError(info.Source, info.Destination, err.Error(), 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+	s, ok := baseSender.(symlinkSender)
+	if !ok {
+		jptm.LogSendError(info.Source, info.Destination, "sender implementation does not support symlinks", 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	// step 3: check overwrite option, in the same way as for files
+	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() {
+		exists, dstLmt, existenceErr := baseSender.RemoteFileExists()
+		if existenceErr != nil {
+			jptm.LogSendError(info.Source, info.Destination, "Could not check destination file existence. "+existenceErr.Error(), 0)
+			jptm.SetStatus(common.ETransferStatus.Failed()) // is a real failure, not just a SkippedFileAlreadyExists, in this case
+			jptm.ReportTransferDone()
+			return
+		}
+		if exists {
+			shouldOverwrite := false
+			if jptm.GetOverwriteOption() == common.EOverwriteOption.Prompt() {
+				// remove the SAS before prompting the user
+				parsed, _ := url.Parse(info.Destination)
+				parsed.RawQuery = ""
+				shouldOverwrite = jptm.GetOverwritePrompter().ShouldOverwrite(parsed.String(), common.EEntityType.File())
+			} else if jptm.GetOverwriteOption() == common.EOverwriteOption.IfSourceNewer() {
+				shouldOverwrite = jptm.LastModifiedTime().After(dstLmt)
+			}
+
+			if !shouldOverwrite {
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "File already exists, so will be skipped")
+				jptm.SetStatus(common.ETransferStatus.SkippedEntityAlreadyExists())
+				jptm.ReportTransferDone()
+				return
+			}
+		}
+	}
+
+	// step 4: read the link, as it is now. We record its target exactly as written, so relative links stay relative
+	linkTarget, err := os.Readlink(info.Source)
+	if err != nil {
+		jptm.LogSendError(info.Source, info.Destination, "Couldn't read symlink. "+err.Error(), 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	jptm.SetDestinationIsModified()
+	if err = s.SendSymlink(linkTarget); err != nil {
+		jptm.FailActiveSend("sending symlink", err)
+	}
+
+	commonSenderCompletion(jptm, baseSender, info) // for consistency, always run the standard epilogue
+}
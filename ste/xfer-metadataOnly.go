@@ -0,0 +1,119 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+var errMetadataOnlyDestinationMissing = errors.New("the destination blob does not exist, so its metadata cannot be set. " +
+	"Copy it without --metadata-only first")
+
+// MetadataOnly gives an existing destination blob the metadata, blob index tags and HTTP headers of its source, without
+// copying any data. A destination blob that doesn't exist fails its transfer, since there is nothing to set them on
+func MetadataOnly(jptm IJobPartTransferMgr, p pipeline.Pipeline, sipf sourceInfoProviderFactory) {
+	if jptm.WasCanceled() {
+		jptm.ReportTransferDone()
+		return
+	}
+
+	// like set-properties, the requests are made from a chunk, so that they run in the main pool
+	id := common.NewChunkID(jptm.Info().Source, 0, 0)
+	cf := createChunkFunc(true, jptm, id, func() {
+		setMetadataOnlyBlob(jptm, p, sipf)
+	})
+	jptm.ScheduleChunks(cf)
+}
+
+func setMetadataOnlyBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline, sipf sourceInfoProviderFactory) {
+	info := jptm.Info()
+
+	transferDone := func(status common.TransferStatus, err error) {
+		if status == common.ETransferStatus.Failed() {
+			jptm.LogError(info.Destination, "METADATA-ONLY FAILED with error: ", err)
+		} else {
+			jptm.Log(pipeline.LogInfo, fmt.Sprintf("METADATA-ONLY SUCCESSFUL: %s", strings.Split(info.Destination, "?")[0]))
+		}
+
+		jptm.SetStatus(status)
+		jptm.ResetSourceSize() // no data is copied, so no bytes are counted as transferred
+		jptm.ReportTransferDone()
+	}
+
+	sip, err := sipf(jptm)
+	if err != nil {
+		transferDone(common.ETransferStatus.Failed(), err)
+		return
+	}
+	props, err := sip.Properties()
+	if err != nil {
+		transferDone(common.ETransferStatus.Failed(), err)
+		return
+	}
+
+	u, err := url.Parse(info.Destination)
+	if err != nil {
+		transferDone(common.ETransferStatus.Failed(), err)
+		return
+	}
+	destBlobURL := azblob.NewBlobURL(*u, p)
+	cpk := common.ToClientProvidedKeyOptions(jptm.CpkInfo(), jptm.CpkScopeInfo())
+
+	destProps, err := destBlobURL.GetProperties(jptm.Context(), azblob.BlobAccessConditions{}, cpk)
+	if exists, _, err := remoteObjectExists(destProps, err); err != nil {
+		errorHandlerForXferSetProperties(err, jptm, transferDone)
+		return
+	} else if !exists {
+		transferDone(common.ETransferStatus.Failed(), errMetadataOnlyDestinationMissing)
+		return
+	}
+
+	// the Content-MD5 describes the destination's data, which is left as it is, so it's kept rather than taken from the source
+	headers := props.SrcHTTPHeaders.ToAzBlobHTTPHeaders()
+	headers.ContentMD5 = destProps.ContentMD5()
+	if _, err = destBlobURL.SetHTTPHeaders(jptm.Context(), headers, azblob.BlobAccessConditions{}); err != nil {
+		errorHandlerForXferSetProperties(err, jptm, transferDone)
+		return
+	}
+
+	if _, err = destBlobURL.SetMetadata(jptm.Context(), props.SrcMetadata.ToAzBlobMetadata(), azblob.BlobAccessConditions{}, cpk); err != nil {
+		errorHandlerForXferSetProperties(err, jptm, transferDone)
+		return
+	}
+
+	// the source only has tags if they are being preserved (--s2s-preserve-blob-tags), and otherwise the destination's are left alone
+	if len(props.SrcBlobTags) > 0 {
+		if _, err = destBlobURL.SetTags(jptm.Context(), nil, nil, nil, props.SrcBlobTags.ToAzBlobTagsMap()); err != nil {
+			errorHandlerForXferSetProperties(err, jptm, transferDone)
+			return
+		}
+	}
+
+	transferDone(common.ETransferStatus.Success(), nil)
+}
@@ -40,6 +40,8 @@ func remoteToLocal(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer, d
 	info := jptm.Info()
 	if info.IsFolderPropertiesTransfer() {
 		remoteToLocal_folder(jptm, p, pacer, df)
+	} else if info.EntityType == common.EEntityType.Symlink() {
+		remoteToLocal_symlink(jptm, p, pacer, df)
 	} else {
 		remoteToLocal_file(jptm, p, pacer, df)
 	}
@@ -55,6 +57,8 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	dl := df()
 
 	// step 2: get the source, destination info for the transfer.
+	// If the job streams its files into an archive, this transfer's destination is an entry in it
+	archive, entryName := jptm.DestinationArchive()
 	fileSize := int64(info.SourceSize)
 	downloadChunkSize := info.BlockSize
 
@@ -69,8 +73,9 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	}
 	// if the force Write flags is set to false or prompt
 	// then check the file exists at the remote location
-	// if it does, react accordingly
-	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() {
+	// if it does, react accordingly.
+	// (An archive's entries are all new, since the archive is written from scratch)
+	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() && archive == nil {
 		dstProps, err := common.OSStat(info.Destination)
 		if err == nil {
 			// if the error is nil, then file exists locally
@@ -80,7 +85,7 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 			if jptm.GetOverwriteOption() == common.EOverwriteOption.Prompt() {
 				shouldOverwrite = jptm.GetOverwritePrompter().ShouldOverwrite(info.Destination, common.EEntityType.File())
 			} else if jptm.GetOverwriteOption() == common.EOverwriteOption.IfSourceNewer() {
-				// only overwrite if source lmt is newer (after) the destination
+				// only overwrite if source lmt is newer (after) the destination. If they are equal, the file is skipped
 				if jptm.LastModifiedTime().After(dstProps.ModTime()) {
 					shouldOverwrite = true
 				}
@@ -128,7 +133,11 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		} else {
 			err := jptm.WaitUntilLockDestination(jptm.Context())
 			if err == nil {
-				err = createEmptyFile(jptm, info.Destination)
+				if archive != nil {
+					err = archive.AddEntry(jptm.Context(), newArchiveFileHeader(jptm, entryName))
+				} else {
+					err = createEmptyFile(jptm, info.Destination)
+				}
 			}
 			if err != nil {
 				jptm.LogDownloadError(info.Source, info.Destination, "Empty File Creation error "+err.Error(), 0)
@@ -163,6 +172,13 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	if strings.EqualFold(info.Destination, common.Dev_Null) {
 		// the user wants to discard the downloaded data
 		dstFile = devNullWriter{}
+	} else if archive != nil {
+		// wait for this file's turn at the archive, which it keeps until the epilogue closes its entry
+		dstFile, err = archive.OpenEntry(jptm.Context(), newArchiveFileHeader(jptm, entryName))
+		if err != nil {
+			failFileCreation(err)
+			return
+		}
 	} else {
 		// Normal scenario, create the destination file as expected
 		// Use pseudo chunk id to allow our usual state tracking mechanism to keep count of how many
@@ -217,7 +233,8 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		numChunks,
 		MaxRetryPerDownloadBody,
 		jptm.MD5ValidationOption(),
-		sourceMd5Exists)
+		sourceMd5Exists,
+		jptm.DownloadRangesInPlace())
 
 	// step 5c: run prologue in downloader (here it can, for example, create things that will require cleanup in the epilogue)
 	common.GetLifecycleMgr().E2EAwaitAllowOpenFiles()
@@ -279,12 +296,20 @@ func createDestinationFile(jptm IJobPartTransferMgr, destination string, size in
 			return nil, err
 		}
 		// Why get the decompression type again here, when we already looked at it at enumeration time?
-		// Because we have better ability to report unsupported compression types here, with clear "transfer failed" handling,
+		// Because we have better ability to report problems here, with clear "transfer failed" handling,
 		// and we still need to set size to zero here, so relying on enumeration more wouldn't simply this code much, if at all.
+	} else if cannotDecompress := jptm.CannotDecompress(); cannotDecompress != nil {
+		// an encoding that we can't decompress is no reason to fail the transfer. The file is saved just as it is stored
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, cannotDecompress.Error()+", so the file is saved without being decompressed")
 	}
 
 	var dstFile io.WriteCloser
-	dstFile, err = common.CreateFileOfSizeWithWriteThroughOption(destination, size, writeThrough, jptm.GetFolderCreationTracker(), jptm.GetForceIfReadOnly())
+	if jptm.DownloadRangesInPlace() {
+		// the ranges will be written in whatever order they arrive, so there should be no need to fill the gaps between them first
+		dstFile, err = common.CreateSparseFileOfSizeWithWriteThroughOption(destination, size, writeThrough, jptm.GetFolderCreationTracker(), jptm.GetForceIfReadOnly())
+	} else {
+		dstFile, err = common.CreateFileOfSizeWithWriteThroughOption(destination, size, writeThrough, jptm.GetFolderCreationTracker(), jptm.GetForceIfReadOnly())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -303,6 +328,7 @@ func createDestinationFile(jptm IJobPartTransferMgr, destination string, size in
 // complete epilogue. Handles both success and failure
 func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, activeDstFile io.WriteCloser, cw common.ChunkedFileWriter) {
 	info := jptm.Info()
+	archive, _ := jptm.DestinationArchive() // an entry in an archive has no file of its own to check, rename or date
 
 	// allow our usual state tracking mechanism to keep count of how many epilogues are running at any given instant, for perf diagnostics
 	pseudoId := common.NewPseudoChunkIDForWholeFile(info.Source)
@@ -331,18 +357,19 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 
 		// Check MD5 (but only if file was fully flushed and saved - else no point and may not have actualAsSaved hash anyway)
 		if jptm.IsLive() {
+			jptm.RecordContentMD5(md5OfFileAsWritten)
 			comparison := md5Comparer{
 				expected:         info.SrcHTTPHeaders.ContentMD5, // the MD5 that came back from Service when we enumerated the source
 				actualAsSaved:    md5OfFileAsWritten,
 				validationOption: jptm.MD5ValidationOption(),
-				logger:           jptm}
+				logger:           jptm.ComponentLogger(common.ELogComponent.Validation())}
 			err := comparison.Check()
 			if err != nil {
 				jptm.FailActiveDownload("Checking MD5 hash", err)
 			}
 
 			// check length if enabled (except for dev null and decompression case, where that's impossible)
-			if info.DestLengthValidation && info.Destination != common.Dev_Null && !jptm.ShouldDecompress() {
+			if info.DestLengthValidation && info.Destination != common.Dev_Null && !jptm.ShouldDecompress() && archive == nil {
 				fi, err := common.OSStat(info.getDownloadPath())
 
 				if err != nil {
@@ -356,7 +383,7 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 			// downloaded and not corrupt. Infact, post this point we should only log errors and
 			// not fail the transfer.
 			renameNecessary := !strings.EqualFold(info.getDownloadPath(), info.Destination) &&
-				!strings.EqualFold(info.Destination, common.Dev_Null)
+				!strings.EqualFold(info.Destination, common.Dev_Null) && archive == nil
 			if err == nil && renameNecessary {
 				renameErr := os.Rename(info.getDownloadPath(), info.Destination)
 				if renameErr != nil {
@@ -373,16 +400,15 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 		dl.Epilogue() // it can release resources here
 	}
 
-	// Preserve modified time
-	if jptm.IsLive() {
-		// TODO: the old version of this code did NOT consider it an error to be unable to set the modification date/time
-		// TODO: ...So I have preserved that behavior here.
-		// TODO: question: But is that correct?
+	// Preserve modified time (which, in an archive, is already in the entry's header)
+	if jptm.IsLive() && archive == nil {
+		// Being unable to set the modification time is only a warning. The content has arrived intact, so the transfer
+		// has still succeeded. Note that the file system may round the time, e.g. to 2 seconds on FAT
 		lastModifiedTime, preserveLastModifiedTime := jptm.PreserveLastModifiedTime()
 		if preserveLastModifiedTime && !info.PreserveSMBInfo {
 			err := os.Chtimes(jptm.Info().Destination, lastModifiedTime, lastModifiedTime)
 			if err != nil {
-				jptm.LogError(info.Destination, "Changing Modified Time ", err)
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Could not preserve last modified time: "+err.Error())
 				// do NOT return, since final status and cleanup logging still to come
 			} else {
 				jptm.Log(pipeline.LogInfo, fmt.Sprintf(" Preserved Modified Time for %s", info.Destination))
@@ -405,8 +431,10 @@ func commonDownloaderCompletion(jptm IJobPartTransferMgr, info TransferInfo, ent
 		if jptm.ShouldLog(pipeline.LogDebug) {
 			jptm.Log(pipeline.LogDebug, " Finalizing Transfer Cancellation/Failure")
 		}
-		// for files only, cleanup local file if applicable
-		if entityType == entityType.File() && jptm.IsDeadInflight() && jptm.HoldsDestinationLock() {
+		// for files only, cleanup local file if applicable.
+		// A failed entry in an archive can't be taken back, so it stays there, padded to its full size
+		archive, _ := jptm.DestinationArchive()
+		if entityType == entityType.File() && jptm.IsDeadInflight() && jptm.HoldsDestinationLock() && archive == nil {
 			jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "Deleting incomplete destination file")
 
 			// the file created locally should be deleted
@@ -21,6 +21,8 @@
 package ste
 
 import (
+	"archive/tar"
+
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
@@ -39,6 +41,11 @@ func remoteToLocal_folder(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer p
 		return
 	}
 
+	if archive, entryName := jptm.DestinationArchive(); archive != nil {
+		remoteToLocal_folderInArchive(jptm, archive, entryName)
+		return
+	}
+
 	dl, ok := df().(folderDownloader)
 	if !ok {
 		jptm.LogDownloadError(info.Source, info.Destination, "downloader implementation does not support folders", 0)
@@ -71,3 +78,21 @@ func remoteToLocal_folder(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer p
 	commonDownloaderCompletion(jptm, info, common.EEntityType.Folder()) // for consistency, always run the standard epilogue
 
 }
+
+// adds a folder's entry to the job's destination archive. Entries don't carry a folder's properties, so the downloader
+// has nothing to do. The root folder is the archive itself, and has no entry
+func remoteToLocal_folderInArchive(jptm IJobPartTransferMgr, archive *destinationArchive, entryName string) {
+	if entryName != "" {
+		jptm.SetDestinationIsModified()
+		err := archive.AddEntry(jptm.Context(), &tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     entryName + "/",
+			Mode:     0755,
+			ModTime:  jptm.LastModifiedTime(),
+		})
+		if err != nil {
+			jptm.FailActiveDownload("adding folder to archive", err)
+		}
+	}
+	commonDownloaderCompletion(jptm, jptm.Info(), common.EEntityType.Folder())
+}
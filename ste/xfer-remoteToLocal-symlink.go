@@ -0,0 +1,146 @@
+package ste
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// general-purpose "any remote persistence location" to local, for blobs that stand for symlinks (see blobSymlinkSender).
+// The link is recreated from the target recorded in the blob's metadata, so nothing is downloaded.
+func remoteToLocal_symlink(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer, df downloaderFactory) {
+
+	info := jptm.Info()
+
+	// Perform initial checks
+	// If the transfer was cancelled, then report transfer as done
+	if jptm.WasCanceled() {
+		/* This is the earliest we detect that jptm was cancelled, before we go to destination */
+		jptm.SetStatus(common.ETransferStatus.Cancelled())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	linkTarget, err := common.DecodeSymlinkTarget(info.SrcMetadata[common.POSIXSymlinkTargetMeta])
+	if err == nil && linkTarget == "" {
+		err = errors.New("the blob has no " + common.POSIXSymlinkTargetMeta + " metadata")
+	}
+	if err != nil {
+		jptm.LogDownloadError(info.Source, info.Destination, "Couldn't read symlink target. "+err.Error(), 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	if strings.EqualFold(info.Destination, common.Dev_Null) {
+		commonDownloaderCompletion(jptm, info, common.EEntityType.Symlink()) // nothing to create
+		return
+	}
+
+	if archive, entryName := jptm.DestinationArchive(); archive != nil {
+		jptm.SetDestinationIsModified()
+		err = archive.AddEntry(jptm.Context(), &tar.Header{
+			Typeflag: tar.TypeSymlink,
+			Name:     entryName,
+			Linkname: linkTarget,
+			Mode:     0777,
+			ModTime:  jptm.LastModifiedTime(),
+		})
+		if err != nil {
+			jptm.FailActiveDownload("adding symlink to archive", err)
+		}
+		commonDownloaderCompletion(jptm, info, common.EEntityType.Symlink())
+		return
+	}
+
+	// The target comes from the blob's metadata, which whoever wrote the blob could set to anything. So don't plant a link
+	// that leads out of the destination
+	if err = checkSymlinkTarget(linkTarget, info.Destination, jptm.GetDestinationRoot()); err != nil {
+		jptm.LogDownloadError(info.Source, info.Destination, "Refusing to create symlink. "+err.Error(), 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	// Lstat, rather than stat, so that an existing link is found even when its own target does not exist
+	dstProps, err := os.Lstat(info.Destination)
+	if err == nil {
+		if jptm.GetOverwriteOption() != common.EOverwriteOption.True() {
+			shouldOverwrite := false
+			if jptm.GetOverwriteOption() == common.EOverwriteOption.Prompt() {
+				shouldOverwrite = jptm.GetOverwritePrompter().ShouldOverwrite(info.Destination, common.EEntityType.File())
+			} else if jptm.GetOverwriteOption() == common.EOverwriteOption.IfSourceNewer() {
+				shouldOverwrite = jptm.LastModifiedTime().After(dstProps.ModTime())
+			}
+
+			if !shouldOverwrite {
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "File already exists, so will be skipped")
+				jptm.SetStatus(common.ETransferStatus.SkippedEntityAlreadyExists())
+				jptm.ReportTransferDone()
+				return
+			}
+		}
+	}
+
+	err = jptm.WaitUntilLockDestination(jptm.Context())
+	if err != nil {
+		jptm.LogDownloadError(info.Source, info.Destination, err.Error(), 0)
+		jptm.SetStatus(common.ETransferStatus.Failed())
+		jptm.ReportTransferDone()
+		return
+	}
+
+	jptm.SetDestinationIsModified()
+	err = common.CreateParentDirectoryIfNotExist(info.Destination, jptm.GetFolderCreationTracker())
+	if err == nil && dstProps != nil {
+		err = os.Remove(info.Destination) // a symlink can't be created over what is there already
+	}
+	if err == nil {
+		err = os.Symlink(linkTarget, info.Destination)
+	}
+	if err != nil {
+		jptm.FailActiveDownload("creating symlink", err)
+	}
+
+	commonDownloaderCompletion(jptm, info, common.EEntityType.Symlink()) // for consistency, always run the standard epilogue
+}
+
+// checkSymlinkTarget returns an error if a link at linkPath, to target, would lead outside of the destination root.
+// Absolute targets are refused, as are relative ones that climb out of the root. A ".." is only allowed at the start of
+// the target, because one that follows a name is resolved from wherever that name leads, which may itself be a link.
+// Since every link is checked like this, following any chain of them can't leave the root either.
+func checkSymlinkTarget(target, linkPath, destinationRoot string) error {
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") || strings.HasPrefix(target, `\`) || filepath.VolumeName(target) != "" {
+		return fmt.Errorf("its target %q is an absolute path", target)
+	}
+
+	seenName := false
+	for _, element := range strings.FieldsFunc(target, func(r rune) bool { return r == '/' || r == os.PathSeparator }) {
+		switch {
+		case element == "..":
+			if seenName {
+				return fmt.Errorf("its target %q has a '..' after a name", target)
+			}
+		case element != ".":
+			seenName = true
+		}
+	}
+
+	root := filepath.Clean(common.ToShortPath(destinationRoot))
+	linkPath = filepath.Clean(common.ToShortPath(linkPath))
+	if root == linkPath {
+		// a single blob was downloaded, so the link can only lead to what is beside it
+		root = filepath.Dir(root)
+	}
+	rel, err := filepath.Rel(root, filepath.Join(filepath.Dir(linkPath), filepath.FromSlash(target)))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return fmt.Errorf("its target %q is outside of the destination %s", target, root)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// snapshotSource takes a snapshot of the source blob and points the rest of the transfer at it, so that what is copied
+// can't be changed by anyone writing to the blob meanwhile. The snapshot is deleted when the transfer is done, whether
+// or not it worked. A source that is already a snapshot, or a version, can't change, so it is used as it is.
+func snapshotSource(jptm IJobPartTransferMgr) error {
+	u, err := url.Parse(jptm.Info().Source)
+	if err != nil {
+		return err
+	}
+	if parts := azblob.NewBlobURLParts(*u); parts.Snapshot != "" || parts.VersionID != "" {
+		return nil
+	}
+	blobURL := azblob.NewBlobURL(*u, jptm.SourceProviderPipeline())
+
+	cpk := azblob.ClientProvidedKeyOptions{}
+	if jptm.IsSourceEncrypted() {
+		cpk = common.ToClientProvidedKeyOptions(jptm.CpkInfo(), jptm.CpkScopeInfo())
+	}
+	resp, err := blobURL.CreateSnapshot(jptm.Context(), nil, azblob.BlobAccessConditions{}, cpk)
+	if err != nil {
+		return err
+	}
+	snapshot := resp.Snapshot()
+	if jptm.ShouldLog(pipeline.LogInfo) {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "Copying from snapshot "+snapshot+" of the source")
+	}
+
+	jptm.UseSourceSnapshot(snapshot, func() {
+		// the transfer's own context is cancelled by now, so the deletion gets one of its own
+		deletionContext, cancelFn := context.WithTimeout(context.WithValue(context.Background(), ServiceAPIVersionOverride, DefaultServiceApiVersion), 2*time.Minute)
+		defer cancelFn()
+		if _, err := blobURL.WithSnapshot(snapshot).Delete(deletionContext, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{}); err != nil {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Could not delete snapshot "+snapshot+" of the source, which must be deleted by hand: "+err.Error())
+		}
+	})
+	return nil
+}
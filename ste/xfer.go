@@ -38,6 +38,17 @@ const UploadRetryDelay = time.Second * 1
 const UploadMaxRetryDelay = time.Second * 60
 
 var UploadTryTimeout = time.Minute * 15
+
+// RequestRetryOptions is the retry policy applied to each request that a transfer makes (e.g. for one chunk of a file),
+// as opposed to the transfer as a whole. TryTimeout is not set here, since it comes from UploadTryTimeout.
+// Azure Files pipelines don't support Multiplier or NoJitter, and always use the default values of those. They never honor
+// Retry-After either, whatever IgnoreRetryAfter says.
+var RequestRetryOptions = XferRetryOptions{
+	Policy:        RetryPolicyExponential,
+	MaxTries:      UploadMaxTries,
+	RetryDelay:    UploadRetryDelay,
+	MaxRetryDelay: UploadMaxRetryDelay,
+}
 var ADLSFlushThreshold uint32 = 7500 // The # of blocks to flush at a time-- Implemented only for CI.
 
 // download related
@@ -55,6 +66,9 @@ const PacerTimeToWaitInMs = 50
 // Sync.Once is used so we only log a CPK error once and prevent gumming up stdout
 var cpkAccessFailureLogGLCM sync.Once
 
+// Likewise, the explanation of why archived blobs can't be copied is only given once
+var archivedSourceFailureLogGLCM sync.Once
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // These types are define the STE Coordinator
@@ -78,8 +92,15 @@ func parameterizeSend(targetFunction newJobXferWithSenderFactory, sf senderFacto
 	}
 }
 
+// Takes the metadata-only transfer function, and makes it ready to use with a specific type of source info provider
+func parameterizeMetadataOnly(sipf sourceInfoProviderFactory) newJobXfer {
+	return func(jptm IJobPartTransferMgr, pipeline pipeline.Pipeline, pacer pacer) {
+		MetadataOnly(jptm, pipeline, sipf)
+	}
+}
+
 // the xfer factory is generated based on the type of source and destination
-func computeJobXfer(fromTo common.FromTo, blobType common.BlobType) newJobXfer {
+func computeJobXfer(fromTo common.FromTo, blobType common.BlobType, metadataOnly bool) newJobXfer {
 
 	const blobFSNotS2S = "blobFS not supported as S2S source"
 
@@ -158,6 +179,9 @@ func computeJobXfer(fromTo common.FromTo, blobType common.BlobType) newJobXfer {
 	case common.EFromTo.BlobNone(), common.EFromTo.BlobFSNone(), common.EFromTo.FileNone():
 		return SetProperties
 	default:
+		if metadataOnly {
+			return parameterizeMetadataOnly(getSipFactory(fromTo.From()))
+		}
 		if fromTo.IsDownload() {
 			return parameterizeDownload(remoteToLocal, getDownloader(fromTo.From()))
 		} else {
@@ -267,10 +267,19 @@ func prepareRequestForServiceLogging(request pipeline.Request) *http.Request {
 			}
 		}
 	}
+
+	// The customer provided key (CPK) is the secret that encrypts the data, so it must never be logged
+	if exist, key := doesHeaderExistCaseInsensitive(req.Header, xMsEncryptionKeyHeader); exist {
+		if req == request {
+			req = request.Copy()
+		}
+		req.Header.Set(key, "REDACTED")
+	}
 	return req.Request
 }
 
 const xMsCopySourceHeader = "x-ms-copy-source"
+const xMsEncryptionKeyHeader = "x-ms-encryption-key"
 
 func doesHeaderExistCaseInsensitive(header http.Header, key string) (bool, string) {
 	for keyInHeader := range header {
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
@@ -56,6 +57,18 @@ type XferRetryOptions struct {
 	// If you specify 0, then you must also specify 0 for RetryDelay.
 	MaxRetryDelay time.Duration
 
+	// Multiplier specifies how much the delay grows with each retry, under the exponential policy (0=default, which is 2).
+	// The n-th retry waits RetryDelay * (1 + Multiplier + ... + Multiplier^(n-1)), so a value of 1 makes the delay grow linearly.
+	Multiplier float64
+
+	// NoJitter turns off the random variation (between 0.8x and 1.3x) that is otherwise applied to each delay, so that
+	// concurrent operations that failed together don't all retry at the same moment.
+	NoJitter bool
+
+	// IgnoreRetryAfter stops the policy honoring the Retry-After header of a throttled (503 or 429) response. Otherwise, the
+	// next try waits as long as the header asks, up to MaxRetryDelay, instead of the delay that the policy would have used.
+	IgnoreRetryAfter bool
+
 	// RetryReadsFromSecondaryHost specifies whether the retry policy should retry a read operation against another host.
 	// If RetryReadsFromSecondaryHost is "" (the default) then operations are not retried against another host.
 	// NOTE: Before setting this field, make sure you understand the issues around reading stale & potentially-inconsistent
@@ -84,6 +97,9 @@ func (o XferRetryOptions) defaults() XferRetryOptions {
 	if (o.RetryDelay == 0 && o.MaxRetryDelay != 0) || (o.RetryDelay != 0 && o.MaxRetryDelay == 0) {
 		panic("Both RetryDelay and MaxRetryDelay must be 0 or neither can be 0")
 	}
+	if o.Multiplier != 0 && o.Multiplier < 1 {
+		panic("Multiplier must be 0 or >= 1")
+	}
 
 	IfDefault := func(current *time.Duration, desired time.Duration) {
 		if *current == time.Duration(0) {
@@ -95,6 +111,9 @@ func (o XferRetryOptions) defaults() XferRetryOptions {
 	if o.MaxTries == 0 {
 		o.MaxTries = 4
 	}
+	if o.Multiplier == 0 {
+		o.Multiplier = 2
+	}
 	switch o.Policy {
 	case RetryPolicyExponential:
 		IfDefault(&o.TryTimeout, 1*time.Minute)
@@ -110,30 +129,61 @@ func (o XferRetryOptions) defaults() XferRetryOptions {
 }
 
 func (o XferRetryOptions) calcDelay(try int32) time.Duration { // try is >=1; never 0
-	pow := func(number int64, exponent int32) int64 { // pow is nested helper function
-		var result int64 = 1
-		for n := int32(0); n < exponent; n++ {
-			result *= number
-		}
-		return result
-	}
-
-	delay := time.Duration(0)
+	delay := float64(0) // as a float, so that large numbers of tries can't overflow it
 	switch o.Policy {
 	case RetryPolicyExponential:
-		delay = time.Duration(pow(2, try-1)-1) * o.RetryDelay
+		// RetryDelay * (1 + m + m^2 + ... + m^(try-2)), which, for the default multiplier of 2, is ((2 ^ (try-1)) - 1) * RetryDelay
+		step := float64(o.RetryDelay)
+		for n := int32(1); n < try; n++ {
+			delay += step
+			step *= o.Multiplier
+		}
 
 	case RetryPolicyFixed:
 		if try > 1 { // Any try after the 1st uses the fixed delay
-			delay = o.RetryDelay
+			delay = float64(o.RetryDelay)
+		}
+	}
+
+	if !o.NoJitter {
+		// Introduce some jitter:  [0.0, 1.0) / 2 = [0.0, 0.5) + 0.8 = [0.8, 1.3)
+		delay *= float64(rand.Float32()/2 + 0.8) // NOTE: We want math/rand; not crypto/rand
+	}
+	if delay > float64(o.MaxRetryDelay) {
+		return o.MaxRetryDelay
+	}
+	return time.Duration(delay)
+}
+
+// retryAfterDelay is how long a throttled response asked, with its Retry-After header, for the client to wait before
+// trying again. The header holds either a number of seconds or an HTTP date. It is 0 if the response wasn't throttled,
+// has no valid header, or if IgnoreRetryAfter is set. It is capped at MaxRetryDelay, so that the service can't stall a
+// request for longer than the policy itself ever would
+func (o XferRetryOptions) retryAfterDelay(response pipeline.Response, now time.Time) time.Duration {
+	if o.IgnoreRetryAfter || response == nil || response.Response() == nil {
+		return 0
+	}
+	resp := response.Response()
+	if resp.StatusCode != http.StatusServiceUnavailable && resp.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+
+	value := resp.Header.Get("Retry-After")
+	delay := time.Duration(0)
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds > int64(o.MaxRetryDelay/time.Second) {
+			return o.MaxRetryDelay // so that a huge number of seconds can't overflow the duration
 		}
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = date.Sub(now)
 	}
 
-	// Introduce some jitter:  [0.0, 1.0) / 2 = [0.0, 0.5) + 0.8 = [0.8, 1.3)
-	// For casts and rounding - be careful, as per https://github.com/golang/go/issues/20757
-	delay = time.Duration(float32(delay) * (rand.Float32()/2 + 0.8)) // NOTE: We want math/rand; not crypto/rand
+	if delay <= 0 {
+		return 0
+	}
 	if delay > o.MaxRetryDelay {
-		delay = o.MaxRetryDelay
+		return o.MaxRetryDelay
 	}
 	return delay
 }
@@ -157,8 +207,13 @@ func NewBFSXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 			//    For a primary wait ((2 ^ primaryTries - 1) * delay * random(0.8, 1.2)
 			//    If secondary gets a 404, don't fail, retry but future retries are only against the primary
 			//    When retrying against a secondary, ignore the retry count and wait (.1 second * random(0.8, 1.2))
+			// If the primary was throttled, and said how long to wait with Retry-After, the next try of it waits that long instead
+			retryAfter := time.Duration(0)
 			for try := int32(1); try <= o.MaxTries; try++ {
 				logf("\n=====> Try=%d\n", try)
+				if try > 1 {
+					countRetry(ctx)
+				}
 
 				// Determine which endpoint to try. It's primary if there is no secondary or if it is an add # attempt.
 				tryingPrimary := !considerSecondary || (try%2 == 1)
@@ -166,6 +221,10 @@ func NewBFSXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 				if tryingPrimary {
 					primaryTry++
 					delay := o.calcDelay(primaryTry)
+					if retryAfter > 0 {
+						delay = retryAfter
+						retryAfter = 0
+					}
 					logf("Primary try=%d, Delay=%v\n", primaryTry, delay)
 					time.Sleep(delay) // The 1st try returns 0 delay
 				} else {
@@ -281,6 +340,9 @@ func NewBFSXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 					}
 					break // Don't retry
 				}
+				if tryingPrimary {
+					retryAfter = o.retryAfterDelay(response, time.Now())
+				}
 				if response.Response() != nil {
 					// If we're going to retry and we got a previous response, then flush its body to avoid leaking its TCP connection
 					io.Copy(ioutil.Discard, response.Response().Body)
@@ -294,6 +356,21 @@ func NewBFSXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 	})
 }
 
+var retryCountContextKey = contextKey{"retryCount"}
+
+// withRetryCounter returns a context that contains a counter, which the retry policies increment each time they retry
+// a request made with that context (or one derived from it). It lets us count the retries used by each transfer.
+func withRetryCounter(ctx context.Context, counter *int32) context.Context {
+	return context.WithValue(ctx, retryCountContextKey, counter)
+}
+
+// countRetry records, in the context's retry counter (if any), that a request is being retried
+func countRetry(ctx context.Context) {
+	if counter, ok := ctx.Value(retryCountContextKey).(*int32); ok {
+		atomic.AddInt32(counter, 1)
+	}
+}
+
 var retrySuppressionContextKey = contextKey{"retrySuppression"}
 
 // withNoRetryForBlob returns a context that contains a marker to say we don't want any retries to happen
@@ -324,12 +401,17 @@ func NewBlobXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 			//    For a primary wait ((2 ^ primaryTries - 1) * delay * random(0.8, 1.2)
 			//    If secondary gets a 404, don't fail, retry but future retries are only against the primary
 			//    When retrying against a secondary, ignore the retry count and wait (.1 second * random(0.8, 1.2))
+			// If the primary was throttled, and said how long to wait with Retry-After, the next try of it waits that long instead
+			retryAfter := time.Duration(0)
 			maxTries := o.MaxTries
 			if _, ok := ctx.Value(retrySuppressionContextKey).(struct{}); ok {
 				maxTries = 1 // retries are suppressed by the context
 			}
 			for try := int32(1); try <= maxTries; try++ {
 				logf("\n=====> Try=%d\n", try)
+				if try > 1 {
+					countRetry(ctx)
+				}
 
 				// Determine which endpoint to try. It's primary if there is no secondary or if it is an add # attempt.
 				tryingPrimary := !considerSecondary || (try%2 == 1)
@@ -337,6 +419,10 @@ func NewBlobXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 				if tryingPrimary {
 					primaryTry++
 					delay := o.calcDelay(primaryTry)
+					if retryAfter > 0 {
+						delay = retryAfter
+						retryAfter = 0
+					}
 					logf("Primary try=%d, Delay=%f s\n", primaryTry, delay.Seconds())
 					time.Sleep(delay) // The 1st try returns 0 delay
 				} else {
@@ -453,6 +539,9 @@ func NewBlobXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 					}
 					break // Don't retry
 				}
+				if tryingPrimary {
+					retryAfter = o.retryAfterDelay(response, time.Now())
+				}
 				if response.Response() != nil {
 					// If we're going to retry and we got a previous response, then flush its body to avoid leaking its TCP connection
 					io.Copy(ioutil.Discard, response.Response().Body)
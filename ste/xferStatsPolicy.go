@@ -42,10 +42,11 @@ type PipelineNetworkStats struct {
 	atomicStartSeconds         int64
 	nocopy                     common.NoCopy
 	tunerInterface             ConcurrencyTuner
+	throughputTuner            retryNotificationReceiver // nil unless the throughput is being auto-tuned
 }
 
-func newPipelineNetworkStats(tunerInterface ConcurrencyTuner) *PipelineNetworkStats {
-	s := &PipelineNetworkStats{tunerInterface: tunerInterface}
+func newPipelineNetworkStats(tunerInterface ConcurrencyTuner, throughputTuner retryNotificationReceiver) *PipelineNetworkStats {
+	s := &PipelineNetworkStats{tunerInterface: tunerInterface, throughputTuner: throughputTuner}
 	tunerWillCallUs := tunerInterface.RequestCallbackWhenStable(s.start) // we want to start gather stats after the tuner has reached a stable value. No point in gathering them earlier
 	if !tunerWillCallUs {
 		// assume tuner is inactive, and start ourselves now
@@ -186,7 +187,10 @@ func (p *xferStatsPolicy) Do(ctx context.Context, request pipeline.Request) (pip
 			// TODO should we also count status 500?  It is mentioned here as timeout:https://docs.microsoft.com/en-us/azure/storage/common/storage-scalability-targets
 			if rr := resp.Response(); rr != nil && rr.StatusCode == http.StatusServiceUnavailable {
 				p.stats.tunerInterface.recordRetry() // always tell the tuner
-				if p.stats.IsStarted() {             // but only count it here, if we have started
+				if p.stats.throughputTuner != nil {
+					p.stats.throughputTuner.RetryCallback() // and the throughput tuner, which backs off in response
+				}
+				if p.stats.IsStarted() { // but only count it here, if we have started
 					// To find out why the server was busy we need to look at the response
 					responseBodyText := transparentlyReadBody(rr)
 					p.stats.recordRetry(responseBodyText)
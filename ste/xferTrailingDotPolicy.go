@@ -0,0 +1,80 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+)
+
+// trailingDotServiceVersion is the first version of the Files service that honours x-ms-allow-trailing-dot
+const trailingDotServiceVersion = "2022-11-02"
+
+type allowTrailingDot struct{}
+
+// AllowTrailingDot is the key of a context value that, when true, lets the requests made with that context keep the
+// trailing dots in their names (see NewTrailingDotPolicyFactory). It's set for the jobs whose plan allows trailing dots
+var AllowTrailingDot = allowTrailingDot{}
+
+// hasTrailingDotSegment says whether any segment of the path ends with a dot. Segments that are nothing but dots
+// are left alone, since they are navigation rather than names
+func hasTrailingDotSegment(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasSuffix(segment, ".") && strings.Trim(segment, ".") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTrailingDotPolicyFactory stops the Files service from silently stripping trailing dots. Without
+// x-ms-allow-trailing-dot, "file." and "dir./child" are treated as "file" and "dir/child".
+// It only acts on requests whose context has AllowTrailingDot set, so other jobs and commands are unaffected.
+// Even then, the header is only sent when a name in the request actually ends with a dot, because it needs a newer service
+// version, and every other request is left exactly as it was
+func NewTrailingDotPolicyFactory() pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			if allowed, _ := ctx.Value(AllowTrailingDot).(bool); !allowed {
+				return next.Do(ctx, request)
+			}
+			needsVersion := false
+			if hasTrailingDotSegment(request.URL.Path) {
+				request.Header.Set("x-ms-allow-trailing-dot", "true")
+				needsVersion = true
+			}
+			if copySource := request.Header.Get("x-ms-copy-source"); copySource != "" {
+				if u, err := url.Parse(copySource); err == nil && hasTrailingDotSegment(u.Path) {
+					request.Header.Set("x-ms-source-allow-trailing-dot", "true")
+					needsVersion = true
+				}
+			}
+			// versions are dates, so they compare as strings
+			if needsVersion && request.Header.Get("x-ms-version") < trailingDotServiceVersion {
+				request.Header.Set("x-ms-version", trailingDotServiceVersion)
+			}
+			return next.Do(ctx, request)
+		}
+	})
+}
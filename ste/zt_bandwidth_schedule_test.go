@@ -0,0 +1,101 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type bandwidthScheduleSuite struct{}
+
+var _ = chk.Suite(&bandwidthScheduleSuite{})
+
+// recordingPacer passes on each new target that it is given, so that a test can see when the target changes
+type recordingPacer struct {
+	targets chan int64
+}
+
+func (p *recordingPacer) RequestTrafficAllocation(_ context.Context, _ int64) error { return nil }
+func (p *recordingPacer) UpdateTargetBytesPerSecond(newTarget int64)                { p.targets <- newTarget }
+func (p *recordingPacer) UndoRequest(_ int64)                                       {}
+func (p *recordingPacer) Close() error                                              { return nil }
+func (p *recordingPacer) GetTotalTraffic() int64                                    { return 0 }
+
+func (s *bandwidthScheduleSuite) TestScheduleGivesCapByTimeOfDay(c *chk.C) {
+	schedule, err := ParseBandwidthSchedule("09:00-17:00=50, 17:00-09:00=unlimited")
+	c.Assert(err, chk.IsNil)
+	at := func(hour, min int) time.Time { return time.Date(2021, 3, 1, hour, min, 0, 0, time.Local) }
+
+	const fiftyMbps = 50 * 1000 * 1000 / 8
+	c.Assert(schedule.BytesPerSecondAt(at(9, 0), 123), chk.Equals, int64(fiftyMbps))
+	c.Assert(schedule.BytesPerSecondAt(at(16, 59), 123), chk.Equals, int64(fiftyMbps))
+	c.Assert(schedule.BytesPerSecondAt(at(17, 0), 123), chk.Equals, int64(0)) // unlimited
+	c.Assert(schedule.BytesPerSecondAt(at(2, 30), 123), chk.Equals, int64(0)) // the window wraps past midnight
+
+	// outside all windows, the rate is the one that applies without a schedule
+	schedule, err = ParseBandwidthSchedule("22:00-06:00=10")
+	c.Assert(err, chk.IsNil)
+	c.Assert(schedule.BytesPerSecondAt(at(12, 0), 123), chk.Equals, int64(123))
+	c.Assert(schedule.BytesPerSecondAt(at(23, 0), 123), chk.Equals, int64(10*1000*1000/8))
+}
+
+func (s *bandwidthScheduleSuite) TestMalformedScheduleIsRejected(c *chk.C) {
+	for _, raw := range []string{"", "09:00-17:00", "09:00=50", "9am-5pm=50", "09:00-25:00=50", "09:00-17:00=0", "09:00-17:00=fast"} {
+		_, err := ParseBandwidthSchedule(raw)
+		c.Assert(err, chk.NotNil, chk.Commentf(raw))
+	}
+}
+
+func (s *bandwidthScheduleSuite) TestScheduleChangesCapAcrossBoundary(c *chk.C) {
+	schedule, err := ParseBandwidthSchedule("09:00-17:00=50,17:00-09:00=unlimited")
+	c.Assert(err, chk.IsNil)
+
+	// the ticks are the clock, so the test can move it on as fast as it likes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := &recordingPacer{targets: make(chan int64)}
+	ticks := make(chan time.Time)
+	start := time.Date(2021, 3, 1, 16, 58, 0, 0, time.Local)
+	go schedule.Apply(ctx, p, 0, start, ticks, discardingLogger{})
+
+	nextTarget := func() int64 {
+		select {
+		case target := <-p.targets:
+			return target
+		case <-time.After(5 * time.Second):
+			c.Fatal("the cap was not changed")
+			return -1
+		}
+	}
+
+	// still in business hours, so nothing changes, until the clock passes 17:00
+	ticks <- start.Add(time.Minute)
+	ticks <- start.Add(2 * time.Minute)
+	c.Assert(nextTarget(), chk.Equals, int64(0))
+
+	// through the night, and back into business hours the next morning
+	ticks <- start.Add(10 * time.Hour)
+	ticks <- start.Add(16*time.Hour + 2*time.Minute)
+	c.Assert(nextTarget(), chk.Equals, int64(50*1000*1000/8))
+}
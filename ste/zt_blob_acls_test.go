@@ -0,0 +1,39 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type blobACLsSuite struct{}
+
+var _ = chk.Suite(&blobACLsSuite{})
+
+func (s *blobACLsSuite) TestNamedPrincipalsAreFoundInAccessAndDefaultACLs(c *chk.C) {
+	acl := "user::rwx,user:1111:r-x,group::r-x,group:2222:rwx,mask::rwx,other::---," +
+		"default:user::rwx,default:user:1111:r-x,default:user:3333:--x,default:group::r-x,default:mask::r-x,default:other::---"
+	c.Assert(namedPrincipals(acl), chk.DeepEquals, []string{"user:1111", "group:2222", "user:3333"})
+
+	// the owning user and group, the mask and others are not named
+	c.Assert(namedPrincipals("user::rwx,group::r-x,other::r--"), chk.HasLen, 0)
+	c.Assert(namedPrincipals(""), chk.HasLen, 0)
+}
@@ -170,8 +170,33 @@ func (s *concurrencyTunerSuite) TestConcurrencyTuner__HighBandwidthWorkaround_St
 	s.runTest(c, steps, s.noMax(), true, true)
 }
 
+func (s *concurrencyTunerSuite) TestConcurrencyTuner_BacksOffOnRetriesWhenAskedTo(c *chk.C) {
+	const throttledAbove = 64
+
+	// a service that keeps getting faster as concurrency goes up, but responds with 503s above a certain level
+	settle := func(backOffOnRetries bool) int {
+		t := NewAutoConcurrencyTuner(4, s.noMax(), false, backOffOnRetries)
+		observedMbps := -1
+		for i := 0; i < 100; i++ {
+			conc, reason := t.GetRecommendedConcurrency(observedMbps, false)
+			if reason == concurrencyReasonAtOptimum || reason == concurrencyReasonHitMax || reason == concurrencyReasonFinished {
+				return conc
+			}
+			if conc > throttledAbove {
+				t.recordRetry()
+			}
+			observedMbps = conc * 100
+		}
+		c.Fatal("tuner did not settle")
+		return 0
+	}
+
+	c.Assert(settle(true) <= throttledAbove, chk.Equals, true)
+	c.Assert(settle(false) > throttledAbove, chk.Equals, true) // without being asked to, it ignores the 503s
+}
+
 func (s *concurrencyTunerSuite) runTest(c *chk.C, steps []tunerStep, maxConcurrency int, isBenchmarking bool, simulateRetries bool) {
-	t := NewAutoConcurrencyTuner(4, maxConcurrency, isBenchmarking)
+	t := NewAutoConcurrencyTuner(4, maxConcurrency, isBenchmarking, false)
 	observedMbps := -1 // there's no observation at first
 	observedHighCpu := false
 
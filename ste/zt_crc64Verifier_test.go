@@ -0,0 +1,162 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type crc64VerifierSuite struct{}
+
+var _ = chk.Suite(&crc64VerifierSuite{})
+
+// crc64TestJptm is just enough of a transfer for a chunk func to run, recording how the transfer fails, if it does
+type crc64TestJptm struct {
+	IJobPartTransferMgr
+	verify     bool
+	failedWith error
+}
+
+func (j *crc64TestJptm) Context() context.Context                         { return context.Background() }
+func (j *crc64TestJptm) VerifyCrc64() bool                                { return j.verify }
+func (j *crc64TestJptm) WasCanceled() bool                                { return false }
+func (j *crc64TestJptm) OccupyAConnection()                               {}
+func (j *crc64TestJptm) ReleaseAConnection()                              {}
+func (j *crc64TestJptm) SetDestinationIsModified()                        {}
+func (j *crc64TestJptm) LogChunkStatus(common.ChunkID, common.WaitReason) {}
+func (j *crc64TestJptm) ReportChunkDone(common.ChunkID) (bool, uint32)    { return true, 1 }
+func (j *crc64TestJptm) FailActiveUpload(_ string, err error)             { j.failedWith = err }
+
+// crc64TestChunkReader is a chunk whose data is already in memory
+type crc64TestChunkReader struct {
+	common.SingleChunkReader
+	data []byte
+	*bytes.Reader
+}
+
+func newCrc64TestChunkReader(data []byte) *crc64TestChunkReader {
+	return &crc64TestChunkReader{data: data, Reader: bytes.NewReader(data)}
+}
+
+func (r *crc64TestChunkReader) Read(p []byte) (int, error) { return r.Reader.Read(p) }
+func (r *crc64TestChunkReader) Seek(offset int64, whence int) (int64, error) {
+	return r.Reader.Seek(offset, whence)
+}
+func (r *crc64TestChunkReader) Close() error              { return nil }
+func (r *crc64TestChunkReader) Length() int64             { return int64(len(r.data)) }
+func (r *crc64TestChunkReader) WriteBufferTo(h hash.Hash) { h.Write(r.data) }
+
+// crc64TestService reads what it's sent, like the service would, and answers with the CRC64 that it is told to
+type crc64TestService struct {
+	crc64 func(received []byte) []byte
+}
+
+func (s *crc64TestService) New(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.Policy {
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		received, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		header := http.Header{}
+		if crc := s.crc64(received); crc != nil {
+			header.Set("x-ms-content-crc64", base64.StdEncoding.EncodeToString(crc))
+		}
+		return pipeline.NewHTTPResponse(&http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    request.Request,
+		}), nil
+	})
+}
+
+func (s *crc64VerifierSuite) stageBlock(verify bool, serviceCrc64 func(received []byte) []byte) *crc64TestJptm {
+	jptm := &crc64TestJptm{verify: verify}
+	u, _ := url.Parse("https://account.blob.core.windows.net/container/blob")
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: &crc64TestService{crc64: serviceCrc64}})
+	uploader := &blockBlobUploader{blockBlobSenderBase: blockBlobSenderBase{
+		jptm:             jptm,
+		destBlockBlobURL: azblob.NewBlockBlobURL(*u, p),
+		pacer:            NewNullAutoPacer(),
+		blockIDs:         make([]string, 1),
+		muBlockIDs:       &sync.Mutex{},
+	}}
+
+	data := []byte("the data of the block, which is sent to the service")
+	id := common.NewChunkID("file", 0, int64(len(data)))
+	uploader.generatePutBlock(id, 0, newCrc64TestChunkReader(data))(0)
+	return jptm
+}
+
+func crc64AsServiceComputes(received []byte) []byte {
+	h := crc64.New(azureStorageCrc64Table)
+	h.Write(received)
+	crc := make([]byte, crc64.Size)
+	binary.LittleEndian.PutUint64(crc, h.Sum64())
+	return crc
+}
+
+func (s *crc64VerifierSuite) TestCrc64IsTheOneAzureStorageUses(c *chk.C) {
+	// the check value of CRC-64/NVME, which is what Azure Storage computes
+	crc := chunkCrc64(&crc64TestJptm{verify: true}, newCrc64TestChunkReader([]byte("123456789")))
+	c.Assert(binary.LittleEndian.Uint64(crc), chk.Equals, uint64(0xAE8B14860A799888))
+}
+
+func (s *crc64VerifierSuite) TestWrongCrc64FailsTheTransfer(c *chk.C) {
+	jptm := s.stageBlock(true, func(received []byte) []byte {
+		crc := crc64AsServiceComputes(received)
+		crc[0] ^= 0xff
+		return crc
+	})
+	c.Assert(jptm.failedWith, chk.NotNil)
+	c.Assert(errors.Is(jptm.failedWith, errCrc64Mismatch), chk.Equals, true)
+}
+
+func (s *crc64VerifierSuite) TestMatchingCrc64IsAccepted(c *chk.C) {
+	jptm := s.stageBlock(true, crc64AsServiceComputes)
+	c.Assert(jptm.failedWith, chk.IsNil)
+}
+
+func (s *crc64VerifierSuite) TestMissingCrc64IsAccepted(c *chk.C) {
+	jptm := s.stageBlock(true, func([]byte) []byte { return nil })
+	c.Assert(jptm.failedWith, chk.IsNil)
+}
+
+func (s *crc64VerifierSuite) TestWrongCrc64IsIgnoredUnlessVerifying(c *chk.C) {
+	jptm := s.stageBlock(false, func([]byte) []byte { return make([]byte, crc64.Size) })
+	c.Assert(jptm.failedWith, chk.IsNil)
+}
@@ -0,0 +1,175 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type destinationArchiveSuite struct{}
+
+var _ = chk.Suite(&destinationArchiveSuite{})
+
+// readArchive returns the content of each regular entry of the archive, and the names of all its entries in order
+func readArchive(c *chk.C, archivePath string, format common.ArchiveFormat) (map[string][]byte, []string) {
+	f, err := os.Open(archivePath)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+
+	var r io.Reader = f
+	if format == common.EArchiveFormat.TarGz() {
+		gz, err := gzip.NewReader(f)
+		c.Assert(err, chk.IsNil)
+		r = gz
+	}
+
+	contents := make(map[string][]byte)
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, chk.IsNil)
+		names = append(names, hdr.Name)
+		if hdr.Typeflag == tar.TypeReg {
+			data, err := ioutil.ReadAll(tr)
+			c.Assert(err, chk.IsNil)
+			contents[hdr.Name] = data
+		}
+	}
+	return contents, names
+}
+
+func (s *destinationArchiveSuite) TestConcurrentEntriesAreWrittenWhole(c *chk.C) {
+	for _, format := range []common.ArchiveFormat{common.EArchiveFormat.Tar(), common.EArchiveFormat.TarGz()} {
+		dir := c.MkDir()
+		archivePath := filepath.Join(dir, "sub", "backup.tar") // the folder is created with the archive
+		a := newDestinationArchive(archivePath, format)
+
+		// many transfers, each writing its content in several pieces, as the chunked file writer would
+		const fileCount = 20
+		expected := make(map[string][]byte)
+		var wg sync.WaitGroup
+		for i := 0; i < fileCount; i++ {
+			name := fmt.Sprintf("dir%d/file%d.txt", i%3, i)
+			content := bytes.Repeat([]byte{byte('a' + i)}, 1000*(i+1))
+			expected[name] = content
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w, err := a.OpenEntry(context.Background(), &tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0644})
+				c.Check(err, chk.IsNil)
+				for start := 0; start < len(content); start += 300 {
+					end := start + 300
+					if end > len(content) {
+						end = len(content)
+					}
+					_, err = w.Write(content[start:end])
+					c.Check(err, chk.IsNil)
+				}
+				c.Check(w.Close(), chk.IsNil)
+			}()
+		}
+		c.Assert(a.AddEntry(context.Background(), &tar.Header{Typeflag: tar.TypeDir, Name: "empty/", Mode: 0755}), chk.IsNil)
+		wg.Wait()
+		c.Assert(a.Close(), chk.IsNil)
+
+		contents, names := readArchive(c, archivePath, format)
+		c.Assert(names, chk.HasLen, fileCount+1)
+		c.Assert(contents, chk.DeepEquals, expected)
+	}
+}
+
+func (s *destinationArchiveSuite) TestFailedEntryIsPaddedAndNextOneFollows(c *chk.C) {
+	archivePath := filepath.Join(c.MkDir(), "backup.tar")
+	a := newDestinationArchive(archivePath, common.EArchiveFormat.Tar())
+
+	w, err := a.OpenEntry(context.Background(), &tar.Header{Typeflag: tar.TypeReg, Name: "failed.bin", Size: 5000, Mode: 0644})
+	c.Assert(err, chk.IsNil)
+	_, err = w.Write([]byte("part"))
+	c.Assert(err, chk.IsNil)
+
+	// the next transfer must wait for its turn, until the failed one closes its entry
+	next := make(chan error, 1)
+	go func() {
+		w2, err := a.OpenEntry(context.Background(), &tar.Header{Typeflag: tar.TypeReg, Name: "next.txt", Size: 4, Mode: 0644})
+		if err == nil {
+			_, err = w2.Write([]byte("next"))
+			if err == nil {
+				err = w2.Close()
+			}
+		}
+		next <- err
+	}()
+	select {
+	case <-next:
+		c.Fatal("second entry was opened while the first still had the archive")
+	default:
+	}
+
+	c.Assert(w.Close(), chk.IsNil)
+	c.Assert(<-next, chk.IsNil)
+	_, err = w.Write([]byte("late"))
+	c.Assert(err, chk.NotNil) // a chunk that arrives after the entry was closed mustn't corrupt the next one
+	c.Assert(a.Close(), chk.IsNil)
+
+	contents, names := readArchive(c, archivePath, common.EArchiveFormat.Tar())
+	c.Assert(names, chk.DeepEquals, []string{"failed.bin", "next.txt"})
+	c.Assert(contents["failed.bin"], chk.HasLen, 5000)
+	c.Assert(string(contents["failed.bin"][:4]), chk.Equals, "part")
+	c.Assert(contents["failed.bin"][4:], chk.DeepEquals, make([]byte, 4996))
+	c.Assert(string(contents["next.txt"]), chk.Equals, "next")
+}
+
+func (s *destinationArchiveSuite) TestWaitingForTurnHonorsCancellation(c *chk.C) {
+	a := newDestinationArchive(filepath.Join(c.MkDir(), "backup.tar"), common.EArchiveFormat.Tar())
+	w, err := a.OpenEntry(context.Background(), &tar.Header{Typeflag: tar.TypeReg, Name: "a.txt", Size: 0, Mode: 0644})
+	c.Assert(err, chk.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = a.OpenEntry(ctx, &tar.Header{Typeflag: tar.TypeReg, Name: "b.txt", Size: 0, Mode: 0644})
+	c.Assert(err, chk.Equals, context.Canceled)
+
+	c.Assert(w.Close(), chk.IsNil)
+	c.Assert(a.Close(), chk.IsNil)
+}
+
+func (s *destinationArchiveSuite) TestArchiveEntryName(c *chk.C) {
+	c.Assert(archiveEntryName("/dir/file.txt", "https://acct.blob.core.windows.net/c/dir/file.txt"), chk.Equals, "dir/file.txt")
+	c.Assert(archiveEntryName("", "https://acct.blob.core.windows.net/c/dir/single.txt?sv=1"), chk.Equals, "single.txt")
+	c.Assert(archiveEntryName("", ""), chk.Equals, "")
+}
@@ -0,0 +1,136 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type failureCategorySuite struct{}
+
+var _ = chk.Suite(&failureCategorySuite{})
+
+// failingTransport answers each request with the status, or fails it with the error, given for the name of its blob,
+// without going to the network
+type failingTransport struct {
+	statuses map[string]int
+	errs     map[string]error
+}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+	if err, ok := t.errs[name]; ok {
+		return nil, err
+	}
+	status := t.statuses[name]
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func (s *failureCategorySuite) TestErrorsAreCategorizedByKind(c *chk.C) {
+	transport := &failingTransport{
+		statuses: map[string]int{
+			"unauthorized": http.StatusUnauthorized,
+			"forbidden":    http.StatusForbidden,
+			"busy":         http.StatusServiceUnavailable,
+			"tooMany":      http.StatusTooManyRequests,
+			"missing":      http.StatusNotFound,
+			"changed":      http.StatusPreconditionFailed,
+			"broken":       http.StatusInternalServerError,
+		},
+		errs: map[string]error{
+			"refused": &url.Error{Op: "Get", URL: "https://fakeaccount.blob.core.windows.net", Err: syscall.ECONNREFUSED},
+		},
+	}
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()},
+		pipeline.Options{HTTPSender: newAzcopyHTTPClientFactory(&http.Client{Transport: transport})})
+	errorFor := func(name string) error {
+		u, err := url.Parse("https://fakeaccount.blob.core.windows.net/container/" + name)
+		c.Assert(err, chk.IsNil)
+		_, err = azblob.NewBlobURL(*u, p).GetProperties(context.Background(), azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+		c.Assert(err, chk.NotNil, chk.Commentf(name))
+		return err
+	}
+
+	expected := map[string]common.FailureCategory{
+		"unauthorized": common.EFailureCategory.Auth(),
+		"forbidden":    common.EFailureCategory.Auth(),
+		"busy":         common.EFailureCategory.Throttling(),
+		"tooMany":      common.EFailureCategory.Throttling(),
+		"missing":      common.EFailureCategory.NotFound(),
+		"changed":      common.EFailureCategory.Precondition(),
+		"broken":       common.EFailureCategory.Other(),
+		"refused":      common.EFailureCategory.Network(),
+	}
+	for name, category := range expected {
+		err := errorFor(name)
+		c.Assert(failureCategoryOf(err, common.ETransferStatus.Failed()), chk.Equals, category, chk.Commentf(name))
+		// senders wrap some of their errors, which doesn't change what kind they are
+		c.Assert(failureCategoryOf(fmt.Errorf("creating folder: %w", err), common.ETransferStatus.Failed()), chk.Equals, category, chk.Commentf(name))
+	}
+
+	// a transfer that failed with the status PreconditionFailed, e.g. on a 409 BlobAlreadyExists from --if-none-match=*,
+	// is a precondition failure whatever its status code
+	c.Assert(failureCategoryOf(errorFor("broken"), common.ETransferStatus.PreconditionFailed()), chk.Equals, common.EFailureCategory.Precondition())
+	c.Assert(failureCategoryOf(errMd5Mismatch, common.ETransferStatus.Failed()), chk.Equals, common.EFailureCategory.ChecksumMismatch())
+	c.Assert(failureCategoryOf(fmt.Errorf("%w (sent 1, received 2)", errCrc64Mismatch), common.ETransferStatus.Failed()), chk.Equals, common.EFailureCategory.ChecksumMismatch())
+	c.Assert(failureCategoryOf(errors.New("the source file could not be opened"), common.ETransferStatus.Failed()), chk.Equals, common.EFailureCategory.Other())
+}
+
+func (s *failureCategorySuite) TestSummaryCountsFailuresByCategory(c *chk.C) {
+	jm := newMaxErrorsTestJobMgr(c.MkDir(), InMemoryTransitJobState{})
+	fail := func(category common.FailureCategory, status common.TransferStatus) {
+		jm.SendXferDoneMsg(xferDoneMsg{Src: "/src/file", TransferStatus: status, FailureCategory: category})
+	}
+	for i := 0; i < 3; i++ {
+		fail(common.EFailureCategory.Auth(), common.ETransferStatus.Failed())
+	}
+	fail(common.EFailureCategory.Network(), common.ETransferStatus.Failed())
+	fail(common.EFailureCategory.Network(), common.ETransferStatus.Failed())
+	fail(common.EFailureCategory.ChecksumMismatch(), common.ETransferStatus.Failed())
+	fail(common.EFailureCategory.Other(), common.ETransferStatus.BlobTierFailure())
+	fail(common.EFailureCategory.Precondition(), common.ETransferStatus.PreconditionFailed())
+	jm.SendXferDoneMsg(xferDoneMsg{Src: "/src/ok", TransferStatus: common.ETransferStatus.Success()})
+
+	summary := waitForFailedTransfers(c, jm, 8)
+	counts := summary.FailuresByCategory
+	c.Assert(counts, chk.DeepEquals, common.FailureCounts{Auth: 3, Network: 2, ChecksumMismatch: 1, Other: 1, Precondition: 1})
+	c.Assert(counts.Of(common.EFailureCategory.Auth()), chk.Equals, uint32(3))
+	c.Assert(counts.Of(common.EFailureCategory.Throttling()), chk.Equals, uint32(0))
+	c.Assert(counts.String(), chk.Equals, "Auth: 3, Network: 2, Precondition: 1, ChecksumMismatch: 1, Other: 1")
+}
@@ -0,0 +1,110 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type immutabilityPolicySuite struct{}
+
+var _ = chk.Suite(&immutabilityPolicySuite{})
+
+// immutabilityTestJptm is just enough of a transfer to work out the immutability policy of its destination
+type immutabilityTestJptm struct {
+	IJobPartTransferMgr
+	policy azblob.ImmutabilityPolicyOptions
+}
+
+func (j *immutabilityTestJptm) Context() context.Context                             { return context.Background() }
+func (j *immutabilityTestJptm) ImmutabilityPolicy() azblob.ImmutabilityPolicyOptions { return j.policy }
+func (j *immutabilityTestJptm) ShouldLog(pipeline.LogLevel) bool                     { return false }
+
+// immutabilityTestService answers Get Blob Properties for a blob that has the given policy, or for a blob that
+// doesn't exist if it has none, and counts the requests
+type immutabilityTestService struct {
+	until    time.Time
+	mode     azblob.BlobImmutabilityPolicyModeType
+	requests int
+}
+
+func (s *immutabilityTestService) New(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.Policy {
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		s.requests++
+		header := http.Header{}
+		status := http.StatusOK
+		if s.until.IsZero() {
+			status = http.StatusNotFound
+		} else {
+			header.Set("x-ms-immutability-policy-until-date", s.until.Format(time.RFC1123))
+			header.Set("x-ms-immutability-policy-mode", string(s.mode))
+		}
+		return pipeline.NewHTTPResponse(&http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    request.Request,
+		}), nil
+	})
+}
+
+func (s *immutabilityPolicySuite) policyFor(asked azblob.ImmutabilityPolicyOptions, service *immutabilityTestService) azblob.ImmutabilityPolicyOptions {
+	u, _ := url.Parse("https://account.blob.core.windows.net/container/blob")
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: service})
+	return immutabilityPolicyToApply(&immutabilityTestJptm{policy: asked}, azblob.NewBlobURL(*u, p), azblob.ClientProvidedKeyOptions{})
+}
+
+func (s *immutabilityPolicySuite) TestRetentionIsNeverShortened(c *chk.C) {
+	asked := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := asked.Add(24 * time.Hour)
+	options := azblob.ImmutabilityPolicyOptions{ImmutabilityPolicyUntilDate: &asked, ImmutabilityPolicyMode: azblob.BlobImmutabilityPolicyModeUnlocked}
+
+	// a new blob gets the policy asked for
+	policy := s.policyFor(options, &immutabilityTestService{})
+	c.Assert(policy.ImmutabilityPolicyUntilDate.Equal(asked), chk.Equals, true)
+
+	// as does one whose policy ends sooner
+	policy = s.policyFor(options, &immutabilityTestService{until: asked.Add(-time.Hour), mode: azblob.BlobImmutabilityPolicyModeUnlocked})
+	c.Assert(policy.ImmutabilityPolicyUntilDate.Equal(asked), chk.Equals, true)
+
+	// but one whose policy lasts longer keeps it, and keeps it locked if it was
+	policy = s.policyFor(options, &immutabilityTestService{until: later, mode: azblob.BlobImmutabilityPolicyModeLocked})
+	c.Assert(policy.ImmutabilityPolicyUntilDate.Equal(later), chk.Equals, true)
+	c.Assert(policy.ImmutabilityPolicyMode, chk.Equals, azblob.BlobImmutabilityPolicyModeLocked)
+	c.Assert(options.ImmutabilityPolicyUntilDate.Equal(asked), chk.Equals, true, chk.Commentf("the policy of the job must not change"))
+}
+
+func (s *immutabilityPolicySuite) TestBlobIsOnlyReadWhenThereIsARetention(c *chk.C) {
+	legalHold := true
+	service := &immutabilityTestService{}
+	policy := s.policyFor(azblob.ImmutabilityPolicyOptions{LegalHold: &legalHold}, service)
+	c.Assert(policy.ImmutabilityPolicyUntilDate, chk.IsNil)
+	c.Assert(*policy.LegalHold, chk.Equals, true)
+	c.Assert(service.requests, chk.Equals, 0)
+}
@@ -0,0 +1,86 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type localAddressSuite struct{}
+
+var _ = chk.Suite(&localAddressSuite{})
+
+// newRemoteAddressServer replies to every request with the address that the request came from
+func newRemoteAddressServer(listener net.Listener) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		_, _ = w.Write([]byte(host))
+	}))
+	server.Listener = listener
+	server.Start()
+	return server
+}
+
+func (s *localAddressSuite) TestHTTPClientDialsFromLocalAddress(c *chk.C) {
+	// any address in 127.0.0.0/8 is a loopback address on Linux, but not on every OS
+	localAddress, err := common.ParseLocalAddress("127.0.0.2")
+	if err != nil {
+		c.Skip("127.0.0.2 is not available here: " + err.Error())
+	}
+	defer func(old net.Addr) { common.GlobalDialLocalAddress = old }(common.GlobalDialLocalAddress)
+	common.GlobalDialLocalAddress = localAddress
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, chk.IsNil)
+	server := newRemoteAddressServer(listener)
+	defer server.Close()
+
+	resp, err := NewAzcopyHTTPClient(0).Get(server.URL)
+	c.Assert(err, chk.IsNil)
+	defer resp.Body.Close()
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	c.Assert(string(body[:n]), chk.Equals, "127.0.0.2")
+}
+
+func (s *localAddressSuite) TestHTTPClientDoesNotDialOtherAddressFamily(c *chk.C) {
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		c.Skip("IPv6 loopback is not available here: " + err.Error())
+	}
+	server := newRemoteAddressServer(listener)
+	defer server.Close()
+
+	localAddress, err := common.ParseLocalAddress("127.0.0.1")
+	c.Assert(err, chk.IsNil)
+	defer func(old net.Addr) { common.GlobalDialLocalAddress = old }(common.GlobalDialLocalAddress)
+	common.GlobalDialLocalAddress = localAddress
+
+	// an IPv4 local address can't reach an IPv6 destination
+	_, err = NewAzcopyHTTPClient(0).Get(server.URL)
+	c.Assert(err, chk.NotNil)
+}
@@ -0,0 +1,132 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type maxErrorsSuite struct{}
+
+var _ = chk.Suite(&maxErrorsSuite{})
+
+// newMaxErrorsTestJobMgr makes a job manager with a part 0 plan, for its status, and a running statusManager, to which
+// the results of transfers can be sent as if they had been done
+func newMaxErrorsTestJobMgr(planDir string, state InMemoryTransitJobState) *jobMgr {
+	defer func(old string) { common.AzcopyJobPlanFolder = old }(common.AzcopyJobPlanFolder)
+	common.AzcopyJobPlanFolder = planDir
+
+	jobID := common.NewJobID()
+	planFile := JobPartPlanFileName(fmt.Sprintf(JobPartPlanFileNameFormat, jobID.String(), 0, DataSchemaVersion))
+	planFile.Create(common.CopyJobPartOrderRequest{JobID: jobID, PartNum: 0, IsFinalPart: true, FromTo: common.EFromTo.LocalBlob()})
+
+	jm := &jobMgr{
+		jobID:       jobID,
+		logger:      smallFilePoolTestLogger{},
+		jobPartMgrs: newJobPartToJobPartMgr(),
+		jstm: &jobStatusManager{
+			respChan:        make(chan common.ListJobSummaryResponse),
+			listReq:         make(chan struct{}),
+			partCreated:     make(chan JobPartCreatedMsg, 100),
+			xferDone:        make(chan xferDoneMsg, 1000),
+			xferDoneDrained: make(chan struct{}),
+			statusMgrDone:   make(chan struct{}),
+		},
+	}
+	jm.ctx, jm.cancel = context.WithCancel(context.Background())
+	jm.jobPartMgrs.Set(0, &jobPartMgr{filename: planFile, planMMF: planFile.Map()})
+	jm.SetInMemoryTransitJobState(state)
+	go jm.handleStatusUpdateMessage()
+	return jm
+}
+
+// waitForFailedTransfers waits until the statusManager has counted this many failed transfers, and returns its summary
+func waitForFailedTransfers(c *chk.C, jm *jobMgr, count uint32) common.ListJobSummaryResponse {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		summary := jm.ListJobSummary()
+		if summary.TransfersFailed >= count {
+			return summary
+		}
+		if time.Now().After(deadline) {
+			c.Fatalf("only %d of %d failed transfers were counted", summary.TransfersFailed, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *maxErrorsSuite) TestJobCancelsItselfOnceMoreTransfersFailThanMaxErrors(c *chk.C) {
+	dir := c.MkDir()
+	manifestPath := filepath.Join(dir, "manifest.jsonl")
+	jm := newMaxErrorsTestJobMgr(dir, InMemoryTransitJobState{MaxErrors: 3, ManifestOutput: manifestPath})
+	status := func() common.JobStatus {
+		jpm, _ := jm.JobPartMgr(0)
+		return jpm.Plan().JobStatus()
+	}
+	fail := func(name string) {
+		jm.SendXferDoneMsg(xferDoneMsg{Src: "/src/" + name, Dst: "https://acct.blob.core.windows.net/c/" + name,
+			TransferStatus: common.ETransferStatus.Failed(), ErrorCode: 500, ErrorMessage: "injected"})
+	}
+
+	// as many failures as are allowed, among successes, leave the job running
+	jm.SendXferDoneMsg(xferDoneMsg{Src: "/src/ok", Dst: "https://acct.blob.core.windows.net/c/ok", TransferStatus: common.ETransferStatus.Success()})
+	fail("a")
+	fail("b")
+	jm.SendXferDoneMsg(xferDoneMsg{Src: "/src/skip", Dst: "https://acct.blob.core.windows.net/c/skip", TransferStatus: common.ETransferStatus.SkippedEntityAlreadyExists()})
+	fail("c")
+	summary := waitForFailedTransfers(c, jm, 3)
+	c.Assert(summary.AbortReason, chk.Equals, "")
+	c.Assert(status(), chk.Equals, common.EJobStatus.InProgress())
+	c.Assert(jm.Context().Err(), chk.IsNil)
+
+	// one more cancels the job, and with it the transfers in flight
+	fail("d")
+	summary = waitForFailedTransfers(c, jm, 4)
+	c.Assert(summary.AbortReason, chk.Equals, "more than 3 transfers failed")
+	c.Assert(status(), chk.Equals, common.EJobStatus.Cancelling())
+	c.Assert(jm.Context().Err(), chk.Equals, context.Canceled)
+
+	// the transfers that were in flight still report in, and the manifest is written once they all have
+	fail("e")
+	close(jm.jstm.xferDone)
+	jm.waitToDrainXferDone()
+	summary = jm.ListJobSummary()
+	c.Assert(summary.TransfersFailed, chk.Equals, uint32(5))
+	c.Assert(summary.AbortReason, chk.Equals, "more than 3 transfers failed")
+	c.Assert(readManifestRecords(c, manifestPath), chk.HasLen, 1)
+	c.Assert(readManifestRecords(c, failedManifestPath(manifestPath)), chk.HasLen, 5)
+}
+
+func (s *maxErrorsSuite) TestNoMaxErrorsMeansNoLimit(c *chk.C) {
+	jm := newMaxErrorsTestJobMgr(c.MkDir(), InMemoryTransitJobState{})
+	for i := 0; i < 50; i++ {
+		jm.SendXferDoneMsg(xferDoneMsg{Src: "/src/file", TransferStatus: common.ETransferStatus.Failed()})
+	}
+	summary := waitForFailedTransfers(c, jm, 50)
+	c.Assert(summary.AbortReason, chk.Equals, "")
+	c.Assert(jm.Context().Err(), chk.IsNil)
+}
@@ -0,0 +1,163 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type progressWatchdogSuite struct{}
+
+var _ = chk.Suite(&progressWatchdogSuite{})
+
+// trickleReader returns one byte per gap, so it is slow, but never stalls for as long as a gap
+type trickleReader struct {
+	remaining int
+	gap       time.Duration
+}
+
+func (r *trickleReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.gap)
+	r.remaining--
+	p[0] = 'x'
+	return 1, nil
+}
+
+// stallingReader returns a little data, then stalls until its transfer is cancelled
+type stallingReader struct {
+	ctx      context.Context
+	returned bool
+}
+
+func (r *stallingReader) Read(p []byte) (int, error) {
+	if !r.returned {
+		r.returned = true
+		p[0] = 'x'
+		return 1, nil
+	}
+	<-r.ctx.Done()
+	return 0, r.ctx.Err()
+}
+
+// holdingPacer makes each request for traffic wait for a while, as a pacer does when the job is throttled
+type holdingPacer struct {
+	hold time.Duration
+}
+
+func (p *holdingPacer) RequestTrafficAllocation(ctx context.Context, byteCount int64) error {
+	select {
+	case <-time.After(p.hold):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+func (p *holdingPacer) UpdateTargetBytesPerSecond(int64) {}
+func (p *holdingPacer) UndoRequest(int64)                {}
+func (p *holdingPacer) Close() error                     { return nil }
+
+// simulateTransfer reads the whole body made by newBody through a paced response body, as a downloader does,
+// with a watchdog that cancels the transfer's context when it stalls
+func simulateTransfer(timeout time.Duration, newBody func(ctx context.Context) io.Reader) error {
+	return simulatePacedTransfer(timeout, NewNullAutoPacer(), newBody)
+}
+
+func simulatePacedTransfer(timeout time.Duration, p pacer, newBody func(ctx context.Context) io.Reader) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := newProgressWatchdog(timeout, cancel)
+	ctx = withProgressWatchdog(ctx, w)
+	w.Start()
+	defer w.Stop()
+
+	body := newPacedResponseBody(ctx, ioutil.NopCloser(newBody(ctx)), p)
+	_, err := io.Copy(ioutil.Discard, body)
+	return err
+}
+
+func (s *progressWatchdogSuite) TestStalledTransferFailsWhileSlowOneCompletes(c *chk.C) {
+	const timeout = 300 * time.Millisecond
+	stalled := make(chan error, 1)
+	slow := make(chan error, 1)
+	start := time.Now()
+
+	go func() {
+		stalled <- simulateTransfer(timeout, func(ctx context.Context) io.Reader { return &stallingReader{ctx: ctx} })
+	}()
+	go func() {
+		// takes about three times the timeout in all, but is never idle for as long as the timeout
+		slow <- simulateTransfer(timeout, func(context.Context) io.Reader { return &trickleReader{remaining: 18, gap: 50 * time.Millisecond} })
+	}()
+
+	c.Assert(<-stalled, chk.Equals, context.Canceled)
+	c.Assert(<-slow, chk.IsNil)
+	c.Assert(time.Since(start) > 2*timeout, chk.Equals, true) // so the slow one outlived the timeout, because its progress reset it
+}
+
+func (s *progressWatchdogSuite) TestWaitingForThePacerIsNotAStall(c *chk.C) {
+	const timeout = 100 * time.Millisecond
+
+	// each read is held back by the pacer for longer than the timeout, but the data comes as soon as it is allowed to
+	err := simulatePacedTransfer(timeout, &holdingPacer{hold: 3 * timeout}, func(context.Context) io.Reader {
+		return &trickleReader{remaining: 2}
+	})
+	c.Assert(err, chk.IsNil)
+
+	// whereas a transfer that stalls after the pacer lets it go still fails
+	err = simulatePacedTransfer(timeout, &holdingPacer{hold: 3 * timeout}, func(ctx context.Context) io.Reader { return &stallingReader{ctx: ctx} })
+	c.Assert(err, chk.Equals, context.Canceled)
+}
+
+func (s *progressWatchdogSuite) TestStoppedWatchdogDoesNotFire(c *chk.C) {
+	var stalls int32
+	w := newProgressWatchdog(50*time.Millisecond, func() { atomic.AddInt32(&stalls, 1) })
+	w.Start()
+	w.Stop()
+	time.Sleep(150 * time.Millisecond)
+	c.Assert(atomic.LoadInt32(&stalls), chk.Equals, int32(0))
+}
+
+func (s *progressWatchdogSuite) TestWatchdogFiresOnlyOnce(c *chk.C) {
+	var stalls int32
+	w := newProgressWatchdog(20*time.Millisecond, func() { atomic.AddInt32(&stalls, 1) })
+	w.Start()
+	time.Sleep(150 * time.Millisecond)
+	w.ReportProgress() // progress after the stall must not re-arm it
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(atomic.LoadInt32(&stalls), chk.Equals, int32(1))
+}
+
+func (s *progressWatchdogSuite) TestNilWatchdogIsANoOp(c *chk.C) {
+	var w *progressWatchdog
+	w.Start()
+	w.ReportProgress()
+	w.Stop()
+	c.Assert(progressWatchdogFrom(context.Background()), chk.IsNil)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-file-go/azfile"
+	chk "gopkg.in/check.v1"
+)
+
+type securityInfoPersistenceManagerSuite struct{}
+
+var _ = chk.Suite(&securityInfoPersistenceManagerSuite{})
+
+// permissionStoreTestService answers CreatePermission like a share whose permission store holds at most capacity descriptors
+type permissionStoreTestService struct {
+	capacity  int
+	errorCode string // what a full store fails with, if not FilePermissionLimitExceeded
+	created   []string
+}
+
+func (s *permissionStoreTestService) New(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.Policy {
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		received, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		header := http.Header{}
+		status := http.StatusCreated
+		if len(s.created) >= s.capacity {
+			status = http.StatusConflict
+			header.Set("x-ms-error-code", string(serviceCodeFilePermissionLimitExceeded))
+			if s.errorCode != "" {
+				header.Set("x-ms-error-code", s.errorCode)
+			}
+		} else {
+			s.created = append(s.created, string(received))
+			header.Set("x-ms-file-permission-key", "key"+strconv.Itoa(len(s.created)))
+		}
+
+		return pipeline.NewHTTPResponse(&http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    request.Request,
+		}), nil
+	})
+}
+
+func (s *securityInfoPersistenceManagerSuite) shareURL(service *permissionStoreTestService) azfile.ShareURL {
+	u, _ := url.Parse("https://account.file.core.windows.net/myshare?sig=secret")
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: service})
+	return azfile.NewShareURL(*u, p)
+}
+
+func (s *securityInfoPersistenceManagerSuite) TestIdenticalSDDLsAreCreatedOncePerShare(c *chk.C) {
+	service := &permissionStoreTestService{capacity: 10}
+	sipm := newSecurityInfoPersistenceManager(context.Background())
+	share := s.shareURL(service)
+
+	first, err := sipm.PutSDDL("O:SYG:SYD:(A;;FA;;;SY)", share)
+	c.Assert(err, chk.IsNil)
+	second, err := sipm.PutSDDL("O:SYG:SYD:(A;;FA;;;SY)", share)
+	c.Assert(err, chk.IsNil)
+	other, err := sipm.PutSDDL("O:BAG:SYD:(A;;FA;;;BA)", share)
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(second, chk.Equals, first)
+	c.Assert(other, chk.Not(chk.Equals), first)
+	c.Assert(service.created, chk.HasLen, 2)
+}
+
+func (s *securityInfoPersistenceManagerSuite) TestFullPermissionStoreGivesClearError(c *chk.C) {
+	service := &permissionStoreTestService{capacity: 1}
+	sipm := newSecurityInfoPersistenceManager(context.Background())
+	share := s.shareURL(service)
+
+	_, err := sipm.PutSDDL("O:SYG:SYD:(A;;FA;;;SY)", share)
+	c.Assert(err, chk.IsNil)
+
+	_, err = sipm.PutSDDL("O:BAG:SYD:(A;;FA;;;BA)", share)
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "share myshare cannot store any more distinct permissions"), chk.Equals, true)
+}
+
+func (s *securityInfoPersistenceManagerSuite) TestOtherLimitsAreNotTakenForAFullPermissionStore(c *chk.C) {
+	service := &permissionStoreTestService{capacity: 0, errorCode: "FilePermissionSizeLimitExceeded"}
+	sipm := newSecurityInfoPersistenceManager(context.Background())
+
+	_, err := sipm.PutSDDL("O:SYG:SYD:(A;;FA;;;SY)", s.shareURL(service))
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "cannot store any more distinct permissions"), chk.Equals, false)
+	c.Assert(strings.Contains(err.Error(), "FilePermissionSizeLimitExceeded"), chk.Equals, true)
+}
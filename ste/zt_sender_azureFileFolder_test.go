@@ -0,0 +1,174 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-file-go/azfile"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type azureFileFolderSenderSuite struct{}
+
+var _ = chk.Suite(&azureFileFolderSenderSuite{})
+
+// azureFileFolderTestJptm is just enough of a folder transfer to ensure the folder exists
+type azureFileFolderTestJptm struct {
+	IJobPartTransferMgr
+	info    TransferInfo
+	tracker *azureFileFolderTestTracker
+	sipm    *securityInfoPersistenceManager
+}
+
+func (j *azureFileFolderTestJptm) Info() TransferInfo                              { return j.info }
+func (j *azureFileFolderTestJptm) Context() context.Context                        { return context.Background() }
+func (j *azureFileFolderTestJptm) GetFolderCreationTracker() FolderCreationTracker { return j.tracker }
+func (j *azureFileFolderTestJptm) SecurityInfoPersistenceManager() *securityInfoPersistenceManager {
+	return j.sipm
+}
+
+type azureFileFolderTestTracker struct {
+	created []string
+}
+
+func (t *azureFileFolderTestTracker) RecordCreation(folder string) {
+	t.created = append(t.created, folder)
+}
+func (t *azureFileFolderTestTracker) ShouldSetProperties(string, common.OverwriteOption, common.Prompter) bool {
+	return true
+}
+func (t *azureFileFolderTestTracker) StopTracking(string) {}
+
+// azureFileFolderTestSip is a source folder with fixed SMB properties
+type azureFileFolderTestSip struct {
+	ISMBPropertyBearingSourceInfoProvider
+}
+
+func (s *azureFileFolderTestSip) GetSDDL() (string, error) { return "O:SYG:SYD:(A;;FA;;;SY)", nil }
+func (s *azureFileFolderTestSip) GetSMBProperties() (TypedSMBPropertyHolder, error) {
+	return s, nil
+}
+func (s *azureFileFolderTestSip) FileCreationTime() time.Time {
+	return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+func (s *azureFileFolderTestSip) FileLastWriteTime() time.Time {
+	return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+func (s *azureFileFolderTestSip) FileAttributes() azfile.FileAttributeFlags {
+	return azfile.FileAttributeHidden
+}
+
+// azureFileFolderTestService is a share that has only its root directory, recording the requests it is sent
+type azureFileFolderTestService struct {
+	requests []*http.Request
+}
+
+func (s *azureFileFolderTestService) New(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.Policy {
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		s.requests = append(s.requests, request.Request)
+
+		header := http.Header{}
+		status := http.StatusOK
+		switch {
+		case request.Method == http.MethodGet && request.URL.Path != "/share":
+			status = http.StatusNotFound
+			header.Set("x-ms-error-code", string(azfile.ServiceCodeResourceNotFound))
+		case request.Method == http.MethodPut && request.URL.Query().Get("comp") == "filepermission":
+			status = http.StatusCreated
+			header.Set("x-ms-file-permission-key", "permkey")
+		case request.Method == http.MethodPut && request.URL.Query().Get("comp") == "":
+			status = http.StatusCreated
+		}
+
+		return pipeline.NewHTTPResponse(&http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    request.Request,
+		}), nil
+	})
+}
+
+func (s *azureFileFolderSenderSuite) ensureFolderExists(c *chk.C, info TransferInfo) *azureFileFolderTestService {
+	service := &azureFileFolderTestService{}
+	tracker := &azureFileFolderTestTracker{}
+	u, _ := url.Parse("https://account.file.core.windows.net/share/fold1")
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: service})
+	sender := &azureFileSenderBase{
+		jptm:            &azureFileFolderTestJptm{info: info, tracker: tracker, sipm: newSecurityInfoPersistenceManager(context.Background())},
+		fileOrDirURL:    azfile.NewDirectoryURL(*u, p),
+		pipeline:        p,
+		ctx:             context.Background(),
+		sip:             &azureFileFolderTestSip{},
+		metadataToApply: azfile.Metadata{"owner": "me"},
+	}
+
+	c.Assert(sender.EnsureFolderExists(), chk.Equals, folderPropertiesSetInCreation{})
+	c.Assert(tracker.created, chk.DeepEquals, []string{"https://account.file.core.windows.net/share/fold1"})
+	return service
+}
+
+// describe gives the method and operation of each request, e.g. "GET directory" or "PUT properties"
+func (s *azureFileFolderSenderSuite) describe(requests []*http.Request) []string {
+	var result []string
+	for _, r := range requests {
+		op := r.URL.Query().Get("comp")
+		if op == "" {
+			op = r.URL.Query().Get("restype")
+		}
+		result = append(result, strings.Join([]string{r.Method, op}, " "))
+	}
+	return result
+}
+
+func (s *azureFileFolderSenderSuite) TestNewFolderIsCreatedWithItsProperties(c *chk.C) {
+	service := s.ensureFolderExists(c, TransferInfo{PreserveSMBInfo: true, EntityType: common.EEntityType.Folder()})
+
+	c.Assert(s.describe(service.requests), chk.DeepEquals, []string{"GET directory", "GET directory", "PUT directory"})
+	create := service.requests[2]
+	c.Assert(create.Header.Get("x-ms-meta-owner"), chk.Equals, "me")
+	c.Assert(create.Header.Get("x-ms-file-attributes"), chk.Equals, "Hidden|Directory")
+	c.Assert(create.Header.Get("x-ms-file-creation-time"), chk.Equals, "2020-01-02T03:04:05.0000000Z")
+	c.Assert(create.Header.Get("x-ms-file-permission"), chk.Equals, "inherit")
+}
+
+func (s *azureFileFolderSenderSuite) TestNewFolderPermissionsAreSetAgainWithTheSMBInfo(c *chk.C) {
+	service := s.ensureFolderExists(c, TransferInfo{
+		PreserveSMBInfo:        true,
+		PreserveSMBPermissions: common.EPreservePermissionsOption.ACLsOnly(),
+		EntityType:             common.EEntityType.Folder(),
+	})
+
+	c.Assert(s.describe(service.requests), chk.DeepEquals, []string{"GET directory", "GET directory", "PUT filepermission", "PUT directory", "PUT properties"})
+	for _, r := range service.requests[3:] {
+		c.Assert(r.Header.Get("x-ms-file-permission-key"), chk.Equals, "permkey")
+		c.Assert(r.Header.Get("x-ms-file-attributes"), chk.Equals, "Hidden|Directory")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type smallFilePoolSuite struct{}
+
+var _ = chk.Suite(&smallFilePoolSuite{})
+
+// newSmallFilePoolTestJobMgr makes a job manager with just enough in it to schedule and initiate transfers
+func newSmallFilePoolTestJobMgr(transferInitiationPoolSize, smallFileConcurrency int) *jobMgr {
+	normalTransferCh, lowTransferCh := make(chan IJobPartTransferMgr, 1000), make(chan IJobPartTransferMgr, 1000)
+	smallFileTransferCh := make(chan IJobPartTransferMgr, 1000)
+	jm := &jobMgr{
+		concurrency: ConcurrencySettings{
+			TransferInitiationPoolSize: &ConfiguredInt{Value: transferInitiationPoolSize},
+			SmallFileThreshold:         &ConfiguredInt{Value: defaultSmallFileThreshold},
+			SmallFileConcurrency:       &ConfiguredInt{Value: smallFileConcurrency},
+		},
+		logger:         smallFilePoolTestLogger{},
+		reportCancelCh: make(chan struct{}, 1),
+		coordinatorChannels: CoordinatorChannels{
+			normalTransferCh:    normalTransferCh,
+			lowTransferCh:       lowTransferCh,
+			smallFileTransferCh: smallFileTransferCh,
+		},
+		xferChannels: XferChannels{
+			normalTransferCh:    normalTransferCh,
+			lowTransferCh:       lowTransferCh,
+			smallFileTransferCh: smallFileTransferCh,
+			normalChunckCh:      make(chan chunkFunc, 1000),
+			lowChunkCh:          make(chan chunkFunc, 1000),
+			smallFileChunkCh:    make(chan chunkFunc, 1000),
+			closeTransferCh:     make(chan struct{}, 100),
+			closeSmallFileCh:    make(chan struct{}, 2*smallFileConcurrency),
+			scheduleCloseCh:     make(chan struct{}, 1),
+		},
+	}
+	jm.startTransferProcessors()
+	return jm
+}
+
+type smallFilePoolTestLogger struct {
+	common.ILoggerResetable
+}
+
+func (smallFilePoolTestLogger) ShouldLog(pipeline.LogLevel) bool { return false }
+func (smallFilePoolTestLogger) Log(pipeline.LogLevel, string)    {}
+
+// smallFilePoolTestTransfers counts the files transferred. The large files don't finish until they are released,
+// so any small file that finishes before then can't have been held up by them
+type smallFilePoolTestTransfers struct {
+	wg                          sync.WaitGroup
+	largeStarted                chan struct{}
+	release                     chan struct{}
+	atomicSmallFilesTransferred int32
+}
+
+// smallFilePoolTestJptm is a transfer that does nothing, unless it's a large file, which waits to be released
+type smallFilePoolTestJptm struct {
+	IJobPartTransferMgr
+	size      int64
+	smallFile bool
+	transfers *smallFilePoolTestTransfers
+}
+
+func (j *smallFilePoolTestJptm) IsSmallFile() bool { return j.smallFile }
+func (j *smallFilePoolTestJptm) WasCanceled() bool { return false }
+func (j *smallFilePoolTestJptm) ComponentLogger(common.LogComponent) transferComponentLogger {
+	return transferComponentLogger{logger: smallFilePoolTestLogger{}}
+}
+
+func (j *smallFilePoolTestJptm) StartJobXfer() {
+	defer j.transfers.wg.Done()
+	if j.size >= defaultSmallFileThreshold {
+		j.transfers.largeStarted <- struct{}{}
+		<-j.transfers.release
+		return
+	}
+	atomic.AddInt32(&j.transfers.atomicSmallFilesTransferred, 1)
+}
+
+// transferMix schedules as many large files as there are transfer initiation workers, so that they take up all of
+// those workers, and then many 1K ones. It says how many of the small files were transferred while the large ones
+// were still in progress, before releasing those and waiting for everything to finish
+func (s *smallFilePoolSuite) transferMix(c *chk.C, jm *jobMgr, smallFilesToWaitFor int) (smallFilesBeforeRelease int) {
+	largeFiles, smallFiles := jm.concurrency.TransferInitiationPoolSize.Value, 200
+	transfers := &smallFilePoolTestTransfers{largeStarted: make(chan struct{}, largeFiles), release: make(chan struct{})}
+	transfers.wg.Add(largeFiles + smallFiles)
+	defer jm.cleanupTransferRoutine()
+
+	schedule := func(size int64) {
+		jm.ScheduleTransfer(common.EJobPriority.Normal(), &smallFilePoolTestJptm{size: size, smallFile: jm.IsSmallFile(size), transfers: transfers})
+	}
+	for i := 0; i < largeFiles; i++ {
+		schedule(100 * 1024 * 1024)
+	}
+	for i := 0; i < smallFiles; i++ {
+		schedule(1024)
+	}
+
+	// the large files were queued first, so they are what the transfer initiation workers picked up
+	for i := 0; i < largeFiles; i++ {
+		<-transfers.largeStarted
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for int(atomic.LoadInt32(&transfers.atomicSmallFilesTransferred)) < smallFilesToWaitFor && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	smallFilesBeforeRelease = int(atomic.LoadInt32(&transfers.atomicSmallFilesTransferred))
+
+	close(transfers.release)
+	transfers.wg.Wait()
+	c.Assert(atomic.LoadInt32(&transfers.atomicSmallFilesTransferred), chk.Equals, int32(smallFiles))
+	return smallFilesBeforeRelease
+}
+
+func (s *smallFilePoolSuite) TestSmallFilesAreNotHeldUpByLargeOnes(c *chk.C) {
+	const transferInitiationPoolSize = 4
+
+	// without a small-file pool, the small files queue up behind the large ones, for the same few workers
+	c.Assert(s.transferMix(c, newSmallFilePoolTestJobMgr(transferInitiationPoolSize, 0), 0), chk.Equals, 0)
+
+	// with one, they are all transferred while the large ones are still going
+	c.Assert(s.transferMix(c, newSmallFilePoolTestJobMgr(transferInitiationPoolSize, 32), 200), chk.Equals, 200)
+}
+
+func (s *smallFilePoolSuite) TestSmallFileChunksRunInTheSmallFilePool(c *chk.C) {
+	// the main pool is never started here, so only the small-file pool can run the chunk
+	jm := newSmallFilePoolTestJobMgr(1, 1)
+	defer jm.cleanupTransferRoutine()
+	jptm := &jobPartTransferMgr{jobPartMgr: &jobPartMgr{jobMgr: jm}, smallFile: true}
+
+	ran := make(chan struct{})
+	jptm.ScheduleChunks(func(int) { close(ran) })
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the small file's chunk was not run")
+	}
+	c.Assert(len(jm.xferChannels.normalChunckCh), chk.Equals, 0)
+}
+
+func (s *smallFilePoolSuite) TestSmallFileThreshold(c *chk.C) {
+	settings := ConcurrencySettings{
+		SmallFileThreshold:   &ConfiguredInt{Value: defaultSmallFileThreshold},
+		SmallFileConcurrency: &ConfiguredInt{Value: 32},
+	}
+	c.Assert(settings.IsSmallFile(1024), chk.Equals, true)
+	c.Assert(settings.IsSmallFile(defaultSmallFileThreshold-1), chk.Equals, true)
+	c.Assert(settings.IsSmallFile(defaultSmallFileThreshold), chk.Equals, false)
+
+	// no small-file pool means no small files
+	settings.SmallFileConcurrency.Value = 0
+	c.Assert(settings.IsSmallFile(1024), chk.Equals, false)
+}
+
+func (s *smallFilePoolSuite) TestSmallFilePoolIsOffByDefault(c *chk.C) {
+	settings := NewConcurrencySettings(math.MaxInt32, false)
+	c.Assert(settings.SmallFileConcurrency.Value, chk.Equals, 0)
+	c.Assert(settings.IsSmallFile(1024), chk.Equals, false)
+	c.Assert(settings.MaxIdleConnections, chk.Equals, settings.MaxMainPoolSize.Value)
+}
+
+func (s *smallFilePoolSuite) TestSmallFileSettingsOutOfRangeAreRejected(c *chk.C) {
+	// the pool may be turned off, but not given a negative size
+	concurrency := common.EEnvironmentVariable.SmallFileConcurrency().Name
+	c.Assert((&ConfiguredInt{Value: 0, EnvVarName: concurrency}).checkAtLeast(0), chk.IsNil)
+	c.Assert((&ConfiguredInt{Value: 64, EnvVarName: concurrency}).checkAtLeast(0), chk.IsNil)
+	c.Assert((&ConfiguredInt{Value: -1, EnvVarName: concurrency}).checkAtLeast(0), chk.ErrorMatches, ".*"+concurrency+" -1 is invalid.*")
+
+	// no file is smaller than a threshold of 0, so the pool would never be used
+	threshold := common.EEnvironmentVariable.SmallFileThreshold().Name
+	c.Assert((&ConfiguredInt{Value: 1, EnvVarName: threshold}).checkAtLeast(1), chk.IsNil)
+	c.Assert((&ConfiguredInt{Value: 0, EnvVarName: threshold}).checkAtLeast(1), chk.ErrorMatches, ".*"+threshold+" 0 is invalid.*")
+	c.Assert((&ConfiguredInt{Value: -5, EnvVarName: threshold}).checkAtLeast(1), chk.ErrorMatches, ".*"+threshold+" -5 is invalid.*")
+}
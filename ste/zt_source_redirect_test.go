@@ -0,0 +1,92 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	chk "gopkg.in/check.v1"
+)
+
+type sourceRedirectSuite struct{}
+
+var _ = chk.Suite(&sourceRedirectSuite{})
+
+// newRedirectingServer serves "0123456789" at /data, honouring x-ms-range, redirects /hop to /data, and /loop to itself
+func newRedirectingServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-ms-range") == "bytes=2-4" {
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte("234"))
+			return
+		}
+		_, _ = w.Write([]byte("0123456789"))
+	})
+	mux.HandleFunc("/hop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/data", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+func rangedGet(c *chk.C, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	c.Assert(err, chk.IsNil)
+	req.Header.Set("x-ms-range", "bytes=2-4")
+	return client.Do(req)
+}
+
+func (s *sourceRedirectSuite) TestRangedReadFollowsRedirectWhenAllowed(c *chk.C) {
+	server := newRedirectingServer()
+	defer server.Close()
+
+	resp, err := rangedGet(c, withSourceRedirects(NewAzcopyHTTPClient(0)), server.URL+"/hop")
+	c.Assert(err, chk.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, chk.IsNil)
+	c.Assert(resp.StatusCode, chk.Equals, http.StatusPartialContent)
+	c.Assert(string(body), chk.Equals, "234")
+}
+
+func (s *sourceRedirectSuite) TestRedirectLoopFailsCleanly(c *chk.C) {
+	server := newRedirectingServer()
+	defer server.Close()
+
+	_, err := rangedGet(c, withSourceRedirects(NewAzcopyHTTPClient(0)), server.URL+"/loop")
+	c.Assert(err, chk.NotNil)
+	c.Assert(err, chk.ErrorMatches, ".*stopped after 5 redirects.*")
+}
+
+func (s *sourceRedirectSuite) TestRedirectsAreHandledAsBeforeByDefault(c *chk.C) {
+	server := newRedirectingServer()
+	defer server.Close()
+
+	// without the option, the client keeps http.Client's default policy
+	c.Assert(NewAzcopyHTTPClient(0).CheckRedirect, chk.IsNil)
+	_, err := rangedGet(c, NewAzcopyHTTPClient(0), server.URL+"/loop")
+	c.Assert(err, chk.ErrorMatches, ".*stopped after 10 redirects.*")
+}
@@ -0,0 +1,58 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type symlinkTargetSuite struct{}
+
+var _ = chk.Suite(&symlinkTargetSuite{})
+
+func (s *symlinkTargetSuite) TestSymlinkTargetsMustStayInTheDestination(c *chk.C) {
+	root := filepath.Join(c.MkDir(), "dst")
+	link := filepath.Join(root, "dir", "link")
+
+	for _, target := range []string{"b.txt", "../b.txt", "..", "./b.txt", "sub/file", "../other/./deeper"} {
+		c.Assert(checkSymlinkTarget(target, link, root), chk.IsNil, chk.Commentf(target))
+	}
+
+	// a blob's metadata can name any target, so these would plant links to wherever the blob's author chose
+	for _, target := range []string{
+		"/etc/passwd",
+		"/",
+		"../../outside",
+		"../../../../../../etc",
+		"../../dst-sibling/file", // a sibling that shares the root's name as a prefix is still outside
+		"sub/../../../outside",   // a '..' after a name is resolved through whatever that name is, which may be a link
+		"sub/..",
+		"./sub/../../x",
+	} {
+		c.Assert(checkSymlinkTarget(target, link, root), chk.NotNil, chk.Commentf(target))
+	}
+
+	// when a single blob is downloaded, the destination root is the link itself, so its folder is the boundary
+	single := filepath.Join(root, "link")
+	c.Assert(checkSymlinkTarget("neighbour", single, single), chk.IsNil)
+	c.Assert(checkSymlinkTarget("../neighbour", single, single), chk.NotNil)
+}
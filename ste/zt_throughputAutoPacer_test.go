@@ -0,0 +1,136 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type throughputAutoPacerSuite struct{}
+
+var _ = chk.Suite(&throughputAutoPacerSuite{})
+
+// throttlingTransport stands in for a service that takes up to limitBytesPerInterval in each tuning interval,
+// and answers every request past that with ServerBusy (503), without going to the network
+type throttlingTransport struct {
+	limitBytesPerInterval int64
+	bytesThisInterval     int64
+	throttledThisInterval bool
+}
+
+func (t *throttlingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.bytesThisInterval += req.ContentLength
+	status := http.StatusCreated
+	if t.bytesThisInterval > t.limitBytesPerInterval {
+		status = http.StatusServiceUnavailable
+		t.throttledThisInterval = true
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// nextInterval starts a new interval, and says whether any request was throttled in the one that has just finished
+func (t *throttlingTransport) nextInterval() (wasThrottled bool) {
+	wasThrottled = t.throttledThisInterval
+	t.bytesThisInterval = 0
+	t.throttledThisInterval = false
+	return
+}
+
+type discardingLogger struct{}
+
+func (discardingLogger) ShouldLog(_ pipeline.LogLevel) bool { return false }
+func (discardingLogger) Log(_ pipeline.LogLevel, _ string)  {}
+func (discardingLogger) Panic(err error)                    { panic(err) }
+
+func (s *throughputAutoPacerSuite) TestThroughputTunerSettlesBelowThrottlingLimit(c *chk.C) {
+	const limit = 1000 * 1000
+	const requestSize = 16 * 1000
+	const intervals = 600
+
+	// no goroutine tunes this pacer. The test tunes it instead, once per simulated interval, so that it runs in no time at all
+	a := newAutoTokenBucketPacer(limit/10, 0, discardingLogger{}, jobThroughputTunerString)
+	a.inSlowStart = true
+	defer a.Close()
+
+	// the 503s reach the pacer in the same way as they do in a job: through the stats policy
+	transport := &throttlingTransport{limitBytesPerInterval: limit}
+	stats := newPipelineNetworkStats(&NullConcurrencyTuner{}, a)
+	p := pipeline.NewPipeline(
+		[]pipeline.Factory{newXferStatsPolicyFactory(stats), pipeline.MethodFactoryMarker()},
+		pipeline.Options{HTTPSender: newAzcopyHTTPClientFactory(&http.Client{Transport: transport})})
+	u, err := url.Parse("https://fakeaccount.blob.core.windows.net/container/blob")
+	c.Assert(err, chk.IsNil)
+	blob := azblob.NewBlockBlobURL(*u, p)
+	data := make([]byte, requestSize)
+
+	now := time.Now()
+	throttledIntervals := 0
+	for i := 0; i < intervals; i++ {
+		// send as much as the pacer allows. The job is assumed to have plenty to send, so that the pacer is what limits it
+		for sent := int64(0); sent+requestSize <= a.targetBytesPerSecond(); sent += requestSize {
+			_, _ = blob.StageBlock(context.Background(), "AAAA", bytes.NewReader(data), azblob.LeaseAccessConditions{}, nil, azblob.ClientProvidedKeyOptions{})
+		}
+
+		if transport.nextInterval() && i >= intervals/2 {
+			throttledIntervals++
+		}
+		now = now.Add(tuningIntervalDuration)
+		a.tune(now)
+	}
+
+	// it has found the limit, and settled a little below it, without being throttled for much of the time
+	steadyState := a.SteadyStateBytesPerSecond()
+	c.Assert(steadyState < limit, chk.Equals, true, chk.Commentf("steady state %d", steadyState))
+	c.Assert(steadyState > limit*3/4, chk.Equals, true, chk.Commentf("steady state %d", steadyState))
+	c.Assert(throttledIntervals < intervals/20, chk.Equals, true, chk.Commentf("throttled in %d intervals", throttledIntervals))
+}
+
+func (s *throughputAutoPacerSuite) TestThroughputTunerStaysWithinCap(c *chk.C) {
+	a := newAutoTokenBucketPacer(100, 0, discardingLogger{}, jobThroughputTunerString)
+	a.inSlowStart = true
+	a.maxBytesPerSecond = 1000
+	defer a.Close()
+
+	// with no 503s at all, the rate rises to the cap, and no further
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		now = now.Add(tuningIntervalDuration)
+		a.tune(now)
+		c.Assert(a.targetBytesPerSecond() <= 1000, chk.Equals, true)
+	}
+	c.Assert(a.targetBytesPerSecond(), chk.Equals, int64(1000))
+}
@@ -0,0 +1,87 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type trailingDotPolicySuite struct{}
+
+var _ = chk.Suite(&trailingDotPolicySuite{})
+
+// sendThroughTrailingDotPolicy returns the headers of the request, as the policy passes it on, for a job that allows trailing dots
+func sendThroughTrailingDotPolicy(c *chk.C, rawURL string, copySource string) http.Header {
+	return sendThroughTrailingDotPolicyWithContext(c, context.WithValue(context.Background(), AllowTrailingDot, true), rawURL, copySource)
+}
+
+func sendThroughTrailingDotPolicyWithContext(c *chk.C, ctx context.Context, rawURL string, copySource string) http.Header {
+	var seen http.Header
+	last := pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		seen = request.Header
+		return nil, nil
+	})
+	policy := NewTrailingDotPolicyFactory().New(last, nil)
+
+	u, err := url.Parse(rawURL)
+	c.Assert(err, chk.IsNil)
+	request, err := pipeline.NewRequest(http.MethodPut, *u, nil)
+	c.Assert(err, chk.IsNil)
+	request.Header.Set("x-ms-version", "2020-02-10")
+	if copySource != "" {
+		request.Header.Set("x-ms-copy-source", copySource)
+	}
+	_, err = policy.Do(ctx, request)
+	c.Assert(err, chk.IsNil)
+	return seen
+}
+
+func (s *trailingDotPolicySuite) TestOnlyNamesWithTrailingDotsGetTheHeader(c *chk.C) {
+	plain := sendThroughTrailingDotPolicy(c, "https://acct.file.core.windows.net/share/dir/file.txt", "")
+	c.Assert(plain.Get("x-ms-allow-trailing-dot"), chk.Equals, "")
+	c.Assert(plain.Get("x-ms-version"), chk.Equals, "2020-02-10")
+
+	for _, path := range []string{"/share/file.", "/share/dir./child"} {
+		dotted := sendThroughTrailingDotPolicy(c, "https://acct.file.core.windows.net"+path+"?comp=range", "")
+		c.Assert(dotted.Get("x-ms-allow-trailing-dot"), chk.Equals, "true")
+		c.Assert(dotted.Get("x-ms-version"), chk.Equals, trailingDotServiceVersion)
+		c.Assert(dotted.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "")
+	}
+}
+
+func (s *trailingDotPolicySuite) TestCopySourceWithTrailingDotGetsTheSourceHeader(c *chk.C) {
+	h := sendThroughTrailingDotPolicy(c, "https://acct.file.core.windows.net/share/file", "https://other.file.core.windows.net/share/file.?sv=x")
+	c.Assert(h.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "true")
+	c.Assert(h.Get("x-ms-allow-trailing-dot"), chk.Equals, "")
+	c.Assert(h.Get("x-ms-version"), chk.Equals, trailingDotServiceVersion)
+}
+
+func (s *trailingDotPolicySuite) TestNothingChangesUnlessTheJobAllowsTrailingDots(c *chk.C) {
+	h := sendThroughTrailingDotPolicyWithContext(c, context.Background(), "https://acct.file.core.windows.net/share/dir./file.", "https://other.file.core.windows.net/share/file.?sv=x")
+	c.Assert(h.Get("x-ms-allow-trailing-dot"), chk.Equals, "")
+	c.Assert(h.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "")
+	c.Assert(h.Get("x-ms-version"), chk.Equals, "2020-02-10")
+}
@@ -0,0 +1,114 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type transferManifestSuite struct{}
+
+var _ = chk.Suite(&transferManifestSuite{})
+
+func readManifestRecords(c *chk.C, path string) []manifestRecord {
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, chk.IsNil)
+
+	records := make([]manifestRecord, 0)
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var r manifestRecord
+		c.Assert(json.Unmarshal([]byte(line), &r), chk.IsNil)
+		records = append(records, r)
+	}
+	return records
+}
+
+func (s *transferManifestSuite) TestFailedManifestPath(c *chk.C) {
+	c.Assert(failedManifestPath(filepath.Join("out", "manifest.jsonl")), chk.Equals, filepath.Join("out", "manifest.failed.jsonl"))
+	c.Assert(failedManifestPath("manifest"), chk.Equals, "manifest.failed")
+}
+
+func (s *transferManifestSuite) TestContentMD5CanBeRecordedWhileItIsRead(c *chk.C) {
+	// the sender records the MD5 from its own goroutine, while the transfer may be reported from another
+	jptm := &jobPartTransferMgr{}
+	jptm.RecordContentMD5([]byte{1})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			jptm.RecordContentMD5([]byte{byte(i)})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c.Assert(jptm.knownContentMD5(), chk.HasLen, 1)
+	}
+	wg.Wait()
+	c.Assert(jptm.knownContentMD5(), chk.DeepEquals, []byte{99})
+}
+
+func (s *transferManifestSuite) TestManifestRecordsTransferredAndFailedFiles(c *chk.C) {
+	dir, err := ioutil.TempDir("", "manifest")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.jsonl")
+
+	m, err := newTransferManifest(path)
+	c.Assert(err, chk.IsNil)
+
+	ts := common.ETransferStatus
+	c.Assert(m.Add(xferDoneMsg{Src: "/src/a.txt", Dst: "https://acct.blob.core.windows.net/c/a.txt?sv=2020-02-10&sig=secret",
+		TransferStatus: ts.Success(), TransferSize: 10, ContentMD5: []byte{1, 2, 3}}), chk.IsNil)
+	c.Assert(m.Add(xferDoneMsg{Src: "/src/dir", Dst: "https://acct.blob.core.windows.net/c/dir",
+		TransferStatus: ts.Success(), IsFolderProperties: true}), chk.IsNil)
+	c.Assert(m.Add(xferDoneMsg{Src: "/src/b.txt", Dst: "https://acct.blob.core.windows.net/c/b.txt",
+		TransferStatus: ts.Failed(), TransferSize: 20, ErrorCode: 403, ErrorMessage: "denied"}), chk.IsNil)
+	c.Assert(m.Add(xferDoneMsg{Src: "/src/c.txt", Dst: "https://acct.blob.core.windows.net/c/c.txt",
+		TransferStatus: ts.SkippedEntityAlreadyExists()}), chk.IsNil)
+	c.Assert(m.Close(), chk.IsNil)
+
+	// the folder and the skipped file are in neither manifest, and the SAS is redacted
+	transferred := readManifestRecords(c, path)
+	c.Assert(transferred, chk.HasLen, 1)
+	c.Assert(transferred[0].Source, chk.Equals, "/src/a.txt")
+	c.Assert(strings.Contains(transferred[0].Destination, "secret"), chk.Equals, false)
+	c.Assert(transferred[0].Size, chk.Equals, uint64(10))
+	c.Assert(transferred[0].ContentMD5, chk.Equals, "AQID")
+	c.Assert(transferred[0].ErrorMessage, chk.Equals, "")
+
+	failed := readManifestRecords(c, filepath.Join(dir, "manifest.failed.jsonl"))
+	c.Assert(failed, chk.HasLen, 1)
+	c.Assert(failed[0].Source, chk.Equals, "/src/b.txt")
+	c.Assert(failed[0].ErrorCode, chk.Equals, int32(403))
+	c.Assert(failed[0].ErrorMessage, chk.Equals, "denied")
+}
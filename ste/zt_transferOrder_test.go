@@ -0,0 +1,101 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type transferOrderSuite struct{}
+
+var _ = chk.Suite(&transferOrderSuite{})
+
+// recordingDispatcher stands in for the job manager's scheduling, and records the names of the transfers in the order
+// that the part dispatches them
+type recordingDispatcher struct {
+	dispatched []string
+}
+
+// dispatchPart makes a plan for a part with the given transfers, in the given order, and dispatches its transfers in
+// the order that the part's plan says
+func (d *recordingDispatcher) dispatchPart(c *chk.C, order common.TransferOrder, priorityPattern string, transfers []common.CopyTransfer) {
+	defer func(old string) { common.AzcopyJobPlanFolder = old }(common.AzcopyJobPlanFolder)
+	common.AzcopyJobPlanFolder = c.MkDir()
+
+	jobID := common.NewJobID()
+	planFile := JobPartPlanFileName(fmt.Sprintf(JobPartPlanFileNameFormat, jobID.String(), 0, DataSchemaVersion))
+	planFile.Create(common.CopyJobPartOrderRequest{
+		JobID:           jobID,
+		IsFinalPart:     true,
+		FromTo:          common.EFromTo.BlobLocal(),
+		SourceRoot:      common.ResourceString{Value: "https://account.blob.core.windows.net/container"},
+		DestinationRoot: common.ResourceString{Value: "/tmp/destination"},
+		Transfers:       common.Transfers{List: transfers},
+		TransferOrder:   order,
+		PriorityPattern: priorityPattern,
+	})
+	mmf := planFile.Map()
+	defer mmf.Unmap()
+	plan := mmf.Plan()
+
+	jpm := &jobPartMgr{filename: planFile, planMMF: mmf}
+	for _, t := range jpm.transferSchedulingOrder(plan) {
+		src, _ := plan.TransferSrcDstRelatives(t)
+		d.dispatched = append(d.dispatched, path.Base(src))
+	}
+}
+
+func transferOrderTestTransfers() []common.CopyTransfer {
+	file := func(name string, size int64) common.CopyTransfer {
+		return common.CopyTransfer{Source: "/dir/" + name, Destination: "/dir/" + name, EntityType: common.EEntityType.File(), SourceSize: size}
+	}
+	return []common.CopyTransfer{
+		file("medium.bin", 500),
+		file("large.bin", 9000),
+		{Source: "/dir", Destination: "/dir", EntityType: common.EEntityType.Folder()},
+		file("index.json", 40),
+		file("small.txt", 10),
+		file("same-as-medium.bin", 500),
+		file("config.json", 700),
+	}
+}
+
+func (s *transferOrderSuite) TestDispatchOrderFollowsTheTransferOrder(c *chk.C) {
+	cases := []struct {
+		order           common.TransferOrder
+		priorityPattern string
+		expected        []string
+	}{
+		{common.ETransferOrder.Listed(), "", []string{"medium.bin", "large.bin", "dir", "index.json", "small.txt", "same-as-medium.bin", "config.json"}},
+		// folders go first, and files of the same size keep the order they were listed in
+		{common.ETransferOrder.SmallestFirst(), "", []string{"dir", "small.txt", "index.json", "medium.bin", "same-as-medium.bin", "config.json", "large.bin"}},
+		{common.ETransferOrder.LargestFirst(), "", []string{"dir", "large.bin", "config.json", "medium.bin", "same-as-medium.bin", "index.json", "small.txt"}},
+		{common.ETransferOrder.PatternPriority(), "*.json;small.*", []string{"dir", "index.json", "small.txt", "config.json", "medium.bin", "large.bin", "same-as-medium.bin"}},
+	}
+	for _, test := range cases {
+		d := &recordingDispatcher{}
+		d.dispatchPart(c, test.order, test.priorityPattern, transferOrderTestTransfers())
+		c.Assert(d.dispatched, chk.DeepEquals, test.expected, chk.Commentf("order %s", test.order))
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type xferLogPolicySuite struct{}
+
+var _ = chk.Suite(&xferLogPolicySuite{})
+
+func (s *xferLogPolicySuite) TestCustomerProvidedKeyIsNotLogged(c *chk.C) {
+	req, err := http.NewRequest(http.MethodPut, "https://account.blob.core.windows.net/container/blob", nil)
+	c.Assert(err, chk.IsNil)
+	req.Header.Set("x-ms-encryption-key", "c2VjcmV0")
+	req.Header.Set("x-ms-encryption-key-sha256", "aGFzaA==")
+	request := pipeline.Request{Request: req}
+
+	logged := prepareRequestForLogging(request)
+	c.Assert(logged.Header.Get("x-ms-encryption-key"), chk.Equals, "REDACTED")
+	c.Assert(logged.Header.Get("x-ms-encryption-key-sha256"), chk.Equals, "aGFzaA==")
+
+	// the request that is sent must still have the key
+	c.Assert(request.Header.Get("x-ms-encryption-key"), chk.Equals, "c2VjcmV0")
+}
@@ -0,0 +1,188 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type retryPolicySuite struct{}
+
+var _ = chk.Suite(&retryPolicySuite{})
+
+// faultInjectingTransport fails the first failuresToInject requests it is given with a transient (500) error,
+// and answers all later ones successfully, without going to the network. If retryAfter is set, the failures are
+// 503s (Server Busy) instead, with that as their Retry-After header
+type faultInjectingTransport struct {
+	failuresToInject int32
+	retryAfter       func() string
+	atomicAttempts   int32
+}
+
+func (t *faultInjectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := http.StatusOK
+	header := http.Header{}
+	if atomic.AddInt32(&t.atomicAttempts, 1) <= t.failuresToInject {
+		status = http.StatusInternalServerError
+		if t.retryAfter != nil {
+			status = http.StatusServiceUnavailable
+			header.Set("Retry-After", t.retryAfter())
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func newFaultInjectingBlobURL(c *chk.C, o XferRetryOptions, transport *faultInjectingTransport) azblob.BlobURL {
+	p := pipeline.NewPipeline(
+		[]pipeline.Factory{NewBlobXferRetryPolicyFactory(o), pipeline.MethodFactoryMarker()},
+		pipeline.Options{HTTPSender: newAzcopyHTTPClientFactory(&http.Client{Transport: transport})})
+	u, err := url.Parse("https://fakeaccount.blob.core.windows.net/container/blob")
+	c.Assert(err, chk.IsNil)
+	return azblob.NewBlobURL(*u, p)
+}
+
+func (s *retryPolicySuite) TestDefaultDelaysAreExponential(c *chk.C) {
+	o := XferRetryOptions{RetryDelay: time.Second, MaxRetryDelay: time.Minute, NoJitter: true}.defaults()
+
+	// ((2 ^ (try-1)) - 1) * RetryDelay, capped at MaxRetryDelay
+	expected := []time.Duration{0, 1 * time.Second, 3 * time.Second, 7 * time.Second, 15 * time.Second, 31 * time.Second, time.Minute, time.Minute}
+	for i, delay := range expected {
+		c.Assert(o.calcDelay(int32(i+1)), chk.Equals, delay)
+	}
+}
+
+func (s *retryPolicySuite) TestMultiplierControlsDelayGrowth(c *chk.C) {
+	linear := XferRetryOptions{RetryDelay: time.Second, MaxRetryDelay: time.Minute, Multiplier: 1, NoJitter: true}.defaults()
+	c.Assert(linear.calcDelay(2), chk.Equals, 1*time.Second)
+	c.Assert(linear.calcDelay(5), chk.Equals, 4*time.Second)
+
+	triple := XferRetryOptions{RetryDelay: time.Second, MaxRetryDelay: time.Minute, Multiplier: 3, NoJitter: true}.defaults()
+	c.Assert(triple.calcDelay(2), chk.Equals, 1*time.Second)
+	c.Assert(triple.calcDelay(4), chk.Equals, 13*time.Second)
+	c.Assert(triple.calcDelay(100), chk.Equals, time.Minute)
+}
+
+func (s *retryPolicySuite) TestJitterStaysWithinBounds(c *chk.C) {
+	o := XferRetryOptions{RetryDelay: 10 * time.Second, MaxRetryDelay: time.Minute}.defaults()
+	for i := 0; i < 100; i++ {
+		delay := o.calcDelay(2)
+		c.Assert(delay >= 8*time.Second, chk.Equals, true)
+		c.Assert(delay < 13*time.Second, chk.Equals, true)
+	}
+}
+
+func (s *retryPolicySuite) TestTransientErrorsAreRetriedUntilSuccess(c *chk.C) {
+	transport := &faultInjectingTransport{failuresToInject: 3}
+	o := XferRetryOptions{MaxTries: 5, RetryDelay: time.Millisecond, MaxRetryDelay: 10 * time.Millisecond}
+	var retries int32
+	ctx := withRetryCounter(context.Background(), &retries)
+
+	_, err := newFaultInjectingBlobURL(c, o, transport).GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+
+	c.Assert(err, chk.IsNil)
+	c.Assert(atomic.LoadInt32(&transport.atomicAttempts), chk.Equals, int32(4))
+	c.Assert(atomic.LoadInt32(&retries), chk.Equals, int32(3))
+}
+
+func (s *retryPolicySuite) TestRetriesStopAtMaxTries(c *chk.C) {
+	transport := &faultInjectingTransport{failuresToInject: 10}
+	o := XferRetryOptions{MaxTries: 3, RetryDelay: time.Millisecond, MaxRetryDelay: 10 * time.Millisecond}
+	var retries int32
+	ctx := withRetryCounter(context.Background(), &retries)
+
+	_, err := newFaultInjectingBlobURL(c, o, transport).GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+
+	c.Assert(err, chk.NotNil)
+	stgErr, ok := err.(azblob.StorageError)
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(stgErr.Response().StatusCode, chk.Equals, http.StatusInternalServerError)
+	c.Assert(atomic.LoadInt32(&transport.atomicAttempts), chk.Equals, int32(3))
+	c.Assert(atomic.LoadInt32(&retries), chk.Equals, int32(2))
+}
+
+func (s *retryPolicySuite) TestRetryAfterIsParsedAndCapped(c *chk.C) {
+	o := XferRetryOptions{RetryDelay: time.Second, MaxRetryDelay: time.Minute}.defaults()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	delayFor := func(o XferRetryOptions, status int, retryAfter string) time.Duration {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return o.retryAfterDelay(pipeline.NewHTTPResponse(resp), now)
+	}
+
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, "7"), chk.Equals, 7*time.Second)
+	c.Assert(delayFor(o, http.StatusTooManyRequests, "7"), chk.Equals, 7*time.Second)
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, now.Add(20*time.Second).Format(http.TimeFormat)), chk.Equals, 20*time.Second)
+
+	// capped by the longest delay the policy would use
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, "3600"), chk.Equals, time.Minute)
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, "99999999999999999"), chk.Equals, time.Minute)
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, now.Add(time.Hour).Format(http.TimeFormat)), chk.Equals, time.Minute)
+
+	// nothing to honor, so the policy's own delay is used
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, ""), chk.Equals, time.Duration(0))
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, "soon"), chk.Equals, time.Duration(0))
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, "-5"), chk.Equals, time.Duration(0))
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, now.Add(-time.Minute).Format(http.TimeFormat)), chk.Equals, time.Duration(0))
+	c.Assert(delayFor(o, http.StatusInternalServerError, "7"), chk.Equals, time.Duration(0))
+	o.IgnoreRetryAfter = true
+	c.Assert(delayFor(o, http.StatusServiceUnavailable, "7"), chk.Equals, time.Duration(0))
+}
+
+func (s *retryPolicySuite) TestThrottledRequestsWaitAsLongAsRetryAfterAsks(c *chk.C) {
+	// the policy's own delay is much shorter than what the service asks for, so a wait of at least what was asked
+	// shows that the header was used. How much longer the wait is depends on the machine, so it isn't checked here;
+	// the delays themselves are checked exactly, against a fixed time, by TestRetryAfterIsParsedAndCapped
+	o := XferRetryOptions{MaxTries: 3, RetryDelay: time.Millisecond, MaxRetryDelay: 10 * time.Second}
+	getProperties := func(o XferRetryOptions, retryAfter func() string) (time.Duration, int32) {
+		transport := &faultInjectingTransport{failuresToInject: 1, retryAfter: retryAfter}
+		start := time.Now()
+		_, err := newFaultInjectingBlobURL(c, o, transport).GetProperties(context.Background(), azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+		c.Assert(err, chk.IsNil)
+		return time.Since(start), atomic.LoadInt32(&transport.atomicAttempts)
+	}
+
+	waited, attempts := getProperties(o, func() string { return "1" })
+	c.Assert(attempts, chk.Equals, int32(2))
+	c.Assert(waited >= time.Second, chk.Equals, true, chk.Commentf("waited %v for Retry-After: 1", waited))
+
+	// an HTTP date only has whole seconds, so the wait is somewhere in the second before it
+	waited, attempts = getProperties(o, func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) })
+	c.Assert(attempts, chk.Equals, int32(2))
+	c.Assert(waited >= time.Second, chk.Equals, true, chk.Commentf("waited %v for a Retry-After date 2s ahead", waited))
+}
@@ -0,0 +1,145 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type metadataOnlySuite struct{}
+
+var _ = chk.Suite(&metadataOnlySuite{})
+
+// metadataOnlyTestJptm is just enough of a transfer to set the properties of its destination, and remembers how it ended
+type metadataOnlyTestJptm struct {
+	IJobPartTransferMgr
+	status common.TransferStatus
+	err    error
+	done   bool
+}
+
+func (j *metadataOnlyTestJptm) Info() TransferInfo {
+	return TransferInfo{
+		Source:      "https://source.blob.core.windows.net/container/file.txt",
+		Destination: "https://destination.blob.core.windows.net/container/file.txt",
+	}
+}
+func (j *metadataOnlyTestJptm) Context() context.Context               { return context.Background() }
+func (j *metadataOnlyTestJptm) CpkInfo() common.CpkInfo                { return common.CpkInfo{} }
+func (j *metadataOnlyTestJptm) CpkScopeInfo() common.CpkScopeInfo      { return common.CpkScopeInfo{} }
+func (j *metadataOnlyTestJptm) SetStatus(status common.TransferStatus) { j.status = status }
+func (j *metadataOnlyTestJptm) ResetSourceSize()                       {}
+func (j *metadataOnlyTestJptm) LogError(_, _ string, err error)        { j.err = err }
+func (j *metadataOnlyTestJptm) Log(_ pipeline.LogLevel, _ string)      {}
+func (j *metadataOnlyTestJptm) ReportTransferDone() uint32             { j.done = true; return 0 }
+
+// metadataOnlyTestSip is a source with fixed properties
+type metadataOnlyTestSip struct {
+	ISourceInfoProvider
+}
+
+func (s *metadataOnlyTestSip) Properties() (*SrcProperties, error) {
+	return &SrcProperties{
+		SrcHTTPHeaders: common.ResourceHTTPHeaders{ContentType: "text/plain", ContentMD5: []byte("the source's md5")},
+		SrcMetadata:    common.Metadata{"owner": "me"},
+	}, nil
+}
+
+// metadataOnlyTestService is a destination that has, or hasn't, the blob, recording the requests it is sent
+type metadataOnlyTestService struct {
+	blobExists bool
+	requests   []*http.Request
+}
+
+func (s *metadataOnlyTestService) New(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.Policy {
+	return pipeline.PolicyFunc(func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		s.requests = append(s.requests, request.Request)
+
+		header := http.Header{}
+		status := http.StatusOK
+		if request.Method == http.MethodHead {
+			if s.blobExists {
+				header.Set("Content-MD5", base64.StdEncoding.EncodeToString([]byte("the destination's md5")))
+			} else {
+				status = http.StatusNotFound
+				header.Set("x-ms-error-code", string(azblob.ServiceCodeBlobNotFound))
+			}
+		}
+
+		return pipeline.NewHTTPResponse(&http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    request.Request,
+		}), nil
+	})
+}
+
+func (s *metadataOnlySuite) setMetadataOnly(blobExists bool) (*metadataOnlyTestJptm, *metadataOnlyTestService) {
+	jptm := &metadataOnlyTestJptm{}
+	service := &metadataOnlyTestService{blobExists: blobExists}
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: service})
+	setMetadataOnlyBlob(jptm, p, func(IJobPartTransferMgr) (ISourceInfoProvider, error) { return &metadataOnlyTestSip{}, nil })
+	return jptm, service
+}
+
+// describe gives the method and operation of each request, e.g. "HEAD " or "PUT metadata"
+func (s *metadataOnlySuite) describe(requests []*http.Request) []string {
+	var result []string
+	for _, r := range requests {
+		result = append(result, strings.Join([]string{r.Method, r.URL.Query().Get("comp")}, " "))
+	}
+	return result
+}
+
+func (s *metadataOnlySuite) TestPropertiesAreSetWithoutCopyingData(c *chk.C) {
+	jptm, service := s.setMetadataOnly(true)
+
+	c.Assert(jptm.status, chk.Equals, common.ETransferStatus.Success())
+	c.Assert(jptm.done, chk.Equals, true)
+	c.Assert(s.describe(service.requests), chk.DeepEquals, []string{"HEAD ", "PUT properties", "PUT metadata"})
+
+	headers := service.requests[1].Header
+	c.Assert(headers.Get("x-ms-blob-content-type"), chk.Equals, "text/plain")
+	// the destination's data is unchanged, so its MD5 still applies, whatever the source's is
+	c.Assert(headers.Get("x-ms-blob-content-md5"), chk.Equals, base64.StdEncoding.EncodeToString([]byte("the destination's md5")))
+	c.Assert(service.requests[2].Header.Get("x-ms-meta-owner"), chk.Equals, "me")
+}
+
+func (s *metadataOnlySuite) TestMissingDestinationFails(c *chk.C) {
+	jptm, service := s.setMetadataOnly(false)
+
+	c.Assert(jptm.status, chk.Equals, common.ETransferStatus.Failed())
+	c.Assert(jptm.err, chk.Equals, errMetadataOnlyDestinationMissing)
+	c.Assert(jptm.done, chk.Equals, true)
+	c.Assert(s.describe(service.requests), chk.DeepEquals, []string{"HEAD "})
+}